@@ -111,6 +111,26 @@ func generateWshClientApiFile(tsTypeMap map[reflect.Type]string) error {
 	return err
 }
 
+func generateTextMimeTypesFile() error {
+	fileName := "frontend/app/store/textmimetypes.ts"
+	var buf bytes.Buffer
+	fmt.Fprintf(os.Stderr, "generating text mimetypes file to %s\n", fileName)
+	fmt.Fprintf(&buf, "// Copyright 2025, Command Line Inc.\n")
+	fmt.Fprintf(&buf, "// SPDX-License-Identifier: Apache-2.0\n\n")
+	fmt.Fprintf(&buf, "// generated by cmd/generate/main-generatets.go\n\n")
+	fmt.Fprintf(&buf, "// wshrpc.TextApplicationMimeTypes\n")
+	fmt.Fprintf(&buf, "export const TextApplicationMimeTypes: string[] = [\n")
+	for _, mimeType := range wshrpc.TextApplicationMimeTypes {
+		fmt.Fprintf(&buf, "    %q,\n", mimeType)
+	}
+	fmt.Fprintf(&buf, "];\n")
+	written, err := utilfn.WriteFileIfDifferent(fileName, buf.Bytes())
+	if !written {
+		fmt.Fprintf(os.Stderr, "no changes to %s\n", fileName)
+	}
+	return err
+}
+
 func main() {
 	err := service.ValidateServiceMap()
 	if err != nil {
@@ -133,4 +153,9 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error generating wshserver file: %v\n", err)
 		os.Exit(1)
 	}
+	err = generateTextMimeTypesFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating text mimetypes file: %v\n", err)
+		os.Exit(1)
+	}
 }
@@ -176,9 +176,19 @@ func createMainWshClient() {
 	rpc := wshserver.GetMainRpcClient()
 	wshutil.DefaultRouter.RegisterRoute(wshutil.DefaultRoute, rpc, true)
 	wps.Broker.SetClient(wshutil.DefaultRouter)
-	localConnWsh := wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{Conn: wshrpc.LocalConnName}, &wshremote.ServerImpl{})
+	localConnRpcCtx := wshrpc.RpcContext{Conn: wshrpc.LocalConnName}
+	fileInfoCacheTtl := time.Duration(wconfig.GetConnFileInfoCacheTtlMs(wshrpc.LocalConnName)) * time.Millisecond
+	localConnImpl := &wshremote.ServerImpl{InProcess: true, FileInfoCacheTTL: fileInfoCacheTtl}
+	// in-process, so route:gone is delivered to the main rpc client instead of the local conn route
+	rpc.EventListener.On(wps.Event_RouteGone, localConnImpl.HandleRouteGone)
+	wps.Broker.Subscribe(wshutil.DefaultRoute, wps.SubscriptionRequest{Event: wps.Event_RouteGone, AllScopes: true})
+	localConnWsh := wshutil.MakeWshRpc(nil, nil, localConnRpcCtx, localConnImpl)
+	wshserver.InitSysInfoHistory()
 	go wshremote.RunSysInfoLoop(localConnWsh, wshrpc.LocalConnName)
-	wshutil.DefaultRouter.RegisterRoute(wshutil.MakeConnectionRouteId(wshrpc.LocalConnName), localConnWsh, true)
+	localConnRouteId := wshutil.MakeConnectionRouteId(wshrpc.LocalConnName)
+	wshutil.DefaultRouter.RegisterRoute(localConnRouteId, localConnWsh, true)
+	// in-process passthrough (skips serialization for calls routed to the local conn)
+	wshutil.DefaultRouter.RegisterLocalImpl(localConnRouteId, localConnWsh)
 }
 
 func grabAndRemoveEnvVars() error {
@@ -297,6 +307,14 @@ func main() {
 	go stdinReadWatch()
 	go telemetryLoop()
 	configWatcher()
+	err = wshutil.SetRpcLogLevel(wconfig.GetWatcher().GetFullConfig().Settings.DebugRpcLog)
+	if err != nil {
+		log.Printf("error setting rpc log level: %v\n", err)
+	}
+	wshutil.SetRequireFrameSigning(wconfig.GetWatcher().GetFullConfig().Settings.ConnRequireRpcSigning)
+	subIdleTimeoutSecs := wconfig.GetWatcher().GetFullConfig().Settings.DebugSubIdleTimeoutSecs
+	wshutil.SetSubIdleTimeout(time.Duration(subIdleTimeoutSecs * float64(time.Second)))
+	go wshutil.DefaultRouter.RunSubReaper(context.Background())
 	webListener, err := web.MakeTCPListener("web")
 	if err != nil {
 		log.Printf("error creating web listener: %v\n", err)
@@ -18,6 +18,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/util/packetparser"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshremote"
@@ -33,9 +34,11 @@ var serverCmd = &cobra.Command{
 }
 
 var connServerRouter bool
+var connServerFileInfoCacheTtlMs int64
 
 func init() {
 	serverCmd.Flags().BoolVar(&connServerRouter, "router", false, "run in local router mode")
+	serverCmd.Flags().Int64Var(&connServerFileInfoCacheTtlMs, "fileinfocachettl", 0, "fileinfo cache ttl in ms (0 for the default, negative disables the cache)")
 	rootCmd.AddCommand(serverCmd)
 }
 
@@ -130,16 +133,24 @@ func setupConnServerRpcClientWithRouter(router *wshutil.WshRouter) (*wshutil.Wsh
 	if err != nil {
 		return nil, fmt.Errorf("error extracting rpc context from %s: %v", wshutil.WaveJwtTokenVarName, err)
 	}
-	authRtn, err := router.HandleProxyAuth(jwtToken)
+	authRtn, err := router.HandleProxyAuth(jwtToken, "")
 	if err != nil {
 		return nil, fmt.Errorf("error handling proxy auth: %v", err)
 	}
 	inputCh := make(chan []byte, wshutil.DefaultInputChSize)
 	outputCh := make(chan []byte, wshutil.DefaultOutputChSize)
-	connServerClient := wshutil.MakeWshRpc(inputCh, outputCh, *rpcCtx, &wshremote.ServerImpl{LogWriter: os.Stdout})
+	connServerImpl := &wshremote.ServerImpl{LogWriter: os.Stdout, Router: router, FileInfoCacheTTL: time.Duration(connServerFileInfoCacheTtlMs) * time.Millisecond}
+	connServerClient := wshutil.MakeWshRpc(inputCh, outputCh, *rpcCtx, connServerImpl)
 	connServerClient.SetAuthToken(authRtn.AuthToken)
 	router.RegisterRoute(authRtn.RouteId, connServerClient, false)
 	wshclient.RouteAnnounceCommand(connServerClient, nil)
+	err = connServerImpl.WatchRouteGone(connServerClient)
+	if err != nil {
+		log.Printf("error subscribing to route:gone events: %v\n", err)
+	}
+	// routes local to this router (remote wsh) publish route:gone on the local broker
+	wps.Broker.SetClient(router)
+	wps.Broker.Subscribe(authRtn.RouteId, wps.SubscriptionRequest{Event: wps.Event_RouteGone, AllScopes: true})
 	return connServerClient, nil
 }
 
@@ -187,10 +198,15 @@ func serverRunRouter() error {
 }
 
 func serverRunNormal() error {
-	err := setupRpcClient(&wshremote.ServerImpl{LogWriter: os.Stdout})
+	connServerImpl := &wshremote.ServerImpl{LogWriter: os.Stdout, DirectUpstream: true, FileInfoCacheTTL: time.Duration(connServerFileInfoCacheTtlMs) * time.Millisecond}
+	err := setupRpcClient(connServerImpl)
 	if err != nil {
 		return err
 	}
+	err = connServerImpl.WatchRouteGone(RpcClient)
+	if err != nil {
+		log.Printf("error subscribing to route:gone events: %v\n", err)
+	}
 	WriteStdout("running wsh connserver (%s)\n", RpcContext.Conn)
 	go wshremote.RunSysInfoLoop(RpcClient, RpcContext.Conn)
 	select {} // run forever
@@ -24,8 +24,16 @@ var debugBlockIdsCmd = &cobra.Command{
 	Hidden: true,
 }
 
+var debugRoutesCmd = &cobra.Command{
+	Use:    "routes",
+	Short:  "dump the wavesrv route table",
+	RunE:   debugRoutesRun,
+	Hidden: true,
+}
+
 func init() {
 	debugCmd.AddCommand(debugBlockIdsCmd)
+	debugCmd.AddCommand(debugRoutesCmd)
 	rootCmd.AddCommand(debugCmd)
 }
 
@@ -45,3 +53,16 @@ func debugBlockIdsRun(cmd *cobra.Command, args []string) error {
 	WriteStdout("%s\n", string(barr))
 	return nil
 }
+
+func debugRoutesRun(cmd *cobra.Command, args []string) error {
+	routes, err := wshclient.DebugDumpRoutesCommand(RpcClient, nil)
+	if err != nil {
+		return err
+	}
+	barr, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		return err
+	}
+	WriteStdout("%s\n", string(barr))
+	return nil
+}
@@ -92,6 +92,7 @@ func streamWriteToWaveFile(fileData wshrpc.CommandFileData, reader io.Reader) er
 
 func streamReadFromWaveFile(fileData wshrpc.CommandFileData, size int64, writer io.Writer) error {
 	const chunkSize = 32 * 1024 // 32KB chunks
+	fileData.Encoding = wshrpc.FileEncoding_Gzip
 	for offset := int64(0); offset < size; offset += chunkSize {
 		// Calculate the length of this chunk
 		length := chunkSize
@@ -106,13 +107,13 @@ func streamReadFromWaveFile(fileData wshrpc.CommandFileData, size int64, writer
 		}
 
 		// Read the chunk
-		content64, err := wshclient.FileReadCommand(RpcClient, fileData, &wshrpc.RpcOpts{Timeout: fileTimeout})
+		readRtn, err := wshclient.FileReadDetectCommand(RpcClient, fileData, &wshrpc.RpcOpts{Timeout: fileTimeout})
 		if err != nil {
 			return fmt.Errorf("reading chunk at offset %d: %w", offset, err)
 		}
 
 		// Decode and write the chunk
-		chunk, err := base64.StdEncoding.DecodeString(content64)
+		chunk, err := wshrpc.DecodeFileReadData(readRtn.Data64, readRtn.DataEncoding)
 		if err != nil {
 			return fmt.Errorf("decoding chunk at offset %d: %w", offset, err)
 		}
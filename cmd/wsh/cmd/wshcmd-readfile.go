@@ -4,8 +4,6 @@
 package cmd
 
 import (
-	"encoding/base64"
-
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
@@ -29,12 +27,12 @@ func runReadFile(cmd *cobra.Command, args []string) {
 		WriteStderr("[error] %v\n", err)
 		return
 	}
-	resp64, err := wshclient.FileReadCommand(RpcClient, wshrpc.CommandFileData{ZoneId: fullORef.OID, FileName: args[0]}, &wshrpc.RpcOpts{Timeout: 5000})
+	readRtn, err := wshclient.FileReadDetectCommand(RpcClient, wshrpc.CommandFileData{ZoneId: fullORef.OID, FileName: args[0], Encoding: wshrpc.FileEncoding_Gzip}, &wshrpc.RpcOpts{Timeout: 5000})
 	if err != nil {
 		WriteStderr("[error] reading file: %v\n", err)
 		return
 	}
-	resp, err := base64.StdEncoding.DecodeString(resp64)
+	resp, err := wshrpc.DecodeFileReadData(readRtn.Data64, readRtn.DataEncoding)
 	if err != nil {
 		WriteStderr("[error] decoding file: %v\n", err)
 		return
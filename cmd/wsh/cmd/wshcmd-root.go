@@ -145,7 +145,14 @@ func setupRpcClient(serverImpl wshutil.ServerImpl) error {
 	if err != nil {
 		return fmt.Errorf("error extracting socket name from %s: %v", wshutil.WaveJwtTokenVarName, err)
 	}
-	RpcClient, err = wshutil.SetupDomainSocketRpcClient(sockName, serverImpl)
+	var signer *wshutil.RpcFrameSigner
+	if signKey := os.Getenv(wshutil.WaveRpcSignKeyVarName); signKey != "" {
+		signer, err = wshutil.MakeClientRpcFrameSigner(signKey)
+		if err != nil {
+			return err
+		}
+	}
+	RpcClient, err = wshutil.SetupDomainSocketRpcClient(sockName, serverImpl, signer)
 	if err != nil {
 		return fmt.Errorf("error setting up domain socket rpc client: %v", err)
 	}
@@ -534,15 +534,15 @@ const BlockFrame_Default_Component = (props: BlockFrameProps) => {
         };
     }, [manageConnection]);
     React.useEffect(() => {
-        // on mount, if manageConnection, call ConnEnsure
+        // on mount, if manageConnection, call ConnWarmup (ensures the connection and resolves its env up front)
         if (!manageConnection || blockData == null || preview) {
             return;
         }
         const connName = blockData?.meta?.connection;
         if (!util.isBlank(connName)) {
-            console.log("ensure conn", nodeModel.blockId, connName);
-            RpcApi.ConnEnsureCommand(TabRpcClient, connName, { timeout: 60000 }).catch((e) => {
-                console.log("error ensuring connection", nodeModel.blockId, connName, e);
+            console.log("warmup conn", nodeModel.blockId, connName);
+            RpcApi.ConnWarmupCommand(TabRpcClient, connName, { timeout: 60000 }).catch((e) => {
+                console.log("error warming up connection", nodeModel.blockId, connName, e);
             });
         }
     }, [manageConnection, blockData]);
@@ -806,7 +806,7 @@ const ChangeConnectionBlockModal = React.memo(
                         ? `var(--conn-icon-color-${connColorNum})`
                         : "var(--grey-text-color)",
                 value: connName,
-                label: connName,
+                label: connStatus?.label ? `${connStatus.label} (${connName})` : connName,
                 current: connName == connection,
             };
             return item;
@@ -0,0 +1,26 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// generated by cmd/generate/main-generatets.go
+
+// wshrpc.TextApplicationMimeTypes
+export const TextApplicationMimeTypes: string[] = [
+    "application/sql",
+    "application/pem-certificate-chain",
+    "application/x-php",
+    "application/x-httpd-php",
+    "application/liquid",
+    "application/graphql",
+    "application/javascript",
+    "application/typescript",
+    "application/x-javascript",
+    "application/x-typescript",
+    "application/dart",
+    "application/vnd.dart",
+    "application/x-ruby",
+    "application/wasm",
+    "application/x-latex",
+    "application/x-sh",
+    "application/x-python",
+    "application/x-awk",
+];
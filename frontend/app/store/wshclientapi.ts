@@ -27,6 +27,16 @@ class RpcApiType {
         return client.wshRpcCall("blockinfo", data, opts);
     }
 
+    // command "broadcastinput" [call]
+    BroadcastInputCommand(client: WshClient, data: CommandBroadcastInputData, opts?: RpcOpts): Promise<CommandBroadcastInputRtnData> {
+        return client.wshRpcCall("broadcastinput", data, opts);
+    }
+
+    // command "capabilities" [call]
+    CapabilitiesCommand(client: WshClient, opts?: RpcOpts): Promise<CapabilitiesRtnData> {
+        return client.wshRpcCall("capabilities", null, opts);
+    }
+
     // command "connconnect" [call]
     ConnConnectCommand(client: WshClient, data: ConnRequest, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("connconnect", data, opts);
@@ -47,26 +57,56 @@ class RpcApiType {
         return client.wshRpcCall("connlist", null, opts);
     }
 
+    // command "connlistpage" [call]
+    ConnListPageCommand(client: WshClient, data: PageOpts, opts?: RpcOpts): Promise<StringPage> {
+        return client.wshRpcCall("connlistpage", data, opts);
+    }
+
     // command "connreinstallwsh" [call]
     ConnReinstallWshCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("connreinstallwsh", data, opts);
     }
 
+    // command "connrename" [call]
+    ConnRenameCommand(client: WshClient, data: CommandConnRenameData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("connrename", data, opts);
+    }
+
     // command "connstatus" [call]
     ConnStatusCommand(client: WshClient, opts?: RpcOpts): Promise<ConnStatus[]> {
         return client.wshRpcCall("connstatus", null, opts);
     }
 
+    // command "connvalidate" [call]
+    ConnValidateCommand(client: WshClient, data: CommandConnValidateData, opts?: RpcOpts): Promise<ConnValidateRtnData> {
+        return client.wshRpcCall("connvalidate", data, opts);
+    }
+
+    // command "connwarmup" [call]
+    ConnWarmupCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("connwarmup", data, opts);
+    }
+
     // command "controllerinput" [call]
     ControllerInputCommand(client: WshClient, data: CommandBlockInputData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("controllerinput", data, opts);
     }
 
+    // command "controllerresize" [call]
+    ControllerResizeCommand(client: WshClient, data: CommandControllerResizeData, opts?: RpcOpts): Promise<TermSize> {
+        return client.wshRpcCall("controllerresize", data, opts);
+    }
+
     // command "controllerresync" [call]
     ControllerResyncCommand(client: WshClient, data: CommandControllerResyncData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("controllerresync", data, opts);
     }
 
+    // command "controllerstatus" [call]
+    ControllerStatusCommand(client: WshClient, data: CommandControllerStatusData, opts?: RpcOpts): Promise<ControllerStatus> {
+        return client.wshRpcCall("controllerstatus", data, opts);
+    }
+
     // command "controllerstop" [call]
     ControllerStopCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("controllerstop", data, opts);
@@ -82,6 +122,11 @@ class RpcApiType {
         return client.wshRpcCall("createsubblock", data, opts);
     }
 
+    // command "debugdumproutes" [call]
+    DebugDumpRoutesCommand(client: WshClient, opts?: RpcOpts): Promise<RouteInfo[]> {
+        return client.wshRpcCall("debugdumproutes", null, opts);
+    }
+
     // command "deleteblock" [call]
     DeleteBlockCommand(client: WshClient, data: CommandDeleteBlockData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("deleteblock", data, opts);
@@ -102,6 +147,16 @@ class RpcApiType {
         return client.wshRpcCall("dispose", data, opts);
     }
 
+    // command "eventlistallsubs" [call]
+    EventListAllSubsCommand(client: WshClient, opts?: RpcOpts): Promise<SubscriptionInfo[]> {
+        return client.wshRpcCall("eventlistallsubs", null, opts);
+    }
+
+    // command "eventlistsubs" [call]
+    EventListSubsCommand(client: WshClient, opts?: RpcOpts): Promise<SubscriptionInfo[]> {
+        return client.wshRpcCall("eventlistsubs", null, opts);
+    }
+
     // command "eventpublish" [call]
     EventPublishCommand(client: WshClient, data: WaveEvent, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("eventpublish", data, opts);
@@ -112,6 +167,11 @@ class RpcApiType {
         return client.wshRpcCall("eventreadhistory", data, opts);
     }
 
+    // command "eventreadhistorylimited" [call]
+    EventReadHistoryLimitedCommand(client: WshClient, data: CommandEventReadHistoryData, opts?: RpcOpts): Promise<EventReadHistoryRtnData> {
+        return client.wshRpcCall("eventreadhistorylimited", data, opts);
+    }
+
     // command "eventrecv" [call]
     EventRecvCommand(client: WshClient, data: WaveEvent, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("eventrecv", data, opts);
@@ -122,6 +182,11 @@ class RpcApiType {
         return client.wshRpcCall("eventsub", data, opts);
     }
 
+    // command "eventsubbatch" [call]
+    EventSubBatchCommand(client: WshClient, data: SubscriptionRequest[], opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("eventsubbatch", data, opts);
+    }
+
     // command "eventunsub" [call]
     EventUnsubCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("eventunsub", data, opts);
@@ -132,13 +197,18 @@ class RpcApiType {
         return client.wshRpcCall("eventunsuball", null, opts);
     }
 
+    // command "eventunsubbatch" [call]
+    EventUnsubBatchCommand(client: WshClient, data: string[], opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("eventunsubbatch", data, opts);
+    }
+
     // command "fileappend" [call]
     FileAppendCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("fileappend", data, opts);
     }
 
     // command "fileappendijson" [call]
-    FileAppendIJsonCommand(client: WshClient, data: CommandAppendIJsonData, opts?: RpcOpts): Promise<void> {
+    FileAppendIJsonCommand(client: WshClient, data: CommandAppendIJsonData, opts?: RpcOpts): Promise<CommandAppendIJsonRtnData> {
         return client.wshRpcCall("fileappendijson", data, opts);
     }
 
@@ -152,6 +222,11 @@ class RpcApiType {
         return client.wshRpcCall("filedelete", data, opts);
     }
 
+    // command "fileflush" [call]
+    FileFlushCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("fileflush", data, opts);
+    }
+
     // command "fileinfo" [call]
     FileInfoCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<WaveFileInfo> {
         return client.wshRpcCall("fileinfo", data, opts);
@@ -167,6 +242,16 @@ class RpcApiType {
         return client.wshRpcCall("fileread", data, opts);
     }
 
+    // command "filereaddetect" [call]
+    FileReadDetectCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<FileReadRtnData> {
+        return client.wshRpcCall("filereaddetect", data, opts);
+    }
+
+    // command "filetail" [responsestream]
+	FileTailCommand(client: WshClient, data: CommandFileTailData, opts?: RpcOpts): AsyncGenerator<FileReadChunk, void, boolean> {
+        return client.wshRpcStream("filetail", data, opts);
+    }
+
     // command "filewrite" [call]
     FileWriteCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("filewrite", data, opts);
@@ -177,11 +262,36 @@ class RpcApiType {
         return client.wshRpcCall("focuswindow", data, opts);
     }
 
+    // command "getconnbandwidth" [call]
+    GetConnBandwidthCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<ConnBandwidth> {
+        return client.wshRpcCall("getconnbandwidth", data, opts);
+    }
+
+    // command "getcpuhistory" [call]
+    GetCpuHistoryCommand(client: WshClient, data: CpuHistoryRequest, opts?: RpcOpts): Promise<TimeSeriesData[]> {
+        return client.wshRpcCall("getcpuhistory", data, opts);
+    }
+
+    // command "getfocus" [call]
+    GetFocusCommand(client: WshClient, data: CommandGetFocusData, opts?: RpcOpts): Promise<ORef> {
+        return client.wshRpcCall("getfocus", data, opts);
+    }
+
+    // command "getlayout" [call]
+    GetLayoutCommand(client: WshClient, data: CommandGetLayoutData, opts?: RpcOpts): Promise<TabLayout> {
+        return client.wshRpcCall("getlayout", data, opts);
+    }
+
     // command "getmeta" [call]
     GetMetaCommand(client: WshClient, data: CommandGetMetaData, opts?: RpcOpts): Promise<MetaType> {
         return client.wshRpcCall("getmeta", data, opts);
     }
 
+    // command "getrecenterrors" [call]
+    GetRecentErrorsCommand(client: WshClient, data: CommandRecentErrorsData, opts?: RpcOpts): Promise<RpcErrorRecord[]> {
+        return client.wshRpcCall("getrecenterrors", data, opts);
+    }
+
     // command "getupdatechannel" [call]
     GetUpdateChannelCommand(client: WshClient, opts?: RpcOpts): Promise<string> {
         return client.wshRpcCall("getupdatechannel", null, opts);
@@ -192,6 +302,21 @@ class RpcApiType {
         return client.wshRpcCall("getvar", data, opts);
     }
 
+    // command "health" [call]
+    HealthCommand(client: WshClient, opts?: RpcOpts): Promise<HealthRtnData> {
+        return client.wshRpcCall("health", null, opts);
+    }
+
+    // command "listblockviews" [call]
+    ListBlockViewsCommand(client: WshClient, data: CommandListViewsData, opts?: RpcOpts): Promise<string[]> {
+        return client.wshRpcCall("listblockviews", data, opts);
+    }
+
+    // command "listcontrollers" [call]
+    ListControllersCommand(client: WshClient, data: CommandListControllersData, opts?: RpcOpts): Promise<ControllerStatus[]> {
+        return client.wshRpcCall("listcontrollers", data, opts);
+    }
+
     // command "message" [call]
     MessageCommand(client: WshClient, data: CommandMessageData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("message", data, opts);
@@ -202,16 +327,71 @@ class RpcApiType {
         return client.wshRpcCall("notify", data, opts);
     }
 
+    // command "openresource" [call]
+    OpenResourceCommand(client: WshClient, data: CommandOpenResourceData, opts?: RpcOpts): Promise<ORef> {
+        return client.wshRpcCall("openresource", data, opts);
+    }
+
     // command "path" [call]
     PathCommand(client: WshClient, data: PathCommandData, opts?: RpcOpts): Promise<string> {
         return client.wshRpcCall("path", data, opts);
     }
 
+    // command "remotechmod" [call]
+    RemoteChmodCommand(client: WshClient, data: CommandRemoteChmodData, opts?: RpcOpts): Promise<FileOpPreview> {
+        return client.wshRpcCall("remotechmod", data, opts);
+    }
+
+    // command "remoteclipboardget" [call]
+    RemoteClipboardGetCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("remoteclipboardget", data, opts);
+    }
+
+    // command "remoteclipboardset" [call]
+    RemoteClipboardSetCommand(client: WshClient, data: CommandRemoteClipboardData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remoteclipboardset", data, opts);
+    }
+
+    // command "remoteexpandpath" [call]
+    RemoteExpandPathCommand(client: WshClient, data: CommandRemoteExpandData, opts?: RpcOpts): Promise<string[]> {
+        return client.wshRpcCall("remoteexpandpath", data, opts);
+    }
+
+    // command "remoteextractarchive" [call]
+    RemoteExtractArchiveCommand(client: WshClient, data: CommandRemoteExtractData, opts?: RpcOpts): Promise<CommandRemoteExtractRtnData> {
+        return client.wshRpcCall("remoteextractarchive", data, opts);
+    }
+
+    // command "remotefilechecksum" [call]
+    RemoteFileChecksumCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("remotefilechecksum", data, opts);
+    }
+
+    // command "remotefileclose" [call]
+    RemoteFileCloseCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remotefileclose", data, opts);
+    }
+
+    // command "remotefilecopy" [call]
+    RemoteFileCopyCommand(client: WshClient, data: CommandRemoteFileCopyData, opts?: RpcOpts): Promise<FileOpPreview> {
+        return client.wshRpcCall("remotefilecopy", data, opts);
+    }
+
     // command "remotefiledelete" [call]
-    RemoteFileDeleteCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+    RemoteFileDeleteCommand(client: WshClient, data: CommandRemoteFileDeleteData, opts?: RpcOpts): Promise<FileOpPreview> {
         return client.wshRpcCall("remotefiledelete", data, opts);
     }
 
+    // command "remotefilediff" [call]
+    RemoteFileDiffCommand(client: WshClient, data: CommandRemoteDiffData, opts?: RpcOpts): Promise<CommandRemoteDiffRtnData> {
+        return client.wshRpcCall("remotefilediff", data, opts);
+    }
+
+    // command "remotefileheadtail" [call]
+    RemoteFileHeadTailCommand(client: WshClient, data: CommandRemoteHeadTailData, opts?: RpcOpts): Promise<string[]> {
+        return client.wshRpcCall("remotefileheadtail", data, opts);
+    }
+
     // command "remotefileinfo" [call]
     RemoteFileInfoCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<FileInfo> {
         return client.wshRpcCall("remotefileinfo", data, opts);
@@ -222,21 +402,91 @@ class RpcApiType {
         return client.wshRpcCall("remotefilejoin", data, opts);
     }
 
+    // command "remotefilelines" [call]
+    RemoteFileLinesCommand(client: WshClient, data: CommandRemoteFileLinesData, opts?: RpcOpts): Promise<FileLinesRtnData> {
+        return client.wshRpcCall("remotefilelines", data, opts);
+    }
+
+    // command "remotefileopen" [call]
+    RemoteFileOpenCommand(client: WshClient, data: CommandRemoteFileOpenData, opts?: RpcOpts): Promise<RemoteFileOpenRtnData> {
+        return client.wshRpcCall("remotefileopen", data, opts);
+    }
+
+    // command "remotefilereadat" [call]
+    RemoteFileReadAtCommand(client: WshClient, data: CommandRemoteFileReadAtData, opts?: RpcOpts): Promise<RemoteFileReadAtRtnData> {
+        return client.wshRpcCall("remotefilereadat", data, opts);
+    }
+
     // command "remotefilerename" [call]
-    RemoteFileRenameCommand(client: WshClient, data: string[], opts?: RpcOpts): Promise<void> {
+    RemoteFileRenameCommand(client: WshClient, data: CommandRemoteFileRenameData, opts?: RpcOpts): Promise<FileOpPreview> {
         return client.wshRpcCall("remotefilerename", data, opts);
     }
 
+    // command "remotefilestat" [call]
+    RemoteFileStatCommand(client: WshClient, data: CommandRemoteFileStatData, opts?: RpcOpts): Promise<FileInfo[]> {
+        return client.wshRpcCall("remotefilestat", data, opts);
+    }
+
     // command "remotefiletouch" [call]
     RemoteFileTouchCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("remotefiletouch", data, opts);
     }
 
+    // command "remotefilewriteat" [call]
+    RemoteFileWriteAtCommand(client: WshClient, data: CommandRemoteFileWriteAtData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remotefilewriteat", data, opts);
+    }
+
+    // command "remotefindduplicates" [responsestream]
+	RemoteFindDuplicatesCommand(client: WshClient, data: CommandRemoteDedupData, opts?: RpcOpts): AsyncGenerator<DuplicateGroup, void, boolean> {
+        return client.wshRpcStream("remotefindduplicates", data, opts);
+    }
+
+    // command "remotekillprocess" [call]
+    RemoteKillProcessCommand(client: WshClient, data: CommandRemoteKillData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remotekillprocess", data, opts);
+    }
+
+    // command "remotelistdir" [call]
+    RemoteListDirCommand(client: WshClient, data: CommandRemoteListDirData, opts?: RpcOpts): Promise<FileInfoPage> {
+        return client.wshRpcCall("remotelistdir", data, opts);
+    }
+
     // command "remotemkdir" [call]
-    RemoteMkdirCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+    RemoteMkdirCommand(client: WshClient, data: CommandRemoteMkdirData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("remotemkdir", data, opts);
     }
 
+    // command "remotemountinfo" [call]
+    RemoteMountInfoCommand(client: WshClient, data: CommandRemoteMountData, opts?: RpcOpts): Promise<MountInfo> {
+        return client.wshRpcCall("remotemountinfo", data, opts);
+    }
+
+    // command "remotemultitail" [responsestream]
+	RemoteMultiTailCommand(client: WshClient, data: CommandRemoteMultiTailData, opts?: RpcOpts): AsyncGenerator<MultiTailEvent, void, boolean> {
+        return client.wshRpcStream("remotemultitail", data, opts);
+    }
+
+    // command "remoterunscript" [responsestream]
+	RemoteRunScriptCommand(client: WshClient, data: CommandRemoteScriptData, opts?: RpcOpts): AsyncGenerator<ExecOutputChunk, void, boolean> {
+        return client.wshRpcStream("remoterunscript", data, opts);
+    }
+
+    // command "remotesetreadlimit" [call]
+    RemoteSetReadLimitCommand(client: WshClient, data: number, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remotesetreadlimit", data, opts);
+    }
+
+    // command "remotestatfs" [call]
+    RemoteStatFSCommand(client: WshClient, data: CommandRemoteStatFSData, opts?: RpcOpts): Promise<StatFSRtnData> {
+        return client.wshRpcCall("remotestatfs", data, opts);
+    }
+
+    // command "remotestreamarchive" [responsestream]
+	RemoteStreamArchiveCommand(client: WshClient, data: CommandRemoteArchiveData, opts?: RpcOpts): AsyncGenerator<ArchiveChunk, void, boolean> {
+        return client.wshRpcStream("remotestreamarchive", data, opts);
+    }
+
     // command "remotestreamcpudata" [responsestream]
 	RemoteStreamCpuDataCommand(client: WshClient, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
         return client.wshRpcStream("remotestreamcpudata", null, opts);
@@ -247,16 +497,46 @@ class RpcApiType {
         return client.wshRpcStream("remotestreamfile", data, opts);
     }
 
+    // command "remotestreamlistdir" [responsestream]
+	RemoteStreamListDirCommand(client: WshClient, data: CommandRemoteListDirData, opts?: RpcOpts): AsyncGenerator<ListDirChunk, void, boolean> {
+        return client.wshRpcStream("remotestreamlistdir", data, opts);
+    }
+
+    // command "remotetransfer" [responsestream]
+	RemoteTransferCommand(client: WshClient, data: CommandRemoteTransferData, opts?: RpcOpts): AsyncGenerator<RemoteTransferProgress, void, boolean> {
+        return client.wshRpcStream("remotetransfer", data, opts);
+    }
+
+    // command "remotewhich" [call]
+    RemoteWhichCommand(client: WshClient, data: CommandRemoteWhichData, opts?: RpcOpts): Promise<string[]> {
+        return client.wshRpcCall("remotewhich", data, opts);
+    }
+
     // command "remotewritefile" [call]
     RemoteWriteFileCommand(client: WshClient, data: CommandRemoteWriteFileData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("remotewritefile", data, opts);
     }
 
+    // command "remotewritefilechunk" [call]
+    RemoteWriteFileChunkCommand(client: WshClient, data: CommandRemoteWriteChunkData, opts?: RpcOpts): Promise<RemoteWriteChunkAck> {
+        return client.wshRpcCall("remotewritefilechunk", data, opts);
+    }
+
     // command "resolveids" [call]
     ResolveIdsCommand(client: WshClient, data: CommandResolveIdsData, opts?: RpcOpts): Promise<CommandResolveIdsRtnData> {
         return client.wshRpcCall("resolveids", data, opts);
     }
 
+    // command "restoreblock" [call]
+    RestoreBlockCommand(client: WshClient, data: CommandRestoreBlockData, opts?: RpcOpts): Promise<ORef> {
+        return client.wshRpcCall("restoreblock", data, opts);
+    }
+
+    // command "resumestream" [responsestream]
+	ResumeStreamCommand(client: WshClient, data: CommandResumeStreamData, opts?: RpcOpts): AsyncGenerator<WaveAIPacketType, void, boolean> {
+        return client.wshRpcStream("resumestream", data, opts);
+    }
+
     // command "routeannounce" [call]
     RouteAnnounceCommand(client: WshClient, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("routeannounce", null, opts);
@@ -277,11 +557,31 @@ class RpcApiType {
         return client.wshRpcCall("setconnectionsconfig", data, opts);
     }
 
+    // command "setfocus" [call]
+    SetFocusCommand(client: WshClient, data: CommandSetFocusData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("setfocus", data, opts);
+    }
+
+    // command "setlayout" [call]
+    SetLayoutCommand(client: WshClient, data: TabLayout, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("setlayout", data, opts);
+    }
+
     // command "setmeta" [call]
     SetMetaCommand(client: WshClient, data: CommandSetMetaData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("setmeta", data, opts);
     }
 
+    // command "setmetabatch" [call]
+    SetMetaBatchCommand(client: WshClient, data: CommandSetMetaBatchData, opts?: RpcOpts): Promise<SetMetaBatchResult[]> {
+        return client.wshRpcCall("setmetabatch", data, opts);
+    }
+
+    // command "setmetapatch" [call]
+    SetMetaPatchCommand(client: WshClient, data: CommandSetMetaPatchData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("setmetapatch", data, opts);
+    }
+
     // command "setvar" [call]
     SetVarCommand(client: WshClient, data: CommandVarData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("setvar", data, opts);
@@ -292,11 +592,41 @@ class RpcApiType {
         return client.wshRpcCall("setview", data, opts);
     }
 
+    // command "setviewbatch" [call]
+    SetViewBatchCommand(client: WshClient, data: CommandBlockSetViewData[], opts?: RpcOpts): Promise<SetViewBatchResult[]> {
+        return client.wshRpcCall("setviewbatch", data, opts);
+    }
+
+    // command "shutdown" [call]
+    ShutdownCommand(client: WshClient, data: CommandShutdownData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("shutdown", data, opts);
+    }
+
+    // command "snapshotblock" [call]
+    SnapshotBlockCommand(client: WshClient, data: CommandSnapshotBlockData, opts?: RpcOpts): Promise<BlockSnapshot> {
+        return client.wshRpcCall("snapshotblock", data, opts);
+    }
+
+    // command "streamcontrolleroutput" [responsestream]
+	StreamControllerOutputCommand(client: WshClient, data: CommandStreamOutputData, opts?: RpcOpts): AsyncGenerator<ControllerOutputChunk, void, boolean> {
+        return client.wshRpcStream("streamcontrolleroutput", data, opts);
+    }
+
     // command "streamcpudata" [responsestream]
 	StreamCpuDataCommand(client: WshClient, data: CpuDataRequest, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
         return client.wshRpcStream("streamcpudata", data, opts);
     }
 
+    // command "streamgpudata" [responsestream]
+	StreamGpuDataCommand(client: WshClient, data: GpuDataRequest, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
+        return client.wshRpcStream("streamgpudata", data, opts);
+    }
+
+    // command "streamsensordata" [responsestream]
+	StreamSensorDataCommand(client: WshClient, data: SensorDataRequest, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
+        return client.wshRpcStream("streamsensordata", data, opts);
+    }
+
     // command "streamtest" [responsestream]
 	StreamTestCommand(client: WshClient, opts?: RpcOpts): AsyncGenerator<number, void, boolean> {
         return client.wshRpcStream("streamtest", null, opts);
@@ -347,6 +677,11 @@ class RpcApiType {
         return client.wshRpcCall("webselector", data, opts);
     }
 
+    // command "whoami" [call]
+    WhoAmICommand(client: WshClient, opts?: RpcOpts): Promise<CommandWhoAmIRtnData> {
+        return client.wshRpcCall("whoami", null, opts);
+    }
+
     // command "workspacelist" [call]
     WorkspaceListCommand(client: WshClient, opts?: RpcOpts): Promise<WorkspaceInfoData[]> {
         return client.wshRpcCall("workspacelist", null, opts);
@@ -46,6 +46,10 @@ async function* rpcResponseGenerator(
                 if (msg.error != null) {
                     throw new Error(msg.error);
                 }
+                if (msg.streamstart) {
+                    // stream start acks are not surfaced to generator consumers
+                    continue;
+                }
                 if (!msg.cont && msg.data == null) {
                     return;
                 }
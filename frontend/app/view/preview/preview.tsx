@@ -6,6 +6,7 @@ import { CenteredDiv } from "@/app/element/quickelems";
 import { TypeAheadModal } from "@/app/modals/typeaheadmodal";
 import { ContextMenuModel } from "@/app/store/contextmenu";
 import { tryReinjectKey } from "@/app/store/keymodel";
+import { TextApplicationMimeTypes } from "@/app/store/textmimetypes";
 import { RpcApi } from "@/app/store/wshclientapi";
 import { TabRpcClient } from "@/app/store/wshrpcutil";
 import { CodeEditor } from "@/app/view/codeeditor/codeeditor";
@@ -51,35 +52,13 @@ const SpecializedViewMap: { [view: string]: ({ model }: SpecializedViewProps) =>
     directory: DirectoryPreview,
 };
 
-const textApplicationMimetypes = [
-    "application/sql",
-    "application/pem-certificate-chain",
-    "application/x-php",
-    "application/x-httpd-php",
-    "application/liquid",
-    "application/graphql",
-    "application/javascript",
-    "application/typescript",
-    "application/x-javascript",
-    "application/x-typescript",
-    "application/dart",
-    "application/vnd.dart",
-    "application/x-ruby",
-    "application/sql",
-    "application/wasm",
-    "application/x-latex",
-    "application/x-sh",
-    "application/x-python",
-    "application/x-awk",
-];
-
 function isTextFile(mimeType: string): boolean {
     if (mimeType == null) {
         return false;
     }
     return (
         mimeType.startsWith("text/") ||
-        textApplicationMimetypes.includes(mimeType) ||
+        TextApplicationMimeTypes.includes(mimeType) ||
         (mimeType.startsWith("application/") &&
             (mimeType.includes("json") || mimeType.includes("yaml") || mimeType.includes("toml"))) ||
         mimeType.includes("xml")
@@ -175,6 +175,7 @@ export class WaveAiModel implements ViewModel {
                 apiversion: settings["ai:apiversion"] ?? null,
                 maxtokens: settings["ai:maxtokens"] ?? null,
                 timeoutms: settings["ai:timeoutms"] ?? 60000,
+                keepalivems: settings["ai:keepalivems"] ?? null,
                 baseurl: settings["ai:baseurl"] ?? null,
             };
             return opts;
@@ -356,8 +357,11 @@ export class WaveAiModel implements ViewModel {
             try {
                 const aiGen = RpcApi.StreamWaveAiCommand(TabRpcClient, beMsg, { timeout: opts.timeoutms });
                 for await (const msg of aiGen) {
-                    fullMsg += msg.text ?? "";
-                    globalStore.set(this.updateLastMessageAtom, msg.text ?? "", true);
+                    // keep-alive pings (sent while the model is slow to respond) carry no text, raw packets are debug only
+                    if (msg.type != "ping" && msg.type != "raw") {
+                        fullMsg += msg.text ?? "";
+                        globalStore.set(this.updateLastMessageAtom, msg.text ?? "", true);
+                    }
                     if (this.cancel) {
                         break;
                     }
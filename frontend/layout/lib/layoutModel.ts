@@ -472,6 +472,29 @@ export class LayoutModel {
                             );
                             break;
                         }
+                        case LayoutTreeActionType.FocusNode: {
+                            const leaf = this?.getNodeByBlockId(action.blockid);
+                            if (leaf) {
+                                this.treeReducer(
+                                    {
+                                        type: LayoutTreeActionType.FocusNode,
+                                        nodeId: leaf.id,
+                                    } as LayoutTreeFocusNodeAction,
+                                    false
+                                );
+                            } else {
+                                console.error(
+                                    "Cannot apply eventbus layout action FocusNode, could not find leaf node with blockId",
+                                    action.blockid
+                                );
+                            }
+                            break;
+                        }
+                        case LayoutTreeActionType.ReplaceTree: {
+                            // the backend already wrote the new tree into the layout state (see SetLayoutCommand)
+                            this.updateTree();
+                            break;
+                        }
                         default:
                             console.warn("unsupported layout action", action);
                             break;
@@ -70,6 +70,7 @@ export enum LayoutTreeActionType {
     FocusNode = "focus",
     MagnifyNodeToggle = "magnify",
     ClearTree = "clear",
+    ReplaceTree = "replace",
 }
 
 /**
@@ -45,6 +45,11 @@ declare global {
         message?: string;
     };
 
+    // wshrpc.ArchiveChunk
+    type ArchiveChunk = {
+        data64: string;
+    };
+
     // waveobj.Block
     type Block = WaveObj & {
         parentoref?: string;
@@ -84,6 +89,35 @@ declare global {
         inputdata64: string;
     };
 
+    // wshrpc.BlockSnapshot
+    type BlockSnapshot = {
+        version: number;
+        blockid: string;
+        meta: MetaType;
+        runtimeopts?: RuntimeOpts;
+        files?: BlockSnapshotFile[];
+    };
+
+    // wshrpc.BlockSnapshotFile
+    type BlockSnapshotFile = {
+        name: string;
+        size: number;
+        meta?: {[key: string]: any};
+        opts?: FileOptsType;
+        data64?: string;
+        byref?: boolean;
+    };
+
+    // wshrpc.CapabilitiesRtnData
+    type CapabilitiesRtnData = {
+        version: string;
+        protocolversion: number;
+        commands: string[];
+        features?: string[];
+        codec: string;
+        maxmessagesize: number;
+    };
+
     // waveobj.Client
     type Client = WaveObj & {
         windowids: string[];
@@ -103,12 +137,19 @@ declare global {
         zoneid: string;
         filename: string;
         data: {[key: string]: any};
+        expectedversion?: number;
+    };
+
+    // wshrpc.CommandAppendIJsonRtnData
+    type CommandAppendIJsonRtnData = {
+        version: number;
     };
 
     // wshrpc.CommandAuthenticateRtnData
     type CommandAuthenticateRtnData = {
         routeid: string;
         authtoken?: string;
+        signkey?: string;
     };
 
     // wshrpc.CommandBlockInputData
@@ -125,6 +166,42 @@ declare global {
         view: string;
     };
 
+    // wshrpc.CommandBroadcastInputData
+    type CommandBroadcastInputData = {
+        blockids?: string[];
+        tabid?: string;
+        inputdata64?: string;
+        signame?: string;
+        termsize?: TermSize;
+    };
+
+    // wshrpc.CommandBroadcastInputRtnData
+    type CommandBroadcastInputRtnData = {
+        sentblockids?: string[];
+        errors?: {[key: string]: string};
+    };
+
+    // wshrpc.CommandConnRenameData
+    type CommandConnRenameData = {
+        connection: string;
+        newname: string;
+        alias?: boolean;
+    };
+
+    // wshrpc.CommandConnValidateData
+    type CommandConnValidateData = {
+        host: string;
+        keywords?: ConnKeywords;
+        password?: string;
+        timeoutms?: number;
+    };
+
+    // wshrpc.CommandControllerResizeData
+    type CommandControllerResizeData = {
+        blockid: string;
+        termsize: TermSize;
+    };
+
     // wshrpc.CommandControllerResyncData
     type CommandControllerResyncData = {
         forcerestart?: boolean;
@@ -133,6 +210,11 @@ declare global {
         rtopts?: RuntimeOpts;
     };
 
+    // wshrpc.CommandControllerStatusData
+    type CommandControllerStatusData = {
+        blockid: string;
+    };
+
     // wshrpc.CommandCreateBlockData
     type CommandCreateBlockData = {
         tabid: string;
@@ -140,6 +222,8 @@ declare global {
         rtopts?: RuntimeOpts;
         magnified?: boolean;
         ephemeral?: boolean;
+        initfiles?: CommandFileData[];
+        vars?: {[key: string]: string};
     };
 
     // wshrpc.CommandCreateSubBlockData
@@ -179,6 +263,14 @@ declare global {
         filename: string;
         data64?: string;
         at?: CommandFileDataAt;
+        maxsize?: number;
+        sizemode?: string;
+        encoding?: string;
+        compressminsize?: number;
+        detectsamplesize?: number;
+        binarymaxsize?: number;
+        ifmodtime?: number;
+        ifsize?: number;
     };
 
     // wshrpc.CommandFileDataAt
@@ -196,9 +288,42 @@ declare global {
         limit?: number;
     };
 
+    // wshrpc.CommandFileTailData
+    type CommandFileTailData = {
+        zoneid: string;
+        filename: string;
+        backfillbytes?: number;
+        backfilllines?: number;
+    };
+
+    // wshrpc.CommandGetFocusData
+    type CommandGetFocusData = {
+        tabid: string;
+    };
+
+    // wshrpc.CommandGetLayoutData
+    type CommandGetLayoutData = {
+        tabid: string;
+    };
+
     // wshrpc.CommandGetMetaData
     type CommandGetMetaData = {
         oref: ORef;
+        keys?: string[];
+        maxdepth?: number;
+        prefix?: string;
+        stripprefix?: boolean;
+    };
+
+    // wshrpc.CommandListControllersData
+    type CommandListControllersData = {
+        tabid?: string;
+        runningonly?: boolean;
+    };
+
+    // wshrpc.CommandListViewsData
+    type CommandListViewsData = {
+        blockid: string;
     };
 
     // wshrpc.CommandMessageData
@@ -207,10 +332,214 @@ declare global {
         message: string;
     };
 
+    // wshrpc.CommandOpenResourceData
+    type CommandOpenResourceData = {
+        tabid: string;
+        url?: string;
+        conn?: string;
+        path?: string;
+        magnified?: boolean;
+    };
+
+    // wshrpc.CommandRecentErrorsData
+    type CommandRecentErrorsData = {
+        routeid?: string;
+        limit?: number;
+    };
+
+    // wshrpc.CommandRemoteArchiveData
+    type CommandRemoteArchiveData = {
+        path: string;
+        include?: string[];
+        exclude?: string[];
+        compression?: string;
+        maxsize?: number;
+    };
+
+    // wshrpc.CommandRemoteChmodData
+    type CommandRemoteChmodData = {
+        path: string;
+        mode: string;
+        recursive?: boolean;
+        dryrun?: boolean;
+    };
+
+    // wshrpc.CommandRemoteClipboardData
+    type CommandRemoteClipboardData = {
+        conn?: string;
+        text: string;
+    };
+
+    // wshrpc.CommandRemoteDedupData
+    type CommandRemoteDedupData = {
+        path: string;
+        mode?: string;
+        minsize?: number;
+        exclude?: string[];
+    };
+
+    // wshrpc.CommandRemoteDiffData
+    type CommandRemoteDiffData = {
+        path1: string;
+        path2?: string;
+        data64?: string;
+        context?: number;
+    };
+
+    // wshrpc.CommandRemoteDiffRtnData
+    type CommandRemoteDiffRtnData = {
+        identical?: boolean;
+        diff?: string;
+        hunks?: DiffHunk[];
+    };
+
+    // wshrpc.CommandRemoteExpandData
+    type CommandRemoteExpandData = {
+        path: string;
+        env?: {[key: string]: string};
+        nullglob?: boolean;
+    };
+
+    // wshrpc.CommandRemoteExtractData
+    type CommandRemoteExtractData = {
+        path: string;
+        archivepath: string;
+        removearchive?: boolean;
+        compression?: string;
+        maxsize?: number;
+        preservemode?: boolean;
+    };
+
+    // wshrpc.CommandRemoteExtractRtnData
+    type CommandRemoteExtractRtnData = {
+        entries: ExtractEntryResult[];
+    };
+
+    // wshrpc.CommandRemoteFileCopyData
+    type CommandRemoteFileCopyData = {
+        srcpath: string;
+        destpath: string;
+        overwrite?: boolean;
+        dryrun?: boolean;
+    };
+
+    // wshrpc.CommandRemoteFileDeleteData
+    type CommandRemoteFileDeleteData = {
+        path: string;
+        recursive?: boolean;
+        dryrun?: boolean;
+    };
+
+    // wshrpc.CommandRemoteFileLinesData
+    type CommandRemoteFileLinesData = {
+        path: string;
+        startline: number;
+        endline?: number;
+    };
+
+    // wshrpc.CommandRemoteFileOpenData
+    type CommandRemoteFileOpenData = {
+        path: string;
+        write?: boolean;
+        create?: boolean;
+        createmode?: number;
+    };
+
+    // wshrpc.CommandRemoteFileReadAtData
+    type CommandRemoteFileReadAtData = {
+        handle: string;
+        offset: number;
+        size: number;
+    };
+
+    // wshrpc.CommandRemoteFileRenameData
+    type CommandRemoteFileRenameData = {
+        srcpath: string;
+        destpath: string;
+        overwrite?: boolean;
+        dryrun?: boolean;
+    };
+
+    // wshrpc.CommandRemoteFileStatData
+    type CommandRemoteFileStatData = {
+        paths: string[];
+        nocache?: boolean;
+        listentries?: boolean;
+    };
+
+    // wshrpc.CommandRemoteFileWriteAtData
+    type CommandRemoteFileWriteAtData = {
+        handle: string;
+        offset: number;
+        data64: string;
+    };
+
+    // wshrpc.CommandRemoteHeadTailData
+    type CommandRemoteHeadTailData = {
+        path: string;
+        lines?: number;
+        fromend?: boolean;
+    };
+
+    // wshrpc.CommandRemoteKillData
+    type CommandRemoteKillData = {
+        pid: number;
+        signal?: string;
+        force?: boolean;
+        gracems?: number;
+    };
+
+    // wshrpc.CommandRemoteListDirData
+    type CommandRemoteListDirData = {
+        path: string;
+        cursor?: string;
+        limit?: number;
+        exclude?: string[];
+        respectgitignore?: boolean;
+        shallow?: boolean;
+    };
+
+    // wshrpc.CommandRemoteMkdirData
+    type CommandRemoteMkdirData = {
+        path: string;
+        recursive?: boolean;
+        mustcreate?: boolean;
+        createmode?: number;
+    };
+
+    // wshrpc.CommandRemoteMountData
+    type CommandRemoteMountData = {
+        path: string;
+    };
+
+    // wshrpc.CommandRemoteMultiTailData
+    type CommandRemoteMultiTailData = {
+        paths: string[];
+        backfilllines?: number;
+        combined?: boolean;
+    };
+
+    // wshrpc.CommandRemoteScriptData
+    type CommandRemoteScriptData = {
+        interpreter: string;
+        script: string;
+        args?: string[];
+        stdin64?: string;
+        env?: {[key: string]: string};
+        cwd?: string;
+        timeoutms?: number;
+    };
+
+    // wshrpc.CommandRemoteStatFSData
+    type CommandRemoteStatFSData = {
+        path: string;
+    };
+
     // wshrpc.CommandRemoteStreamFileData
     type CommandRemoteStreamFileData = {
         path: string;
         byterange?: string;
+        decompress?: string;
     };
 
     // wshrpc.CommandRemoteStreamFileRtnData
@@ -219,11 +548,37 @@ declare global {
         data64?: string;
     };
 
+    // wshrpc.CommandRemoteTransferData
+    type CommandRemoteTransferData = {
+        srcconn?: string;
+        srcpath: string;
+        destconn?: string;
+        destpath: string;
+    };
+
+    // wshrpc.CommandRemoteWhichData
+    type CommandRemoteWhichData = {
+        program: string;
+        all?: boolean;
+    };
+
+    // wshrpc.CommandRemoteWriteChunkData
+    type CommandRemoteWriteChunkData = {
+        uploadid: string;
+        path: string;
+        offset: number;
+        data64?: string;
+        done?: boolean;
+        checksum?: string;
+        createmode?: number;
+    };
+
     // wshrpc.CommandRemoteWriteFileData
     type CommandRemoteWriteFileData = {
         path: string;
         data64: string;
         createmode?: number;
+        noatomic?: boolean;
     };
 
     // wshrpc.CommandResolveIdsData
@@ -237,10 +592,61 @@ declare global {
         resolvedids: {[key: string]: ORef};
     };
 
+    // wshrpc.CommandRestoreBlockData
+    type CommandRestoreBlockData = {
+        tabid: string;
+        snapshot: BlockSnapshot;
+        magnified?: boolean;
+    };
+
+    // wshrpc.CommandResumeStreamData
+    type CommandResumeStreamData = {
+        requestid: string;
+        afterseq?: number;
+    };
+
+    // wshrpc.CommandSetFocusData
+    type CommandSetFocusData = {
+        tabid: string;
+        blockid: string;
+    };
+
+    // wshrpc.CommandSetMetaBatchData
+    type CommandSetMetaBatchData = {
+        items: CommandSetMetaData[];
+        allornothing?: boolean;
+    };
+
     // wshrpc.CommandSetMetaData
     type CommandSetMetaData = {
         oref: ORef;
         meta: MetaType;
+        version?: number;
+    };
+
+    // wshrpc.CommandSetMetaPatchData
+    type CommandSetMetaPatchData = {
+        oref: ORef;
+        command: {[key: string]: any};
+        version?: number;
+    };
+
+    // wshrpc.CommandShutdownData
+    type CommandShutdownData = {
+        restart?: boolean;
+        gracems?: number;
+    };
+
+    // wshrpc.CommandSnapshotBlockData
+    type CommandSnapshotBlockData = {
+        blockid: string;
+        maxinlinesize?: number;
+    };
+
+    // wshrpc.CommandStreamOutputData
+    type CommandStreamOutputData = {
+        blockid: string;
+        backfillbytes?: number;
     };
 
     // wshrpc.CommandVarData
@@ -274,12 +680,28 @@ declare global {
         opts?: WebSelectorOpts;
     };
 
+    // wshrpc.CommandWhoAmIRtnData
+    type CommandWhoAmIRtnData = {
+        routeid: string;
+        rpccontext: RpcContext;
+    };
+
     // wconfig.ConfigError
     type ConfigError = {
         file: string;
         err: string;
     };
 
+    // wshrpc.ConnBandwidth
+    type ConnBandwidth = {
+        connection: string;
+        limit: number;
+        readrate: number;
+        writerate: number;
+        bytesread: number;
+        byteswritten: number;
+    };
+
     // wshrpc.ConnConfigRequest
     type ConnConfigRequest = {
         host: string;
@@ -291,6 +713,8 @@ declare global {
         "conn:wshenabled"?: boolean;
         "conn:askbeforewshinstall"?: boolean;
         "conn:overrideconfig"?: boolean;
+        "conn:bandwidthlimit"?: number;
+        "conn:fileinfocachettlms"?: number;
         "display:hidden"?: boolean;
         "display:order"?: number;
         "term:*"?: boolean;
@@ -329,12 +753,64 @@ declare global {
         activeconnnum: number;
         error?: string;
         wsherror?: string;
+        ready?: boolean;
+        label?: string;
+    };
+
+    // wshrpc.ConnValidateRtnData
+    type ConnValidateRtnData = {
+        success: boolean;
+        category?: string;
+        error?: string;
+        user?: string;
+        hostname?: string;
+        port?: string;
+        hostkeyunknown?: boolean;
+        hostkeyfingerprint?: string;
+        durationms: number;
+    };
+
+    // wshrpc.ControllerOutputChunk
+    type ControllerOutputChunk = {
+        data64: string;
+        backfill?: boolean;
+    };
+
+    // wshrpc.ControllerStatus
+    type ControllerStatus = {
+        blockid: string;
+        tabid?: string;
+        status: string;
+        running: boolean;
+        pid?: number;
+        cmdline?: string;
+        connname?: string;
+        startts?: number;
+        exitcode: number;
     };
 
     // wshrpc.CpuDataRequest
     type CpuDataRequest = {
         id: string;
         count: number;
+        conn?: string;
+        downsample?: DownsampleSpec;
+    };
+
+    // wshrpc.CpuHistoryRequest
+    type CpuHistoryRequest = {
+        conn?: string;
+        sincets?: number;
+        maxitems?: number;
+    };
+
+    // wshrpc.DiffHunk
+    type DiffHunk = {
+        oldstart: number;
+        oldlines: number;
+        newstart: number;
+        newlines: number;
+        lines: string[];
     };
 
     // vdom.DomRect
@@ -347,6 +823,41 @@ declare global {
         height: number;
     };
 
+    // wshrpc.DownsampleSpec
+    type DownsampleSpec = {
+        bucketms: number;
+        agg?: string;
+    };
+
+    // wshrpc.DuplicateGroup
+    type DuplicateGroup = {
+        mode: string;
+        key: string;
+        size: number;
+        paths: string[];
+    };
+
+    // wshrpc.EventReadHistoryRtnData
+    type EventReadHistoryRtnData = {
+        events: WaveEvent[];
+        truncated?: boolean;
+    };
+
+    // wshrpc.ExecOutputChunk
+    type ExecOutputChunk = {
+        stream?: string;
+        data64?: string;
+        done?: boolean;
+        exitcode: number;
+    };
+
+    // wshrpc.ExtractEntryResult
+    type ExtractEntryResult = {
+        name: string;
+        path: string;
+        size: number;
+    };
+
     // waveobj.FileDef
     type FileDef = {
         content?: string;
@@ -366,6 +877,45 @@ declare global {
         isdir?: boolean;
         mimetype?: string;
         readonly?: boolean;
+        entrytype?: string;
+        statted?: boolean;
+        allocsize?: number;
+    };
+
+    // wshrpc.FileInfoPage
+    type FileInfoPage = {
+        items: FileInfo[];
+        nextcursor?: string;
+        total: number;
+    };
+
+    // wshrpc.FileLine
+    type FileLine = {
+        line: number;
+        offset: number;
+        text: string;
+    };
+
+    // wshrpc.FileLinesRtnData
+    type FileLinesRtnData = {
+        lines: FileLine[];
+        endoffset: number;
+        eof?: boolean;
+    };
+
+    // wshrpc.FileOpChange
+    type FileOpChange = {
+        path: string;
+        action: string;
+        destpath?: string;
+        mode?: string;
+        isdir?: boolean;
+    };
+
+    // wshrpc.FileOpPreview
+    type FileOpPreview = {
+        changes?: FileOpChange[];
+        truncated?: boolean;
     };
 
     // filestore.FileOptsType
@@ -376,6 +926,23 @@ declare global {
         ijsonbudget?: number;
     };
 
+    // wshrpc.FileReadChunk
+    type FileReadChunk = {
+        data64?: string;
+        offset: number;
+        backfill?: boolean;
+        reset?: boolean;
+    };
+
+    // wshrpc.FileReadRtnData
+    type FileReadRtnData = {
+        data64: string;
+        dataencoding?: string;
+        binary?: boolean;
+        encoding?: string;
+        truncated?: boolean;
+    };
+
     // wconfig.FullConfigType
     type FullConfigType = {
         settings: SettingsType;
@@ -394,6 +961,21 @@ declare global {
         data64: string;
     };
 
+    // wshrpc.GpuDataRequest
+    type GpuDataRequest = {
+        intervalms?: number;
+        downsample?: DownsampleSpec;
+    };
+
+    // wshrpc.HealthRtnData
+    type HealthRtnData = {
+        status: string;
+        uptimems: number;
+        version: string;
+        numroutes: number;
+        activestreams: number;
+    };
+
     // waveobj.LayoutActionData
     type LayoutActionData = {
         actiontype: string;
@@ -420,6 +1002,11 @@ declare global {
         blockid: string;
     };
 
+    // wshrpc.ListDirChunk
+    type ListDirChunk = {
+        items: FileInfo[];
+    };
+
     // waveobj.MetaTSType
     type MetaType = {
         view?: string;
@@ -519,9 +1106,35 @@ declare global {
         color: string;
     };
 
+    // wshrpc.MountInfo
+    type MountInfo = {
+        path: string;
+        mountpoint: string;
+        fstype: string;
+        device?: string;
+        network?: boolean;
+        readonly?: boolean;
+    };
+
+    // wshrpc.MultiTailEvent
+    type MultiTailEvent = {
+        path?: string;
+        lines?: string[];
+        sources?: number[];
+        backfill?: boolean;
+        status?: string;
+        error?: string;
+    };
+
     // waveobj.ORef
     type ORef = string;
 
+    // wshrpc.PageOpts
+    type PageOpts = {
+        cursor?: string;
+        limit?: number;
+    };
+
     // wshrpc.PathCommandData
     type PathCommandData = {
         pathtype: string;
@@ -536,6 +1149,62 @@ declare global {
         y: number;
     };
 
+    // wshrpc.RemoteFileOpenRtnData
+    type RemoteFileOpenRtnData = {
+        handle: string;
+        info: FileInfo;
+    };
+
+    // wshrpc.RemoteFileReadAtRtnData
+    type RemoteFileReadAtRtnData = {
+        data64: string;
+        eof?: boolean;
+    };
+
+    // wshrpc.RemoteTransferProgress
+    type RemoteTransferProgress = {
+        phase: string;
+        bytestransferred: number;
+        totalbytes: number;
+        checksum?: string;
+    };
+
+    // wshrpc.RemoteWriteChunkAck
+    type RemoteWriteChunkAck = {
+        committed: number;
+        done?: boolean;
+    };
+
+    // wshrpc.RouteInfo
+    type RouteInfo = {
+        routeid: string;
+        ctype?: string;
+        conn?: string;
+        blockid?: string;
+        announced?: boolean;
+        viarouteid?: string;
+        numsubs: number;
+        lastactivityts?: number;
+    };
+
+    // wshrpc.RpcContext
+    type RpcContext = {
+        ctype?: string;
+        blockid?: string;
+        tabid?: string;
+        conn?: string;
+        meta?: {[key: string]: string};
+    };
+
+    // wshrpc.RpcErrorRecord
+    type RpcErrorRecord = {
+        ts: number;
+        command: string;
+        route: string;
+        message: string;
+        code: string;
+    };
+
     // wshutil.RpcMessage
     type RpcMessage = {
         command?: string;
@@ -548,8 +1217,14 @@ declare global {
         cont?: boolean;
         cancel?: boolean;
         error?: string;
+        partial?: boolean;
+        streamstart?: boolean;
         datatype?: string;
         data?: any;
+        signnonce?: string;
+        seq?: number;
+        sig?: string;
+        meta?: {[key: string]: string};
     };
 
     // wshrpc.RpcOpts
@@ -557,6 +1232,8 @@ declare global {
         timeout?: number;
         noresponse?: boolean;
         route?: string;
+        meta?: {[key: string]: string};
+        nostreamstart?: boolean;
     };
 
     // waveobj.RuntimeOpts
@@ -565,6 +1242,13 @@ declare global {
         winsize?: WinSize;
     };
 
+    // wshrpc.SensorDataRequest
+    type SensorDataRequest = {
+        intervalms?: number;
+        sensors?: string[];
+        downsample?: DownsampleSpec;
+    };
+
     // webcmd.SetBlockTermSizeWSCommand
     type SetBlockTermSizeWSCommand = {
         wscommand: "setblocktermsize";
@@ -572,6 +1256,18 @@ declare global {
         termsize: TermSize;
     };
 
+    // wshrpc.SetMetaBatchResult
+    type SetMetaBatchResult = {
+        oref: ORef;
+        error?: string;
+    };
+
+    // wshrpc.SetViewBatchResult
+    type SetViewBatchResult = {
+        blockid: string;
+        error?: string;
+    };
+
     // wconfig.SettingsType
     type SettingsType = {
         "app:*"?: boolean;
@@ -588,6 +1284,7 @@ declare global {
         "ai:apiversion"?: string;
         "ai:maxtokens"?: number;
         "ai:timeoutms"?: number;
+        "ai:keepalivems"?: number;
         "ai:fontsize"?: number;
         "ai:fixedfontsize"?: number;
         "term:*"?: boolean;
@@ -641,11 +1338,31 @@ declare global {
         "window:savelastwindow"?: boolean;
         "window:dimensions"?: string;
         "window:zoom"?: number;
+        "sysinfo:*"?: boolean;
+        "sysinfo:historysecs"?: number;
+        "sysinfo:historyresolutionms"?: number;
+        "debug:*"?: boolean;
+        "debug:rpclog"?: string;
+        "debug:subidletimeoutsecs"?: number;
         "telemetry:*"?: boolean;
         "telemetry:enabled"?: boolean;
         "conn:*"?: boolean;
         "conn:askbeforewshinstall"?: boolean;
         "conn:wshenabled"?: boolean;
+        "conn:bandwidthlimit"?: number;
+        "conn:fileinfocachettlms"?: number;
+        "conn:requirerpcsigning"?: boolean;
+    };
+
+    // wshrpc.StatFSRtnData
+    type StatFSRtnData = {
+        path: string;
+        totalbytes: number;
+        freebytes: number;
+        availbytes: number;
+        totalinodes?: number;
+        freeinodes?: number;
+        noinodes?: boolean;
     };
 
     // waveobj.StickerClickOptsType
@@ -669,11 +1386,28 @@ declare global {
         display: StickerDisplayOptsType;
     };
 
+    // wshrpc.StringPage
+    type StringPage = {
+        items: string[];
+        nextcursor?: string;
+        total: number;
+    };
+
+    // wps.SubscriptionInfo
+    type SubscriptionInfo = {
+        routeid: string;
+        event: string;
+        scopes?: string[];
+        allscopes?: boolean;
+        subts: number;
+    };
+
     // wps.SubscriptionRequest
     type SubscriptionRequest = {
         event: string;
         scopes?: string[];
         allscopes?: boolean;
+        replaylast?: boolean;
     };
 
     // waveobj.Tab
@@ -683,6 +1417,28 @@ declare global {
         blockids: string[];
     };
 
+    // wshrpc.TabLayout
+    type TabLayout = {
+        tabid: string;
+        rootnode?: TabLayoutNode;
+        focusedblockid?: string;
+        magnifiedblockid?: string;
+    };
+
+    // waveobj.TabLayoutNode
+    type TabLayoutNode = {
+        id: string;
+        data?: TabLayoutNodeData;
+        children?: TabLayoutNode[];
+        flexDirection: string;
+        size: number;
+    };
+
+    // waveobj.TabLayoutNodeData
+    type TabLayoutNodeData = {
+        blockId: string;
+    };
+
     // waveobj.TermSize
     type TermSize = {
         rows: number;
@@ -963,6 +1719,9 @@ declare global {
         filename: string;
         fileop: string;
         data64: string;
+        size?: number;
+        offset?: number;
+        ijsoncommand?: {[key: string]: any};
     };
 
     // webcmd.WSRpcCommand
@@ -976,6 +1735,16 @@ declare global {
         fullconfig: FullConfigType;
     };
 
+    // wshrpc.WaveAIDryRunType
+    type WaveAIDryRunType = {
+        valid: boolean;
+        fitscontext: boolean;
+        prompttokens: number;
+        maxtokens?: number;
+        contextwindow?: number;
+        unsupported?: string[];
+    };
+
     // wshrpc.WaveAIOptsType
     type WaveAIOptsType = {
         model: string;
@@ -987,6 +1756,9 @@ declare global {
         maxtokens?: number;
         maxchoices?: number;
         timeoutms?: number;
+        stream?: boolean;
+        keepalivems?: number;
+        markdownblocks?: boolean;
     };
 
     // wshrpc.WaveAIPacketType
@@ -999,6 +1771,12 @@ declare global {
         index?: number;
         text?: string;
         error?: string;
+        partial?: boolean;
+        quota?: WaveAIQuotaType;
+        dryrun?: WaveAIDryRunType;
+        conversationid?: string;
+        requestid?: string;
+        seq?: number;
     };
 
     // wshrpc.WaveAIPromptMessageType
@@ -1006,6 +1784,14 @@ declare global {
         role: string;
         content: string;
         name?: string;
+        cachehint?: boolean;
+    };
+
+    // wshrpc.WaveAIQuotaType
+    type WaveAIQuotaType = {
+        remainingrequests?: number;
+        remainingtokens?: number;
+        resetts?: number;
     };
 
     // wshrpc.WaveAIStreamRequest
@@ -1013,6 +1799,11 @@ declare global {
         clientid?: string;
         opts: WaveAIOptsType;
         prompt: WaveAIPromptMessageType[];
+        debug?: boolean;
+        dryrun?: boolean;
+        conversation?: boolean;
+        conversationid?: string;
+        resetconversation?: boolean;
     };
 
     // wshrpc.WaveAIUsageType
@@ -1020,6 +1811,7 @@ declare global {
         prompt_tokens?: number;
         completion_tokens?: number;
         total_tokens?: number;
+        cached_tokens?: number;
     };
 
     // wps.WaveEvent
@@ -1029,6 +1821,11 @@ declare global {
         sender?: string;
         persist?: number;
         data?: any;
+        scopeseqs?: {[key: string]: number};
+        targetroutes?: string[];
+        deadletter?: boolean;
+        throttlekey?: string;
+        throttlems?: number;
     };
 
     // filestore.WaveFile
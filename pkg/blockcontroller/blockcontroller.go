@@ -11,6 +11,7 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -57,6 +58,7 @@ const (
 )
 
 const DefaultTimeout = 2 * time.Second
+const ResizeDebounceTime = 50 * time.Millisecond
 
 var globalLock = &sync.Mutex{}
 var blockControllerMap = make(map[string]*BlockController)
@@ -65,6 +67,13 @@ type BlockInputUnion struct {
 	InputData []byte            `json:"inputdata,omitempty"`
 	SigName   string            `json:"signame,omitempty"`
 	TermSize  *waveobj.TermSize `json:"termsize,omitempty"`
+
+	resizeWaiters []chan resizeResult // notified once TermSize has been applied
+}
+
+type resizeResult struct {
+	TermSize waveobj.TermSize
+	Err      error
 }
 
 type BlockController struct {
@@ -78,8 +87,13 @@ type BlockController struct {
 	ShellInputCh      chan *BlockInputUnion
 	ShellProcStatus   string
 	ShellProcExitCode int
+	ShellProcStartTs  int64
 	RunLock           *atomic.Bool
 	StatusVersion     int
+	lastTermSize      *waveobj.TermSize // last size sent to the pty
+	pendingTermSize   *waveobj.TermSize // debounced resize (see Resize)
+	resizeTimer       *time.Timer
+	resizeWaiters     []chan resizeResult // Resize calls waiting on the pending (debounced) resize
 }
 
 type BlockControllerRuntimeStatus struct {
@@ -111,6 +125,56 @@ func (bc *BlockController) GetRuntimeStatus() *BlockControllerRuntimeStatus {
 	return &rtn
 }
 
+// returns a not-running status if blockId has no controller (or it has never been started)
+func GetControllerProcStatus(blockId string) wshrpc.ControllerStatus {
+	rtn := wshrpc.ControllerStatus{BlockId: blockId, Status: Status_Init}
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return rtn
+	}
+	return bc.getProcStatus()
+}
+
+func (bc *BlockController) getProcStatus() wshrpc.ControllerStatus {
+	rtn := wshrpc.ControllerStatus{BlockId: bc.BlockId, TabId: bc.TabId, Status: Status_Init}
+	bc.WithLock(func() {
+		if bc.ShellProcStatus != "" {
+			rtn.Status = bc.ShellProcStatus
+		}
+		rtn.Running = bc.ShellProcStatus == Status_Running
+		rtn.ExitCode = bc.ShellProcExitCode
+		rtn.StartTs = bc.ShellProcStartTs
+		if bc.ShellProc != nil {
+			rtn.Pid = bc.ShellProc.Pid()
+			rtn.CmdLine = bc.ShellProc.CmdLine()
+			rtn.ConnName = bc.ShellProc.ConnName
+		}
+	})
+	return rtn
+}
+
+// statuses of the controllers in tabId (all tabs if tabId is empty), sorted by start time then block id
+func ListControllerProcStatus(tabId string, runningOnly bool) []wshrpc.ControllerStatus {
+	var rtn []wshrpc.ControllerStatus
+	for _, bc := range getControllerList() {
+		if tabId != "" && bc.TabId != tabId {
+			continue
+		}
+		status := bc.getProcStatus()
+		if runningOnly && !status.Running {
+			continue
+		}
+		rtn = append(rtn, status)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		if rtn[i].StartTs != rtn[j].StartTs {
+			return rtn[i].StartTs < rtn[j].StartTs
+		}
+		return rtn[i].BlockId < rtn[j].BlockId
+	})
+	return rtn
+}
+
 func (bc *BlockController) getShellProc() *shellexec.ShellProc {
 	bc.Lock.Lock()
 	defer bc.Lock.Unlock()
@@ -135,7 +199,8 @@ func (bc *BlockController) UpdateControllerAndSendUpdate(updateFn func() bool) {
 				waveobj.MakeORef(waveobj.OType_Tab, bc.TabId).String(),
 				waveobj.MakeORef(waveobj.OType_Block, bc.BlockId).String(),
 			},
-			Data: rtStatus,
+			Data:    rtStatus,
+			Persist: 1, // so subscribers can replay the current status
 		})
 	}
 }
@@ -173,10 +238,18 @@ func HandleTruncateBlockFile(blockId string) error {
 func HandleAppendBlockFile(blockId string, blockFile string, data []byte) error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
 	defer cancelFn()
-	err := filestore.WFS.AppendData(ctx, blockId, blockFile, data)
+	var tap *outputTap
+	if blockFile == BlockFile_Term {
+		tap = lockOutputTap(blockId)
+		defer tap.unlock(blockId)
+	}
+	offset, err := filestore.WFS.AppendDataOffset(ctx, blockId, blockFile, data)
 	if err != nil {
 		return fmt.Errorf("error appending to blockfile: %w", err)
 	}
+	if tap != nil {
+		tap.notify_nolock(data)
+	}
 	wps.Broker.Publish(wps.WaveEvent{
 		Event: wps.Event_BlockFile,
 		Scopes: []string{
@@ -187,6 +260,8 @@ func HandleAppendBlockFile(blockId string, blockFile string, data []byte) error
 			FileName: blockFile,
 			FileOp:   wps.FileOp_Append,
 			Data64:   base64.StdEncoding.EncodeToString(data),
+			Size:     offset + int64(len(data)),
+			Offset:   offset,
 		},
 	})
 	return nil
@@ -342,6 +417,7 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 				return nil, fmt.Errorf("error making jwt token: %w", err)
 			}
 			cmdOpts.Env[wshutil.WaveJwtTokenVarName] = jwtStr
+			cmdOpts.Env[wshutil.WaveRpcSignKeyVarName] = wshutil.MakeRpcFrameSignKey(jwtStr)
 		}
 		shellProc, err = shellexec.StartWslShellProc(ctx, rc.TermSize, cmdStr, cmdOpts, wslConn)
 		if err != nil {
@@ -366,6 +442,7 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 				return nil, fmt.Errorf("error making jwt token: %w", err)
 			}
 			cmdOpts.Env[wshutil.WaveJwtTokenVarName] = jwtStr
+			cmdOpts.Env[wshutil.WaveRpcSignKeyVarName] = wshutil.MakeRpcFrameSignKey(jwtStr)
 		}
 		if !conn.WshEnabled.Load() {
 			shellProc, err = shellexec.StartRemoteShellProcNoWsh(rc.TermSize, cmdStr, cmdOpts, conn)
@@ -395,6 +472,7 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 				return nil, fmt.Errorf("error making jwt token: %w", err)
 			}
 			cmdOpts.Env[wshutil.WaveJwtTokenVarName] = jwtStr
+			cmdOpts.Env[wshutil.WaveRpcSignKeyVarName] = wshutil.MakeRpcFrameSignKey(jwtStr)
 		}
 		settings := wconfig.GetWatcher().GetFullConfig().Settings
 		if settings.TermLocalShellPath != "" {
@@ -417,6 +495,7 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 	bc.UpdateControllerAndSendUpdate(func() bool {
 		bc.ShellProc = shellProc
 		bc.ShellProcStatus = Status_Running
+		bc.ShellProcStartTs = time.Now().UnixMilli()
 		return true
 	})
 	return shellProc, nil
@@ -424,7 +503,9 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 
 func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellProc, rc *RunShellOpts, blockMeta waveobj.MetaMapType) error {
 	shellInputCh := make(chan *BlockInputUnion, 32)
-	bc.ShellInputCh = shellInputCh
+	bc.WithLock(func() {
+		bc.setShellInputCh_nolock(shellInputCh, &rc.TermSize)
+	})
 
 	// make esc sequence wshclient wshProxy
 	// we don't need to authenticate this wshProxy since it is coming direct
@@ -442,7 +523,7 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 			shellProc.Close()
 			bc.WithLock(func() {
 				// so no other events are sent
-				bc.ShellInputCh = nil
+				bc.setShellInputCh_nolock(nil, nil)
 			})
 			shellProc.Cmd.Wait()
 			exitCode := shellProc.Cmd.ExitCode()
@@ -481,7 +562,10 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 				shellProc.Cmd.Write(ic.InputData)
 			}
 			if ic.TermSize != nil {
-				updateTermSize(shellProc, bc.BlockId, *ic.TermSize)
+				termSize := *ic.TermSize
+				bc.setLastTermSize(shellInputCh, termSize)
+				err := updateTermSize(shellProc, bc.BlockId, termSize)
+				notifyResizeWaiters(ic.resizeWaiters, resizeResult{TermSize: termSize, Err: err})
 			}
 		}
 	}()
@@ -523,7 +607,8 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 	return nil
 }
 
-func updateTermSize(shellProc *shellexec.ShellProc, blockId string, termSize waveobj.TermSize) {
+// non-pty procs (wsl) implement SetSize as a no-op, so their resizes succeed
+func updateTermSize(shellProc *shellexec.ShellProc, blockId string, termSize waveobj.TermSize) error {
 	err := setTermSizeInDB(blockId, termSize)
 	if err != nil {
 		log.Printf("error setting pty size: %v\n", err)
@@ -531,6 +616,34 @@ func updateTermSize(shellProc *shellexec.ShellProc, blockId string, termSize wav
 	err = shellProc.Cmd.SetSize(termSize.Rows, termSize.Cols)
 	if err != nil {
 		log.Printf("error setting pty size: %v\n", err)
+		return fmt.Errorf("error setting pty size: %w", err)
+	}
+	return nil
+}
+
+// called when a shell proc starts (with the size its pty was started at) and when it ends (nil inputCh).
+// the last size belongs to the pty, so a new shell proc doesn't inherit the previous one's size.
+func (bc *BlockController) setShellInputCh_nolock(inputCh chan *BlockInputUnion, termSize *waveobj.TermSize) {
+	bc.ShellInputCh = inputCh
+	bc.lastTermSize = nil
+	if termSize != nil {
+		startSize := *termSize
+		bc.lastTermSize = &startSize
+	}
+}
+
+// records a size applied to the pty behind inputCh (ignored if that shell proc has since been replaced)
+func (bc *BlockController) setLastTermSize(inputCh chan *BlockInputUnion, termSize waveobj.TermSize) {
+	bc.Lock.Lock()
+	defer bc.Lock.Unlock()
+	if bc.ShellInputCh == inputCh {
+		bc.lastTermSize = &termSize
+	}
+}
+
+func notifyResizeWaiters(waiters []chan resizeResult, result resizeResult) {
+	for _, waiter := range waiters {
+		waiter <- result
 	}
 }
 
@@ -687,6 +800,60 @@ func (bc *BlockController) SendInput(inputUnion *BlockInputUnion) error {
 	return nil
 }
 
+// resizes the pty and waits until the resize has been applied, returning the applied size.
+// rapid resizes (drag-resize) are debounced so only the last size in a burst is applied (every
+// caller in the burst gets that size back), and resizing to the current size is a no-op.
+func (bc *BlockController) Resize(ctx context.Context, termSize waveobj.TermSize) (waveobj.TermSize, error) {
+	bc.Lock.Lock()
+	if bc.ShellInputCh == nil {
+		bc.Lock.Unlock()
+		return waveobj.TermSize{}, fmt.Errorf("no shell input chan")
+	}
+	if bc.pendingTermSize == nil && bc.lastTermSize != nil && *bc.lastTermSize == termSize {
+		bc.Lock.Unlock()
+		return termSize, nil
+	}
+	bc.pendingTermSize = &termSize
+	waiter := make(chan resizeResult, 1)
+	bc.resizeWaiters = append(bc.resizeWaiters, waiter)
+	if bc.resizeTimer == nil {
+		bc.resizeTimer = time.AfterFunc(ResizeDebounceTime, bc.flushResize)
+	}
+	bc.Lock.Unlock()
+	select {
+	case result := <-waiter:
+		return result.TermSize, result.Err
+	case <-ctx.Done():
+		return waveobj.TermSize{}, fmt.Errorf("error waiting for resize: %w", ctx.Err())
+	}
+}
+
+func (bc *BlockController) flushResize() {
+	var termSize *waveobj.TermSize
+	var waiters []chan resizeResult
+	var lastTermSize waveobj.TermSize
+	bc.WithLock(func() {
+		termSize = bc.pendingTermSize
+		waiters = bc.resizeWaiters
+		bc.pendingTermSize = nil
+		bc.resizeWaiters = nil
+		bc.resizeTimer = nil
+		if termSize != nil && bc.lastTermSize != nil && *termSize == *bc.lastTermSize {
+			lastTermSize = *bc.lastTermSize
+			termSize = nil
+		}
+	})
+	if termSize == nil {
+		notifyResizeWaiters(waiters, resizeResult{TermSize: lastTermSize})
+		return
+	}
+	err := bc.SendInput(&BlockInputUnion{TermSize: termSize, resizeWaiters: waiters})
+	if err != nil {
+		log.Printf("error resizing block %s: %v\n", bc.BlockId, err)
+		notifyResizeWaiters(waiters, resizeResult{Err: fmt.Errorf("error resizing block: %w", err)})
+	}
+}
+
 func CheckConnStatus(blockId string) error {
 	bdata, err := wstore.DBMustGet[*waveobj.Block](context.Background(), blockId)
 	if err != nil {
@@ -0,0 +1,191 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func addTestController(t *testing.T, tabId string, blockId string, status string, startTs int64) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	blockControllerMap[blockId] = &BlockController{
+		Lock:             &sync.Mutex{},
+		ControllerType:   BlockController_Shell,
+		TabId:            tabId,
+		BlockId:          blockId,
+		ShellProcStatus:  status,
+		ShellProcStartTs: startTs,
+		RunLock:          &atomic.Bool{},
+	}
+	t.Cleanup(func() {
+		globalLock.Lock()
+		defer globalLock.Unlock()
+		delete(blockControllerMap, blockId)
+	})
+}
+
+func TestListControllerProcStatus(t *testing.T) {
+	addTestController(t, "tab1", "block-b", Status_Running, 200)
+	addTestController(t, "tab1", "block-a", Status_Running, 100)
+	addTestController(t, "tab1", "block-c", Status_Done, 50)
+	addTestController(t, "tab2", "block-d", Status_Running, 10)
+
+	statuses := ListControllerProcStatus("tab1", false)
+	if len(statuses) != 3 || statuses[0].BlockId != "block-c" || statuses[1].BlockId != "block-a" || statuses[2].BlockId != "block-b" {
+		t.Fatalf("expected the tab1 controllers sorted by start time, got %#v", statuses)
+	}
+	if statuses[0].Running || statuses[0].Status != Status_Done || !statuses[1].Running || statuses[1].TabId != "tab1" {
+		t.Errorf("unexpected statuses %#v", statuses)
+	}
+
+	running := ListControllerProcStatus("tab1", true)
+	if len(running) != 2 || running[0].BlockId != "block-a" || running[1].BlockId != "block-b" {
+		t.Errorf("expected only the running tab1 controllers, got %#v", running)
+	}
+
+	all := ListControllerProcStatus("", true)
+	if len(all) != 3 || all[0].BlockId != "block-d" {
+		t.Errorf("expected the running controllers from every tab, got %#v", all)
+	}
+}
+
+// stands in for the shell proc input loop, returns the sizes applied to the (fake) pty
+func runTestResizeLoop(bc *BlockController, inputCh chan *BlockInputUnion) chan waveobj.TermSize {
+	appliedCh := make(chan waveobj.TermSize, 10)
+	go func() {
+		for ic := range inputCh {
+			if ic.TermSize != nil {
+				bc.setLastTermSize(inputCh, *ic.TermSize)
+				appliedCh <- *ic.TermSize
+				notifyResizeWaiters(ic.resizeWaiters, resizeResult{TermSize: *ic.TermSize})
+			}
+		}
+	}()
+	return appliedCh
+}
+
+func expectNoResize(t *testing.T, appliedCh chan waveobj.TermSize) {
+	t.Helper()
+	select {
+	case termSize := <-appliedCh:
+		t.Errorf("expected no resize, got %v", termSize)
+	case <-time.After(2 * ResizeDebounceTime):
+	}
+}
+
+func TestResize(t *testing.T) {
+	ctx := context.Background()
+	bc := &BlockController{Lock: &sync.Mutex{}, BlockId: "block-resize"}
+	inputCh := make(chan *BlockInputUnion, 32)
+	defer close(inputCh)
+	appliedCh := runTestResizeLoop(bc, inputCh)
+	startSize := waveobj.TermSize{Rows: 24, Cols: 80}
+	bc.WithLock(func() { bc.setShellInputCh_nolock(inputCh, &startSize) })
+
+	if termSize, err := bc.Resize(ctx, startSize); err != nil || termSize != startSize {
+		t.Errorf("expected resizing to the start size to be a no-op, got %v err:%v", termSize, err)
+	}
+	expectNoResize(t, appliedCh)
+
+	// a burst only applies the last size, and every caller gets it back
+	sizes := []waveobj.TermSize{{Rows: 25, Cols: 90}, {Rows: 26, Cols: 95}, {Rows: 30, Cols: 100}}
+	var wg sync.WaitGroup
+	for idx, termSize := range sizes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rtnSize, err := bc.Resize(ctx, termSize); err != nil || rtnSize != sizes[len(sizes)-1] {
+				t.Errorf("expected the last size in the burst, got %v err:%v", rtnSize, err)
+			}
+		}()
+		for {
+			var numWaiters int
+			bc.WithLock(func() { numWaiters = len(bc.resizeWaiters) })
+			if numWaiters == idx+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wg.Wait()
+	if termSize := <-appliedCh; termSize != sizes[len(sizes)-1] {
+		t.Errorf("expected only the last size to be applied, got %v", termSize)
+	}
+	expectNoResize(t, appliedCh)
+
+	// a restarted shell proc starts at its own size, the previous proc's last size no longer applies
+	bc.WithLock(func() { bc.setShellInputCh_nolock(nil, nil) })
+	if _, err := bc.Resize(ctx, startSize); err == nil {
+		t.Errorf("expected an error with no shell proc running")
+	}
+	restartCh := make(chan *BlockInputUnion, 32)
+	defer close(restartCh)
+	restartAppliedCh := runTestResizeLoop(bc, restartCh)
+	bc.WithLock(func() { bc.setShellInputCh_nolock(restartCh, &startSize) })
+	if termSize, err := bc.Resize(ctx, sizes[len(sizes)-1]); err != nil || termSize != sizes[len(sizes)-1] {
+		t.Errorf("expected the restarted proc to be resized, got %v err:%v", termSize, err)
+	}
+	if termSize := <-restartAppliedCh; termSize != sizes[len(sizes)-1] {
+		t.Errorf("expected the resize to reach the restarted proc, got %v", termSize)
+	}
+}
+
+func TestResizeNotApplied(t *testing.T) {
+	bc := &BlockController{Lock: &sync.Mutex{}, BlockId: "block-resize-notapplied"}
+	inputCh := make(chan *BlockInputUnion, 32)
+	defer close(inputCh)
+	appliedCh := runTestResizeLoop(bc, inputCh)
+	startSize := waveobj.TermSize{Rows: 24, Cols: 80}
+	bc.WithLock(func() { bc.setShellInputCh_nolock(inputCh, &startSize) })
+
+	// a burst that ends at the current size is dropped, the callers get the current size back
+	otherSize := waveobj.TermSize{Rows: 30, Cols: 100}
+	otherRtnCh := make(chan waveobj.TermSize, 1)
+	go func() {
+		termSize, _ := bc.Resize(context.Background(), otherSize)
+		otherRtnCh <- termSize
+	}()
+	for {
+		var pending bool
+		bc.WithLock(func() { pending = bc.pendingTermSize != nil })
+		if pending {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if termSize, err := bc.Resize(context.Background(), startSize); err != nil || termSize != startSize {
+		t.Errorf("expected the current size back, got %v err:%v", termSize, err)
+	}
+	if termSize := <-otherRtnCh; termSize != startSize {
+		t.Errorf("expected every caller in the burst to get the current size back, got %v", termSize)
+	}
+	expectNoResize(t, appliedCh)
+
+	// the caller stops waiting when its context is done
+	ctx, cancelFn := context.WithTimeout(context.Background(), ResizeDebounceTime/4)
+	defer cancelFn()
+	bc.WithLock(func() { bc.setShellInputCh_nolock(make(chan *BlockInputUnion, 32), &startSize) })
+	if _, err := bc.Resize(ctx, otherSize); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+	time.Sleep(2 * ResizeDebounceTime) // let the abandoned resize flush
+
+	// the shell proc ends before the debounced resize is sent
+	bc.WithLock(func() { bc.setShellInputCh_nolock(inputCh, &startSize) })
+	go func() {
+		time.Sleep(ResizeDebounceTime / 4)
+		bc.WithLock(func() { bc.setShellInputCh_nolock(nil, nil) })
+	}()
+	if _, err := bc.Resize(context.Background(), otherSize); err == nil {
+		t.Errorf("expected an error when the shell proc is gone before the resize is sent")
+	}
+}
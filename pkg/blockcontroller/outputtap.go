@@ -0,0 +1,124 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+)
+
+// output taps let in-process consumers (StreamControllerOutputCommand) follow a block's
+// pty output as it is appended to the term blockfile.  the tap lock is held across the
+// append + notify so attaching (backfill read + listener registration) never misses or
+// repeats bytes.  a tap is removed once it has no listeners (it is marked removed under
+// its lock, so anyone holding a stale pointer retries with a fresh tap).
+
+const OutputListenerChSize = 256
+
+type outputTap struct {
+	Lock      *sync.Mutex
+	Listeners map[string]chan []byte
+	Removed   bool
+}
+
+var outputTapsLock = &sync.Mutex{}
+var outputTaps = make(map[string]*outputTap)
+
+func getOutputTap(blockId string) *outputTap {
+	outputTapsLock.Lock()
+	defer outputTapsLock.Unlock()
+	tap := outputTaps[blockId]
+	if tap == nil {
+		tap = &outputTap{Lock: &sync.Mutex{}, Listeners: make(map[string]chan []byte)}
+		outputTaps[blockId] = tap
+	}
+	return tap
+}
+
+// returns the block's tap, locked
+func lockOutputTap(blockId string) *outputTap {
+	for {
+		tap := getOutputTap(blockId)
+		tap.Lock.Lock()
+		if !tap.Removed {
+			return tap
+		}
+		tap.Lock.Unlock()
+	}
+}
+
+// must hold tap.Lock, removes the tap if nobody is listening
+func (tap *outputTap) removeIfUnused_nolock(blockId string) {
+	if len(tap.Listeners) > 0 || tap.Removed {
+		return
+	}
+	tap.Removed = true
+	outputTapsLock.Lock()
+	defer outputTapsLock.Unlock()
+	if outputTaps[blockId] == tap {
+		delete(outputTaps, blockId)
+	}
+}
+
+// unlocks the tap (removing it if it has no listeners)
+func (tap *outputTap) unlock(blockId string) {
+	tap.removeIfUnused_nolock(blockId)
+	tap.Lock.Unlock()
+}
+
+// must hold tap.Lock
+func (tap *outputTap) notify_nolock(data []byte) {
+	for id, ch := range tap.Listeners {
+		dataCopy := make([]byte, len(data))
+		copy(dataCopy, data)
+		select {
+		case ch <- dataCopy:
+		default:
+			// listener can't keep up, close it rather than silently dropping output
+			close(ch)
+			delete(tap.Listeners, id)
+		}
+	}
+}
+
+// returns the last backfillBytes of the term file (if > 0) and a channel that receives all output appended after it.
+// the channel is closed if the listener falls too far behind.  call the returned detach func to stop listening.
+func AttachOutputListener(ctx context.Context, blockId string, backfillBytes int64) ([]byte, chan []byte, func(), error) {
+	tap := lockOutputTap(blockId)
+	defer tap.unlock(blockId)
+	var backfill []byte
+	if backfillBytes > 0 {
+		file, err := filestore.WFS.Stat(ctx, blockId, BlockFile_Term)
+		if err != nil && err != fs.ErrNotExist {
+			return nil, nil, nil, fmt.Errorf("error getting term file: %w", err)
+		}
+		if file != nil {
+			offset := file.Size - backfillBytes
+			if offset < 0 {
+				offset = 0
+			}
+			_, backfill, err = filestore.WFS.ReadAt(ctx, blockId, BlockFile_Term, offset, file.Size-offset)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error reading term file: %w", err)
+			}
+		}
+	}
+	id := uuid.NewString()
+	ch := make(chan []byte, OutputListenerChSize)
+	tap.Listeners[id] = ch
+	detachFn := func() {
+		tap.Lock.Lock()
+		defer tap.unlock(blockId)
+		if tap.Listeners[id] != nil {
+			close(ch)
+			delete(tap.Listeners, id)
+		}
+	}
+	return backfill, ch, detachFn, nil
+}
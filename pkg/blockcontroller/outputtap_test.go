@@ -0,0 +1,68 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"testing"
+)
+
+// what HandleAppendBlockFile does around the blockfile append
+func notifyTestOutput(blockId string, data string) {
+	tap := lockOutputTap(blockId)
+	defer tap.unlock(blockId)
+	tap.notify_nolock([]byte(data))
+}
+
+func hasOutputTap(blockId string) bool {
+	outputTapsLock.Lock()
+	defer outputTapsLock.Unlock()
+	return outputTaps[blockId] != nil
+}
+
+func TestOutputTapListener(t *testing.T) {
+	blockId := "tap-block"
+	notifyTestOutput(blockId, "before")
+	if hasOutputTap(blockId) {
+		t.Errorf("output with no listeners should not leave a tap behind")
+	}
+	_, outputCh, detachFn, err := AttachOutputListener(context.Background(), blockId, 0)
+	if err != nil {
+		t.Fatalf("error attaching: %v", err)
+	}
+	notifyTestOutput(blockId, "hello")
+	if output := <-outputCh; string(output) != "hello" {
+		t.Errorf("expected %q, got %q", "hello", output)
+	}
+	detachFn()
+	if _, ok := <-outputCh; ok {
+		t.Errorf("expected the channel to be closed after detach")
+	}
+	if hasOutputTap(blockId) {
+		t.Errorf("expected the tap to be removed after its last listener detached")
+	}
+	detachFn() // detaching twice is harmless
+}
+
+func TestOutputTapSlowListener(t *testing.T) {
+	blockId := "tap-slow-block"
+	_, outputCh, detachFn, err := AttachOutputListener(context.Background(), blockId, 0)
+	if err != nil {
+		t.Fatalf("error attaching: %v", err)
+	}
+	defer detachFn()
+	for i := 0; i <= OutputListenerChSize; i++ {
+		notifyTestOutput(blockId, "x")
+	}
+	var count int
+	for range outputCh {
+		count++
+	}
+	if count != OutputListenerChSize {
+		t.Errorf("expected the listener to get %d chunks before being closed, got %d", OutputListenerChSize, count)
+	}
+	if hasOutputTap(blockId) {
+		t.Errorf("expected the tap to be removed once its only listener was dropped")
+	}
+}
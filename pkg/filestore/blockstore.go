@@ -8,7 +8,9 @@ package filestore
 // but all writes only go to the cache, and then the cache is periodically flushed to the DB
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -24,8 +26,43 @@ const (
 	// ijson meta keys
 	IJsonNumCommands      = "ijson:numcmds"
 	IJsonIncrementalBytes = "ijson:incbytes"
+	IJsonVersion          = "ijson:version" // incremented on every AppendIJson
 )
 
+var ErrIJsonVersionMismatch = errors.New("ijson version mismatch")
+
+var ErrPreconditionFailed = errors.New("write precondition failed")
+
+// optimistic concurrency for writes, nil fields are not checked
+type WriteCond struct {
+	ModTs *int64
+	Size  *int64
+}
+
+// returned (matching ErrPreconditionFailed) when a WriteCond does not match, holds the current stat
+type PreconditionFailedError struct {
+	ModTs int64
+	Size  int64
+}
+
+func (e PreconditionFailedError) Error() string {
+	return fmt.Sprintf("%v: current modts %d, size %d", ErrPreconditionFailed, e.ModTs, e.Size)
+}
+
+func (e PreconditionFailedError) Unwrap() error {
+	return ErrPreconditionFailed
+}
+
+func (cond *WriteCond) check(file *WaveFile) error {
+	if cond == nil {
+		return nil
+	}
+	if (cond.ModTs != nil && *cond.ModTs != file.ModTs) || (cond.Size != nil && *cond.Size != file.Size) {
+		return PreconditionFailedError{ModTs: file.ModTs, Size: file.Size}
+	}
+	return nil
+}
+
 const (
 	IJsonHighCommands = 100
 	IJsonHighRatio    = 3
@@ -233,57 +270,177 @@ func (s *FileStore) WriteMeta(ctx context.Context, zoneId string, name string, m
 }
 
 func (s *FileStore) WriteFile(ctx context.Context, zoneId string, name string, data []byte) error {
-	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+	_, err := s.WriteFileIf(ctx, zoneId, name, data, nil)
+	return err
+}
+
+// like WriteFile, but only writes if the file matches cond (checked under the file lock).
+// returns the file size after the write (taken under the lock).
+func (s *FileStore) WriteFileIf(ctx context.Context, zoneId string, name string, data []byte, cond *WriteCond) (int64, error) {
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
 		err := entry.loadFileIntoCache(ctx)
 		if err != nil {
-			return err
+			return 0, err
+		}
+		err = cond.check(entry.File)
+		if err != nil {
+			return 0, err
 		}
 		entry.writeAt(0, data, true)
+		newSize := entry.File.Size // the flush clears the entry
 		// since WriteFile can *truncate* the file, we need to flush the file to the DB immediately
-		return entry.flushToDB(ctx, true)
+		err = entry.flushToDB(ctx, true)
+		if err != nil {
+			return 0, err
+		}
+		return newSize, nil
 	})
 }
 
 func (s *FileStore) WriteAt(ctx context.Context, zoneId string, name string, offset int64, data []byte) error {
+	_, err := s.WriteAtIf(ctx, zoneId, name, offset, data, nil)
+	return err
+}
+
+// like WriteAt, but only writes if the file matches cond (checked under the file lock).
+// returns the file size after the write (taken under the lock).
+func (s *FileStore) WriteAtIf(ctx context.Context, zoneId string, name string, offset int64, data []byte, cond *WriteCond) (int64, error) {
 	if offset < 0 {
-		return fmt.Errorf("offset must be non-negative")
+		return 0, fmt.Errorf("offset must be non-negative")
 	}
-	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
 		err := entry.loadFileIntoCache(ctx)
 		if err != nil {
-			return err
+			return 0, err
+		}
+		err = cond.check(entry.File)
+		if err != nil {
+			return 0, err
 		}
 		file := entry.File
 		if offset > file.Size {
-			return fmt.Errorf("offset is past the end of the file")
+			return 0, fmt.Errorf("offset is past the end of the file")
 		}
 		partMap := file.computePartMap(offset, int64(len(data)))
 		incompleteParts := incompletePartsFromMap(partMap)
 		err = entry.loadDataPartsIntoCache(ctx, incompleteParts)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		entry.writeAt(offset, data, false)
-		return nil
+		return entry.File.Size, nil
 	})
 }
 
 func (s *FileStore) AppendData(ctx context.Context, zoneId string, name string, data []byte) error {
-	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+	_, err := s.AppendDataOffset(ctx, zoneId, name, data)
+	return err
+}
+
+// like AppendData, but returns the offset the data was written at (the file size before the append,
+// taken under the file lock so it is exact even with concurrent writers)
+func (s *FileStore) AppendDataOffset(ctx context.Context, zoneId string, name string, data []byte) (int64, error) {
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
 		err := entry.loadFileIntoCache(ctx)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		partMap := entry.File.computePartMap(entry.File.Size, int64(len(data)))
+		offset := entry.File.Size
+		partMap := entry.File.computePartMap(offset, int64(len(data)))
 		incompleteParts := incompletePartsFromMap(partMap)
 		if len(incompleteParts) > 0 {
 			err = entry.loadDataPartsIntoCache(ctx, incompleteParts)
 			if err != nil {
-				return err
+				return 0, err
 			}
 		}
-		entry.writeAt(entry.File.Size, data, false)
-		return nil
+		entry.writeAt(offset, data, false)
+		return offset, nil
+	})
+}
+
+// drops data from the front of the file so it is at most maxSize bytes, cutting at the next newline
+// (when there is one) so the file does not start with a partial line.  returns the new size.
+// circular files are already bounded and are not trimmed.
+func (s *FileStore) TrimFront(ctx context.Context, zoneId string, name string, maxSize int64) (int64, error) {
+	if maxSize <= 0 {
+		return 0, fmt.Errorf("maxsize must be positive")
+	}
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
+		err := entry.loadFileIntoCache(ctx)
+		if err != nil {
+			return 0, err
+		}
+		file := entry.File
+		if file.Opts.Circular || file.Size <= maxSize {
+			return file.Size, nil
+		}
+		_, data, err := entry.readAt(ctx, file.Size-maxSize, maxSize, false)
+		if err != nil {
+			return 0, err
+		}
+		if nlIdx := bytes.IndexByte(data, '\n'); nlIdx != -1 && nlIdx < len(data)-1 {
+			data = data[nlIdx+1:]
+		}
+		entry.writeAt(0, data, true)
+		newSize := entry.File.Size // the flush clears the entry
+		// like WriteFile, this truncates, so flush to the DB immediately
+		err = entry.flushToDB(ctx, true)
+		if err != nil {
+			return 0, err
+		}
+		return newSize, nil
+	})
+}
+
+// moves the file's data to rotatedName (replacing it) and truncates the file.  the file stays locked
+// throughout, so no appends are lost.  the data is written to a temp file that is then renamed over
+// rotatedName, so a failed rotate leaves the old rotated file intact.  returns the rotated size.
+func (s *FileStore) RotateFile(ctx context.Context, zoneId string, name string, rotatedName string) (int64, error) {
+	if rotatedName == name {
+		return 0, fmt.Errorf("cannot rotate a file onto itself")
+	}
+	tmpName := rotatedName + ".tmp"
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
+		err := entry.loadFileIntoCache(ctx)
+		if err != nil {
+			return 0, err
+		}
+		file := entry.File
+		_, data, err := entry.readAt(ctx, 0, 0, true)
+		if err != nil {
+			return 0, err
+		}
+		// a temp file left by a failed rotate is replaced
+		err = s.DeleteFile(ctx, zoneId, tmpName)
+		if err != nil {
+			return 0, err
+		}
+		err = s.MakeFile(ctx, zoneId, tmpName, file.Meta, file.Opts)
+		if err != nil {
+			return 0, fmt.Errorf("error creating temp file: %w", err)
+		}
+		err = s.WriteFile(ctx, zoneId, tmpName, data)
+		if err != nil {
+			return 0, fmt.Errorf("error writing temp file: %w", err)
+		}
+		err = withLock(s, zoneId, rotatedName, func(rotatedEntry *CacheEntry) error {
+			err := dbRenameFile(ctx, zoneId, tmpName, rotatedName)
+			if err != nil {
+				return fmt.Errorf("error renaming temp file: %w", err)
+			}
+			rotatedEntry.clear()
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		entry.writeAt(0, nil, true)
+		err = entry.flushToDB(ctx, true)
+		if err != nil {
+			return 0, err
+		}
+		return int64(len(data)), nil
 	})
 }
 
@@ -310,6 +467,8 @@ func (s *FileStore) compactIJson(ctx context.Context, entry *CacheEntry) error {
 	if err != nil {
 		return err
 	}
+	// keep the trailing newline so subsequent appends start on their own line
+	newBytes = append(newBytes, '\n')
 	entry.writeAt(0, newBytes, true)
 	return nil
 }
@@ -328,11 +487,34 @@ func (s *FileStore) CompactIJson(ctx context.Context, zoneId string, name string
 }
 
 func (s *FileStore) AppendIJson(ctx context.Context, zoneId string, name string, command map[string]any) error {
+	_, _, err := s.AppendIJsonVersioned(ctx, zoneId, name, command, nil)
+	return err
+}
+
+func getIJsonVersion(file *WaveFile) int64 {
+	switch val := file.Meta[IJsonVersion].(type) {
+	case int64:
+		return val
+	case int:
+		return int64(val)
+	case float64:
+		// meta loaded from the DB
+		return int64(val)
+	}
+	return 0
+}
+
+// appends the command under the file lock (so concurrent appends never interleave) and returns the
+// new document version and the file size after the append (which may have compacted it).
+// if expectedVersion is set and does not match the current version, nothing is written and
+// ErrIJsonVersionMismatch is returned.
+func (s *FileStore) AppendIJsonVersioned(ctx context.Context, zoneId string, name string, command map[string]any, expectedVersion *int64) (int64, int64, error) {
 	data, err := ijson.ValidateAndMarshalCommand(command)
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
-	return withLock(s, zoneId, name, func(entry *CacheEntry) error {
+	var newVersion, newSize int64
+	err = withLock(s, zoneId, name, func(entry *CacheEntry) error {
 		err := entry.loadFileIntoCache(ctx)
 		if err != nil {
 			return err
@@ -340,6 +522,15 @@ func (s *FileStore) AppendIJson(ctx context.Context, zoneId string, name string,
 		if !entry.File.Opts.IJson {
 			return fmt.Errorf("file %s:%s is not an ijson file", zoneId, name)
 		}
+		curVersion := getIJsonVersion(entry.File)
+		if expectedVersion != nil && *expectedVersion != curVersion {
+			return fmt.Errorf("%w: expected %d, current %d", ErrIJsonVersionMismatch, *expectedVersion, curVersion)
+		}
+		newVersion = curVersion + 1
+		if entry.File.Meta == nil {
+			entry.File.Meta = make(FileMeta)
+		}
+		entry.File.Meta[IJsonVersion] = newVersion
 		partMap := entry.File.computePartMap(entry.File.Size, int64(len(data)))
 		incompleteParts := incompletePartsFromMap(partMap)
 		if len(incompleteParts) > 0 {
@@ -352,6 +543,7 @@ func (s *FileStore) AppendIJson(ctx context.Context, zoneId string, name string,
 		entry.writeAt(entry.File.Size, data, false)
 		entry.writeAt(entry.File.Size, []byte("\n"), false)
 		if oldSize == 0 {
+			newSize = entry.File.Size
 			return nil
 		}
 		// check if we should compact
@@ -364,8 +556,13 @@ func (s *FileStore) AppendIJson(ctx context.Context, zoneId string, name string,
 				return err
 			}
 		}
+		newSize = entry.File.Size
 		return nil
 	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return newVersion, newSize, nil
 }
 
 func (s *FileStore) GetAllZoneIds(ctx context.Context) ([]string, error) {
@@ -427,6 +624,41 @@ func (s *FileStore) FlushCache(ctx context.Context) (stats FlushStats, rtnErr er
 	return stats, nil
 }
 
+// writes a file's cached (not yet flushed) data to the db now instead of waiting for the flusher.  the db
+// commit is synced to disk, so the data survives a crash once this returns.  each flush is its own
+// transaction (with an fsync), so it is much more expensive than a write: call it at checkpoints, not
+// after every append.  returns false if nothing was cached.
+func (s *FileStore) FlushFile(ctx context.Context, zoneId string, name string) (bool, error) {
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (bool, error) {
+		if entry.File == nil {
+			return false, nil
+		}
+		err := entry.flushToDB(ctx, false)
+		if err != nil {
+			return false, fmt.Errorf("error flushing file %q: %w", name, err)
+		}
+		return true, nil
+	})
+}
+
+// flushes every cached file in the zone (see FlushFile), returns the number of files flushed
+func (s *FileStore) FlushZone(ctx context.Context, zoneId string) (int, error) {
+	var numFlushed int
+	for _, key := range s.getDirtyCacheKeys() {
+		if key.ZoneId != zoneId {
+			continue
+		}
+		flushed, err := s.FlushFile(ctx, zoneId, key.Name)
+		if err != nil {
+			return numFlushed, err
+		}
+		if flushed {
+			numFlushed++
+		}
+	}
+	return numFlushed, nil
+}
+
 ///////////////////////////////////
 
 func (f *WaveFile) partIdxAtOffset(offset int64) int {
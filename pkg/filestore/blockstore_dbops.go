@@ -36,6 +36,25 @@ func dbDeleteFile(ctx context.Context, zoneId string, name string) error {
 	})
 }
 
+// replaces newName (if it exists) with oldName, in one transaction
+func dbRenameFile(ctx context.Context, zoneId string, oldName string, newName string) error {
+	return WithTx(ctx, func(tx *TxWrap) error {
+		query := "SELECT zoneid FROM db_wave_file WHERE zoneid = ? AND name = ?"
+		if !tx.Exists(query, zoneId, oldName) {
+			return fs.ErrNotExist
+		}
+		query = "DELETE FROM db_wave_file WHERE zoneid = ? AND name = ?"
+		tx.Exec(query, zoneId, newName)
+		query = "DELETE FROM db_file_data WHERE zoneid = ? AND name = ?"
+		tx.Exec(query, zoneId, newName)
+		query = "UPDATE db_wave_file SET name = ? WHERE zoneid = ? AND name = ?"
+		tx.Exec(query, newName, zoneId, oldName)
+		query = "UPDATE db_file_data SET name = ? WHERE zoneid = ? AND name = ?"
+		tx.Exec(query, newName, zoneId, oldName)
+		return nil
+	})
+}
+
 func dbGetZoneFileNames(ctx context.Context, zoneId string) ([]string, error) {
 	return WithTxRtn(ctx, func(tx *TxWrap) ([]string, error) {
 		var files []string
@@ -388,6 +388,131 @@ func TestWriteFile(t *testing.T) {
 	checkFileData(t, ctx, zoneId, "c1", "3456789 123456789 123456789 123456789 apple banana")
 }
 
+func TestWriteFileIf(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	fileName := "cond"
+	err := WFS.MakeFile(ctx, zoneId, fileName, nil, FileOptsType{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	err = WFS.WriteFile(ctx, zoneId, fileName, []byte("hello world!"))
+	if err != nil {
+		t.Fatalf("error writing data: %v", err)
+	}
+	file, err := WFS.Stat(ctx, zoneId, fileName)
+	if err != nil {
+		t.Fatalf("error stating file: %v", err)
+	}
+	modTs, size := file.ModTs, file.Size
+	staleModTs, staleSize := modTs-1, size+1
+
+	_, err = WFS.WriteFileIf(ctx, zoneId, fileName, []byte("stale"), &WriteCond{ModTs: &staleModTs})
+	var condErr PreconditionFailedError
+	if !errors.As(err, &condErr) || !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected precondition failed error, got %v", err)
+	}
+	if condErr.ModTs != modTs || condErr.Size != size {
+		t.Errorf("expected current stat (%d, %d), got (%d, %d)", modTs, size, condErr.ModTs, condErr.Size)
+	}
+	_, err = WFS.WriteAtIf(ctx, zoneId, fileName, 0, []byte("J"), &WriteCond{ModTs: &modTs, Size: &staleSize})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected precondition failed error for size, got %v", err)
+	}
+	checkFileData(t, ctx, zoneId, fileName, "hello world!")
+
+	_, err = WFS.WriteAtIf(ctx, zoneId, fileName, 0, []byte("J"), &WriteCond{ModTs: &modTs, Size: &size})
+	if err != nil {
+		t.Fatalf("error writing with matching precondition: %v", err)
+	}
+	checkFileData(t, ctx, zoneId, fileName, "Jello world!")
+	_, err = WFS.WriteFileIf(ctx, zoneId, fileName, []byte("goodbye"), &WriteCond{Size: &size})
+	if err != nil {
+		t.Fatalf("error writing with matching precondition: %v", err)
+	}
+	checkFileData(t, ctx, zoneId, fileName, "goodbye")
+}
+
+func TestTrimFront(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	fileName := "log"
+	err := WFS.MakeFile(ctx, zoneId, fileName, nil, FileOptsType{})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	lines := ""
+	for idx := 0; idx < 20; idx++ {
+		lines += fmt.Sprintf("line %02d\n", idx)
+	}
+	WFS.AppendData(ctx, zoneId, fileName, []byte(lines))
+	newSize, err := WFS.TrimFront(ctx, zoneId, fileName, 20)
+	if err != nil {
+		t.Fatalf("error trimming: %v", err)
+	}
+	// cut at the next newline, so only whole lines are kept
+	if newSize != 16 {
+		t.Errorf("expected the trimmed size to be 16, got %d", newSize)
+	}
+	checkFileData(t, ctx, zoneId, fileName, "line 18\nline 19\n")
+	if newSize, err = WFS.TrimFront(ctx, zoneId, fileName, 100); err != nil || newSize != 16 {
+		t.Errorf("expected a file under maxsize to be left alone, got size %d (err:%v)", newSize, err)
+	}
+}
+
+func TestRotateFile(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	fileName := "log"
+	rotatedName := "log.1"
+	for _, name := range []string{fileName, rotatedName, rotatedName + ".tmp"} {
+		if err := WFS.MakeFile(ctx, zoneId, name, FileMeta{"name": name}, FileOptsType{}); err != nil {
+			t.Fatalf("error creating file %q: %v", name, err)
+		}
+	}
+	// rotatedName has unflushed data in the cache, and there is a temp file left by a failed rotate
+	WFS.AppendData(ctx, zoneId, rotatedName, []byte("old rotated data"))
+	WFS.AppendData(ctx, zoneId, rotatedName+".tmp", []byte("stale"))
+	newData := bytes.Repeat([]byte("0123456789"), 12)
+	WFS.AppendData(ctx, zoneId, fileName, newData)
+
+	rotatedSize, err := WFS.RotateFile(ctx, zoneId, fileName, rotatedName)
+	if err != nil {
+		t.Fatalf("error rotating: %v", err)
+	}
+	if rotatedSize != int64(len(newData)) {
+		t.Errorf("expected a rotated size of %d, got %d", len(newData), rotatedSize)
+	}
+	checkFileData(t, ctx, zoneId, rotatedName, string(newData))
+	checkFileSize(t, ctx, zoneId, fileName, 0)
+	if _, err := WFS.Stat(ctx, zoneId, rotatedName+".tmp"); err != fs.ErrNotExist {
+		t.Errorf("expected the temp file to be renamed away, got %v", err)
+	}
+	if file, _ := WFS.Stat(ctx, zoneId, rotatedName); file == nil || file.Meta["name"] != fileName {
+		t.Errorf("expected the rotated file to get the file's meta, got %v", file)
+	}
+	// appends after the rotate go to the (now empty) file
+	WFS.AppendData(ctx, zoneId, fileName, []byte("next"))
+	checkFileData(t, ctx, zoneId, fileName, "next")
+	checkFileData(t, ctx, zoneId, rotatedName, string(newData))
+
+	if _, err := WFS.RotateFile(ctx, zoneId, fileName, fileName); err == nil {
+		t.Errorf("expected rotating a file onto itself to fail")
+	}
+}
+
 func TestCircularWrites(t *testing.T) {
 	initDb(t)
 	defer cleanupDb(t)
@@ -748,3 +873,129 @@ func TestIJson(t *testing.T) {
 		t.Errorf("data mismatch: expected %v, got %v", rootSet["data"], outData)
 	}
 }
+
+func TestIJsonConcurrentAppend(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	fileName := "ij1"
+	err := WFS.MakeFile(ctx, zoneId, fileName, nil, FileOptsType{IJson: true})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	version, _, err := WFS.AppendIJsonVersioned(ctx, zoneId, fileName, ijson.MakeSetCommand(nil, map[string]any{"items": []any{}}), nil)
+	if err != nil {
+		t.Fatalf("error appending ijson: %v", err)
+	}
+	if version != 1 {
+		t.Fatalf("version mismatch: expected 1, got %d", version)
+	}
+	const numWriters = 16
+	const numAppends = 50
+	var wg sync.WaitGroup
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < numAppends; j++ {
+				cmd := ijson.MakeAppendCommand(ijson.Path{"items"}, map[string]any{"n": float64(n), "j": float64(j)})
+				err := WFS.AppendIJson(ctx, zoneId, fileName, cmd)
+				if err != nil {
+					t.Errorf("error appending ijson (%d): %v", n, err)
+				}
+				if j == numAppends/2 {
+					// ignore error here (concurrent flushing)
+					WFS.FlushCache(ctx)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	_, fullData, err := WFS.ReadFile(ctx, zoneId, fileName)
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	cmds, err := ijson.ParseIJson(fullData)
+	if err != nil {
+		t.Fatalf("error parsing ijson: %v", err)
+	}
+	outData, err := ijson.ApplyCommands(nil, cmds, 0)
+	if err != nil {
+		t.Fatalf("error applying ijson: %v", err)
+	}
+	items, _ := outData.(map[string]any)["items"].([]any)
+	if len(items) != numWriters*numAppends {
+		t.Fatalf("item count mismatch: expected %d, got %d", numWriters*numAppends, len(items))
+	}
+	// each writer's appends must appear in the order they were made
+	lastSeen := make(map[float64]float64)
+	for _, item := range items {
+		itemMap := item.(map[string]any)
+		n, j := itemMap["n"].(float64), itemMap["j"].(float64)
+		if last, ok := lastSeen[n]; ok && j <= last {
+			t.Errorf("writer %v: append %v applied after %v", n, j, last)
+		}
+		lastSeen[n] = j
+	}
+	file, err := WFS.Stat(ctx, zoneId, fileName)
+	if err != nil {
+		t.Fatalf("error stating file: %v", err)
+	}
+	expectedVersion := int64(1 + numWriters*numAppends)
+	if getIJsonVersion(file) != expectedVersion {
+		t.Errorf("version mismatch: expected %d, got %d", expectedVersion, getIJsonVersion(file))
+	}
+	staleVersion := int64(1)
+	_, _, err = WFS.AppendIJsonVersioned(ctx, zoneId, fileName, ijson.MakeDelCommand(ijson.Path{"items"}), &staleVersion)
+	if !errors.Is(err, ErrIJsonVersionMismatch) {
+		t.Errorf("expected version mismatch error, got %v", err)
+	}
+	version, _, err = WFS.AppendIJsonVersioned(ctx, zoneId, fileName, ijson.MakeDelCommand(ijson.Path{"items"}), &expectedVersion)
+	if err != nil {
+		t.Fatalf("error appending ijson with expected version: %v", err)
+	}
+	if version != expectedVersion+1 {
+		t.Errorf("version mismatch: expected %d, got %d", expectedVersion+1, version)
+	}
+}
+
+func TestFlushFile(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	for _, fileName := range []string{"t1", "t2", "t3"} {
+		err := WFS.MakeFile(ctx, zoneId, fileName, nil, FileOptsType{})
+		if err != nil {
+			t.Fatalf("error creating file: %v", err)
+		}
+	}
+	_, err := WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	flushed, err := WFS.FlushFile(ctx, zoneId, "t1")
+	if err != nil || flushed {
+		t.Errorf("flush with nothing cached should be a no-op (flushed:%v err:%v)", flushed, err)
+	}
+	WFS.AppendData(ctx, zoneId, "t1", []byte("output before kill"))
+	WFS.AppendData(ctx, zoneId, "t2", []byte("zone data"))
+	WFS.AppendData(ctx, zoneId, "t3", []byte("more zone data"))
+	flushed, err = WFS.FlushFile(ctx, zoneId, "t1")
+	if err != nil || !flushed {
+		t.Fatalf("expected t1 to be flushed (flushed:%v err:%v)", flushed, err)
+	}
+	numFlushed, err := WFS.FlushZone(ctx, zoneId)
+	if err != nil || numFlushed != 2 {
+		t.Fatalf("expected the other 2 files to be flushed (flushed:%d err:%v)", numFlushed, err)
+	}
+	WFS.AppendData(ctx, zoneId, "t1", []byte(" (lost)"))
+	// simulate a restart: anything not flushed to the db is gone
+	WFS.clearCache()
+	checkFileData(t, ctx, zoneId, "t1", "output before kill")
+	checkFileData(t, ctx, zoneId, "t2", "zone data")
+	checkFileData(t, ctx, zoneId, "t3", "more zone data")
+}
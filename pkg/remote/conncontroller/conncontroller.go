@@ -25,6 +25,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/remote"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
@@ -64,6 +65,9 @@ type SSHConn struct {
 	HasWaiter          *atomic.Bool
 	LastConnectTime    int64
 	ActiveConnNum      int
+	EnvCache           *envutil.ConnEnvCache
+	Label              string // display label (see RenameConn), empty uses the connection name
+	RouteAlias         string // extra route id for the conn route (see RenameConn)
 }
 
 func GetAllConnStatus() []wshrpc.ConnStatus {
@@ -102,18 +106,14 @@ func (conn *SSHConn) DeriveConnStatus() wshrpc.ConnStatus {
 		ActiveConnNum: conn.ActiveConnNum,
 		Error:         conn.Error,
 		WshError:      conn.WshError,
+		Ready:         conn.EnvCache != nil,
+		Label:         conn.Label,
 	}
 }
 
 func (conn *SSHConn) FireConnChangeEvent() {
 	status := conn.DeriveConnStatus()
-	event := wps.WaveEvent{
-		Event: wps.Event_ConnChange,
-		Scopes: []string{
-			fmt.Sprintf("connection:%s", conn.GetName()),
-		},
-		Data: status,
-	}
+	event := wps.MakeConnChangeEvent(conn.GetName(), status)
 	log.Printf("sending event: %+#v", event)
 	wps.Broker.Publish(event)
 }
@@ -140,6 +140,12 @@ func (conn *SSHConn) Close() error {
 
 func (conn *SSHConn) close_nolock() {
 	// does not set status (that should happen at another level)
+	conn.EnvCache = nil
+	if conn.RouteAlias != "" {
+		// the conn route goes away with the connserver, so the alias would dangle (the label is kept)
+		wshutil.DefaultRouter.RemoveRouteAlias(conn.RouteAlias)
+		conn.RouteAlias = ""
+	}
 	if conn.DomainSockListener != nil {
 		conn.DomainSockListener.Close()
 		conn.DomainSockListener = nil
@@ -246,10 +252,11 @@ func (conn *SSHConn) StartConnServer() error {
 		return err
 	}
 	var cmdStr string
+	connServerArgs := wconfig.GetConnServerArgs(conn.GetName())
 	if remote.IsPowershell(shellPath) {
-		cmdStr = fmt.Sprintf("$env:%s=\"%s\"; %s connserver", wshutil.WaveJwtTokenVarName, jwtToken, wshPath)
+		cmdStr = fmt.Sprintf("$env:%s=\"%s\"; %s connserver%s", wshutil.WaveJwtTokenVarName, jwtToken, wshPath, connServerArgs)
 	} else {
-		cmdStr = fmt.Sprintf("%s=\"%s\" %s connserver", wshutil.WaveJwtTokenVarName, jwtToken, wshPath)
+		cmdStr = fmt.Sprintf("%s=\"%s\" %s connserver%s", wshutil.WaveJwtTokenVarName, jwtToken, wshPath, connServerArgs)
 	}
 	log.Printf("starting conn controller: %s\n", cmdStr)
 	err = sshSession.Start(cmdStr)
@@ -644,6 +651,69 @@ func EnsureConnection(ctx context.Context, connName string) error {
 	}
 }
 
+// names that can't be given to another connection: "local", wsl connections, configured connections (connections.json)
+func isReservedConnName(newName string) bool {
+	if newName == wshrpc.LocalConnName || strings.HasPrefix(newName, "wsl://") {
+		return true
+	}
+	_, ok := wconfig.ReadFullConfig().Connections[newName]
+	return ok
+}
+
+// relabels a connection without touching its session.  with addAlias, "conn:<newName>" also routes to the
+// connection (the original route keeps working) until it disconnects.  names used by another connection
+// (live or configured) are rejected.
+func RenameConn(connName string, newName string, addAlias bool) error {
+	connOpts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return fmt.Errorf("error parsing connection name: %w", err)
+	}
+	globalLock.Lock()
+	conn := clientControllerMap[*connOpts]
+	var collision bool
+	for _, other := range clientControllerMap {
+		if other == conn || newName == "" {
+			continue
+		}
+		other.WithLock(func() {
+			collision = collision || other.GetName() == newName || other.Label == newName
+		})
+	}
+	globalLock.Unlock()
+	if conn == nil {
+		return fmt.Errorf("connection not found: %s", connName)
+	}
+	if newName != "" && newName != conn.GetName() && isReservedConnName(newName) {
+		collision = true
+	}
+	if collision {
+		return fmt.Errorf("connection name %q is already in use", newName)
+	}
+	var oldAlias string
+	conn.WithLock(func() {
+		oldAlias = conn.RouteAlias
+	})
+	newAlias := ""
+	if addAlias && newName != conn.GetName() {
+		newAlias = wshutil.MakeConnectionRouteId(newName)
+	}
+	if newAlias != "" && newAlias != oldAlias {
+		err := wshutil.DefaultRouter.SetRouteAlias(newAlias, wshutil.MakeConnectionRouteId(conn.GetName()))
+		if err != nil {
+			return fmt.Errorf("connection name %q is already in use: %w", newName, err)
+		}
+	}
+	if oldAlias != "" && oldAlias != newAlias {
+		wshutil.DefaultRouter.RemoveRouteAlias(oldAlias)
+	}
+	conn.WithLock(func() {
+		conn.Label = newName
+		conn.RouteAlias = newAlias
+	})
+	conn.FireConnChangeEvent()
+	return nil
+}
+
 func DisconnectClient(opts *remote.SSHOpts) error {
 	conn := getConnInternal(opts)
 	if conn == nil {
@@ -0,0 +1,79 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func makeTestConn(t *testing.T, connName string) *SSHConn {
+	t.Helper()
+	opts, err := remote.ParseOpts(connName)
+	if err != nil {
+		t.Fatalf("error parsing %q: %v", connName, err)
+	}
+	t.Cleanup(func() {
+		globalLock.Lock()
+		defer globalLock.Unlock()
+		delete(clientControllerMap, *opts)
+	})
+	return getConnInternal(opts)
+}
+
+func getRouteAliasTarget(alias string) string {
+	wshutil.DefaultRouter.Lock.Lock()
+	defer wshutil.DefaultRouter.Lock.Unlock()
+	return wshutil.DefaultRouter.RouteAliases[alias]
+}
+
+func TestRenameConn(t *testing.T) {
+	configDir := t.TempDir()
+	oldConfigDir := wavebase.ConfigHome_VarCache
+	wavebase.ConfigHome_VarCache = configDir
+	defer func() { wavebase.ConfigHome_VarCache = oldConfigDir }()
+	os.WriteFile(filepath.Join(configDir, wconfig.ConnectionsFile), []byte(`{"user@configured": {}}`), 0644)
+
+	conn1 := makeTestConn(t, "user@host1")
+	makeTestConn(t, "user@host2")
+	if err := RenameConn("user@host1", "prod", true); err != nil {
+		t.Fatalf("error renaming: %v", err)
+	}
+	alias := wshutil.MakeConnectionRouteId("prod")
+	if status := conn1.DeriveConnStatus(); status.Label != "prod" {
+		t.Errorf("expected the label in the conn status, got %q", status.Label)
+	}
+	if target := getRouteAliasTarget(alias); target != wshutil.MakeConnectionRouteId("user@host1") {
+		t.Errorf("expected %s to alias the conn route, got %q", alias, target)
+	}
+
+	for _, newName := range []string{"prod", "user@host1", "user@configured", "wsl://Ubuntu", "local"} {
+		if err := RenameConn("user@host2", newName, false); err == nil {
+			t.Errorf("expected renaming to %q to be rejected", newName)
+		}
+	}
+	if err := RenameConn("user@host1", "user@host1", false); err != nil {
+		t.Errorf("expected a connection to take back its own name, got %v", err)
+	}
+	if getRouteAliasTarget(alias) != "" {
+		t.Errorf("expected the old alias to be removed")
+	}
+
+	if err := RenameConn("user@host1", "prod", true); err != nil {
+		t.Fatalf("error renaming: %v", err)
+	}
+	conn1.WithLock(conn1.close_nolock)
+	if getRouteAliasTarget(alias) != "" || conn1.RouteAlias != "" {
+		t.Errorf("expected the alias to be removed when the connection closes")
+	}
+	if conn1.DeriveConnStatus().Label != "prod" {
+		t.Errorf("expected the label to survive a disconnect")
+	}
+}
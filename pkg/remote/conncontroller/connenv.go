@@ -0,0 +1,90 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/genconn"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+)
+
+func (conn *SSHConn) GetEnvCache() *envutil.ConnEnvCache {
+	conn.Lock.Lock()
+	defer conn.Lock.Unlock()
+	return conn.EnvCache
+}
+
+func resolveConnEnvCache(ctx context.Context, client *ssh.Client) (*envutil.ConnEnvCache, error) {
+	shellClient := genconn.MakeSSHShellClient(client)
+	envOut, stderr, err := genconn.RunSimpleCommand(ctx, shellClient, genconn.CommandSpec{Cmd: envutil.PrintEnvCmd})
+	if err != nil {
+		return nil, fmt.Errorf("error getting env: %w, stderr: %s", err, stderr)
+	}
+	env := envutil.ParseEnvOutput(envOut)
+	homeDir := env["HOME"]
+	if homeDir == "" {
+		homeOut, stderr, err := genconn.RunSimpleCommand(ctx, shellClient, genconn.CommandSpec{Cmd: `echo "$HOME"`})
+		if err != nil {
+			return nil, fmt.Errorf("error getting home dir: %w, stderr: %s", err, stderr)
+		}
+		homeDir = strings.TrimSpace(homeOut)
+	}
+	clientOs, clientArch, err := remote.GetClientPlatform(ctx, shellClient)
+	if err != nil {
+		return nil, err
+	}
+	shellPath, err := remote.DetectShell(client)
+	if err != nil {
+		return nil, fmt.Errorf("error detecting shell: %w", err)
+	}
+	return &envutil.ConnEnvCache{
+		ShellPath:  shellPath,
+		HomeDir:    homeDir,
+		ClientOs:   clientOs,
+		ClientArch: clientArch,
+		Env:        env,
+	}, nil
+}
+
+// eagerly resolves (and caches) the env, home dir, shell, and os of a connected connection
+func (conn *SSHConn) Warmup(ctx context.Context) error {
+	if conn.GetStatus() != Status_Connected {
+		return fmt.Errorf("cannot warm up %q when status is %q", conn.GetName(), conn.GetStatus())
+	}
+	return envutil.WarmupEnvCache(ctx, conn, resolveConnEnvCache)
+}
+
+// a close/reconnect while resolving invalidates the result
+func (conn *SSHConn) StoreEnvCache(client *ssh.Client, envCache *envutil.ConnEnvCache) bool {
+	conn.Lock.Lock()
+	defer conn.Lock.Unlock()
+	if conn.Status != Status_Connected || conn.Client != client || conn.EnvCache != nil {
+		return false
+	}
+	conn.EnvCache = envCache
+	return true
+}
+
+// connects if needed, then warms up the connection (nothing to do for local)
+func WarmupConnection(ctx context.Context, connName string) error {
+	if connName == "" {
+		return nil
+	}
+	err := EnsureConnection(ctx, connName)
+	if err != nil {
+		return err
+	}
+	connOpts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return fmt.Errorf("error parsing connection name: %w", err)
+	}
+	conn := GetConn(ctx, connOpts, false, &wshrpc.ConnKeywords{})
+	return conn.Warmup(ctx)
+}
@@ -0,0 +1,273 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/skeema/knownhosts"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	xknownhosts "golang.org/x/crypto/ssh/knownhosts"
+)
+
+// checks a proposed connection config (the "test connection" button) without saving it or prompting
+// the user.  it dials, checks the host key against known_hosts (nothing is written), and authenticates,
+// then closes the connection right away without opening a session.  publickey auth uses the agent and
+// unencrypted identity files.  a password is only sent to hosts whose key is already in known_hosts.
+
+const ConnValidateTimeout = 10 * time.Second
+const MaxConnValidateTimeout = 60 * time.Second
+
+type ConnValidateDialFn func(ctx context.Context, network string, addr string) (net.Conn, error)
+
+type validateHostKeyState struct {
+	unknown          bool
+	fingerprint      string
+	err              error
+	passwordWithheld bool
+}
+
+func connValidateFailure(rtn wshrpc.ConnValidateRtnData, category string, err error) wshrpc.ConnValidateRtnData {
+	rtn.Success = false
+	rtn.Category = category
+	rtn.Error = err.Error()
+	return rtn
+}
+
+func isConnRefusedError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	// windows reports WSAECONNREFUSED
+	return strings.Contains(err.Error(), "actively refused")
+}
+
+func classifyConnValidateError(ctx context.Context, err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.IsTimeout {
+		return wshrpc.ConnValidateFail_Dns
+	}
+	if isConnRefusedError(err) {
+		return wshrpc.ConnValidateFail_Refused
+	}
+	var netErr net.Error
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return wshrpc.ConnValidateFail_Timeout
+	}
+	return wshrpc.ConnValidateFail_Other
+}
+
+// unknown keys are accepted (and flagged), changed or revoked keys fail
+func makeValidateHostKeyCallback(sshKeywords *wshrpc.ConnKeywords, state *validateHostKeyState) (ssh.HostKeyCallback, HostKeyAlgorithms, error) {
+	knownHostsFiles, err := getKnownHostsFiles(sshKeywords)
+	if err != nil {
+		return nil, nil, err
+	}
+	// missing files are only created by a real connect
+	var existingFiles []string
+	for _, filename := range knownHostsFiles {
+		if _, err := os.Stat(filename); err == nil {
+			existingFiles = append(existingFiles, filename)
+		}
+	}
+	basicCallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return &xknownhosts.KeyError{}
+	}
+	hostKeyAlgorithms := func(hostWithPort string) (algos []string) {
+		return nil
+	}
+	if len(existingFiles) > 0 {
+		keyDb, err := knownhosts.NewDB(existingFiles...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("known_hosts formatting error: %w", err)
+		}
+		basicCallback = keyDb.HostKeyCallback()
+		hostKeyAlgorithms = keyDb.HostKeyAlgorithms
+	}
+	callback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		state.fingerprint = ssh.FingerprintSHA256(key)
+		err := basicCallback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+		var keyErr *xknownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				state.unknown = true
+				return nil
+			}
+			err = fmt.Errorf("remote host identification has changed")
+		}
+		state.err = err
+		return err
+	}
+	return callback, hostKeyAlgorithms, nil
+}
+
+// returns the auth methods in preferred order, along with a func to release the agent
+func makeValidateAuthMethods(sshKeywords *wshrpc.ConnKeywords, password string, state *validateHostKeyState) ([]ssh.AuthMethod, func()) {
+	var signers []ssh.Signer
+	closeFn := func() {}
+	if agentPath := utilfn.SafeDeref(sshKeywords.SshIdentityAgent); agentPath != "" {
+		if agentConn, err := net.Dial("unix", agentPath); err == nil {
+			closeFn = func() { agentConn.Close() }
+			signers, _ = agent.NewClient(agentConn).Signers()
+		}
+	}
+	for _, identityFile := range sshKeywords.SshIdentityFile {
+		filePath, err := wavebase.ExpandHomeDir(identityFile)
+		if err != nil {
+			continue
+		}
+		privateKey, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		// keys with a passphrase are skipped (there is no prompt)
+		if signer, err := ssh.ParsePrivateKey(privateKey); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	getPassword := func() (string, error) {
+		if state.unknown {
+			state.passwordWithheld = true
+			return "", fmt.Errorf("password not sent to an unknown host")
+		}
+		return password, nil
+	}
+	var authMethods []ssh.AuthMethod
+	for _, authMethodName := range sshKeywords.SshPreferredAuthentications {
+		switch authMethodName {
+		case "publickey":
+			if utilfn.SafeDeref(sshKeywords.SshPubkeyAuthentication) && len(signers) > 0 {
+				authMethods = append(authMethods, ssh.PublicKeys(signers...))
+			}
+		case "password":
+			if utilfn.SafeDeref(sshKeywords.SshPasswordAuthentication) && password != "" {
+				authMethods = append(authMethods, ssh.PasswordCallback(getPassword))
+			}
+		case "keyboard-interactive":
+			if utilfn.SafeDeref(sshKeywords.SshKbdInteractiveAuthentication) && password != "" {
+				authMethods = append(authMethods, ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+					answers := make([]string, len(questions))
+					for idx := range questions {
+						if echos[idx] {
+							return nil, fmt.Errorf("unexpected keyboard-interactive question %q", questions[idx])
+						}
+						secret, err := getPassword()
+						if err != nil {
+							return nil, err
+						}
+						answers[idx] = secret
+					}
+					return answers, nil
+				}))
+			}
+		}
+	}
+	return authMethods, closeFn
+}
+
+func validateConnKeywords(ctx context.Context, sshKeywords *wshrpc.ConnKeywords, password string, dialFn ConnValidateDialFn) wshrpc.ConnValidateRtnData {
+	rtn := wshrpc.ConnValidateRtnData{
+		User:     utilfn.SafeDeref(sshKeywords.SshUser),
+		HostName: utilfn.SafeDeref(sshKeywords.SshHostName),
+		Port:     utilfn.SafeDeref(sshKeywords.SshPort),
+	}
+	if len(sshKeywords.SshProxyJump) > 0 {
+		return connValidateFailure(rtn, wshrpc.ConnValidateFail_Other, fmt.Errorf("connections through a ProxyJump cannot be validated"))
+	}
+	hostKeyState := &validateHostKeyState{}
+	hostKeyCallback, hostKeyAlgorithms, err := makeValidateHostKeyCallback(sshKeywords, hostKeyState)
+	if err != nil {
+		return connValidateFailure(rtn, wshrpc.ConnValidateFail_Config, err)
+	}
+	authMethods, closeAgent := makeValidateAuthMethods(sshKeywords, password, hostKeyState)
+	defer closeAgent()
+
+	networkAddr := rtn.HostName + ":" + rtn.Port
+	conn, err := dialFn(ctx, "tcp", networkAddr)
+	if err != nil {
+		return connValidateFailure(rtn, classifyConnValidateError(ctx, err), err)
+	}
+	defer conn.Close()
+	// unblocks the handshake on timeout
+	stopFn := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stopFn()
+	clientConfig := &ssh.ClientConfig{
+		User:              rtn.User,
+		Auth:              authMethods,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: hostKeyAlgorithms(networkAddr),
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, networkAddr, clientConfig)
+	rtn.HostKeyUnknown = hostKeyState.unknown
+	rtn.HostKeyFingerprint = hostKeyState.fingerprint
+	if err != nil {
+		switch {
+		case hostKeyState.err != nil:
+			return connValidateFailure(rtn, wshrpc.ConnValidateFail_HostKey, hostKeyState.err)
+		case ctx.Err() != nil:
+			return connValidateFailure(rtn, classifyConnValidateError(ctx, ctx.Err()), err)
+		case hostKeyState.passwordWithheld:
+			return connValidateFailure(rtn, wshrpc.ConnValidateFail_HostKey, fmt.Errorf("the host key is not in known_hosts yet, so the password was not sent (connect once to confirm the host key)"))
+		case strings.Contains(err.Error(), "unable to authenticate"):
+			return connValidateFailure(rtn, wshrpc.ConnValidateFail_Auth, err)
+		}
+		return connValidateFailure(rtn, classifyConnValidateError(ctx, err), err)
+	}
+	ssh.NewClient(sshConn, chans, reqs).Close()
+	rtn.Success = true
+	return rtn
+}
+
+// resolves the keywords like a real connect (ssh config, then the proposed keywords, then the user/port
+// in the host) and runs the check.  dialFn is only replaced in tests.
+func ValidateConnection(ctx context.Context, data wshrpc.CommandConnValidateData, dialFn ConnValidateDialFn) wshrpc.ConnValidateRtnData {
+	startTime := time.Now()
+	timeout := ConnValidateTimeout
+	if data.TimeoutMs > 0 {
+		timeout = min(time.Duration(data.TimeoutMs)*time.Millisecond, MaxConnValidateTimeout)
+	}
+	ctx, cancelFn := context.WithTimeout(ctx, timeout)
+	defer cancelFn()
+	if dialFn == nil {
+		dialFn = (&net.Dialer{}).DialContext
+	}
+	var rtn wshrpc.ConnValidateRtnData
+	opts, err := ParseOpts(data.Host)
+	if err != nil {
+		rtn = connValidateFailure(rtn, wshrpc.ConnValidateFail_Config, err)
+	} else if sshConfigKeywords, err := findSshConfigKeywords(opts.SSHHost); err != nil {
+		rtn = connValidateFailure(rtn, wshrpc.ConnValidateFail_Config, fmt.Errorf("error reading ssh config: %w", err))
+	} else {
+		parsedKeywords := &wshrpc.ConnKeywords{}
+		if opts.SSHUser != "" {
+			parsedKeywords.SshUser = &opts.SSHUser
+		}
+		if opts.SSHPort != "" {
+			parsedKeywords.SshPort = &opts.SSHPort
+		}
+		sshKeywords := mergeKeywords(mergeKeywords(sshConfigKeywords, &data.Keywords), parsedKeywords)
+		sshKeywords.SshIdentityFile = append(slices.Clone(data.Keywords.SshIdentityFile), sshConfigKeywords.SshIdentityFile...)
+		rtn = validateConnKeywords(ctx, sshKeywords, data.Password, dialFn)
+	}
+	rtn.DurationMs = time.Since(startTime).Milliseconds()
+	if data.Password != "" {
+		rtn.Error = strings.ReplaceAll(rtn.Error, data.Password, "****")
+	}
+	return rtn
+}
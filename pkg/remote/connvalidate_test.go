@@ -0,0 +1,199 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+	xknownhosts "golang.org/x/crypto/ssh/knownhosts"
+)
+
+const testValidatePassword = "hunter2-secret"
+
+// a connection to a server that never answers (with a tcp remote address, like a dialed conn)
+type testPipeConn struct {
+	net.Conn
+}
+
+func (c testPipeConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 22}
+}
+
+func makeTestHostKey(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privKey)
+	if err != nil {
+		t.Fatalf("error creating signer: %v", err)
+	}
+	return signer
+}
+
+// the ssh server only accepts testValidatePassword.  it listens on loopback since the handshake
+// deadlocks over net.Pipe (both sides write their version first)
+func makeTestSshDialer(t *testing.T, hostKey ssh.Signer) ConnValidateDialFn {
+	t.Helper()
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == testValidatePassword {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			serverConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer serverConn.Close()
+				sshConn, chans, reqs, err := ssh.NewServerConn(serverConn, serverConfig)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				go func() {
+					for newChan := range chans {
+						newChan.Reject(ssh.Prohibited, "no sessions")
+					}
+				}()
+				sshConn.Wait()
+			}()
+		}
+	}()
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, listener.Addr().String())
+	}
+}
+
+func makeTestValidateKeywords(t *testing.T, knownHostsLines ...string) *wshrpc.ConnKeywords {
+	t.Helper()
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(knownHostsFile, []byte(strings.Join(knownHostsLines, "\n")+"\n"), 0600); err != nil {
+		t.Fatalf("error writing known_hosts: %v", err)
+	}
+	yes := true
+	user, hostName, port := "testuser", "testhost", "22"
+	return &wshrpc.ConnKeywords{
+		SshUser:                     &user,
+		SshHostName:                 &hostName,
+		SshPort:                     &port,
+		SshPasswordAuthentication:   &yes,
+		SshPreferredAuthentications: []string{"publickey", "password"},
+		SshUserKnownHostsFile:       []string{knownHostsFile},
+		SshGlobalKnownHostsFile:     []string{knownHostsFile},
+	}
+}
+
+func TestValidateConnDialFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialErr  error
+		expected string
+	}{
+		{"dns", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "testhost", IsNotFound: true}}, wshrpc.ConnValidateFail_Dns},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, wshrpc.ConnValidateFail_Refused},
+		{"other", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}, wshrpc.ConnValidateFail_Other},
+	}
+	for _, tc := range tests {
+		dialFn := func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			return nil, tc.dialErr
+		}
+		rtn := validateConnKeywords(context.Background(), makeTestValidateKeywords(t), testValidatePassword, dialFn)
+		if rtn.Success || rtn.Category != tc.expected {
+			t.Errorf("%s: expected category %q, got %+v", tc.name, tc.expected, rtn)
+		}
+	}
+}
+
+func TestValidateConnTimeout(t *testing.T) {
+	// the dial hangs
+	dialFn := func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelFn()
+	rtn := validateConnKeywords(ctx, makeTestValidateKeywords(t), "", dialFn)
+	if rtn.Category != wshrpc.ConnValidateFail_Timeout {
+		t.Errorf("dial timeout: got %+v", rtn)
+	}
+
+	// the server accepts the connection but never speaks
+	dialFn = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		clientConn, _ := net.Pipe()
+		return testPipeConn{clientConn}, nil
+	}
+	ctx, cancelFn = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelFn()
+	rtn = validateConnKeywords(ctx, makeTestValidateKeywords(t), "", dialFn)
+	if rtn.Category != wshrpc.ConnValidateFail_Timeout {
+		t.Errorf("handshake timeout: got %+v", rtn)
+	}
+}
+
+func TestValidateConnAuth(t *testing.T) {
+	hostKey := makeTestHostKey(t)
+	knownLine := xknownhosts.Line([]string{xknownhosts.Normalize("testhost:22")}, hostKey.PublicKey())
+	dialFn := makeTestSshDialer(t, hostKey)
+
+	rtn := validateConnKeywords(context.Background(), makeTestValidateKeywords(t, knownLine), "wrong-password", dialFn)
+	if rtn.Category != wshrpc.ConnValidateFail_Auth || strings.Contains(rtn.Error, "wrong-password") {
+		t.Errorf("wrong password: got %+v", rtn)
+	}
+	rtn = validateConnKeywords(context.Background(), makeTestValidateKeywords(t, knownLine), testValidatePassword, dialFn)
+	if !rtn.Success || rtn.HostKeyUnknown || rtn.User != "testuser" {
+		t.Errorf("expected success, got %+v", rtn)
+	}
+
+	// the password is never sent to a host missing from known_hosts
+	rtn = validateConnKeywords(context.Background(), makeTestValidateKeywords(t), testValidatePassword, dialFn)
+	if rtn.Category != wshrpc.ConnValidateFail_HostKey || !rtn.HostKeyUnknown || rtn.HostKeyFingerprint != ssh.FingerprintSHA256(hostKey.PublicKey()) {
+		t.Errorf("unknown host: got %+v", rtn)
+	}
+	otherKey := makeTestHostKey(t)
+	changedLine := xknownhosts.Line([]string{xknownhosts.Normalize("testhost:22")}, otherKey.PublicKey())
+	rtn = validateConnKeywords(context.Background(), makeTestValidateKeywords(t, changedLine), testValidatePassword, dialFn)
+	if rtn.Category != wshrpc.ConnValidateFail_HostKey || rtn.HostKeyUnknown {
+		t.Errorf("changed host key: got %+v", rtn)
+	}
+}
+
+func TestValidateConnectionRedactsPassword(t *testing.T) {
+	dialFn := func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		return nil, fmt.Errorf("proxy rejected %s", testValidatePassword)
+	}
+	data := wshrpc.CommandConnValidateData{
+		Host:     "testuser@testhost",
+		Keywords: *makeTestValidateKeywords(t),
+		Password: testValidatePassword,
+	}
+	rtn := ValidateConnection(context.Background(), data, dialFn)
+	if rtn.Success || strings.Contains(rtn.Error, testValidatePassword) {
+		t.Errorf("expected a redacted failure, got %+v", rtn)
+	}
+}
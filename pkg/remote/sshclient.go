@@ -387,13 +387,14 @@ func lineContainsMatch(line []byte, matches [][]byte) bool {
 	return false
 }
 
-func createHostKeyCallback(sshKeywords *wshrpc.ConnKeywords) (ssh.HostKeyCallback, HostKeyAlgorithms, error) {
+// returns the expanded known_hosts files to check (root only uses the global files)
+func getKnownHostsFiles(sshKeywords *wshrpc.ConnKeywords) ([]string, error) {
 	globalKnownHostsFiles := sshKeywords.SshGlobalKnownHostsFile
 	userKnownHostsFiles := sshKeywords.SshUserKnownHostsFile
 
 	osUser, err := user.Current()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	var unexpandedKnownHostsFiles []string
 	if osUser.Username == "root" {
@@ -413,7 +414,15 @@ func createHostKeyCallback(sshKeywords *wshrpc.ConnKeywords) (ssh.HostKeyCallbac
 
 	// there are no good known hosts files
 	if len(knownHostsFiles) == 0 {
-		return nil, nil, fmt.Errorf("no known_hosts files provided by ssh. defaults are overridden")
+		return nil, fmt.Errorf("no known_hosts files provided by ssh. defaults are overridden")
+	}
+	return knownHostsFiles, nil
+}
+
+func createHostKeyCallback(sshKeywords *wshrpc.ConnKeywords) (ssh.HostKeyCallback, HostKeyAlgorithms, error) {
+	knownHostsFiles, err := getKnownHostsFiles(sshKeywords)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var unreadableFiles []string
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"time"
 
 	"github.com/wavetermdev/waveterm/pkg/filestore"
@@ -31,18 +32,16 @@ type FullFile struct {
 func (fs *FileService) SaveFile_Meta() tsgenmeta.MethodMeta {
 	return tsgenmeta.MethodMeta{
 		Desc:     "save file",
-		ArgNames: []string{"connection", "path", "data64"},
+		ArgNames: []string{"ctx", "connection", "path", "data64"},
 	}
 }
 
-func (fs *FileService) SaveFile(connection string, path string, data64 string) error {
-	if connection == "" {
-		connection = wshrpc.LocalConnName
+func (fs *FileService) SaveFile(ctx context.Context, connection string, path string, data64 string) error {
+	data, err := base64.StdEncoding.DecodeString(data64)
+	if err != nil {
+		return fmt.Errorf("error decoding file data: %w", err)
 	}
-	connRoute := wshutil.MakeConnectionRouteId(connection)
-	client := wshserver.GetMainRpcClient()
-	writeData := wshrpc.CommandRemoteWriteFileData{Path: path, Data64: data64}
-	return wshclient.RemoteWriteFileCommand(client, writeData, &wshrpc.RpcOpts{Route: connRoute})
+	return wshserver.WriteConnFile(ctx, connection, path, data, 0)
 }
 
 func (fs *FileService) StatFile_Meta() tsgenmeta.MethodMeta {
@@ -67,7 +66,7 @@ func (fs *FileService) Mkdir(connection string, path string) error {
 	}
 	connRoute := wshutil.MakeConnectionRouteId(connection)
 	client := wshserver.GetMainRpcClient()
-	return wshclient.RemoteMkdirCommand(client, path, &wshrpc.RpcOpts{Route: connRoute})
+	return wshclient.RemoteMkdirCommand(client, wshrpc.CommandRemoteMkdirData{Path: path, Recursive: true, MustCreate: true}, &wshrpc.RpcOpts{Route: connRoute})
 }
 
 func (fs *FileService) TouchFile(connection string, path string) error {
@@ -85,7 +84,8 @@ func (fs *FileService) Rename(connection string, path string, newPath string) er
 	}
 	connRoute := wshutil.MakeConnectionRouteId(connection)
 	client := wshserver.GetMainRpcClient()
-	return wshclient.RemoteFileRenameCommand(client, [2]string{path, newPath}, &wshrpc.RpcOpts{Route: connRoute})
+	_, err := wshclient.RemoteFileRenameCommand(client, wshrpc.CommandRemoteFileRenameData{SrcPath: path, DestPath: newPath}, &wshrpc.RpcOpts{Route: connRoute})
+	return err
 }
 
 func (fs *FileService) ReadFile_Meta() tsgenmeta.MethodMeta {
@@ -101,6 +101,9 @@ func (fs *FileService) ReadFile(connection string, path string) (*FullFile, erro
 	}
 	connRoute := wshutil.MakeConnectionRouteId(connection)
 	client := wshserver.GetMainRpcClient()
+	if err := wshserver.ApplyConnReadLimit(connection); err != nil {
+		log.Printf("readfile: %v\n", err)
+	}
 	streamFileData := wshrpc.CommandRemoteStreamFileData{Path: path}
 	rtnCh := wshclient.RemoteStreamFileCommand(client, streamFileData, &wshrpc.RpcOpts{Route: connRoute})
 	fullFile := &FullFile{}
@@ -135,7 +138,8 @@ func (fs *FileService) ReadFile(connection string, path string) (*FullFile, erro
 				continue
 			}
 			decoder := base64.NewDecoder(base64.StdEncoding, bytes.NewReader([]byte(resp.Data64)))
-			_, err := io.Copy(&fileBuf, decoder)
+			n, err := io.Copy(&fileBuf, decoder)
+			wshserver.RecordConnRead(connection, int(n))
 			if err != nil {
 				return nil, fmt.Errorf("stream file, failed to decode base64 data %q: %w", resp.Data64, err)
 			}
@@ -177,7 +181,8 @@ func (fs *FileService) DeleteFile(connection string, path string) error {
 	}
 	connRoute := wshutil.MakeConnectionRouteId(connection)
 	client := wshserver.GetMainRpcClient()
-	return wshclient.RemoteFileDeleteCommand(client, path, &wshrpc.RpcOpts{Route: connRoute})
+	_, err := wshclient.RemoteFileDeleteCommand(client, wshrpc.CommandRemoteFileDeleteData{Path: path}, &wshrpc.RpcOpts{Route: connRoute})
+	return err
 }
 
 func (fs *FileService) GetFullConfig() wconfig.FullConfigType {
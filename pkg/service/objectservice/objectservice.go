@@ -171,10 +171,18 @@ func (svc *ObjectService) UpdateObject(uiContext waveobj.UIContext, waveObj wave
 	if !found {
 		return nil, fmt.Errorf("object not found: %s", oref)
 	}
+	layoutState, isLayoutState := waveObj.(*waveobj.LayoutState)
+	var oldFocusedBlockId string
+	if isLayoutState {
+		oldFocusedBlockId = wcore.GetLayoutStateFocusedBlock(ctx, layoutState.OID)
+	}
 	err = wstore.DBUpdate(ctx, waveObj)
 	if err != nil {
 		return nil, fmt.Errorf("error updating object: %w", err)
 	}
+	if isLayoutState {
+		wcore.PublishLayoutStateFocusChange(ctx, oldFocusedBlockId, layoutState)
+	}
 	if (waveObj.GetOType() == waveobj.OType_Workspace) && (waveObj.(*waveobj.Workspace).Name != "") {
 		wps.Broker.Publish(wps.WaveEvent{
 			Event: wps.Event_WorkspaceUpdate})
@@ -49,6 +49,24 @@ type ShellProc struct {
 	WaitErr   error    // WaitErr is synchronized by DoneCh (written before DoneCh is closed) and CloseOnce
 }
 
+// returns the local process id, or 0 if unknown (remote sessions)
+func (sp *ShellProc) Pid() int {
+	if cw, ok := sp.Cmd.(CmdWrap); ok && cw.Cmd.Process != nil {
+		return cw.Cmd.Process.Pid
+	}
+	return 0
+}
+
+func (sp *ShellProc) CmdLine() string {
+	switch cmd := sp.Cmd.(type) {
+	case CmdWrap:
+		return strings.Join(cmd.Cmd.Args, " ")
+	case SessionWrap:
+		return cmd.StartCmd
+	}
+	return ""
+}
+
 func (sp *ShellProc) Close() {
 	sp.Cmd.KillGraceful(DefaultGracefulKillWait)
 	go func() {
@@ -151,7 +169,11 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 	utilCtx, cancelFn := context.WithTimeout(ctx, 2*time.Second)
 	defer cancelFn()
 	client := conn.GetClient()
+	envCache := conn.GetEnvCache()
 	shellPath := cmdOpts.ShellPath
+	if shellPath == "" && envCache != nil {
+		shellPath = envCache.ShellPath
+	}
 	if shellPath == "" {
 		remoteShellPath, err := wsl.DetectShell(utilCtx, client)
 		if err != nil {
@@ -168,7 +190,12 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 		return nil, err
 	}
 
-	homeDir := wsl.GetHomeDir(utilCtx, client)
+	var homeDir string
+	if envCache != nil {
+		homeDir = envCache.HomeDir
+	} else {
+		homeDir = wsl.GetHomeDir(utilCtx, client)
+	}
 	shellOpts = append(shellOpts, "~", "-d", client.Name())
 
 	var subShellOpts []string
@@ -212,10 +239,17 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 	if !ok {
 		return nil, fmt.Errorf("no jwt token provided to connection")
 	}
+	signKey := cmdOpts.Env[wshutil.WaveRpcSignKeyVarName]
 	if remote.IsPowershell(shellPath) {
 		shellOpts = append(shellOpts, "--", fmt.Sprintf(`$env:%s=%s;`, wshutil.WaveJwtTokenVarName, jwtToken))
+		if signKey != "" {
+			shellOpts = append(shellOpts, fmt.Sprintf(`$env:%s=%s;`, wshutil.WaveRpcSignKeyVarName, signKey))
+		}
 	} else {
 		shellOpts = append(shellOpts, "--", fmt.Sprintf(`%s=%s`, wshutil.WaveJwtTokenVarName, jwtToken))
+		if signKey != "" {
+			shellOpts = append(shellOpts, fmt.Sprintf(`%s=%s`, wshutil.WaveRpcSignKeyVarName, signKey))
+		}
 	}
 
 	if isZshShell(shellPath) {
@@ -285,7 +319,11 @@ func StartRemoteShellProcNoWsh(termSize waveobj.TermSize, cmdStr string, cmdOpts
 
 func StartRemoteShellProc(termSize waveobj.TermSize, cmdStr string, cmdOpts CommandOptsType, conn *conncontroller.SSHConn) (*ShellProc, error) {
 	client := conn.GetClient()
+	envCache := conn.GetEnvCache()
 	shellPath := cmdOpts.ShellPath
+	if shellPath == "" && envCache != nil {
+		shellPath = envCache.ShellPath
+	}
 	if shellPath == "" {
 		remoteShellPath, err := remote.DetectShell(client)
 		if err != nil {
@@ -304,7 +342,12 @@ func StartRemoteShellProc(termSize waveobj.TermSize, cmdStr string, cmdOpts Comm
 	}
 	shellOpts = append(shellOpts, cmdOpts.ShellOpts...)
 
-	homeDir := remote.GetHomeDir(client)
+	var homeDir string
+	if envCache != nil {
+		homeDir = envCache.HomeDir
+	} else {
+		homeDir = remote.GetHomeDir(client)
+	}
 
 	if cmdStr == "" {
 		/* transform command in order to inject environment vars */
@@ -381,9 +424,16 @@ func StartRemoteShellProc(termSize waveobj.TermSize, cmdStr string, cmdOpts Comm
 		return nil, fmt.Errorf("no jwt token provided to connection")
 	}
 
+	signKey := cmdOpts.Env[wshutil.WaveRpcSignKeyVarName]
 	if remote.IsPowershell(shellPath) {
+		if signKey != "" {
+			cmdCombined = fmt.Sprintf(`$env:%s="%s"; %s`, wshutil.WaveRpcSignKeyVarName, signKey, cmdCombined)
+		}
 		cmdCombined = fmt.Sprintf(`$env:%s="%s"; %s`, wshutil.WaveJwtTokenVarName, jwtToken, cmdCombined)
 	} else {
+		if signKey != "" {
+			cmdCombined = fmt.Sprintf(`%s=%s %s`, wshutil.WaveRpcSignKeyVarName, signKey, cmdCombined)
+		}
 		cmdCombined = fmt.Sprintf(`%s=%s %s`, wshutil.WaveJwtTokenVarName, jwtToken, cmdCombined)
 	}
 
@@ -0,0 +1,49 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package envutil
+
+import (
+	"context"
+	"fmt"
+)
+
+// resolved once per connection (cleared on close), used to skip the lazy lookups on later operations
+type ConnEnvCache struct {
+	ShellPath  string
+	HomeDir    string
+	ClientOs   string
+	ClientArch string
+	Env        map[string]string
+}
+
+// a connection (ssh or wsl) that caches its env, C is its client type
+type EnvCacheConn[C comparable] interface {
+	GetName() string
+	GetClient() C
+	GetEnvCache() *ConnEnvCache
+	// stores envCache if the connection is still connected on client, returns true if it was stored
+	StoreEnvCache(client C, envCache *ConnEnvCache) bool
+	FireConnChangeEvent()
+}
+
+// resolves (with resolveFn) and caches the env of a connected connection, a no-op if it is already cached.
+// a close/reconnect while resolving invalidates the result (StoreEnvCache fails and it is discarded).
+func WarmupEnvCache[C comparable](ctx context.Context, conn EnvCacheConn[C], resolveFn func(context.Context, C) (*ConnEnvCache, error)) error {
+	if conn.GetEnvCache() != nil {
+		return nil
+	}
+	client := conn.GetClient()
+	var nilClient C
+	if client == nilClient {
+		return fmt.Errorf("client is nil")
+	}
+	envCache, err := resolveFn(ctx, client)
+	if err != nil {
+		return fmt.Errorf("error warming up connection %q: %w", conn.GetName(), err)
+	}
+	if conn.StoreEnvCache(client, envCache) {
+		conn.FireConnChangeEvent()
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package envutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testClient struct {
+	name string
+}
+
+type testEnvCacheConn struct {
+	client     *testClient
+	envCache   *ConnEnvCache
+	numEvents  int
+	reconnects bool // the client is replaced while resolving
+}
+
+func (conn *testEnvCacheConn) GetName() string            { return "test" }
+func (conn *testEnvCacheConn) GetClient() *testClient     { return conn.client }
+func (conn *testEnvCacheConn) GetEnvCache() *ConnEnvCache { return conn.envCache }
+func (conn *testEnvCacheConn) FireConnChangeEvent()       { conn.numEvents++ }
+
+func (conn *testEnvCacheConn) StoreEnvCache(client *testClient, envCache *ConnEnvCache) bool {
+	if conn.client != client || conn.envCache != nil {
+		return false
+	}
+	conn.envCache = envCache
+	return true
+}
+
+func TestWarmupEnvCache(t *testing.T) {
+	cached := &ConnEnvCache{HomeDir: "/cached"}
+	tests := []struct {
+		name           string
+		conn           *testEnvCacheConn
+		resolveErr     error
+		expectedErr    bool
+		expectedHome   string
+		expectedEvents int
+		expectedCalls  int
+	}{
+		{"resolves", &testEnvCacheConn{client: &testClient{}}, nil, false, "/home/test", 1, 1},
+		{"already cached", &testEnvCacheConn{client: &testClient{}, envCache: cached}, nil, false, "/cached", 0, 0},
+		{"nil client", &testEnvCacheConn{}, nil, true, "", 0, 0},
+		{"resolve error", &testEnvCacheConn{client: &testClient{}}, errors.New("unreachable"), true, "", 0, 1},
+		{"reconnected while resolving", &testEnvCacheConn{client: &testClient{}, reconnects: true}, nil, false, "", 0, 1},
+	}
+	for _, tc := range tests {
+		numCalls := 0
+		resolveFn := func(ctx context.Context, client *testClient) (*ConnEnvCache, error) {
+			numCalls++
+			if tc.conn.reconnects {
+				tc.conn.client = &testClient{}
+			}
+			if tc.resolveErr != nil {
+				return nil, tc.resolveErr
+			}
+			return &ConnEnvCache{HomeDir: "/home/test"}, nil
+		}
+		err := WarmupEnvCache(context.Background(), tc.conn, resolveFn)
+		if (err != nil) != tc.expectedErr {
+			t.Errorf("%s: expected err:%v, got %v", tc.name, tc.expectedErr, err)
+		}
+		if tc.resolveErr != nil && !errors.Is(err, tc.resolveErr) {
+			t.Errorf("%s: expected the resolve error to be wrapped, got %v", tc.name, err)
+		}
+		var homeDir string
+		if tc.conn.envCache != nil {
+			homeDir = tc.conn.envCache.HomeDir
+		}
+		if homeDir != tc.expectedHome || tc.conn.numEvents != tc.expectedEvents || numCalls != tc.expectedCalls {
+			t.Errorf("%s: expected home:%q events:%d calls:%d, got home:%q events:%d calls:%d", tc.name, tc.expectedHome, tc.expectedEvents, tc.expectedCalls, homeDir, tc.conn.numEvents, numCalls)
+		}
+	}
+}
@@ -67,3 +67,14 @@ func RmEnv(envStr string, key string) string {
 	delete(envMap, key)
 	return MapToEnv(envMap)
 }
+
+// prints the env NUL separated when supported (falls back to newline separated output)
+const PrintEnvCmd = "env -0 2>/dev/null || env"
+
+// parses the output of PrintEnvCmd
+func ParseEnvOutput(output string) map[string]string {
+	if !strings.Contains(output, "\x00") {
+		output = strings.ReplaceAll(output, "\n", "\x00")
+	}
+	return EnvToMap(output)
+}
@@ -0,0 +1,134 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// token bucket (bytes/sec) used to cap connection bandwidth, plus a sliding-window throughput meter.
+// a wait larger than the bucket puts it into debt rather than failing, so big chunks just slow the
+// caller down (later callers wait for the debt to be paid off).
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// how much can be sent at once after an idle period
+const BurstSecs = 0.25
+
+const MeterWindow = 5 * time.Second
+
+type TokenBucket struct {
+	lock    sync.Mutex
+	rate    float64 // bytes/sec, <= 0 is unlimited
+	tokens  float64 // can go negative (debt)
+	lastAdd time.Time
+}
+
+func MakeTokenBucket(rate int64) *TokenBucket {
+	return &TokenBucket{rate: float64(rate), tokens: float64(rate) * BurstSecs, lastAdd: time.Now()}
+}
+
+func (tb *TokenBucket) refill_nolock(now time.Time) {
+	if tb.rate <= 0 {
+		tb.tokens = 0
+		tb.lastAdd = now
+		return
+	}
+	tb.tokens = min(tb.tokens+now.Sub(tb.lastAdd).Seconds()*tb.rate, tb.rate*BurstSecs)
+	tb.lastAdd = now
+}
+
+// changes the rate, outstanding debt is kept (at the new rate)
+func (tb *TokenBucket) SetRate(rate int64) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+	if float64(rate) == tb.rate {
+		return
+	}
+	tb.refill_nolock(time.Now())
+	tb.rate = float64(rate)
+	tb.tokens = min(tb.tokens, tb.rate*BurstSecs)
+}
+
+func (tb *TokenBucket) GetRate() int64 {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+	return int64(tb.rate)
+}
+
+// takes n tokens and returns how long the caller must wait before using them
+func (tb *TokenBucket) reserve(n int) time.Duration {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+	now := time.Now()
+	tb.refill_nolock(now)
+	if tb.rate <= 0 {
+		return 0
+	}
+	tb.tokens -= float64(n)
+	if tb.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+}
+
+// blocks until n bytes may be sent, only returns an error if ctx is done
+func (tb *TokenBucket) Wait(ctx context.Context, n int) error {
+	delay := tb.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bytes per second over the last MeterWindow (in one second buckets), plus the running total
+type Meter struct {
+	lock    sync.Mutex
+	total   int64
+	buckets [int(MeterWindow / time.Second)]int64
+	lastSec int64
+}
+
+func (m *Meter) advance_nolock(nowSec int64) {
+	if nowSec-m.lastSec >= int64(len(m.buckets)) {
+		clear(m.buckets[:])
+	} else {
+		for sec := m.lastSec + 1; sec <= nowSec; sec++ {
+			m.buckets[sec%int64(len(m.buckets))] = 0
+		}
+	}
+	m.lastSec = max(m.lastSec, nowSec)
+}
+
+func (m *Meter) Add(n int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nowSec := time.Now().Unix()
+	m.advance_nolock(nowSec)
+	m.buckets[nowSec%int64(len(m.buckets))] += int64(n)
+	m.total += int64(n)
+}
+
+func (m *Meter) Rate() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.advance_nolock(time.Now().Unix())
+	var sum int64
+	for _, val := range m.buckets {
+		sum += val
+	}
+	return sum / int64(len(m.buckets))
+}
+
+func (m *Meter) Total() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.total
+}
@@ -0,0 +1,47 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketCap(t *testing.T) {
+	const rate = 1024 * 1024
+	const total = 1024 * 1024
+	const chunkSize = 64 * 1024
+	tb := MakeTokenBucket(rate)
+	var meter Meter
+	start := time.Now()
+	for sent := 0; sent < total; sent += chunkSize {
+		if err := tb.Wait(context.Background(), chunkSize); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		meter.Add(chunkSize)
+	}
+	elapsed := time.Since(start)
+	// the initial burst goes out immediately, the rest at the configured rate
+	expected := time.Duration(float64(total-rate*BurstSecs) / rate * float64(time.Second))
+	if elapsed < expected*9/10 || elapsed > expected*3/2 {
+		t.Errorf("transfer took %v, expected about %v", elapsed, expected)
+	}
+	if meter.Total() != total {
+		t.Errorf("meter total: got %d, want %d", meter.Total(), total)
+	}
+
+	// waiting is interrupted by the context, and an unlimited bucket never waits
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tb.Wait(ctx, 10*rate); err == nil {
+		t.Errorf("expected the wait to be cancelled")
+	}
+	tb.SetRate(0)
+	start = time.Now()
+	tb.Wait(context.Background(), 100*rate)
+	if time.Since(start) > 10*time.Millisecond {
+		t.Errorf("unlimited bucket should not wait")
+	}
+}
@@ -21,16 +21,29 @@ type AnthropicBackend struct{}
 
 var _ AIBackend = AnthropicBackend{}
 
+// the api allows at most 4 cache_control breakpoints per request
+const AnthropicMaxCacheBreakpoints = 4
+
 // Claude API request types
+type anthropicCacheControl struct {
+	Type string `json:"type"` // "ephemeral"
+}
+
+type anthropicTextBlock struct {
+	Type         string                 `json:"type"` // "text"
+	Text         string                 `json:"text"`
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
 type anthropicMessage struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"` // a string, or []anthropicTextBlock when it carries a cache breakpoint
 }
 
 type anthropicRequest struct {
 	Model       string             `json:"model"`
 	Messages    []anthropicMessage `json:"messages"`
-	System      string             `json:"system,omitempty"`
+	System      any                `json:"system,omitempty"` // a string, or []anthropicTextBlock when it carries a cache breakpoint
 	MaxTokens   int                `json:"max_tokens,omitempty"`
 	Stream      bool               `json:"stream"`
 	Temperature float32            `json:"temperature,omitempty"`
@@ -43,8 +56,84 @@ type anthropicContentBlock struct {
 }
 
 type anthropicUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"` // does not include the cache tokens
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+func (u *anthropicUsage) toWaveAIUsage() *wshrpc.WaveAIUsageType {
+	promptTokens := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &wshrpc.WaveAIUsageType{
+		PromptTokens:     promptTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      promptTokens + u.OutputTokens,
+		CachedTokens:     u.CacheReadInputTokens,
+	}
+}
+
+func makeAnthropicCachedContent(text string) []anthropicTextBlock {
+	return []anthropicTextBlock{{Type: "text", Text: text, CacheControl: &anthropicCacheControl{Type: "ephemeral"}}}
+}
+
+// converts the prompt, system messages are joined into the system prompt.  CacheHint messages become
+// cache_control breakpoints (a hinted system message marks the whole system prompt).
+func makeAnthropicRequest(request wshrpc.WaveAIStreamRequest, model string) anthropicRequest {
+	// keep the latest breakpoints, the system prompt is the earliest prefix so it only gets a leftover one
+	budget := AnthropicMaxCacheBreakpoints
+	cacheIdxs := make(map[int]bool)
+	for idx := len(request.Prompt) - 1; idx >= 0 && budget > 0; idx-- {
+		msg := request.Prompt[idx]
+		if msg.CacheHint && msg.Role != "system" {
+			cacheIdxs[idx] = true
+			budget--
+		}
+	}
+	var systemHinted bool
+	for _, msg := range request.Prompt {
+		if msg.CacheHint && msg.Role == "system" && budget > 0 {
+			systemHinted = true
+		}
+	}
+	var messages []anthropicMessage
+	var systemPrompt string
+	for idx, msg := range request.Prompt {
+		if msg.Role == "system" {
+			if systemPrompt != "" {
+				systemPrompt += "\n"
+			}
+			systemPrompt += msg.Content
+			continue
+		}
+
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "assistant"
+		}
+
+		var content any = msg.Content
+		if cacheIdxs[idx] {
+			content = makeAnthropicCachedContent(msg.Content)
+		}
+		messages = append(messages, anthropicMessage{
+			Role:    role,
+			Content: content,
+		})
+	}
+
+	rtn := anthropicRequest{
+		Model:     model,
+		Messages:  messages,
+		Stream:    true,
+		MaxTokens: request.Opts.MaxTokens,
+	}
+	if systemPrompt != "" {
+		rtn.System = systemPrompt
+		if systemHinted {
+			rtn.System = makeAnthropicCachedContent(systemPrompt)
+		}
+	}
+	return rtn
 }
 
 type anthropicResponseMessage struct {
@@ -131,37 +220,7 @@ func (AnthropicBackend) StreamCompletion(ctx context.Context, request wshrpc.Wav
 			model = "claude-3-sonnet-20250229" // default model
 		}
 
-		// Convert messages format
-		var messages []anthropicMessage
-		var systemPrompt string
-
-		for _, msg := range request.Prompt {
-			if msg.Role == "system" {
-				if systemPrompt != "" {
-					systemPrompt += "\n"
-				}
-				systemPrompt += msg.Content
-				continue
-			}
-
-			role := "user"
-			if msg.Role == "assistant" {
-				role = "assistant"
-			}
-
-			messages = append(messages, anthropicMessage{
-				Role:    role,
-				Content: msg.Content,
-			})
-		}
-
-		anthropicReq := anthropicRequest{
-			Model:     model,
-			Messages:  messages,
-			System:    systemPrompt,
-			Stream:    true,
-			MaxTokens: request.Opts.MaxTokens,
-		}
+		anthropicReq := makeAnthropicRequest(request, model)
 
 		reqBody, err := json.Marshal(anthropicReq)
 		if err != nil {
@@ -180,7 +239,7 @@ func (AnthropicBackend) StreamCompletion(ctx context.Context, request wshrpc.Wav
 		req.Header.Set("x-api-key", request.Opts.APIToken)
 		req.Header.Set("anthropic-version", "2023-06-01")
 
-		client := &http.Client{}
+		client := makeAIHttpClient(request, rtn)
 		resp, err := client.Do(req)
 		if err != nil {
 			rtn <- makeAIError(fmt.Errorf("failed to send anthropic request: %v", err))
@@ -194,6 +253,10 @@ func (AnthropicBackend) StreamCompletion(ctx context.Context, request wshrpc.Wav
 			return
 		}
 
+		if quotaPk := makeQuotaPacket(parseAnthropicQuota(resp.Header)); quotaPk != nil {
+			rtn <- *quotaPk
+		}
+
 		reader := bufio.NewReader(resp.Body)
 		for {
 			// Check for context cancellation
@@ -259,11 +322,7 @@ func (AnthropicBackend) StreamCompletion(ctx context.Context, request wshrpc.Wav
 				// Update message metadata, usage stats
 				if event.Usage != nil {
 					pk := MakeWaveAIPacket()
-					pk.Usage = &wshrpc.WaveAIUsageType{
-						PromptTokens:     event.Usage.InputTokens,
-						CompletionTokens: event.Usage.OutputTokens,
-						TotalTokens:      event.Usage.InputTokens + event.Usage.OutputTokens,
-					}
+					pk.Usage = event.Usage.toWaveAIUsage()
 					rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
 				}
 
@@ -272,11 +331,7 @@ func (AnthropicBackend) StreamCompletion(ctx context.Context, request wshrpc.Wav
 					pk := MakeWaveAIPacket()
 					pk.FinishReason = event.Message.StopReason
 					if event.Message.Usage != nil {
-						pk.Usage = &wshrpc.WaveAIUsageType{
-							PromptTokens:     event.Message.Usage.InputTokens,
-							CompletionTokens: event.Message.Usage.OutputTokens,
-							TotalTokens:      event.Message.Usage.InputTokens + event.Message.Usage.OutputTokens,
-						}
+						pk.Usage = event.Message.Usage.toWaveAIUsage()
 					}
 					rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
 				}
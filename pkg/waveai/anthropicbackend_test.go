@@ -0,0 +1,74 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestAnthropicCacheHints(t *testing.T) {
+	request := wshrpc.WaveAIStreamRequest{
+		Opts: &wshrpc.WaveAIOptsType{MaxTokens: 100},
+		Prompt: []wshrpc.WaveAIPromptMessageType{
+			{Role: "system", Content: "long system prompt", CacheHint: true},
+			{Role: "user", Content: "context", CacheHint: true},
+			{Role: "assistant", Content: "ok"},
+			{Role: "user", Content: "question"},
+		},
+	}
+	barr, _ := json.Marshal(makeAnthropicRequest(request, "model"))
+	expected := `{"model":"model","messages":[` +
+		`{"role":"user","content":[{"type":"text","text":"context","cache_control":{"type":"ephemeral"}}]},` +
+		`{"role":"assistant","content":"ok"},{"role":"user","content":"question"}],` +
+		`"system":[{"type":"text","text":"long system prompt","cache_control":{"type":"ephemeral"}}],"max_tokens":100,"stream":true}`
+	if string(barr) != expected {
+		t.Errorf("unexpected request:\n got %s\nwant %s", barr, expected)
+	}
+
+	// without hints the request is unchanged (plain string content)
+	for idx := range request.Prompt {
+		request.Prompt[idx].CacheHint = false
+	}
+	barr, _ = json.Marshal(makeAnthropicRequest(request, "model"))
+	if strings.Contains(string(barr), "cache_control") || !strings.Contains(string(barr), `"system":"long system prompt"`) {
+		t.Errorf("unexpected request without hints: %s", barr)
+	}
+}
+
+func TestAnthropicCacheHintsLimit(t *testing.T) {
+	request := wshrpc.WaveAIStreamRequest{
+		Opts:   &wshrpc.WaveAIOptsType{},
+		Prompt: []wshrpc.WaveAIPromptMessageType{{Role: "system", Content: "sys", CacheHint: true}},
+	}
+	for idx := 0; idx < 6; idx++ {
+		request.Prompt = append(request.Prompt, wshrpc.WaveAIPromptMessageType{Role: "user", Content: "msg", CacheHint: true})
+	}
+	anthropicReq := makeAnthropicRequest(request, "model")
+	if _, ok := anthropicReq.System.(string); !ok {
+		t.Errorf("expected the system prompt to lose its breakpoint, got %#v", anthropicReq.System)
+	}
+	var numCached int
+	for idx, msg := range anthropicReq.Messages {
+		if _, ok := msg.Content.([]anthropicTextBlock); ok {
+			numCached++
+			if idx < 2 {
+				t.Errorf("expected the latest hints to be kept, message %d has a breakpoint", idx)
+			}
+		}
+	}
+	if numCached != AnthropicMaxCacheBreakpoints {
+		t.Errorf("expected %d breakpoints, got %d", AnthropicMaxCacheBreakpoints, numCached)
+	}
+}
+
+func TestAnthropicCachedUsage(t *testing.T) {
+	usage := (&anthropicUsage{InputTokens: 10, OutputTokens: 5, CacheCreationInputTokens: 100, CacheReadInputTokens: 1000}).toWaveAIUsage()
+	if usage.PromptTokens != 1110 || usage.CachedTokens != 1000 || usage.TotalTokens != 1115 || usage.CompletionTokens != 5 {
+		t.Errorf("unexpected usage %#v", usage)
+	}
+}
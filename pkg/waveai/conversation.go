@@ -0,0 +1,200 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// server-side conversation history (see WaveAIStreamRequest.Conversation).  a turn is only added to the
+// history when the response completes without an error.  the history is trimmed (oldest turns first,
+// system messages are kept) to ConversationMaxMessages and to the model's context window when it is known.
+// conversations are dropped after ConversationIdleTTL without a request.
+
+const WaveAIConversationPacketstr = "conversation"
+const ConversationIdleTTL = 30 * time.Minute
+const ConversationMaxMessages = 100
+const MaxConversations = 200
+
+type aiConversation struct {
+	id       string
+	history  []wshrpc.WaveAIPromptMessageType
+	lastUsed time.Time
+	busy     bool // a request is in flight (turns must be sequential)
+}
+
+type conversationTable struct {
+	lock          *sync.Mutex
+	ttl           time.Duration
+	conversations map[string]*aiConversation
+}
+
+var conversations = makeConversationTable(ConversationIdleTTL)
+
+func makeConversationTable(ttl time.Duration) *conversationTable {
+	return &conversationTable{lock: &sync.Mutex{}, ttl: ttl, conversations: make(map[string]*aiConversation)}
+}
+
+func (t *conversationTable) removeExpired_nolock(now time.Time) {
+	for id, conv := range t.conversations {
+		if !conv.busy && now.Sub(conv.lastUsed) > t.ttl {
+			delete(t.conversations, id)
+		}
+	}
+}
+
+// drops the oldest non-system messages until the history fits, the remaining turns start with a user message
+func trimConversationHistory(history []wshrpc.WaveAIPromptMessageType, maxMessages int, maxTokens int) []wshrpc.WaveAIPromptMessageType {
+	fits := func() bool {
+		return len(history) <= maxMessages && (maxTokens <= 0 || estimatePromptTokens(history) <= maxTokens)
+	}
+	if fits() {
+		return history
+	}
+	history = slices.Clone(history)
+	for !fits() {
+		idx := slices.IndexFunc(history, func(msg wshrpc.WaveAIPromptMessageType) bool { return msg.Role != "system" })
+		if idx == -1 {
+			break
+		}
+		history = slices.Delete(history, idx, idx+1)
+		for idx < len(history) && history[idx].Role != "system" && history[idx].Role != "user" {
+			history = slices.Delete(history, idx, idx+1)
+		}
+	}
+	return history
+}
+
+// token budget for the prompt (0 if the model's context window is unknown)
+func getConversationMaxTokens(opts *wshrpc.WaveAIOptsType) int {
+	if opts == nil {
+		return 0
+	}
+	modelInfo, known := getAIModelInfo(opts.Model)
+	if !known {
+		return 0
+	}
+	return max(modelInfo.ContextWindow-opts.MaxTokens, 0)
+}
+
+// starts or continues the request's conversation, returns it (marked busy) and the full prompt to send.
+// the caller must call finishTurn when the response is done.
+func (t *conversationTable) startTurn(request wshrpc.WaveAIStreamRequest) (*aiConversation, []wshrpc.WaveAIPromptMessageType, error) {
+	if len(request.Prompt) == 0 {
+		return nil, nil, fmt.Errorf("prompt is required")
+	}
+	now := time.Now()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.removeExpired_nolock(now)
+	var conv *aiConversation
+	if request.ConversationId != "" {
+		conv = t.conversations[request.ConversationId]
+		if conv == nil {
+			return nil, nil, fmt.Errorf("conversation %q not found (it may have expired)", request.ConversationId)
+		}
+		if conv.busy {
+			return nil, nil, fmt.Errorf("conversation %q already has a request in progress", request.ConversationId)
+		}
+		if request.ResetConversation {
+			conv.history = nil
+		}
+	} else {
+		if len(t.conversations) >= MaxConversations {
+			return nil, nil, fmt.Errorf("too many conversations (max %d)", MaxConversations)
+		}
+		conv = &aiConversation{id: uuid.New().String()}
+		t.conversations[conv.id] = conv
+	}
+	conv.busy = true
+	conv.lastUsed = now
+	maxTokens := getConversationMaxTokens(request.Opts)
+	if maxTokens > 0 {
+		// leave room for the new messages
+		maxTokens = max(maxTokens-estimatePromptTokens(request.Prompt), 1)
+	}
+	history := trimConversationHistory(conv.history, ConversationMaxMessages-len(request.Prompt), maxTokens)
+	prompt := make([]wshrpc.WaveAIPromptMessageType, 0, len(history)+len(request.Prompt))
+	prompt = append(prompt, history...)
+	if len(prompt) > 0 {
+		// the history doesn't change between turns, so it can be cached by the provider
+		prompt[len(prompt)-1].CacheHint = true
+	}
+	prompt = append(prompt, request.Prompt...)
+	return conv, prompt, nil
+}
+
+// records the turn (the prompt that was sent and the response) if the response completed, always clears busy
+func (t *conversationTable) finishTurn(conv *aiConversation, prompt []wshrpc.WaveAIPromptMessageType, response string, completed bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	conv.busy = false
+	conv.lastUsed = time.Now()
+	if !completed {
+		return
+	}
+	history := slices.Clone(prompt)
+	for idx := range history {
+		history[idx].CacheHint = false
+	}
+	history = append(history, wshrpc.WaveAIPromptMessageType{Role: "assistant", Content: response})
+	conv.history = trimConversationHistory(history, ConversationMaxMessages, 0)
+}
+
+// sends the conversation packet first, then forwards ch while collecting the response text for finishTurn
+func (t *conversationTable) withConversation(ctx context.Context, ch chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], conv *aiConversation, prompt []wshrpc.WaveAIPromptMessageType, requestId string) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	go func() {
+		var response strings.Builder
+		completed := false
+		defer func() {
+			panichandler.PanicHandler("waveai:withConversation", recover())
+		}()
+		defer close(rtn)
+		// finished before rtn is closed, so the client can send the next turn as soon as it sees the end
+		defer func() {
+			t.finishTurn(conv, prompt, response.String(), completed)
+		}()
+		defer func() {
+			go func() {
+				for range ch {
+				}
+			}()
+		}()
+		send := func(resp wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]) bool {
+			select {
+			case rtn <- resp:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		convPk := wshrpc.WaveAIPacketType{Type: WaveAIConversationPacketstr, ConversationId: conv.id, RequestId: requestId}
+		if !send(wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: convPk}) {
+			return
+		}
+		failed := false
+		for resp := range ch {
+			if resp.Error != nil || resp.Response.Error != "" {
+				failed = true
+			} else if resp.Response.Type == WaveAIPacketstr && resp.Response.Index == 0 {
+				response.WriteString(resp.Response.Text)
+			}
+			if !send(resp) {
+				return
+			}
+		}
+		completed = !failed
+	}()
+	return rtn
+}
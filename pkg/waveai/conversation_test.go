@@ -0,0 +1,117 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func userMsg(content string) wshrpc.WaveAIPromptMessageType {
+	return wshrpc.WaveAIPromptMessageType{Role: "user", Content: content}
+}
+
+func assistantMsg(content string) wshrpc.WaveAIPromptMessageType {
+	return wshrpc.WaveAIPromptMessageType{Role: "assistant", Content: content}
+}
+
+// runs one turn with a fake backend that answers with texts (or fails with err), returns the conversation id
+func runTestTurn(t *testing.T, table *conversationTable, request wshrpc.WaveAIStreamRequest, err error, texts ...string) (string, []wshrpc.WaveAIPromptMessageType) {
+	t.Helper()
+	conv, prompt, startErr := table.startTurn(request)
+	if startErr != nil {
+		t.Fatalf("error starting turn: %v", startErr)
+	}
+	backendCh := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], len(texts)+1)
+	for _, text := range texts {
+		backendCh <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: wshrpc.WaveAIPacketType{Type: WaveAIPacketstr, Text: text}}
+	}
+	if err != nil {
+		backendCh <- makeAIError(err)
+	}
+	close(backendCh)
+	var convId string
+	for resp := range table.withConversation(context.Background(), backendCh, conv, prompt, "") {
+		if resp.Response.Type == WaveAIConversationPacketstr {
+			convId = resp.Response.ConversationId
+		}
+	}
+	if convId != conv.id {
+		t.Fatalf("expected a conversation packet with id %q first, got %q", conv.id, convId)
+	}
+	return convId, prompt
+}
+
+func TestConversationContinuation(t *testing.T) {
+	table := makeConversationTable(time.Hour)
+	system := wshrpc.WaveAIPromptMessageType{Role: "system", Content: "be brief"}
+	convId, _ := runTestTurn(t, table, wshrpc.WaveAIStreamRequest{Conversation: true, Prompt: []wshrpc.WaveAIPromptMessageType{system, userMsg("hi")}}, nil, "hello", " there")
+
+	_, prompt := runTestTurn(t, table, wshrpc.WaveAIStreamRequest{ConversationId: convId, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("how are you?")}}, nil, "fine")
+	cachedReply := assistantMsg("hello there")
+	cachedReply.CacheHint = true
+	expected := []wshrpc.WaveAIPromptMessageType{system, userMsg("hi"), cachedReply, userMsg("how are you?")}
+	if !reflect.DeepEqual(prompt, expected) {
+		t.Errorf("continued prompt:\ngot  %v\nwant %v", prompt, expected)
+	}
+
+	// a failed turn is not added to the history
+	runTestTurn(t, table, wshrpc.WaveAIStreamRequest{ConversationId: convId, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("lost")}}, errors.New("provider error"), "partial")
+	_, prompt = runTestTurn(t, table, wshrpc.WaveAIStreamRequest{ConversationId: convId, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("again")}}, nil, "ok")
+	if len(prompt) != 6 || prompt[4].Content != "fine" || prompt[5] != userMsg("again") {
+		t.Errorf("failed turn should not be in the history, got %v", prompt)
+	}
+
+	_, prompt = runTestTurn(t, table, wshrpc.WaveAIStreamRequest{ConversationId: convId, ResetConversation: true, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("fresh")}}, nil, "ok")
+	if !reflect.DeepEqual(prompt, []wshrpc.WaveAIPromptMessageType{userMsg("fresh")}) {
+		t.Errorf("reset should clear the history, got %v", prompt)
+	}
+
+	conv, _, err := table.startTurn(wshrpc.WaveAIStreamRequest{ConversationId: convId, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("one")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := table.startTurn(wshrpc.WaveAIStreamRequest{ConversationId: convId, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("two")}}); err == nil || !strings.Contains(err.Error(), "in progress") {
+		t.Errorf("concurrent turns should be rejected, got %v", err)
+	}
+	table.finishTurn(conv, nil, "", false)
+}
+
+func TestConversationExpiry(t *testing.T) {
+	table := makeConversationTable(20 * time.Millisecond)
+	convId, _ := runTestTurn(t, table, wshrpc.WaveAIStreamRequest{Conversation: true, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("hi")}}, nil, "hello")
+	runTestTurn(t, table, wshrpc.WaveAIStreamRequest{ConversationId: convId, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("still there?")}}, nil, "yes")
+	time.Sleep(50 * time.Millisecond)
+	_, _, err := table.startTurn(wshrpc.WaveAIStreamRequest{ConversationId: convId, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("hello?")}})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected the idle conversation to expire, got %v", err)
+	}
+	if len(table.conversations) != 0 {
+		t.Errorf("expired conversation was not removed")
+	}
+}
+
+func TestTrimConversationHistory(t *testing.T) {
+	system := wshrpc.WaveAIPromptMessageType{Role: "system", Content: "sys"}
+	history := []wshrpc.WaveAIPromptMessageType{system, userMsg("q1"), assistantMsg("a1"), userMsg("q2"), assistantMsg("a2"), userMsg("q3"), assistantMsg("a3")}
+	trimmed := trimConversationHistory(history, 4, 0)
+	expected := []wshrpc.WaveAIPromptMessageType{system, userMsg("q3"), assistantMsg("a3")}
+	if !reflect.DeepEqual(trimmed, expected) {
+		t.Errorf("trim to 4 messages: got %v, want %v", trimmed, expected)
+	}
+	if history[1] != userMsg("q1") {
+		t.Errorf("trimming modified the original history")
+	}
+	perTurnTokens := estimatePromptTokens(history[1:3])
+	trimmed = trimConversationHistory(history, 100, estimatePromptTokens([]wshrpc.WaveAIPromptMessageType{system})+2*perTurnTokens)
+	if len(trimmed) != 5 || trimmed[1] != userMsg("q2") {
+		t.Errorf("trim to 2 turns of tokens: got %v", trimmed)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const WaveAIDryRunPacketstr = "dryrun"
+
+// rough estimate (about 4 characters per token plus a few tokens of per-message framing)
+const DryRunCharsPerToken = 4
+const DryRunMessageOverheadTokens = 4
+
+const (
+	AIFeature_Vision = "vision"
+	AIFeature_System = "system messages"
+)
+
+type aiModelInfo struct {
+	ContextWindow int
+	Vision        bool
+	NoSystem      bool
+}
+
+// matched by model name prefix (longest prefix first), models not listed have an unknown context window
+var aiModelTable = []struct {
+	Prefix string
+	Info   aiModelInfo
+}{
+	{"gpt-4o-mini", aiModelInfo{ContextWindow: 128000, Vision: true}},
+	{"gpt-4o", aiModelInfo{ContextWindow: 128000, Vision: true}},
+	{"gpt-4-turbo", aiModelInfo{ContextWindow: 128000, Vision: true}},
+	{"gpt-4-32k", aiModelInfo{ContextWindow: 32768}},
+	{"gpt-4", aiModelInfo{ContextWindow: 8192}},
+	{"gpt-3.5-turbo", aiModelInfo{ContextWindow: 16385}},
+	{"o1-mini", aiModelInfo{ContextWindow: 128000, NoSystem: true}},
+	{"o1-preview", aiModelInfo{ContextWindow: 128000, NoSystem: true}},
+	{"o1", aiModelInfo{ContextWindow: 200000, Vision: true}},
+	{"o3-mini", aiModelInfo{ContextWindow: 200000}},
+	{"claude-3", aiModelInfo{ContextWindow: 200000, Vision: true}},
+	{"claude-", aiModelInfo{ContextWindow: 200000, Vision: true}},
+	{"gemini-1.5-pro", aiModelInfo{ContextWindow: 2097152, Vision: true}},
+	{"gemini-", aiModelInfo{ContextWindow: 1048576, Vision: true}},
+	{"sonar", aiModelInfo{ContextWindow: 127072}},
+}
+
+func getAIModelInfo(model string) (aiModelInfo, bool) {
+	model = strings.ToLower(model)
+	for _, entry := range aiModelTable {
+		if strings.HasPrefix(model, entry.Prefix) {
+			return entry.Info, true
+		}
+	}
+	return aiModelInfo{}, false
+}
+
+func estimatePromptTokens(prompt []wshrpc.WaveAIPromptMessageType) int {
+	var tokens int
+	for _, msg := range prompt {
+		tokens += DryRunMessageOverheadTokens + (len(msg.Content)+DryRunCharsPerToken-1)/DryRunCharsPerToken
+	}
+	return tokens
+}
+
+// images are passed inline as data urls
+func promptHasImages(prompt []wshrpc.WaveAIPromptMessageType) bool {
+	for _, msg := range prompt {
+		if strings.Contains(msg.Content, "data:image/") {
+			return true
+		}
+	}
+	return false
+}
+
+func makeDryRunResult(request wshrpc.WaveAIStreamRequest) *wshrpc.WaveAIDryRunType {
+	rtn := &wshrpc.WaveAIDryRunType{
+		PromptTokens: estimatePromptTokens(request.Prompt),
+		MaxTokens:    request.Opts.MaxTokens,
+		FitsContext:  true,
+	}
+	modelInfo, known := getAIModelInfo(request.Opts.Model)
+	if known {
+		rtn.ContextWindow = modelInfo.ContextWindow
+		rtn.FitsContext = rtn.PromptTokens+rtn.MaxTokens <= modelInfo.ContextWindow
+		if !modelInfo.Vision && promptHasImages(request.Prompt) {
+			rtn.Unsupported = append(rtn.Unsupported, AIFeature_Vision)
+		}
+		if modelInfo.NoSystem {
+			for _, msg := range request.Prompt {
+				if msg.Role == "system" {
+					rtn.Unsupported = append(rtn.Unsupported, AIFeature_System)
+					break
+				}
+			}
+		}
+	}
+	rtn.Valid = rtn.FitsContext && len(rtn.Unsupported) == 0
+	return rtn
+}
+
+// returns a channel with the single dry-run packet (nothing is sent to the provider)
+func runDryRun(request wshrpc.WaveAIStreamRequest) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], 1)
+	pk := wshrpc.WaveAIPacketType{Type: WaveAIDryRunPacketstr, Model: request.Opts.Model, DryRun: makeDryRunResult(request)}
+	rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: pk}
+	close(rtn)
+	return rtn
+}
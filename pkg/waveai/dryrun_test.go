@@ -0,0 +1,64 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func runTestDryRun(t *testing.T, request wshrpc.WaveAIStreamRequest) *wshrpc.WaveAIDryRunType {
+	t.Helper()
+	request.DryRun = true
+	var packets []wshrpc.WaveAIPacketType
+	for resp := range RunAICommand(context.Background(), request) {
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %v", resp.Error)
+		}
+		packets = append(packets, resp.Response)
+	}
+	if len(packets) != 1 || packets[0].Type != WaveAIDryRunPacketstr || packets[0].DryRun == nil {
+		t.Fatalf("expected a single dryrun packet, got %#v", packets)
+	}
+	return packets[0].DryRun
+}
+
+func TestDryRun(t *testing.T) {
+	// the base url points nowhere, a dry run must not contact the provider
+	opts := &wshrpc.WaveAIOptsType{Model: "gpt-4", APIToken: "test-token", BaseURL: "http://127.0.0.1:1/v1", MaxTokens: 1000}
+	smallPrompt := []wshrpc.WaveAIPromptMessageType{{Role: "user", Content: "hello"}}
+	result := runTestDryRun(t, wshrpc.WaveAIStreamRequest{Opts: opts, Prompt: smallPrompt})
+	if !result.Valid || !result.FitsContext || result.ContextWindow != 8192 || result.PromptTokens == 0 {
+		t.Errorf("small prompt should be valid, got %#v", result)
+	}
+
+	largePrompt := []wshrpc.WaveAIPromptMessageType{{Role: "user", Content: strings.Repeat("word ", 8000)}}
+	result = runTestDryRun(t, wshrpc.WaveAIStreamRequest{Opts: opts, Prompt: largePrompt})
+	if result.Valid || result.FitsContext || result.PromptTokens <= result.ContextWindow-result.MaxTokens {
+		t.Errorf("over-context prompt should not fit, got %#v", result)
+	}
+
+	imagePrompt := []wshrpc.WaveAIPromptMessageType{{Role: "user", Content: "what is this? data:image/png;base64,iVBORw0KGgo="}}
+	result = runTestDryRun(t, wshrpc.WaveAIStreamRequest{Opts: opts, Prompt: imagePrompt})
+	if result.Valid || !slices.Contains(result.Unsupported, AIFeature_Vision) {
+		t.Errorf("image prompt should be unsupported for gpt-4, got %#v", result)
+	}
+	visionOpts := *opts
+	visionOpts.Model = "gpt-4o"
+	result = runTestDryRun(t, wshrpc.WaveAIStreamRequest{Opts: &visionOpts, Prompt: imagePrompt})
+	if !result.Valid || len(result.Unsupported) != 0 {
+		t.Errorf("image prompt should be valid for gpt-4o, got %#v", result)
+	}
+
+	unknownOpts := *opts
+	unknownOpts.Model = "my-local-model"
+	result = runTestDryRun(t, wshrpc.WaveAIStreamRequest{Opts: &unknownOpts, Prompt: largePrompt})
+	if !result.Valid || result.ContextWindow != 0 {
+		t.Errorf("unknown models should be assumed to fit, got %#v", result)
+	}
+}
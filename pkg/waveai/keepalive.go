@@ -0,0 +1,75 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// proxies in front of wavesrv (or between the client and wavesrv) close streams that are idle for too long,
+// which happens while a slow model is thinking.  ping packets carry no text or usage, clients skip them.
+
+const WaveAIPingPacketstr = "ping"
+const DefaultKeepAliveInterval = 15 * time.Second
+const MinKeepAliveInterval = 1 * time.Second
+
+func getKeepAliveInterval(opts *wshrpc.WaveAIOptsType) time.Duration {
+	if opts == nil || opts.KeepAliveMs == 0 {
+		return DefaultKeepAliveInterval
+	}
+	if opts.KeepAliveMs < 0 {
+		return 0
+	}
+	return max(time.Duration(opts.KeepAliveMs)*time.Millisecond, MinKeepAliveInterval)
+}
+
+// forwards everything from ch, sending a ping whenever nothing was sent for interval
+func withKeepAlive(ctx context.Context, ch chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], interval time.Duration) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	if ch == nil || interval <= 0 {
+		return ch
+	}
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("waveai:withKeepAlive", recover())
+		}()
+		defer close(rtn)
+		// keep draining so the backend goroutine can always finish
+		defer func() {
+			go func() {
+				for range ch {
+				}
+			}()
+		}()
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case resp, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case rtn <- resp:
+				case <-ctx.Done():
+					return
+				}
+			case <-timer.C:
+				select {
+				case rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: wshrpc.WaveAIPacketType{Type: WaveAIPingPacketstr}}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			timer.Reset(interval)
+		}
+	}()
+	return rtn
+}
@@ -0,0 +1,39 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestWithKeepAlive(t *testing.T) {
+	backendCh := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	go func() {
+		defer close(backendCh)
+		time.Sleep(130 * time.Millisecond)
+		backendCh <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: wshrpc.WaveAIPacketType{Type: WaveAIPacketstr, Text: "hello"}}
+	}()
+	var numPings int
+	var text string
+	for resp := range withKeepAlive(context.Background(), backendCh, 50*time.Millisecond) {
+		if resp.Response.Type == WaveAIPingPacketstr {
+			if resp.Response.Text != "" || resp.Response.Usage != nil {
+				t.Errorf("ping packet should be empty, got %#v", resp.Response)
+			}
+			numPings++
+			continue
+		}
+		text += resp.Response.Text
+	}
+	if numPings < 1 || numPings > 3 {
+		t.Errorf("expected 1-3 pings during the quiet period, got %d", numPings)
+	}
+	if text != "hello" {
+		t.Errorf("expected text %q, got %q", "hello", text)
+	}
+}
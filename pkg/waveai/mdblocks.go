@@ -0,0 +1,188 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// in markdownblocks mode (WaveAIOptsType.MarkdownBlocks) text is buffered and only emitted up to a
+// safe block boundary: after a blank line, after a closing code fence, or before a line that starts
+// a new block (list item, heading, code fence).  nothing inside an open code fence is emitted.
+// the remainder is flushed (with Partial set) in the packet that carries the FinishReason, or in a
+// final packet when the stream ends.
+
+func isCodeFence(trimmedLine string) (string, bool) {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmedLine, marker) {
+			return marker, true
+		}
+	}
+	return "", false
+}
+
+// lineStart may be incomplete (still streaming), returns false until the block marker is complete
+func startsMarkdownBlock(lineStart string) bool {
+	trimmed := strings.TrimLeft(lineStart, " ")
+	if _, ok := isCodeFence(trimmed); ok || strings.HasPrefix(trimmed, "#") {
+		return true
+	}
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(trimmed, marker) {
+			return true
+		}
+	}
+	numDigits := 0
+	for numDigits < len(trimmed) && trimmed[numDigits] >= '0' && trimmed[numDigits] <= '9' {
+		numDigits++
+	}
+	return numDigits > 0 && strings.HasPrefix(trimmed[numDigits:], ". ")
+}
+
+// returns the end of the last safe block boundary in text (0 if there is none yet)
+func findMarkdownBoundary(text string) int {
+	boundary := 0
+	inFence := false
+	fenceMarker := ""
+	pos := 0
+	for {
+		nlIdx := strings.IndexByte(text[pos:], '\n')
+		if nlIdx == -1 {
+			return boundary
+		}
+		lineEnd := pos + nlIdx + 1
+		trimmed := strings.TrimSpace(text[pos:lineEnd])
+		pos = lineEnd
+		if inFence {
+			if strings.HasPrefix(trimmed, fenceMarker) && strings.Trim(trimmed, fenceMarker[:1]) == "" {
+				inFence = false
+				boundary = lineEnd
+			}
+			continue
+		}
+		if marker, ok := isCodeFence(trimmed); ok {
+			inFence = true
+			fenceMarker = marker
+			continue
+		}
+		if trimmed == "" {
+			boundary = lineEnd
+			continue
+		}
+		nextLine := text[lineEnd:]
+		if nlIdx := strings.IndexByte(nextLine, '\n'); nlIdx != -1 {
+			nextLine = nextLine[:nlIdx+1]
+			if strings.TrimSpace(nextLine) == "" {
+				continue // the blank line itself is the boundary
+			}
+		}
+		if startsMarkdownBlock(nextLine) {
+			boundary = lineEnd
+		}
+	}
+}
+
+type markdownBlockBuffer struct {
+	buf strings.Builder
+}
+
+// adds text, returns the text that is safe to emit
+func (b *markdownBlockBuffer) add(text string) string {
+	b.buf.WriteString(text)
+	bufStr := b.buf.String()
+	boundary := findMarkdownBoundary(bufStr)
+	if boundary == 0 {
+		return ""
+	}
+	b.buf.Reset()
+	b.buf.WriteString(bufStr[boundary:])
+	return bufStr[:boundary]
+}
+
+// returns the buffered text (plus extra) and whether it ends mid-block
+func (b *markdownBlockBuffer) flush(extra string) (string, bool) {
+	rtn := b.buf.String() + extra
+	b.buf.Reset()
+	return rtn, rtn != "" && findMarkdownBoundary(rtn) != len(rtn)
+}
+
+func withMarkdownBlocks(ctx context.Context, ch chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	if ch == nil {
+		return nil
+	}
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("waveai:withMarkdownBlocks", recover())
+		}()
+		defer close(rtn)
+		defer func() {
+			go func() {
+				for range ch {
+				}
+			}()
+		}()
+		var mdBuf markdownBlockBuffer
+		send := func(resp wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]) bool {
+			select {
+			case rtn <- resp:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		sendFlush := func() bool {
+			text, partial := mdBuf.flush("")
+			if text == "" {
+				return true
+			}
+			pk := MakeWaveAIPacket()
+			pk.Text = text
+			pk.Partial = partial
+			return send(wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk})
+		}
+		for {
+			var resp wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]
+			var ok bool
+			select {
+			case resp, ok = <-ch:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				sendFlush()
+				return
+			}
+			if resp.Error != nil {
+				if sendFlush() {
+					send(resp)
+				}
+				return
+			}
+			if resp.Response.Type != WaveAIPacketstr {
+				if !send(resp) {
+					return
+				}
+				continue
+			}
+			pk := resp.Response
+			if pk.FinishReason != "" {
+				pk.Text, pk.Partial = mdBuf.flush(pk.Text)
+			} else {
+				pk.Text = mdBuf.add(pk.Text)
+				if pk.Text == "" && pk.Model == "" && pk.Usage == nil && pk.Quota == nil && pk.Error == "" {
+					continue // nothing to send until a boundary arrives
+				}
+			}
+			if !send(wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: pk}) {
+				return
+			}
+		}
+	}()
+	return rtn
+}
@@ -0,0 +1,66 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func runMarkdownBlocks(deltas []string, finishReason string) []wshrpc.WaveAIPacketType {
+	backendCh := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	go func() {
+		defer close(backendCh)
+		for idx, delta := range deltas {
+			pk := wshrpc.WaveAIPacketType{Type: WaveAIPacketstr, Text: delta}
+			if idx == len(deltas)-1 {
+				pk.FinishReason = finishReason
+			}
+			backendCh <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: pk}
+		}
+	}()
+	var rtn []wshrpc.WaveAIPacketType
+	for resp := range withMarkdownBlocks(context.Background(), backendCh) {
+		rtn = append(rtn, resp.Response)
+	}
+	return rtn
+}
+
+func TestMarkdownBlocksCodeFence(t *testing.T) {
+	deltas := []string{"Here is the code:\n", "\n``", "`go\nfunc main() {\n", "\tfmt.Println(\"hi\")\n}\n``", "`\nDone", "."}
+	packets := runMarkdownBlocks(deltas, "")
+	var texts []string
+	for _, pk := range packets {
+		texts = append(texts, pk.Text)
+	}
+	expected := []string{"Here is the code:\n\n", "```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n", "Done."}
+	if strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected packets:\n got %q\nwant %q", texts, expected)
+	}
+	for idx, pk := range packets {
+		if pk.Partial != (idx == len(packets)-1) {
+			t.Errorf("packet %d: unexpected partial=%v", idx, pk.Partial)
+		}
+	}
+}
+
+func TestMarkdownBlocksListAndFinish(t *testing.T) {
+	deltas := []string{"Steps:\n- one\n", "- tw", "o\n- three"}
+	packets := runMarkdownBlocks(deltas, "stop")
+	var texts []string
+	for _, pk := range packets {
+		texts = append(texts, pk.Text)
+	}
+	expected := []string{"Steps:\n", "- one\n", "- two\n- three"}
+	if strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected packets:\n got %q\nwant %q", texts, expected)
+	}
+	last := packets[len(packets)-1]
+	if last.FinishReason != "stop" || !last.Partial {
+		t.Errorf("expected the buffered text to be flushed in the finish packet (partial), got %#v", last)
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"regexp"
 	"strings"
+	"time"
 
 	openaiapi "github.com/sashabaranov/go-openai"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
@@ -22,6 +24,9 @@ var _ AIBackend = OpenAIBackend{}
 
 const DefaultAzureAPIVersion = "2023-05-15"
 
+// openai reasoning models (o1, o3-mini, ...) only accept max_completion_tokens
+var openAIReasoningModelRe = regexp.MustCompile(`^o\d+(-|$)`)
+
 // copied from go-openai/config.go
 func defaultAzureMapperFn(model string) string {
 	return regexp.MustCompile(`[.:]`).ReplaceAllString(model, "")
@@ -99,6 +104,9 @@ func (OpenAIBackend) StreamCompletion(ctx context.Context, request wshrpc.WaveAI
 		if request.Opts.APIVersion != "" {
 			clientConfig.APIVersion = request.Opts.APIVersion
 		}
+		if request.Debug {
+			clientConfig.HTTPClient = makeAIHttpClient(request, rtn)
+		}
 
 		client := openaiapi.NewClientWithConfig(clientConfig)
 		req := openaiapi.ChatCompletionRequest{
@@ -106,47 +114,39 @@ func (OpenAIBackend) StreamCompletion(ctx context.Context, request wshrpc.WaveAI
 			Messages: convertPrompt(request.Prompt),
 		}
 
-		// Handle o1 models differently - use non-streaming API
-		if strings.HasPrefix(request.Opts.Model, "o1-") {
-			req.MaxCompletionTokens = request.Opts.MaxTokens
-			req.Stream = false
-
-			// Make non-streaming API call
-			resp, err := client.CreateChatCompletion(ctx, req)
+		if !shouldStreamOpenAI(request.Opts) {
+			err := runOpenAIBlocking(ctx, client, req, request.Opts, rtn)
 			if err != nil {
-				rtn <- makeAIError(fmt.Errorf("error calling openai API: %v", err))
-				return
-			}
-
-			// Send header packet
-			headerPk := MakeWaveAIPacket()
-			headerPk.Model = resp.Model
-			headerPk.Created = resp.Created
-			rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *headerPk}
-
-			// Send content packet(s)
-			for i, choice := range resp.Choices {
-				pk := MakeWaveAIPacket()
-				pk.Index = i
-				pk.Text = choice.Message.Content
-				pk.FinishReason = string(choice.FinishReason)
-				rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
+				rtn <- makeAIError(err)
 			}
 			return
 		}
 
-		// Original streaming implementation for non-o1 models
+		// streaming implementation
 		req.Stream = true
-		req.MaxTokens = request.Opts.MaxTokens
+		setOpenAIMaxTokens(&req, request.Opts)
 		if request.Opts.MaxChoices > 1 {
 			req.N = request.Opts.MaxChoices
 		}
 
 		apiResp, err := client.CreateChatCompletionStream(ctx, req)
 		if err != nil {
-			rtn <- makeAIError(fmt.Errorf("error calling openai API: %v", err))
+			if request.Opts.Stream != nil {
+				// streaming was explicitly requested, don't fall back
+				rtn <- makeAIError(fmt.Errorf("error calling openai API: %v", err))
+				return
+			}
+			// provider may not support streaming, degrade to a single blocking request
+			log.Printf("openai streaming request failed (%v), retrying without streaming\n", err)
+			blockingErr := runOpenAIBlocking(ctx, client, req, request.Opts, rtn)
+			if blockingErr != nil {
+				rtn <- makeAIError(fmt.Errorf("error calling openai API (streaming: %v) (non-streaming: %v)", err, blockingErr))
+			}
 			return
 		}
+		if quotaPk := makeQuotaPacket(parseOpenAIQuota(apiResp.Header(), time.Now())); quotaPk != nil {
+			rtn <- *quotaPk
+		}
 		sentHeader := false
 		for {
 			streamResp, err := apiResp.Recv()
@@ -175,3 +175,67 @@ func (OpenAIBackend) StreamCompletion(ctx context.Context, request wshrpc.WaveAI
 	}()
 	return rtn
 }
+
+func shouldStreamOpenAI(opts *wshrpc.WaveAIOptsType) bool {
+	if opts.Stream != nil {
+		return *opts.Stream
+	}
+	// o1 models do not support streaming
+	return !strings.HasPrefix(opts.Model, "o1-")
+}
+
+// reasoning models reject max_tokens, everything else gets max_tokens (openai-compatible servers like ollama
+// and azure's older api versions don't know max_completion_tokens)
+func setOpenAIMaxTokens(req *openaiapi.ChatCompletionRequest, opts *wshrpc.WaveAIOptsType) {
+	if openAIReasoningModelRe.MatchString(opts.Model) {
+		req.MaxTokens = 0
+		req.MaxCompletionTokens = opts.MaxTokens
+		return
+	}
+	req.MaxTokens = opts.MaxTokens
+	req.MaxCompletionTokens = 0
+}
+
+// makes a single non-streaming request and emits the full response as synthetic packets (header, content, finish)
+func runOpenAIBlocking(ctx context.Context, client *openaiapi.Client, req openaiapi.ChatCompletionRequest, opts *wshrpc.WaveAIOptsType, rtn chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]) error {
+	req.Stream = false
+	setOpenAIMaxTokens(&req, opts)
+	if opts.MaxChoices > 1 {
+		req.N = opts.MaxChoices
+	}
+	resp, err := client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return fmt.Errorf("error calling openai API: %v", err)
+	}
+	if quotaPk := makeQuotaPacket(parseOpenAIQuota(resp.Header(), time.Now())); quotaPk != nil {
+		rtn <- *quotaPk
+	}
+	headerPk := MakeWaveAIPacket()
+	headerPk.Model = resp.Model
+	headerPk.Created = resp.Created
+	rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *headerPk}
+	for i, choice := range resp.Choices {
+		pk := MakeWaveAIPacket()
+		pk.Index = i
+		pk.Text = choice.Message.Content
+		pk.FinishReason = string(choice.FinishReason)
+		if pk.FinishReason == "" {
+			pk.FinishReason = string(openaiapi.FinishReasonStop)
+		}
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
+	}
+	if resp.Usage.TotalTokens > 0 {
+		usagePk := MakeWaveAIPacket()
+		usagePk.Usage = &wshrpc.WaveAIUsageType{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		}
+		// openai caches long prompt prefixes automatically (CacheHint needs no directive), only the usage is reported
+		if resp.Usage.PromptTokensDetails != nil {
+			usagePk.Usage.CachedTokens = resp.Usage.PromptTokensDetails.CachedTokens
+		}
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *usagePk}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	openaiapi "github.com/sashabaranov/go-openai"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestSetOpenAIMaxTokens(t *testing.T) {
+	tests := []struct {
+		model              string
+		completionTokenKey bool
+	}{
+		{"gpt-4o", false},
+		{"llama3.1", false},
+		{"o1", true},
+		{"o1-mini", true},
+		{"o3-mini", true},
+		{"omni-model", false},
+	}
+	for _, tc := range tests {
+		req := openaiapi.ChatCompletionRequest{MaxTokens: 1, MaxCompletionTokens: 1}
+		setOpenAIMaxTokens(&req, &wshrpc.WaveAIOptsType{Model: tc.model, MaxTokens: 100})
+		if tc.completionTokenKey && (req.MaxCompletionTokens != 100 || req.MaxTokens != 0) {
+			t.Errorf("%s: expected max_completion_tokens, got %+v", tc.model, req)
+		}
+		if !tc.completionTokenKey && (req.MaxTokens != 100 || req.MaxCompletionTokens != 0) {
+			t.Errorf("%s: expected max_tokens, got %+v", tc.model, req)
+		}
+	}
+}
+
+// an openai-compatible server without streaming support, records the body of each request
+func makeNoStreamOpenAIServer(t *testing.T) (*httptest.Server, func() []map[string]any) {
+	var lock sync.Mutex
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		barr, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		json.Unmarshal(barr, &body)
+		lock.Lock()
+		bodies = append(bodies, body)
+		lock.Unlock()
+		if stream, _ := body["stream"].(bool); stream {
+			http.Error(w, `{"error":{"message":"streaming is not supported"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"test-model","created":1,"choices":[{"index":0,"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []map[string]any {
+		lock.Lock()
+		defer lock.Unlock()
+		return bodies
+	}
+}
+
+func collectAIPackets(t *testing.T, ch chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]) []wshrpc.WaveAIPacketType {
+	var rtn []wshrpc.WaveAIPacketType
+	for respUnion := range ch {
+		if respUnion.Error != nil {
+			t.Fatalf("unexpected error: %v", respUnion.Error)
+		}
+		rtn = append(rtn, respUnion.Response)
+	}
+	return rtn
+}
+
+func TestOpenAIBlockingFallback(t *testing.T) {
+	srv, getBodies := makeNoStreamOpenAIServer(t)
+	opts := &wshrpc.WaveAIOptsType{Model: "llama3.1", BaseURL: srv.URL, MaxTokens: 50}
+	packets := collectAIPackets(t, OpenAIBackend{}.StreamCompletion(context.Background(), wshrpc.WaveAIStreamRequest{Opts: opts}))
+	var text, finishReason, model string
+	for _, pk := range packets {
+		text += pk.Text
+		model += pk.Model
+		if pk.FinishReason != "" {
+			finishReason = pk.FinishReason
+		}
+	}
+	if text != "hello" || model != "test-model" || finishReason != string(openaiapi.FinishReasonStop) {
+		t.Errorf("unexpected packets %+v", packets)
+	}
+	bodies := getBodies()
+	if len(bodies) != 2 {
+		t.Fatalf("expected a streaming request and a blocking retry, got %d requests", len(bodies))
+	}
+	blockingBody := bodies[1]
+	if blockingBody["max_tokens"] != float64(50) || blockingBody["max_completion_tokens"] != nil {
+		t.Errorf("expected max_tokens for a non-reasoning model, got %v", blockingBody)
+	}
+
+	// streaming explicitly requested, no fallback
+	streamOn := true
+	opts.Stream = &streamOn
+	var gotErr bool
+	for respUnion := range (OpenAIBackend{}).StreamCompletion(context.Background(), wshrpc.WaveAIStreamRequest{Opts: opts}) {
+		gotErr = gotErr || respUnion.Error != nil
+	}
+	if !gotErr || len(getBodies()) != 3 {
+		t.Errorf("expected an error and no blocking retry when streaming is forced (%d requests)", len(getBodies()))
+	}
+}
+
+func TestOpenAIBlockingReasoningModel(t *testing.T) {
+	srv, getBodies := makeNoStreamOpenAIServer(t)
+	opts := &wshrpc.WaveAIOptsType{Model: "o1-mini", BaseURL: srv.URL, MaxTokens: 50}
+	collectAIPackets(t, OpenAIBackend{}.StreamCompletion(context.Background(), wshrpc.WaveAIStreamRequest{Opts: opts}))
+	bodies := getBodies()
+	if len(bodies) != 1 {
+		t.Fatalf("expected a single blocking request for an o1 model, got %d", len(bodies))
+	}
+	if bodies[0]["max_completion_tokens"] != float64(50) || bodies[0]["max_tokens"] != nil {
+		t.Errorf("expected max_completion_tokens for a reasoning model, got %v", bodies[0])
+	}
+}
@@ -108,7 +108,7 @@ func (PerplexityBackend) StreamCompletion(ctx context.Context, request wshrpc.Wa
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+request.Opts.APIToken)
 
-		client := &http.Client{}
+		client := makeAIHttpClient(request, rtn)
 		resp, err := client.Do(req)
 		if err != nil {
 			rtn <- makeAIError(fmt.Errorf("failed to send perplexity request: %v", err))
@@ -0,0 +1,82 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func parseQuotaInt(header http.Header, name string) *int {
+	valStr := strings.TrimSpace(header.Get(name))
+	if valStr == "" {
+		return nil
+	}
+	val, err := strconv.Atoi(valStr)
+	if err != nil {
+		return nil
+	}
+	return &val
+}
+
+func minResetTs(resetTs int64, t time.Time) int64 {
+	ts := t.UnixMilli()
+	if resetTs == 0 || ts < resetTs {
+		return ts
+	}
+	return resetTs
+}
+
+func makeQuota(remainingRequests *int, remainingTokens *int, resetTs int64) *wshrpc.WaveAIQuotaType {
+	if remainingRequests == nil && remainingTokens == nil && resetTs == 0 {
+		return nil
+	}
+	return &wshrpc.WaveAIQuotaType{RemainingRequests: remainingRequests, RemainingTokens: remainingTokens, ResetTs: resetTs}
+}
+
+// anthropic-ratelimit-* headers, resets are RFC 3339 timestamps
+func parseAnthropicQuota(header http.Header) *wshrpc.WaveAIQuotaType {
+	var resetTs int64
+	for _, name := range []string{"anthropic-ratelimit-requests-reset", "anthropic-ratelimit-tokens-reset"} {
+		resetTime, err := time.Parse(time.RFC3339, strings.TrimSpace(header.Get(name)))
+		if err == nil {
+			resetTs = minResetTs(resetTs, resetTime)
+		}
+	}
+	return makeQuota(
+		parseQuotaInt(header, "anthropic-ratelimit-requests-remaining"),
+		parseQuotaInt(header, "anthropic-ratelimit-tokens-remaining"),
+		resetTs,
+	)
+}
+
+// x-ratelimit-* headers, resets are durations from now (e.g. "1s", "6m0s", "120ms")
+func parseOpenAIQuota(header http.Header, now time.Time) *wshrpc.WaveAIQuotaType {
+	var resetTs int64
+	for _, name := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		resetDur, err := time.ParseDuration(strings.TrimSpace(header.Get(name)))
+		if err == nil {
+			resetTs = minResetTs(resetTs, now.Add(resetDur))
+		}
+	}
+	return makeQuota(
+		parseQuotaInt(header, "x-ratelimit-remaining-requests"),
+		parseQuotaInt(header, "x-ratelimit-remaining-tokens"),
+		resetTs,
+	)
+}
+
+// returns nil when the provider didn't report any quota (no packet is sent)
+func makeQuotaPacket(quota *wshrpc.WaveAIQuotaType) *wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	if quota == nil {
+		return nil
+	}
+	pk := MakeWaveAIPacket()
+	pk.Quota = quota
+	return &wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
+}
@@ -0,0 +1,55 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseAnthropicQuota(t *testing.T) {
+	header := http.Header{}
+	header.Set("anthropic-ratelimit-requests-remaining", "2")
+	header.Set("anthropic-ratelimit-tokens-remaining", "39000")
+	header.Set("anthropic-ratelimit-requests-reset", "2025-01-02T03:04:30Z")
+	header.Set("anthropic-ratelimit-tokens-reset", "2025-01-02T03:04:05Z")
+	quota := parseAnthropicQuota(header)
+	if quota == nil || quota.RemainingRequests == nil || *quota.RemainingRequests != 2 || quota.RemainingTokens == nil || *quota.RemainingTokens != 39000 {
+		t.Fatalf("unexpected quota %#v", quota)
+	}
+	expectedReset := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli()
+	if quota.ResetTs != expectedReset {
+		t.Errorf("expected the earliest reset %d, got %d", expectedReset, quota.ResetTs)
+	}
+}
+
+func TestParseOpenAIQuota(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("x-ratelimit-remaining-requests", "0")
+	header.Set("x-ratelimit-reset-requests", "6m0s")
+	header.Set("x-ratelimit-reset-tokens", "120ms")
+	quota := parseOpenAIQuota(header, now)
+	if quota == nil || quota.RemainingRequests == nil || *quota.RemainingRequests != 0 {
+		t.Fatalf("expected 0 remaining requests to be reported, got %#v", quota)
+	}
+	if quota.RemainingTokens != nil {
+		t.Errorf("expected remaining tokens to be omitted, got %d", *quota.RemainingTokens)
+	}
+	if quota.ResetTs != now.Add(120*time.Millisecond).UnixMilli() {
+		t.Errorf("unexpected reset %d", quota.ResetTs)
+	}
+}
+
+func TestParseQuotaMissing(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-ratelimit-remaining-requests", "not a number")
+	if quota := parseOpenAIQuota(header, time.Now()); quota != nil {
+		t.Errorf("expected no quota, got %#v", quota)
+	}
+	if quota := parseAnthropicQuota(http.Header{}); quota != nil || makeQuotaPacket(quota) != nil {
+		t.Errorf("expected no quota packet, got %#v", quota)
+	}
+}
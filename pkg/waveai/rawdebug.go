@@ -0,0 +1,99 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// with WaveAIStreamRequest.Debug set, every non-empty line of the provider's response body is sent
+// (before the packets parsed from it) as a raw packet with the line in Text.  the api token is scrubbed.
+// supported for the http backends (openai, anthropic, perplexity), not google or the wave cloud.
+
+const WaveAIRawPacketstr = "raw"
+const RawDebugRedacted = "[redacted]"
+
+// calls lineFn with each complete line as it's read, a trailing line without a newline is sent at EOF
+type rawLineReader struct {
+	body   io.ReadCloser
+	buf    []byte
+	lineFn func(line string)
+}
+
+func (r *rawLineReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	for {
+		idx := bytes.IndexByte(r.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		r.sendLine(r.buf[:idx])
+		r.buf = r.buf[idx+1:]
+	}
+	if err == io.EOF && len(r.buf) > 0 {
+		r.sendLine(r.buf)
+		r.buf = nil
+	}
+	return n, err
+}
+
+func (r *rawLineReader) sendLine(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) == 0 {
+		return
+	}
+	r.lineFn(string(line))
+}
+
+func (r *rawLineReader) Close() error {
+	return r.body.Close()
+}
+
+type rawDebugTransport struct {
+	base   http.RoundTripper
+	lineFn func(line string)
+}
+
+func (t *rawDebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &rawLineReader{body: resp.Body, lineFn: t.lineFn}
+	return resp, nil
+}
+
+func scrubAPIToken(line string, apiToken string) string {
+	if apiToken == "" {
+		return line
+	}
+	return strings.ReplaceAll(line, apiToken, RawDebugRedacted)
+}
+
+func makeRawPacket(line string) wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	return wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: wshrpc.WaveAIPacketType{Type: WaveAIRawPacketstr, Text: line}}
+}
+
+// the body is read from the backend goroutine, so raw packets are sent on rtn in order with the parsed ones
+func makeAIHttpClient(request wshrpc.WaveAIStreamRequest, rtn chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]) *http.Client {
+	if !request.Debug {
+		return &http.Client{}
+	}
+	var apiToken string
+	if request.Opts != nil {
+		apiToken = request.Opts.APIToken
+	}
+	return &http.Client{Transport: &rawDebugTransport{
+		base: http.DefaultTransport,
+		lineFn: func(line string) {
+			rtn <- makeRawPacket(scrubAPIToken(line, apiToken))
+		},
+	}}
+}
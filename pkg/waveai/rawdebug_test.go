@@ -0,0 +1,69 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const testRawToken = "sk-test-secret"
+
+func runRawDebugStream(t *testing.T, baseURL string, debug bool) ([]string, string) {
+	stream := true
+	request := wshrpc.WaveAIStreamRequest{
+		Opts:   &wshrpc.WaveAIOptsType{Model: "gpt-test", APIToken: testRawToken, BaseURL: baseURL, Stream: &stream},
+		Prompt: []wshrpc.WaveAIPromptMessageType{{Role: "user", Content: "hi"}},
+		Debug:  debug,
+	}
+	var rawLines []string
+	var text string
+	for resp := range (OpenAIBackend{}).StreamCompletion(context.Background(), request) {
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %v", resp.Error)
+		}
+		if resp.Response.Type == WaveAIRawPacketstr {
+			rawLines = append(rawLines, resp.Response.Text)
+			continue
+		}
+		text += resp.Response.Text
+	}
+	return rawLines, text
+}
+
+func TestRawDebugPackets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk := `{"id":"1","object":"chat.completion.chunk","model":"gpt-test","choices":[{"index":0,"delta":{"content":%q}}]}`
+		fmt.Fprintf(w, "data: "+chunk+"\n\n", "hello ")
+		fmt.Fprintf(w, "data: "+chunk+"\n\n", "echo "+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	rawLines, text := runRawDebugStream(t, server.URL, false)
+	if len(rawLines) != 0 {
+		t.Errorf("expected no raw packets without debug, got %v", rawLines)
+	}
+	if text != "hello echo "+testRawToken {
+		t.Errorf("unexpected text %q", text)
+	}
+
+	rawLines, text = runRawDebugStream(t, server.URL, true)
+	if len(rawLines) != 3 || !strings.HasPrefix(rawLines[0], `data: {"id":"1"`) || rawLines[2] != "data: [DONE]" {
+		t.Fatalf("unexpected raw packets %v", rawLines)
+	}
+	if strings.Contains(strings.Join(rawLines, "\n"), testRawToken) || !strings.Contains(rawLines[1], RawDebugRedacted) {
+		t.Errorf("expected the api token to be scrubbed, got %v", rawLines)
+	}
+	if !strings.HasPrefix(text, "hello ") {
+		t.Errorf("expected the parsed packets to be unchanged, got %q", text)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// resumable conversation turns.  the response is generated detached from the client's request and
+// buffered under a request id (sent in the "conversation" packet), packets are numbered with Seq.
+// after a disconnect the client calls ResumeStreamCommand with the last Seq it saw to get the rest.
+// generation is cancelled when no client has been attached for ResumeStreamTimeout, and a finished
+// response can be resumed for ResumeStreamTimeout after it ends.
+
+const ResumeStreamTimeout = 2 * time.Minute
+const MaxResumableStreams = 100
+
+type resumableStream struct {
+	requestId    string
+	packets      []wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]
+	done         bool
+	notifyCh     chan struct{} // closed (and replaced) whenever packets or done change
+	numAttached  int
+	cancelGen    context.CancelFunc
+	detachTimer  *time.Timer
+	keepAliveDur time.Duration
+}
+
+type resumableStreamTable struct {
+	lock    *sync.Mutex
+	timeout time.Duration
+	streams map[string]*resumableStream
+}
+
+var resumableStreams = makeResumableStreamTable(ResumeStreamTimeout)
+
+func makeResumableStreamTable(timeout time.Duration) *resumableStreamTable {
+	return &resumableStreamTable{lock: &sync.Mutex{}, timeout: timeout, streams: make(map[string]*resumableStream)}
+}
+
+func (s *resumableStream) notify_nolock() {
+	close(s.notifyCh)
+	s.notifyCh = make(chan struct{})
+}
+
+// buffers everything from ch under requestId, cancelGen stops the generation (the producer of ch)
+func (t *resumableStreamTable) start(requestId string, ch chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], cancelGen context.CancelFunc, keepAliveDur time.Duration) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.streams) >= MaxResumableStreams {
+		return fmt.Errorf("too many resumable streams (max %d)", MaxResumableStreams)
+	}
+	stream := &resumableStream{requestId: requestId, notifyCh: make(chan struct{}), cancelGen: cancelGen, keepAliveDur: keepAliveDur}
+	t.streams[requestId] = stream
+	t.startDetachTimer_nolock(stream)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("waveai:resumableStream", recover())
+		}()
+		for resp := range ch {
+			t.lock.Lock()
+			resp.Response.Seq = len(stream.packets) + 1
+			stream.packets = append(stream.packets, resp)
+			stream.notify_nolock()
+			t.lock.Unlock()
+		}
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		stream.done = true
+		stream.notify_nolock()
+		if stream.detachTimer != nil {
+			stream.detachTimer.Stop()
+		}
+		cancelGen()
+		time.AfterFunc(t.timeout, func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			if t.streams[requestId] == stream {
+				delete(t.streams, requestId)
+			}
+		})
+	}()
+	return nil
+}
+
+// cancels the generation if nobody attaches within the timeout
+func (t *resumableStreamTable) startDetachTimer_nolock(stream *resumableStream) {
+	stream.detachTimer = time.AfterFunc(t.timeout, func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		if stream.numAttached == 0 && !stream.done {
+			stream.cancelGen()
+		}
+	})
+}
+
+// returns the packets after afterSeq, followed by the live stream (until it ends or ctx is done), and the keepalive interval
+func (t *resumableStreamTable) attach(ctx context.Context, requestId string, afterSeq int) (chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], time.Duration, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	stream := t.streams[requestId]
+	if stream == nil {
+		return nil, 0, fmt.Errorf("stream %q not found (it may have finished more than %v ago)", requestId, t.timeout)
+	}
+	if afterSeq < 0 || afterSeq > len(stream.packets) {
+		return nil, 0, fmt.Errorf("invalid seq %d for stream %q (%d packets so far)", afterSeq, requestId, len(stream.packets))
+	}
+	stream.numAttached++
+	if stream.detachTimer != nil {
+		stream.detachTimer.Stop()
+		stream.detachTimer = nil
+	}
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("waveai:resumableStream:attach", recover())
+		}()
+		defer close(rtn)
+		defer func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			stream.numAttached--
+			if stream.numAttached == 0 && !stream.done {
+				t.startDetachTimer_nolock(stream)
+			}
+		}()
+		nextIdx := afterSeq
+		for {
+			t.lock.Lock()
+			packets := stream.packets[nextIdx:]
+			done := stream.done
+			notifyCh := stream.notifyCh
+			t.lock.Unlock()
+			for _, resp := range packets {
+				select {
+				case rtn <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+			nextIdx += len(packets)
+			if done {
+				return
+			}
+			select {
+			case <-notifyCh:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return rtn, stream.keepAliveDur, nil
+}
+
+func ResumeStream(ctx context.Context, data wshrpc.CommandResumeStreamData) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	rtnCh, keepAliveDur, err := resumableStreams.attach(ctx, data.RequestId, data.AfterSeq)
+	if err != nil {
+		return makeAIErrorCh(err)
+	}
+	return withKeepAlive(ctx, rtnCh, keepAliveDur)
+}
@@ -0,0 +1,104 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func sendTestText(backendCh chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], text string) {
+	backendCh <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: wshrpc.WaveAIPacketType{Type: WaveAIPacketstr, Text: text}}
+}
+
+func TestResumeStreamAfterDisconnect(t *testing.T) {
+	convTable := makeConversationTable(time.Hour)
+	streamTable := makeResumableStreamTable(time.Hour)
+	conv, prompt, err := convTable.startTurn(wshrpc.WaveAIStreamRequest{Conversation: true, Prompt: []wshrpc.WaveAIPromptMessageType{userMsg("hi")}})
+	if err != nil {
+		t.Fatalf("error starting turn: %v", err)
+	}
+	genCtx, cancelGen := context.WithCancel(context.Background())
+	backendCh := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	genCh := convTable.withConversation(genCtx, backendCh, conv, prompt, "req1")
+	if err := streamTable.start("req1", genCh, cancelGen, 0); err != nil {
+		t.Fatalf("error starting stream: %v", err)
+	}
+
+	clientCtx, disconnect := context.WithCancel(context.Background())
+	clientCh, _, err := streamTable.attach(clientCtx, "req1", 0)
+	if err != nil {
+		t.Fatalf("error attaching: %v", err)
+	}
+	convPk := <-clientCh
+	if convPk.Response.Type != WaveAIConversationPacketstr || convPk.Response.RequestId != "req1" || convPk.Response.Seq != 1 {
+		t.Fatalf("expected the conversation packet first, got %+v", convPk.Response)
+	}
+	sendTestText(backendCh, "hello")
+	if pk := <-clientCh; pk.Response.Text != "hello" || pk.Response.Seq != 2 {
+		t.Fatalf("unexpected packet %+v", pk.Response)
+	}
+
+	// the client goes away, generation continues
+	disconnect()
+	for range clientCh {
+	}
+	sendTestText(backendCh, " there")
+	sendTestText(backendCh, "!")
+	close(backendCh)
+
+	resumeCh, _, err := streamTable.attach(context.Background(), "req1", 2)
+	if err != nil {
+		t.Fatalf("error resuming: %v", err)
+	}
+	var text string
+	lastSeq := 2
+	for pk := range resumeCh {
+		if pk.Response.Seq != lastSeq+1 {
+			t.Errorf("expected seq %d, got %d", lastSeq+1, pk.Response.Seq)
+		}
+		lastSeq = pk.Response.Seq
+		text += pk.Response.Text
+	}
+	if text != " there!" {
+		t.Errorf("resumed text: got %q", text)
+	}
+	if genCtx.Err() == nil {
+		t.Errorf("generation context should be released when the stream ends")
+	}
+	convTable.lock.Lock()
+	history := conv.history
+	convTable.lock.Unlock()
+	if len(history) != 2 || history[1].Content != "hello there!" {
+		t.Errorf("the turn should be recorded in full, got %v", history)
+	}
+	if _, _, err := streamTable.attach(context.Background(), "req1", 10); err == nil {
+		t.Errorf("expected an error for a seq past the end")
+	}
+}
+
+func TestResumeStreamDetachTimeout(t *testing.T) {
+	streamTable := makeResumableStreamTable(20 * time.Millisecond)
+	genCtx, cancelGen := context.WithCancel(context.Background())
+	backendCh := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	streamTable.start("req1", backendCh, cancelGen, 0)
+	clientCtx, disconnect := context.WithCancel(context.Background())
+	clientCh, _, _ := streamTable.attach(clientCtx, "req1", 0)
+	time.Sleep(50 * time.Millisecond)
+	if genCtx.Err() != nil {
+		t.Fatalf("generation should continue while a client is attached")
+	}
+	disconnect()
+	for range clientCh {
+	}
+	select {
+	case <-genCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("generation was not cancelled after the client stayed away")
+	}
+	close(backendCh)
+}
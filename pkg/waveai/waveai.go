@@ -5,8 +5,11 @@ package waveai
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 )
@@ -55,7 +58,30 @@ func makeAIError(err error) wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
 	return wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Error: err}
 }
 
+func makeAIErrorCh(err error) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], 1)
+	rtn <- makeAIError(err)
+	close(rtn)
+	return rtn
+}
+
 func RunAICommand(ctx context.Context, request wshrpc.WaveAIStreamRequest) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	if request.DryRun {
+		if request.Opts == nil {
+			request.Opts = &wshrpc.WaveAIOptsType{}
+		}
+		if request.ConversationId != "" {
+			// validates the prompt the conversation would send (without changing the conversation)
+			request.ResetConversation = false
+			conv, prompt, err := conversations.startTurn(request)
+			if err != nil {
+				return makeAIErrorCh(err)
+			}
+			conversations.finishTurn(conv, nil, "", false)
+			request.Prompt = prompt
+		}
+		return runDryRun(request)
+	}
 	telemetry.GoUpdateActivityWrap(wshrpc.ActivityUpdate{NumAIReqs: 1}, "RunAICommand")
 
 	endpoint := request.Opts.BaseURL
@@ -82,7 +108,51 @@ func RunAICommand(ctx context.Context, request wshrpc.WaveAIStreamRequest) chan
 		log.Printf("no backend found for %s\n", request.Opts.APIType)
 		return nil
 	}
+	if request.Opts.Stream != nil && !*request.Opts.Stream && request.Opts.APIType != APIType_OpenAI {
+		return makeAIErrorCh(fmt.Errorf("non-streaming requests are not supported for api type %q", request.Opts.APIType))
+	}
+	var conv *aiConversation
+	if request.Conversation || request.ConversationId != "" {
+		var err error
+		conv, request.Prompt, err = conversations.startTurn(request)
+		if err != nil {
+			return makeAIErrorCh(err)
+		}
+	}
 
 	log.Printf("sending ai chat message to %s endpoint %q using model %s\n", request.Opts.APIType, endpoint, request.Opts.Model)
-	return backend.StreamCompletion(ctx, request)
+	keepAliveDur := getKeepAliveInterval(request.Opts)
+	if conv != nil {
+		return runResumableTurn(ctx, backend, request, conv, keepAliveDur)
+	}
+	rtnCh := backend.StreamCompletion(ctx, request)
+	if request.Opts.MarkdownBlocks {
+		rtnCh = withMarkdownBlocks(ctx, rtnCh)
+	}
+	return withKeepAlive(ctx, rtnCh, keepAliveDur)
+}
+
+// conversation turns keep generating when the client goes away (see ResumeStream)
+func runResumableTurn(ctx context.Context, backend AIBackend, request wshrpc.WaveAIStreamRequest, conv *aiConversation, keepAliveDur time.Duration) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	genCtx, cancelGen := context.WithCancel(context.WithoutCancel(ctx))
+	requestId := uuid.New().String()
+	genCh := backend.StreamCompletion(genCtx, request)
+	genCh = conversations.withConversation(genCtx, genCh, conv, request.Prompt, requestId)
+	if request.Opts.MarkdownBlocks {
+		genCh = withMarkdownBlocks(genCtx, genCh)
+	}
+	err := resumableStreams.start(requestId, genCh, cancelGen, keepAliveDur)
+	if err != nil {
+		cancelGen()
+		go func() {
+			for range genCh {
+			}
+		}()
+		return makeAIErrorCh(err)
+	}
+	rtnCh, _, err := resumableStreams.attach(ctx, requestId, 0)
+	if err != nil {
+		return makeAIErrorCh(err)
+	}
+	return withKeepAlive(ctx, rtnCh, keepAliveDur)
 }
@@ -0,0 +1,119 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveobj
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// typed copy of LayoutState.RootNode.  the tree is owned by the frontend layout model (LayoutNode in
+// frontend/layout/lib/types.ts), sizes are flex weights relative to the node's siblings (the backend
+// never knows pixel positions).  leaves hold a block, inner nodes hold children.
+
+const (
+	LayoutFlexDirection_Row    = "row"
+	LayoutFlexDirection_Column = "column"
+)
+
+type TabLayoutNode struct {
+	Id            string             `json:"id"`
+	Data          *TabLayoutNodeData `json:"data,omitempty"`
+	Children      []*TabLayoutNode   `json:"children,omitempty"`
+	FlexDirection string             `json:"flexDirection"`
+	Size          float64            `json:"size"`
+}
+
+type TabLayoutNodeData struct {
+	BlockId string `json:"blockId"`
+}
+
+// converts LayoutState.RootNode (usually a decoded json map) to a typed tree, nil if there is no tree yet
+func ParseLayoutTree(rootNode any) (*TabLayoutNode, error) {
+	if rootNode == nil {
+		return nil, nil
+	}
+	barr, err := json.Marshal(rootNode)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding layout tree: %w", err)
+	}
+	var rtn *TabLayoutNode
+	err = json.Unmarshal(barr, &rtn)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding layout tree: %w", err)
+	}
+	return rtn, nil
+}
+
+func (node *TabLayoutNode) walk(fn func(node *TabLayoutNode) error) error {
+	if err := fn(node); err != nil {
+		return err
+	}
+	for _, child := range node.Children {
+		if err := child.walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checks the tree structure, returns the leaf order (in tree order)
+func (root *TabLayoutNode) Validate() ([]LeafOrderEntry, error) {
+	var leafOrder []LeafOrderEntry
+	nodeIds := make(map[string]bool)
+	blockIds := make(map[string]bool)
+	err := root.walk(func(node *TabLayoutNode) error {
+		if node == nil {
+			return fmt.Errorf("layout tree has a nil node")
+		}
+		if node.Id == "" {
+			return fmt.Errorf("layout node is missing its id")
+		}
+		if nodeIds[node.Id] {
+			return fmt.Errorf("duplicate layout node id %q", node.Id)
+		}
+		nodeIds[node.Id] = true
+		if node.FlexDirection != LayoutFlexDirection_Row && node.FlexDirection != LayoutFlexDirection_Column {
+			return fmt.Errorf("layout node %q has invalid flexDirection %q", node.Id, node.FlexDirection)
+		}
+		if node.Size < 0 {
+			return fmt.Errorf("layout node %q has a negative size", node.Id)
+		}
+		if node.Data != nil && len(node.Children) > 0 {
+			return fmt.Errorf("layout node %q cannot have both a block and children", node.Id)
+		}
+		if node.Data == nil {
+			if len(node.Children) == 0 && node != root {
+				// only an empty tab's root may have neither
+				return fmt.Errorf("layout node %q must have a block or children", node.Id)
+			}
+			return nil
+		}
+		if node.Data.BlockId == "" {
+			return fmt.Errorf("layout node %q is missing its blockId", node.Id)
+		}
+		if blockIds[node.Data.BlockId] {
+			return fmt.Errorf("block %q appears more than once in the layout", node.Data.BlockId)
+		}
+		blockIds[node.Data.BlockId] = true
+		leafOrder = append(leafOrder, LeafOrderEntry{NodeId: node.Id, BlockId: node.Data.BlockId})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leafOrder, nil
+}
+
+// returns the block in the leaf with nodeId ("" if there is no such leaf)
+func (root *TabLayoutNode) GetLeafBlockId(nodeId string) string {
+	var rtn string
+	root.walk(func(node *TabLayoutNode) error {
+		if node != nil && node.Id == nodeId && node.Data != nil {
+			rtn = node.Data.BlockId
+		}
+		return nil
+	})
+	return rtn
+}
@@ -0,0 +1,96 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveobj
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// a 3-pane layout as the frontend stores it: one block on the left, two stacked on the right
+const testLayoutJson = `{
+	"id": "root", "flexDirection": "row", "size": 10,
+	"children": [
+		{"id": "left", "flexDirection": "column", "size": 60, "data": {"blockId": "block1"}},
+		{"id": "right", "flexDirection": "column", "size": 40, "children": [
+			{"id": "top", "flexDirection": "row", "size": 25.5, "data": {"blockId": "block2"}},
+			{"id": "bottom", "flexDirection": "row", "size": 74.5, "data": {"blockId": "block3"}}
+		]}
+	]
+}`
+
+func TestLayoutTreeRoundTrip(t *testing.T) {
+	var stored any
+	if err := json.Unmarshal([]byte(testLayoutJson), &stored); err != nil {
+		t.Fatalf("bad test json: %v", err)
+	}
+	rootNode, err := ParseLayoutTree(stored)
+	if err != nil {
+		t.Fatalf("error parsing layout: %v", err)
+	}
+	leafOrder, err := rootNode.Validate()
+	if err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	expectedOrder := []LeafOrderEntry{{NodeId: "left", BlockId: "block1"}, {NodeId: "top", BlockId: "block2"}, {NodeId: "bottom", BlockId: "block3"}}
+	if !reflect.DeepEqual(leafOrder, expectedOrder) {
+		t.Errorf("leaf order: got %v, want %v", leafOrder, expectedOrder)
+	}
+	if blockId := rootNode.GetLeafBlockId("top"); blockId != "block2" {
+		t.Errorf("GetLeafBlockId(top): got %q", blockId)
+	}
+	if blockId := rootNode.GetLeafBlockId("right"); blockId != "" {
+		t.Errorf("inner nodes have no block, got %q", blockId)
+	}
+
+	// storing the typed tree (as SetTabLayout does) and reading it back is lossless
+	barr, err := json.Marshal(rootNode)
+	if err != nil {
+		t.Fatalf("error encoding layout: %v", err)
+	}
+	var restored any
+	json.Unmarshal(barr, &restored)
+	if !reflect.DeepEqual(restored, stored) {
+		t.Errorf("layout changed in the round trip:\n%s", barr)
+	}
+	again, _ := ParseLayoutTree(restored)
+	if !reflect.DeepEqual(again, rootNode) {
+		t.Errorf("typed layout changed in the round trip")
+	}
+
+	if rootNode, err := ParseLayoutTree(nil); rootNode != nil || err != nil {
+		t.Errorf("no tree should parse to nil, got %v, %v", rootNode, err)
+	}
+	empty := &TabLayoutNode{Id: "root", FlexDirection: LayoutFlexDirection_Row}
+	if leafOrder, err := empty.Validate(); err != nil || len(leafOrder) != 0 {
+		t.Errorf("an empty root should be valid, got %v, %v", leafOrder, err)
+	}
+}
+
+func TestLayoutTreeValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(root *TabLayoutNode)
+		errPart string
+	}{
+		{"duplicate block", func(root *TabLayoutNode) { root.Children[1].Children[1].Data.BlockId = "block1" }, "more than once"},
+		{"duplicate node", func(root *TabLayoutNode) { root.Children[1].Children[0].Id = "left" }, "duplicate layout node id"},
+		{"bad direction", func(root *TabLayoutNode) { root.FlexDirection = "diagonal" }, "invalid flexDirection"},
+		{"leaf with children", func(root *TabLayoutNode) { root.Children[1].Data = &TabLayoutNodeData{BlockId: "block4"} }, "both a block and children"},
+		{"empty inner node", func(root *TabLayoutNode) { root.Children[1].Children = nil }, "must have a block or children"},
+		{"negative size", func(root *TabLayoutNode) { root.Children[0].Size = -1 }, "negative size"},
+		{"nil child", func(root *TabLayoutNode) { root.Children[0] = nil }, "nil node"},
+	}
+	for _, tc := range tests {
+		var rootNode *TabLayoutNode
+		json.Unmarshal([]byte(testLayoutJson), &rootNode)
+		tc.mutate(rootNode)
+		_, err := rootNode.Validate()
+		if err == nil || !strings.Contains(err.Error(), tc.errPart) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.errPart, err)
+		}
+	}
+}
@@ -3,6 +3,8 @@
 
 package waveobj
 
+import "strings"
+
 type MetaMapType map[string]any
 
 func (m MetaMapType) GetString(key string, def string) string {
@@ -90,3 +92,101 @@ func (m MetaMapType) GetStringArray(key string) []string {
 	}
 	return rtn
 }
+
+// returns a copy of m containing only the given keys, dotted keys select nested paths (e.g. "cmd:env.PATH")
+// nested maps deeper than maxDepth are omitted (maxDepth <= 0 means no limit)
+// with no keys and no depth limit, m is returned as-is
+func (m MetaMapType) SelectKeys(keys []string, maxDepth int) MetaMapType {
+	if len(keys) == 0 {
+		if maxDepth <= 0 {
+			return m
+		}
+		return MetaMapType(copyMetaMap(m, 1, maxDepth))
+	}
+	rtn := make(map[string]any)
+	for _, key := range keys {
+		path := strings.Split(key, ".")
+		val, ok := getMetaPath(m, path)
+		if !ok {
+			continue
+		}
+		setMetaPath(rtn, path, val)
+	}
+	return MetaMapType(copyMetaMap(rtn, 1, maxDepth))
+}
+
+// returns a copy of m containing only the top-level keys starting with prefix (e.g. "term:"), with strip
+// the prefix is removed from the returned keys (a key equal to the prefix is then dropped)
+func (m MetaMapType) SelectPrefix(prefix string, strip bool, maxDepth int) MetaMapType {
+	rtn := make(map[string]any)
+	for key, val := range m {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if strip {
+			key = strings.TrimPrefix(key, prefix)
+			if key == "" {
+				continue
+			}
+		}
+		rtn[key] = val
+	}
+	return MetaMapType(copyMetaMap(rtn, 1, maxDepth))
+}
+
+func asMetaMap(v any) (map[string]any, bool) {
+	switch mval := v.(type) {
+	case map[string]any:
+		return mval, true
+	case MetaMapType:
+		return mval, true
+	}
+	return nil, false
+}
+
+func getMetaPath(m map[string]any, path []string) (any, bool) {
+	var cur any = m
+	for _, part := range path {
+		curMap, ok := asMetaMap(cur)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = curMap[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// sets val (copied) at path, creating intermediate maps as needed
+func setMetaPath(m map[string]any, path []string, val any) {
+	cur := m
+	for _, part := range path[:len(path)-1] {
+		next, ok := asMetaMap(cur[part])
+		if !ok {
+			next = make(map[string]any)
+			cur[part] = next
+		}
+		cur = next
+	}
+	if valMap, ok := asMetaMap(val); ok {
+		val = copyMetaMap(valMap, 1, 0)
+	}
+	cur[path[len(path)-1]] = val
+}
+
+func copyMetaMap(m map[string]any, depth int, maxDepth int) map[string]any {
+	rtn := make(map[string]any, len(m))
+	for key, val := range m {
+		if valMap, ok := asMetaMap(val); ok {
+			if maxDepth > 0 && depth >= maxDepth {
+				continue
+			}
+			rtn[key] = copyMetaMap(valMap, depth+1, maxDepth)
+			continue
+		}
+		rtn[key] = val
+	}
+	return rtn
+}
@@ -0,0 +1,111 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveobj
+
+import (
+	"reflect"
+	"testing"
+)
+
+func makeTestMeta() MetaMapType {
+	return MetaMapType{
+		"view":          "term",
+		"term:fontsize": float64(12),
+		"cmd:env": map[string]any{
+			"PATH": "/usr/bin",
+			"HOME": "/home/user",
+		},
+		"widget": map[string]any{
+			"layout": map[string]any{
+				"width":  float64(100),
+				"height": float64(50),
+			},
+			"title": "test",
+		},
+	}
+}
+
+func TestSelectKeysNested(t *testing.T) {
+	meta := makeTestMeta()
+	rtn := meta.SelectKeys([]string{"view", "cmd:env.PATH", "widget.layout.width", "missing.key"}, 0)
+	expected := MetaMapType{
+		"view": "term",
+		"cmd:env": map[string]any{
+			"PATH": "/usr/bin",
+		},
+		"widget": map[string]any{
+			"layout": map[string]any{
+				"width": float64(100),
+			},
+		},
+	}
+	if !reflect.DeepEqual(rtn, expected) {
+		t.Errorf("unexpected selection:\n got: %v\nwant: %v", rtn, expected)
+	}
+	// selecting a subtree and a path inside it should return the whole subtree
+	rtn = meta.SelectKeys([]string{"widget.layout", "widget.layout.height"}, 0)
+	expected = MetaMapType{
+		"widget": map[string]any{
+			"layout": map[string]any{
+				"width":  float64(100),
+				"height": float64(50),
+			},
+		},
+	}
+	if !reflect.DeepEqual(rtn, expected) {
+		t.Errorf("unexpected selection:\n got: %v\nwant: %v", rtn, expected)
+	}
+	if !reflect.DeepEqual(meta, makeTestMeta()) {
+		t.Errorf("SelectKeys modified the source meta")
+	}
+}
+
+func TestSelectKeysMaxDepth(t *testing.T) {
+	meta := makeTestMeta()
+	rtn := meta.SelectKeys(nil, 2)
+	expected := MetaMapType{
+		"view":          "term",
+		"term:fontsize": float64(12),
+		"cmd:env": map[string]any{
+			"PATH": "/usr/bin",
+			"HOME": "/home/user",
+		},
+		"widget": map[string]any{
+			"title": "test",
+		},
+	}
+	if !reflect.DeepEqual(rtn, expected) {
+		t.Errorf("unexpected selection:\n got: %v\nwant: %v", rtn, expected)
+	}
+}
+
+func TestSelectPrefix(t *testing.T) {
+	meta := makeTestMeta()
+	meta["term:*"] = true
+	meta["term:theme"] = "dark"
+	meta["terminal"] = "other"
+	rtn := meta.SelectPrefix("term:", false, 0)
+	expected := MetaMapType{"term:*": true, "term:fontsize": float64(12), "term:theme": "dark"}
+	if !reflect.DeepEqual(rtn, expected) {
+		t.Errorf("unexpected selection:\n got: %v\nwant: %v", rtn, expected)
+	}
+	rtn = meta.SelectPrefix("term:", true, 0)
+	expected = MetaMapType{"*": true, "fontsize": float64(12), "theme": "dark"}
+	if !reflect.DeepEqual(rtn, expected) {
+		t.Errorf("unexpected stripped selection:\n got: %v\nwant: %v", rtn, expected)
+	}
+	// a key equal to the prefix has no name left once stripped
+	rtn = meta.SelectPrefix("view", true, 0)
+	if len(rtn) != 0 {
+		t.Errorf("expected no keys, got %v", rtn)
+	}
+	rtn = meta.SelectPrefix("widget", false, 2)
+	expected = MetaMapType{"widget": map[string]any{"title": "test"}}
+	if !reflect.DeepEqual(rtn, expected) {
+		t.Errorf("unexpected depth-limited selection:\n got: %v\nwant: %v", rtn, expected)
+	}
+	if len(meta.SelectPrefix("nomatch:", false, 0)) != 0 {
+		t.Errorf("expected an empty selection for an unmatched prefix")
+	}
+}
@@ -231,6 +231,32 @@ func (*LayoutState) GetOType() string {
 	return OType_LayoutState
 }
 
+// returns the node id of the leaf holding blockId ("" if the frontend hasn't laid it out yet)
+func (ls *LayoutState) GetBlockNodeId(blockId string) string {
+	if ls.LeafOrder == nil {
+		return ""
+	}
+	for _, leaf := range *ls.LeafOrder {
+		if leaf.BlockId == blockId {
+			return leaf.NodeId
+		}
+	}
+	return ""
+}
+
+// returns the block id of the focused leaf ("" if nothing is focused)
+func (ls *LayoutState) GetFocusedBlockId() string {
+	if ls.FocusedNodeId == "" || ls.LeafOrder == nil {
+		return ""
+	}
+	for _, leaf := range *ls.LeafOrder {
+		if leaf.NodeId == ls.FocusedNodeId {
+			return leaf.BlockId
+		}
+	}
+	return ""
+}
+
 type FileDef struct {
 	Content string         `json:"content,omitempty"`
 	Meta    map[string]any `json:"meta,omitempty"`
@@ -0,0 +1,27 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveobj
+
+import "testing"
+
+func TestLayoutStateFocus(t *testing.T) {
+	ls := &LayoutState{}
+	if blockId := ls.GetFocusedBlockId(); blockId != "" {
+		t.Errorf("empty layout should have no focused block, got %q", blockId)
+	}
+	ls.LeafOrder = &[]LeafOrderEntry{{NodeId: "node1", BlockId: "block1"}, {NodeId: "node2", BlockId: "block2"}}
+	for _, blockId := range []string{"block2", "block1"} {
+		ls.FocusedNodeId = ls.GetBlockNodeId(blockId)
+		if got := ls.GetFocusedBlockId(); got != blockId {
+			t.Errorf("focused %q, got %q back", blockId, got)
+		}
+	}
+	if nodeId := ls.GetBlockNodeId("missing"); nodeId != "" {
+		t.Errorf("missing block should have no node, got %q", nodeId)
+	}
+	ls.FocusedNodeId = "stale-node"
+	if blockId := ls.GetFocusedBlockId(); blockId != "" {
+		t.Errorf("stale focused node should resolve to no block, got %q", blockId)
+	}
+}
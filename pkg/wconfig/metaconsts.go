@@ -21,6 +21,7 @@ const (
 	ConfigKey_AIApiVersion                   = "ai:apiversion"
 	ConfigKey_AiMaxTokens                    = "ai:maxtokens"
 	ConfigKey_AiTimeoutMs                    = "ai:timeoutms"
+	ConfigKey_AiKeepAliveMs                  = "ai:keepalivems"
 	ConfigKey_AiFontSize                     = "ai:fontsize"
 	ConfigKey_AiFixedFontSize                = "ai:fixedfontsize"
 
@@ -85,11 +86,22 @@ const (
 	ConfigKey_WindowDimensions               = "window:dimensions"
 	ConfigKey_WindowZoom                     = "window:zoom"
 
+	ConfigKey_SysInfoClear                   = "sysinfo:*"
+	ConfigKey_SysInfoHistorySecs             = "sysinfo:historysecs"
+	ConfigKey_SysInfoHistoryResolutionMs     = "sysinfo:historyresolutionms"
+
+	ConfigKey_DebugClear                     = "debug:*"
+	ConfigKey_DebugRpcLog                    = "debug:rpclog"
+	ConfigKey_DebugSubIdleTimeoutSecs        = "debug:subidletimeoutsecs"
+
 	ConfigKey_TelemetryClear                 = "telemetry:*"
 	ConfigKey_TelemetryEnabled               = "telemetry:enabled"
 
 	ConfigKey_ConnClear                      = "conn:*"
 	ConfigKey_ConnAskBeforeWshInstall        = "conn:askbeforewshinstall"
 	ConfigKey_ConnWshEnabled                 = "conn:wshenabled"
+	ConfigKey_ConnBandwidthLimit             = "conn:bandwidthlimit"
+	ConfigKey_ConnFileInfoCacheTtlMs         = "conn:fileinfocachettlms"
+	ConfigKey_ConnRequireRpcSigning          = "conn:requirerpcsigning"
 )
 
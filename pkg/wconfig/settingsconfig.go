@@ -48,6 +48,7 @@ type SettingsType struct {
 	AIApiVersion    string  `json:"ai:apiversion,omitempty"`
 	AiMaxTokens     float64 `json:"ai:maxtokens,omitempty"`
 	AiTimeoutMs     float64 `json:"ai:timeoutms,omitempty"`
+	AiKeepAliveMs   float64 `json:"ai:keepalivems,omitempty"`
 	AiFontSize      float64 `json:"ai:fontsize,omitempty"`
 	AiFixedFontSize float64 `json:"ai:fixedfontsize,omitempty"`
 
@@ -112,12 +113,23 @@ type SettingsType struct {
 	WindowDimensions                    string   `json:"window:dimensions,omitempty"`
 	WindowZoom                          *float64 `json:"window:zoom,omitempty"`
 
+	SysInfoClear               bool    `json:"sysinfo:*,omitempty"`
+	SysInfoHistorySecs         float64 `json:"sysinfo:historysecs,omitempty"`
+	SysInfoHistoryResolutionMs float64 `json:"sysinfo:historyresolutionms,omitempty"`
+
+	DebugClear              bool    `json:"debug:*,omitempty"`
+	DebugRpcLog             string  `json:"debug:rpclog,omitempty"`
+	DebugSubIdleTimeoutSecs float64 `json:"debug:subidletimeoutsecs,omitempty"`
+
 	TelemetryClear   bool `json:"telemetry:*,omitempty"`
 	TelemetryEnabled bool `json:"telemetry:enabled,omitempty"`
 
-	ConnClear               bool `json:"conn:*,omitempty"`
-	ConnAskBeforeWshInstall bool `json:"conn:askbeforewshinstall,omitempty"`
-	ConnWshEnabled          bool `json:"conn:wshenabled,omitempty"`
+	ConnClear               bool  `json:"conn:*,omitempty"`
+	ConnAskBeforeWshInstall bool  `json:"conn:askbeforewshinstall,omitempty"`
+	ConnWshEnabled          bool  `json:"conn:wshenabled,omitempty"`
+	ConnBandwidthLimit      int64 `json:"conn:bandwidthlimit,omitempty"`
+	ConnFileInfoCacheTtlMs  int64 `json:"conn:fileinfocachettlms,omitempty"`
+	ConnRequireRpcSigning   bool  `json:"conn:requirerpcsigning,omitempty"`
 }
 
 type ConfigError struct {
@@ -533,6 +545,24 @@ func SetConnectionsConfigValue(connName string, toMerge waveobj.MetaMapType) err
 	return WriteWaveHomeConfigFile(ConnectionsFile, m)
 }
 
+// the fileinfo cache ttl (ms) for a connection, the connections.json value overrides the global setting
+func GetConnFileInfoCacheTtlMs(connName string) int64 {
+	fullConfig := GetWatcher().GetFullConfig()
+	ttlMs := fullConfig.Settings.ConnFileInfoCacheTtlMs
+	if connSettings, ok := fullConfig.Connections[connName]; ok && connSettings.ConnFileInfoCacheTtlMs != nil {
+		ttlMs = *connSettings.ConnFileInfoCacheTtlMs
+	}
+	return ttlMs
+}
+
+// extra command line args for the connserver on a connection (the settings it needs at startup)
+func GetConnServerArgs(connName string) string {
+	if ttlMs := GetConnFileInfoCacheTtlMs(connName); ttlMs != 0 {
+		return fmt.Sprintf(" --fileinfocachettl %d", ttlMs)
+	}
+	return ""
+}
+
 type WidgetConfigType struct {
 	DisplayOrder float64          `json:"display:order,omitempty"`
 	Icon         string           `json:"icon,omitempty"`
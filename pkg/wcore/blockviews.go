@@ -0,0 +1,41 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"sort"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+)
+
+// must be kept in sync with makeViewModel in frontend/app/block/block.tsx
+var GeneralBlockViews = []string{"term", "preview", "web", "waveai", "sysinfo", "cpuplot", "plot", "help", "tips"}
+
+// views the block can be switched to.  vdom blocks only render as "vdom".  views defined by widgets
+// in the config are included as well.
+func GetBlockViews(block *waveobj.Block) []string {
+	if block.Meta.GetString(waveobj.MetaKey_View, "") == "vdom" {
+		return []string{"vdom"}
+	}
+	rtn := append([]string{}, GeneralBlockViews...)
+	seen := make(map[string]bool)
+	for _, view := range rtn {
+		seen[view] = true
+	}
+	var widgetViews []string
+	fullConfig := wconfig.GetWatcher().GetFullConfig()
+	for _, widgets := range []map[string]wconfig.WidgetConfigType{fullConfig.DefaultWidgets, fullConfig.Widgets} {
+		for _, widget := range widgets {
+			view := widget.BlockDef.Meta.GetString(waveobj.MetaKey_View, "")
+			if view == "" || seen[view] || widget.BlockDef.Meta.GetString(waveobj.MetaKey_Controller, "") != "" {
+				continue
+			}
+			seen[view] = true
+			widgetViews = append(widgetViews, view)
+		}
+	}
+	sort.Strings(widgetViews)
+	return append(rtn, widgetViews...)
+}
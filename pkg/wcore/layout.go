@@ -7,9 +7,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
 )
 
@@ -18,6 +20,8 @@ const (
 	LayoutActionDataType_InsertAtIndex = "insertatindex"
 	LayoutActionDataType_Remove        = "delete"
 	LayoutActionDataType_ClearTree     = "clear"
+	LayoutActionDataType_Focus         = "focus"
+	LayoutActionDataType_Replace       = "replace" // the tree in the layout state was replaced (see SetTabLayout)
 )
 
 type PortableLayout []struct {
@@ -123,6 +127,185 @@ func QueueLayoutActionForTab(ctx context.Context, tabId string, actions ...waveo
 	return QueueLayoutAction(ctx, layoutStateId, actions...)
 }
 
+// returns the focused block in the tab ("" if nothing is focused)
+func GetFocusedBlock(ctx context.Context, tabId string) (string, error) {
+	layoutStateId, err := GetLayoutIdForTab(ctx, tabId)
+	if err != nil {
+		return "", err
+	}
+	layoutStateObj, err := wstore.DBGet[*waveobj.LayoutState](ctx, layoutStateId)
+	if err != nil {
+		return "", fmt.Errorf("unable to get layout state for given id %s: %w", layoutStateId, err)
+	}
+	if layoutStateObj == nil {
+		return "", fmt.Errorf("layout state not found for tab %s", tabId)
+	}
+	return layoutStateObj.GetFocusedBlockId(), nil
+}
+
+// records the focused node (so GetFocusedBlock sees it right away) and queues a focus action for the frontend
+func SetFocusedBlock(ctx context.Context, tabId string, blockId string) error {
+	tabObj, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return fmt.Errorf("unable to get tab %s: %w", tabId, err)
+	}
+	if tabObj == nil {
+		return fmt.Errorf("tab not found: %q", tabId)
+	}
+	if !slices.Contains(tabObj.BlockIds, blockId) {
+		return fmt.Errorf("block %q not found in tab %q", blockId, tabId)
+	}
+	layoutStateObj, err := wstore.DBGet[*waveobj.LayoutState](ctx, tabObj.LayoutState)
+	if err != nil {
+		return fmt.Errorf("unable to get layout state for given id %s: %w", tabObj.LayoutState, err)
+	}
+	if layoutStateObj == nil {
+		return fmt.Errorf("layout state not found for tab %s", tabId)
+	}
+	if nodeId := layoutStateObj.GetBlockNodeId(blockId); nodeId != "" {
+		layoutStateObj.FocusedNodeId = nodeId
+	}
+	action := waveobj.LayoutActionData{ActionType: LayoutActionDataType_Focus, BlockId: blockId, Focused: true}
+	if layoutStateObj.PendingBackendActions == nil {
+		layoutStateObj.PendingBackendActions = &[]waveobj.LayoutActionData{action}
+	} else {
+		*layoutStateObj.PendingBackendActions = append(*layoutStateObj.PendingBackendActions, action)
+	}
+	err = wstore.DBUpdate(ctx, layoutStateObj)
+	if err != nil {
+		return fmt.Errorf("unable to update layout state: %w", err)
+	}
+	return nil
+}
+
+func PublishFocusChange(tabId string, blockId string) {
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_FocusChange,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Tab, tabId).String()},
+		Data:   wps.FocusChangeData{TabId: tabId, BlockId: blockId},
+	})
+}
+
+// publishes focuschange if the focus moved to another block (clearing the focus is not a change)
+func PublishFocusChangeIfMoved(tabId string, oldBlockId string, newBlockId string) {
+	if newBlockId == "" || newBlockId == oldBlockId {
+		return
+	}
+	PublishFocusChange(tabId, newBlockId)
+}
+
+// returns the focused block of a layout state ("" if nothing is focused or the layout state doesn't exist)
+func GetLayoutStateFocusedBlock(ctx context.Context, layoutStateId string) string {
+	layoutStateObj, err := wstore.DBGet[*waveobj.LayoutState](ctx, layoutStateId)
+	if err != nil || layoutStateObj == nil {
+		return ""
+	}
+	return layoutStateObj.GetFocusedBlockId()
+}
+
+// publishes focuschange for a layout state written by the frontend (focus changes made in the UI arrive
+// as layout state updates, not through SetFocusedBlock)
+func PublishLayoutStateFocusChange(ctx context.Context, oldBlockId string, layoutStateObj *waveobj.LayoutState) {
+	newBlockId := layoutStateObj.GetFocusedBlockId()
+	if newBlockId == "" || newBlockId == oldBlockId {
+		return
+	}
+	tabId, err := wstore.DBFindTabForBlockId(ctx, newBlockId)
+	if err != nil {
+		log.Printf("error finding tab for focused block %s: %v\n", newBlockId, err)
+		return
+	}
+	PublishFocusChange(tabId, newBlockId)
+}
+
+func getTabLayoutState(ctx context.Context, tabId string) (*waveobj.Tab, *waveobj.LayoutState, error) {
+	tabObj, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get tab %s: %w", tabId, err)
+	}
+	if tabObj == nil {
+		return nil, nil, fmt.Errorf("tab not found: %q", tabId)
+	}
+	layoutStateObj, err := wstore.DBGet[*waveobj.LayoutState](ctx, tabObj.LayoutState)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get layout state for given id %s: %w", tabObj.LayoutState, err)
+	}
+	if layoutStateObj == nil {
+		return nil, nil, fmt.Errorf("layout state not found for tab %s", tabId)
+	}
+	return tabObj, layoutStateObj, nil
+}
+
+// returns the tab's layout tree (nil if the frontend hasn't laid out the tab yet) and its focused and
+// magnified blocks
+func GetTabLayout(ctx context.Context, tabId string) (*waveobj.TabLayoutNode, string, string, error) {
+	_, layoutStateObj, err := getTabLayoutState(ctx, tabId)
+	if err != nil {
+		return nil, "", "", err
+	}
+	rootNode, err := waveobj.ParseLayoutTree(layoutStateObj.RootNode)
+	if err != nil || rootNode == nil {
+		return nil, "", "", err
+	}
+	return rootNode, rootNode.GetLeafBlockId(layoutStateObj.FocusedNodeId), rootNode.GetLeafBlockId(layoutStateObj.MagnifiedNodeId), nil
+}
+
+// replaces the tab's layout tree, which must hold every block in the tab exactly once.  focusedBlockId and
+// magnifiedBlockId are optional.  the frontend picks up the new tree through a replace action.
+func SetTabLayout(ctx context.Context, tabId string, rootNode *waveobj.TabLayoutNode, focusedBlockId string, magnifiedBlockId string) error {
+	if rootNode == nil {
+		return fmt.Errorf("layout tree is required")
+	}
+	leafOrder, err := rootNode.Validate()
+	if err != nil {
+		return err
+	}
+	tabObj, layoutStateObj, err := getTabLayoutState(ctx, tabId)
+	if err != nil {
+		return err
+	}
+	layoutBlockIds := make(map[string]string, len(leafOrder)) // blockid => nodeid
+	for _, leaf := range leafOrder {
+		if !slices.Contains(tabObj.BlockIds, leaf.BlockId) {
+			return fmt.Errorf("block %q not found in tab %q", leaf.BlockId, tabId)
+		}
+		layoutBlockIds[leaf.BlockId] = leaf.NodeId
+	}
+	for _, blockId := range tabObj.BlockIds {
+		if layoutBlockIds[blockId] == "" {
+			return fmt.Errorf("block %q is missing from the layout", blockId)
+		}
+	}
+	for _, blockId := range []string{focusedBlockId, magnifiedBlockId} {
+		if blockId != "" && layoutBlockIds[blockId] == "" {
+			return fmt.Errorf("block %q not found in the layout", blockId)
+		}
+	}
+	layoutStateObj.RootNode = rootNode
+	layoutStateObj.LeafOrder = &leafOrder
+	layoutStateObj.FocusedNodeId = layoutBlockIds[focusedBlockId]
+	layoutStateObj.MagnifiedNodeId = layoutBlockIds[magnifiedBlockId]
+	action := waveobj.LayoutActionData{ActionType: LayoutActionDataType_Replace}
+	if layoutStateObj.PendingBackendActions == nil {
+		layoutStateObj.PendingBackendActions = &[]waveobj.LayoutActionData{action}
+	} else {
+		*layoutStateObj.PendingBackendActions = append(*layoutStateObj.PendingBackendActions, action)
+	}
+	err = wstore.DBUpdate(ctx, layoutStateObj)
+	if err != nil {
+		return fmt.Errorf("unable to update layout state: %w", err)
+	}
+	return nil
+}
+
+func PublishLayoutChange(tabId string) {
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_LayoutChange,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Tab, tabId).String()},
+		Data:   wps.LayoutChangeData{TabId: tabId},
+	})
+}
+
 func ApplyPortableLayout(ctx context.Context, tabId string, layout PortableLayout) error {
 	log.Printf("ApplyPortableLayout, tabId: %s, layout: %v\n", tabId, layout)
 	actions := make([]waveobj.LayoutActionData, len(layout)+1)
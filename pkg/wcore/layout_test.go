@@ -0,0 +1,31 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+)
+
+func TestPublishFocusChange(t *testing.T) {
+	tabScope := waveobj.MakeORef(waveobj.OType_Tab, "tab1").String()
+	eventCh := make(chan wps.WaveEvent, 1)
+	unsubFn := wps.Broker.SubscribeLocal(wps.Event_FocusChange, tabScope, func(event wps.WaveEvent) {
+		eventCh <- event
+	})
+	defer unsubFn()
+	PublishFocusChange("tab1", "block1")
+	select {
+	case event := <-eventCh:
+		data, ok := event.Data.(wps.FocusChangeData)
+		if !ok || data.TabId != "tab1" || data.BlockId != "block1" {
+			t.Errorf("unexpected event data: %#v", event.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for focuschange event")
+	}
+}
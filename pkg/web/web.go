@@ -245,6 +245,9 @@ func handleLocalStreamFile(w http.ResponseWriter, r *http.Request, path string,
 
 func handleRemoteStreamFile(w http.ResponseWriter, _ *http.Request, conn string, path string, no404 bool) error {
 	client := wshserver.GetMainRpcClient()
+	if err := wshserver.ApplyConnReadLimit(conn); err != nil {
+		log.Printf("streamfile: %v\n", err)
+	}
 	streamFileData := wshrpc.CommandRemoteStreamFileData{Path: path}
 	route := wshutil.MakeConnectionRouteId(conn)
 	rtnCh := wshclient.RemoteStreamFileCommand(client, streamFileData, &wshrpc.RpcOpts{Route: route})
@@ -290,7 +293,8 @@ func handleRemoteStreamFile(w http.ResponseWriter, _ *http.Request, conn string,
 			continue
 		}
 		decoder := base64.NewDecoder(base64.StdEncoding, bytes.NewReader([]byte(respUnion.Response.Data64)))
-		_, err := io.Copy(w, decoder)
+		n, err := io.Copy(w, decoder)
+		wshserver.RecordConnRead(conn, int(n))
 		if err != nil {
 			log.Printf("error streaming file %q: %v\n", path, err)
 			// not sure what to do here, the headers have already been sent.
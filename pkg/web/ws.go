@@ -173,10 +173,11 @@ func ReadLoop(conn *websocket.Conn, outputCh chan any, closeCh chan any, rpcInpu
 		conn.SetReadDeadline(time.Now().Add(readWait))
 		msgType := getMessageType(jmsg)
 		if msgType == "pong" {
-			// nothing
+			wshutil.DefaultRouter.RouteHeartbeat(routeId)
 			continue
 		}
 		if msgType == "ping" {
+			wshutil.DefaultRouter.RouteHeartbeat(routeId)
 			now := time.Now()
 			pongMessage := map[string]interface{}{"type": "pong", "stime": now.UnixMilli()}
 			outputCh <- pongMessage
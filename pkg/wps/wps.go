@@ -5,9 +5,18 @@
 package wps
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 )
@@ -17,6 +26,8 @@ import (
 
 const MaxPersist = 4096
 const ReMakeArrThreshold = 10 * 1024
+const MaxRouteQueueSize = 4096
+const MinScopeSeqsPrune = 16 * 1024
 
 type Client interface {
 	SendEvent(routeId string, event WaveEvent)
@@ -42,12 +53,44 @@ type BrokerType struct {
 	Lock       *sync.Mutex
 	Client     Client
 	SubMap     map[string]*BrokerSubscription
+	SubInfoMap map[string]map[string]*SubscriptionInfo // routeid => event => subinfo
 	PersistMap map[persistKey]*persistEventWrap
+	LocalSubs  map[int]*localSub // in-process subscribers (see SubscribeLocal), lazily created
+	nextSubId  int
+
+	scopeSeqs      map[persistKey]int64           // last assigned seq (see WaveEvent.ScopeSeqs), lazily created
+	scopeSeqsPrune int                            // size of scopeSeqs that triggers the next prune
+	routeQueues    map[string]*routeQueue         // routeid => events waiting to be sent, lazily created
+	queueSeq       int64                          // last seq assigned to a queued event (across all routes)
+	throttles      map[throttleKey]*throttleState // see WaveEvent.ThrottleKey, lazily created
+}
+
+// events are queued per route under the broker lock (in seq order), then sent by whichever publisher
+// finds the queue idle.  that publisher only sends up to its own events (so its latency is bounded by
+// what was queued before it), anything queued behind it is handed off to a background sender.  only one
+// sender owns a queue at a time, so concurrent publishers can't reorder a route's events, and a
+// single-threaded publisher still sends synchronously.
+type routeQueue struct {
+	Events  []queuedEvent
+	Sending bool
+	Dropped int // events dropped since the queue overflowed (logged when it drains)
+}
+
+type queuedEvent struct {
+	Seq   int64
+	Event WaveEvent
+}
+
+type localSub struct {
+	Event string
+	Scope string // empty matches all scopes
+	Fn    func(WaveEvent)
 }
 
 var Broker = &BrokerType{
 	Lock:       &sync.Mutex{},
 	SubMap:     make(map[string]*BrokerSubscription),
+	SubInfoMap: make(map[string]map[string]*SubscriptionInfo),
 	PersistMap: make(map[persistKey]*persistEventWrap),
 }
 
@@ -79,9 +122,33 @@ func (b *BrokerType) Subscribe(subRouteId string, sub SubscriptionRequest) {
 	if sub.Event == "" {
 		return
 	}
+	client, untilSeq := b.subscribe(subRouteId, sub)
+	if client != nil {
+		b.sendRouteQueue(client, subRouteId, untilSeq)
+	}
+}
+
+// subscribes to each valid request (in order, so a later request for the same event replaces an earlier one).
+// invalid requests are skipped and reported together in the returned error.
+func (b *BrokerType) SubscribeBatch(subRouteId string, subs []SubscriptionRequest) error {
+	var errs []error
+	for idx, sub := range subs {
+		if err := sub.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: %w", idx, err))
+			continue
+		}
+		b.Subscribe(subRouteId, sub)
+	}
+	return errors.Join(errs...)
+}
+
+// queues the events to replay (if sub.ReplayLast is set), returns the client to send them with (nil if none
+// were queued) and the queue seq to send up to
+func (b *BrokerType) subscribe(subRouteId string, sub SubscriptionRequest) (Client, int64) {
 	b.Lock.Lock()
 	defer b.Lock.Unlock()
 	b.unsubscribe_nolock(subRouteId, sub.Event)
+	b.addSubInfo_nolock(subRouteId, sub)
 	bs := b.SubMap[sub.Event]
 	if bs == nil {
 		bs = &BrokerSubscription{
@@ -93,16 +160,70 @@ func (b *BrokerType) Subscribe(subRouteId string, sub SubscriptionRequest) {
 	}
 	if sub.AllScopes {
 		bs.AllSubs = utilfn.AddElemToSliceUniq(bs.AllSubs, subRouteId)
-		return
+	} else {
+		for _, scope := range sub.Scopes {
+			starMatch := scopeHasStarMatch(scope)
+			if starMatch {
+				addStrToScopeMap(bs.StarSubs, scope, subRouteId)
+			} else {
+				addStrToScopeMap(bs.ScopeSubs, scope, subRouteId)
+			}
+		}
 	}
-	for _, scope := range sub.Scopes {
-		starMatch := scopeHasStarMatch(scope)
-		if starMatch {
-			addStrToScopeMap(bs.StarSubs, scope, subRouteId)
-		} else {
-			addStrToScopeMap(bs.ScopeSubs, scope, subRouteId)
+	if !sub.ReplayLast || b.Client == nil {
+		return nil, 0
+	}
+	replayEvents := b.getReplayEvents_nolock(sub)
+	if len(replayEvents) == 0 {
+		return nil, 0
+	}
+	for _, event := range replayEvents {
+		b.queueEvent_nolock(subRouteId, *event)
+	}
+	return b.Client, b.queueSeq
+}
+
+// last persisted event for each matching scope (star scopes match per concrete scope, so "block:*" replays one event per block).
+// events persisted under multiple scopes are only returned once.
+func (b *BrokerType) getReplayEvents_nolock(sub SubscriptionRequest) []*WaveEvent {
+	var matchingScopes []string
+	for key, pe := range b.PersistMap {
+		if key.Event != sub.Event || len(pe.Events) == 0 {
+			continue
+		}
+		if sub.AllScopes || subScopesMatch(sub.Scopes, key.Scope) {
+			matchingScopes = append(matchingScopes, key.Scope)
 		}
 	}
+	sort.Strings(matchingScopes)
+	seen := make(map[*WaveEvent]bool)
+	var rtn []*WaveEvent
+	for _, scope := range matchingScopes {
+		pe := b.PersistMap[persistKey{Event: sub.Event, Scope: scope}]
+		lastEvent := pe.Events[len(pe.Events)-1]
+		if seen[lastEvent] {
+			continue
+		}
+		seen[lastEvent] = true
+		rtn = append(rtn, lastEvent)
+	}
+	return rtn
+}
+
+func subScopesMatch(subScopes []string, scope string) bool {
+	if scope == "" {
+		// "" holds every persisted event, only used for allscopes
+		return false
+	}
+	for _, subScope := range subScopes {
+		if subScope == scope {
+			return true
+		}
+		if scopeHasStarMatch(subScope) && utilfn.StarMatchString(subScope, scope, ":") {
+			return true
+		}
+	}
+	return false
 }
 
 func (bs *BrokerSubscription) IsEmpty() bool {
@@ -143,7 +264,34 @@ func (b *BrokerType) Unsubscribe(subRouteId string, eventName string) {
 	b.unsubscribe_nolock(subRouteId, eventName)
 }
 
+func (b *BrokerType) addSubInfo_nolock(subRouteId string, sub SubscriptionRequest) {
+	routeSubs := b.SubInfoMap[subRouteId]
+	if routeSubs == nil {
+		routeSubs = make(map[string]*SubscriptionInfo)
+		b.SubInfoMap[subRouteId] = routeSubs
+	}
+	routeSubs[sub.Event] = &SubscriptionInfo{
+		RouteId:   subRouteId,
+		Event:     sub.Event,
+		Scopes:    append([]string(nil), sub.Scopes...),
+		AllScopes: sub.AllScopes,
+		SubTs:     time.Now().UnixMilli(),
+	}
+}
+
+func (b *BrokerType) removeSubInfo_nolock(subRouteId string, eventName string) {
+	routeSubs := b.SubInfoMap[subRouteId]
+	if routeSubs == nil {
+		return
+	}
+	delete(routeSubs, eventName)
+	if len(routeSubs) == 0 {
+		delete(b.SubInfoMap, subRouteId)
+	}
+}
+
 func (b *BrokerType) unsubscribe_nolock(subRouteId string, eventName string) {
+	b.removeSubInfo_nolock(subRouteId, eventName)
 	bs := b.SubMap[eventName]
 	if bs == nil {
 		return
@@ -160,9 +308,25 @@ func (b *BrokerType) unsubscribe_nolock(subRouteId string, eventName string) {
 	}
 }
 
+func (b *BrokerType) UnsubscribeBatch(subRouteId string, eventNames []string) {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	for _, eventName := range eventNames {
+		b.unsubscribe_nolock(subRouteId, eventName)
+	}
+}
+
 func (b *BrokerType) UnsubscribeAll(subRouteId string) {
 	b.Lock.Lock()
 	defer b.Lock.Unlock()
+	delete(b.SubInfoMap, subRouteId)
+	if rq := b.routeQueues[subRouteId]; rq != nil {
+		// a sender that owns the queue removes it once it finds it empty
+		rq.Events = nil
+		if !rq.Sending {
+			delete(b.routeQueues, subRouteId)
+		}
+	}
 	for eventType, bs := range b.SubMap {
 		bs.AllSubs = utilfn.RemoveElemFromSlice(bs.AllSubs, subRouteId)
 		removeStrFromScopeMapAll(bs.StarSubs, subRouteId)
@@ -173,6 +337,34 @@ func (b *BrokerType) UnsubscribeAll(subRouteId string) {
 	}
 }
 
+// returns the active subscriptions for the given route (sorted by event)
+func (b *BrokerType) ListSubscriptions(subRouteId string) []SubscriptionInfo {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	return listSubInfos(b.SubInfoMap[subRouteId])
+}
+
+// returns all active subscriptions (sorted by route, then event)
+func (b *BrokerType) ListAllSubscriptions() []SubscriptionInfo {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	var rtn []SubscriptionInfo
+	for _, routeId := range utilfn.GetOrderedMapKeys(b.SubInfoMap) {
+		rtn = append(rtn, listSubInfos(b.SubInfoMap[routeId])...)
+	}
+	return rtn
+}
+
+func listSubInfos(routeSubs map[string]*SubscriptionInfo) []SubscriptionInfo {
+	var rtn []SubscriptionInfo
+	for _, eventName := range utilfn.GetOrderedMapKeys(routeSubs) {
+		subInfo := *routeSubs[eventName]
+		subInfo.Scopes = append([]string(nil), subInfo.Scopes...)
+		rtn = append(rtn, subInfo)
+	}
+	return rtn
+}
+
 // does not take wildcards, use "" for all
 func (b *BrokerType) ReadEventHistory(eventType string, scope string, maxItems int) []*WaveEvent {
 	if maxItems <= 0 {
@@ -194,7 +386,50 @@ func (b *BrokerType) ReadEventHistory(eventType string, scope string, maxItems i
 	return rtn
 }
 
-func (b *BrokerType) persistEvent(event WaveEvent) {
+// grabs the newest maxItems events under the lock.  only the slice header is copied: events are
+// never modified once persisted, and appends/trims never overwrite the returned range.
+func (b *BrokerType) snapshotEventHistory(eventType string, scope string, maxItems int) []*WaveEvent {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	pe := b.PersistMap[persistKey{Event: eventType, Scope: scope}]
+	if pe == nil || len(pe.Events) == 0 {
+		return nil
+	}
+	maxItems = min(maxItems, len(pe.Events))
+	return pe.Events[len(pe.Events)-maxItems:]
+}
+
+// like ReadEventHistory, but marshals each event's data, which is where the time goes when a big history is
+// returned over rpc.  works newest first, stopping if ctx is done (returns ctx.Err()) or once deadline has passed.
+// on deadline the newest events marshaled so far are returned with truncated=true.  a zero deadline means no
+// time budget.  the returned events are copies with Data set to the marshaled json.RawMessage.
+func (b *BrokerType) ReadEventHistoryCtx(ctx context.Context, eventType string, scope string, maxItems int, deadline time.Time) ([]*WaveEvent, bool, error) {
+	if maxItems <= 0 {
+		return nil, false, nil
+	}
+	events := b.snapshotEventHistory(eventType, scope, maxItems)
+	rtn := make([]*WaveEvent, len(events))
+	for idx := len(events) - 1; idx >= 0; idx-- {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+		if idx < len(events)-1 && !deadline.IsZero() && time.Now().After(deadline) {
+			return rtn[idx+1:], true, nil
+		}
+		eventCopy := *events[idx]
+		if eventCopy.Data != nil {
+			barr, err := json.Marshal(eventCopy.Data)
+			if err != nil {
+				return nil, false, fmt.Errorf("error marshaling event data: %w", err)
+			}
+			eventCopy.Data = json.RawMessage(barr)
+		}
+		rtn[idx] = &eventCopy
+	}
+	return rtn, false, nil
+}
+
+func (b *BrokerType) persistEvent_nolock(event WaveEvent) {
 	if event.Persist <= 0 {
 		return
 	}
@@ -207,8 +442,6 @@ func (b *BrokerType) persistEvent(event WaveEvent) {
 		scopeMap[scope] = true
 	}
 	scopeMap[""] = true
-	b.Lock.Lock()
-	defer b.Lock.Unlock()
 	for scope := range scopeMap {
 		key := persistKey{Event: event.Event, Scope: scope}
 		pe := b.PersistMap[key]
@@ -221,6 +454,9 @@ func (b *BrokerType) persistEvent(event WaveEvent) {
 		}
 		pe.Events = append(pe.Events, &event)
 		pe.ArrTotalAdds++
+		if len(pe.Events) > numPersist {
+			pe.Events = pe.Events[len(pe.Events)-numPersist:]
+		}
 		if pe.ArrTotalAdds > ReMakeArrThreshold {
 			pe.Events = append([]*WaveEvent{}, pe.Events...)
 			pe.ArrTotalAdds = len(pe.Events)
@@ -228,21 +464,237 @@ func (b *BrokerType) persistEvent(event WaveEvent) {
 	}
 }
 
+// subscribes an in-process listener (no route), returns the unsubscribe function
+// fn is called synchronously from Publish (event.Data is not marshaled), so it must not block
+func (b *BrokerType) SubscribeLocal(eventName string, scope string, fn func(WaveEvent)) func() {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	if b.LocalSubs == nil {
+		b.LocalSubs = make(map[int]*localSub)
+	}
+	b.nextSubId++
+	subId := b.nextSubId
+	b.LocalSubs[subId] = &localSub{Event: eventName, Scope: scope, Fn: fn}
+	return func() {
+		b.Lock.Lock()
+		defer b.Lock.Unlock()
+		delete(b.LocalSubs, subId)
+	}
+}
+
+func (b *BrokerType) getMatchingLocalSubs_nolock(event WaveEvent) []*localSub {
+	var rtn []*localSub
+	for _, sub := range b.LocalSubs {
+		if sub.Event != event.Event {
+			continue
+		}
+		if sub.Scope == "" || utilfn.ContainsStr(event.Scopes, sub.Scope) {
+			rtn = append(rtn, sub)
+		}
+	}
+	return rtn
+}
+
+// local subscribers are called after the event is queued for its routes, outside of the broker lock
 func (b *BrokerType) Publish(event WaveEvent) {
 	// log.Printf("BrokerType.Publish: %v\n", event)
-	if event.Persist > 0 {
-		b.persistEvent(event)
+	if event.ThrottleKey != "" && event.ThrottleMs > 0 && !b.throttleEvent(event) {
+		return
+	}
+	b.publishNow(event)
+}
+
+func (b *BrokerType) publishNow(event WaveEvent) {
+	client, routeIds, untilSeq, localSubs := b.queuePublishedEvent(&event)
+	for _, sub := range localSubs {
+		sub.Fn(event)
 	}
-	client := b.GetClient()
 	if client == nil {
 		return
 	}
-	routeIds := b.getMatchingRouteIds(event)
 	for _, routeId := range routeIds {
-		client.SendEvent(routeId, event)
+		b.sendRouteQueue(client, routeId, untilSeq)
+	}
+}
+
+// assigns seqs, persists and queues the event (plus any dead letter) under one lock so that seq order,
+// history order and delivery order all agree.  returns the routes that need their queues sent and the
+// queue seq to send them up to.
+func (b *BrokerType) queuePublishedEvent(event *WaveEvent) (Client, []string, int64, []*localSub) {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	var localSubs []*localSub
+	if len(event.TargetRoutes) == 0 {
+		b.assignScopeSeqs_nolock(event)
+		if event.Persist > 0 {
+			b.persistEvent_nolock(*event)
+		}
+		localSubs = b.getMatchingLocalSubs_nolock(*event)
+	}
+	client := b.Client
+	if client == nil {
+		return nil, nil, 0, localSubs
+	}
+	routeIds := b.getMatchingRouteIds_nolock(*event)
+	var sendRouteIds []string
+	if len(event.TargetRoutes) > 0 {
+		var skippedRoutes []string
+		routeIds, skippedRoutes = filterTargetRoutes(routeIds, event.TargetRoutes)
+		if len(skippedRoutes) > 0 && event.DeadLetter && event.Sender != "" {
+			b.queueEvent_nolock(event.Sender, WaveEvent{
+				Event: Event_DeadLetter,
+				Data:  DeadLetterData{Event: event.Event, Scopes: event.Scopes, SkippedRoutes: skippedRoutes},
+			})
+			sendRouteIds = append(sendRouteIds, event.Sender)
+		}
+	}
+	for _, routeId := range routeIds {
+		b.queueEvent_nolock(routeId, *event)
+	}
+	sendRouteIds = append(sendRouteIds, routeIds...)
+	return client, sendRouteIds, b.queueSeq, localSubs
+}
+
+func (b *BrokerType) assignScopeSeqs_nolock(event *WaveEvent) {
+	if len(event.Scopes) == 0 {
+		return
+	}
+	if b.scopeSeqs == nil {
+		b.scopeSeqs = make(map[persistKey]int64)
+	}
+	event.ScopeSeqs = make(map[string]int64, len(event.Scopes))
+	for _, scope := range event.Scopes {
+		key := persistKey{Event: event.Event, Scope: scope}
+		b.scopeSeqs[key]++
+		event.ScopeSeqs[scope] = b.scopeSeqs[key]
+	}
+	if len(b.scopeSeqs) > max(b.scopeSeqsPrune, MinScopeSeqsPrune) {
+		b.pruneScopeSeqs_nolock()
 	}
 }
 
+// drops the seqs for scopes that nobody can observe (no persisted history and no subscribers), they restart
+// at 1 if the scope is published again.  the next prune happens when the map has doubled.
+func (b *BrokerType) pruneScopeSeqs_nolock() {
+	for key := range b.scopeSeqs {
+		if pe := b.PersistMap[key]; pe != nil && len(pe.Events) > 0 {
+			continue
+		}
+		probe := WaveEvent{Event: key.Event, Scopes: []string{key.Scope}}
+		if len(b.getMatchingRouteIds_nolock(probe)) > 0 || len(b.getMatchingLocalSubs_nolock(probe)) > 0 {
+			continue
+		}
+		delete(b.scopeSeqs, key)
+	}
+	b.scopeSeqsPrune = 2 * len(b.scopeSeqs)
+}
+
+// a full queue drops the event (logged, and the subscriber sees the gap in ScopeSeqs)
+func (b *BrokerType) queueEvent_nolock(routeId string, event WaveEvent) {
+	if b.routeQueues == nil {
+		b.routeQueues = make(map[string]*routeQueue)
+	}
+	rq := b.routeQueues[routeId]
+	if rq == nil {
+		rq = &routeQueue{}
+		b.routeQueues[routeId] = rq
+	}
+	if len(rq.Events) >= MaxRouteQueueSize {
+		if rq.Dropped == 0 {
+			log.Printf("[wps] event queue for route %s is full (%d events), dropping events\n", routeId, len(rq.Events))
+		}
+		rq.Dropped++
+		return
+	}
+	b.queueSeq++
+	rq.Events = append(rq.Events, queuedEvent{Seq: b.queueSeq, Event: event})
+}
+
+// returns the queued events up to untilSeq and marks the queue as sending.  isOwner is set when the caller
+// is already sending the queue, otherwise nil is returned if someone else is.  handOff is set (and the queue
+// stays marked as sending) when only events past untilSeq are left, the caller must start a background
+// sender for them.  an empty queue is removed.
+func (b *BrokerType) takeRouteQueue(routeId string, untilSeq int64, isOwner bool) (events []WaveEvent, handOff bool) {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	rq := b.routeQueues[routeId]
+	if rq == nil {
+		return nil, false
+	}
+	if rq.Sending && !isOwner {
+		return nil, false
+	}
+	if len(rq.Events) == 0 {
+		if rq.Dropped > 0 {
+			log.Printf("[wps] event queue for route %s drained, %d events were dropped\n", routeId, rq.Dropped)
+		}
+		delete(b.routeQueues, routeId)
+		return nil, false
+	}
+	rq.Sending = true
+	numTake := 0
+	for numTake < len(rq.Events) && rq.Events[numTake].Seq <= untilSeq {
+		numTake++
+	}
+	if numTake == 0 {
+		return nil, true
+	}
+	for _, qe := range rq.Events[:numTake] {
+		events = append(events, qe.Event)
+	}
+	rq.Events = rq.Events[numTake:]
+	if len(rq.Events) == 0 {
+		rq.Events = nil
+	}
+	return events, false
+}
+
+// sends the route's events up to untilSeq if the queue is idle (see routeQueue)
+func (b *BrokerType) sendRouteQueue(client Client, routeId string, untilSeq int64) {
+	events, handOff := b.takeRouteQueue(routeId, untilSeq, false)
+	for len(events) > 0 {
+		for _, event := range events {
+			client.SendEvent(routeId, event)
+		}
+		events, handOff = b.takeRouteQueue(routeId, untilSeq, true)
+	}
+	if !handOff {
+		return
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("wps:sendRouteQueue", recover())
+		}()
+		for {
+			events, _ := b.takeRouteQueue(routeId, math.MaxInt64, true)
+			if len(events) == 0 {
+				return
+			}
+			for _, event := range events {
+				client.SendEvent(routeId, event)
+			}
+		}
+	}()
+}
+
+// returns the target routes that are in routeIds (in target order, deduped) and the ones that are not
+func filterTargetRoutes(routeIds []string, targetRoutes []string) ([]string, []string) {
+	var rtn, skipped []string
+	seen := make(map[string]bool)
+	for _, routeId := range targetRoutes {
+		if seen[routeId] {
+			continue
+		}
+		seen[routeId] = true
+		if utilfn.ContainsStr(routeIds, routeId) {
+			rtn = append(rtn, routeId)
+		} else {
+			skipped = append(skipped, routeId)
+		}
+	}
+	return rtn, skipped
+}
+
 func (b *BrokerType) SendUpdateEvents(updates waveobj.UpdatesRtnType) {
 	for _, update := range updates {
 		b.Publish(WaveEvent{
@@ -253,9 +705,7 @@ func (b *BrokerType) SendUpdateEvents(updates waveobj.UpdatesRtnType) {
 	}
 }
 
-func (b *BrokerType) getMatchingRouteIds(event WaveEvent) []string {
-	b.Lock.Lock()
-	defer b.Lock.Unlock()
+func (b *BrokerType) getMatchingRouteIds_nolock(event WaveEvent) []string {
 	bs := b.SubMap[event.Event]
 	if bs == nil {
 		return nil
@@ -0,0 +1,430 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type recordingClient struct {
+	lock     sync.Mutex
+	events   []WaveEvent
+	routeIds []string
+}
+
+func (c *recordingClient) SendEvent(routeId string, event WaveEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, event)
+	c.routeIds = append(c.routeIds, routeId)
+}
+
+func makeTestBroker() (*BrokerType, *recordingClient) {
+	client := &recordingClient{}
+	broker := &BrokerType{
+		Lock:       &sync.Mutex{},
+		SubMap:     make(map[string]*BrokerSubscription),
+		SubInfoMap: make(map[string]map[string]*SubscriptionInfo),
+		PersistMap: make(map[persistKey]*persistEventWrap),
+	}
+	broker.SetClient(client)
+	return broker, client
+}
+
+// events queued behind another publisher's are sent in the background
+func waitRouteQueuesDrained(t *testing.T, broker *BrokerType) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		broker.Lock.Lock()
+		numQueues := len(broker.routeQueues)
+		broker.Lock.Unlock()
+		if numQueues == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d route queues to drain", numQueues)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blocks SendEvent for events whose data is in gates until the gate is closed
+type gatedClient struct {
+	recordingClient
+	gates   map[any]chan struct{}
+	started chan any
+}
+
+func (c *gatedClient) SendEvent(routeId string, event WaveEvent) {
+	if gate := c.gates[event.Data]; gate != nil {
+		c.started <- event.Data
+		<-gate
+	}
+	c.recordingClient.SendEvent(routeId, event)
+}
+
+func makeGatedTestBroker(gatedData ...any) (*BrokerType, *gatedClient) {
+	broker, _ := makeTestBroker()
+	client := &gatedClient{gates: make(map[any]chan struct{}), started: make(chan any, len(gatedData))}
+	for _, data := range gatedData {
+		client.gates[data] = make(chan struct{})
+	}
+	broker.SetClient(client)
+	return broker, client
+}
+
+func TestSubscribeReplayLast(t *testing.T) {
+	broker, client := makeTestBroker()
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"block:1"}, Data: "a", Persist: 10})
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"block:1"}, Data: "b", Persist: 10})
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"block:2"}, Data: "c", Persist: 10})
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"tab:1"}, Data: "d", Persist: 10})
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"block:3"}, Data: "not persisted"})
+
+	broker.Subscribe("route1", SubscriptionRequest{Event: "status", Scopes: []string{"block:*"}, ReplayLast: true})
+	if len(client.events) != 2 {
+		t.Fatalf("expected 2 replayed events, got %d: %v", len(client.events), client.events)
+	}
+	if client.events[0].Data != "b" || client.events[1].Data != "c" {
+		t.Errorf("unexpected replayed events: %v", client.events)
+	}
+
+	client.events = nil
+	broker.Subscribe("route2", SubscriptionRequest{Event: "status", AllScopes: true, ReplayLast: true})
+	if len(client.events) != 3 {
+		t.Errorf("expected 3 replayed events for allscopes, got %d: %v", len(client.events), client.events)
+	}
+
+	client.events = nil
+	broker.Subscribe("route3", SubscriptionRequest{Event: "status", Scopes: []string{"block:1"}})
+	if len(client.events) != 0 {
+		t.Errorf("expected no replay without replaylast, got %v", client.events)
+	}
+}
+
+func TestPublishTargetRoutes(t *testing.T) {
+	broker, client := makeTestBroker()
+	broker.Subscribe("route1", SubscriptionRequest{Event: "msg", AllScopes: true})
+	broker.Subscribe("route2", SubscriptionRequest{Event: "msg", Scopes: []string{"block:1"}})
+	broker.Subscribe("route3", SubscriptionRequest{Event: "msg", Scopes: []string{"block:2"}})
+
+	// route3 is subscribed but not to this scope, route4 isn't subscribed at all
+	broker.Publish(WaveEvent{
+		Event:        "msg",
+		Scopes:       []string{"block:1"},
+		Sender:       "sender",
+		TargetRoutes: []string{"route2", "route3", "route4"},
+		DeadLetter:   true,
+		Persist:      1,
+	})
+	if len(client.events) != 2 {
+		t.Fatalf("expected delivery to route2 plus a dead letter, got %v", client.events)
+	}
+	deadLetter := client.events[0]
+	if deadLetter.Event != Event_DeadLetter {
+		t.Fatalf("expected dead letter event, got %v", deadLetter)
+	}
+	dlData := deadLetter.Data.(DeadLetterData)
+	if len(dlData.SkippedRoutes) != 2 || dlData.SkippedRoutes[0] != "route3" || dlData.SkippedRoutes[1] != "route4" {
+		t.Errorf("unexpected skipped routes: %v", dlData.SkippedRoutes)
+	}
+	if len(broker.ReadEventHistory("msg", "block:1", 10)) != 0 {
+		t.Errorf("targeted events should not be persisted")
+	}
+
+	err := WaveEvent{Event: "msg", TargetRoutes: []string{"bad route"}}.ValidateTargetRoutes()
+	if err == nil {
+		t.Errorf("expected route with whitespace to be rejected")
+	}
+}
+
+// cancels the ctx once it has been marshaled cancelAfter times
+type cancelingMarshaler struct {
+	count       *atomic.Int32
+	cancelAfter int32
+	cancelFn    context.CancelFunc
+}
+
+func (m cancelingMarshaler) MarshalJSON() ([]byte, error) {
+	if m.count.Add(1) == m.cancelAfter {
+		m.cancelFn()
+	}
+	return []byte("1"), nil
+}
+
+func TestReadEventHistoryCtx(t *testing.T) {
+	broker, _ := makeTestBroker()
+	for i := 0; i < MaxPersist; i++ {
+		broker.Publish(WaveEvent{Event: "sysinfo", Scopes: []string{"conn"}, Data: i, Persist: MaxPersist})
+	}
+	getData := func(event *WaveEvent) string {
+		return string(event.Data.(json.RawMessage))
+	}
+
+	events, truncated, err := broker.ReadEventHistoryCtx(context.Background(), "sysinfo", "conn", MaxPersist, time.Time{})
+	if err != nil || truncated || len(events) != MaxPersist {
+		t.Fatalf("expected full history, got %d events (truncated:%v err:%v)", len(events), truncated, err)
+	}
+	if getData(events[0]) != "0" || getData(events[MaxPersist-1]) != fmt.Sprint(MaxPersist-1) {
+		t.Errorf("expected events oldest first, got %v ... %v", getData(events[0]), getData(events[MaxPersist-1]))
+	}
+	if history := broker.ReadEventHistory("sysinfo", "conn", 1); history[0].Data != MaxPersist-1 {
+		t.Errorf("the persisted events were modified: %v", history[0].Data)
+	}
+
+	// past the budget: only the newest event comes back
+	events, truncated, err = broker.ReadEventHistoryCtx(context.Background(), "sysinfo", "conn", MaxPersist, time.Now().Add(-time.Second))
+	if err != nil || !truncated || len(events) != 1 || getData(events[0]) != fmt.Sprint(MaxPersist-1) {
+		t.Fatalf("expected a truncated read with the newest event, got %d events (truncated:%v err:%v)", len(events), truncated, err)
+	}
+
+	// cancelling mid-read stops the scan at the next event
+	broker, _ = makeTestBroker()
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	marshalCount := &atomic.Int32{}
+	for i := 0; i < MaxPersist; i++ {
+		data := cancelingMarshaler{count: marshalCount, cancelAfter: 10, cancelFn: cancelFn}
+		broker.Publish(WaveEvent{Event: "sysinfo", Scopes: []string{"conn"}, Data: data, Persist: MaxPersist})
+	}
+	events, _, err = broker.ReadEventHistoryCtx(ctx, "sysinfo", "conn", MaxPersist, time.Time{})
+	if err != context.Canceled || events != nil {
+		t.Errorf("expected canceled read to stop with no events, got %d events (err:%v)", len(events), err)
+	}
+	if count := marshalCount.Load(); count != 10 {
+		t.Errorf("expected the scan to stop right after the cancel, marshaled %d events", count)
+	}
+}
+
+func TestSubscribeLocal(t *testing.T) {
+	broker, _ := makeTestBroker()
+	var got []any
+	unsubFn := broker.SubscribeLocal("blockfile", "block:1", func(event WaveEvent) {
+		got = append(got, event.Data)
+	})
+	broker.Publish(WaveEvent{Event: "blockfile", Scopes: []string{"block:1"}, Data: "a"})
+	broker.Publish(WaveEvent{Event: "blockfile", Scopes: []string{"block:2"}, Data: "b"})
+	broker.Publish(WaveEvent{Event: "other", Scopes: []string{"block:1"}, Data: "c"})
+	unsubFn()
+	broker.Publish(WaveEvent{Event: "blockfile", Scopes: []string{"block:1"}, Data: "d"})
+	if len(got) != 1 || got[0] != "a" {
+		t.Errorf("expected only the matching event before unsubscribe, got %v", got)
+	}
+}
+
+func TestConnChangeScopes(t *testing.T) {
+	broker, client := makeTestBroker()
+	broker.Subscribe("global", SubscriptionRequest{Event: Event_ConnChange, AllScopes: true})
+	broker.Subscribe("watch-a", SubscriptionRequest{Event: Event_ConnChange, Scopes: []string{MakeConnScope("user@a")}})
+	broker.Subscribe("watch-b", SubscriptionRequest{Event: Event_ConnChange, Scopes: []string{MakeConnScope("user@b")}})
+
+	broker.Publish(MakeConnChangeEvent("user@a", "connected"))
+	sort.Strings(client.routeIds)
+	if len(client.routeIds) != 2 || client.routeIds[0] != "global" || client.routeIds[1] != "watch-a" {
+		t.Fatalf("expected delivery to global and watch-a only, got %v", client.routeIds)
+	}
+
+	// a late subscriber to one connection gets that connection's current status
+	client.events, client.routeIds = nil, nil
+	broker.Publish(MakeConnChangeEvent("user@b", "error"))
+	client.events, client.routeIds = nil, nil
+	broker.Subscribe("late", SubscriptionRequest{Event: Event_ConnChange, Scopes: []string{MakeConnScope("user@b")}, ReplayLast: true})
+	if len(client.events) != 1 || client.events[0].Data != "error" {
+		t.Errorf("expected the last user@b status to be replayed, got %v", client.events)
+	}
+}
+
+func TestPublishOrderedPerScope(t *testing.T) {
+	broker, client := makeTestBroker()
+	const numPublishers = 8
+	const numEvents = 500
+	broker.Subscribe("route1", SubscriptionRequest{Event: "update", AllScopes: true})
+	broker.Subscribe("route2", SubscriptionRequest{Event: "update", Scopes: []string{"block:*"}})
+	var wg sync.WaitGroup
+	for pubIdx := 0; pubIdx < numPublishers; pubIdx++ {
+		wg.Add(1)
+		go func(pubIdx int) {
+			defer wg.Done()
+			scope := fmt.Sprintf("block:%d", pubIdx%3)
+			for idx := 0; idx < numEvents; idx++ {
+				broker.Publish(WaveEvent{Event: "update", Scopes: []string{scope}, Data: idx})
+			}
+		}(pubIdx)
+	}
+	wg.Wait()
+	waitRouteQueuesDrained(t, broker)
+
+	if len(client.events) != 2*numPublishers*numEvents {
+		t.Fatalf("expected %d deliveries, got %d", 2*numPublishers*numEvents, len(client.events))
+	}
+	lastSeq := make(map[string]int64) // routeid + scope => seq
+	for idx, event := range client.events {
+		scope := event.Scopes[0]
+		key := client.routeIds[idx] + "|" + scope
+		seq := event.ScopeSeqs[scope]
+		if seq != lastSeq[key]+1 {
+			t.Fatalf("route %s scope %s: expected seq %d, got %d", client.routeIds[idx], scope, lastSeq[key]+1, seq)
+		}
+		lastSeq[key] = seq
+	}
+	if len(broker.routeQueues) != 0 {
+		t.Errorf("expected the route queues to be drained, got %d", len(broker.routeQueues))
+	}
+}
+
+func TestSubscribeBatch(t *testing.T) {
+	broker, client := makeTestBroker()
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"block:1"}, Data: "ready", Persist: 1})
+	broker.Publish(WaveEvent{Event: "config", Data: "cfg", Persist: 1})
+
+	err := broker.SubscribeBatch("route1", []SubscriptionRequest{
+		{Event: "status", Scopes: []string{"block:1"}, ReplayLast: true},
+		{Event: "meta", Scopes: []string{"bad scope"}},
+		{Event: "config", AllScopes: true, ReplayLast: true},
+		{Event: ""},
+	})
+	if err == nil || !strings.Contains(err.Error(), "subscription 1") || !strings.Contains(err.Error(), "subscription 3") {
+		t.Errorf("expected errors for subscriptions 1 and 3, got %v", err)
+	}
+	subs := broker.ListSubscriptions("route1")
+	if len(subs) != 2 || subs[0].Event != "config" || subs[1].Event != "status" {
+		t.Errorf("expected the valid subscriptions to register, got %v", subs)
+	}
+	if len(client.events) != 2 || client.events[0].Data != "ready" || client.events[1].Data != "cfg" {
+		t.Errorf("expected both replays, got %v", client.events)
+	}
+
+	broker.UnsubscribeBatch("route1", []string{"status", "config"})
+	if subs := broker.ListSubscriptions("route1"); len(subs) != 0 {
+		t.Errorf("expected no subscriptions after the batch unsubscribe, got %v", subs)
+	}
+}
+
+func TestPublishThrottle(t *testing.T) {
+	broker, client := makeTestBroker()
+	broker.Subscribe("route1", SubscriptionRequest{Event: "progress", AllScopes: true})
+	for i := 0; i < 10; i++ {
+		broker.Publish(WaveEvent{Event: "progress", Scopes: []string{"block:1"}, Data: i, ThrottleKey: "block:1", ThrottleMs: 50})
+	}
+	// other keys (and events without a key) are not held back by the burst
+	broker.Publish(WaveEvent{Event: "progress", Scopes: []string{"block:2"}, Data: "other", ThrottleKey: "block:2", ThrottleMs: 50})
+	broker.Publish(WaveEvent{Event: "progress", Scopes: []string{"block:1"}, Data: "unthrottled"})
+	getData := func() []any {
+		client.lock.Lock()
+		defer client.lock.Unlock()
+		var rtn []any
+		for _, event := range client.events {
+			rtn = append(rtn, event.Data)
+		}
+		return rtn
+	}
+	if data := getData(); fmt.Sprint(data) != "[0 other unthrottled]" {
+		t.Fatalf("expected only the leading events before the window ends, got %v", data)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if data := getData(); fmt.Sprint(data) != "[0 other unthrottled 9]" {
+		t.Errorf("expected the latest event to be delivered once after the window, got %v", data)
+	}
+}
+
+func TestPublishLatencyBounded(t *testing.T) {
+	broker, client := makeGatedTestBroker("first", "second")
+	broker.Subscribe("route1", SubscriptionRequest{Event: "update", AllScopes: true})
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		broker.Publish(WaveEvent{Event: "update", Data: "first"})
+	}()
+	<-client.started
+	// queued behind "first", so this publisher finds the queue busy and returns right away
+	broker.Publish(WaveEvent{Event: "update", Data: "second"})
+	close(client.gates["first"])
+	select {
+	case <-firstDone:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("the first publisher is stuck sending the second publisher's event")
+	}
+	if data := <-client.started; data != "second" {
+		t.Fatalf("expected the second event to be sent in the background, got %v", data)
+	}
+	close(client.gates["second"])
+	waitRouteQueuesDrained(t, broker)
+	if len(client.events) != 2 || client.events[0].Data != "first" || client.events[1].Data != "second" {
+		t.Errorf("expected both events in order, got %v", client.events)
+	}
+}
+
+func TestRouteQueueOverflow(t *testing.T) {
+	broker, client := makeGatedTestBroker("blocker")
+	broker.Subscribe("route1", SubscriptionRequest{Event: "update", AllScopes: true})
+	go broker.Publish(WaveEvent{Event: "update", Data: "blocker"})
+	<-client.started
+	const numExtra = 10
+	for i := 0; i < MaxRouteQueueSize+numExtra; i++ {
+		broker.Publish(WaveEvent{Event: "update", Scopes: []string{"block:1"}, Data: i})
+	}
+	broker.Lock.Lock()
+	dropped := broker.routeQueues["route1"].Dropped
+	broker.Lock.Unlock()
+	if dropped != numExtra {
+		t.Errorf("expected %d dropped events, got %d", numExtra, dropped)
+	}
+	close(client.gates["blocker"])
+	waitRouteQueuesDrained(t, broker)
+	if len(client.events) != 1+MaxRouteQueueSize {
+		t.Fatalf("expected %d events, got %d", 1+MaxRouteQueueSize, len(client.events))
+	}
+	// the subscriber can see the gap
+	broker.Publish(WaveEvent{Event: "update", Scopes: []string{"block:1"}, Data: "after"})
+	lastEvent := client.events[len(client.events)-1]
+	if expectedSeq := int64(MaxRouteQueueSize + numExtra + 1); lastEvent.ScopeSeqs["block:1"] != expectedSeq {
+		t.Errorf("expected seq %d after the overflow, got %d", expectedSeq, lastEvent.ScopeSeqs["block:1"])
+	}
+}
+
+func TestPruneScopeSeqs(t *testing.T) {
+	broker, _ := makeTestBroker()
+	broker.Subscribe("route1", SubscriptionRequest{Event: "update", Scopes: []string{"block:sub"}})
+	broker.Publish(WaveEvent{Event: "update", Scopes: []string{"block:sub"}})
+	broker.Publish(WaveEvent{Event: "update", Scopes: []string{"block:persist"}, Persist: 1})
+	for i := 0; i < MinScopeSeqsPrune; i++ {
+		broker.Publish(WaveEvent{Event: "update", Scopes: []string{fmt.Sprintf("block:%d", i)}})
+	}
+	if len(broker.scopeSeqs) > MinScopeSeqsPrune {
+		t.Errorf("expected the unobserved scope seqs to be pruned, got %d", len(broker.scopeSeqs))
+	}
+	for _, scope := range []string{"block:sub", "block:persist"} {
+		if broker.scopeSeqs[persistKey{Event: "update", Scope: scope}] != 1 {
+			t.Errorf("expected the seq for %s to be kept", scope)
+		}
+	}
+}
+
+func TestReplayBeforeLiveEvents(t *testing.T) {
+	broker, client := makeGatedTestBroker("old")
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"block:1"}, Data: "old", Persist: 1})
+	subDone := make(chan struct{})
+	go func() {
+		defer close(subDone)
+		broker.Subscribe("route1", SubscriptionRequest{Event: "status", Scopes: []string{"block:1"}, ReplayLast: true})
+	}()
+	// the replay is queued with the subscribe, so a live event published while it is being sent waits behind it
+	<-client.started
+	broker.Publish(WaveEvent{Event: "status", Scopes: []string{"block:1"}, Data: "live", Persist: 1})
+	close(client.gates["old"])
+	<-subDone
+	waitRouteQueuesDrained(t, broker)
+	if len(client.events) != 2 || client.events[0].Data != "old" || client.events[1].Data != "live" {
+		t.Errorf("expected the replay before the live event, got %v", client.events)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wps
+
+import (
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+const MaxThrottleMs = 60 * 1000
+const ThrottlePruneSize = 1024 // idle throttle entries are removed once there are this many
+
+type throttleKey struct {
+	Event string
+	Key   string
+}
+
+type throttleState struct {
+	lastSent time.Time
+	window   time.Duration
+	pending  *WaveEvent // the latest event held back in the current window
+	timer    *time.Timer
+}
+
+func (e WaveEvent) getThrottleWindow() time.Duration {
+	return time.Duration(min(e.ThrottleMs, MaxThrottleMs)) * time.Millisecond
+}
+
+func (b *BrokerType) pruneThrottles_nolock(now time.Time) {
+	if len(b.throttles) < ThrottlePruneSize {
+		return
+	}
+	for key, ts := range b.throttles {
+		if ts.timer == nil && now.Sub(ts.lastSent) >= ts.window {
+			delete(b.throttles, key)
+		}
+	}
+}
+
+// returns true if the event should be published now, otherwise it replaces the key's pending event
+// (which is published when the window ends)
+func (b *BrokerType) throttleEvent(event WaveEvent) bool {
+	b.Lock.Lock()
+	defer b.Lock.Unlock()
+	if b.throttles == nil {
+		b.throttles = make(map[throttleKey]*throttleState)
+	}
+	now := time.Now()
+	key := throttleKey{Event: event.Event, Key: event.ThrottleKey}
+	ts := b.throttles[key]
+	if ts == nil {
+		b.pruneThrottles_nolock(now)
+		ts = &throttleState{}
+		b.throttles[key] = ts
+	}
+	ts.window = event.getThrottleWindow()
+	if ts.timer == nil && now.Sub(ts.lastSent) >= ts.window {
+		ts.lastSent = now
+		return true
+	}
+	ts.pending = &event
+	if ts.timer == nil {
+		ts.timer = time.AfterFunc(ts.lastSent.Add(ts.window).Sub(now), func() {
+			defer func() {
+				panichandler.PanicHandler("wps:flushThrottle", recover())
+			}()
+			b.flushThrottle(key)
+		})
+	}
+	return false
+}
+
+func (b *BrokerType) flushThrottle(key throttleKey) {
+	b.Lock.Lock()
+	ts := b.throttles[key]
+	var event *WaveEvent
+	if ts != nil {
+		event = ts.pending
+		ts.pending = nil
+		ts.timer = nil
+		ts.lastSent = time.Now()
+	}
+	b.Lock.Unlock()
+	if event != nil {
+		b.publishNow(*event)
+	}
+}
@@ -1,6 +1,12 @@
 package wps
 
-import "github.com/wavetermdev/waveterm/pkg/util/utilfn"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+)
 
 const (
 	Event_BlockClose       = "blockclose"
@@ -13,24 +19,132 @@ const (
 	Event_UserInput        = "userinput"
 	Event_RouteGone        = "route:gone"
 	Event_WorkspaceUpdate  = "workspace:update"
+	Event_DeadLetter       = "event:deadletter"
+	Event_ServerShutdown   = "server:shutdown"
+	Event_FocusChange      = "focuschange"
+	Event_LayoutChange     = "layoutchange"
 )
 
+const MaxRouteIdLen = 256
+
 type WaveEvent struct {
 	Event   string   `json:"event"`
 	Scopes  []string `json:"scopes,omitempty"`
 	Sender  string   `json:"sender,omitempty"`
 	Persist int      `json:"persist,omitempty"`
 	Data    any      `json:"data,omitempty"`
+
+	// per (event, scope) sequence numbers, assigned by the broker on publish (targeted events don't get them).
+	// delivery to a route is in publish order, so a subscriber that sees a scope's seq jump has missed events
+	// (the route's queue overflowed).
+	ScopeSeqs map[string]int64 `json:"scopeseqs,omitempty"`
+
+	// point-to-point delivery.  scopes are matched first (as usual), then delivery is limited to the
+	// matching routes listed here.  targets that aren't subscribed are skipped.  targeted events are
+	// never persisted (they would otherwise be replayed to other routes).
+	TargetRoutes []string `json:"targetroutes,omitempty"`
+	DeadLetter   bool     `json:"deadletter,omitempty"` // send an Event_DeadLetter event back to Sender listing skipped targets
+
+	// coalescing for high frequency events.  events of the same type sharing a ThrottleKey are delivered
+	// at most once per ThrottleMs (the first one right away, then the latest one at the end of the window).
+	// the events in between are dropped entirely (not delivered, persisted or given seqs), so only use a key
+	// for events where the latest state is all that matters (e.g. "metachange:<oref>"), never for
+	// incremental data like blockfile appends.  the events sharing a key should have the same scopes/targets.
+	ThrottleKey string `json:"throttlekey,omitempty"`
+	ThrottleMs  int    `json:"throttlems,omitempty"` // capped at MaxThrottleMs, ignored without ThrottleKey
+}
+
+// data for Event_DeadLetter
+type DeadLetterData struct {
+	Event         string   `json:"event"`
+	Scopes        []string `json:"scopes,omitempty"`
+	SkippedRoutes []string `json:"skippedroutes"`
+}
+
+// data for Event_FocusChange (scoped to the tab oref)
+type FocusChangeData struct {
+	TabId   string `json:"tabid"`
+	BlockId string `json:"blockid"`
+}
+
+// data for Event_LayoutChange (scoped to the tab oref), sent when the layout is replaced with SetLayoutCommand
+type LayoutChangeData struct {
+	TabId string `json:"tabid"`
+}
+
+func ValidateRouteId(routeId string) error {
+	if routeId == "" {
+		return fmt.Errorf("route id is empty")
+	}
+	if len(routeId) > MaxRouteIdLen {
+		return fmt.Errorf("route id is too long (max %d)", MaxRouteIdLen)
+	}
+	if strings.IndexFunc(routeId, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) >= 0 {
+		return fmt.Errorf("route id %q contains invalid characters", routeId)
+	}
+	return nil
+}
+
+func (e WaveEvent) ValidateTargetRoutes() error {
+	for _, routeId := range e.TargetRoutes {
+		if err := ValidateRouteId(routeId); err != nil {
+			return fmt.Errorf("invalid target route: %w", err)
+		}
+	}
+	return nil
 }
 
 func (e WaveEvent) HasScope(scope string) bool {
 	return utilfn.ContainsStr(e.Scopes, scope)
 }
 
+func MakeConnScope(connName string) string {
+	return "conn:" + connName
+}
+
+// scoped to "conn:<name>" (and the older "connection:<name>") so a subscriber can watch a single
+// connection.  the last status is persisted per connection, subscribe with ReplayLast to get the current one.
+func MakeConnChangeEvent(connName string, connStatus any) WaveEvent {
+	return WaveEvent{
+		Event: Event_ConnChange,
+		Scopes: []string{
+			MakeConnScope(connName),
+			fmt.Sprintf("connection:%s", connName),
+		},
+		Persist: 1,
+		Data:    connStatus,
+	}
+}
+
 type SubscriptionRequest struct {
+	Event      string   `json:"event"`
+	Scopes     []string `json:"scopes,omitempty"`
+	AllScopes  bool     `json:"allscopes,omitempty"`
+	ReplayLast bool     `json:"replaylast,omitempty"` // on subscribe, send the last persisted event for each matching scope
+}
+
+func (sub SubscriptionRequest) Validate() error {
+	if sub.Event == "" {
+		return fmt.Errorf("event is empty")
+	}
+	if !sub.AllScopes && len(sub.Scopes) == 0 {
+		return fmt.Errorf("no scopes for event %q (set allscopes to match every scope)", sub.Event)
+	}
+	for _, scope := range sub.Scopes {
+		if scope == "" || strings.IndexFunc(scope, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("invalid scope %q for event %q", scope, sub.Event)
+		}
+	}
+	return nil
+}
+
+// returned by the event list subs commands (for debugging subscriptions)
+type SubscriptionInfo struct {
+	RouteId   string   `json:"routeid"`
 	Event     string   `json:"event"`
 	Scopes    []string `json:"scopes,omitempty"`
 	AllScopes bool     `json:"allscopes,omitempty"`
+	SubTs     int64    `json:"subts"`
 }
 
 const (
@@ -42,8 +156,11 @@ const (
 )
 
 type WSFileEventData struct {
-	ZoneId   string `json:"zoneid"`
-	FileName string `json:"filename"`
-	FileOp   string `json:"fileop"`
-	Data64   string `json:"data64"`
+	ZoneId       string         `json:"zoneid"`
+	FileName     string         `json:"filename"`
+	FileOp       string         `json:"fileop"`
+	Data64       string         `json:"data64"`
+	Size         int64          `json:"size,omitempty"`         // file size after the op (when known)
+	Offset       int64          `json:"offset,omitempty"`       // file offset of Data64 (appends, when Size is set)
+	IJsonCommand map[string]any `json:"ijsoncommand,omitempty"` // the applied command (ijson appends only)
 }
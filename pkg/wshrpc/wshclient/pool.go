@@ -0,0 +1,246 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// Client is a pooled rpc client for out-of-process automation.  it connects to wavesrv over the
+// domain socket named in a WAVETERM_JWT token.  each pooled transport authenticates once and
+// keeps its route for the life of the client.  in-process code should use the router instead.
+
+const DefaultClientPoolSize = 1
+const DefaultClientMaxConcurrent = 32
+const ClientAuthTimeoutMs = 5000
+
+var ErrClientClosed = errors.New("wsh client is closed")
+
+type ClientOpts struct {
+	PoolSize      int                // number of socket connections, defaults to 1
+	MaxConcurrent int                // max in-flight calls and streams across the pool, defaults to 32
+	RpcOpts       *wshrpc.RpcOpts    // defaults for every call (route, timeout), copied per call
+	RpcContext    *wshrpc.RpcContext // bound default context, see BindRpcContext
+}
+
+type clientTransport struct {
+	conn net.Conn
+	rpc  *wshutil.WshRpc
+}
+
+type Client struct {
+	lock       *sync.Mutex
+	opts       ClientOpts
+	boundCtx   *wshrpc.RpcContext // protected by lock
+	transports []*clientTransport
+	next       int
+	sem        chan struct{}
+	closeCh    chan struct{}
+	closed     bool
+	inFlight   *sync.WaitGroup
+}
+
+// if jwtToken is empty, WAVETERM_JWT from the environment is used
+func Dial(jwtToken string, opts *ClientOpts) (*Client, error) {
+	if jwtToken == "" {
+		jwtToken = os.Getenv(wshutil.WaveJwtTokenVarName)
+	}
+	if jwtToken == "" {
+		return nil, fmt.Errorf("no jwt token (%s is not set)", wshutil.WaveJwtTokenVarName)
+	}
+	sockName, err := wshutil.ExtractUnverifiedSocketName(jwtToken)
+	if err != nil {
+		return nil, fmt.Errorf("error extracting socket name from jwt token: %w", err)
+	}
+	c := &Client{
+		lock:     &sync.Mutex{},
+		closeCh:  make(chan struct{}),
+		inFlight: &sync.WaitGroup{},
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.RpcContext != nil {
+		c.BindRpcContext(*c.opts.RpcContext)
+	}
+	if c.opts.PoolSize <= 0 {
+		c.opts.PoolSize = DefaultClientPoolSize
+	}
+	if c.opts.MaxConcurrent <= 0 {
+		c.opts.MaxConcurrent = DefaultClientMaxConcurrent
+	}
+	c.sem = make(chan struct{}, c.opts.MaxConcurrent)
+	for i := 0; i < c.opts.PoolSize; i++ {
+		transport, err := dialClientTransport(sockName, jwtToken)
+		if err != nil {
+			c.closeTransports()
+			return nil, err
+		}
+		c.transports = append(c.transports, transport)
+	}
+	return c, nil
+}
+
+func dialClientTransport(sockName string, jwtToken string) (*clientTransport, error) {
+	conn, err := wshutil.DialDomainSocket(sockName)
+	if err != nil {
+		return nil, err
+	}
+	rpc, _, err := wshutil.SetupConnRpcClient(conn, nil, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_, err = AuthenticateCommand(rpc, jwtToken, &wshrpc.RpcOpts{Timeout: ClientAuthTimeoutMs})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error authenticating: %w", err)
+	}
+	return &clientTransport{conn: conn, rpc: rpc}, nil
+}
+
+func (c *Client) closeTransports() {
+	for _, transport := range c.transports {
+		transport.conn.Close()
+	}
+}
+
+// waits for a concurrency slot and picks the next transport (round robin).  must call release() on success.
+func (c *Client) acquire(ctx context.Context) (*clientTransport, error) {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil, ErrClientClosed
+	}
+	c.inFlight.Add(1)
+	c.lock.Unlock()
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		c.inFlight.Done()
+		return nil, ctx.Err()
+	case <-c.closeCh:
+		c.inFlight.Done()
+		return nil, ErrClientClosed
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	transport := c.transports[c.next%len(c.transports)]
+	c.next++
+	return transport, nil
+}
+
+func (c *Client) release() {
+	<-c.sem
+	c.inFlight.Done()
+}
+
+// binds a default RpcContext (Conn, ClientType) for subsequent calls, it fills the matching fields of the
+// call data (tagged `wshdefault`) when they are left unset.  explicit fields in the call data always win.
+func (c *Client) BindRpcContext(rpcCtx wshrpc.RpcContext) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.boundCtx = &rpcCtx
+}
+
+func (c *Client) callOpts() *wshrpc.RpcOpts {
+	var rtn wshrpc.RpcOpts
+	if c.opts.RpcOpts != nil {
+		rtn = *c.opts.RpcOpts
+	}
+	rtn.StreamCancelFn = nil
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.boundCtx != nil && rtn.DefaultContext == nil {
+		ctxCopy := *c.boundCtx
+		rtn.DefaultContext = &ctxCopy
+	}
+	return &rtn
+}
+
+// runs a single (non-streaming) call on a pooled transport.  ctx bounds the wait for a free slot,
+// and its deadline bounds the call itself (passed as opts.Deadline, see RpcOptsFromContext).
+func (c *Client) Call(ctx context.Context, fn func(rpc *wshutil.WshRpc, opts *wshrpc.RpcOpts) error) error {
+	transport, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.release()
+	return fn(transport.rpc, wshrpc.RpcOptsFromContext(ctx, c.callOpts()))
+}
+
+// runs a streaming call on a pooled transport.  the stream holds its slot until it completes.
+// canceling ctx (or closing the client) cancels the stream on the server.
+func Stream[T any](c *Client, ctx context.Context, fn func(rpc *wshutil.WshRpc, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[T]) chan wshrpc.RespOrErrorUnion[T] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[T])
+	transport, err := c.acquire(ctx)
+	if err != nil {
+		rtnErr(rtn, err)
+		return rtn
+	}
+	opts := c.callOpts()
+	streamCh := fn(transport.rpc, opts)
+	cancelStream := func() {
+		if opts.StreamCancelFn != nil {
+			opts.StreamCancelFn()
+		}
+		for range streamCh {
+		}
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("wshclient.Stream", recover())
+		}()
+		defer close(rtn)
+		defer c.release()
+		for {
+			select {
+			case <-ctx.Done():
+				cancelStream()
+				return
+			case <-c.closeCh:
+				cancelStream()
+				return
+			case resp, ok := <-streamCh:
+				if !ok {
+					return
+				}
+				select {
+				case rtn <- resp:
+				case <-ctx.Done():
+					cancelStream()
+					return
+				case <-c.closeCh:
+					cancelStream()
+					return
+				}
+			}
+		}
+	}()
+	return rtn
+}
+
+// stops new calls, cancels active streams, waits for in-flight calls to finish, then closes the transports
+func (c *Client) Close() error {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.closeCh)
+	c.lock.Unlock()
+	c.inFlight.Wait()
+	c.closeTransports()
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshclient_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const exampleBlockId = "a1b2c3d4-0000-4000-8000-000000000001"
+
+// stands in for wavesrv so the example can run without the app
+type exampleServerImpl struct{}
+
+func (*exampleServerImpl) WshServerImpl() {}
+
+func (*exampleServerImpl) CreateBlockCommand(ctx context.Context, data wshrpc.CommandCreateBlockData) (waveobj.ORef, error) {
+	return waveobj.MakeORef(waveobj.OType_Block, exampleBlockId), nil
+}
+
+func (*exampleServerImpl) StreamWaveAiCommand(ctx context.Context, request wshrpc.WaveAIStreamRequest) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], 2)
+	rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: wshrpc.WaveAIPacketType{Text: "hello"}}
+	rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: wshrpc.WaveAIPacketType{Text: " world\n"}}
+	close(rtn)
+	return rtn
+}
+
+// listens on a domain socket (like wavesrv) and returns a jwt token for it
+func startExampleServer() string {
+	sockDir, err := os.MkdirTemp("", "wshclient-example")
+	if err != nil {
+		log.Fatalf("error creating socket dir: %v", err)
+	}
+	sockName := filepath.Join(sockDir, "wave.sock")
+	listener, err := net.Listen("unix", sockName)
+	if err != nil {
+		log.Fatalf("error listening on %s: %v", sockName, err)
+	}
+	go wshutil.RunWshRpcOverListener(listener)
+	serverRpc := wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, &exampleServerImpl{})
+	wshutil.DefaultRouter.RegisterRoute(wshutil.DefaultRoute, serverRpc, false)
+	jwtToken, err := wshutil.MakeClientJWTToken(wshrpc.RpcContext{BlockId: uuid.NewString()}, sockName)
+	if err != nil {
+		log.Fatalf("error making jwt token: %v", err)
+	}
+	return jwtToken
+}
+
+func ExampleDial() {
+	// out-of-process tools pass "" to use WAVETERM_JWT from the environment
+	jwtToken := startExampleServer()
+	client, err := wshclient.Dial(jwtToken, &wshclient.ClientOpts{
+		PoolSize:      2,
+		MaxConcurrent: 8,
+		RpcOpts:       &wshrpc.RpcOpts{Timeout: 10000},
+	})
+	if err != nil {
+		log.Fatalf("error connecting to wavesrv: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelFn()
+	var blockRef waveobj.ORef
+	err = client.Call(ctx, func(rpc *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
+		var err error
+		blockRef, err = wshclient.CreateBlockCommand(rpc, wshrpc.CommandCreateBlockData{
+			TabId: "<tab-id>",
+			BlockDef: &waveobj.BlockDef{
+				Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "waveai"},
+			},
+		}, opts)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("error creating block: %v", err)
+	}
+	fmt.Printf("created block %s\n", blockRef.String())
+
+	aiReq := wshrpc.WaveAIStreamRequest{
+		Opts:   &wshrpc.WaveAIOptsType{Model: "gpt-4o-mini", APIToken: "<token>"},
+		Prompt: []wshrpc.WaveAIPromptMessageType{{Role: "user", Content: "hello"}},
+	}
+	respCh := wshclient.Stream(client, ctx, func(rpc *wshutil.WshRpc, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+		return wshclient.StreamWaveAiCommand(rpc, aiReq, opts)
+	})
+	for resp := range respCh {
+		if resp.Error != nil {
+			log.Fatalf("stream error: %v", resp.Error)
+		}
+		fmt.Print(resp.Response.Text)
+	}
+	// Output:
+	// created block block:a1b2c3d4-0000-4000-8000-000000000001
+	// hello world
+}
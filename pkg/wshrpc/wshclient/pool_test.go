@@ -0,0 +1,83 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshclient
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func TestClientBoundRpcContext(t *testing.T) {
+	c := &Client{lock: &sync.Mutex{}}
+	c.BindRpcContext(wshrpc.RpcContext{Conn: "ssh:bound"})
+
+	data := applyRpcContextDefaults(wshrpc.CpuHistoryRequest{}, c.callOpts()).(wshrpc.CpuHistoryRequest)
+	if data.Conn != "ssh:bound" {
+		t.Errorf("expected unset conn to fall back to the bound default, got %q", data.Conn)
+	}
+	data = applyRpcContextDefaults(wshrpc.CpuHistoryRequest{Conn: "ssh:explicit"}, c.callOpts()).(wshrpc.CpuHistoryRequest)
+	if data.Conn != "ssh:explicit" {
+		t.Errorf("expected explicit conn to win, got %q", data.Conn)
+	}
+
+	// without a binding nothing is filled
+	unbound := &Client{lock: &sync.Mutex{}}
+	data = applyRpcContextDefaults(wshrpc.CpuHistoryRequest{}, unbound.callOpts()).(wshrpc.CpuHistoryRequest)
+	if data.Conn != "" {
+		t.Errorf("expected no default without a binding, got %q", data.Conn)
+	}
+}
+
+func TestClientCallDeadline(t *testing.T) {
+	c := &Client{
+		lock:       &sync.Mutex{},
+		opts:       ClientOpts{RpcOpts: &wshrpc.RpcOpts{Timeout: 60000}},
+		transports: []*clientTransport{{}},
+		sem:        make(chan struct{}, 1),
+		closeCh:    make(chan struct{}),
+		inFlight:   &sync.WaitGroup{},
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+	var timeoutMs int
+	c.Call(ctx, func(rpc *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
+		timeoutMs, _ = opts.GetTimeoutMs(wshutil.DefaultTimeoutMs)
+		return nil
+	})
+	if timeoutMs <= 0 || timeoutMs > 1000 {
+		t.Errorf("expected the ctx deadline to bound the call timeout, got %dms", timeoutMs)
+	}
+	c.Call(context.Background(), func(rpc *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
+		timeoutMs, _ = opts.GetTimeoutMs(wshutil.DefaultTimeoutMs)
+		return nil
+	})
+	if timeoutMs != 60000 {
+		t.Errorf("expected the default timeout without a ctx deadline, got %dms", timeoutMs)
+	}
+}
+
+func TestBoundRpcContextRequestTypes(t *testing.T) {
+	c := &Client{lock: &sync.Mutex{}}
+	c.BindRpcContext(wshrpc.RpcContext{Conn: "ssh:bound"})
+	tests := []struct {
+		data    any
+		getConn func(any) string
+	}{
+		{wshrpc.CpuDataRequest{}, func(d any) string { return d.(wshrpc.CpuDataRequest).Conn }},
+		{wshrpc.CpuHistoryRequest{}, func(d any) string { return d.(wshrpc.CpuHistoryRequest).Conn }},
+		{wshrpc.CommandOpenResourceData{}, func(d any) string { return d.(wshrpc.CommandOpenResourceData).Conn }},
+		{wshrpc.CommandRemoteClipboardData{}, func(d any) string { return d.(wshrpc.CommandRemoteClipboardData).Conn }},
+		{wshrpc.CommandConnRenameData{}, func(d any) string { return d.(wshrpc.CommandConnRenameData).Connection }},
+	}
+	for _, tc := range tests {
+		if conn := tc.getConn(applyRpcContextDefaults(tc.data, c.callOpts())); conn != "ssh:bound" {
+			t.Errorf("%T: expected unset conn to fall back to the bound default, got %q", tc.data, conn)
+		}
+	}
+}
@@ -37,6 +37,18 @@ func BlockInfoCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (*ws
 	return resp, err
 }
 
+// command "broadcastinput", wshserver.BroadcastInputCommand
+func BroadcastInputCommand(w *wshutil.WshRpc, data wshrpc.CommandBroadcastInputData, opts *wshrpc.RpcOpts) (wshrpc.CommandBroadcastInputRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandBroadcastInputRtnData](w, "broadcastinput", data, opts)
+	return resp, err
+}
+
+// command "capabilities", wshserver.CapabilitiesCommand
+func CapabilitiesCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (wshrpc.CapabilitiesRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CapabilitiesRtnData](w, "capabilities", nil, opts)
+	return resp, err
+}
+
 // command "connconnect", wshserver.ConnConnectCommand
 func ConnConnectCommand(w *wshutil.WshRpc, data wshrpc.ConnRequest, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "connconnect", data, opts)
@@ -61,30 +73,66 @@ func ConnListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]string, error)
 	return resp, err
 }
 
+// command "connlistpage", wshserver.ConnListPageCommand
+func ConnListPageCommand(w *wshutil.WshRpc, data wshrpc.PageOpts, opts *wshrpc.RpcOpts) (wshrpc.StringPage, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.StringPage](w, "connlistpage", data, opts)
+	return resp, err
+}
+
 // command "connreinstallwsh", wshserver.ConnReinstallWshCommand
 func ConnReinstallWshCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "connreinstallwsh", data, opts)
 	return err
 }
 
+// command "connrename", wshserver.ConnRenameCommand
+func ConnRenameCommand(w *wshutil.WshRpc, data wshrpc.CommandConnRenameData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "connrename", data, opts)
+	return err
+}
+
 // command "connstatus", wshserver.ConnStatusCommand
 func ConnStatusCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.ConnStatus, error) {
 	resp, err := sendRpcRequestCallHelper[[]wshrpc.ConnStatus](w, "connstatus", nil, opts)
 	return resp, err
 }
 
+// command "connvalidate", wshserver.ConnValidateCommand
+func ConnValidateCommand(w *wshutil.WshRpc, data wshrpc.CommandConnValidateData, opts *wshrpc.RpcOpts) (wshrpc.ConnValidateRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.ConnValidateRtnData](w, "connvalidate", data, opts)
+	return resp, err
+}
+
+// command "connwarmup", wshserver.ConnWarmupCommand
+func ConnWarmupCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "connwarmup", data, opts)
+	return err
+}
+
 // command "controllerinput", wshserver.ControllerInputCommand
 func ControllerInputCommand(w *wshutil.WshRpc, data wshrpc.CommandBlockInputData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "controllerinput", data, opts)
 	return err
 }
 
+// command "controllerresize", wshserver.ControllerResizeCommand
+func ControllerResizeCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerResizeData, opts *wshrpc.RpcOpts) (waveobj.TermSize, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.TermSize](w, "controllerresize", data, opts)
+	return resp, err
+}
+
 // command "controllerresync", wshserver.ControllerResyncCommand
 func ControllerResyncCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerResyncData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "controllerresync", data, opts)
 	return err
 }
 
+// command "controllerstatus", wshserver.ControllerStatusCommand
+func ControllerStatusCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerStatusData, opts *wshrpc.RpcOpts) (wshrpc.ControllerStatus, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.ControllerStatus](w, "controllerstatus", data, opts)
+	return resp, err
+}
+
 // command "controllerstop", wshserver.ControllerStopCommand
 func ControllerStopCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "controllerstop", data, opts)
@@ -103,6 +151,12 @@ func CreateSubBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandCreateSubBlockD
 	return resp, err
 }
 
+// command "debugdumproutes", wshserver.DebugDumpRoutesCommand
+func DebugDumpRoutesCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.RouteInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.RouteInfo](w, "debugdumproutes", nil, opts)
+	return resp, err
+}
+
 // command "deleteblock", wshserver.DeleteBlockCommand
 func DeleteBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandDeleteBlockData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "deleteblock", data, opts)
@@ -127,6 +181,18 @@ func DisposeCommand(w *wshutil.WshRpc, data wshrpc.CommandDisposeData, opts *wsh
 	return err
 }
 
+// command "eventlistallsubs", wshserver.EventListAllSubsCommand
+func EventListAllSubsCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wps.SubscriptionInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wps.SubscriptionInfo](w, "eventlistallsubs", nil, opts)
+	return resp, err
+}
+
+// command "eventlistsubs", wshserver.EventListSubsCommand
+func EventListSubsCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wps.SubscriptionInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]wps.SubscriptionInfo](w, "eventlistsubs", nil, opts)
+	return resp, err
+}
+
 // command "eventpublish", wshserver.EventPublishCommand
 func EventPublishCommand(w *wshutil.WshRpc, data wps.WaveEvent, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "eventpublish", data, opts)
@@ -139,6 +205,12 @@ func EventReadHistoryCommand(w *wshutil.WshRpc, data wshrpc.CommandEventReadHist
 	return resp, err
 }
 
+// command "eventreadhistorylimited", wshserver.EventReadHistoryLimitedCommand
+func EventReadHistoryLimitedCommand(w *wshutil.WshRpc, data wshrpc.CommandEventReadHistoryData, opts *wshrpc.RpcOpts) (wshrpc.EventReadHistoryRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.EventReadHistoryRtnData](w, "eventreadhistorylimited", data, opts)
+	return resp, err
+}
+
 // command "eventrecv", wshserver.EventRecvCommand
 func EventRecvCommand(w *wshutil.WshRpc, data wps.WaveEvent, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "eventrecv", data, opts)
@@ -151,6 +223,12 @@ func EventSubCommand(w *wshutil.WshRpc, data wps.SubscriptionRequest, opts *wshr
 	return err
 }
 
+// command "eventsubbatch", wshserver.EventSubBatchCommand
+func EventSubBatchCommand(w *wshutil.WshRpc, data []wps.SubscriptionRequest, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "eventsubbatch", data, opts)
+	return err
+}
+
 // command "eventunsub", wshserver.EventUnsubCommand
 func EventUnsubCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "eventunsub", data, opts)
@@ -163,6 +241,12 @@ func EventUnsubAllCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
 	return err
 }
 
+// command "eventunsubbatch", wshserver.EventUnsubBatchCommand
+func EventUnsubBatchCommand(w *wshutil.WshRpc, data []string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "eventunsubbatch", data, opts)
+	return err
+}
+
 // command "fileappend", wshserver.FileAppendCommand
 func FileAppendCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "fileappend", data, opts)
@@ -170,9 +254,9 @@ func FileAppendCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wsh
 }
 
 // command "fileappendijson", wshserver.FileAppendIJsonCommand
-func FileAppendIJsonCommand(w *wshutil.WshRpc, data wshrpc.CommandAppendIJsonData, opts *wshrpc.RpcOpts) error {
-	_, err := sendRpcRequestCallHelper[any](w, "fileappendijson", data, opts)
-	return err
+func FileAppendIJsonCommand(w *wshutil.WshRpc, data wshrpc.CommandAppendIJsonData, opts *wshrpc.RpcOpts) (wshrpc.CommandAppendIJsonRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandAppendIJsonRtnData](w, "fileappendijson", data, opts)
+	return resp, err
 }
 
 // command "filecreate", wshserver.FileCreateCommand
@@ -187,6 +271,12 @@ func FileDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wsh
 	return err
 }
 
+// command "fileflush", wshserver.FileFlushCommand
+func FileFlushCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "fileflush", data, opts)
+	return err
+}
+
 // command "fileinfo", wshserver.FileInfoCommand
 func FileInfoCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) (*wshrpc.WaveFileInfo, error) {
 	resp, err := sendRpcRequestCallHelper[*wshrpc.WaveFileInfo](w, "fileinfo", data, opts)
@@ -205,6 +295,17 @@ func FileReadCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrp
 	return resp, err
 }
 
+// command "filereaddetect", wshserver.FileReadDetectCommand
+func FileReadDetectCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) (wshrpc.FileReadRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.FileReadRtnData](w, "filereaddetect", data, opts)
+	return resp, err
+}
+
+// command "filetail", wshserver.FileTailCommand
+func FileTailCommand(w *wshutil.WshRpc, data wshrpc.CommandFileTailData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.FileReadChunk](w, "filetail", data, opts)
+}
+
 // command "filewrite", wshserver.FileWriteCommand
 func FileWriteCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "filewrite", data, opts)
@@ -217,12 +318,42 @@ func FocusWindowCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) er
 	return err
 }
 
+// command "getconnbandwidth", wshserver.GetConnBandwidthCommand
+func GetConnBandwidthCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (wshrpc.ConnBandwidth, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.ConnBandwidth](w, "getconnbandwidth", data, opts)
+	return resp, err
+}
+
+// command "getcpuhistory", wshserver.GetCpuHistoryCommand
+func GetCpuHistoryCommand(w *wshutil.WshRpc, data wshrpc.CpuHistoryRequest, opts *wshrpc.RpcOpts) ([]wshrpc.TimeSeriesData, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.TimeSeriesData](w, "getcpuhistory", data, opts)
+	return resp, err
+}
+
+// command "getfocus", wshserver.GetFocusCommand
+func GetFocusCommand(w *wshutil.WshRpc, data wshrpc.CommandGetFocusData, opts *wshrpc.RpcOpts) (waveobj.ORef, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.ORef](w, "getfocus", data, opts)
+	return resp, err
+}
+
+// command "getlayout", wshserver.GetLayoutCommand
+func GetLayoutCommand(w *wshutil.WshRpc, data wshrpc.CommandGetLayoutData, opts *wshrpc.RpcOpts) (wshrpc.TabLayout, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.TabLayout](w, "getlayout", data, opts)
+	return resp, err
+}
+
 // command "getmeta", wshserver.GetMetaCommand
 func GetMetaCommand(w *wshutil.WshRpc, data wshrpc.CommandGetMetaData, opts *wshrpc.RpcOpts) (waveobj.MetaMapType, error) {
 	resp, err := sendRpcRequestCallHelper[waveobj.MetaMapType](w, "getmeta", data, opts)
 	return resp, err
 }
 
+// command "getrecenterrors", wshserver.GetRecentErrorsCommand
+func GetRecentErrorsCommand(w *wshutil.WshRpc, data wshrpc.CommandRecentErrorsData, opts *wshrpc.RpcOpts) ([]wshrpc.RpcErrorRecord, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.RpcErrorRecord](w, "getrecenterrors", data, opts)
+	return resp, err
+}
+
 // command "getupdatechannel", wshserver.GetUpdateChannelCommand
 func GetUpdateChannelCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (string, error) {
 	resp, err := sendRpcRequestCallHelper[string](w, "getupdatechannel", nil, opts)
@@ -235,6 +366,24 @@ func GetVarCommand(w *wshutil.WshRpc, data wshrpc.CommandVarData, opts *wshrpc.R
 	return resp, err
 }
 
+// command "health", wshserver.HealthCommand
+func HealthCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (wshrpc.HealthRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.HealthRtnData](w, "health", nil, opts)
+	return resp, err
+}
+
+// command "listblockviews", wshserver.ListBlockViewsCommand
+func ListBlockViewsCommand(w *wshutil.WshRpc, data wshrpc.CommandListViewsData, opts *wshrpc.RpcOpts) ([]string, error) {
+	resp, err := sendRpcRequestCallHelper[[]string](w, "listblockviews", data, opts)
+	return resp, err
+}
+
+// command "listcontrollers", wshserver.ListControllersCommand
+func ListControllersCommand(w *wshutil.WshRpc, data wshrpc.CommandListControllersData, opts *wshrpc.RpcOpts) ([]wshrpc.ControllerStatus, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ControllerStatus](w, "listcontrollers", data, opts)
+	return resp, err
+}
+
 // command "message", wshserver.MessageCommand
 func MessageCommand(w *wshutil.WshRpc, data wshrpc.CommandMessageData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "message", data, opts)
@@ -247,18 +396,84 @@ func NotifyCommand(w *wshutil.WshRpc, data wshrpc.WaveNotificationOptions, opts
 	return err
 }
 
+// command "openresource", wshserver.OpenResourceCommand
+func OpenResourceCommand(w *wshutil.WshRpc, data wshrpc.CommandOpenResourceData, opts *wshrpc.RpcOpts) (waveobj.ORef, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.ORef](w, "openresource", data, opts)
+	return resp, err
+}
+
 // command "path", wshserver.PathCommand
 func PathCommand(w *wshutil.WshRpc, data wshrpc.PathCommandData, opts *wshrpc.RpcOpts) (string, error) {
 	resp, err := sendRpcRequestCallHelper[string](w, "path", data, opts)
 	return resp, err
 }
 
-// command "remotefiledelete", wshserver.RemoteFileDeleteCommand
-func RemoteFileDeleteCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
-	_, err := sendRpcRequestCallHelper[any](w, "remotefiledelete", data, opts)
+// command "remotechmod", wshserver.RemoteChmodCommand
+func RemoteChmodCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteChmodData, opts *wshrpc.RpcOpts) (wshrpc.FileOpPreview, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.FileOpPreview](w, "remotechmod", data, opts)
+	return resp, err
+}
+
+// command "remoteclipboardget", wshserver.RemoteClipboardGetCommand
+func RemoteClipboardGetCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "remoteclipboardget", data, opts)
+	return resp, err
+}
+
+// command "remoteclipboardset", wshserver.RemoteClipboardSetCommand
+func RemoteClipboardSetCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteClipboardData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remoteclipboardset", data, opts)
+	return err
+}
+
+// command "remoteexpandpath", wshserver.RemoteExpandPathCommand
+func RemoteExpandPathCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteExpandData, opts *wshrpc.RpcOpts) ([]string, error) {
+	resp, err := sendRpcRequestCallHelper[[]string](w, "remoteexpandpath", data, opts)
+	return resp, err
+}
+
+// command "remoteextractarchive", wshserver.RemoteExtractArchiveCommand
+func RemoteExtractArchiveCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteExtractData, opts *wshrpc.RpcOpts) (wshrpc.CommandRemoteExtractRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandRemoteExtractRtnData](w, "remoteextractarchive", data, opts)
+	return resp, err
+}
+
+// command "remotefilechecksum", wshserver.RemoteFileChecksumCommand
+func RemoteFileChecksumCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "remotefilechecksum", data, opts)
+	return resp, err
+}
+
+// command "remotefileclose", wshserver.RemoteFileCloseCommand
+func RemoteFileCloseCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remotefileclose", data, opts)
 	return err
 }
 
+// command "remotefilecopy", wshserver.RemoteFileCopyCommand
+func RemoteFileCopyCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileCopyData, opts *wshrpc.RpcOpts) (wshrpc.FileOpPreview, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.FileOpPreview](w, "remotefilecopy", data, opts)
+	return resp, err
+}
+
+// command "remotefiledelete", wshserver.RemoteFileDeleteCommand
+func RemoteFileDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileDeleteData, opts *wshrpc.RpcOpts) (wshrpc.FileOpPreview, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.FileOpPreview](w, "remotefiledelete", data, opts)
+	return resp, err
+}
+
+// command "remotefilediff", wshserver.RemoteFileDiffCommand
+func RemoteFileDiffCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteDiffData, opts *wshrpc.RpcOpts) (wshrpc.CommandRemoteDiffRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandRemoteDiffRtnData](w, "remotefilediff", data, opts)
+	return resp, err
+}
+
+// command "remotefileheadtail", wshserver.RemoteFileHeadTailCommand
+func RemoteFileHeadTailCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteHeadTailData, opts *wshrpc.RpcOpts) ([]string, error) {
+	resp, err := sendRpcRequestCallHelper[[]string](w, "remotefileheadtail", data, opts)
+	return resp, err
+}
+
 // command "remotefileinfo", wshserver.RemoteFileInfoCommand
 func RemoteFileInfoCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (*wshrpc.FileInfo, error) {
 	resp, err := sendRpcRequestCallHelper[*wshrpc.FileInfo](w, "remotefileinfo", data, opts)
@@ -271,10 +486,34 @@ func RemoteFileJoinCommand(w *wshutil.WshRpc, data []string, opts *wshrpc.RpcOpt
 	return resp, err
 }
 
+// command "remotefilelines", wshserver.RemoteFileLinesCommand
+func RemoteFileLinesCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileLinesData, opts *wshrpc.RpcOpts) (wshrpc.FileLinesRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.FileLinesRtnData](w, "remotefilelines", data, opts)
+	return resp, err
+}
+
+// command "remotefileopen", wshserver.RemoteFileOpenCommand
+func RemoteFileOpenCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileOpenData, opts *wshrpc.RpcOpts) (wshrpc.RemoteFileOpenRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.RemoteFileOpenRtnData](w, "remotefileopen", data, opts)
+	return resp, err
+}
+
+// command "remotefilereadat", wshserver.RemoteFileReadAtCommand
+func RemoteFileReadAtCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileReadAtData, opts *wshrpc.RpcOpts) (wshrpc.RemoteFileReadAtRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.RemoteFileReadAtRtnData](w, "remotefilereadat", data, opts)
+	return resp, err
+}
+
 // command "remotefilerename", wshserver.RemoteFileRenameCommand
-func RemoteFileRenameCommand(w *wshutil.WshRpc, data [2]string, opts *wshrpc.RpcOpts) error {
-	_, err := sendRpcRequestCallHelper[any](w, "remotefilerename", data, opts)
-	return err
+func RemoteFileRenameCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileRenameData, opts *wshrpc.RpcOpts) (wshrpc.FileOpPreview, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.FileOpPreview](w, "remotefilerename", data, opts)
+	return resp, err
+}
+
+// command "remotefilestat", wshserver.RemoteFileStatCommand
+func RemoteFileStatCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileStatData, opts *wshrpc.RpcOpts) ([]*wshrpc.FileInfo, error) {
+	resp, err := sendRpcRequestCallHelper[[]*wshrpc.FileInfo](w, "remotefilestat", data, opts)
+	return resp, err
 }
 
 // command "remotefiletouch", wshserver.RemoteFileTouchCommand
@@ -283,12 +522,68 @@ func RemoteFileTouchCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts
 	return err
 }
 
+// command "remotefilewriteat", wshserver.RemoteFileWriteAtCommand
+func RemoteFileWriteAtCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileWriteAtData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remotefilewriteat", data, opts)
+	return err
+}
+
+// command "remotefindduplicates", wshserver.RemoteFindDuplicatesCommand
+func RemoteFindDuplicatesCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteDedupData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.DuplicateGroup](w, "remotefindduplicates", data, opts)
+}
+
+// command "remotekillprocess", wshserver.RemoteKillProcessCommand
+func RemoteKillProcessCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteKillData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remotekillprocess", data, opts)
+	return err
+}
+
+// command "remotelistdir", wshserver.RemoteListDirCommand
+func RemoteListDirCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteListDirData, opts *wshrpc.RpcOpts) (wshrpc.FileInfoPage, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.FileInfoPage](w, "remotelistdir", data, opts)
+	return resp, err
+}
+
 // command "remotemkdir", wshserver.RemoteMkdirCommand
-func RemoteMkdirCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+func RemoteMkdirCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteMkdirData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "remotemkdir", data, opts)
 	return err
 }
 
+// command "remotemountinfo", wshserver.RemoteMountInfoCommand
+func RemoteMountInfoCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteMountData, opts *wshrpc.RpcOpts) (wshrpc.MountInfo, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.MountInfo](w, "remotemountinfo", data, opts)
+	return resp, err
+}
+
+// command "remotemultitail", wshserver.RemoteMultiTailCommand
+func RemoteMultiTailCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteMultiTailData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.MultiTailEvent](w, "remotemultitail", data, opts)
+}
+
+// command "remoterunscript", wshserver.RemoteRunScriptCommand
+func RemoteRunScriptCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteScriptData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.ExecOutputChunk](w, "remoterunscript", data, opts)
+}
+
+// command "remotesetreadlimit", wshserver.RemoteSetReadLimitCommand
+func RemoteSetReadLimitCommand(w *wshutil.WshRpc, data int64, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remotesetreadlimit", data, opts)
+	return err
+}
+
+// command "remotestatfs", wshserver.RemoteStatFSCommand
+func RemoteStatFSCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteStatFSData, opts *wshrpc.RpcOpts) (wshrpc.StatFSRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.StatFSRtnData](w, "remotestatfs", data, opts)
+	return resp, err
+}
+
+// command "remotestreamarchive", wshserver.RemoteStreamArchiveCommand
+func RemoteStreamArchiveCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteArchiveData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.ArchiveChunk](w, "remotestreamarchive", data, opts)
+}
+
 // command "remotestreamcpudata", wshserver.RemoteStreamCpuDataCommand
 func RemoteStreamCpuDataCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
 	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "remotestreamcpudata", nil, opts)
@@ -299,18 +594,51 @@ func RemoteStreamFileCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteStreamF
 	return sendRpcRequestResponseStreamHelper[wshrpc.CommandRemoteStreamFileRtnData](w, "remotestreamfile", data, opts)
 }
 
+// command "remotestreamlistdir", wshserver.RemoteStreamListDirCommand
+func RemoteStreamListDirCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteListDirData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.ListDirChunk] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.ListDirChunk](w, "remotestreamlistdir", data, opts)
+}
+
+// command "remotetransfer", wshserver.RemoteTransferCommand
+func RemoteTransferCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteTransferData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.RemoteTransferProgress] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.RemoteTransferProgress](w, "remotetransfer", data, opts)
+}
+
+// command "remotewhich", wshserver.RemoteWhichCommand
+func RemoteWhichCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteWhichData, opts *wshrpc.RpcOpts) ([]string, error) {
+	resp, err := sendRpcRequestCallHelper[[]string](w, "remotewhich", data, opts)
+	return resp, err
+}
+
 // command "remotewritefile", wshserver.RemoteWriteFileCommand
 func RemoteWriteFileCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteWriteFileData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "remotewritefile", data, opts)
 	return err
 }
 
+// command "remotewritefilechunk", wshserver.RemoteWriteFileChunkCommand
+func RemoteWriteFileChunkCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteWriteChunkData, opts *wshrpc.RpcOpts) (wshrpc.RemoteWriteChunkAck, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.RemoteWriteChunkAck](w, "remotewritefilechunk", data, opts)
+	return resp, err
+}
+
 // command "resolveids", wshserver.ResolveIdsCommand
 func ResolveIdsCommand(w *wshutil.WshRpc, data wshrpc.CommandResolveIdsData, opts *wshrpc.RpcOpts) (wshrpc.CommandResolveIdsRtnData, error) {
 	resp, err := sendRpcRequestCallHelper[wshrpc.CommandResolveIdsRtnData](w, "resolveids", data, opts)
 	return resp, err
 }
 
+// command "restoreblock", wshserver.RestoreBlockCommand
+func RestoreBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandRestoreBlockData, opts *wshrpc.RpcOpts) (waveobj.ORef, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.ORef](w, "restoreblock", data, opts)
+	return resp, err
+}
+
+// command "resumestream", wshserver.ResumeStreamCommand
+func ResumeStreamCommand(w *wshutil.WshRpc, data wshrpc.CommandResumeStreamData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.WaveAIPacketType](w, "resumestream", data, opts)
+}
+
 // command "routeannounce", wshserver.RouteAnnounceCommand
 func RouteAnnounceCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "routeannounce", nil, opts)
@@ -335,12 +663,36 @@ func SetConnectionsConfigCommand(w *wshutil.WshRpc, data wshrpc.ConnConfigReques
 	return err
 }
 
+// command "setfocus", wshserver.SetFocusCommand
+func SetFocusCommand(w *wshutil.WshRpc, data wshrpc.CommandSetFocusData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "setfocus", data, opts)
+	return err
+}
+
+// command "setlayout", wshserver.SetLayoutCommand
+func SetLayoutCommand(w *wshutil.WshRpc, data wshrpc.TabLayout, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "setlayout", data, opts)
+	return err
+}
+
 // command "setmeta", wshserver.SetMetaCommand
 func SetMetaCommand(w *wshutil.WshRpc, data wshrpc.CommandSetMetaData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "setmeta", data, opts)
 	return err
 }
 
+// command "setmetabatch", wshserver.SetMetaBatchCommand
+func SetMetaBatchCommand(w *wshutil.WshRpc, data wshrpc.CommandSetMetaBatchData, opts *wshrpc.RpcOpts) ([]wshrpc.SetMetaBatchResult, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.SetMetaBatchResult](w, "setmetabatch", data, opts)
+	return resp, err
+}
+
+// command "setmetapatch", wshserver.SetMetaPatchCommand
+func SetMetaPatchCommand(w *wshutil.WshRpc, data wshrpc.CommandSetMetaPatchData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "setmetapatch", data, opts)
+	return err
+}
+
 // command "setvar", wshserver.SetVarCommand
 func SetVarCommand(w *wshutil.WshRpc, data wshrpc.CommandVarData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "setvar", data, opts)
@@ -353,11 +705,44 @@ func SetViewCommand(w *wshutil.WshRpc, data wshrpc.CommandBlockSetViewData, opts
 	return err
 }
 
+// command "setviewbatch", wshserver.SetViewBatchCommand
+func SetViewBatchCommand(w *wshutil.WshRpc, data []wshrpc.CommandBlockSetViewData, opts *wshrpc.RpcOpts) ([]wshrpc.SetViewBatchResult, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.SetViewBatchResult](w, "setviewbatch", data, opts)
+	return resp, err
+}
+
+// command "shutdown", wshserver.ShutdownCommand
+func ShutdownCommand(w *wshutil.WshRpc, data wshrpc.CommandShutdownData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "shutdown", data, opts)
+	return err
+}
+
+// command "snapshotblock", wshserver.SnapshotBlockCommand
+func SnapshotBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandSnapshotBlockData, opts *wshrpc.RpcOpts) (wshrpc.BlockSnapshot, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.BlockSnapshot](w, "snapshotblock", data, opts)
+	return resp, err
+}
+
+// command "streamcontrolleroutput", wshserver.StreamControllerOutputCommand
+func StreamControllerOutputCommand(w *wshutil.WshRpc, data wshrpc.CommandStreamOutputData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.ControllerOutputChunk] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.ControllerOutputChunk](w, "streamcontrolleroutput", data, opts)
+}
+
 // command "streamcpudata", wshserver.StreamCpuDataCommand
 func StreamCpuDataCommand(w *wshutil.WshRpc, data wshrpc.CpuDataRequest, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
 	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "streamcpudata", data, opts)
 }
 
+// command "streamgpudata", wshserver.StreamGpuDataCommand
+func StreamGpuDataCommand(w *wshutil.WshRpc, data wshrpc.GpuDataRequest, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "streamgpudata", data, opts)
+}
+
+// command "streamsensordata", wshserver.StreamSensorDataCommand
+func StreamSensorDataCommand(w *wshutil.WshRpc, data wshrpc.SensorDataRequest, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "streamsensordata", data, opts)
+}
+
 // command "streamtest", wshserver.StreamTestCommand
 func StreamTestCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[int] {
 	return sendRpcRequestResponseStreamHelper[int](w, "streamtest", nil, opts)
@@ -414,6 +799,12 @@ func WebSelectorCommand(w *wshutil.WshRpc, data wshrpc.CommandWebSelectorData, o
 	return resp, err
 }
 
+// command "whoami", wshserver.WhoAmICommand
+func WhoAmICommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (wshrpc.CommandWhoAmIRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandWhoAmIRtnData](w, "whoami", nil, opts)
+	return resp, err
+}
+
 // command "workspacelist", wshserver.WorkspaceListCommand
 func WorkspaceListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.WorkspaceInfoData, error) {
 	resp, err := sendRpcRequestCallHelper[[]wshrpc.WorkspaceInfoData](w, "workspacelist", nil, opts)
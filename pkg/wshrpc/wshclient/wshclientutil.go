@@ -4,7 +4,9 @@
 package wshclient
 
 import (
+	"context"
 	"errors"
+	"reflect"
 
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
@@ -12,10 +14,26 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
 )
 
+// returns a copy of data with the opts.DefaultContext defaults applied (data itself is never modified)
+func applyRpcContextDefaults(data any, opts *wshrpc.RpcOpts) any {
+	if opts.DefaultContext == nil || data == nil {
+		return data
+	}
+	dataVal := reflect.ValueOf(data)
+	if dataVal.Kind() != reflect.Struct {
+		return data
+	}
+	dataPtr := reflect.New(dataVal.Type())
+	dataPtr.Elem().Set(dataVal)
+	wshrpc.ApplyRpcContextDefaults(dataPtr.Interface(), *opts.DefaultContext)
+	return dataPtr.Elem().Interface()
+}
+
 func sendRpcRequestCallHelper[T any](w *wshutil.WshRpc, command string, data interface{}, opts *wshrpc.RpcOpts) (T, error) {
 	if opts == nil {
 		opts = &wshrpc.RpcOpts{}
 	}
+	data = applyRpcContextDefaults(data, opts)
 	var respData T
 	if w == nil {
 		return respData, errors.New("nil wshrpc passed to wshclient")
@@ -27,6 +45,13 @@ func sendRpcRequestCallHelper[T any](w *wshutil.WshRpc, command string, data int
 		}
 		return respData, nil
 	}
+	timeoutMs, err := opts.GetTimeoutMs(wshutil.DefaultTimeoutMs)
+	if err != nil {
+		return respData, err
+	}
+	if resp, handled, err := wshutil.DefaultRouter.CallLocalImpl(w, command, data, opts, timeoutMs); handled {
+		return localRespHelper[T](resp, err)
+	}
 	resp, err := w.SendRpcRequest(command, data, opts)
 	if err != nil {
 		return respData, err
@@ -38,6 +63,22 @@ func sendRpcRequestCallHelper[T any](w *wshutil.WshRpc, command string, data int
 	return respData, nil
 }
 
+// converts a response from a local (passthrough) call, avoids the json round trip when the types match
+func localRespHelper[T any](resp any, err error) (T, error) {
+	var respData T
+	if err != nil {
+		return respData, err
+	}
+	if resp == nil {
+		return respData, nil
+	}
+	if typedResp, ok := resp.(T); ok {
+		return typedResp, nil
+	}
+	err = utilfn.ReUnmarshal(&respData, resp)
+	return respData, err
+}
+
 func rtnErr[T any](ch chan wshrpc.RespOrErrorUnion[T], err error) {
 	go func() {
 		defer func() {
@@ -57,7 +98,30 @@ func sendRpcRequestResponseStreamHelper[T any](w *wshutil.WshRpc, command string
 		rtnErr(respChan, errors.New("nil wshrpc passed to wshclient"))
 		return respChan
 	}
-	reqHandler, err := w.SendComplexRequest(command, data, opts)
+	data = applyRpcContextDefaults(data, opts)
+	var onStart func(wshrpc.StreamStartData)
+	if !opts.NoStreamStart {
+		onStart = opts.OnStreamStart
+	}
+	timeoutMs, err := opts.GetTimeoutMs(wshutil.DefaultTimeoutMs)
+	if err != nil {
+		rtnErr(respChan, err)
+		return respChan
+	}
+	if localCh, cancelFn, handled, err := wshutil.DefaultRouter.StreamLocalImpl(w, command, data, opts, timeoutMs, onStart); handled {
+		if err != nil {
+			rtnErr(respChan, err)
+			return respChan
+		}
+		if localCh == nil {
+			close(respChan)
+			return respChan
+		}
+		opts.StreamCancelFn = cancelFn
+		go relayLocalStream(localCh, respChan, cancelFn)
+		return respChan
+	}
+	reqHandler, err := w.SendStreamRequest(command, data, opts)
 	if err != nil {
 		rtnErr(respChan, err)
 		return respChan
@@ -77,7 +141,7 @@ func sendRpcRequestResponseStreamHelper[T any](w *wshutil.WshRpc, command string
 			}
 			resp, err := reqHandler.NextResponse()
 			if err != nil {
-				respChan <- wshrpc.RespOrErrorUnion[T]{Error: err}
+				respChan <- wshrpc.RespOrErrorUnion[T]{Error: err, PartialComplete: wshrpc.IsStreamPartialError(err)}
 				break
 			}
 			var respData T
@@ -91,3 +155,61 @@ func sendRpcRequestResponseStreamHelper[T any](w *wshutil.WshRpc, command string
 	}()
 	return respChan
 }
+
+// relays a local (passthrough) response stream into respChan
+// the local impl's channel is normally the exact same type, but fall back to a reflective recv + ReUnmarshal if it is not
+func relayLocalStream[T any](localCh any, respChan chan wshrpc.RespOrErrorUnion[T], cancelFn context.CancelFunc) {
+	defer func() {
+		panichandler.PanicHandler("relayLocalStream", recover())
+	}()
+	defer close(respChan)
+	defer cancelFn()
+	if typedCh, ok := localCh.(chan wshrpc.RespOrErrorUnion[T]); ok {
+		for resp := range typedCh {
+			if resp.Error != nil {
+				resp.PartialComplete = true
+				respChan <- resp
+				go func() {
+					for range typedCh {
+					}
+				}()
+				break
+			}
+			respChan <- resp
+		}
+		return
+	}
+	localChVal := reflect.ValueOf(localCh)
+	for {
+		respVal, ok := localChVal.Recv()
+		if !ok {
+			break
+		}
+		errorVal := respVal.FieldByName("Error")
+		if !errorVal.IsNil() {
+			respChan <- wshrpc.RespOrErrorUnion[T]{Error: errorVal.Interface().(error), PartialComplete: true}
+			go func() {
+				for {
+					if _, ok := localChVal.Recv(); !ok {
+						return
+					}
+				}
+			}()
+			break
+		}
+		var respData T
+		err := utilfn.ReUnmarshal(&respData, respVal.FieldByName("Response").Interface())
+		if err != nil {
+			respChan <- wshrpc.RespOrErrorUnion[T]{Error: err}
+			break
+		}
+		respChan <- wshrpc.RespOrErrorUnion[T]{Response: respData}
+	}
+}
+
+// uploads data to opts.Path on the route in rpcOpts with acknowledged chunks (see wshrpc.RunChunkedWrite)
+func RemoteWriteFileChunked(w *wshutil.WshRpc, data []byte, opts wshrpc.ChunkedWriteOpts, rpcOpts *wshrpc.RpcOpts) error {
+	return wshrpc.RunChunkedWrite(opts, data, func(chunk wshrpc.CommandRemoteWriteChunkData) (wshrpc.RemoteWriteChunkAck, error) {
+		return RemoteWriteFileChunkCommand(w, chunk, rpcOpts)
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshclient_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshremote"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const (
+	testRpcRouteId   = "conn:test-rpc"
+	testLocalRouteId = "conn:test-local"
+	testClientRoute  = "test-client"
+	testLargeSize    = 8 * 1024 * 1024
+)
+
+// returns its own state from getmeta
+type stateServerImpl struct {
+	meta waveobj.MetaMapType
+}
+
+func (*stateServerImpl) WshServerImpl() {}
+
+func (impl *stateServerImpl) GetMetaCommand(ctx context.Context, data wshrpc.CommandGetMetaData) (waveobj.MetaMapType, error) {
+	return impl.meta, nil
+}
+
+var setupOnce sync.Once
+var testClient *wshutil.WshRpc
+
+func setupRoutes() *wshutil.WshRpc {
+	setupOnce.Do(func() {
+		rpcCtx := wshrpc.RpcContext{Conn: "test"}
+		rpcImpl := &wshremote.ServerImpl{LogWriter: io.Discard}
+		wshutil.DefaultRouter.RegisterRoute(testRpcRouteId, wshutil.MakeWshRpc(nil, nil, rpcCtx, rpcImpl), false)
+		localRpc := wshutil.MakeWshRpc(nil, nil, rpcCtx, &wshremote.ServerImpl{LogWriter: io.Discard})
+		wshutil.DefaultRouter.RegisterRoute(testLocalRouteId, localRpc, false)
+		wshutil.DefaultRouter.RegisterLocalImpl(testLocalRouteId, localRpc)
+		testClient = wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil)
+		wshutil.DefaultRouter.RegisterRoute(testClientRoute, testClient, false)
+	})
+	return testClient
+}
+
+func makeLargeFile(t testing.TB) string {
+	fileName := filepath.Join(t.TempDir(), "large.bin")
+	data := make([]byte, testLargeSize)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		t.Fatalf("error writing test file: %v", err)
+	}
+	return fileName
+}
+
+func readFile(t testing.TB, client *wshutil.WshRpc, routeId string, path string) int {
+	rtnCh := wshclient.RemoteStreamFileCommand(client, wshrpc.CommandRemoteStreamFileData{Path: path}, &wshrpc.RpcOpts{Route: routeId, Timeout: 10000})
+	var total int
+	for respUnion := range rtnCh {
+		if respUnion.Error != nil {
+			t.Fatalf("error streaming file: %v", respUnion.Error)
+		}
+		dataBytes, err := base64.StdEncoding.DecodeString(respUnion.Response.Data64)
+		if err != nil {
+			t.Fatalf("error decoding data: %v", err)
+		}
+		total += len(dataBytes)
+	}
+	return total
+}
+
+func TestLocalPassthrough(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	client := setupRoutes()
+	fileName := makeLargeFile(t)
+	rpcInfo, err := wshclient.RemoteFileInfoCommand(client, fileName, &wshrpc.RpcOpts{Route: testRpcRouteId})
+	if err != nil {
+		t.Fatalf("error getting file info (rpc): %v", err)
+	}
+	localInfo, err := wshclient.RemoteFileInfoCommand(client, fileName, &wshrpc.RpcOpts{Route: testLocalRouteId})
+	if err != nil {
+		t.Fatalf("error getting file info (local): %v", err)
+	}
+	if *rpcInfo != *localInfo {
+		t.Errorf("file info mismatch: rpc=%#v local=%#v", rpcInfo, localInfo)
+	}
+	if size := readFile(t, client, testRpcRouteId, fileName); size != testLargeSize {
+		t.Errorf("rpc read size mismatch: %d", size)
+	}
+	if size := readFile(t, client, testLocalRouteId, fileName); size != testLargeSize {
+		t.Errorf("local read size mismatch: %d", size)
+	}
+
+	// the caller can't change the impl's state through the response
+	stateRouteId := "conn:test-state"
+	stateImpl := &stateServerImpl{meta: waveobj.MetaMapType{"list": []any{"a"}}}
+	stateRpc := wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, stateImpl)
+	wshutil.DefaultRouter.RegisterRoute(stateRouteId, stateRpc, false)
+	defer wshutil.DefaultRouter.UnregisterRoute(stateRouteId)
+	wshutil.DefaultRouter.RegisterLocalImpl(stateRouteId, stateRpc)
+	defer wshutil.DefaultRouter.UnregisterLocalImpl(stateRouteId)
+	meta, err := wshclient.GetMetaCommand(client, wshrpc.CommandGetMetaData{}, &wshrpc.RpcOpts{Route: stateRouteId})
+	if err != nil {
+		t.Fatalf("error getting meta (local): %v", err)
+	}
+	meta["added"] = true
+	meta["list"].([]any)[0] = "changed"
+	if len(stateImpl.meta) != 1 || stateImpl.meta["list"].([]any)[0] != "a" {
+		t.Errorf("mutating the response changed the impl's state: %v", stateImpl.meta)
+	}
+}
+
+func benchmarkRead(b *testing.B, routeId string) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	client := setupRoutes()
+	fileName := makeLargeFile(b)
+	b.SetBytes(testLargeSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		readFile(b, client, routeId, fileName)
+	}
+}
+
+func BenchmarkReadRpc(b *testing.B) {
+	benchmarkRead(b, testRpcRouteId)
+}
+
+func BenchmarkReadLocal(b *testing.B) {
+	benchmarkRead(b, testLocalRouteId)
+}
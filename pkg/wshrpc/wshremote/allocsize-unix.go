@@ -0,0 +1,27 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// st_blocks is always in 512 byte units.  returns 0 unless the file is sparse (fewer bytes allocated than its size)
+func sparseAllocSize(finfo fs.FileInfo) int64 {
+	if !finfo.Mode().IsRegular() {
+		return 0
+	}
+	stat, ok := finfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	allocSize := int64(stat.Blocks) * 512
+	if allocSize >= finfo.Size() {
+		return 0
+	}
+	return allocSize
+}
@@ -0,0 +1,94 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestFileEntryTypes(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "regular"), []byte("hello"), 0644)
+	os.Mkdir(filepath.Join(dir, "dir"), 0755)
+	os.Symlink("regular", filepath.Join(dir, "symlink"))
+	if err := syscall.Mkfifo(filepath.Join(dir, "pipe"), 0644); err != nil {
+		t.Fatalf("error making fifo: %v", err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, "socket"))
+	if err != nil {
+		t.Fatalf("error making socket: %v", err)
+	}
+	defer listener.Close()
+	impl := &ServerImpl{}
+	ctx := context.Background()
+
+	expected := map[string]string{
+		"regular": wshrpc.FileEntryType_File,
+		"dir":     wshrpc.FileEntryType_Dir,
+		"symlink": wshrpc.FileEntryType_Symlink,
+		"pipe":    wshrpc.FileEntryType_Pipe,
+		"socket":  wshrpc.FileEntryType_Socket,
+	}
+	for _, shallow := range []bool{false, true} {
+		page, err := impl.RemoteListDirCommand(ctx, wshrpc.CommandRemoteListDirData{Path: dir, Shallow: shallow})
+		if err != nil {
+			t.Fatalf("error listing dir: %v", err)
+		}
+		if len(page.Items) != len(expected) {
+			t.Fatalf("expected %d entries, got %d", len(expected), len(page.Items))
+		}
+		for _, item := range page.Items {
+			if item.EntryType != expected[item.Name] {
+				t.Errorf("%s (shallow:%v): expected type %q, got %q", item.Name, shallow, expected[item.Name], item.EntryType)
+			}
+		}
+	}
+
+	if _, err := os.Stat("/dev/null"); err == nil {
+		finfo, err := impl.RemoteFileStatCommand(ctx, wshrpc.CommandRemoteFileStatData{Paths: []string{"/dev/null"}, ListEntries: true})
+		if err != nil || finfo[0].EntryType != wshrpc.FileEntryType_Device {
+			t.Errorf("expected /dev/null to be a device, got %#v, %v", finfo, err)
+		}
+	}
+}
+
+func TestSparseAllocSize(t *testing.T) {
+	dir := t.TempDir()
+	sparsePath := filepath.Join(dir, "sparse")
+	fd, err := os.Create(sparsePath)
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	fd.WriteAt([]byte("end"), 10*1024*1024)
+	fd.Close()
+	densePath := filepath.Join(dir, "dense")
+	os.WriteFile(densePath, make([]byte, 64*1024), 0644)
+	impl := &ServerImpl{}
+
+	sparse, err := impl.RemoteFileInfoCommand(context.Background(), sparsePath)
+	if err != nil {
+		t.Fatalf("error getting fileinfo: %v", err)
+	}
+	var stat syscall.Stat_t
+	syscall.Stat(sparsePath, &stat)
+	if int64(stat.Blocks)*512 >= sparse.Size {
+		t.Skipf("filesystem doesn't support sparse files")
+	}
+	if sparse.AllocSize <= 0 || sparse.AllocSize >= sparse.Size {
+		t.Errorf("expected a sparse alloc size, got %d (size %d)", sparse.AllocSize, sparse.Size)
+	}
+	dense, err := impl.RemoteFileInfoCommand(context.Background(), densePath)
+	if err != nil || dense.AllocSize != 0 {
+		t.Errorf("expected no alloc size for a dense file, got %#v, %v", dense, err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"syscall"
+)
+
+// extracted files are never opened through a symlink
+const openNoFollow = syscall.O_NOFOLLOW
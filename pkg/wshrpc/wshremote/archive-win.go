@@ -0,0 +1,9 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+// windows has no O_NOFOLLOW (the leaf is checked with Lstat before it is created with O_EXCL)
+const openNoFollow = 0
@@ -0,0 +1,464 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const DefaultMaxArchiveSize = 1024 * 1024 * 1024 // 1G (uncompressed file contents)
+const ArchiveChunkSize = 64 * 1024
+
+// sends the archive bytes as ArchiveChunks of ArchiveChunkSize
+type archiveChunkWriter struct {
+	ctx context.Context
+	ch  chan wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk]
+	buf []byte
+}
+
+func (w *archiveChunkWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= ArchiveChunkSize {
+		if err := w.send(w.buf[:ArchiveChunkSize]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[ArchiveChunkSize:]
+	}
+	return len(p), nil
+}
+
+func (w *archiveChunkWriter) send(data []byte) error {
+	chunk := wshrpc.ArchiveChunk{Data64: base64.StdEncoding.EncodeToString(data)}
+	select {
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case w.ch <- wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk]{Response: chunk}:
+		return nil
+	}
+}
+
+func (w *archiveChunkWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.send(w.buf)
+	w.buf = nil
+	return err
+}
+
+// patterns are matched against both the path relative to the archive root and the base name
+func matchesAnyGlob(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(relPath)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func writeArchive(ctx context.Context, data wshrpc.CommandRemoteArchiveData, rootPath string, out io.Writer) error {
+	maxSize := data.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxArchiveSize
+	}
+	var gzWriter *gzip.Writer
+	switch data.Compression {
+	case "", wshrpc.ArchiveCompression_None:
+	case wshrpc.ArchiveCompression_Gzip:
+		gzWriter = gzip.NewWriter(out)
+		out = gzWriter
+	default:
+		return fmt.Errorf("invalid compression %q", data.Compression)
+	}
+	tarWriter := tar.NewWriter(out)
+	rootBase := filepath.Base(rootPath)
+	var totalSize int64
+	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+		if relPath != "." && matchesAnyGlob(data.Exclude, relPath) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && len(data.Include) > 0 && !matchesAnyGlob(data.Include, relPath) {
+			return nil
+		}
+		finfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var linkTarget string
+		if finfo.Mode()&fs.ModeSymlink != 0 {
+			linkTarget, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(finfo, linkTarget)
+		if err != nil {
+			return fmt.Errorf("cannot archive %q: %w", path, err)
+		}
+		header.Name = filepath.ToSlash(filepath.Join(rootBase, relPath))
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if header.Typeflag == tar.TypeReg {
+			totalSize += header.Size
+			if totalSize > maxSize {
+				return fmt.Errorf("archive exceeds max size of %d bytes", maxSize)
+			}
+		}
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		_, err = io.CopyN(tarWriter, fd, header.Size)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+	if gzWriter != nil {
+		return gzWriter.Close()
+	}
+	return nil
+}
+
+func (impl *ServerImpl) RemoteStreamArchiveCommand(ctx context.Context, data wshrpc.CommandRemoteArchiveData) chan wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RemoteStreamArchiveCommand", recover())
+		}()
+		defer close(ch)
+		rootPath, err := wavebase.ExpandHomeDir(data.Path)
+		if err != nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk]{Error: err}
+			return
+		}
+		rootPath = filepath.Clean(rootPath)
+		if _, err := os.Lstat(rootPath); err != nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk]{Error: fmt.Errorf("cannot archive %q: %w", data.Path, err)}
+			return
+		}
+		chunkWriter := &archiveChunkWriter{ctx: ctx, ch: ch}
+		err = writeArchive(ctx, data, rootPath, chunkWriter)
+		if err == nil {
+			err = chunkWriter.flush()
+		}
+		if err != nil && ctx.Err() == nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.ArchiveChunk]{Error: fmt.Errorf("error archiving %q: %w", data.Path, err)}
+		}
+	}()
+	return ch
+}
+
+// extraction never follows symlinks: every entry is created under the resolved destination, each
+// existing path component is checked with Lstat (symlinks, including ones from earlier entries, are
+// refused), and files are opened with O_EXCL|O_NOFOLLOW.  symlink entries must resolve inside the
+// destination too.
+
+// resolves a tar entry name to a path relative to the destination, rejecting absolute paths and entries that escape it
+func resolveExtractPath(name string) (string, error) {
+	relPath := filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if relPath == "" || relPath == "." {
+		return ".", nil
+	}
+	if !filepath.IsLocal(relPath) {
+		return "", fmt.Errorf("invalid archive entry %q: path escapes destination", name)
+	}
+	return filepath.Clean(relPath), nil
+}
+
+func isPathUnder(dir string, path string) bool {
+	relPath, err := filepath.Rel(dir, path)
+	return err == nil && (relPath == "." || filepath.IsLocal(relPath))
+}
+
+// creates the directories in relPath under realDestDir one at a time, refusing any component that is a
+// symlink or not a directory
+func ensureExtractDir(realDestDir string, relPath string, mode fs.FileMode) error {
+	if relPath == "." {
+		return nil
+	}
+	curPath := realDestDir
+	for _, part := range strings.Split(relPath, string(filepath.Separator)) {
+		curPath = filepath.Join(curPath, part)
+		finfo, err := os.Lstat(curPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			if err := os.Mkdir(curPath, mode); err != nil && !errors.Is(err, fs.ErrExist) {
+				return err
+			}
+			finfo, err = os.Lstat(curPath)
+		}
+		if err != nil {
+			return err
+		}
+		if finfo.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("path component %q is a symlink", part)
+		}
+		if !finfo.IsDir() {
+			return fmt.Errorf("path component %q is not a directory", part)
+		}
+	}
+	return nil
+}
+
+// makes room for a new file or symlink at path (an existing file or symlink is removed, never followed)
+func clearExtractLeaf(path string) error {
+	finfo, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if finfo.IsDir() {
+		return fmt.Errorf("%q is a directory", filepath.Base(path))
+	}
+	return os.Remove(path)
+}
+
+// link targets must be relative, can only go up at the start ("../a" but not "a/..", which would resolve
+// through a) and must resolve inside realDestDir
+func checkSymlinkTarget(realDestDir string, linkPath string, linkname string) error {
+	target := filepath.FromSlash(linkname)
+	if linkname == "" || filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return fmt.Errorf("invalid symlink target %q: must be a relative path", linkname)
+	}
+	seenName := false
+	for _, part := range strings.Split(target, string(filepath.Separator)) {
+		switch part {
+		case "", ".":
+		case "..":
+			if seenName {
+				return fmt.Errorf("invalid symlink target %q: \"..\" after a path component", linkname)
+			}
+		default:
+			seenName = true
+		}
+	}
+	targetPath := filepath.Join(filepath.Dir(linkPath), target)
+	if !isPathUnder(realDestDir, targetPath) {
+		return fmt.Errorf("invalid symlink target %q: points outside destination", linkname)
+	}
+	// the existing part of the target may go through links created by earlier entries
+	for existing := targetPath; isPathUnder(realDestDir, existing); existing = filepath.Dir(existing) {
+		realPath, err := filepath.EvalSymlinks(existing)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !isPathUnder(realDestDir, realPath) {
+			return fmt.Errorf("invalid symlink target %q: resolves outside destination", linkname)
+		}
+		return nil
+	}
+	return nil
+}
+
+func extractArchiveEntry(tarReader *tar.Reader, header *tar.Header, realDestDir string, relPath string, preserveMode bool, remaining int64) (int64, error) {
+	destPath := filepath.Join(realDestDir, relPath)
+	switch header.Typeflag {
+	case tar.TypeDir:
+		mode := fs.FileMode(0755)
+		if preserveMode {
+			mode = header.FileInfo().Mode().Perm()
+		}
+		err := ensureExtractDir(realDestDir, relPath, mode)
+		if err != nil {
+			return 0, err
+		}
+		if preserveMode && relPath != "." {
+			return 0, os.Chmod(destPath, mode)
+		}
+		return 0, nil
+	case tar.TypeReg:
+		if header.Size > remaining {
+			return 0, fmt.Errorf("archive exceeds max size")
+		}
+		if relPath == "." {
+			return 0, fmt.Errorf("cannot write a file over the destination")
+		}
+		err := ensureExtractDir(realDestDir, filepath.Dir(relPath), 0755)
+		if err != nil {
+			return 0, err
+		}
+		mode := fs.FileMode(0644)
+		if preserveMode {
+			mode = header.FileInfo().Mode().Perm()
+		}
+		err = clearExtractLeaf(destPath)
+		if err != nil {
+			return 0, err
+		}
+		fd, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, mode)
+		if err != nil {
+			return 0, err
+		}
+		defer fd.Close()
+		written, err := io.CopyN(fd, tarReader, header.Size)
+		if err != nil {
+			return written, err
+		}
+		if preserveMode {
+			return written, fd.Chmod(mode)
+		}
+		return written, nil
+	case tar.TypeSymlink:
+		if relPath == "." {
+			return 0, fmt.Errorf("cannot replace the destination with a symlink")
+		}
+		err := checkSymlinkTarget(realDestDir, destPath, header.Linkname)
+		if err != nil {
+			return 0, err
+		}
+		err = ensureExtractDir(realDestDir, filepath.Dir(relPath), 0755)
+		if err != nil {
+			return 0, err
+		}
+		err = clearExtractLeaf(destPath)
+		if err != nil {
+			return 0, err
+		}
+		return 0, os.Symlink(header.Linkname, destPath)
+	default:
+		return 0, fmt.Errorf("unsupported archive entry type %q", string(header.Typeflag))
+	}
+}
+
+func openExtractArchive(archivePath string, compression string) (io.Reader, func(), error) {
+	fd, err := os.Open(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	bufReader := bufio.NewReaderSize(fd, ArchiveChunkSize)
+	if compression == "" {
+		if magic, _ := bufReader.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+			compression = wshrpc.ArchiveCompression_Gzip
+		}
+	}
+	switch compression {
+	case "", wshrpc.ArchiveCompression_None:
+		return bufReader, func() { fd.Close() }, nil
+	case wshrpc.ArchiveCompression_Gzip:
+		gzReader, err := gzip.NewReader(bufReader)
+		if err != nil {
+			fd.Close()
+			return nil, nil, fmt.Errorf("error reading gzip archive: %w", err)
+		}
+		return gzReader, func() { gzReader.Close(); fd.Close() }, nil
+	default:
+		fd.Close()
+		return nil, nil, fmt.Errorf("invalid compression %q", compression)
+	}
+}
+
+func (impl *ServerImpl) RemoteExtractArchiveCommand(ctx context.Context, data wshrpc.CommandRemoteExtractData) (wshrpc.CommandRemoteExtractRtnData, error) {
+	var rtn wshrpc.CommandRemoteExtractRtnData
+	if data.ArchivePath == "" {
+		return rtn, fmt.Errorf("archivepath is required")
+	}
+	archivePath, err := wavebase.ExpandHomeDir(data.ArchivePath)
+	if err != nil {
+		return rtn, err
+	}
+	if data.RemoveArchive {
+		defer os.Remove(archivePath)
+	}
+	destDir, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return rtn, err
+	}
+	destDir = filepath.Clean(destDir)
+	maxSize := data.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxArchiveSize
+	}
+	archiveReader, closeFn, err := openExtractArchive(archivePath, data.Compression)
+	if err != nil {
+		return rtn, fmt.Errorf("cannot open archive %q: %w", data.ArchivePath, err)
+	}
+	defer closeFn()
+	err = os.MkdirAll(destDir, 0755)
+	if err != nil {
+		return rtn, fmt.Errorf("cannot create destination %q: %w", data.Path, err)
+	}
+	defer impl.invalidateFileInfo(destDir)
+	realDestDir, err := filepath.EvalSymlinks(destDir)
+	if err != nil {
+		return rtn, fmt.Errorf("cannot resolve destination %q: %w", data.Path, err)
+	}
+	tarReader := tar.NewReader(archiveReader)
+	var totalSize int64
+	for {
+		if ctx.Err() != nil {
+			return rtn, ctx.Err()
+		}
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return rtn, nil
+		}
+		if err != nil {
+			return rtn, fmt.Errorf("error reading archive: %w", err)
+		}
+		relPath, err := resolveExtractPath(header.Name)
+		if err != nil {
+			return rtn, err
+		}
+		written, err := extractArchiveEntry(tarReader, header, realDestDir, relPath, data.PreserveMode, maxSize-totalSize)
+		totalSize += written
+		if err != nil {
+			return rtn, fmt.Errorf("error extracting %q: %w", header.Name, err)
+		}
+		rtn.Entries = append(rtn.Entries, wshrpc.ExtractEntryResult{
+			Name: header.Name,
+			Path: filepath.Join(destDir, relPath),
+			Size: written,
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	os.MkdirAll(filepath.Join(srcDir, "sub"), 0755)
+	os.MkdirAll(filepath.Join(srcDir, "node_modules"), 0755)
+	os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("hello"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "sub", "b.txt"), []byte("world"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "node_modules", "c.txt"), []byte("skip"), 0644)
+
+	impl := &ServerImpl{}
+	ch := impl.RemoteStreamArchiveCommand(context.Background(), wshrpc.CommandRemoteArchiveData{
+		Path:        srcDir,
+		Exclude:     []string{"node_modules"},
+		Compression: wshrpc.ArchiveCompression_Gzip,
+	})
+	var archive bytes.Buffer
+	for resp := range ch {
+		if resp.Error != nil {
+			t.Fatalf("archive error: %v", resp.Error)
+		}
+		data, _ := base64.StdEncoding.DecodeString(resp.Response.Data64)
+		archive.Write(data)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	os.WriteFile(archivePath, archive.Bytes(), 0644)
+	destDir := t.TempDir()
+	rtn, err := impl.RemoteExtractArchiveCommand(context.Background(), wshrpc.CommandRemoteExtractData{
+		Path:          destDir,
+		ArchivePath:   archivePath,
+		RemoveArchive: true,
+	})
+	if err != nil {
+		t.Fatalf("extract error: %v", err)
+	}
+	if _, err := os.Stat(archivePath); !os.IsNotExist(err) {
+		t.Errorf("archive was not removed")
+	}
+	if len(rtn.Entries) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(rtn.Entries))
+	}
+	rootName := filepath.Base(srcDir)
+	contents, err := os.ReadFile(filepath.Join(destDir, rootName, "sub", "b.txt"))
+	if err != nil || string(contents) != "world" {
+		t.Errorf("bad extracted contents %q: %v", contents, err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, rootName, "node_modules")); err == nil {
+		t.Errorf("excluded directory was archived")
+	}
+}
+
+// writes a tar of headers (regular files get header.Size bytes of "x") and returns its path
+func makeTestTar(t *testing.T, headers ...*tar.Header) string {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, header := range headers {
+		tw.WriteHeader(header)
+		if header.Size > 0 {
+			tw.Write(bytes.Repeat([]byte("x"), int(header.Size)))
+		}
+	}
+	tw.Close()
+	archivePath := filepath.Join(t.TempDir(), "archive.tar")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("error writing archive: %v", err)
+	}
+	return archivePath
+}
+
+func TestExtractArchiveTraversal(t *testing.T) {
+	evilFile := &tar.Header{Name: "up/evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}
+	tests := map[string][]*tar.Header{
+		"dotdot":  {{Name: "../evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}},
+		"abs":     {{Name: "/tmp/evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}},
+		"symlink": {{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../"}},
+		"symlinkabs": {
+			{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "/tmp"},
+			evilFile,
+		},
+		"symlinkchain": {
+			{Name: "self", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "self/.."},
+			evilFile,
+		},
+		// "sub/.." is fine lexically, but the symlink created after it makes it resolve outside
+		"symlinklater": {
+			{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "sub/.."},
+			{Name: "sub", Typeflag: tar.TypeSymlink, Linkname: "."},
+			evilFile,
+		},
+		// links inside the destination can't be written through either
+		"writethroughlink": {
+			{Name: "real", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "real"},
+			evilFile,
+		},
+	}
+	impl := &ServerImpl{}
+	for name, headers := range tests {
+		parentDir := t.TempDir()
+		destDir := filepath.Join(parentDir, "dest")
+		_, err := impl.RemoteExtractArchiveCommand(context.Background(), wshrpc.CommandRemoteExtractData{Path: destDir, ArchivePath: makeTestTar(t, headers...)})
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if _, err := os.Stat(filepath.Join(parentDir, "evil.txt")); err == nil {
+			t.Errorf("%s: file written outside destination", name)
+		}
+	}
+}
+
+func TestExtractArchiveExistingLinks(t *testing.T) {
+	impl := &ServerImpl{}
+	outsideDir := t.TempDir()
+	outsideFile := filepath.Join(outsideDir, "target.txt")
+	os.WriteFile(outsideFile, []byte("keep"), 0644)
+	destDir := t.TempDir()
+	os.Symlink(outsideDir, filepath.Join(destDir, "outdir"))
+	os.Symlink(outsideFile, filepath.Join(destDir, "file.txt"))
+
+	// a symlink already in the destination is not followed to write outside it
+	_, err := impl.RemoteExtractArchiveCommand(context.Background(), wshrpc.CommandRemoteExtractData{
+		Path:        destDir,
+		ArchivePath: makeTestTar(t, &tar.Header{Name: "outdir/evil.txt", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}),
+	})
+	if err == nil {
+		t.Errorf("expected an error writing through an existing symlink")
+	}
+	if _, err := os.Stat(filepath.Join(outsideDir, "evil.txt")); err == nil {
+		t.Errorf("file written through an existing symlink")
+	}
+
+	// a file entry replaces a symlink at its path instead of writing to the link's target
+	_, err = impl.RemoteExtractArchiveCommand(context.Background(), wshrpc.CommandRemoteExtractData{
+		Path:        destDir,
+		ArchivePath: makeTestTar(t, &tar.Header{Name: "file.txt", Typeflag: tar.TypeReg, Size: 3, Mode: 0644}),
+	})
+	if err != nil {
+		t.Fatalf("extract error: %v", err)
+	}
+	if contents, _ := os.ReadFile(outsideFile); string(contents) != "keep" {
+		t.Errorf("extraction wrote through a symlink, target has %q", contents)
+	}
+	finfo, err := os.Lstat(filepath.Join(destDir, "file.txt"))
+	if err != nil || !finfo.Mode().IsRegular() || finfo.Size() != 3 {
+		t.Errorf("expected file.txt to be replaced with a regular file, got %v %v", finfo, err)
+	}
+
+	// links that stay inside the destination are extracted
+	rtn, err := impl.RemoteExtractArchiveCommand(context.Background(), wshrpc.CommandRemoteExtractData{
+		Path: destDir,
+		ArchivePath: makeTestTar(t,
+			&tar.Header{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0755},
+			&tar.Header{Name: "sub/link", Typeflag: tar.TypeSymlink, Linkname: "../file.txt"},
+		),
+	})
+	if err != nil || len(rtn.Entries) != 2 {
+		t.Fatalf("extract error: %v %+v", err, rtn)
+	}
+	if target, err := os.Readlink(filepath.Join(destDir, "sub", "link")); err != nil || target != "../file.txt" {
+		t.Errorf("unexpected link %q: %v", target, err)
+	}
+}
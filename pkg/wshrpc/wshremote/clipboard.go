@@ -0,0 +1,150 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// bridges to the host's clipboard tool.  linux needs a display server (wl-clipboard for wayland,
+// xclip or xsel for x11), wsl falls back to the windows tools.  copy tools like xclip fork a process
+// that serves the selection, so their output isn't captured (it would never close).
+
+const ErrPrefix_NoClipboard = "no_clipboard"
+const MaxClipboardSize = 1024 * 1024
+const ClipboardTimeout = 5 * time.Second
+
+type clipboardBackend struct {
+	Name     string
+	Env      string // required environment variable (the display server), empty if none
+	GetArgs  []string
+	SetArgs  []string
+	TrimCRLF bool // the get tool adds a trailing newline
+}
+
+var clipboardBackends = map[string][]clipboardBackend{
+	"darwin": {
+		{Name: "pbcopy", GetArgs: []string{"pbpaste"}, SetArgs: []string{"pbcopy"}},
+	},
+	"windows": {
+		{Name: "clip", GetArgs: []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}, SetArgs: []string{"clip.exe"}, TrimCRLF: true},
+	},
+	"": {
+		{Name: "wl-clipboard", Env: "WAYLAND_DISPLAY", GetArgs: []string{"wl-paste", "--no-newline"}, SetArgs: []string{"wl-copy"}},
+		{Name: "xclip", Env: "DISPLAY", GetArgs: []string{"xclip", "-selection", "clipboard", "-out"}, SetArgs: []string{"xclip", "-selection", "clipboard", "-in"}},
+		{Name: "xsel", Env: "DISPLAY", GetArgs: []string{"xsel", "--clipboard", "--output"}, SetArgs: []string{"xsel", "--clipboard", "--input"}},
+		{Name: "wsl", GetArgs: []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}, SetArgs: []string{"clip.exe"}, TrimCRLF: true},
+	},
+}
+
+// returns the first backend whose display server is running and whose tools are installed
+func detectClipboardBackend(goos string, getenv func(string) string, lookPath func(string) (string, error)) (*clipboardBackend, error) {
+	backends, ok := clipboardBackends[goos]
+	if !ok {
+		backends = clipboardBackends[""]
+	}
+	for _, backend := range backends {
+		if backend.Env != "" && getenv(backend.Env) == "" {
+			continue
+		}
+		if _, err := lookPath(backend.GetArgs[0]); err != nil {
+			continue
+		}
+		if _, err := lookPath(backend.SetArgs[0]); err != nil {
+			continue
+		}
+		rtn := backend
+		return &rtn, nil
+	}
+	if goos == "darwin" || goos == "windows" {
+		return nil, fmt.Errorf("%s: clipboard tools not found", ErrPrefix_NoClipboard)
+	}
+	return nil, fmt.Errorf("%s: no clipboard available (needs a display server with wl-clipboard, xclip, or xsel installed)", ErrPrefix_NoClipboard)
+}
+
+func (backend *clipboardBackend) get(ctx context.Context) (string, error) {
+	ctx, cancelFn := context.WithTimeout(ctx, ClipboardTimeout)
+	defer cancelFn()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, backend.GetArgs[0], backend.GetArgs[1:]...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error reading clipboard with %s: %w %s", backend.Name, err, strings.TrimSpace(stderr.String()))
+	}
+	if len(output) > MaxClipboardSize {
+		return "", fmt.Errorf("clipboard contents too large (%d bytes, max %d)", len(output), MaxClipboardSize)
+	}
+	text := string(output)
+	if backend.TrimCRLF {
+		text = strings.TrimSuffix(text, "\r\n")
+	}
+	return text, nil
+}
+
+func (backend *clipboardBackend) set(ctx context.Context, text string) error {
+	if len(text) > MaxClipboardSize {
+		return fmt.Errorf("clipboard text too large (%d bytes, max %d)", len(text), MaxClipboardSize)
+	}
+	ctx, cancelFn := context.WithTimeout(ctx, ClipboardTimeout)
+	defer cancelFn()
+	cmd := exec.CommandContext(ctx, backend.SetArgs[0], backend.SetArgs[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error setting clipboard with %s: %w", backend.Name, err)
+	}
+	return nil
+}
+
+// only operators can use the clipboard, and only for the connection the request names (so a request
+// can't reach another machine's clipboard through a route alias or a misrouted call)
+func (impl *ServerImpl) checkClipboardAccess(ctx context.Context, connName string) error {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if !impl.isOperatorSource(rpcSource) {
+		return fmt.Errorf("clipboard access is only allowed from the wave app (not %q)", rpcSource)
+	}
+	if connName == "" {
+		return fmt.Errorf("clipboard request has no connection")
+	}
+	respHandler := wshutil.GetRpcResponseHandlerFromContext(ctx)
+	if respHandler == nil {
+		return fmt.Errorf("clipboard request has no rpc context")
+	}
+	if rpcConn := respHandler.GetRpcContext().Conn; rpcConn != connName {
+		return fmt.Errorf("clipboard request for %q was routed to %q", connName, rpcConn)
+	}
+	return nil
+}
+
+func (impl *ServerImpl) RemoteClipboardGetCommand(ctx context.Context, connName string) (string, error) {
+	if err := impl.checkClipboardAccess(ctx, connName); err != nil {
+		return "", err
+	}
+	backend, err := detectClipboardBackend(runtime.GOOS, os.Getenv, exec.LookPath)
+	if err != nil {
+		return "", err
+	}
+	return backend.get(ctx)
+}
+
+func (impl *ServerImpl) RemoteClipboardSetCommand(ctx context.Context, data wshrpc.CommandRemoteClipboardData) error {
+	if err := impl.checkClipboardAccess(ctx, data.Conn); err != nil {
+		return err
+	}
+	backend, err := detectClipboardBackend(runtime.GOOS, os.Getenv, exec.LookPath)
+	if err != nil {
+		return err
+	}
+	return backend.set(ctx, data.Text)
+}
@@ -0,0 +1,128 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func TestDetectClipboardBackend(t *testing.T) {
+	mockLookPath := func(installed ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, tool := range installed {
+				if tool == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", fmt.Errorf("%s not found", name)
+		}
+	}
+	mockEnv := func(vars ...string) func(string) string {
+		return func(name string) string {
+			for _, envVar := range vars {
+				if envVar == name {
+					return ":0"
+				}
+			}
+			return ""
+		}
+	}
+	tests := []struct {
+		name     string
+		goos     string
+		env      []string
+		tools    []string
+		expected string
+	}{
+		{"mac", "darwin", nil, []string{"pbcopy", "pbpaste"}, "pbcopy"},
+		{"wayland", "linux", []string{"WAYLAND_DISPLAY", "DISPLAY"}, []string{"wl-copy", "wl-paste", "xclip"}, "wl-clipboard"},
+		{"x11 xclip", "linux", []string{"DISPLAY"}, []string{"wl-copy", "wl-paste", "xclip"}, "xclip"},
+		{"x11 xsel", "freebsd", []string{"DISPLAY"}, []string{"xsel"}, "xsel"},
+		{"wsl", "linux", nil, []string{"clip.exe", "powershell.exe", "xclip"}, "wsl"},
+		{"headless", "linux", nil, []string{"xclip", "xsel"}, ""},
+		{"half installed", "linux", []string{"WAYLAND_DISPLAY"}, []string{"wl-paste"}, ""},
+	}
+	for _, tc := range tests {
+		backend, err := detectClipboardBackend(tc.goos, mockEnv(tc.env...), mockLookPath(tc.tools...))
+		if tc.expected == "" {
+			if err == nil || !strings.HasPrefix(err.Error(), ErrPrefix_NoClipboard) {
+				t.Errorf("%s: expected an unsupported error, got %v, %v", tc.name, backend, err)
+			}
+			continue
+		}
+		if err != nil || backend.Name != tc.expected {
+			t.Errorf("%s: expected %s, got %v, %v", tc.name, tc.expected, backend, err)
+		}
+	}
+}
+
+func TestClipboardBackendRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake backend uses sh")
+	}
+	clipFile := filepath.Join(t.TempDir(), "clipboard")
+	backend := &clipboardBackend{
+		Name:     "fake",
+		GetArgs:  []string{"sh", "-c", "cat " + clipFile + " && printf '\\r\\n'"},
+		SetArgs:  []string{"sh", "-c", "cat > " + clipFile},
+		TrimCRLF: true,
+	}
+	ctx := context.Background()
+	text := "copied on the remote\nsecond line"
+	if err := backend.set(ctx, text); err != nil {
+		t.Fatalf("error setting clipboard: %v", err)
+	}
+	got, err := backend.get(ctx)
+	if err != nil || got != text {
+		t.Errorf("clipboard round trip: got %q, %v", got, err)
+	}
+	if err := backend.set(ctx, strings.Repeat("x", MaxClipboardSize+1)); err == nil {
+		t.Errorf("expected an error for oversized text")
+	}
+	backend.GetArgs = []string{"sh", "-c", "echo 'Error: cannot open display' >&2; exit 1"}
+	if _, err := backend.get(ctx); err == nil || !strings.Contains(err.Error(), "open display") {
+		t.Errorf("expected the tool's error output, got %v", err)
+	}
+}
+
+func TestClipboardAccess(t *testing.T) {
+	router := wshutil.NewWshRouter()
+	router.RegisterRoute("proc:1", wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil), false)
+	connCtx := wshrpc.RpcContext{Conn: "user@host"}
+	makeCtx := func(source string, rpcCtx wshrpc.RpcContext) context.Context {
+		return wshutil.WithLocalRequest(context.Background(), source, wshrpc.Command_RemoteClipboardGet, rpcCtx)
+	}
+	tests := []struct {
+		name     string
+		impl     *ServerImpl
+		ctx      context.Context
+		connName string
+	}{
+		{"no-router", &ServerImpl{}, makeCtx("tab:1", connCtx), "user@host"},
+		{"no-context", &ServerImpl{DirectUpstream: true}, context.Background(), "user@host"},
+		{"local-wsh", &ServerImpl{Router: router}, makeCtx("proc:1", connCtx), "user@host"},
+		{"no-conn", &ServerImpl{Router: router}, makeCtx("tab:1", connCtx), ""},
+		{"other-conn", &ServerImpl{Router: router}, makeCtx("tab:1", connCtx), "user@otherhost"},
+		{"unknown-conn", &ServerImpl{Router: router}, makeCtx("tab:1", wshrpc.RpcContext{}), "user@host"},
+	}
+	for _, tc := range tests {
+		if err := tc.impl.checkClipboardAccess(tc.ctx, tc.connName); err == nil {
+			t.Errorf("%s: expected clipboard access to be rejected", tc.name)
+		}
+		if _, err := tc.impl.RemoteClipboardGetCommand(tc.ctx, tc.connName); err == nil || strings.HasPrefix(err.Error(), ErrPrefix_NoClipboard) {
+			t.Errorf("%s: expected the get to be rejected before looking for a clipboard, got %v", tc.name, err)
+		}
+	}
+	if err := (&ServerImpl{Router: router}).checkClipboardAccess(makeCtx("tab:1", connCtx), "user@host"); err != nil {
+		t.Errorf("clipboard access from the wave app: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+)
+
+const EnvCacheResolveTimeout = 5 * time.Second
+
+// the connection's env as the env command (envutil.PrintEnvCmd) prints it through the user's shell (the same
+// way wavesrv resolves the connection's env cache). each connection runs its own remote server, so it is
+// resolved once per connection. falls back to the server's own environment if the command fails.
+func (impl *ServerImpl) getEnvCache(ctx context.Context) *envutil.ConnEnvCache {
+	impl.envCacheLock.Lock()
+	defer impl.envCacheLock.Unlock()
+	if impl.envCache == nil {
+		impl.envCache = resolveEnvCache(ctx)
+	}
+	return impl.envCache
+}
+
+func getShellPath() string {
+	if shellPath := os.Getenv("SHELL"); shellPath != "" {
+		return shellPath
+	}
+	return "sh"
+}
+
+func resolveEnvCache(ctx context.Context) *envutil.ConnEnvCache {
+	serverEnv := envutil.EnvToMap(strings.Join(os.Environ(), "\x00"))
+	if runtime.GOOS == "windows" {
+		return &envutil.ConnEnvCache{Env: serverEnv}
+	}
+	shellPath := getShellPath()
+	ctx, cancelFn := context.WithTimeout(ctx, EnvCacheResolveTimeout)
+	defer cancelFn()
+	envOut, err := exec.CommandContext(ctx, shellPath, "-c", envutil.PrintEnvCmd).Output()
+	if err != nil {
+		log.Printf("error getting env (using the server env): %v\n", err)
+		return &envutil.ConnEnvCache{ShellPath: shellPath, Env: serverEnv}
+	}
+	return &envutil.ConnEnvCache{ShellPath: shellPath, Env: envutil.ParseEnvOutput(string(envOut))}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the decompressed output is capped (compressed logs can expand a lot)
+const MaxDecompressedSize = 10 * MaxFileSize
+
+// resolves the decompression format for a file, returns "" to stream the file raw
+func getStreamDecompressFormat(path string, decompress string) (string, error) {
+	switch decompress {
+	case "", wshrpc.StreamDecompress_None:
+		return "", nil
+	case wshrpc.StreamDecompress_Gzip, wshrpc.StreamDecompress_Bzip2:
+		return decompress, nil
+	case wshrpc.StreamDecompress_Auto:
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".gz", ".tgz":
+			return wshrpc.StreamDecompress_Gzip, nil
+		case ".bz2":
+			return wshrpc.StreamDecompress_Bzip2, nil
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("invalid decompress option %q", decompress)
+}
+
+func makeDecompressReader(format string, r io.Reader) (io.Reader, error) {
+	switch format {
+	case wshrpc.StreamDecompress_Gzip:
+		return gzip.NewReader(r)
+	case wshrpc.StreamDecompress_Bzip2:
+		return bzip2.NewReader(r), nil
+	}
+	return nil, fmt.Errorf("%s decompression is not supported", format)
+}
+
+// streams the decompressed contents of a file (byteRange applies to the decompressed data).
+// corrupt or truncated data is reported as an error after the data decoded before it was hit.
+func (impl *ServerImpl) remoteStreamFileDecompress(ctx context.Context, path string, format string, byteRange ByteRangeType, dataCallback func(fileInfo []*wshrpc.FileInfo, data []byte)) error {
+	fd, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open file %q: %w", path, err)
+	}
+	defer fd.Close()
+	reader, err := makeDecompressReader(format, fd)
+	if err != nil {
+		return fmt.Errorf("decompressing %q: %w", path, err)
+	}
+	decompressErr := func(err error) error {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("decompressing %q (%s): data is truncated", path, format)
+		}
+		return fmt.Errorf("decompressing %q (%s): data is corrupt: %w", path, format, err)
+	}
+	var pos int64
+	if !byteRange.All && byteRange.Start > 0 {
+		skipped, err := io.CopyN(io.Discard, reader, byteRange.Start)
+		pos = skipped
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return decompressErr(err)
+		}
+	}
+	buf := make([]byte, FileChunkSize)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if !byteRange.All && pos+int64(n) > byteRange.End {
+				n = int(byteRange.End - pos)
+			}
+			pos += int64(n)
+			if pos > MaxDecompressedSize {
+				return fmt.Errorf("decompressed data of %q is too large (max %d bytes)", path, MaxDecompressedSize)
+			}
+			dataCallback(nil, buf[:n])
+		}
+		if !byteRange.All && pos >= byteRange.End {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return decompressErr(err)
+		}
+	}
+}
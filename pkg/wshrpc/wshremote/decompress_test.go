@@ -0,0 +1,124 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func streamTestFile(impl *ServerImpl, data wshrpc.CommandRemoteStreamFileData) ([]byte, error) {
+	var buf bytes.Buffer
+	err := impl.remoteStreamFileInternal(context.Background(), data, func(fileInfo []*wshrpc.FileInfo, data []byte) {
+		buf.Write(data)
+	})
+	return buf.Bytes(), err
+}
+
+func TestStreamFileDecompress(t *testing.T) {
+	impl := &ServerImpl{}
+	var logData bytes.Buffer
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&logData, "127.0.0.1 - - [01/Jan/2025:00:00:%02d] \"GET /page/%d HTTP/1.1\" 200\n", i%60, i)
+	}
+	var gzData bytes.Buffer
+	gzWriter := gzip.NewWriter(&gzData)
+	gzWriter.Write(logData.Bytes())
+	gzWriter.Close()
+	tmpDir := t.TempDir()
+	gzPath := filepath.Join(tmpDir, "access.log.2.gz")
+	os.WriteFile(gzPath, gzData.Bytes(), 0644)
+
+	rtn, err := streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: gzPath})
+	if err != nil || !bytes.Equal(rtn, gzData.Bytes()) {
+		t.Errorf("raw mode should stream the compressed bytes (err: %v)", err)
+	}
+	rtn, err = streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: gzPath, Decompress: wshrpc.StreamDecompress_Auto})
+	if err != nil || !bytes.Equal(rtn, logData.Bytes()) {
+		t.Errorf("auto mode should stream the decompressed log (got %d bytes, err: %v)", len(rtn), err)
+	}
+	rtn, err = streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: gzPath, Decompress: wshrpc.StreamDecompress_Gzip, ByteRange: "100-200"})
+	if err != nil || !bytes.Equal(rtn, logData.Bytes()[100:200]) {
+		t.Errorf("byte range should apply to the decompressed data (got %q, err: %v)", rtn, err)
+	}
+
+	truncPath := filepath.Join(tmpDir, "trunc.log.gz")
+	os.WriteFile(truncPath, gzData.Bytes()[:gzData.Len()/2], 0644)
+	rtn, err = streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: truncPath, Decompress: wshrpc.StreamDecompress_Auto})
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("expected a truncated error, got %v", err)
+	}
+	if len(rtn) == 0 || !bytes.HasPrefix(logData.Bytes(), rtn) {
+		t.Errorf("data before the truncation should be streamed (got %d bytes)", len(rtn))
+	}
+
+	corruptData := bytes.Clone(gzData.Bytes())
+	for idx := 20; idx < 60; idx++ {
+		corruptData[idx] ^= 0xff
+	}
+	corruptPath := filepath.Join(tmpDir, "corrupt.log.gz")
+	os.WriteFile(corruptPath, corruptData, 0644)
+	_, err = streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: corruptPath, Decompress: wshrpc.StreamDecompress_Auto})
+	if err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("expected a corrupt data error, got %v", err)
+	}
+
+	plainPath := filepath.Join(tmpDir, "plain.log")
+	os.WriteFile(plainPath, logData.Bytes(), 0644)
+	rtn, err = streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: plainPath, Decompress: wshrpc.StreamDecompress_Auto})
+	if err != nil || !bytes.Equal(rtn, logData.Bytes()) {
+		t.Errorf("auto mode should stream files without a known extension raw (err: %v)", err)
+	}
+	// zstd isn't supported, so .zst files are streamed raw in auto mode
+	zstPath := filepath.Join(tmpDir, "access.log.zst")
+	os.WriteFile(zstPath, gzData.Bytes(), 0644)
+	rtn, err = streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: zstPath, Decompress: wshrpc.StreamDecompress_Auto})
+	if err != nil || !bytes.Equal(rtn, gzData.Bytes()) {
+		t.Errorf("auto mode should stream .zst files raw (err: %v)", err)
+	}
+	for _, decompress := range []string{"lzma", "zstd"} {
+		_, err = streamTestFile(impl, wshrpc.CommandRemoteStreamFileData{Path: plainPath, Decompress: decompress})
+		if err == nil {
+			t.Errorf("expected an error for the invalid decompress option %q", decompress)
+		}
+	}
+}
+
+func TestStreamFileReadLimit(t *testing.T) {
+	impl := &ServerImpl{DirectUpstream: true}
+	const limit = 512 * 1024
+	localCtx := wshutil.WithLocalRequest(context.Background(), "proc:1", wshrpc.Command_RemoteSetReadLimit, wshrpc.RpcContext{})
+	if err := (&ServerImpl{}).RemoteSetReadLimitCommand(localCtx, limit); err == nil {
+		t.Errorf("expected a non-operator source to be refused")
+	}
+	opCtx := wshutil.WithLocalRequest(context.Background(), "tab:1", wshrpc.Command_RemoteSetReadLimit, wshrpc.RpcContext{})
+	if err := impl.RemoteSetReadLimitCommand(opCtx, limit); err != nil {
+		t.Fatalf("error setting read limit: %v", err)
+	}
+	filePath := filepath.Join(t.TempDir(), "data.bin")
+	os.WriteFile(filePath, bytes.Repeat([]byte("x"), limit/2), 0644)
+	startTime := time.Now()
+	var total int
+	for respUnion := range impl.RemoteStreamFileCommand(context.Background(), wshrpc.CommandRemoteStreamFileData{Path: filePath}) {
+		if respUnion.Error != nil {
+			t.Fatalf("stream error: %v", respUnion.Error)
+		}
+		total += base64.StdEncoding.DecodedLen(len(respUnion.Response.Data64))
+	}
+	// the bucket starts empty when the rate is set, so half a second's worth takes about half a second
+	if elapsed := time.Since(startTime); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the stream to respect the %d bytes/sec cap, %d bytes took %v", limit, total, elapsed)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"fmt"
+	"io/fs"
+	"syscall"
+)
+
+const inodeKeysSupported = true
+
+// returns "<dev>:<inode>" and the link count
+func getInodeKey(finfo fs.FileInfo) (string, uint64, bool) {
+	stat, ok := finfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", 0, false
+	}
+	return fmt.Sprintf("%d:%d", stat.Dev, stat.Ino), uint64(stat.Nlink), true
+}
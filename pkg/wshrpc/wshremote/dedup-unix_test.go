@@ -0,0 +1,85 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func collectDuplicateGroups(t *testing.T, impl *ServerImpl, data wshrpc.CommandRemoteDedupData) []wshrpc.DuplicateGroup {
+	t.Helper()
+	var groups []wshrpc.DuplicateGroup
+	for resp := range impl.RemoteFindDuplicatesCommand(context.Background(), data) {
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %v", resp.Error)
+		}
+		slices.Sort(resp.Response.Paths)
+		groups = append(groups, resp.Response)
+	}
+	return groups
+}
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	bigData := bytes.Repeat([]byte("duplicate "), 500)
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+		return path
+	}
+	orig := writeFile("orig.txt", bigData)
+	copy1 := writeFile("sub/copy.txt", bigData)
+	writeFile("samesize.txt", bytes.Repeat([]byte("different "), 500))
+	tiny1 := writeFile("tiny1.txt", []byte("tiny"))
+	writeFile("tiny2.txt", []byte("tiny"))
+	link := filepath.Join(dir, "sub", "link.txt")
+	if err := os.Link(orig, link); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+	tinyLink := filepath.Join(dir, "tinylink.txt")
+	os.Link(tiny1, tinyLink)
+	impl := &ServerImpl{}
+
+	groups := collectDuplicateGroups(t, impl, wshrpc.CommandRemoteDedupData{Path: dir})
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 hardlink groups, got %+v", groups)
+	}
+	for _, group := range groups {
+		if group.Mode != wshrpc.DedupMode_Hardlink || len(group.Paths) != 2 {
+			t.Errorf("unexpected group %+v", group)
+		}
+	}
+	groups = collectDuplicateGroups(t, impl, wshrpc.CommandRemoteDedupData{Path: dir, MinSize: 100})
+	if len(groups) != 1 || !slices.Equal(groups[0].Paths, []string{orig, link}) || groups[0].Size != int64(len(bigData)) {
+		t.Errorf("min size should skip the tiny hardlinks, got %+v", groups)
+	}
+
+	// the hardlinked copy of orig is hashed once, tiny files are never hashed
+	groups = collectDuplicateGroups(t, impl, wshrpc.CommandRemoteDedupData{Path: dir, Mode: wshrpc.DedupMode_Content})
+	if len(groups) != 1 || len(groups[0].Paths) != 2 || groups[0].Paths[1] != copy1 || groups[0].Mode != wshrpc.DedupMode_Content {
+		t.Fatalf("expected one content group with orig and the copy, got %+v", groups)
+	}
+	groups = collectDuplicateGroups(t, impl, wshrpc.CommandRemoteDedupData{Path: dir, Mode: wshrpc.DedupMode_Content, Exclude: []string{"sub"}})
+	if len(groups) != 0 {
+		t.Errorf("excluded directories should not be searched, got %+v", groups)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for resp := range impl.RemoteFindDuplicatesCommand(ctx, wshrpc.CommandRemoteDedupData{Path: dir, Mode: wshrpc.DedupMode_Content}) {
+		t.Errorf("a cancelled search should end without results, got %+v", resp)
+	}
+}
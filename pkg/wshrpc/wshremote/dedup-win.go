@@ -0,0 +1,17 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"io/fs"
+)
+
+const inodeKeysSupported = false
+
+// the file index needs an extra GetFileInformationByHandle call (opening every file), not supported
+func getInodeKey(finfo fs.FileInfo) (string, uint64, bool) {
+	return "", 0, false
+}
@@ -0,0 +1,220 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// finds files sharing an inode (hardlink mode) or with identical contents (content mode) under a
+// directory.  content mode only hashes files whose size matches another file's, extra links to an
+// inode are hashed once (and reported with its first path).  unreadable subdirectories are skipped.
+
+const MinDedupContentSize = 1024
+
+type hardlinkGroup struct {
+	group wshrpc.DuplicateGroup
+	nlink uint64
+	sent  bool
+}
+
+func sendDuplicateGroup(ctx context.Context, ch chan wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup], group wshrpc.DuplicateGroup) error {
+	select {
+	case ch <- wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup]{Response: group}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// calls fn for each regular file of at least minSize bytes
+func walkDedupFiles(ctx context.Context, rootPath string, data wshrpc.CommandRemoteDedupData, minSize int64, fn func(path string, finfo fs.FileInfo) error) error {
+	return filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if path == rootPath {
+				return walkErr
+			}
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+		if relPath != "." && matchesAnyGlob(data.Exclude, relPath) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		finfo, err := d.Info()
+		if err != nil || finfo.Size() < minSize {
+			return nil
+		}
+		return fn(path, finfo)
+	})
+}
+
+func findHardlinks(ctx context.Context, rootPath string, data wshrpc.CommandRemoteDedupData, ch chan wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup]) error {
+	if !inodeKeysSupported {
+		return fmt.Errorf("hardlink mode is not supported on this platform")
+	}
+	groups := make(map[string]*hardlinkGroup)
+	var order []string
+	err := walkDedupFiles(ctx, rootPath, data, data.MinSize, func(path string, finfo fs.FileInfo) error {
+		key, nlink, ok := getInodeKey(finfo)
+		if !ok || nlink < 2 {
+			return nil
+		}
+		hg := groups[key]
+		if hg == nil {
+			hg = &hardlinkGroup{group: wshrpc.DuplicateGroup{Mode: wshrpc.DedupMode_Hardlink, Key: key, Size: finfo.Size()}, nlink: nlink}
+			groups[key] = hg
+			order = append(order, key)
+		}
+		hg.group.Paths = append(hg.group.Paths, path)
+		if uint64(len(hg.group.Paths)) == hg.nlink {
+			// every link is under the root, the group is complete
+			hg.sent = true
+			return sendDuplicateGroup(ctx, ch, hg.group)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range order {
+		hg := groups[key]
+		if hg.sent || len(hg.group.Paths) < 2 {
+			continue
+		}
+		if err := sendDuplicateGroup(ctx, ch, hg.group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashDedupFile(ctx context.Context, path string) (string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, ctxReader{ctx: ctx, r: fd}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func findContentDuplicates(ctx context.Context, rootPath string, data wshrpc.CommandRemoteDedupData, ch chan wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup]) error {
+	bySize := make(map[int64][]string)
+	seenInodes := make(map[string]bool)
+	err := walkDedupFiles(ctx, rootPath, data, max(data.MinSize, MinDedupContentSize), func(path string, finfo fs.FileInfo) error {
+		if key, _, ok := getInodeKey(finfo); ok {
+			if seenInodes[key] {
+				return nil
+			}
+			seenInodes[key] = true
+		}
+		bySize[finfo.Size()] = append(bySize[finfo.Size()], path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var sizes []int64
+	for size, paths := range bySize {
+		if len(paths) > 1 {
+			sizes = append(sizes, size)
+		}
+	}
+	// largest first, they free the most space
+	slices.SortFunc(sizes, func(a, b int64) int { return cmp.Compare(b, a) })
+	for _, size := range sizes {
+		byHash := make(map[string][]string)
+		var hashOrder []string
+		for _, path := range bySize[size] {
+			hash, err := hashDedupFile(ctx, path)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				// unreadable (or removed since the walk)
+				continue
+			}
+			if byHash[hash] == nil {
+				hashOrder = append(hashOrder, hash)
+			}
+			byHash[hash] = append(byHash[hash], path)
+		}
+		for _, hash := range hashOrder {
+			if len(byHash[hash]) < 2 {
+				continue
+			}
+			group := wshrpc.DuplicateGroup{Mode: wshrpc.DedupMode_Content, Key: hash, Size: size, Paths: byHash[hash]}
+			if err := sendDuplicateGroup(ctx, ch, group); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (impl *ServerImpl) RemoteFindDuplicatesCommand(ctx context.Context, data wshrpc.CommandRemoteDedupData) chan wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RemoteFindDuplicatesCommand", recover())
+		}()
+		defer close(ch)
+		rootPath, err := wavebase.ExpandHomeDir(data.Path)
+		if err != nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup]{Error: err}
+			return
+		}
+		rootPath = filepath.Clean(rootPath)
+		if finfo, err := os.Stat(rootPath); err != nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup]{Error: fmt.Errorf("cannot search %q: %w", data.Path, err)}
+			return
+		} else if !finfo.IsDir() {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup]{Error: fmt.Errorf("cannot search %q: not a directory", data.Path)}
+			return
+		}
+		switch data.Mode {
+		case "", wshrpc.DedupMode_Hardlink:
+			err = findHardlinks(ctx, rootPath, data, ch)
+		case wshrpc.DedupMode_Content:
+			err = findContentDuplicates(ctx, rootPath, data, ch)
+		default:
+			err = fmt.Errorf("invalid mode %q", data.Mode)
+		}
+		if err != nil && ctx.Err() == nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.DuplicateGroup]{Error: fmt.Errorf("error finding duplicates in %q: %w", data.Path, err)}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,55 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const MaxExpandPathMatches = 10000
+
+// follows the shell's order: tilde, then variables (unset variables expand to ""), then the glob
+func expandPathString(data wshrpc.CommandRemoteExpandData) (string, error) {
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return "", err
+	}
+	return os.Expand(path, func(varName string) string {
+		if val, ok := data.Env[varName]; ok {
+			return val
+		}
+		return os.Getenv(varName)
+	}), nil
+}
+
+func (impl *ServerImpl) RemoteExpandPathCommand(ctx context.Context, data wshrpc.CommandRemoteExpandData) ([]string, error) {
+	if data.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	path, err := expandPathString(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot expand path %q: %w", data.Path, err)
+	}
+	if !strings.ContainsAny(path, "*?[") {
+		return []string{path}, nil
+	}
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", data.Path, err)
+	}
+	if len(matches) > MaxExpandPathMatches {
+		return nil, fmt.Errorf("glob %q matches too many paths (%d, max %d)", data.Path, len(matches), MaxExpandPathMatches)
+	}
+	if len(matches) == 0 && !data.NullGlob {
+		return []string{path}, nil
+	}
+	return matches, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRemoteExpandPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	impl := &ServerImpl{}
+	env := map[string]string{"EXPAND_TEST_DIR": dir}
+	tests := []struct {
+		path     string
+		nullGlob bool
+		want     []string
+	}{
+		{"$EXPAND_TEST_DIR/*.go", false, []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}},
+		{"${EXPAND_TEST_DIR}/c.txt", false, []string{filepath.Join(dir, "c.txt")}},
+		{"$EXPAND_TEST_DIR/*.rs", false, []string{filepath.Join(dir, "*.rs")}},
+		{"$EXPAND_TEST_DIR/*.rs", true, nil},
+	}
+	for _, tc := range tests {
+		got, err := impl.RemoteExpandPathCommand(context.Background(), wshrpc.CommandRemoteExpandData{Path: tc.path, Env: env, NullGlob: tc.nullGlob})
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.path, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q (nullglob=%v): got %v, want %v", tc.path, tc.nullGlob, got, tc.want)
+		}
+	}
+}
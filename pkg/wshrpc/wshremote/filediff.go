@@ -0,0 +1,257 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const MaxDiffFileSize = 2 * 1024 * 1024
+const MaxDiffEdits = 2000 // limits the work (and memory) spent on files that are mostly different
+const DefaultDiffContext = 3
+
+const diffNoEolMarker = "\\ No newline at end of file"
+
+// internal suffix for a last line without a newline, so "a" and "a\n" compare as different lines
+const diffNoEolSuffix = "\x00noeol"
+
+type diffOp struct {
+	Kind   byte // ' ', '-', or '+'
+	OldIdx int  // position in the old lines before this op
+	NewIdx int  // position in the new lines before this op
+	Line   string
+}
+
+func readDiffFile(path string) ([]byte, error) {
+	path, err := wavebase.ExpandHomeDir(path)
+	if err != nil {
+		return nil, err
+	}
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat file %q: %w", path, err)
+	}
+	if finfo.IsDir() {
+		return nil, fmt.Errorf("%q is a directory", path)
+	}
+	if finfo.Size() > MaxDiffFileSize {
+		return nil, fmt.Errorf("file %q is too large to diff (max %d bytes)", path, MaxDiffFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file %q: %w", path, err)
+	}
+	return data, nil
+}
+
+func splitDiffLines(name string, data []byte) ([]string, error) {
+	if len(data) > MaxDiffFileSize {
+		return nil, fmt.Errorf("%s is too large to diff (max %d bytes)", name, MaxDiffFileSize)
+	}
+	if binary, _ := wshrpc.DetectTextEncoding(data, 0); binary {
+		return nil, fmt.Errorf("cannot diff binary file %s", name)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	lines := strings.Split(string(data), "\n")
+	if data[len(data)-1] == '\n' {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += diffNoEolSuffix
+	}
+	return lines, nil
+}
+
+// myers diff over lines, returns the edit script (equal lines included).  errors if more than maxEdits lines change.
+func myersDiff(a []string, b []string, maxEdits int) ([]diffOp, error) {
+	n, m := len(a), len(b)
+	// trace[d] holds the furthest x for diagonals k in [-d, d] (index k+d) after d edits
+	var trace [][]int
+	numEdits := -1
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return nil, fmt.Errorf("files differ in more than %d lines", maxEdits)
+		}
+		cur := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if d == 0 {
+				x = 0
+			} else {
+				prev := trace[d-1]
+				if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+					x = prev[k+1+d-1]
+				} else {
+					x = prev[k-1+d-1] + 1
+				}
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			cur[k+d] = x
+			if x >= n && y >= m {
+				numEdits = d
+				break
+			}
+		}
+		trace = append(trace, cur)
+		if numEdits >= 0 {
+			break
+		}
+	}
+	var ops []diffOp
+	x, y := n, m
+	for d := numEdits; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{Kind: ' ', OldIdx: x - 1, NewIdx: y - 1, Line: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{Kind: '+', OldIdx: x, NewIdx: y - 1, Line: b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{Kind: '-', OldIdx: x - 1, NewIdx: y, Line: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{Kind: ' ', OldIdx: x - 1, NewIdx: y - 1, Line: a[x-1]})
+		x--
+		y--
+	}
+	for left, right := 0, len(ops)-1; left < right; left, right = left+1, right-1 {
+		ops[left], ops[right] = ops[right], ops[left]
+	}
+	return ops, nil
+}
+
+func makeDiffHunk(ops []diffOp) wshrpc.DiffHunk {
+	hunk := wshrpc.DiffHunk{OldStart: ops[0].OldIdx, NewStart: ops[0].NewIdx}
+	for _, op := range ops {
+		line, hasNoEol := strings.CutSuffix(op.Line, diffNoEolSuffix)
+		hunk.Lines = append(hunk.Lines, string(op.Kind)+line)
+		if hasNoEol {
+			hunk.Lines = append(hunk.Lines, diffNoEolMarker)
+		}
+		if op.Kind != '+' {
+			hunk.OldLines++
+		}
+		if op.Kind != '-' {
+			hunk.NewLines++
+		}
+	}
+	if hunk.OldLines > 0 {
+		hunk.OldStart++
+	}
+	if hunk.NewLines > 0 {
+		hunk.NewStart++
+	}
+	return hunk
+}
+
+// groups changes into hunks, changes closer than 2*contextLines lines share a hunk
+func makeDiffHunks(ops []diffOp, contextLines int) []wshrpc.DiffHunk {
+	var hunks []wshrpc.DiffHunk
+	idx := 0
+	for idx < len(ops) {
+		if ops[idx].Kind == ' ' {
+			idx++
+			continue
+		}
+		start := max(idx-contextLines, 0)
+		lastChange := idx
+		for scan := idx; scan < len(ops) && scan-lastChange <= 2*contextLines; scan++ {
+			if ops[scan].Kind != ' ' {
+				lastChange = scan
+			}
+		}
+		end := min(lastChange+contextLines+1, len(ops))
+		hunks = append(hunks, makeDiffHunk(ops[start:end]))
+		idx = end
+	}
+	return hunks
+}
+
+func formatUnifiedDiff(oldName string, newName string, hunks []wshrpc.DiffHunk) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		for _, line := range hunk.Lines {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
+func (impl *ServerImpl) RemoteFileDiffCommand(ctx context.Context, data wshrpc.CommandRemoteDiffData) (wshrpc.CommandRemoteDiffRtnData, error) {
+	var rtn wshrpc.CommandRemoteDiffRtnData
+	if data.Path1 == "" || (data.Path2 == "" && data.Data64 == "") {
+		return rtn, fmt.Errorf("path1 and either path2 or data64 are required")
+	}
+	oldData, err := readDiffFile(data.Path1)
+	if err != nil {
+		return rtn, err
+	}
+	newName := data.Path2
+	var newData []byte
+	if data.Path2 != "" {
+		newData, err = readDiffFile(data.Path2)
+	} else {
+		newName = "(data)"
+		newData, err = base64.StdEncoding.DecodeString(data.Data64)
+	}
+	if err != nil {
+		return rtn, err
+	}
+	if bytes.Equal(oldData, newData) {
+		rtn.Identical = true
+		return rtn, nil
+	}
+	oldLines, err := splitDiffLines(data.Path1, oldData)
+	if err != nil {
+		return rtn, err
+	}
+	newLines, err := splitDiffLines(newName, newData)
+	if err != nil {
+		return rtn, err
+	}
+	if ctx.Err() != nil {
+		return rtn, ctx.Err()
+	}
+	ops, err := myersDiff(oldLines, newLines, MaxDiffEdits)
+	if err != nil {
+		return rtn, err
+	}
+	contextLines := data.Context
+	if contextLines <= 0 {
+		contextLines = DefaultDiffContext
+	}
+	rtn.Hunks = makeDiffHunks(ops, contextLines)
+	rtn.Diff = formatUnifiedDiff(data.Path1, newName, rtn.Hunks)
+	return rtn, nil
+}
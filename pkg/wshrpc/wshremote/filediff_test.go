@@ -0,0 +1,72 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRemoteFileDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, contents string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(contents), 0644)
+		return path
+	}
+	impl := &ServerImpl{}
+	ctx := context.Background()
+	old := write("old.conf", "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n")
+
+	// identical
+	rtn, err := impl.RemoteFileDiffCommand(ctx, wshrpc.CommandRemoteDiffData{Path1: old, Path2: write("same.conf", "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n")})
+	if err != nil || !rtn.Identical || rtn.Diff != "" {
+		t.Fatalf("expected identical files, got %#v, %v", rtn, err)
+	}
+
+	// two changes far enough apart for separate hunks
+	newPath := write("new.conf", "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nl\nm\n")
+	rtn, err = impl.RemoteFileDiffCommand(ctx, wshrpc.CommandRemoteDiffData{Path1: old, Path2: newPath, Context: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "--- " + old + "\n+++ " + newPath + "\n" +
+		"@@ -1,4 +1,4 @@\n a\n-b\n+B\n c\n d\n" +
+		"@@ -9,4 +9,4 @@\n i\n j\n-k\n l\n+m\n"
+	if rtn.Identical || rtn.Diff != expected {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", rtn.Diff, expected)
+	}
+	if len(rtn.Hunks) != 2 || rtn.Hunks[1].OldStart != 9 || rtn.Hunks[1].NewLines != 4 {
+		t.Errorf("unexpected hunks %#v", rtn.Hunks)
+	}
+
+	// against a client blob, only the missing trailing newline differs
+	rtn, err = impl.RemoteFileDiffCommand(ctx, wshrpc.CommandRemoteDiffData{Path1: write("short.conf", "x\ny\n"), Data64: base64.StdEncoding.EncodeToString([]byte("x\ny"))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(rtn.Diff, "@@ -1,2 +1,2 @@\n x\n-y\n+y\n\\ No newline at end of file\n") {
+		t.Errorf("unexpected no-newline diff:\n%s", rtn.Diff)
+	}
+
+	// against an empty file
+	rtn, err = impl.RemoteFileDiffCommand(ctx, wshrpc.CommandRemoteDiffData{Path1: write("empty.conf", ""), Path2: write("one.conf", "x\n")})
+	if err != nil || !strings.HasSuffix(rtn.Diff, "@@ -0,0 +1,1 @@\n+x\n") {
+		t.Errorf("unexpected diff from empty file: %q, %v", rtn.Diff, err)
+	}
+
+	// missing and binary files are errors
+	if _, err := impl.RemoteFileDiffCommand(ctx, wshrpc.CommandRemoteDiffData{Path1: old, Path2: filepath.Join(dir, "missing.conf")}); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+	if _, err := impl.RemoteFileDiffCommand(ctx, wshrpc.CommandRemoteDiffData{Path1: old, Path2: write("bin", "a\x00b")}); err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Errorf("expected an error for a binary file, got %v", err)
+	}
+}
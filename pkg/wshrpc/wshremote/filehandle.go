@@ -0,0 +1,262 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// open file handles for scattered reads/writes (remote large-file/hex editing).  a handle belongs to
+// the route that opened it, is closed after FileHandleIdleTimeout without use, and is closed when its
+// route goes away (see HandleRouteGone).
+
+const FileHandleIdleTimeout = 5 * time.Minute
+const MaxFileHandlesPerRoute = 64
+const MaxFileHandleReadSize = 4 * 1024 * 1024
+
+type fileHandle struct {
+	lock      *sync.Mutex // serializes ops on the file, and guards closed
+	id        string
+	routeId   string
+	path      string
+	file      *os.File
+	writable  bool
+	closed    bool
+	idleTimer *time.Timer
+}
+
+type fileHandleTable struct {
+	lock    *sync.Mutex
+	handles map[string]*fileHandle
+}
+
+func (impl *ServerImpl) getFileHandles() *fileHandleTable {
+	impl.fileHandlesOnce.Do(func() {
+		impl.fileHandles = &fileHandleTable{lock: &sync.Mutex{}, handles: make(map[string]*fileHandle)}
+	})
+	return impl.fileHandles
+}
+
+func (t *fileHandleTable) numRouteHandles_nolock(routeId string) int {
+	var rtn int
+	for _, fh := range t.handles {
+		if fh.routeId == routeId {
+			rtn++
+		}
+	}
+	return rtn
+}
+
+func (t *fileHandleTable) add(fh *fileHandle) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.numRouteHandles_nolock(fh.routeId) >= MaxFileHandlesPerRoute {
+		return fmt.Errorf("too many open file handles (max %d)", MaxFileHandlesPerRoute)
+	}
+	t.handles[fh.id] = fh
+	fh.idleTimer = time.AfterFunc(FileHandleIdleTimeout, func() {
+		log.Printf("closing idle file handle for %q\n", fh.path)
+		t.close(fh.id)
+	})
+	return nil
+}
+
+// the handle must exist and belong to routeId (unknown and foreign handles get the same error)
+func (t *fileHandleTable) get(routeId string, handleId string) (*fileHandle, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	fh := t.handles[handleId]
+	if fh == nil || fh.routeId != routeId {
+		return nil, fmt.Errorf("invalid or closed file handle")
+	}
+	fh.idleTimer.Reset(FileHandleIdleTimeout)
+	return fh, nil
+}
+
+func (t *fileHandleTable) remove(handleId string) *fileHandle {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	fh := t.handles[handleId]
+	if fh == nil {
+		return nil
+	}
+	delete(t.handles, handleId)
+	fh.idleTimer.Stop()
+	return fh
+}
+
+func (t *fileHandleTable) close(handleId string) error {
+	fh := t.remove(handleId)
+	if fh == nil {
+		return nil
+	}
+	fh.lock.Lock()
+	defer fh.lock.Unlock()
+	fh.closed = true
+	return fh.file.Close()
+}
+
+func (t *fileHandleTable) closeRoute(routeId string) {
+	t.lock.Lock()
+	var handleIds []string
+	for id, fh := range t.handles {
+		if fh.routeId == routeId {
+			handleIds = append(handleIds, id)
+		}
+	}
+	t.lock.Unlock()
+	for _, id := range handleIds {
+		t.close(id)
+	}
+}
+
+// closes the file handles (and removes the chunked uploads) owned by the routes in a wps.Event_RouteGone event
+func (impl *ServerImpl) HandleRouteGone(event *wps.WaveEvent) {
+	for _, routeId := range event.Scopes {
+		impl.getFileHandles().closeRoute(routeId)
+		impl.getChunkUploads().removeRoute(routeId)
+	}
+}
+
+// subscribes (through client, which must be the connserver's rpc client) to route:gone so handles
+// are closed when their owning route disconnects
+func (impl *ServerImpl) WatchRouteGone(client *wshutil.WshRpc) error {
+	client.EventListener.On(wps.Event_RouteGone, impl.HandleRouteGone)
+	return wshclient.EventSubCommand(client, wps.SubscriptionRequest{Event: wps.Event_RouteGone, AllScopes: true}, nil)
+}
+
+func (impl *ServerImpl) RemoteFileOpenCommand(ctx context.Context, data wshrpc.CommandRemoteFileOpenData) (wshrpc.RemoteFileOpenRtnData, error) {
+	var rtn wshrpc.RemoteFileOpenRtnData
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return rtn, err
+	}
+	writable := data.Write || data.Create
+	flags := os.O_RDONLY
+	if writable {
+		flags = os.O_RDWR
+	}
+	if data.Create {
+		flags |= os.O_CREATE
+	}
+	createMode := data.CreateMode
+	if createMode == 0 {
+		createMode = 0644
+	}
+	file, err := os.OpenFile(path, flags, createMode)
+	if err != nil {
+		return rtn, fmt.Errorf("cannot open file %q: %w", path, err)
+	}
+	finfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return rtn, fmt.Errorf("cannot stat file %q: %w", path, err)
+	}
+	if finfo.IsDir() {
+		file.Close()
+		return rtn, fmt.Errorf("cannot open %q: is a directory", path)
+	}
+	fh := &fileHandle{
+		lock:     &sync.Mutex{},
+		id:       uuid.New().String(),
+		routeId:  wshutil.GetRpcSourceFromContext(ctx),
+		path:     path,
+		file:     file,
+		writable: writable,
+	}
+	err = impl.getFileHandles().add(fh)
+	if err != nil {
+		file.Close()
+		return rtn, err
+	}
+	if data.Create {
+		impl.invalidateFileInfo(path)
+	}
+	rtn.Handle = fh.id
+	rtn.Info = statToFileInfo(path, finfo, true)
+	return rtn, nil
+}
+
+func (impl *ServerImpl) RemoteFileReadAtCommand(ctx context.Context, data wshrpc.CommandRemoteFileReadAtData) (wshrpc.RemoteFileReadAtRtnData, error) {
+	var rtn wshrpc.RemoteFileReadAtRtnData
+	if data.Offset < 0 || data.Size < 0 {
+		return rtn, fmt.Errorf("offset and size must be non-negative")
+	}
+	if data.Size > MaxFileHandleReadSize {
+		return rtn, fmt.Errorf("read size %d is too large (max %d)", data.Size, MaxFileHandleReadSize)
+	}
+	fh, err := impl.getFileHandles().get(wshutil.GetRpcSourceFromContext(ctx), data.Handle)
+	if err != nil {
+		return rtn, err
+	}
+	fh.lock.Lock()
+	defer fh.lock.Unlock()
+	if fh.closed {
+		return rtn, fmt.Errorf("invalid or closed file handle")
+	}
+	buf := make([]byte, data.Size)
+	n, err := fh.file.ReadAt(buf, data.Offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return rtn, fmt.Errorf("cannot read file %q: %w", fh.path, err)
+	}
+	rtn.Data64 = base64.StdEncoding.EncodeToString(buf[:n])
+	rtn.EOF = int64(n) < data.Size
+	return rtn, nil
+}
+
+func (impl *ServerImpl) RemoteFileWriteAtCommand(ctx context.Context, data wshrpc.CommandRemoteFileWriteAtData) error {
+	dataBytes, err := wshrpc.DecodeData64("data64", data.Data64)
+	if err != nil {
+		return err
+	}
+	if data.Offset < 0 {
+		return fmt.Errorf("offset must be non-negative")
+	}
+	fh, err := impl.getFileHandles().get(wshutil.GetRpcSourceFromContext(ctx), data.Handle)
+	if err != nil {
+		return err
+	}
+	fh.lock.Lock()
+	defer fh.lock.Unlock()
+	if fh.closed {
+		return fmt.Errorf("invalid or closed file handle")
+	}
+	if !fh.writable {
+		return fmt.Errorf("file handle for %q is read-only", fh.path)
+	}
+	_, err = fh.file.WriteAt(dataBytes, data.Offset)
+	if err != nil {
+		return fmt.Errorf("cannot write file %q: %w", fh.path, err)
+	}
+	impl.invalidateFileInfo(fh.path)
+	return nil
+}
+
+func (impl *ServerImpl) RemoteFileCloseCommand(ctx context.Context, handle string) error {
+	table := impl.getFileHandles()
+	_, err := table.get(wshutil.GetRpcSourceFromContext(ctx), handle)
+	if err != nil {
+		return err
+	}
+	err = table.close(handle)
+	if err != nil {
+		return fmt.Errorf("error closing file handle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func TestFileHandleOps(t *testing.T) {
+	ctx := wshutil.WithLocalRequest(context.Background(), "proc:owner", wshrpc.Command_RemoteFileOpen, wshrpc.RpcContext{})
+	otherCtx := wshutil.WithLocalRequest(context.Background(), "proc:other", wshrpc.Command_RemoteFileReadAt, wshrpc.RpcContext{})
+	path := filepath.Join(t.TempDir(), "data.bin")
+	os.WriteFile(path, []byte("0123456789"), 0644)
+	impl := &ServerImpl{}
+
+	openRtn, err := impl.RemoteFileOpenCommand(ctx, wshrpc.CommandRemoteFileOpenData{Path: path, Write: true})
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	if openRtn.Info.Size != 10 {
+		t.Errorf("expected size 10, got %d", openRtn.Info.Size)
+	}
+	err = impl.RemoteFileWriteAtCommand(ctx, wshrpc.CommandRemoteFileWriteAtData{
+		Handle: openRtn.Handle,
+		Offset: 2,
+		Data64: base64.StdEncoding.EncodeToString([]byte("ab")),
+	})
+	if err != nil {
+		t.Fatalf("error writing: %v", err)
+	}
+	readRtn, err := impl.RemoteFileReadAtCommand(ctx, wshrpc.CommandRemoteFileReadAtData{Handle: openRtn.Handle, Offset: 1, Size: 20})
+	if err != nil {
+		t.Fatalf("error reading: %v", err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(readRtn.Data64)
+	if string(data) != "1ab456789" || !readRtn.EOF {
+		t.Errorf("unexpected read result %q (eof=%v)", string(data), readRtn.EOF)
+	}
+
+	// handles are scoped to the route that opened them
+	if _, err := impl.RemoteFileReadAtCommand(otherCtx, wshrpc.CommandRemoteFileReadAtData{Handle: openRtn.Handle, Size: 1}); err == nil {
+		t.Errorf("expected handle to be rejected for another route")
+	}
+	if err := impl.RemoteFileCloseCommand(otherCtx, openRtn.Handle); err == nil {
+		t.Errorf("expected another route to be unable to close the handle")
+	}
+	impl.HandleRouteGone(&wps.WaveEvent{Event: wps.Event_RouteGone, Scopes: []string{"proc:other"}})
+	if _, err := impl.RemoteFileReadAtCommand(ctx, wshrpc.CommandRemoteFileReadAtData{Handle: openRtn.Handle, Size: 1}); err != nil {
+		t.Errorf("expected handle to survive another route going away: %v", err)
+	}
+	impl.HandleRouteGone(&wps.WaveEvent{Event: wps.Event_RouteGone, Scopes: []string{"proc:owner"}})
+	_, err = impl.RemoteFileReadAtCommand(ctx, wshrpc.CommandRemoteFileReadAtData{Handle: openRtn.Handle, Size: 1})
+	if err == nil {
+		t.Errorf("expected handle to be closed when its route is gone")
+	}
+
+	roRtn, err := impl.RemoteFileOpenCommand(ctx, wshrpc.CommandRemoteFileOpenData{Path: path})
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	err = impl.RemoteFileWriteAtCommand(ctx, wshrpc.CommandRemoteFileWriteAtData{Handle: roRtn.Handle, Data64: "YQ=="})
+	if err == nil {
+		t.Errorf("expected write to a read-only handle to fail")
+	}
+	err = impl.RemoteFileCloseCommand(ctx, roRtn.Handle)
+	if err != nil {
+		t.Errorf("error closing handle: %v", err)
+	}
+	err = impl.RemoteFileCloseCommand(ctx, roRtn.Handle)
+	if err == nil {
+		t.Errorf("expected closing a closed handle to fail")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// short-lived cache for RemoteFileInfo (file browsers re-stat the same paths constantly)
+// entries are invalidated by the remote write/delete/rename/mkdir commands on matching paths.
+// the ttl comes from the conn:fileinfocachettlms setting (passed to connservers with --fileinfocachettl).
+
+const DefaultFileInfoCacheTTL = 2 * time.Second
+const FileInfoCacheMaxEntries = 4096
+
+type fileInfoCacheEntry struct {
+	info    *wshrpc.FileInfo
+	expires time.Time
+}
+
+type fileInfoCache struct {
+	lock    *sync.Mutex
+	ttl     time.Duration
+	entries map[string]*fileInfoCacheEntry
+}
+
+func makeFileInfoCache(ttl time.Duration) *fileInfoCache {
+	if ttl == 0 {
+		ttl = DefaultFileInfoCacheTTL
+	}
+	return &fileInfoCache{
+		lock:    &sync.Mutex{},
+		ttl:     ttl,
+		entries: make(map[string]*fileInfoCacheEntry),
+	}
+}
+
+func fileInfoCacheKey(path string) string {
+	return filepath.Clean(wavebase.ExpandHomeDirSafe(path))
+}
+
+func copyFileInfo(info *wshrpc.FileInfo) *wshrpc.FileInfo {
+	if info == nil {
+		return nil
+	}
+	rtn := *info
+	return &rtn
+}
+
+func (c *fileInfoCache) enabled() bool {
+	return c.ttl > 0
+}
+
+func (c *fileInfoCache) get(path string) *wshrpc.FileInfo {
+	if !c.enabled() {
+		return nil
+	}
+	key := fileInfoCacheKey(path)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry := c.entries[key]
+	if entry == nil {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return copyFileInfo(entry.info)
+}
+
+func (c *fileInfoCache) set(path string, info *wshrpc.FileInfo) {
+	if !c.enabled() || info == nil {
+		return
+	}
+	key := fileInfoCacheKey(path)
+	now := time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.entries) >= FileInfoCacheMaxEntries {
+		c.purgeExpired_nolock(now)
+		if len(c.entries) >= FileInfoCacheMaxEntries {
+			c.entries = make(map[string]*fileInfoCacheEntry)
+		}
+	}
+	c.entries[key] = &fileInfoCacheEntry{info: copyFileInfo(info), expires: now.Add(c.ttl)}
+}
+
+func (c *fileInfoCache) purgeExpired_nolock(now time.Time) {
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// invalidates the path, its parent directory, and (if it is a directory) everything under it
+func (c *fileInfoCache) invalidate(path string) {
+	if !c.enabled() {
+		return
+	}
+	key := fileInfoCacheKey(path)
+	dirPrefix := strings.TrimSuffix(key, string(filepath.Separator)) + string(filepath.Separator)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, key)
+	delete(c.entries, filepath.Dir(key))
+	for entryKey := range c.entries {
+		if strings.HasPrefix(entryKey, dirPrefix) {
+			delete(c.entries, entryKey)
+		}
+	}
+}
+
+func (impl *ServerImpl) getFileInfoCache() *fileInfoCache {
+	impl.fileInfoCacheOnce.Do(func() {
+		impl.fileInfoCache = makeFileInfoCache(impl.FileInfoCacheTTL)
+	})
+	return impl.fileInfoCache
+}
+
+// for commands that take a bare path (no NoCache field), the caller can bypass the cache with the nocache rpc meta key
+func isNoCacheRequest(ctx context.Context) bool {
+	return wshutil.GetRpcMetaFromContext(ctx)[wshrpc.RpcMeta_NoCache] == "1"
+}
+
+func (impl *ServerImpl) cachedFileInfo(path string, noCache bool) (*wshrpc.FileInfo, error) {
+	cache := impl.getFileInfoCache()
+	if !noCache {
+		if info := cache.get(path); info != nil {
+			return info, nil
+		}
+	}
+	info, err := impl.fileInfoInternal(path, true)
+	if err != nil {
+		return nil, err
+	}
+	cache.set(path, info)
+	return info, nil
+}
+
+func (impl *ServerImpl) invalidateFileInfo(paths ...string) {
+	cache := impl.getFileInfoCache()
+	for _, path := range paths {
+		cache.invalidate(path)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func statTestFileSize(t *testing.T, impl *ServerImpl, ctx context.Context, path string) int64 {
+	t.Helper()
+	info, err := impl.RemoteFileInfoCommand(ctx, path)
+	if err != nil {
+		t.Fatalf("error statting %s: %v", path, err)
+	}
+	return info.Size
+}
+
+func TestFileInfoCache(t *testing.T) {
+	ctx := context.Background()
+	noCacheCtx := wshutil.WithLocalRequest(ctx, "test", wshrpc.Command_RemoteFileInfo, wshrpc.RpcContext{Meta: map[string]string{wshrpc.RpcMeta_NoCache: "1"}})
+	impl := &ServerImpl{FileInfoCacheTTL: time.Minute}
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "a.txt")
+	os.WriteFile(filePath, []byte("abc"), 0644)
+
+	if size := statTestFileSize(t, impl, ctx, filePath); size != 3 {
+		t.Fatalf("expected size 3, got %d", size)
+	}
+	os.WriteFile(filePath, []byte("abcdef"), 0644)
+	if size := statTestFileSize(t, impl, ctx, filePath); size != 3 {
+		t.Errorf("expected the cached size 3, got %d", size)
+	}
+	if size := statTestFileSize(t, impl, noCacheCtx, filePath); size != 6 {
+		t.Errorf("expected the nocache meta key to bypass the cache, got size %d", size)
+	}
+
+	os.WriteFile(filePath, []byte("abcdefghi"), 0644)
+	infos, err := impl.RemoteFileStatCommand(ctx, wshrpc.CommandRemoteFileStatData{Paths: []string{filePath}, NoCache: true})
+	if err != nil || len(infos) != 1 || infos[0].Size != 9 {
+		t.Errorf("expected NoCache to bypass the cache on the batch stat, got %v (err: %v)", infos, err)
+	}
+	// a bypassing stat refreshes the cache
+	if size := statTestFileSize(t, impl, ctx, filePath); size != 9 {
+		t.Errorf("expected the refreshed size 9, got %d", size)
+	}
+
+	// invalidating a directory drops the entries under it
+	os.WriteFile(filePath, []byte("a"), 0644)
+	impl.invalidateFileInfo(tmpDir)
+	if size := statTestFileSize(t, impl, ctx, filePath); size != 1 {
+		t.Errorf("expected the invalidated entry to be re-read, got size %d", size)
+	}
+
+	// expired entries are re-read
+	os.WriteFile(filePath, []byte("ab"), 0644)
+	cache := impl.getFileInfoCache()
+	cache.lock.Lock()
+	cache.entries[fileInfoCacheKey(filePath)].expires = time.Now().Add(-time.Second)
+	cache.lock.Unlock()
+	if size := statTestFileSize(t, impl, ctx, filePath); size != 2 {
+		t.Errorf("expected the expired entry to be re-read, got size %d", size)
+	}
+
+	disabledImpl := &ServerImpl{FileInfoCacheTTL: -1}
+	statTestFileSize(t, disabledImpl, ctx, filePath)
+	os.WriteFile(filePath, []byte("abcd"), 0644)
+	if size := statTestFileSize(t, disabledImpl, ctx, filePath); size != 4 {
+		t.Errorf("expected a negative ttl to disable the cache, got size %d", size)
+	}
+}
+
+// stats every entry of a directory, like a file browser does on each navigation
+func BenchmarkFileInfoNavigation(b *testing.B) {
+	tmpDir := b.TempDir()
+	var paths []string
+	for i := 0; i < 200; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("file-%03d.txt", i))
+		os.WriteFile(path, []byte("data"), 0644)
+		paths = append(paths, path)
+	}
+	for _, noCache := range []bool{false, true} {
+		name := "cached"
+		if noCache {
+			name = "uncached"
+		}
+		b.Run(name, func(b *testing.B) {
+			impl := &ServerImpl{FileInfoCacheTTL: time.Minute}
+			data := wshrpc.CommandRemoteFileStatData{Paths: paths, NoCache: noCache}
+			for i := 0; i < b.N; i++ {
+				if _, err := impl.RemoteFileStatCommand(context.Background(), data); err != nil {
+					b.Fatalf("error statting: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// dry runs (DryRun in the delete/copy/rename/chmod commands) only stat and walk, they never modify the filesystem.
+// previews of huge trees are cut off at MaxFileOpPreviewChanges.
+
+const MaxFileOpPreviewChanges = 10000
+
+func addPreviewChange(preview *wshrpc.FileOpPreview, change wshrpc.FileOpChange) bool {
+	if len(preview.Changes) >= MaxFileOpPreviewChanges {
+		preview.Truncated = true
+		return false
+	}
+	preview.Changes = append(preview.Changes, change)
+	return true
+}
+
+// children are listed before their directory (the order RemoveAll deletes in)
+func previewDelete(ctx context.Context, path string, recursive bool) (wshrpc.FileOpPreview, error) {
+	var preview wshrpc.FileOpPreview
+	finfo, err := os.Lstat(path)
+	if err != nil {
+		return preview, err
+	}
+	if !finfo.IsDir() {
+		addPreviewChange(&preview, wshrpc.FileOpChange{Path: path, Action: wshrpc.FileOpAction_Delete})
+		return preview, nil
+	}
+	if !recursive {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return preview, err
+		}
+		if len(entries) > 0 {
+			return preview, fmt.Errorf("directory not empty (use recursive)")
+		}
+		addPreviewChange(&preview, wshrpc.FileOpChange{Path: path, Action: wshrpc.FileOpAction_Delete, IsDir: true})
+		return preview, nil
+	}
+	var dirStack []string
+	err = filepath.WalkDir(path, func(walkPath string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return err
+		}
+		// pop directories we've walked out of, they are deleted after their contents
+		for len(dirStack) > 0 && filepath.Dir(walkPath) != dirStack[len(dirStack)-1] {
+			if !addPreviewChange(&preview, wshrpc.FileOpChange{Path: dirStack[len(dirStack)-1], Action: wshrpc.FileOpAction_Delete, IsDir: true}) {
+				return filepath.SkipAll
+			}
+			dirStack = dirStack[:len(dirStack)-1]
+		}
+		if d.IsDir() {
+			dirStack = append(dirStack, walkPath)
+			return nil
+		}
+		if !addPreviewChange(&preview, wshrpc.FileOpChange{Path: walkPath, Action: wshrpc.FileOpAction_Delete}) {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return wshrpc.FileOpPreview{}, err
+	}
+	for idx := len(dirStack) - 1; idx >= 0; idx-- {
+		if !addPreviewChange(&preview, wshrpc.FileOpChange{Path: dirStack[idx], Action: wshrpc.FileOpAction_Delete, IsDir: true}) {
+			break
+		}
+	}
+	return preview, nil
+}
+
+func parseChmodMode(modeStr string) (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(modeStr, 8, 32)
+	if err != nil || mode > 0o7777 {
+		return 0, fmt.Errorf("invalid mode %q (must be octal, e.g. \"755\")", modeStr)
+	}
+	// setuid/setgid/sticky map to go's mode bits
+	rtn := fs.FileMode(mode & 0o777)
+	if mode&0o4000 != 0 {
+		rtn |= fs.ModeSetuid
+	}
+	if mode&0o2000 != 0 {
+		rtn |= fs.ModeSetgid
+	}
+	if mode&0o1000 != 0 {
+		rtn |= fs.ModeSticky
+	}
+	return rtn, nil
+}
+
+func chmodBits(mode fs.FileMode) fs.FileMode {
+	return mode & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
+
+// symlinks are skipped (chmod would change their targets, which may be outside the tree).
+// files that already have the mode are left out of the preview.
+func (impl *ServerImpl) RemoteChmodCommand(ctx context.Context, data wshrpc.CommandRemoteChmodData) (wshrpc.FileOpPreview, error) {
+	var preview wshrpc.FileOpPreview
+	mode, err := parseChmodMode(data.Mode)
+	if err != nil {
+		return preview, err
+	}
+	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(data.Path))
+	applyFn := func(path string, finfo fs.FileInfo) error {
+		if finfo.Mode()&fs.ModeSymlink != 0 || chmodBits(finfo.Mode()) == mode {
+			return nil
+		}
+		if data.DryRun {
+			if !addPreviewChange(&preview, wshrpc.FileOpChange{Path: path, Action: wshrpc.FileOpAction_Chmod, Mode: data.Mode, IsDir: finfo.IsDir()}) {
+				return filepath.SkipAll
+			}
+			return nil
+		}
+		err := os.Chmod(path, mode)
+		if err != nil {
+			return fmt.Errorf("cannot chmod %q: %w", path, err)
+		}
+		impl.invalidateFileInfo(path)
+		return nil
+	}
+	finfo, err := os.Lstat(cleanedPath)
+	if err != nil {
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot chmod %q: %w", data.Path, err)
+	}
+	if !data.Recursive || !finfo.IsDir() {
+		err = applyFn(cleanedPath, finfo)
+		if err != nil {
+			return wshrpc.FileOpPreview{}, err
+		}
+		return preview, nil
+	}
+	err = filepath.WalkDir(cleanedPath, func(walkPath string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return err
+		}
+		walkInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return applyFn(walkPath, walkInfo)
+	})
+	if err != nil {
+		return wshrpc.FileOpPreview{}, err
+	}
+	return preview, nil
+}
@@ -0,0 +1,447 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// path => mode/size/modtime, for checking that nothing changed
+func snapshotTree(t *testing.T, root string) map[string]string {
+	rtn := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rtn[path] = fmt.Sprintf("%v %v %d", info.Mode(), info.ModTime(), info.Size())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error walking %s: %v", root, err)
+	}
+	return rtn
+}
+
+func TestFileOpsDryRun(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	tree := filepath.Join(root, "tree")
+	os.MkdirAll(filepath.Join(tree, "a", "b"), 0755)
+	os.MkdirAll(filepath.Join(tree, "c"), 0755)
+	for _, name := range []string{"a/b/1.txt", "a/2.txt", "c/3.txt", "4.txt"} {
+		os.WriteFile(filepath.Join(tree, name), []byte(name), 0644)
+	}
+	impl := &ServerImpl{}
+	before := snapshotTree(t, root)
+
+	preview, err := impl.RemoteFileDeleteCommand(ctx, wshrpc.CommandRemoteFileDeleteData{Path: tree, Recursive: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run delete failed: %v", err)
+	}
+	if len(preview.Changes) != 8 {
+		t.Errorf("expected 8 paths to delete, got %v", preview.Changes)
+	}
+	seen := make(map[string]bool)
+	for _, change := range preview.Changes {
+		if change.Action != wshrpc.FileOpAction_Delete {
+			t.Errorf("unexpected action %q", change.Action)
+		}
+		// contents must come before their directory
+		if change.IsDir {
+			entries, _ := os.ReadDir(change.Path)
+			for _, entry := range entries {
+				if !seen[filepath.Join(change.Path, entry.Name())] {
+					t.Errorf("directory %q listed before its child %q", change.Path, entry.Name())
+				}
+			}
+		}
+		seen[change.Path] = true
+	}
+	if last := preview.Changes[len(preview.Changes)-1]; last.Path != tree {
+		t.Errorf("expected the root to be deleted last, got %q", last.Path)
+	}
+	_, err = impl.RemoteFileDeleteCommand(ctx, wshrpc.CommandRemoteFileDeleteData{Path: tree, DryRun: true})
+	if err == nil {
+		t.Errorf("expected non-recursive dry run delete of a non-empty directory to fail")
+	}
+
+	preview, err = impl.RemoteChmodCommand(ctx, wshrpc.CommandRemoteChmodData{Path: tree, Mode: "700", Recursive: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run chmod failed: %v", err)
+	}
+	if len(preview.Changes) != 8 {
+		t.Errorf("expected 8 paths to chmod, got %v", preview.Changes)
+	}
+
+	preview, err = impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: filepath.Join(tree, "4.txt"), DestPath: filepath.Join(root, "5.txt"), DryRun: true})
+	if err != nil || len(preview.Changes) != 1 || preview.Changes[0].Action != wshrpc.FileOpAction_Copy {
+		t.Errorf("unexpected dry run copy result %v (err:%v)", preview, err)
+	}
+	preview, err = impl.RemoteFileRenameCommand(ctx, wshrpc.CommandRemoteFileRenameData{SrcPath: filepath.Join(tree, "c"), DestPath: filepath.Join(root, "d"), DryRun: true})
+	if err != nil || len(preview.Changes) != 1 || preview.Changes[0].Action != wshrpc.FileOpAction_Move || !preview.Changes[0].IsDir {
+		t.Errorf("unexpected dry run rename result %v (err:%v)", preview, err)
+	}
+
+	after := snapshotTree(t, root)
+	if !reflect.DeepEqual(before, after) {
+		t.Fatalf("dry runs modified the filesystem:\nbefore %v\nafter %v", before, after)
+	}
+
+	// the real operations
+	_, err = impl.RemoteChmodCommand(ctx, wshrpc.CommandRemoteChmodData{Path: tree, Mode: "700", Recursive: true})
+	if err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	if finfo, _ := os.Stat(filepath.Join(tree, "a", "2.txt")); finfo.Mode().Perm() != 0700 {
+		t.Errorf("expected mode 0700, got %v", finfo.Mode())
+	}
+	_, err = impl.RemoteFileDeleteCommand(ctx, wshrpc.CommandRemoteFileDeleteData{Path: tree, Recursive: true})
+	if err != nil {
+		t.Fatalf("recursive delete failed: %v", err)
+	}
+	if _, err := os.Stat(tree); !os.IsNotExist(err) {
+		t.Errorf("expected %q to be deleted", tree)
+	}
+}
+
+func TestListDirShallow(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644)
+	os.Mkdir(filepath.Join(dir, "b"), 0755)
+	os.Symlink("a.txt", filepath.Join(dir, "c"))
+	impl := &ServerImpl{}
+	ctx := context.Background()
+
+	shallow, err := impl.RemoteListDirCommand(ctx, wshrpc.CommandRemoteListDirData{Path: dir, Shallow: true})
+	if err != nil {
+		t.Fatalf("error listing dir: %v", err)
+	}
+	full, err := impl.RemoteListDirCommand(ctx, wshrpc.CommandRemoteListDirData{Path: dir})
+	if err != nil {
+		t.Fatalf("error listing dir: %v", err)
+	}
+	expectedTypes := []string{wshrpc.FileEntryType_File, wshrpc.FileEntryType_Dir, wshrpc.FileEntryType_Symlink}
+	var paths []string
+	for idx, item := range shallow.Items {
+		if item.EntryType != expectedTypes[idx] || item.Statted || item.Size != 0 || item.ModTime != 0 {
+			t.Errorf("unexpected shallow entry %#v", item)
+		}
+		if full.Items[idx].EntryType != expectedTypes[idx] || !full.Items[idx].Statted {
+			t.Errorf("unexpected full entry %#v", full.Items[idx])
+		}
+		paths = append(paths, filepath.Join(dir, item.Name))
+	}
+
+	// upgrading the shallow entries gives the same fileinfo's as the full listing
+	upgraded, err := impl.RemoteFileStatCommand(ctx, wshrpc.CommandRemoteFileStatData{Paths: paths, ListEntries: true})
+	if err != nil {
+		t.Fatalf("error upgrading entries: %v", err)
+	}
+	if !reflect.DeepEqual(upgraded, full.Items) {
+		t.Errorf("upgraded entries don't match the full listing:\n%#v\n%#v", upgraded, full.Items)
+	}
+}
+
+func collectStreamListDir(impl *ServerImpl, data wshrpc.CommandRemoteListDirData) ([]*wshrpc.FileInfo, int, error) {
+	var items []*wshrpc.FileInfo
+	numChunks := 0
+	for resp := range impl.RemoteStreamListDirCommand(context.Background(), data) {
+		if resp.Error != nil {
+			return items, numChunks, resp.Error
+		}
+		numChunks++
+		items = append(items, resp.Response.Items...)
+	}
+	return items, numChunks, nil
+}
+
+func TestStreamListDir(t *testing.T) {
+	dir := t.TempDir()
+	numFiles := DirChunkSize*2 + 10
+	for idx := 0; idx < numFiles; idx++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%03d.txt", idx)), []byte("x"), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "skip.log"), []byte("x"), 0644)
+	impl := &ServerImpl{}
+
+	items, numChunks, err := collectStreamListDir(impl, wshrpc.CommandRemoteListDirData{Path: dir, Exclude: []string{"*.log"}})
+	if err != nil {
+		t.Fatalf("error streaming dir: %v", err)
+	}
+	if numChunks < 3 {
+		t.Errorf("expected at least 3 chunks, got %d", numChunks)
+	}
+	page, err := impl.RemoteListDirCommand(context.Background(), wshrpc.CommandRemoteListDirData{Path: dir, Exclude: []string{"*.log"}, Limit: 1000})
+	if err != nil {
+		t.Fatalf("error listing dir: %v", err)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	if !reflect.DeepEqual(items, page.Items) {
+		t.Errorf("streamed entries don't match the paged listing (%d vs %d)", len(items), len(page.Items))
+	}
+
+	items, _, err = collectStreamListDir(impl, wshrpc.CommandRemoteListDirData{Path: dir, Limit: 5, Shallow: true})
+	if err != nil || len(items) != 5 || items[0].Statted {
+		t.Errorf("expected 5 shallow entries, got %d, %v", len(items), err)
+	}
+
+	_, _, err = collectStreamListDir(impl, wshrpc.CommandRemoteListDirData{Path: filepath.Join(dir, "missing")})
+	if err == nil {
+		t.Errorf("expected an error for a missing dir")
+	}
+}
+
+func makeBenchListDir(b *testing.B) string {
+	dir := b.TempDir()
+	for idx := 0; idx < wshrpc.MaxPageLimit; idx++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%05d.txt", idx)), nil, 0644)
+	}
+	return dir
+}
+
+// total allocations match BenchmarkStreamListDir, the difference is the peak (the stream only holds one chunk at a time)
+func BenchmarkListDir(b *testing.B) {
+	dir := makeBenchListDir(b)
+	impl := &ServerImpl{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		page, _ := impl.RemoteListDirCommand(context.Background(), wshrpc.CommandRemoteListDirData{Path: dir, Limit: wshrpc.MaxPageLimit})
+		json.Marshal(page)
+	}
+}
+
+func BenchmarkStreamListDir(b *testing.B) {
+	dir := makeBenchListDir(b)
+	impl := &ServerImpl{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for resp := range impl.RemoteStreamListDirCommand(context.Background(), wshrpc.CommandRemoteListDirData{Path: dir}) {
+			json.Marshal(resp.Response)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"orig":true}`), 0600)
+	impl := &ServerImpl{}
+	newData := base64.StdEncoding.EncodeToString([]byte(`{"orig":false}`))
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+	err := impl.RemoteWriteFileCommand(ctx, wshrpc.CommandRemoteWriteFileData{Path: path, Data64: newData})
+	if err == nil {
+		t.Fatalf("expected the canceled write to fail")
+	}
+	if contents, _ := os.ReadFile(path); string(contents) != `{"orig":true}` {
+		t.Errorf("canceled write changed the file: %q", contents)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected the temp file to be removed, got %d entries", len(entries))
+	}
+
+	// through a symlink, the target is replaced and keeps its permissions
+	linkPath := filepath.Join(dir, "link.json")
+	os.Symlink("config.json", linkPath)
+	err = impl.RemoteWriteFileCommand(context.Background(), wshrpc.CommandRemoteWriteFileData{Path: linkPath, Data64: newData})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	finfo, err := os.Lstat(path)
+	if err != nil || finfo.Mode().Perm() != 0600 {
+		t.Errorf("expected the target to keep mode 0600, got %v, %v", finfo, err)
+	}
+	if contents, _ := os.ReadFile(path); string(contents) != `{"orig":false}` {
+		t.Errorf("unexpected contents %q", contents)
+	}
+	if linfo, err := os.Lstat(linkPath); err != nil || linfo.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("expected the symlink to remain a symlink")
+	}
+}
+
+func TestRemoteFileCopy(t *testing.T) {
+	impl := &ServerImpl{}
+	ctx := context.Background()
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.sh")
+	destPath := filepath.Join(dir, "dest.sh")
+	os.WriteFile(srcPath, []byte("#!/bin/sh\necho hi\n"), 0644)
+	os.Chmod(srcPath, 0751) // not masked by the umask
+	if _, err := impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: srcPath, DestPath: destPath}); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	if finfo, err := os.Stat(destPath); err != nil || finfo.Mode().Perm() != 0751 {
+		t.Errorf("expected the copy to have mode 0751, got %v (err:%v)", finfo.Mode(), err)
+	}
+
+	os.WriteFile(srcPath, []byte("new contents\n"), 0644)
+	if _, err := impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: srcPath, DestPath: destPath}); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected an exists error without overwrite, got %v", err)
+	}
+	if _, err := impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: srcPath, DestPath: destPath, Overwrite: true}); err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	if contents, _ := os.ReadFile(destPath); string(contents) != "new contents\n" {
+		t.Errorf("expected the destination to be replaced, got %q", contents)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected no temp files to be left behind, got %v", entries)
+	}
+	if _, err := impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: srcPath, DestPath: srcPath, Overwrite: true}); err == nil {
+		t.Errorf("expected an error copying a file onto itself")
+	}
+
+	subDir := filepath.Join(dir, "sub")
+	os.Mkdir(subDir, 0755)
+	_, err := impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: subDir, DestPath: filepath.Join(dir, "sub2")})
+	if err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("expected an is a directory error for a directory source, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub2")); !os.IsNotExist(err) {
+		t.Errorf("nothing should be created for a directory source")
+	}
+	_, err = impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: srcPath, DestPath: subDir, Overwrite: true})
+	if err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("expected an is a directory error for a directory destination, got %v", err)
+	}
+}
+
+func TestRemoteFileCopyReadError(t *testing.T) {
+	// a regular file whose reads fail (at offset 0 nothing is mapped)
+	const failingSrc = "/proc/self/mem"
+	if runtime.GOOS != "linux" {
+		t.Skip("needs " + failingSrc)
+	}
+	impl := &ServerImpl{}
+	destPath := filepath.Join(t.TempDir(), "dest.bin")
+	if _, err := impl.RemoteFileCopyCommand(context.Background(), wshrpc.CommandRemoteFileCopyData{SrcPath: failingSrc, DestPath: destPath}); err == nil {
+		t.Fatalf("expected the copy to fail")
+	}
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		t.Errorf("expected the partial destination to be removed, got %v", err)
+	}
+}
+
+func TestRemoteFileMove(t *testing.T) {
+	impl := &ServerImpl{}
+	ctx := context.Background()
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "a.txt")
+	destPath := filepath.Join(dir, "b.txt")
+	os.WriteFile(srcPath, []byte("a"), 0644)
+	os.WriteFile(destPath, []byte("b"), 0644)
+	if _, err := impl.RemoteFileRenameCommand(ctx, wshrpc.CommandRemoteFileRenameData{SrcPath: srcPath, DestPath: destPath}); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected an exists error without overwrite, got %v", err)
+	}
+	if _, err := impl.RemoteFileRenameCommand(ctx, wshrpc.CommandRemoteFileRenameData{SrcPath: srcPath, DestPath: destPath, Overwrite: true}); err != nil {
+		t.Fatalf("overwrite move failed: %v", err)
+	}
+	if contents, _ := os.ReadFile(destPath); string(contents) != "a" {
+		t.Errorf("expected the destination to be replaced, got %q", contents)
+	}
+
+	_, err := impl.RemoteFileRenameCommand(ctx, wshrpc.CommandRemoteFileRenameData{SrcPath: srcPath, DestPath: filepath.Join(dir, "c.txt")})
+	if !wshrpc.IsNotFoundError(err) || wshrpc.IsPermissionDeniedError(err) {
+		t.Errorf("expected a not_found error for a missing source, got %v", err)
+	}
+	if os.Geteuid() > 0 {
+		lockedDir := filepath.Join(dir, "locked")
+		os.Mkdir(lockedDir, 0755)
+		os.WriteFile(filepath.Join(lockedDir, "x.txt"), []byte("x"), 0644)
+		os.Chmod(lockedDir, 0555)
+		defer os.Chmod(lockedDir, 0755)
+		_, err = impl.RemoteFileRenameCommand(ctx, wshrpc.CommandRemoteFileRenameData{SrcPath: filepath.Join(lockedDir, "x.txt"), DestPath: filepath.Join(dir, "x.txt")})
+		if !wshrpc.IsPermissionDeniedError(err) {
+			t.Errorf("expected a permission_denied error, got %v", err)
+		}
+	}
+
+	// a move across filesystems copies the file and removes the source
+	renameFile = func(oldPath string, newPath string) error {
+		return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: errCrossDevice}
+	}
+	defer func() { renameFile = os.Rename }()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chmod(destPath, 0640)
+	os.Chtimes(destPath, modTime, modTime)
+	movedPath := filepath.Join(dir, "moved.txt")
+	if _, err := impl.RemoteFileRenameCommand(ctx, wshrpc.CommandRemoteFileRenameData{SrcPath: destPath, DestPath: movedPath}); err != nil {
+		t.Fatalf("cross filesystem move failed: %v", err)
+	}
+	finfo, err := os.Stat(movedPath)
+	if err != nil || finfo.Mode().Perm() != 0640 || !finfo.ModTime().Equal(modTime) {
+		t.Errorf("expected the mode and modtime to be kept, got %v (err:%v)", finfo, err)
+	}
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		t.Errorf("expected the source to be removed after a cross filesystem move")
+	}
+	subDir := filepath.Join(dir, "sub")
+	os.Mkdir(subDir, 0755)
+	_, err = impl.RemoteFileRenameCommand(ctx, wshrpc.CommandRemoteFileRenameData{SrcPath: subDir, DestPath: filepath.Join(dir, "sub2")})
+	if err == nil || !strings.Contains(err.Error(), "only regular files") {
+		t.Errorf("expected directories to be refused across filesystems, got %v", err)
+	}
+}
+
+func TestRemoteMkdir(t *testing.T) {
+	impl := &ServerImpl{}
+	ctx := context.Background()
+	dir := t.TempDir()
+	deepPath := filepath.Join(dir, "a", "b", "c")
+	if err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: deepPath}); !wshrpc.IsNotFoundError(err) {
+		t.Errorf("expected a not_found error without recursive, got %v", err)
+	}
+	if err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: deepPath, Recursive: true, CreateMode: 0700}); err != nil {
+		t.Fatalf("recursive mkdir failed: %v", err)
+	}
+	if finfo, err := os.Stat(deepPath); err != nil || !finfo.IsDir() || finfo.Mode().Perm() != 0700 {
+		t.Errorf("expected a 0700 directory, got %v (err:%v)", finfo, err)
+	}
+	if err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: deepPath, Recursive: true}); err != nil {
+		t.Errorf("recursive mkdir of an existing directory should succeed, got %v", err)
+	}
+	if err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: deepPath}); err == nil {
+		t.Errorf("expected an error for an existing directory without recursive")
+	}
+	if err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: deepPath, Recursive: true, MustCreate: true}); err == nil {
+		t.Errorf("expected an error for an existing directory with mustcreate")
+	}
+	newPath := filepath.Join(dir, "new", "dir")
+	if err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: newPath, Recursive: true, MustCreate: true}); err != nil {
+		t.Errorf("expected mustcreate to still create missing parents, got %v", err)
+	}
+
+	filePath := filepath.Join(dir, "a", "file")
+	os.WriteFile(filePath, []byte("x"), 0644)
+	if err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: filePath, Recursive: true}); err == nil {
+		t.Errorf("expected an error when a file exists at the path")
+	}
+	// the error names the component that is in the way
+	err := impl.RemoteMkdirCommand(ctx, wshrpc.CommandRemoteMkdirData{Path: filepath.Join(filePath, "x", "y"), Recursive: true})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("%q is not a directory", filePath)) {
+		t.Errorf("expected the error to name %q, got %v", filePath, err)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+	"syscall"
+)
+
+// best effort, only root can give a file to another user (errors are ignored)
+func copyFileOwner(fd *os.File, finfo os.FileInfo) {
+	stat, ok := finfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	fd.Chown(int(stat.Uid), int(stat.Gid))
+}
@@ -0,0 +1,56 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestWriteFileAtomicUmask(t *testing.T) {
+	oldMask := syscall.Umask(027)
+	defer syscall.Umask(oldMask)
+	impl := &ServerImpl{}
+	path := filepath.Join(t.TempDir(), "new.txt")
+	data := wshrpc.CommandRemoteWriteFileData{Path: path, Data64: base64.StdEncoding.EncodeToString([]byte("data")), CreateMode: 0666}
+	if err := impl.RemoteWriteFileCommand(context.Background(), data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if finfo, err := os.Stat(path); err != nil || finfo.Mode().Perm() != 0640 {
+		t.Errorf("expected the create mode to be masked by the umask (0640), got %v (err:%v)", finfo.Mode().Perm(), err)
+	}
+}
+
+func TestWriteFileAtomicOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs root to create a file owned by another user")
+	}
+	const otherId = 65534 // nobody
+	impl := &ServerImpl{}
+	path := filepath.Join(t.TempDir(), "owned.txt")
+	os.WriteFile(path, []byte("orig"), 0640)
+	if err := os.Chown(path, otherId, otherId); err != nil {
+		t.Fatalf("error changing owner: %v", err)
+	}
+	data := wshrpc.CommandRemoteWriteFileData{Path: path, Data64: base64.StdEncoding.EncodeToString([]byte("new"))}
+	if err := impl.RemoteWriteFileCommand(context.Background(), data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	finfo, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("error getting file info: %v", err)
+	}
+	stat := finfo.Sys().(*syscall.Stat_t)
+	if stat.Uid != otherId || stat.Gid != otherId || finfo.Mode().Perm() != 0640 {
+		t.Errorf("expected the replaced file's owner and mode to be kept, got %d:%d %v", stat.Uid, stat.Gid, finfo.Mode().Perm())
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+)
+
+// files on windows are owned through their acls, which are not copied
+func copyFileOwner(fd *os.File, finfo os.FileInfo) {}
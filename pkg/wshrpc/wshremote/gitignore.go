@@ -0,0 +1,189 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// a small .gitignore implementation for filtering listings (RespectGitignore).  rules come from
+// .git/info/exclude and every .gitignore from the repository root down to the listed directory,
+// deeper files take precedence and within a file the last matching rule wins (so "!pattern" can
+// re-include).  the global core.excludesFile is not read.  outside of a repository only the listed
+// directory's own .gitignore applies.
+
+type gitignoreRule struct {
+	baseDir string // the rule applies to paths under this directory
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+type gitignoreMatcher struct {
+	rules []gitignoreRule // shallowest first
+}
+
+// translates a gitignore glob ("**", "*", "?", "[...]") into a regexp over "/"-separated paths
+func gitignoreGlobToRegexp(pattern string) string {
+	var sb strings.Builder
+	for idx := 0; idx < len(pattern); idx++ {
+		ch := pattern[idx]
+		switch {
+		case strings.HasPrefix(pattern[idx:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			idx += 2
+		case strings.HasPrefix(pattern[idx:], "/**") && idx+3 == len(pattern):
+			sb.WriteString("/.*")
+			idx += 2
+		case strings.HasPrefix(pattern[idx:], "**"):
+			sb.WriteString(".*")
+			idx++
+		case ch == '*':
+			sb.WriteString("[^/]*")
+		case ch == '?':
+			sb.WriteString("[^/]")
+		case ch == '[':
+			endIdx := strings.IndexByte(pattern[idx+1:], ']')
+			if endIdx < 0 {
+				sb.WriteString(regexp.QuoteMeta(string(ch)))
+				continue
+			}
+			class := pattern[idx+1 : idx+1+endIdx]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			idx += endIdx + 1
+		case ch == '\\' && idx+1 < len(pattern):
+			idx++
+			sb.WriteString(regexp.QuoteMeta(string(pattern[idx])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	return sb.String()
+}
+
+func parseGitignoreLine(baseDir string, line string) (gitignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+	rule := gitignoreRule{baseDir: baseDir}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+	// a slash anywhere but the end anchors the pattern to the .gitignore's directory
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	reStr := gitignoreGlobToRegexp(line)
+	if !anchored {
+		reStr = "(?:.*/)?" + reStr
+	}
+	re, err := regexp.Compile("^" + reStr + "$")
+	if err != nil {
+		return gitignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+func (m *gitignoreMatcher) addFile(baseDir string, fileName string) {
+	fd, err := os.Open(fileName)
+	if err != nil {
+		return
+	}
+	defer fd.Close()
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		if rule, ok := parseGitignoreLine(baseDir, scanner.Text()); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+func findGitRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// reads the rules that apply to entries of dir (an absolute, cleaned path)
+func makeGitignoreMatcher(dir string) *gitignoreMatcher {
+	m := &gitignoreMatcher{}
+	gitRoot := findGitRoot(dir)
+	if gitRoot == "" {
+		m.addFile(dir, filepath.Join(dir, ".gitignore"))
+		return m
+	}
+	m.addFile(gitRoot, filepath.Join(gitRoot, ".git", "info", "exclude"))
+	relDir, err := filepath.Rel(gitRoot, dir)
+	if err != nil {
+		return m
+	}
+	curDir := gitRoot
+	m.addFile(curDir, filepath.Join(curDir, ".gitignore"))
+	if relDir != "." {
+		for _, part := range strings.Split(relDir, string(filepath.Separator)) {
+			curDir = filepath.Join(curDir, part)
+			m.addFile(curDir, filepath.Join(curDir, ".gitignore"))
+		}
+	}
+	return m
+}
+
+func (m *gitignoreMatcher) matchPath(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		relPath, err := filepath.Rel(rule.baseDir, path)
+		if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+		if rule.re.MatchString(filepath.ToSlash(relPath)) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// a path is also ignored when one of its parent directories (below the rule's base) is, git doesn't
+// look inside excluded directories so they can't be re-included
+func (m *gitignoreMatcher) IsIgnored(path string, isDir bool) bool {
+	if len(m.rules) == 0 {
+		return false
+	}
+	for parent := filepath.Dir(path); parent != filepath.Dir(parent); parent = filepath.Dir(parent) {
+		if m.matchPath(parent, true) {
+			return true
+		}
+	}
+	return m.matchPath(path, isDir)
+}
@@ -0,0 +1,87 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func listDirNames(t *testing.T, impl *ServerImpl, data wshrpc.CommandRemoteListDirData) []string {
+	page, err := impl.RemoteListDirCommand(context.Background(), data)
+	if err != nil {
+		t.Fatalf("error listing %s: %v", data.Path, err)
+	}
+	var names []string
+	for _, item := range page.Items {
+		names = append(names, item.Name)
+	}
+	if page.Total != len(names) {
+		t.Errorf("expected total %d to match the filtered entries, got %d", len(names), page.Total)
+	}
+	return names
+}
+
+func TestListDirGitignore(t *testing.T) {
+	repo := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":            "ref: refs/heads/main\n",
+		".git/info/exclude":    "*.swp\n",
+		".gitignore":           "# build output\n*.log\nbuild/\n/root-only.txt\nnode_modules\n",
+		"root-only.txt":        "",
+		"main.go":              "",
+		"debug.log":            "",
+		"build/out.bin":        "",
+		"node_modules/x/y.js":  "",
+		"sub/.gitignore":       "!keep.log\n*.tmp\ndocs/**/*.html\n",
+		"sub/keep.log":         "",
+		"sub/other.log":        "",
+		"sub/root-only.txt":    "",
+		"sub/a.tmp":            "",
+		"sub/.main.go.swp":     "",
+		"sub/main.go":          "",
+		"sub/build/file":       "",
+		"sub/docs/x/page.html": "",
+		"sub/docs/index.md":    "",
+	}
+	for name, contents := range files {
+		fullPath := filepath.Join(repo, name)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		os.WriteFile(fullPath, []byte(contents), 0644)
+	}
+	impl := &ServerImpl{}
+
+	names := listDirNames(t, impl, wshrpc.CommandRemoteListDirData{Path: repo, RespectGitignore: true})
+	expected := []string{".git", ".gitignore", "main.go", "sub"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("root: expected %v, got %v", expected, names)
+	}
+	// the nested .gitignore re-includes keep.log, "/root-only.txt" is anchored to the repo root,
+	// and "build/" matches at any depth
+	names = listDirNames(t, impl, wshrpc.CommandRemoteListDirData{Path: filepath.Join(repo, "sub"), RespectGitignore: true})
+	expected = []string{".gitignore", "docs", "keep.log", "main.go", "root-only.txt"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("sub: expected %v, got %v", expected, names)
+	}
+	names = listDirNames(t, impl, wshrpc.CommandRemoteListDirData{Path: filepath.Join(repo, "sub", "docs", "x"), RespectGitignore: true})
+	if len(names) != 0 {
+		t.Errorf("sub/docs/x: expected no entries, got %v", names)
+	}
+	// inside an ignored directory everything is ignored
+	names = listDirNames(t, impl, wshrpc.CommandRemoteListDirData{Path: filepath.Join(repo, "node_modules", "x"), RespectGitignore: true})
+	if len(names) != 0 {
+		t.Errorf("node_modules/x: expected no entries, got %v", names)
+	}
+
+	names = listDirNames(t, impl, wshrpc.CommandRemoteListDirData{Path: filepath.Join(repo, "sub"), Exclude: []string{"*.log", ".*"}})
+	expected = []string{"a.tmp", "build", "docs", "main.go", "root-only.txt"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("sub with excludes: expected %v, got %v", expected, names)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// gpu metrics come from nvidia-smi (NVML would need cgo).  machines without it get a stream that ends
+// immediately with an ErrPrefix_NoGpu error.
+
+const DefaultGpuSampleInterval = 2 * time.Second
+const MinGpuSampleInterval = 500 * time.Millisecond
+
+const ErrPrefix_NoGpu = "no_gpu"
+
+var nvidiaSmiFields = []string{"index", "utilization.gpu", "memory.used", "memory.total", "temperature.gpu"}
+var nvidiaSmiKeys = []string{"", "util", "memused", "memtotal", "temp"}
+
+func nvidiaSmiArgs() []string {
+	return []string{"--query-gpu=" + strings.Join(nvidiaSmiFields, ","), "--format=csv,noheader,nounits"}
+}
+
+// one line per gpu.  unsupported values ("[N/A]", "[Not Supported]") are left out.
+func parseNvidiaSmiOutput(output string, values map[string]float64) error {
+	var numGpus int
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != len(nvidiaSmiFields) {
+			return fmt.Errorf("unexpected nvidia-smi output %q", line)
+		}
+		gpuIdx := strings.TrimSpace(fields[0])
+		if _, err := strconv.Atoi(gpuIdx); err != nil {
+			return fmt.Errorf("unexpected nvidia-smi gpu index %q", gpuIdx)
+		}
+		numGpus++
+		for idx := 1; idx < len(fields); idx++ {
+			val, err := strconv.ParseFloat(strings.TrimSpace(fields[idx]), 64)
+			if err != nil {
+				continue
+			}
+			values[wshrpc.TimeSeries_Gpu+":"+gpuIdx+":"+nvidiaSmiKeys[idx]] = val
+		}
+	}
+	if numGpus == 0 {
+		return fmt.Errorf("%s: nvidia-smi reported no gpus", ErrPrefix_NoGpu)
+	}
+	return nil
+}
+
+func sampleGpuData(ctx context.Context, smiPath string, timeout time.Duration) (wshrpc.TimeSeriesData, error) {
+	ctx, cancelFn := context.WithTimeout(ctx, timeout)
+	defer cancelFn()
+	now := time.Now()
+	output, err := exec.CommandContext(ctx, smiPath, nvidiaSmiArgs()...).Output()
+	if err != nil {
+		return wshrpc.TimeSeriesData{}, fmt.Errorf("error running nvidia-smi: %w", err)
+	}
+	values := make(map[string]float64)
+	err = parseNvidiaSmiOutput(string(output), values)
+	if err != nil {
+		return wshrpc.TimeSeriesData{}, err
+	}
+	return wshrpc.TimeSeriesData{Ts: now.UnixMilli(), Values: values}, nil
+}
+
+func (impl *ServerImpl) StreamGpuDataCommand(ctx context.Context, request wshrpc.GpuDataRequest) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData], 16)
+	agg, err := wshrpc.MakeDownsampleAggregator(request.Downsample)
+	if err != nil {
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Error: err}
+		close(rtn)
+		return rtn
+	}
+	interval := DefaultGpuSampleInterval
+	if request.IntervalMs > 0 {
+		interval = max(time.Duration(request.IntervalMs)*time.Millisecond, MinGpuSampleInterval)
+	}
+	wshutil.SetStreamStartParam(ctx, wshrpc.StreamStartParam_IntervalMs, interval.Milliseconds())
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("StreamGpuDataCommand", recover())
+		}()
+		defer close(rtn)
+		smiPath, err := exec.LookPath("nvidia-smi")
+		if err != nil {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Error: fmt.Errorf("%s: nvidia-smi not found (only nvidia gpus are supported)", ErrPrefix_NoGpu)}
+			return
+		}
+		for numSamples := 0; ; numSamples++ {
+			sample, err := sampleGpuData(ctx, smiPath, interval)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if numSamples == 0 && !strings.HasPrefix(err.Error(), ErrPrefix_NoGpu) {
+					// nvidia-smi exists but fails (e.g. no driver loaded)
+					err = fmt.Errorf("%s: %w", ErrPrefix_NoGpu, err)
+				}
+				rtn <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Error: err}
+				return
+			}
+			if !wshrpc.SendTimeSeriesSample(ctx, rtn, agg, sample) {
+				return
+			}
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return rtn
+}
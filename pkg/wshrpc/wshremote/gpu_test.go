@@ -0,0 +1,35 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNvidiaSmiOutput(t *testing.T) {
+	output := "0, 45, 1024, 24576, 61\n1, 0, 3, 81920, [N/A]\n"
+	values := make(map[string]float64)
+	err := parseNvidiaSmiOutput(output, values)
+	if err != nil {
+		t.Fatalf("error parsing output: %v", err)
+	}
+	expected := map[string]float64{
+		"gpu:0:util": 45, "gpu:0:memused": 1024, "gpu:0:memtotal": 24576, "gpu:0:temp": 61,
+		"gpu:1:util": 0, "gpu:1:memused": 3, "gpu:1:memtotal": 81920,
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	err = parseNvidiaSmiOutput("\n", make(map[string]float64))
+	if err == nil || !strings.HasPrefix(err.Error(), ErrPrefix_NoGpu) {
+		t.Errorf("expected no gpu error for empty output, got %v", err)
+	}
+	err = parseNvidiaSmiOutput("NVIDIA-SMI has failed because it couldn't communicate with the NVIDIA driver.", make(map[string]float64))
+	if err == nil {
+		t.Errorf("expected error for unexpected output")
+	}
+}
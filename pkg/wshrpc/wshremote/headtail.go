@@ -0,0 +1,206 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const DefaultHeadTailLines = 10
+const MaxHeadTailLines = 10000
+const MaxHeadTailLineLen = 64 * 1024
+const HeadTailBlockSize = 64 * 1024
+
+func makeHeadTailLine(line []byte) string {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if len(line) > MaxHeadTailLineLen {
+		line = line[:MaxHeadTailLineLen]
+	}
+	return string(line)
+}
+
+func readHeadLines(ctx context.Context, fd *os.File, numLines int) ([]string, error) {
+	reader := bufio.NewReaderSize(fd, HeadTailBlockSize)
+	var rtn []string
+	var lineBuf []byte
+	for len(rtn) < numLines {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		chunk, err := reader.ReadSlice('\n')
+		if len(lineBuf) < MaxHeadTailLineLen {
+			lineBuf = append(lineBuf, chunk[:min(len(chunk), MaxHeadTailLineLen-len(lineBuf))]...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// very long line, the rest of it is skipped
+			continue
+		}
+		if err == nil {
+			rtn = append(rtn, makeHeadTailLine(bytes.TrimSuffix(lineBuf, []byte{'\n'})))
+			lineBuf = lineBuf[:0]
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			if len(lineBuf) > 0 {
+				// no trailing newline
+				rtn = append(rtn, makeHeadTailLine(lineBuf))
+			}
+			break
+		}
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return rtn, nil
+}
+
+// reads backward from EOF in blocks until enough lines are found.  the scan is capped (so one huge line
+// doesn't load the whole file), in which case the first returned line is the (truncated) end of a longer line.
+func readTailLines(ctx context.Context, fd *os.File, size int64, numLines int) ([]string, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	end := size
+	lastByte := make([]byte, 1)
+	if _, err := fd.ReadAt(lastByte, end-1); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	if lastByte[0] == '\n' {
+		end--
+	}
+	maxScan := int64(numLines+1) * MaxHeadTailLineLen
+	pos := end
+	var buf []byte
+	numNewlines := 0
+	for pos > 0 && numNewlines < numLines && end-pos < maxScan {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		readSize := min(int64(HeadTailBlockSize), pos)
+		pos -= readSize
+		block := make([]byte, readSize)
+		if _, err := fd.ReadAt(block, pos); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		numNewlines += bytes.Count(block, []byte{'\n'})
+		buf = append(block, buf...)
+	}
+	parts := bytes.Split(buf, []byte{'\n'})
+	parts = parts[max(len(parts)-numLines, 0):]
+	rtn := make([]string, 0, len(parts))
+	for _, part := range parts {
+		rtn = append(rtn, makeHeadTailLine(part))
+	}
+	return rtn, nil
+}
+
+func (impl *ServerImpl) RemoteFileHeadTailCommand(ctx context.Context, data wshrpc.CommandRemoteHeadTailData) ([]string, error) {
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return nil, err
+	}
+	numLines := data.Lines
+	if numLines <= 0 {
+		numLines = DefaultHeadTailLines
+	}
+	numLines = min(numLines, MaxHeadTailLines)
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %q: %w", path, err)
+	}
+	defer fd.Close()
+	finfo, err := fd.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat file %q: %w", path, err)
+	}
+	if finfo.IsDir() {
+		return nil, fmt.Errorf("%q is a directory", path)
+	}
+	if data.FromEnd {
+		return readTailLines(ctx, fd, finfo.Size(), numLines)
+	}
+	return readHeadLines(ctx, fd, numLines)
+}
+
+// scans forward from the start of the file (line boundaries aren't indexed), so the cost grows with StartLine
+func readFileLines(ctx context.Context, fd *os.File, startLine int, endLine int) (wshrpc.FileLinesRtnData, error) {
+	var rtn wshrpc.FileLinesRtnData
+	reader := bufio.NewReaderSize(fd, HeadTailBlockSize)
+	lineNum := 1
+	var offset, lineStart int64
+	var lineBuf []byte
+	for lineNum <= endLine {
+		if ctx.Err() != nil {
+			return rtn, ctx.Err()
+		}
+		chunk, err := reader.ReadSlice('\n')
+		offset += int64(len(chunk))
+		if lineNum >= startLine && len(lineBuf) < MaxHeadTailLineLen {
+			lineBuf = append(lineBuf, chunk[:min(len(chunk), MaxHeadTailLineLen-len(lineBuf))]...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err == nil {
+			if lineNum >= startLine {
+				rtn.Lines = append(rtn.Lines, wshrpc.FileLine{Line: lineNum, Offset: lineStart, Text: makeHeadTailLine(bytes.TrimSuffix(lineBuf, []byte{'\n'}))})
+			}
+			lineBuf = lineBuf[:0]
+			lineNum++
+			lineStart = offset
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			if offset > lineStart && lineNum >= startLine {
+				// no trailing newline
+				rtn.Lines = append(rtn.Lines, wshrpc.FileLine{Line: lineNum, Offset: lineStart, Text: makeHeadTailLine(lineBuf)})
+			}
+			rtn.EOF = true
+			break
+		}
+		return rtn, fmt.Errorf("error reading file: %w", err)
+	}
+	rtn.EndOffset = offset
+	return rtn, nil
+}
+
+func (impl *ServerImpl) RemoteFileLinesCommand(ctx context.Context, data wshrpc.CommandRemoteFileLinesData) (wshrpc.FileLinesRtnData, error) {
+	if data.StartLine < 1 {
+		return wshrpc.FileLinesRtnData{}, fmt.Errorf("startline must be at least 1")
+	}
+	endLine := data.EndLine
+	if endLine == 0 {
+		endLine = data.StartLine + DefaultHeadTailLines - 1
+	}
+	if endLine < data.StartLine {
+		return wshrpc.FileLinesRtnData{}, fmt.Errorf("endline (%d) is before startline (%d)", endLine, data.StartLine)
+	}
+	if endLine-data.StartLine+1 > MaxHeadTailLines {
+		return wshrpc.FileLinesRtnData{}, fmt.Errorf("too many lines requested (%d), max is %d", endLine-data.StartLine+1, MaxHeadTailLines)
+	}
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return wshrpc.FileLinesRtnData{}, err
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return wshrpc.FileLinesRtnData{}, fmt.Errorf("cannot open file %q: %w", path, err)
+	}
+	defer fd.Close()
+	finfo, err := fd.Stat()
+	if err != nil {
+		return wshrpc.FileLinesRtnData{}, fmt.Errorf("cannot stat file %q: %w", path, err)
+	}
+	if finfo.IsDir() {
+		return wshrpc.FileLinesRtnData{}, fmt.Errorf("%q is a directory", path)
+	}
+	return readFileLines(ctx, fd, data.StartLine, endLine)
+}
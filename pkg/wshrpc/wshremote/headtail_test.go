@@ -0,0 +1,114 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRemoteFileHeadTail(t *testing.T) {
+	dir := t.TempDir()
+	var sb strings.Builder
+	for idx := 1; idx <= 20000; idx++ {
+		fmt.Fprintf(&sb, "line %d\n", idx)
+	}
+	impl := &ServerImpl{}
+	cases := []struct {
+		name     string
+		contents string
+		data     wshrpc.CommandRemoteHeadTailData
+		expected []string
+	}{
+		{"tail", sb.String(), wshrpc.CommandRemoteHeadTailData{Lines: 3, FromEnd: true}, []string{"line 19998", "line 19999", "line 20000"}},
+		{"head", sb.String(), wshrpc.CommandRemoteHeadTailData{Lines: 2}, []string{"line 1", "line 2"}},
+		{"tail no trailing newline", "a\nb\r\nc", wshrpc.CommandRemoteHeadTailData{Lines: 2, FromEnd: true}, []string{"b", "c"}},
+		{"head no trailing newline", "a\nb", wshrpc.CommandRemoteHeadTailData{Lines: 5}, []string{"a", "b"}},
+		{"tail short file", "a\n\nb\n", wshrpc.CommandRemoteHeadTailData{Lines: 10, FromEnd: true}, []string{"a", "", "b"}},
+		{"empty", "", wshrpc.CommandRemoteHeadTailData{Lines: 10, FromEnd: true}, nil},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "-"))
+		os.WriteFile(path, []byte(tc.contents), 0644)
+		tc.data.Path = path
+		lines, err := impl.RemoteFileHeadTailCommand(context.Background(), tc.data)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(lines, tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, lines)
+		}
+	}
+}
+
+func TestRemoteFileHeadTailLongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "long.txt")
+	longLine := strings.Repeat("x", 10*MaxHeadTailLineLen)
+	os.WriteFile(path, []byte("first\n"+longLine+"\nlast\n"), 0644)
+	impl := &ServerImpl{}
+	for _, fromEnd := range []bool{false, true} {
+		lines, err := impl.RemoteFileHeadTailCommand(context.Background(), wshrpc.CommandRemoteHeadTailData{Path: path, Lines: 2, FromEnd: fromEnd})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(lines) != 2 {
+			t.Fatalf("fromend=%v: expected 2 lines, got %d", fromEnd, len(lines))
+		}
+		longIdx := 1
+		if fromEnd {
+			longIdx = 0
+		}
+		if len(lines[longIdx]) != MaxHeadTailLineLen {
+			t.Errorf("fromend=%v: expected the long line truncated to %d, got %d", fromEnd, MaxHeadTailLineLen, len(lines[longIdx]))
+		}
+	}
+}
+
+func TestRemoteFileLines(t *testing.T) {
+	dir := t.TempDir()
+	impl := &ServerImpl{}
+	cases := []struct {
+		name      string
+		contents  string
+		startLine int
+		endLine   int
+		expected  []wshrpc.FileLine
+		endOffset int64
+		eof       bool
+	}{
+		{"lf", "one\ntwo\nthree\nfour\n", 2, 3, []wshrpc.FileLine{{Line: 2, Offset: 4, Text: "two"}, {Line: 3, Offset: 8, Text: "three"}}, 14, false},
+		{"crlf", "one\r\ntwo\r\nthree\r\n", 2, 5, []wshrpc.FileLine{{Line: 2, Offset: 5, Text: "two"}, {Line: 3, Offset: 10, Text: "three"}}, 17, true},
+		{"no final newline", "one\ntwo", 2, 2, []wshrpc.FileLine{{Line: 2, Offset: 4, Text: "two"}}, 7, true},
+		{"mixed", "a\r\nb\nc\r\n", 1, 3, []wshrpc.FileLine{{Line: 1, Offset: 0, Text: "a"}, {Line: 2, Offset: 3, Text: "b"}, {Line: 3, Offset: 5, Text: "c"}}, 8, false},
+		{"past the end", "a\nb\n", 5, 6, nil, 4, true},
+		{"empty lines", "\n\nx\n", 1, 0, []wshrpc.FileLine{{Line: 1, Offset: 0, Text: ""}, {Line: 2, Offset: 1, Text: ""}, {Line: 3, Offset: 2, Text: "x"}}, 4, true},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "-"))
+		os.WriteFile(path, []byte(tc.contents), 0644)
+		rtn, err := impl.RemoteFileLinesCommand(context.Background(), wshrpc.CommandRemoteFileLinesData{Path: path, StartLine: tc.startLine, EndLine: tc.endLine})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rtn.Lines, tc.expected) || rtn.EndOffset != tc.endOffset || rtn.EOF != tc.eof {
+			t.Errorf("%s: got %#v, expected lines %#v endoffset %d eof %v", tc.name, rtn, tc.expected, tc.endOffset, tc.eof)
+		}
+	}
+
+	path := filepath.Join(dir, "lf")
+	if _, err := impl.RemoteFileLinesCommand(context.Background(), wshrpc.CommandRemoteFileLinesData{Path: path, StartLine: 3, EndLine: 2}); err == nil {
+		t.Errorf("expected an error for endline before startline")
+	}
+	if _, err := impl.RemoteFileLinesCommand(context.Background(), wshrpc.CommandRemoteFileLinesData{Path: path, StartLine: 1, EndLine: MaxHeadTailLines + 1}); err == nil {
+		t.Errorf("expected an error for too many lines")
+	}
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+var killSignals = map[string]unix.Signal{
+	"TERM": unix.SIGTERM,
+	"KILL": unix.SIGKILL,
+	"INT":  unix.SIGINT,
+	"HUP":  unix.SIGHUP,
+	"QUIT": unix.SIGQUIT,
+	"USR1": unix.SIGUSR1,
+	"USR2": unix.SIGUSR2,
+	"STOP": unix.SIGSTOP,
+	"CONT": unix.SIGCONT,
+}
+
+func isValidKillSignal(signal string) bool {
+	_, ok := killSignals[signal]
+	return ok
+}
+
+func mapKillErrno(err error) error {
+	if errors.Is(err, unix.ESRCH) {
+		return fmt.Errorf("%w: %w", errProcNotFound, err)
+	}
+	if errors.Is(err, unix.EPERM) {
+		return fmt.Errorf("%w: %w", errProcPermission, err)
+	}
+	return err
+}
+
+// signal 0 checks for existence, EPERM means the process exists (but is owned by someone else)
+func osProcessExists(pid int) (bool, error) {
+	err := unix.Kill(pid, 0)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, unix.EPERM) {
+		return true, mapKillErrno(err)
+	}
+	if errors.Is(err, unix.ESRCH) {
+		return false, nil
+	}
+	return false, err
+}
+
+func osSignalProcess(pid int, signal string) error {
+	sig, ok := killSignals[signal]
+	if !ok {
+		return fmt.Errorf("unsupported signal %q", signal)
+	}
+	return mapKillErrno(unix.Kill(pid, sig))
+}
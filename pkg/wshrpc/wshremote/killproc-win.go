@@ -0,0 +1,56 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// windows can only terminate a process, TERM is treated the same as KILL
+func isValidKillSignal(signal string) bool {
+	return signal == "TERM" || signal == KillSignal_Kill
+}
+
+func osProcessExists(pid int) (bool, error) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+			return true, fmt.Errorf("%w: %w", errProcPermission, err)
+		}
+		return false, nil
+	}
+	defer windows.CloseHandle(handle)
+	var exitCode uint32
+	err = windows.GetExitCodeProcess(handle, &exitCode)
+	if err != nil {
+		return false, err
+	}
+	const stillActive = 259
+	return exitCode == stillActive, nil
+}
+
+func osSignalProcess(pid int, signal string) error {
+	if !isValidKillSignal(signal) {
+		return fmt.Errorf("unsupported signal %q", signal)
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+			return fmt.Errorf("%w: %w", errProcPermission, err)
+		}
+		return fmt.Errorf("%w: %w", errProcNotFound, err)
+	}
+	defer proc.Release()
+	err = proc.Kill()
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return fmt.Errorf("%w: %w", errProcPermission, err)
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const KillSignal_Kill = "KILL"
+const KillPollInterval = 50 * time.Millisecond
+
+var errProcNotFound = errors.New("process not found")
+var errProcPermission = errors.New("permission denied")
+
+// swapped out in tests.  both return errProcNotFound / errProcPermission (wrapped) for those cases
+var processExists = osProcessExists
+var signalProcess = osSignalProcess
+
+func wrapKillError(pid int, signal string, err error) error {
+	if errors.Is(err, errProcNotFound) {
+		return fmt.Errorf("process %d not found", pid)
+	}
+	if errors.Is(err, errProcPermission) {
+		return fmt.Errorf("permission denied sending %s to process %d", signal, pid)
+	}
+	return fmt.Errorf("error sending %s to process %d: %w", signal, pid, err)
+}
+
+// waits up to grace for the process to exit, returns true if it did
+func waitForProcessExit(ctx context.Context, pid int, grace time.Duration) bool {
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if exists, _ := processExists(pid); !exists {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(KillPollInterval):
+		}
+	}
+	exists, _ := processExists(pid)
+	return !exists
+}
+
+func killProcess(ctx context.Context, data wshrpc.CommandRemoteKillData) error {
+	if data.Pid <= 0 {
+		return fmt.Errorf("invalid pid %d", data.Pid)
+	}
+	if data.Pid == os.Getpid() {
+		return fmt.Errorf("cannot signal the wsh server itself (pid %d)", data.Pid)
+	}
+	signal := data.GetSignalName()
+	if !isValidKillSignal(signal) {
+		return fmt.Errorf("unsupported signal %q", data.Signal)
+	}
+	exists, err := processExists(data.Pid)
+	if err != nil && !errors.Is(err, errProcPermission) {
+		return wrapKillError(data.Pid, signal, err)
+	}
+	if !exists {
+		return fmt.Errorf("process %d not found", data.Pid)
+	}
+	err = signalProcess(data.Pid, signal)
+	if err != nil {
+		return wrapKillError(data.Pid, signal, err)
+	}
+	if !data.Force || signal == KillSignal_Kill {
+		return nil
+	}
+	if waitForProcessExit(ctx, data.Pid, data.GetGrace()) {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	err = signalProcess(data.Pid, KillSignal_Kill)
+	if err != nil && !errors.Is(err, errProcNotFound) {
+		return wrapKillError(data.Pid, KillSignal_Kill, err)
+	}
+	return nil
+}
+
+func (impl *ServerImpl) RemoteKillProcessCommand(ctx context.Context, data wshrpc.CommandRemoteKillData) error {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if !impl.isOperatorSource(rpcSource) {
+		return fmt.Errorf("killing processes is only allowed from the wave app (not %q)", rpcSource)
+	}
+	return killProcess(ctx, data)
+}
@@ -0,0 +1,135 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// a fake process table: signals are recorded, processes in ignoreTerm survive everything but KILL
+type mockProcs struct {
+	lock       sync.Mutex
+	alive      map[int]bool
+	ignoreTerm map[int]bool
+	denied     map[int]bool
+	signals    []string
+}
+
+func (m *mockProcs) exists(pid int) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.denied[pid] {
+		return true, errProcPermission
+	}
+	return m.alive[pid], nil
+}
+
+func (m *mockProcs) signal(pid int, signal string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.denied[pid] {
+		return fmt.Errorf("%w: operation not permitted", errProcPermission)
+	}
+	if !m.alive[pid] {
+		return errProcNotFound
+	}
+	m.signals = append(m.signals, fmt.Sprintf("%d:%s", pid, signal))
+	if signal == KillSignal_Kill || !m.ignoreTerm[pid] {
+		delete(m.alive, pid)
+	}
+	return nil
+}
+
+func installMockProcs(t *testing.T, m *mockProcs) {
+	origExists, origSignal := processExists, signalProcess
+	processExists, signalProcess = m.exists, m.signal
+	t.Cleanup(func() {
+		processExists, signalProcess = origExists, origSignal
+	})
+}
+
+func TestKillProcess(t *testing.T) {
+	ctx := context.Background()
+	procs := &mockProcs{
+		alive:      map[int]bool{100: true, 200: true, 300: true, 400: true},
+		ignoreTerm: map[int]bool{200: true, 300: true},
+		denied:     map[int]bool{500: true},
+	}
+	installMockProcs(t, procs)
+
+	if err := killProcess(ctx, wshrpc.CommandRemoteKillData{Pid: 100}); err != nil {
+		t.Errorf("default kill: %v", err)
+	}
+	// TERM is ignored, force escalates to KILL after the grace period
+	if err := killProcess(ctx, wshrpc.CommandRemoteKillData{Pid: 200, Force: true, GraceMs: 100}); err != nil {
+		t.Errorf("forced kill: %v", err)
+	}
+	// without force the ignored TERM is left alone
+	if err := killProcess(ctx, wshrpc.CommandRemoteKillData{Pid: 300, Signal: "sigint"}); err != nil {
+		t.Errorf("int signal: %v", err)
+	}
+	if err := killProcess(ctx, wshrpc.CommandRemoteKillData{Pid: 400, Signal: "KILL", Force: true}); err != nil {
+		t.Errorf("kill signal: %v", err)
+	}
+	expected := []string{"100:TERM", "200:TERM", "200:KILL", "300:INT", "400:KILL"}
+	if !reflect.DeepEqual(procs.signals, expected) {
+		t.Errorf("unexpected signals:\n got: %v\nwant: %v", procs.signals, expected)
+	}
+
+	for name, tc := range map[string]struct {
+		data   wshrpc.CommandRemoteKillData
+		errStr string
+	}{
+		"not-found":  {wshrpc.CommandRemoteKillData{Pid: 999}, "not found"},
+		"permission": {wshrpc.CommandRemoteKillData{Pid: 500}, "permission denied"},
+		"bad-pid":    {wshrpc.CommandRemoteKillData{Pid: -1}, "invalid pid"},
+		"bad-signal": {wshrpc.CommandRemoteKillData{Pid: 300, Signal: "BOGUS"}, "unsupported signal"},
+	} {
+		err := killProcess(ctx, tc.data)
+		if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.errStr, err)
+		}
+	}
+}
+
+func TestKillProcessOperatorOnly(t *testing.T) {
+	procs := &mockProcs{alive: map[int]bool{100: true}}
+	installMockProcs(t, procs)
+	router := wshutil.NewWshRouter()
+	router.RegisterRoute("proc:1", wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil), false)
+	data := wshrpc.CommandRemoteKillData{Pid: 100}
+
+	for name, tc := range map[string]struct {
+		impl   *ServerImpl
+		source string
+	}{
+		"no-router":  {&ServerImpl{}, "tab:1"},
+		"no-source":  {&ServerImpl{DirectUpstream: true}, ""},
+		"local-wsh":  {&ServerImpl{Router: router}, "proc:1"},
+		"no-context": {&ServerImpl{Router: router}, ""},
+	} {
+		ctx := context.Background()
+		if tc.source != "" {
+			ctx = wshutil.WithLocalRequest(ctx, tc.source, wshrpc.Command_RemoteKillProcess, wshrpc.RpcContext{})
+		}
+		if err := tc.impl.RemoteKillProcessCommand(ctx, data); err == nil {
+			t.Errorf("%s: expected the kill to be rejected", name)
+		}
+	}
+	if len(procs.signals) != 0 {
+		t.Fatalf("rejected kills sent signals: %v", procs.signals)
+	}
+	ctx := wshutil.WithLocalRequest(context.Background(), "tab:1", wshrpc.Command_RemoteKillProcess, wshrpc.RpcContext{})
+	if err := (&ServerImpl{Router: router}).RemoteKillProcessCommand(ctx, data); err != nil {
+		t.Errorf("kill from the wave app: %v", err)
+	}
+}
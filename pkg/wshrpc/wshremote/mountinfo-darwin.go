@@ -0,0 +1,34 @@
+//go:build darwin
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/sys/unix"
+)
+
+func findMountPoint(path string) (string, error) {
+	var stat unix.Statfs_t
+	err := unix.Statfs(path, &stat)
+	if err != nil {
+		return "", err
+	}
+	return unix.ByteSliceToString(stat.Mntonname[:]), nil
+}
+
+func readMountInfo(mountPoint string) (wshrpc.MountInfo, error) {
+	var stat unix.Statfs_t
+	err := unix.Statfs(mountPoint, &stat)
+	if err != nil {
+		return wshrpc.MountInfo{}, err
+	}
+	return wshrpc.MountInfo{
+		FsType:   unix.ByteSliceToString(stat.Fstypename[:]),
+		Device:   unix.ByteSliceToString(stat.Mntfromname[:]),
+		Network:  stat.Flags&unix.MNT_LOCAL == 0,
+		ReadOnly: stat.Flags&unix.MNT_RDONLY != 0,
+	}, nil
+}
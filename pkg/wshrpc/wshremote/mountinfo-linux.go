@@ -0,0 +1,123 @@
+//go:build linux
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const ProcMountInfoFile = "/proc/self/mountinfo"
+
+func statDev(path string) (uint64, error) {
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := finfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("no stat info for %q", path)
+	}
+	return uint64(stat.Dev), nil
+}
+
+// walks up until the device changes.  (bind mounts of the same device resolve to the outer mount)
+func findMountPoint(path string) (string, error) {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dev, err := statDev(path)
+	if err != nil {
+		return "", err
+	}
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path, nil
+		}
+		parentDev, err := statDev(parent)
+		if err != nil || parentDev != dev {
+			return path, nil
+		}
+		path = parent
+	}
+}
+
+// mountinfo escapes space, tab, newline and backslash as \ooo
+func unescapeMountInfoField(field string) string {
+	if !strings.Contains(field, "\\") {
+		return field
+	}
+	var sb strings.Builder
+	for idx := 0; idx < len(field); idx++ {
+		if field[idx] == '\\' && idx+3 < len(field) {
+			if val, err := strconv.ParseUint(field[idx+1:idx+4], 8, 8); err == nil {
+				sb.WriteByte(byte(val))
+				idx += 3
+				continue
+			}
+		}
+		sb.WriteByte(field[idx])
+	}
+	return sb.String()
+}
+
+// format: id parentid major:minor root mountpoint options [optional fields...] - fstype source superoptions
+// later entries are mounted on top of earlier ones, so the last match wins
+func parseMountInfo(contents string, mountPoint string) (wshrpc.MountInfo, bool) {
+	var rtn wshrpc.MountInfo
+	var found bool
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 || unescapeMountInfoField(fields[4]) != mountPoint {
+			continue
+		}
+		sepIdx := -1
+		for idx := 6; idx < len(fields); idx++ {
+			if fields[idx] == "-" {
+				sepIdx = idx
+				break
+			}
+		}
+		if sepIdx < 0 || sepIdx+2 >= len(fields) {
+			continue
+		}
+		rtn = wshrpc.MountInfo{
+			FsType: fields[sepIdx+1],
+			Device: unescapeMountInfoField(fields[sepIdx+2]),
+		}
+		for _, opt := range strings.Split(fields[5], ",") {
+			if opt == "ro" {
+				rtn.ReadOnly = true
+			}
+		}
+		found = true
+	}
+	return rtn, found
+}
+
+func readMountInfo(mountPoint string) (wshrpc.MountInfo, error) {
+	barr, err := os.ReadFile(ProcMountInfoFile)
+	if err != nil {
+		return wshrpc.MountInfo{}, err
+	}
+	info, found := parseMountInfo(string(barr), mountPoint)
+	if !found {
+		return wshrpc.MountInfo{}, fmt.Errorf("mount point %q not found in %s", mountPoint, ProcMountInfoFile)
+	}
+	return info, nil
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const testMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+35 22 0:31 / /mnt/my\040share rw,relatime shared:20 - cifs //fileserver/share rw,vers=3.1.1
+36 22 0:32 / /home/user/remote rw,nosuid,nodev - fuse.sshfs user@host:/data rw
+37 22 0:33 / /srv/nfs ro,relatime - nfs4 fileserver:/export ro
+38 37 0:34 / /srv/nfs rw,relatime - tmpfs tmpfs rw
+`
+
+func TestParseMountInfo(t *testing.T) {
+	tests := []struct {
+		mountPoint string
+		expected   wshrpc.MountInfo
+	}{
+		{"/", wshrpc.MountInfo{FsType: "ext4", Device: "/dev/sda1"}},
+		{"/mnt/my share", wshrpc.MountInfo{FsType: "cifs", Device: "//fileserver/share"}},
+		{"/home/user/remote", wshrpc.MountInfo{FsType: "fuse.sshfs", Device: "user@host:/data"}},
+		// later mounts cover earlier ones
+		{"/srv/nfs", wshrpc.MountInfo{FsType: "tmpfs", Device: "tmpfs"}},
+	}
+	for _, test := range tests {
+		info, found := parseMountInfo(testMountInfo, test.mountPoint)
+		if !found {
+			t.Errorf("mount point %q not found", test.mountPoint)
+			continue
+		}
+		if info != test.expected {
+			t.Errorf("mount point %q: expected %+v, got %+v", test.mountPoint, test.expected, info)
+		}
+	}
+	info, _ := parseMountInfo(testMountInfo[:len(testMountInfo)-len("38 37 0:34 / /srv/nfs rw,relatime - tmpfs tmpfs rw\n")], "/srv/nfs")
+	if !info.ReadOnly || !isNetworkFsType(info.FsType) {
+		t.Errorf("expected read-only network mount, got %+v", info)
+	}
+	if !isNetworkFsType("fuse.sshfs") || !isNetworkFsType("cifs") || isNetworkFsType("ext4") {
+		t.Errorf("unexpected network fs type detection")
+	}
+	if _, found := parseMountInfo(testMountInfo, "/nonexistent"); found {
+		t.Errorf("expected unknown mount point to not be found")
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin && !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func findMountPoint(path string) (string, error) {
+	return "", fmt.Errorf("mount info is not supported on %s", runtime.GOOS)
+}
+
+func readMountInfo(mountPoint string) (wshrpc.MountInfo, error) {
+	return wshrpc.MountInfo{}, fmt.Errorf("mount info is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/sys/windows"
+)
+
+// returns the volume root, a drive ("C:\") or a UNC share ("\\server\share\")
+func findMountPoint(path string) (string, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]uint16, windows.MAX_PATH+1)
+	err = windows.GetVolumePathName(pathPtr, &buf[0], uint32(len(buf)))
+	if err != nil {
+		return "", err
+	}
+	return windows.UTF16ToString(buf), nil
+}
+
+func readMountInfo(mountPoint string) (wshrpc.MountInfo, error) {
+	rootPtr, err := windows.UTF16PtrFromString(mountPoint)
+	if err != nil {
+		return wshrpc.MountInfo{}, err
+	}
+	rtn := wshrpc.MountInfo{
+		Network: windows.GetDriveType(rootPtr) == windows.DRIVE_REMOTE || strings.HasPrefix(mountPoint, `\\`),
+	}
+	if strings.HasPrefix(mountPoint, `\\`) {
+		rtn.Device = strings.TrimSuffix(mountPoint, `\`)
+	}
+	var fsFlags uint32
+	fsNameBuf := make([]uint16, windows.MAX_PATH+1)
+	err = windows.GetVolumeInformation(rootPtr, nil, 0, nil, nil, &fsFlags, &fsNameBuf[0], uint32(len(fsNameBuf)))
+	if err != nil {
+		return wshrpc.MountInfo{}, err
+	}
+	rtn.FsType = windows.UTF16ToString(fsNameBuf)
+	rtn.ReadOnly = fsFlags&windows.FILE_READ_ONLY_VOLUME != 0
+	return rtn, nil
+}
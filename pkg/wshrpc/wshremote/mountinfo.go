@@ -0,0 +1,115 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// mount info lets file browsers warn before heavy (recursive) operations on slow network mounts.
+// finding the mount point for a path is cheap (stat/statfs), reading the mount's details can mean
+// parsing the mount table, so results are cached per mount point.
+
+const MountInfoCacheTTL = 30 * time.Second
+
+var networkFsTypes = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb": true, "smb2": true, "smb3": true, "smbfs": true,
+	"afpfs": true, "webdav": true, "davfs": true, "ncpfs": true, "afs": true, "ceph": true,
+	"glusterfs": true, "lustre": true, "gpfs": true, "9p": true, "sshfs": true,
+}
+
+// fuse filesystems are reported as "fuse.<name>" on linux (e.g. fuse.sshfs)
+func isNetworkFsType(fsType string) bool {
+	fsType = strings.ToLower(fsType)
+	return networkFsTypes[fsType] || networkFsTypes[strings.TrimPrefix(fsType, "fuse.")]
+}
+
+type mountInfoCacheEntry struct {
+	info    wshrpc.MountInfo
+	expires time.Time
+}
+
+type mountInfoCache struct {
+	lock    *sync.Mutex
+	entries map[string]*mountInfoCacheEntry // mount point => entry
+}
+
+func (impl *ServerImpl) getMountInfoCache() *mountInfoCache {
+	impl.mountInfoCacheOnce.Do(func() {
+		impl.mountInfoCache = &mountInfoCache{lock: &sync.Mutex{}, entries: make(map[string]*mountInfoCacheEntry)}
+	})
+	return impl.mountInfoCache
+}
+
+func (c *mountInfoCache) get(mountPoint string) (wshrpc.MountInfo, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry := c.entries[mountPoint]
+	if entry == nil || time.Now().After(entry.expires) {
+		delete(c.entries, mountPoint)
+		return wshrpc.MountInfo{}, false
+	}
+	return entry.info, true
+}
+
+func (c *mountInfoCache) set(mountPoint string, info wshrpc.MountInfo) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[mountPoint] = &mountInfoCacheEntry{info: info, expires: time.Now().Add(MountInfoCacheTTL)}
+}
+
+func (impl *ServerImpl) getMountInfo(path string) (wshrpc.MountInfo, error) {
+	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(path))
+	mountPoint, err := findMountPoint(cleanedPath)
+	if err != nil {
+		return wshrpc.MountInfo{}, fmt.Errorf("cannot find mount point for %q: %w", cleanedPath, err)
+	}
+	cache := impl.getMountInfoCache()
+	info, ok := cache.get(mountPoint)
+	if !ok {
+		info, err = readMountInfo(mountPoint)
+		if err != nil {
+			return wshrpc.MountInfo{}, fmt.Errorf("cannot read mount info for %q: %w", mountPoint, err)
+		}
+		info.MountPoint = mountPoint
+		info.Network = info.Network || isNetworkFsType(info.FsType)
+		cache.set(mountPoint, info)
+	}
+	info.Path = cleanedPath
+	return info, nil
+}
+
+func (impl *ServerImpl) RemoteMountInfoCommand(ctx context.Context, data wshrpc.CommandRemoteMountData) (wshrpc.MountInfo, error) {
+	if data.Path == "" {
+		return wshrpc.MountInfo{}, fmt.Errorf("path is required")
+	}
+	return impl.getMountInfo(data.Path)
+}
+
+func (impl *ServerImpl) isNetworkPath(path string) bool {
+	info, err := impl.getMountInfo(path)
+	return err == nil && info.Network
+}
+
+// aborts reads once ctx is done.  (hides the *os.File, so io.Copy can't use copy_file_range/sendfile)
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
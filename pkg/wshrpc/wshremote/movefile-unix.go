@@ -0,0 +1,13 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// returned by rename when the source and destination are on different filesystems
+var errCrossDevice error = unix.EXDEV
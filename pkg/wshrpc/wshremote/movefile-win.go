@@ -0,0 +1,13 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// returned by rename when the source and destination are on different volumes
+var errCrossDevice error = windows.ERROR_NOT_SAME_DEVICE
@@ -0,0 +1,287 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// follows several files in one stream by polling them.  each file is handled on its own: a file that
+// is moved away or deleted is read to its end, and the path is followed again (from the start) when a
+// new file shows up there.  errors on one file are reported as status events and never end the stream.
+
+const MaxMultiTailFiles = 64
+const MultiTailPollInterval = 250 * time.Millisecond
+const MaxMultiTailReadSize = 1024 * 1024 // per file per poll, the rest is picked up on the next poll
+
+type tailedFile struct {
+	path    string
+	fd      *os.File
+	offset  int64
+	partial []byte // unterminated last line
+	status  string // last missing/error status sent (these aren't repeated)
+	errStr  string // with the error status
+}
+
+func (tf *tailedFile) close() {
+	if tf.fd != nil {
+		tf.fd.Close()
+		tf.fd = nil
+	}
+	tf.offset = 0
+	tf.partial = nil
+}
+
+// reads the complete lines added since the last read
+func (tf *tailedFile) readLines() ([]string, error) {
+	var lines []string
+	buf := make([]byte, HeadTailBlockSize)
+	for total := 0; total < MaxMultiTailReadSize; {
+		n, err := tf.fd.ReadAt(buf, tf.offset)
+		if n > 0 {
+			tf.offset += int64(n)
+			total += n
+			data := append(tf.partial, buf[:n]...)
+			for {
+				idx := bytes.IndexByte(data, '\n')
+				if idx == -1 {
+					break
+				}
+				lines = append(lines, makeHeadTailLine(data[:idx]))
+				data = data[idx+1:]
+			}
+			// the rest of a very long line is dropped
+			tf.partial = slices.Clone(data[:min(len(data), MaxHeadTailLineLen)])
+		}
+		if errors.Is(err, io.EOF) || (err == nil && n < len(buf)) {
+			break
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+	return lines, nil
+}
+
+// reads the old file to its end (including an unterminated last line) before it is closed
+func (tf *tailedFile) drain() []string {
+	lines, _ := tf.readLines()
+	if len(tf.partial) > 0 {
+		lines = append(lines, makeHeadTailLine(tf.partial))
+	}
+	tf.close()
+	return lines
+}
+
+func (tf *tailedFile) statusEvent(status string, err error) wshrpc.MultiTailEvent {
+	event := wshrpc.MultiTailEvent{Path: tf.path, Status: status}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// missing/error/following are only reported when they change
+func (tf *tailedFile) setStatus(events []wshrpc.MultiTailEvent, status string, err error) []wshrpc.MultiTailEvent {
+	if status == wshrpc.MultiTailStatus_Following && tf.status == "" {
+		return events
+	}
+	event := tf.statusEvent(status, err)
+	if status == tf.status && event.Error == tf.errStr {
+		return events
+	}
+	tf.status = status
+	if status == wshrpc.MultiTailStatus_Following {
+		tf.status = ""
+	}
+	tf.errStr = event.Error
+	return append(events, event)
+}
+
+func appendLinesEvent(events []wshrpc.MultiTailEvent, path string, lines []string) []wshrpc.MultiTailEvent {
+	if len(lines) == 0 {
+		return events
+	}
+	return append(events, wshrpc.MultiTailEvent{Path: path, Lines: lines})
+}
+
+// opens the file (at its end if backfilling) and returns the backfill event
+func (tf *tailedFile) start(ctx context.Context, backfillLines int) []wshrpc.MultiTailEvent {
+	fd, err := os.Open(tf.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return tf.setStatus(nil, wshrpc.MultiTailStatus_Missing, nil)
+		}
+		return tf.setStatus(nil, wshrpc.MultiTailStatus_Error, err)
+	}
+	finfo, err := fd.Stat()
+	if err == nil && finfo.IsDir() {
+		err = fmt.Errorf("%q is a directory", tf.path)
+	}
+	if err != nil {
+		fd.Close()
+		return tf.setStatus(nil, wshrpc.MultiTailStatus_Error, err)
+	}
+	tf.fd = fd
+	tf.offset = finfo.Size()
+	if backfillLines <= 0 {
+		return nil
+	}
+	lines, err := readTailLines(ctx, fd, finfo.Size(), min(backfillLines, MaxHeadTailLines))
+	if err != nil || len(lines) == 0 {
+		return nil
+	}
+	return []wshrpc.MultiTailEvent{{Path: tf.path, Lines: lines, Backfill: true}}
+}
+
+// checks for rotation/truncation and reads the new lines
+func (tf *tailedFile) poll() []wshrpc.MultiTailEvent {
+	var events []wshrpc.MultiTailEvent
+	pathInfo, statErr := os.Stat(tf.path)
+	if tf.fd != nil {
+		fdInfo, err := tf.fd.Stat()
+		if statErr != nil || err != nil || !os.SameFile(fdInfo, pathInfo) {
+			events = appendLinesEvent(events, tf.path, tf.drain())
+			if statErr == nil {
+				events = append(events, tf.statusEvent(wshrpc.MultiTailStatus_Rotated, nil))
+			}
+		} else if fdInfo.Size() < tf.offset {
+			tf.offset = 0
+			tf.partial = nil
+			events = append(events, tf.statusEvent(wshrpc.MultiTailStatus_Truncated, nil))
+		}
+	}
+	if tf.fd == nil {
+		if statErr != nil {
+			if errors.Is(statErr, fs.ErrNotExist) {
+				return tf.setStatus(events, wshrpc.MultiTailStatus_Missing, nil)
+			}
+			return tf.setStatus(events, wshrpc.MultiTailStatus_Error, statErr)
+		}
+		if pathInfo.IsDir() {
+			return tf.setStatus(events, wshrpc.MultiTailStatus_Error, fmt.Errorf("%q is a directory", tf.path))
+		}
+		fd, err := os.Open(tf.path)
+		if err != nil {
+			return tf.setStatus(events, wshrpc.MultiTailStatus_Error, err)
+		}
+		tf.fd = fd
+		events = tf.setStatus(events, wshrpc.MultiTailStatus_Following, nil)
+	}
+	lines, err := tf.readLines()
+	events = appendLinesEvent(events, tf.path, lines)
+	if err != nil {
+		tf.close()
+		events = tf.setStatus(events, wshrpc.MultiTailStatus_Error, err)
+	}
+	return events
+}
+
+// merges the line events of one poll into a single event, status events are kept separate (and sent first)
+func combineMultiTailEvents(events []wshrpc.MultiTailEvent, pathIdx map[string]int) []wshrpc.MultiTailEvent {
+	var rtn []wshrpc.MultiTailEvent
+	var combined wshrpc.MultiTailEvent
+	for _, event := range events {
+		if len(event.Lines) == 0 {
+			rtn = append(rtn, event)
+			continue
+		}
+		combined.Lines = append(combined.Lines, event.Lines...)
+		for range event.Lines {
+			combined.Sources = append(combined.Sources, pathIdx[event.Path])
+		}
+		combined.Backfill = event.Backfill
+	}
+	if len(combined.Lines) > 0 {
+		rtn = append(rtn, combined)
+	}
+	return rtn
+}
+
+func (impl *ServerImpl) RemoteMultiTailCommand(ctx context.Context, data wshrpc.CommandRemoteMultiTailData) chan wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RemoteMultiTailCommand", recover())
+		}()
+		defer close(ch)
+		if len(data.Paths) == 0 || len(data.Paths) > MaxMultiTailFiles {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent]{Error: fmt.Errorf("between 1 and %d paths are required", MaxMultiTailFiles)}
+			return
+		}
+		files := make([]*tailedFile, 0, len(data.Paths))
+		pathIdx := make(map[string]int)
+		for idx, path := range data.Paths {
+			expandedPath, err := wavebase.ExpandHomeDir(path)
+			if err != nil {
+				ch <- wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent]{Error: err}
+				return
+			}
+			if _, found := pathIdx[expandedPath]; found {
+				ch <- wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent]{Error: fmt.Errorf("path %q is listed more than once", path)}
+				return
+			}
+			// events use the path as requested, so the client can match them up
+			files = append(files, &tailedFile{path: expandedPath})
+			pathIdx[expandedPath] = idx
+		}
+		defer func() {
+			for _, tf := range files {
+				tf.close()
+			}
+		}()
+		send := func(events []wshrpc.MultiTailEvent) bool {
+			if data.Combined {
+				events = combineMultiTailEvents(events, pathIdx)
+			}
+			for _, event := range events {
+				if event.Path != "" {
+					event.Path = data.Paths[pathIdx[event.Path]]
+				}
+				select {
+				case ch <- wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent]{Response: event}:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		var events []wshrpc.MultiTailEvent
+		for _, tf := range files {
+			events = append(events, tf.start(ctx, data.BackfillLines)...)
+		}
+		if !send(events) {
+			return
+		}
+		ticker := time.NewTicker(MultiTailPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			events = nil
+			for _, tf := range files {
+				events = append(events, tf.poll()...)
+			}
+			if !send(events) {
+				return
+			}
+		}
+	}()
+	return ch
+}
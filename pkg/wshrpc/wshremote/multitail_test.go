@@ -0,0 +1,134 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func appendTestFile(t *testing.T, path string, text string) {
+	t.Helper()
+	fd, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("error opening %s: %v", path, err)
+	}
+	defer fd.Close()
+	fd.WriteString(text)
+}
+
+// collects lines by the path they were tagged with until numLines lines have arrived
+func readMultiTailLines(t *testing.T, ch chan wshrpc.RespOrErrorUnion[wshrpc.MultiTailEvent], paths []string, numLines int) map[string][]string {
+	t.Helper()
+	rtn := make(map[string][]string)
+	count := 0
+	timeout := time.After(5 * time.Second)
+	for count < numLines {
+		select {
+		case resp, ok := <-ch:
+			if !ok {
+				t.Fatalf("stream closed early, got %v", rtn)
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %v", resp.Error)
+			}
+			event := resp.Response
+			for idx, line := range event.Lines {
+				path := event.Path
+				if event.Sources != nil {
+					path = paths[event.Sources[idx]]
+				}
+				rtn[path] = append(rtn[path], line)
+				count++
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %d lines, got %v", numLines, rtn)
+		}
+	}
+	return rtn
+}
+
+func TestMultiTail(t *testing.T) {
+	for _, combined := range []bool{false, true} {
+		dir := t.TempDir()
+		pathA := filepath.Join(dir, "a.log")
+		pathB := filepath.Join(dir, "b.log")
+		appendTestFile(t, pathA, "a1\na2\na3\n")
+		appendTestFile(t, pathB, "b1\n")
+		paths := []string{pathA, pathB}
+		ctx, cancel := context.WithCancel(context.Background())
+		impl := &ServerImpl{}
+		ch := impl.RemoteMultiTailCommand(ctx, wshrpc.CommandRemoteMultiTailData{Paths: paths, BackfillLines: 2, Combined: combined})
+
+		lines := readMultiTailLines(t, ch, paths, 3)
+		expected := map[string][]string{pathA: {"a2", "a3"}, pathB: {"b1"}}
+		if !reflect.DeepEqual(lines, expected) {
+			t.Errorf("combined=%v backfill: got %v, want %v", combined, lines, expected)
+		}
+		appendTestFile(t, pathB, "b2\n")
+		appendTestFile(t, pathA, "a4\n")
+		appendTestFile(t, pathB, "b3\n")
+		lines = readMultiTailLines(t, ch, paths, 3)
+		expected = map[string][]string{pathA: {"a4"}, pathB: {"b2", "b3"}}
+		if !reflect.DeepEqual(lines, expected) {
+			t.Errorf("combined=%v new lines: got %v, want %v", combined, lines, expected)
+		}
+		cancel()
+		for range ch {
+		}
+	}
+}
+
+func TestTailedFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendTestFile(t, path, "old1\n")
+	tf := &tailedFile{path: path}
+	defer tf.close()
+	if events := tf.start(context.Background(), 0); len(events) != 0 {
+		t.Fatalf("no backfill requested, got %+v", events)
+	}
+	appendTestFile(t, path, "old2\nunterminated")
+	if events := tf.poll(); !reflect.DeepEqual(events, []wshrpc.MultiTailEvent{{Path: path, Lines: []string{"old2"}}}) {
+		t.Errorf("append: got %+v", events)
+	}
+
+	// rotated with a last write to the old file, which is read to its end first
+	os.Rename(path, path+".1")
+	appendTestFile(t, path+".1", " line\n")
+	appendTestFile(t, path, "new1\n")
+	expected := []wshrpc.MultiTailEvent{
+		{Path: path, Lines: []string{"unterminated line"}},
+		{Path: path, Status: wshrpc.MultiTailStatus_Rotated},
+		{Path: path, Lines: []string{"new1"}},
+	}
+	if events := tf.poll(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("rotation:\ngot  %+v\nwant %+v", events, expected)
+	}
+
+	os.WriteFile(path, []byte("x\n"), 0644)
+	expected = []wshrpc.MultiTailEvent{{Path: path, Status: wshrpc.MultiTailStatus_Truncated}, {Path: path, Lines: []string{"x"}}}
+	if events := tf.poll(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("truncation: got %+v", events)
+	}
+
+	os.Remove(path)
+	if events := tf.poll(); !reflect.DeepEqual(events, []wshrpc.MultiTailEvent{{Path: path, Status: wshrpc.MultiTailStatus_Missing}}) {
+		t.Errorf("removal: got %+v", events)
+	}
+	if events := tf.poll(); len(events) != 0 {
+		t.Errorf("missing status should not repeat, got %+v", events)
+	}
+	appendTestFile(t, path, "back\n")
+	expected = []wshrpc.MultiTailEvent{{Path: path, Status: wshrpc.MultiTailStatus_Following}, {Path: path, Lines: []string{"back"}}}
+	if events := tf.poll(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("reappear: got %+v", events)
+	}
+}
@@ -0,0 +1,149 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+type scriptResult struct {
+	stdout string
+	stderr string
+	done   *wshrpc.ExecOutputChunk
+	err    error
+}
+
+func collectScriptOutput(t *testing.T, ch chan wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]) scriptResult {
+	t.Helper()
+	var rtn scriptResult
+	for resp := range ch {
+		if resp.Error != nil {
+			rtn.err = resp.Error
+			continue
+		}
+		chunk := resp.Response
+		if chunk.Done {
+			rtn.done = &chunk
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(chunk.Data64)
+		if err != nil {
+			t.Fatalf("bad chunk data: %v", err)
+		}
+		if chunk.Stream == wshrpc.ExecStream_Stderr {
+			rtn.stderr += string(data)
+		} else {
+			rtn.stdout += string(data)
+		}
+	}
+	return rtn
+}
+
+func TestRunScript(t *testing.T) {
+	impl := &ServerImpl{DirectUpstream: true}
+	ctx := wshutil.WithLocalRequest(context.Background(), "tab:1", wshrpc.Command_RemoteRunScript, wshrpc.RpcContext{})
+	dir := t.TempDir()
+	script := "read name\necho \"hello $name from $(pwd) $GREETING_ARG $1\"\necho oops >&2\necho \"$0\"\nexit 3\n"
+	ch := impl.RemoteRunScriptCommand(ctx, wshrpc.CommandRemoteScriptData{
+		Interpreter: "sh",
+		Script:      script,
+		Args:        []string{"arg1"},
+		Stdin64:     base64.StdEncoding.EncodeToString([]byte("world\n")),
+		Env:         map[string]string{"GREETING_ARG": "env1"},
+		Cwd:         dir,
+	})
+	result := collectScriptOutput(t, ch)
+	if result.err != nil || result.done == nil {
+		t.Fatalf("expected a final chunk, got %+v", result)
+	}
+	lines := strings.Split(strings.TrimSpace(result.stdout), "\n")
+	if len(lines) != 2 || lines[0] != "hello world from "+dir+" env1 arg1" {
+		t.Errorf("unexpected stdout %q", result.stdout)
+	}
+	if result.stderr != "oops\n" || result.done.ExitCode != 3 {
+		t.Errorf("expected stderr and exit code 3, got %q %d", result.stderr, result.done.ExitCode)
+	}
+	if _, err := os.Stat(lines[len(lines)-1]); !os.IsNotExist(err) {
+		t.Errorf("script file %q was not removed", lines[len(lines)-1])
+	}
+
+	result = collectScriptOutput(t, impl.RemoteRunScriptCommand(ctx, wshrpc.CommandRemoteScriptData{Interpreter: "no-such-interpreter", Script: "true"}))
+	if result.err == nil || result.done != nil {
+		t.Errorf("expected an error for a missing interpreter, got %+v", result)
+	}
+}
+
+func TestRunScriptCancel(t *testing.T) {
+	impl := &ServerImpl{DirectUpstream: true}
+	opCtx := wshutil.WithLocalRequest(context.Background(), "tab:1", wshrpc.Command_RemoteRunScript, wshrpc.RpcContext{})
+	ctx, cancel := context.WithCancel(opCtx)
+	// the background sleep holds stdout open, so it has to be killed along with the shell
+	ch := impl.RemoteRunScriptCommand(ctx, wshrpc.CommandRemoteScriptData{Interpreter: "sh", Script: "echo \"$0\"\nsleep 30 &\nsleep 30\n"})
+	first := <-ch
+	data, _ := base64.StdEncoding.DecodeString(first.Response.Data64)
+	scriptPath := strings.TrimSpace(string(data))
+	if _, err := os.Stat(scriptPath); err != nil {
+		t.Fatalf("expected the script file to exist while running: %v", err)
+	}
+	startTime := time.Now()
+	cancel()
+	result := collectScriptOutput(t, ch)
+	if result.done != nil || result.err != nil {
+		t.Errorf("expected the stream to close without a result, got %+v", result)
+	}
+	if time.Since(startTime) >= ScriptWaitDelay {
+		t.Errorf("cancellation took %v, the process group was not killed", time.Since(startTime))
+	}
+	if _, err := os.Stat(scriptPath); !os.IsNotExist(err) {
+		t.Errorf("script file %q was not removed after cancellation", scriptPath)
+	}
+
+	result = collectScriptOutput(t, impl.RemoteRunScriptCommand(opCtx, wshrpc.CommandRemoteScriptData{Interpreter: "sh", Script: "echo before\nsleep 30\n", TimeoutMs: 100}))
+	if result.stdout != "before\n" || result.err == nil || !strings.Contains(result.err.Error(), "timed out") {
+		t.Errorf("expected output and then a timeout error, got %+v", result)
+	}
+}
+
+func TestRunScriptOperatorOnly(t *testing.T) {
+	router := wshutil.NewWshRouter()
+	router.RegisterRoute("proc:1", wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil), false)
+	marker := filepath.Join(t.TempDir(), "ran")
+	data := wshrpc.CommandRemoteScriptData{Interpreter: "sh", Script: "touch " + marker + "\n"}
+	makeCtx := func(source string) context.Context {
+		return wshutil.WithLocalRequest(context.Background(), source, wshrpc.Command_RemoteRunScript, wshrpc.RpcContext{})
+	}
+	tests := []struct {
+		name string
+		impl *ServerImpl
+		ctx  context.Context
+	}{
+		{"no-router", &ServerImpl{}, makeCtx("tab:1")},
+		{"no-source", &ServerImpl{DirectUpstream: true}, context.Background()},
+		{"local-wsh", &ServerImpl{Router: router}, makeCtx("proc:1")},
+		{"in-process", &ServerImpl{InProcess: true}, makeCtx("tab:1")},
+	}
+	for _, tc := range tests {
+		result := collectScriptOutput(t, tc.impl.RemoteRunScriptCommand(tc.ctx, data))
+		if result.err == nil || result.done != nil {
+			t.Errorf("%s: expected the script to be rejected, got %+v", tc.name, result)
+		}
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("a rejected script ran")
+	}
+	if (&ServerImpl{InProcess: true}).ServesCommand(wshrpc.Command_RemoteRunScript) {
+		t.Errorf("the in-process local connection should not serve runscript")
+	}
+}
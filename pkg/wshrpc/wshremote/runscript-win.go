@@ -0,0 +1,14 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os/exec"
+)
+
+// only the interpreter is killed on cancellation (children would need a job object)
+func setScriptProcAttrs(cmd *exec.Cmd) {
+}
@@ -0,0 +1,187 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// runs a script body with an interpreter.  the script is written to a temp file (so stdin stays free
+// for the script's input) which is removed when the script exits, including on cancellation or
+// timeout (the process group is killed).  output is streamed as it is written, stdout and stderr
+// chunks are sent in the order they are read, not necessarily the order they were written.
+
+const MaxScriptSize = 1024 * 1024
+const ScriptWaitDelay = 2 * time.Second // for grandchildren still holding stdout/stderr after a kill
+
+// temp file extensions and args (before the script path) for interpreters that care
+var scriptInterpreterOpts = map[string]struct {
+	Ext  string
+	Args []string
+}{
+	"python":     {Ext: ".py"},
+	"python3":    {Ext: ".py"},
+	"node":       {Ext: ".js"},
+	"powershell": {Ext: ".ps1", Args: []string{"-NoProfile", "-NonInteractive", "-File"}},
+	"pwsh":       {Ext: ".ps1", Args: []string{"-NoProfile", "-NonInteractive", "-File"}},
+	"cmd":        {Ext: ".bat", Args: []string{"/c"}},
+}
+
+// sends each write as a chunk, blocks while the client is slow
+type scriptOutputWriter struct {
+	ctx    context.Context
+	stream string
+	ch     chan wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]
+}
+
+func (w *scriptOutputWriter) Write(p []byte) (int, error) {
+	chunk := wshrpc.ExecOutputChunk{Stream: w.stream, Data64: base64.StdEncoding.EncodeToString(p)}
+	select {
+	case w.ch <- wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]{Response: chunk}:
+		return len(p), nil
+	case <-w.ctx.Done():
+		return 0, w.ctx.Err()
+	}
+}
+
+// "/usr/bin/python3" => "python3", "PowerShell.exe" => "powershell"
+func getInterpreterName(interpreter string) string {
+	return strings.TrimSuffix(strings.ToLower(filepath.Base(interpreter)), ".exe")
+}
+
+func writeScriptFile(interpreter string, script string) (string, error) {
+	ext := scriptInterpreterOpts[getInterpreterName(interpreter)].Ext
+	fd, err := os.CreateTemp("", "wave-script-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("error creating script file: %w", err)
+	}
+	_, err = fd.WriteString(script)
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fd.Name())
+		return "", fmt.Errorf("error writing script file: %w", err)
+	}
+	return fd.Name(), nil
+}
+
+func makeScriptCmd(ctx context.Context, data wshrpc.CommandRemoteScriptData, scriptPath string, interpreterPath string) (*exec.Cmd, error) {
+	args := append([]string{}, scriptInterpreterOpts[getInterpreterName(data.Interpreter)].Args...)
+	args = append(args, scriptPath)
+	args = append(args, data.Args...)
+	cmd := exec.CommandContext(ctx, interpreterPath, args...)
+	setScriptProcAttrs(cmd)
+	cmd.WaitDelay = ScriptWaitDelay
+	if data.Cwd != "" {
+		cwd, err := wavebase.ExpandHomeDir(data.Cwd)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Dir = cwd
+	}
+	cmd.Env = os.Environ()
+	for key, val := range data.Env {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid environment variable name %q", key)
+		}
+		cmd.Env = append(cmd.Env, key+"="+val)
+	}
+	if data.Stdin64 != "" {
+		stdin, err := base64.StdEncoding.DecodeString(data.Stdin64)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding stdin: %w", err)
+		}
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	return cmd, nil
+}
+
+// returns the exit code, the error is only set if the script could not be run (or timed out)
+func runScript(ctx context.Context, data wshrpc.CommandRemoteScriptData, ch chan wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]) (int, error) {
+	if data.Interpreter == "" {
+		return 0, fmt.Errorf("interpreter is required")
+	}
+	if len(data.Script) > MaxScriptSize {
+		return 0, fmt.Errorf("script too large (%d bytes, max %d)", len(data.Script), MaxScriptSize)
+	}
+	interpreterPath, err := exec.LookPath(data.Interpreter)
+	if err != nil {
+		return 0, fmt.Errorf("interpreter %q not found: %w", data.Interpreter, err)
+	}
+	runCtx := ctx
+	if data.TimeoutMs > 0 {
+		var cancelFn context.CancelFunc
+		runCtx, cancelFn = context.WithTimeout(ctx, time.Duration(data.TimeoutMs)*time.Millisecond)
+		defer cancelFn()
+	}
+	scriptPath, err := writeScriptFile(data.Interpreter, data.Script)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(scriptPath)
+	cmd, err := makeScriptCmd(runCtx, data, scriptPath, interpreterPath)
+	if err != nil {
+		return 0, err
+	}
+	// the output writers stop with the caller's ctx (not the timeout), so output before a timeout is sent
+	cmd.Stdout = &scriptOutputWriter{ctx: ctx, stream: wshrpc.ExecStream_Stdout, ch: ch}
+	cmd.Stderr = &scriptOutputWriter{ctx: ctx, stream: wshrpc.ExecStream_Stderr, ch: ch}
+	err = cmd.Run()
+	if runCtx.Err() != nil && ctx.Err() == nil {
+		return 0, fmt.Errorf("script timed out after %dms", data.TimeoutMs)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error running script: %w", err)
+	}
+	return 0, nil
+}
+
+func (impl *ServerImpl) RemoteRunScriptCommand(ctx context.Context, data wshrpc.CommandRemoteScriptData) chan wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RemoteRunScriptCommand", recover())
+		}()
+		defer close(ch)
+		if impl.InProcess {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]{Error: fmt.Errorf("the local connection runs inside wavesrv and cannot run scripts")}
+			return
+		}
+		rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+		if !impl.isOperatorSource(rpcSource) {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]{Error: fmt.Errorf("running scripts is only allowed from the wave app (not %q)", rpcSource)}
+			return
+		}
+		exitCode, err := runScript(ctx, data, ch)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]{Error: err}
+			return
+		}
+		ch <- wshrpc.RespOrErrorUnion[wshrpc.ExecOutputChunk]{Response: wshrpc.ExecOutputChunk{Done: true, ExitCode: exitCode}}
+	}()
+	return ch
+}
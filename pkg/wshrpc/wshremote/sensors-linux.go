@@ -0,0 +1,77 @@
+//go:build linux
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const HwmonRoot = "/sys/class/hwmon"
+
+func sensorsUnsupportedReason() string {
+	entries, err := os.ReadDir(HwmonRoot)
+	if err != nil || len(entries) == 0 {
+		return "no hwmon devices found in " + HwmonRoot
+	}
+	return ""
+}
+
+func readSensors(values map[string]float64) {
+	readHwmonSensors(HwmonRoot, values)
+}
+
+func readSysfsInt(fileName string) (int64, bool) {
+	barr, err := os.ReadFile(fileName)
+	if err != nil {
+		return 0, false
+	}
+	val, err := strconv.ParseInt(strings.TrimSpace(string(barr)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return val, true
+}
+
+// temp<N>_input is in millidegrees C, fan<N>_input in RPM.  keys use the chip name (hwmon<X>/name) and N-1.
+// chips that show up more than once (e.g. coretemp on multi-socket machines) get the hwmon dir appended.
+func readHwmonSensors(root string, values map[string]float64) {
+	dirs, _ := filepath.Glob(filepath.Join(root, "hwmon*"))
+	seenChips := make(map[string]bool)
+	for _, dir := range dirs {
+		barr, err := os.ReadFile(filepath.Join(dir, "name"))
+		if err != nil {
+			continue
+		}
+		chip := strings.TrimSpace(string(barr))
+		if chip == "" || seenChips[chip] {
+			chip = chip + "-" + filepath.Base(dir)
+		}
+		seenChips[chip] = true
+		readHwmonInputs(dir, "temp", wshrpc.TimeSeries_Temp+":"+chip, 1000, values)
+		readHwmonInputs(dir, "fan", wshrpc.TimeSeries_Fan+":"+chip, 1, values)
+	}
+}
+
+func readHwmonInputs(dir string, kind string, keyPrefix string, divisor float64, values map[string]float64) {
+	inputs, _ := filepath.Glob(filepath.Join(dir, kind+"*_input"))
+	for _, input := range inputs {
+		numStr := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(input), kind), "_input")
+		num, err := strconv.Atoi(numStr)
+		if err != nil || num < 1 {
+			continue
+		}
+		val, ok := readSysfsInt(input)
+		if !ok {
+			continue
+		}
+		values[keyPrefix+":"+strconv.Itoa(num-1)] = float64(val) / divisor
+	}
+}
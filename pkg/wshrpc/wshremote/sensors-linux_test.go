@@ -0,0 +1,53 @@
+//go:build linux
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadHwmonSensors(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"hwmon0/name":        "acpitz\n",
+		"hwmon0/temp1_input": "27800\n",
+		"hwmon1/name":        "coretemp\n",
+		"hwmon1/temp1_input": "45000\n",
+		"hwmon1/temp2_input": "43500\n",
+		"hwmon1/temp2_label": "Core 0\n",
+		"hwmon2/name":        "coretemp\n",
+		"hwmon2/temp1_input": "50000\n",
+		"hwmon3/name":        "nct6775\n",
+		"hwmon3/fan1_input":  "1200\n",
+		"hwmon3/fan2_input":  "garbage\n",
+	}
+	for name, contents := range files {
+		fullPath := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		err := os.WriteFile(fullPath, []byte(contents), 0644)
+		if err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+	}
+	values := make(map[string]float64)
+	readHwmonSensors(root, values)
+	expected := map[string]float64{
+		"temp:acpitz:0":          27.8,
+		"temp:coretemp:0":        45,
+		"temp:coretemp:1":        43.5,
+		"temp:coretemp-hwmon2:0": 50,
+		"fan:nct6775:0":          1200,
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+	if !sensorKeyMatches("temp:coretemp:1", []string{"temp:coretemp"}) || sensorKeyMatches("temp:coretemp-hwmon2:0", []string{"temp:coretemp"}) {
+		t.Errorf("unexpected sensor filter match")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import "runtime"
+
+func sensorsUnsupportedReason() string {
+	return "sensors are not supported on " + runtime.GOOS
+}
+
+func readSensors(values map[string]float64) {}
@@ -0,0 +1,85 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// sensors are only read on linux (hwmon, which also exposes the acpi thermal zones).  macOS (SMC) and
+// windows (WMI) need privileged or cgo access, so on those platforms (and on linux machines without
+// hwmon, e.g. containers) the stream ends immediately with an ErrPrefix_SensorsUnsupported error giving the reason.
+
+const DefaultSensorSampleInterval = 2 * time.Second
+const MinSensorSampleInterval = 500 * time.Millisecond
+
+const ErrPrefix_SensorsUnsupported = "sensors_unsupported"
+
+// filter entries match a full key or a key prefix ending on a ":" boundary
+func sensorKeyMatches(key string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if key == f || strings.HasPrefix(key, strings.TrimSuffix(f, ":")+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func sampleSensorData(filter []string) wshrpc.TimeSeriesData {
+	now := time.Now()
+	values := make(map[string]float64)
+	readSensors(values)
+	for key := range values {
+		if !sensorKeyMatches(key, filter) {
+			delete(values, key)
+		}
+	}
+	return wshrpc.TimeSeriesData{Ts: now.UnixMilli(), Values: values}
+}
+
+func (impl *ServerImpl) StreamSensorDataCommand(ctx context.Context, request wshrpc.SensorDataRequest) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData], 16)
+	agg, err := wshrpc.MakeDownsampleAggregator(request.Downsample)
+	if err != nil {
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Error: err}
+		close(rtn)
+		return rtn
+	}
+	interval := DefaultSensorSampleInterval
+	if request.IntervalMs > 0 {
+		interval = max(time.Duration(request.IntervalMs)*time.Millisecond, MinSensorSampleInterval)
+	}
+	wshutil.SetStreamStartParam(ctx, wshrpc.StreamStartParam_IntervalMs, interval.Milliseconds())
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("StreamSensorDataCommand", recover())
+		}()
+		defer close(rtn)
+		if reason := sensorsUnsupportedReason(); reason != "" {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Error: fmt.Errorf("%s: %s", ErrPrefix_SensorsUnsupported, reason)}
+			return
+		}
+		for {
+			if !wshrpc.SendTimeSeriesSample(ctx, rtn, agg, sampleSensorData(request.Sensors)) {
+				return
+			}
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return rtn
+}
@@ -0,0 +1,36 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestStreamSensorDataUnsupported(t *testing.T) {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	impl := &ServerImpl{}
+	respCh := impl.StreamSensorDataCommand(ctx, wshrpc.SensorDataRequest{})
+	resp, ok := <-respCh
+	if !ok {
+		t.Fatalf("expected a sample or an error, the stream closed without either")
+	}
+	reason := sensorsUnsupportedReason()
+	if reason == "" {
+		if resp.Error != nil {
+			t.Errorf("expected a sample where sensors are supported, got %v", resp.Error)
+		}
+		return
+	}
+	if resp.Error == nil || !strings.HasPrefix(resp.Error.Error(), ErrPrefix_SensorsUnsupported) || !strings.Contains(resp.Error.Error(), reason) {
+		t.Errorf("expected a %s error with the reason %q, got %v", ErrPrefix_SensorsUnsupported, reason, resp.Error)
+	}
+	if _, ok := <-respCh; ok {
+		t.Errorf("expected the stream to end after the unsupported error")
+	}
+}
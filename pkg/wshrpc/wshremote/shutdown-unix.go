@@ -0,0 +1,22 @@
+//go:build !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// replaces the process (same pid and stdio), picking up an updated wsh binary.  only returns on error
+func reexecServer() error {
+	exePath, err := getServerExecutable()
+	if err != nil {
+		return err
+	}
+	err = syscall.Exec(exePath, os.Args, os.Environ())
+	return fmt.Errorf("error re-executing %q: %w", exePath, err)
+}
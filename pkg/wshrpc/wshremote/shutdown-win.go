@@ -0,0 +1,29 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// windows has no exec(), so start a new server on the same stdio; the caller exits right after
+func reexecServer() error {
+	exePath, err := getServerExecutable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exePath, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("error starting %q: %w", exePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// gives the final responses (and the shutdown event) time to be written before the process goes away
+const ShutdownFlushDelay = 200 * time.Millisecond
+
+// commands the in-process local connection doesn't serve (shutting it down would exit wavesrv, and
+// scripts would run as children of wavesrv)
+var inProcessDisabledCommands = map[string]bool{
+	wshrpc.Command_Shutdown:        true,
+	wshrpc.Command_RemoteRunScript: true,
+}
+
+func (impl *ServerImpl) ServesCommand(cmd string) bool {
+	return !impl.InProcess || !inProcessDisabledCommands[cmd]
+}
+
+// operators are callers on the wave app side (wavesrv, the frontend, the updater).  wsh sessions
+// running on the remote machine itself (local routes in router mode) are not.  the routers check
+// operator-only commands too (see wshutil.IsOperatorOnlyCommand), this fails closed when the impl
+// wasn't told how it is reached.
+func (impl *ServerImpl) isOperatorSource(rpcSource string) bool {
+	if rpcSource == "" {
+		return false
+	}
+	switch {
+	case impl.Router != nil:
+		return !impl.Router.IsLocalRoute(rpcSource)
+	case impl.InProcess:
+		return wshutil.DefaultRouter.IsOperatorRoute(rpcSource)
+	case impl.DirectUpstream:
+		return true
+	}
+	return false
+}
+
+func (impl *ServerImpl) ShutdownCommand(ctx context.Context, data wshrpc.CommandShutdownData) error {
+	if impl.InProcess {
+		return fmt.Errorf("the local connection runs inside wavesrv and cannot be shut down")
+	}
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if !impl.isOperatorSource(rpcSource) {
+		return fmt.Errorf("shutdown is only allowed from the wave app (not %q)", rpcSource)
+	}
+	client := wshutil.GetWshRpcFromContext(ctx)
+	if client == nil {
+		return fmt.Errorf("no rpc client for shutdown")
+	}
+	if client.IsDraining() {
+		return fmt.Errorf("%s: shutdown already in progress", wshrpc.ErrPrefix_ShuttingDown)
+	}
+	grace := data.GetGrace()
+	connName := client.GetRpcContext().Conn
+	event := wps.WaveEvent{
+		Event:  wps.Event_ServerShutdown,
+		Scopes: []string{connName},
+		Data:   wshrpc.ShutdownEventData{Conn: connName, Restart: data.Restart, GraceMs: int(grace.Milliseconds())},
+	}
+	wshclient.EventPublishCommand(client, event, &wshrpc.RpcOpts{NoResponse: true})
+	// runs after this call returns, Drain waits for in-flight calls (including this one)
+	go impl.runShutdown(client, data.Restart, grace)
+	return nil
+}
+
+func (impl *ServerImpl) runShutdown(client *wshutil.WshRpc, restart bool, grace time.Duration) {
+	defer func() {
+		panichandler.PanicHandler("ShutdownCommand:runShutdown", recover())
+	}()
+	drained := client.Drain(grace)
+	if !drained {
+		log.Printf("shutdown: in-flight calls still running after %v, canceled\n", grace)
+	}
+	time.Sleep(ShutdownFlushDelay)
+	if restart {
+		log.Printf("shutdown: restarting connserver\n")
+		err := reexecServer()
+		if err != nil {
+			log.Printf("shutdown: error restarting connserver (exiting instead): %v\n", err)
+		}
+	}
+	wshutil.DoShutdown("shutdown requested", 0, true)
+}
+
+func getServerExecutable() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("error getting executable path: %w", err)
+	}
+	return exePath, nil
+}
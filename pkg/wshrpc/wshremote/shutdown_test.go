@@ -0,0 +1,56 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func TestIsOperatorSource(t *testing.T) {
+	router := wshutil.NewWshRouter()
+	router.RegisterRoute("proc:1", wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil), false)
+
+	tests := []struct {
+		name     string
+		impl     *ServerImpl
+		source   string
+		expected bool
+	}{
+		{"no router", &ServerImpl{}, "tab:1", false},
+		{"direct upstream", &ServerImpl{DirectUpstream: true}, "tab:1", true},
+		{"direct upstream no source", &ServerImpl{DirectUpstream: true}, "", false},
+		{"router upstream", &ServerImpl{Router: router}, "tab:1", true},
+		{"router local wsh", &ServerImpl{Router: router}, "proc:1", false},
+		{"router no source", &ServerImpl{Router: router}, "", false},
+	}
+	for _, tc := range tests {
+		if got := tc.impl.isOperatorSource(tc.source); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestInProcessShutdown(t *testing.T) {
+	impl := &ServerImpl{InProcess: true}
+	if impl.ServesCommand(wshrpc.Command_Shutdown) {
+		t.Errorf("the in-process local connection should not serve shutdown")
+	}
+	if !impl.ServesCommand(wshrpc.Command_RemoteFileInfo) {
+		t.Errorf("the in-process local connection should serve file commands")
+	}
+	if !(&ServerImpl{}).ServesCommand(wshrpc.Command_Shutdown) {
+		t.Errorf("connservers should serve shutdown")
+	}
+	if err := impl.ShutdownCommand(context.Background(), wshrpc.CommandShutdownData{}); err == nil {
+		t.Errorf("expected shutdown of the in-process local connection to fail")
+	}
+	if commands := wshutil.GetImplCommands(impl); slices.Contains(commands, wshrpc.Command_Shutdown) {
+		t.Errorf("capabilities should not list shutdown for the in-process local connection")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build darwin
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// darwin's f_bsize is the fundamental block size the counts are in (f_iosize is the preferred io size)
+func statfsBlockSize(stat *unix.Statfs_t) uint64 {
+	return uint64(stat.Bsize)
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// the block counts are in f_frsize units (f_bsize is the preferred io size, which can be larger)
+func statfsBlockSize(stat *unix.Statfs_t) uint64 {
+	if stat.Frsize > 0 {
+		return uint64(stat.Frsize)
+	}
+	return uint64(stat.Bsize)
+}
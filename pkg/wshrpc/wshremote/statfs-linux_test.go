@@ -0,0 +1,28 @@
+//go:build linux
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestStatfsBlockSize(t *testing.T) {
+	tests := []struct {
+		stat     unix.Statfs_t
+		expected uint64
+	}{
+		{unix.Statfs_t{Bsize: 4096, Frsize: 4096}, 4096},
+		{unix.Statfs_t{Bsize: 1048576, Frsize: 4096}, 4096}, // a large preferred io size (e.g. nfs, fuse) doesn't scale the counts
+		{unix.Statfs_t{Bsize: 4096}, 4096},
+	}
+	for _, tc := range tests {
+		if got := statfsBlockSize(&tc.stat); got != tc.expected {
+			t.Errorf("bsize:%d frsize:%d: expected %d, got %d", tc.stat.Bsize, tc.stat.Frsize, tc.expected, got)
+		}
+	}
+}
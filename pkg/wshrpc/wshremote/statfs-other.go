@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func statFS(path string) (wshrpc.StatFSRtnData, error) {
+	return wshrpc.StatFSRtnData{}, fmt.Errorf("filesystem info is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/sys/unix"
+)
+
+func statFS(path string) (wshrpc.StatFSRtnData, error) {
+	var stat unix.Statfs_t
+	err := unix.Statfs(path, &stat)
+	if err != nil {
+		return wshrpc.StatFSRtnData{}, err
+	}
+	blockSize := statfsBlockSize(&stat)
+	return wshrpc.StatFSRtnData{
+		TotalBytes:  uint64(stat.Blocks) * blockSize,
+		FreeBytes:   uint64(stat.Bfree) * blockSize,
+		AvailBytes:  uint64(stat.Bavail) * blockSize,
+		TotalInodes: uint64(stat.Files),
+		FreeInodes:  uint64(stat.Ffree),
+	}, nil
+}
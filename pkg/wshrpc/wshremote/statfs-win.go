@@ -0,0 +1,30 @@
+//go:build windows
+
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/sys/windows"
+)
+
+// ntfs has no fixed inode table, so there are no inode counts
+func statFS(path string) (wshrpc.StatFSRtnData, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return wshrpc.StatFSRtnData{}, err
+	}
+	var availBytes, totalBytes, freeBytes uint64
+	err = windows.GetDiskFreeSpaceEx(pathPtr, &availBytes, &totalBytes, &freeBytes)
+	if err != nil {
+		return wshrpc.StatFSRtnData{}, err
+	}
+	return wshrpc.StatFSRtnData{
+		TotalBytes: totalBytes,
+		FreeBytes:  freeBytes,
+		AvailBytes: availBytes,
+		NoInodes:   true,
+	}, nil
+}
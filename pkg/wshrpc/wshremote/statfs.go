@@ -0,0 +1,53 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// returns path, or its nearest parent that exists
+func nearestExistingPath(path string) (string, error) {
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		path = parent
+	}
+}
+
+func (impl *ServerImpl) RemoteStatFSCommand(ctx context.Context, data wshrpc.CommandRemoteStatFSData) (wshrpc.StatFSRtnData, error) {
+	if data.Path == "" {
+		return wshrpc.StatFSRtnData{}, fmt.Errorf("path is required")
+	}
+	cleanedPath, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return wshrpc.StatFSRtnData{}, err
+	}
+	statPath, err := nearestExistingPath(filepath.Clean(cleanedPath))
+	if err != nil {
+		return wshrpc.StatFSRtnData{}, fmt.Errorf("cannot stat %q: %w", cleanedPath, err)
+	}
+	rtn, err := statFS(statPath)
+	if err != nil {
+		return wshrpc.StatFSRtnData{}, fmt.Errorf("cannot read filesystem info for %q: %w", statPath, err)
+	}
+	rtn.Path = statPath
+	rtn.NoInodes = rtn.NoInodes || rtn.TotalInodes == 0
+	return rtn, nil
+}
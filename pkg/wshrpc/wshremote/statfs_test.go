@@ -0,0 +1,39 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRemoteStatFS(t *testing.T) {
+	dir := t.TempDir()
+	impl := &ServerImpl{}
+	ctx := context.Background()
+
+	rtn, err := impl.RemoteStatFSCommand(ctx, wshrpc.CommandRemoteStatFSData{Path: dir})
+	if err != nil {
+		t.Fatalf("error getting filesystem info: %v", err)
+	}
+	if rtn.TotalBytes == 0 || rtn.FreeBytes > rtn.TotalBytes || rtn.AvailBytes > rtn.FreeBytes {
+		t.Errorf("unexpected byte counts %#v", rtn)
+	}
+	if !rtn.NoInodes && (rtn.TotalInodes == 0 || rtn.FreeInodes > rtn.TotalInodes) {
+		t.Errorf("unexpected inode counts %#v", rtn)
+	}
+
+	// a destination that doesn't exist yet reports its parent's filesystem
+	rtn, err = impl.RemoteStatFSCommand(ctx, wshrpc.CommandRemoteStatFSData{Path: filepath.Join(dir, "new", "dir")})
+	if err != nil || rtn.Path != dir || rtn.TotalBytes == 0 {
+		t.Errorf("expected the parent to be stat'd, got %#v, %v", rtn, err)
+	}
+
+	if _, err := impl.RemoteStatFSCommand(ctx, wshrpc.CommandRemoteStatFSData{}); err == nil {
+		t.Errorf("expected an error for an empty path")
+	}
+}
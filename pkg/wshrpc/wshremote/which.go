@@ -0,0 +1,149 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// programs are looked up on the PATH from the connection's env cache (see getEnvCache).
+// lookups are cached for WhichCacheTTL (keyed by the PATH value, so a changed PATH misses).
+// each connection runs its own remote server, so the cache is per connection.
+
+const WhichCacheTTL = 30 * time.Second
+
+type whichCacheKey struct {
+	Path    string
+	Program string
+	All     bool
+}
+
+type whichCacheEntry struct {
+	paths   []string
+	expires time.Time
+}
+
+type whichCache struct {
+	lock    *sync.Mutex
+	entries map[whichCacheKey]*whichCacheEntry
+}
+
+func (impl *ServerImpl) getWhichCache() *whichCache {
+	impl.whichCacheOnce.Do(func() {
+		impl.whichCache = &whichCache{lock: &sync.Mutex{}, entries: make(map[whichCacheKey]*whichCacheEntry)}
+	})
+	return impl.whichCache
+}
+
+func (c *whichCache) get(key whichCacheKey) ([]string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry := c.entries[key]
+	if entry == nil {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return append([]string{}, entry.paths...), true
+}
+
+func (c *whichCache) set(key whichCacheKey, paths []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	for entryKey, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, entryKey)
+		}
+	}
+	c.entries[key] = &whichCacheEntry{paths: append([]string{}, paths...), expires: now.Add(WhichCacheTTL)}
+}
+
+func isExecutableFile(path string) bool {
+	finfo, err := os.Stat(path)
+	if err != nil || !finfo.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return finfo.Mode().Perm()&0111 != 0
+}
+
+// on windows a program can match with any of the PATHEXT extensions
+func programCandidates(dir string, program string) []string {
+	base := filepath.Join(dir, program)
+	if runtime.GOOS != "windows" || filepath.Ext(program) != "" {
+		return []string{base}
+	}
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = ".com;.exe;.bat;.cmd"
+	}
+	var rtn []string
+	for _, ext := range filepath.SplitList(pathExt) {
+		if ext != "" {
+			rtn = append(rtn, base+strings.ToLower(ext))
+		}
+	}
+	return rtn
+}
+
+func findOnPath(pathEnv string, program string, all bool) []string {
+	rtn := []string{}
+	if strings.ContainsRune(program, '/') || strings.ContainsRune(program, filepath.Separator) {
+		if isExecutableFile(program) {
+			if absPath, err := filepath.Abs(program); err == nil {
+				rtn = append(rtn, absPath)
+			}
+		}
+		return rtn
+	}
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			dir = "."
+		}
+		for _, candidate := range programCandidates(dir, program) {
+			if !isExecutableFile(candidate) {
+				continue
+			}
+			absPath, err := filepath.Abs(candidate)
+			if err != nil || seen[absPath] {
+				continue
+			}
+			seen[absPath] = true
+			rtn = append(rtn, absPath)
+			if !all {
+				return rtn
+			}
+		}
+	}
+	return rtn
+}
+
+// returns an empty list (not an error) when the program is not found
+func (impl *ServerImpl) RemoteWhichCommand(ctx context.Context, data wshrpc.CommandRemoteWhichData) ([]string, error) {
+	if data.Program == "" {
+		return []string{}, nil
+	}
+	key := whichCacheKey{Path: impl.getEnvCache(ctx).Env["PATH"], Program: data.Program, All: data.All}
+	cache := impl.getWhichCache()
+	if paths, ok := cache.get(key); ok {
+		return paths, nil
+	}
+	paths := findOnPath(key.Path, data.Program, data.All)
+	cache.set(key, paths)
+	return paths, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func makeWhichTestDir(t *testing.T, files map[string]os.FileMode) string {
+	dir := t.TempDir()
+	for name, mode := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFindOnPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix executable bits")
+	}
+	dir1 := makeWhichTestDir(t, map[string]os.FileMode{"prog": 0755, "noexec": 0644})
+	dir2 := makeWhichTestDir(t, map[string]os.FileMode{"prog": 0755, "noexec": 0755})
+	pathEnv := dir1 + string(os.PathListSeparator) + dir2
+	tests := []struct {
+		program string
+		all     bool
+		want    []string
+	}{
+		{"prog", false, []string{filepath.Join(dir1, "prog")}},
+		{"prog", true, []string{filepath.Join(dir1, "prog"), filepath.Join(dir2, "prog")}},
+		{"noexec", true, []string{filepath.Join(dir2, "noexec")}},
+		{"missing", true, []string{}},
+		{filepath.Join(dir1, "prog"), false, []string{filepath.Join(dir1, "prog")}},
+		{filepath.Join(dir1, "noexec"), false, []string{}},
+	}
+	for _, tc := range tests {
+		got := findOnPath(pathEnv, tc.program, tc.all)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("findOnPath(%q, all:%v): expected %v, got %v", tc.program, tc.all, tc.want, got)
+		}
+	}
+}
+
+func TestRemoteWhichCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix executable bits")
+	}
+	dir1 := makeWhichTestDir(t, map[string]os.FileMode{"which-test-prog": 0755})
+	dir2 := makeWhichTestDir(t, map[string]os.FileMode{"which-test-prog": 0755})
+	impl := &ServerImpl{envCache: &envutil.ConnEnvCache{Env: map[string]string{"PATH": dir1}}}
+	ctx := context.Background()
+	data := wshrpc.CommandRemoteWhichData{Program: "which-test-prog"}
+
+	// the lookup uses the env cache's PATH (not the server's)
+	paths, err := impl.RemoteWhichCommand(ctx, data)
+	if err != nil || !reflect.DeepEqual(paths, []string{filepath.Join(dir1, "which-test-prog")}) {
+		t.Fatalf("expected the program on the env cache PATH, got %v err:%v", paths, err)
+	}
+	// cached, so a removed program is still found
+	os.Remove(filepath.Join(dir1, "which-test-prog"))
+	if paths, _ = impl.RemoteWhichCommand(ctx, data); len(paths) != 1 {
+		t.Errorf("expected the cached lookup, got %v", paths)
+	}
+	// a different PATH is a different cache key
+	impl.envCache = &envutil.ConnEnvCache{Env: map[string]string{"PATH": dir1 + string(os.PathListSeparator) + dir2}}
+	if paths, _ = impl.RemoteWhichCommand(ctx, data); !reflect.DeepEqual(paths, []string{filepath.Join(dir2, "which-test-prog")}) {
+		t.Errorf("expected a new lookup for the changed PATH, got %v", paths)
+	}
+	if paths, err = impl.RemoteWhichCommand(ctx, wshrpc.CommandRemoteWhichData{Program: "which-test-missing"}); err != nil || paths == nil || len(paths) != 0 {
+		t.Errorf("expected an empty (non-nil) list for a missing program, got %#v err:%v", paths, err)
+	}
+}
+
+func TestResolveEnvCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("runs the env command through a unix shell")
+	}
+	t.Setenv("WHICH_TEST_VAR", "a=b")
+	envCache := resolveEnvCache(context.Background())
+	if envCache.Env["WHICH_TEST_VAR"] != "a=b" || envCache.Env["PATH"] == "" {
+		t.Errorf("expected the env from the env command, got %v", envCache.Env)
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// chunked uploads (see wshrpc.RunChunkedWrite).  an upload belongs to the route that started it and is
+// removed (with its temp file) after ChunkUploadIdleTimeout without a chunk, or when its route goes away.
+// a finished upload is kept for ChunkUploadDoneRetention (it no longer counts against the route's limit),
+// so a resent Done chunk (lost ack) gets the same answer.  a resent chunk must match the upload's path,
+// and a resent Done chunk its size and checksum, so a reused upload id can't be mistaken for a finished upload.
+
+const ChunkUploadIdleTimeout = 5 * time.Minute
+const ChunkUploadDoneRetention = time.Minute
+const MaxChunkUploadsPerRoute = 16
+const MaxPendingChunkBytes = 8 * 1024 * 1024 // out-of-order chunks held per upload
+
+type chunkUpload struct {
+	lock         *sync.Mutex // serializes chunks, guards everything below
+	key          string
+	routeId      string
+	reqPath      string // path as requested (checked against every chunk)
+	path         string // resolved target path
+	targetInfo   os.FileInfo
+	tmpFd        *os.File
+	tmpName      string
+	committed    int64
+	hash         hash.Hash
+	pending      map[int64][]byte // offset => data, for chunks past committed
+	pendingBytes int
+	done         bool
+	doneErr      error
+	checksum     string // set when done
+	idleTimer    *time.Timer
+}
+
+type chunkUploadTable struct {
+	lock    *sync.Mutex
+	uploads map[string]*chunkUpload
+}
+
+func (impl *ServerImpl) getChunkUploads() *chunkUploadTable {
+	impl.chunkUploadsOnce.Do(func() {
+		impl.chunkUploads = &chunkUploadTable{lock: &sync.Mutex{}, uploads: make(map[string]*chunkUpload)}
+	})
+	return impl.chunkUploads
+}
+
+func makeChunkUploadKey(routeId string, uploadId string) string {
+	return routeId + "|" + uploadId
+}
+
+// returns the existing upload, or starts a new one (creating its temp file)
+func (t *chunkUploadTable) getOrStart(routeId string, data wshrpc.CommandRemoteWriteChunkData) (*chunkUpload, error) {
+	key := makeChunkUploadKey(routeId, data.UploadId)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if up := t.uploads[key]; up != nil {
+		if up.reqPath != data.Path {
+			return nil, fmt.Errorf("upload %q is for %q, not %q", data.UploadId, up.reqPath, data.Path)
+		}
+		if !up.isDone() {
+			up.idleTimer.Reset(ChunkUploadIdleTimeout)
+		}
+		return up, nil
+	}
+	var numRouteUploads int
+	for _, up := range t.uploads {
+		if up.routeId == routeId && !up.isDone() {
+			numRouteUploads++
+		}
+	}
+	if numRouteUploads >= MaxChunkUploadsPerRoute {
+		return nil, fmt.Errorf("too many uploads in progress (max %d)", MaxChunkUploadsPerRoute)
+	}
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return nil, err
+	}
+	createMode := data.CreateMode
+	if createMode == 0 {
+		createMode = 0644
+	}
+	path, replaced, tmpFd, err := createTempForPath(path, createMode)
+	if err != nil {
+		return nil, fmt.Errorf("cannot write file %q: %w", data.Path, err)
+	}
+	up := &chunkUpload{
+		lock:       &sync.Mutex{},
+		key:        key,
+		routeId:    routeId,
+		reqPath:    data.Path,
+		path:       path,
+		targetInfo: replaced,
+		tmpFd:      tmpFd,
+		tmpName:    tmpFd.Name(),
+		hash:       sha256.New(),
+		pending:    make(map[int64][]byte),
+	}
+	t.uploads[key] = up
+	up.idleTimer = time.AfterFunc(ChunkUploadIdleTimeout, func() {
+		if !up.isDone() {
+			log.Printf("removing idle upload for %q\n", up.path)
+		}
+		t.remove(key)
+	})
+	return up, nil
+}
+
+func (t *chunkUploadTable) remove(key string) {
+	t.lock.Lock()
+	up := t.uploads[key]
+	delete(t.uploads, key)
+	t.lock.Unlock()
+	if up == nil {
+		return
+	}
+	up.idleTimer.Stop()
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	up.cleanup_nolock()
+}
+
+func (t *chunkUploadTable) removeRoute(routeId string) {
+	t.lock.Lock()
+	var keys []string
+	for key, up := range t.uploads {
+		if up.routeId == routeId {
+			keys = append(keys, key)
+		}
+	}
+	t.lock.Unlock()
+	for _, key := range keys {
+		t.remove(key)
+	}
+}
+
+func (up *chunkUpload) isDone() bool {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	return up.done
+}
+
+// closes and removes the temp file (a noop once it has been renamed)
+func (up *chunkUpload) cleanup_nolock() {
+	if up.tmpFd == nil {
+		return
+	}
+	up.tmpFd.Close()
+	os.Remove(up.tmpName)
+	up.tmpFd = nil
+}
+
+func (up *chunkUpload) write_nolock(data []byte) error {
+	if _, err := up.tmpFd.Write(data); err != nil {
+		return fmt.Errorf("cannot write file %q: %w", up.path, err)
+	}
+	up.hash.Write(data)
+	up.committed += int64(len(data))
+	return nil
+}
+
+// writes the part of the chunk past committed, or holds it if there is a gap before it
+func (up *chunkUpload) addChunk_nolock(offset int64, data []byte) error {
+	endOffset := offset + int64(len(data))
+	if endOffset <= up.committed {
+		return nil
+	}
+	if offset > up.committed {
+		if _, found := up.pending[offset]; !found && up.pendingBytes+len(data) <= MaxPendingChunkBytes {
+			up.pending[offset] = data
+			up.pendingBytes += len(data)
+		}
+		return nil
+	}
+	if err := up.write_nolock(data[up.committed-offset:]); err != nil {
+		return err
+	}
+	for {
+		var progress bool
+		for pendingOffset, pendingData := range up.pending {
+			if pendingOffset > up.committed {
+				continue
+			}
+			delete(up.pending, pendingOffset)
+			up.pendingBytes -= len(pendingData)
+			if pendingEnd := pendingOffset + int64(len(pendingData)); pendingEnd > up.committed {
+				if err := up.write_nolock(pendingData[up.committed-pendingOffset:]); err != nil {
+					return err
+				}
+				progress = true
+			}
+		}
+		if !progress {
+			return nil
+		}
+	}
+}
+
+func (up *chunkUpload) finish_nolock(size int64, checksum string) error {
+	if size != up.committed {
+		return fmt.Errorf("upload is incomplete (%d of %d bytes)", up.committed, size)
+	}
+	if actual := hex.EncodeToString(up.hash.Sum(nil)); actual != checksum {
+		return fmt.Errorf("checksum mismatch for %q (expected %s, got %s)", up.path, checksum, actual)
+	}
+	if err := setReplacedFileAttrs(up.tmpFd, up.targetInfo); err != nil {
+		return err
+	}
+	if err := up.tmpFd.Sync(); err != nil {
+		return err
+	}
+	if err := up.tmpFd.Close(); err != nil {
+		return err
+	}
+	up.tmpFd = nil
+	if err := os.Rename(up.tmpName, up.path); err != nil {
+		os.Remove(up.tmpName)
+		return err
+	}
+	return nil
+}
+
+func (impl *ServerImpl) RemoteWriteFileChunkCommand(ctx context.Context, data wshrpc.CommandRemoteWriteChunkData) (wshrpc.RemoteWriteChunkAck, error) {
+	var rtn wshrpc.RemoteWriteChunkAck
+	if data.UploadId == "" {
+		return rtn, fmt.Errorf("uploadid is required")
+	}
+	if data.Offset < 0 {
+		return rtn, fmt.Errorf("offset must be non-negative")
+	}
+	dataBytes, err := wshrpc.DecodeData64("data64", data.Data64)
+	if err != nil {
+		return rtn, err
+	}
+	if data.Done && (len(dataBytes) > 0 || data.Checksum == "") {
+		return rtn, fmt.Errorf("the done chunk must have a checksum and no data")
+	}
+	up, err := impl.getChunkUploads().getOrStart(wshutil.GetRpcSourceFromContext(ctx), data)
+	if err != nil {
+		return rtn, err
+	}
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	if up.done {
+		// a resent chunk after the upload finished
+		if data.Done && (data.Offset != up.committed || data.Checksum != up.checksum) {
+			return rtn, fmt.Errorf("upload %q already finished with a different size or checksum", data.UploadId)
+		}
+		if !data.Done && data.Offset+int64(len(dataBytes)) > up.committed {
+			return rtn, fmt.Errorf("upload %q already finished", data.UploadId)
+		}
+		if up.doneErr != nil {
+			return rtn, up.doneErr
+		}
+		return wshrpc.RemoteWriteChunkAck{Committed: up.committed, Done: true}, nil
+	}
+	if up.tmpFd == nil {
+		return rtn, fmt.Errorf("upload for %q was canceled", up.path)
+	}
+	if !data.Done {
+		err = up.addChunk_nolock(data.Offset, dataBytes)
+		if err != nil {
+			return rtn, err
+		}
+		return wshrpc.RemoteWriteChunkAck{Committed: up.committed}, nil
+	}
+	err = up.finish_nolock(data.Offset, data.Checksum)
+	if err != nil && up.committed != data.Offset {
+		// not a final answer, the client can still send the missing chunks
+		return rtn, err
+	}
+	up.done = true
+	up.checksum = data.Checksum
+	up.pending = nil
+	up.cleanup_nolock()
+	up.idleTimer.Reset(ChunkUploadDoneRetention)
+	if err != nil {
+		up.doneErr = fmt.Errorf("cannot write file %q: %w", up.path, err)
+		return rtn, up.doneErr
+	}
+	impl.invalidateFileInfo(up.path)
+	return wshrpc.RemoteWriteChunkAck{Committed: up.committed, Done: true}, nil
+}
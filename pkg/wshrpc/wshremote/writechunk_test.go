@@ -0,0 +1,122 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestChunkedWriteDroppedAcks(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	os.WriteFile(path, []byte("old contents"), 0600)
+	data := make([]byte, 100*1024+123)
+	rand.New(rand.NewSource(1)).Read(data)
+	impl := &ServerImpl{}
+
+	// every 3rd chunk is lost before it arrives, every 4th ack is lost after the chunk was written
+	var numCalls, numDropped atomic.Int64
+	sendFn := func(chunk wshrpc.CommandRemoteWriteChunkData) (wshrpc.RemoteWriteChunkAck, error) {
+		callNum := numCalls.Add(1)
+		if callNum%3 == 0 {
+			numDropped.Add(1)
+			return wshrpc.RemoteWriteChunkAck{}, errors.New("EC-TIME: timeout waiting for response")
+		}
+		ack, err := impl.RemoteWriteFileChunkCommand(ctx, chunk)
+		if err == nil && callNum%4 == 0 {
+			numDropped.Add(1)
+			return wshrpc.RemoteWriteChunkAck{}, errors.New("EC-TIME: timeout waiting for response")
+		}
+		return ack, err
+	}
+	opts := wshrpc.ChunkedWriteOpts{UploadId: "upload1", Path: path, ChunkSize: 4096, Window: 4}
+	if err := wshrpc.RunChunkedWrite(opts, data, sendFn); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if numDropped.Load() == 0 {
+		t.Fatalf("expected some chunks or acks to be dropped")
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading uploaded file: %v", err)
+	}
+	if sha256.Sum256(written) != sha256.Sum256(data) {
+		t.Errorf("uploaded file does not match (got %d bytes, want %d)", len(written), len(data))
+	}
+	if finfo, _ := os.Stat(path); finfo.Mode().Perm() != 0600 {
+		t.Errorf("existing file should keep its permissions, got %v", finfo.Mode().Perm())
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*wsh-tmp-*")); len(matches) != 0 {
+		t.Errorf("temp files left behind: %v", matches)
+	}
+
+	// a resent done chunk (lost ack) gets the same answer
+	checksum := sha256.Sum256(data)
+	doneChunk := wshrpc.CommandRemoteWriteChunkData{UploadId: "upload1", Path: path, Offset: int64(len(data)), Done: true, Checksum: hex.EncodeToString(checksum[:])}
+	ack, err := impl.RemoteWriteFileChunkCommand(ctx, doneChunk)
+	if err != nil || !ack.Done || ack.Committed != int64(len(data)) {
+		t.Errorf("resent done chunk: got %+v, %v", ack, err)
+	}
+	// but not if it describes a different upload (a reused upload id)
+	otherChunk := doneChunk
+	otherChunk.Checksum = strings.Repeat("1", 64)
+	if _, err := impl.RemoteWriteFileChunkCommand(ctx, otherChunk); err == nil {
+		t.Errorf("expected a done chunk with a different checksum to be rejected")
+	}
+	otherChunk = doneChunk
+	otherChunk.Path = path + ".other"
+	if _, err := impl.RemoteWriteFileChunkCommand(ctx, otherChunk); err == nil {
+		t.Errorf("expected a chunk for a different path to be rejected")
+	}
+	if _, err := os.Stat(otherChunk.Path); !os.IsNotExist(err) {
+		t.Errorf("a chunk for a different path should not create a file")
+	}
+	otherChunk = wshrpc.CommandRemoteWriteChunkData{UploadId: "upload1", Path: path, Offset: int64(len(data)), Data64: "YWJj"}
+	if _, err := impl.RemoteWriteFileChunkCommand(ctx, otherChunk); err == nil {
+		t.Errorf("expected new data for a finished upload to be rejected")
+	}
+
+	// a bad checksum leaves the target alone
+	badPath := filepath.Join(t.TempDir(), "bad.bin")
+	err = wshrpc.RunChunkedWrite(wshrpc.ChunkedWriteOpts{UploadId: "upload2", Path: badPath}, data, func(chunk wshrpc.CommandRemoteWriteChunkData) (wshrpc.RemoteWriteChunkAck, error) {
+		if chunk.Done {
+			chunk.Checksum = strings.Repeat("0", 64)
+		}
+		return impl.RemoteWriteFileChunkCommand(ctx, chunk)
+	})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+	if _, err := os.Stat(badPath); !os.IsNotExist(err) {
+		t.Errorf("file should not exist after a failed upload")
+	}
+}
+
+func TestChunkedWriteFinishedUploadLimit(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	impl := &ServerImpl{}
+	// finished uploads are kept (for resent acks) but don't count against the route's limit
+	for idx := 0; idx < MaxChunkUploadsPerRoute+2; idx++ {
+		opts := wshrpc.ChunkedWriteOpts{UploadId: fmt.Sprintf("upload%d", idx), Path: filepath.Join(dir, fmt.Sprintf("file%d.txt", idx))}
+		err := wshrpc.RunChunkedWrite(opts, []byte("hello"), func(chunk wshrpc.CommandRemoteWriteChunkData) (wshrpc.RemoteWriteChunkAck, error) {
+			return impl.RemoteWriteFileChunkCommand(ctx, chunk)
+		})
+		if err != nil {
+			t.Fatalf("upload %d failed: %v", idx, err)
+		}
+	}
+}
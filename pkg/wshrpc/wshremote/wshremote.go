@@ -5,28 +5,76 @@ package wshremote
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/util/ratelimit"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
 )
 
 const MaxFileSize = 50 * 1024 * 1024 // 10M
 const MaxDirSize = 1024
 const FileChunkSize = 16 * 1024
 const DirChunkSize = 128
+const AtomicWriteChunkSize = 256 * 1024
 
 type ServerImpl struct {
-	LogWriter io.Writer
+	LogWriter        io.Writer
+	FileInfoCacheTTL time.Duration      // 0 uses DefaultFileInfoCacheTTL, negative disables the cache
+	Router           *wshutil.WshRouter // set in router mode, used to tell local wsh routes from upstream (wave app) callers
+	DirectUpstream   bool               // set in normal mode (no router), every caller came through wavesrv
+	InProcess        bool               // set for the "local" connection that runs inside wavesrv
+
+	fileInfoCacheOnce  sync.Once
+	fileInfoCache      *fileInfoCache
+	whichCacheOnce     sync.Once
+	whichCache         *whichCache
+	envCacheLock       sync.Mutex
+	envCache           *envutil.ConnEnvCache // see getEnvCache
+	fileHandlesOnce    sync.Once
+	fileHandles        *fileHandleTable
+	mountInfoCacheOnce sync.Once
+	mountInfoCache     *mountInfoCache
+	chunkUploadsOnce   sync.Once
+	chunkUploads       *chunkUploadTable
+	readLimiterOnce    sync.Once
+	readLimiter        *ratelimit.TokenBucket // shared by all file streams, set by wavesrv (see RemoteSetReadLimitCommand)
+}
+
+func (impl *ServerImpl) getReadLimiter() *ratelimit.TokenBucket {
+	impl.readLimiterOnce.Do(func() {
+		impl.readLimiter = ratelimit.MakeTokenBucket(0)
+	})
+	return impl.readLimiter
+}
+
+// the cap belongs to the connection (conn:bandwidthlimit in wavesrv), so only the wave app can set it
+func (impl *ServerImpl) RemoteSetReadLimitCommand(ctx context.Context, limit int64) error {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if !impl.isOperatorSource(rpcSource) {
+		return fmt.Errorf("setting the read limit is only allowed from the wave app (not %q)", rpcSource)
+	}
+	impl.getReadLimiter().SetRate(max(limit, 0))
+	return nil
 }
 
 func (*ServerImpl) WshServerImpl() {}
@@ -180,16 +228,32 @@ func (impl *ServerImpl) remoteStreamFileInternal(ctx context.Context, data wshrp
 	}
 	if finfo.IsDir {
 		return impl.remoteStreamFileDir(ctx, path, byteRange, dataCallback)
-	} else {
-		return impl.remoteStreamFileRegular(ctx, path, byteRange, dataCallback)
 	}
+	format, err := getStreamDecompressFormat(path, data.Decompress)
+	if err != nil {
+		return err
+	}
+	if format != "" {
+		return impl.remoteStreamFileDecompress(ctx, path, format, byteRange, dataCallback)
+	}
+	return impl.remoteStreamFileRegular(ctx, path, byteRange, dataCallback)
 }
 
 func (impl *ServerImpl) RemoteStreamFileCommand(ctx context.Context, data wshrpc.CommandRemoteStreamFileData) chan wshrpc.RespOrErrorUnion[wshrpc.CommandRemoteStreamFileRtnData] {
 	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.CommandRemoteStreamFileRtnData], 16)
+	if path, err := wavebase.ExpandHomeDir(data.Path); err == nil {
+		format, _ := getStreamDecompressFormat(path, data.Decompress)
+		// the decompressed size isn't known up front
+		if finfo, err := os.Stat(path); err == nil && finfo.Mode().IsRegular() && format == "" {
+			wshutil.SetStreamStartParam(ctx, wshrpc.StreamStartParam_Size, finfo.Size())
+		}
+	}
+	readLimiter := impl.getReadLimiter()
 	go func() {
 		defer close(ch)
 		err := impl.remoteStreamFileInternal(ctx, data, func(fileInfo []*wshrpc.FileInfo, data []byte) {
+			// slows the stream down, a cancelled ctx ends the stream on its own
+			readLimiter.Wait(ctx, len(data))
 			resp := wshrpc.CommandRemoteStreamFileRtnData{}
 			resp.FileInfo = fileInfo
 			if len(data) > 0 {
@@ -205,18 +269,206 @@ func (impl *ServerImpl) RemoteStreamFileCommand(ctx context.Context, data wshrpc
 	return ch
 }
 
+// returns nil when there is nothing to filter
+func makeDirEntryFilter(dirPath string, data wshrpc.CommandRemoteListDirData) func(entry os.DirEntry) bool {
+	if len(data.Exclude) == 0 && !data.RespectGitignore {
+		return nil
+	}
+	var matcher *gitignoreMatcher
+	if data.RespectGitignore {
+		absDir, err := filepath.Abs(dirPath)
+		if err == nil {
+			dirPath = absDir
+			matcher = makeGitignoreMatcher(dirPath)
+		}
+	}
+	return func(entry os.DirEntry) bool {
+		if matchesAnyGlob(data.Exclude, entry.Name()) {
+			return false
+		}
+		if matcher != nil && matcher.IsIgnored(filepath.Join(dirPath, entry.Name()), entry.IsDir()) {
+			return false
+		}
+		return true
+	}
+}
+
+// filtering (Exclude, RespectGitignore) happens before paging, so cursors and Total refer to the filtered list
+func filterDirEntries(dirPath string, entries []os.DirEntry, data wshrpc.CommandRemoteListDirData) []os.DirEntry {
+	filterFn := makeDirEntryFilter(dirPath, data)
+	if filterFn == nil {
+		return entries
+	}
+	rtn := make([]os.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filterFn(entry) {
+			rtn = append(rtn, entry)
+		}
+	}
+	return rtn
+}
+
+// entries are sorted by name, only the requested page is stat'd (nothing is stat'd for Shallow listings)
+func (impl *ServerImpl) RemoteListDirCommand(ctx context.Context, data wshrpc.CommandRemoteListDirData) (wshrpc.FileInfoPage, error) {
+	var rtn wshrpc.FileInfoPage
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return rtn, err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return rtn, fmt.Errorf("cannot open dir %q: %w", path, err)
+	}
+	entries = filterDirEntries(filepath.Clean(path), entries, data)
+	start, end, nextCursor, err := wshrpc.PageBounds(wshrpc.PageOpts{Cursor: data.Cursor, Limit: data.Limit}, len(entries))
+	if err != nil {
+		return rtn, err
+	}
+	rtn.Items = make([]*wshrpc.FileInfo, 0, end-start)
+	for _, entry := range entries[start:end] {
+		if ctx.Err() != nil {
+			return rtn, ctx.Err()
+		}
+		// removed since ReadDir comes back as NotFound, keeps the page size stable
+		rtn.Items = append(rtn.Items, listEntryToFileInfo(path, entry, data.Shallow))
+	}
+	rtn.NextCursor = nextCursor
+	rtn.Total = len(entries)
+	return rtn, nil
+}
+
+// entries that fail to stat are included with NotFound set (same as the paged listing)
+func listEntryToFileInfo(dirPath string, entry os.DirEntry, shallow bool) *wshrpc.FileInfo {
+	fullPath := filepath.Join(dirPath, entry.Name())
+	if shallow {
+		return dirEntryToShallowFileInfo(fullPath, entry)
+	}
+	finfo, err := entry.Info()
+	if err != nil {
+		return &wshrpc.FileInfo{Path: wavebase.ReplaceHomeDir(fullPath), Name: entry.Name(), NotFound: true}
+	}
+	return statToFileInfo(fullPath, finfo, false)
+}
+
+// streaming counterpart to RemoteListDirCommand.  the directory is read DirChunkSize entries at a time
+// and each batch is sent as it's read, so memory stays bounded.  entries come in directory order (not
+// sorted), Cursor is ignored and Limit caps the total number of entries sent.
+func (impl *ServerImpl) RemoteStreamListDirCommand(ctx context.Context, data wshrpc.CommandRemoteListDirData) chan wshrpc.RespOrErrorUnion[wshrpc.ListDirChunk] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.ListDirChunk], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RemoteStreamListDirCommand", recover())
+		}()
+		defer close(ch)
+		err := streamListDir(ctx, data, func(items []*wshrpc.FileInfo) bool {
+			select {
+			case ch <- wshrpc.RespOrErrorUnion[wshrpc.ListDirChunk]{Response: wshrpc.ListDirChunk{Items: items}}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err != nil {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.ListDirChunk]{Error: err}
+		}
+	}()
+	return ch
+}
+
+// sendFn returns false to stop early (the context was canceled)
+func streamListDir(ctx context.Context, data wshrpc.CommandRemoteListDirData, sendFn func(items []*wshrpc.FileInfo) bool) error {
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return err
+	}
+	path = filepath.Clean(path)
+	dirFd, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open dir %q: %w", path, err)
+	}
+	defer dirFd.Close()
+	filterFn := makeDirEntryFilter(path, data)
+	numSent := 0
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		entries, readErr := dirFd.ReadDir(DirChunkSize)
+		items := make([]*wshrpc.FileInfo, 0, len(entries))
+		for _, entry := range entries {
+			if data.Limit > 0 && numSent+len(items) >= data.Limit {
+				break
+			}
+			if filterFn != nil && !filterFn(entry) {
+				continue
+			}
+			items = append(items, listEntryToFileInfo(path, entry, data.Shallow))
+		}
+		if len(items) > 0 {
+			if !sendFn(items) {
+				return nil
+			}
+			numSent += len(items)
+		}
+		if data.Limit > 0 && numSent >= data.Limit {
+			return nil
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("error reading dir %q: %w", path, readErr)
+		}
+	}
+}
+
+// on windows go maps reparse points, NUL and named pipes onto the same mode bits
+func fileEntryType(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return wshrpc.FileEntryType_Symlink
+	case mode.IsDir():
+		return wshrpc.FileEntryType_Dir
+	case mode.IsRegular():
+		return wshrpc.FileEntryType_File
+	case mode&fs.ModeDevice != 0:
+		return wshrpc.FileEntryType_Device
+	case mode&fs.ModeNamedPipe != 0:
+		return wshrpc.FileEntryType_Pipe
+	case mode&fs.ModeSocket != 0:
+		return wshrpc.FileEntryType_Socket
+	default:
+		return wshrpc.FileEntryType_Other
+	}
+}
+
+// only uses the type bits from the directory read, size and modtime are left zero (Statted is false)
+func dirEntryToShallowFileInfo(fullPath string, entry fs.DirEntry) *wshrpc.FileInfo {
+	return &wshrpc.FileInfo{
+		Path:      wavebase.ReplaceHomeDir(fullPath),
+		Dir:       computeDirPart(fullPath, entry.IsDir()),
+		Name:      entry.Name(),
+		Mode:      entry.Type(),
+		IsDir:     entry.IsDir(),
+		EntryType: fileEntryType(entry.Type()),
+	}
+}
+
 func statToFileInfo(fullPath string, finfo fs.FileInfo, extended bool) *wshrpc.FileInfo {
 	mimeType := utilfn.DetectMimeType(fullPath, finfo, extended)
 	rtn := &wshrpc.FileInfo{
-		Path:     wavebase.ReplaceHomeDir(fullPath),
-		Dir:      computeDirPart(fullPath, finfo.IsDir()),
-		Name:     finfo.Name(),
-		Size:     finfo.Size(),
-		Mode:     finfo.Mode(),
-		ModeStr:  finfo.Mode().String(),
-		ModTime:  finfo.ModTime().UnixMilli(),
-		IsDir:    finfo.IsDir(),
-		MimeType: mimeType,
+		Path:      wavebase.ReplaceHomeDir(fullPath),
+		Dir:       computeDirPart(fullPath, finfo.IsDir()),
+		Name:      finfo.Name(),
+		Size:      finfo.Size(),
+		Mode:      finfo.Mode(),
+		ModeStr:   finfo.Mode().String(),
+		ModTime:   finfo.ModTime().UnixMilli(),
+		IsDir:     finfo.IsDir(),
+		MimeType:  mimeType,
+		EntryType: fileEntryType(finfo.Mode()),
+		Statted:   true,
+		AllocSize: sparseAllocSize(finfo),
 	}
 	if finfo.IsDir() {
 		rtn.Size = -1
@@ -285,6 +537,19 @@ func (*ServerImpl) fileInfoInternal(path string, extended bool) (*wshrpc.FileInf
 	return rtn, nil
 }
 
+// the same fileinfo a (non-shallow) directory listing returns for path
+func listEntryFileInfo(path string) (*wshrpc.FileInfo, error) {
+	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(path))
+	finfo, err := os.Lstat(cleanedPath)
+	if os.IsNotExist(err) {
+		return &wshrpc.FileInfo{Path: wavebase.ReplaceHomeDir(cleanedPath), Name: filepath.Base(cleanedPath), NotFound: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat file %q: %w", path, err)
+	}
+	return statToFileInfo(cleanedPath, finfo, false), nil
+}
+
 func resolvePaths(paths []string) string {
 	if len(paths) == 0 {
 		return wavebase.ExpandHomeDirSafe("~")
@@ -307,7 +572,28 @@ func (impl *ServerImpl) RemoteFileJoinCommand(ctx context.Context, paths []strin
 }
 
 func (impl *ServerImpl) RemoteFileInfoCommand(ctx context.Context, path string) (*wshrpc.FileInfo, error) {
-	return impl.fileInfoInternal(path, true)
+	return impl.cachedFileInfo(path, isNoCacheRequest(ctx))
+}
+
+func (impl *ServerImpl) RemoteFileStatCommand(ctx context.Context, data wshrpc.CommandRemoteFileStatData) ([]*wshrpc.FileInfo, error) {
+	rtn := make([]*wshrpc.FileInfo, 0, len(data.Paths))
+	for _, path := range data.Paths {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var finfo *wshrpc.FileInfo
+		var err error
+		if data.ListEntries {
+			finfo, err = listEntryFileInfo(path)
+		} else {
+			finfo, err = impl.cachedFileInfo(path, data.NoCache || isNoCacheRequest(ctx))
+		}
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, finfo)
+	}
+	return rtn, nil
 }
 
 func (impl *ServerImpl) RemoteFileTouchCommand(ctx context.Context, path string) error {
@@ -321,39 +607,236 @@ func (impl *ServerImpl) RemoteFileTouchCommand(ctx context.Context, path string)
 	if err := os.WriteFile(cleanedPath, []byte{}, 0644); err != nil {
 		return fmt.Errorf("cannot create file %q: %w", cleanedPath, err)
 	}
+	impl.invalidateFileInfo(cleanedPath)
 	return nil
 }
 
-func (impl *ServerImpl) RemoteFileRenameCommand(ctx context.Context, pathTuple [2]string) error {
-	path := pathTuple[0]
-	newPath := pathTuple[1]
-	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(path))
-	cleanedNewPath := filepath.Clean(wavebase.ExpandHomeDirSafe(newPath))
-	if _, err := os.Stat(cleanedNewPath); err == nil {
-		return fmt.Errorf("destination file path %q already exists", path)
+// swapped out in tests
+var renameFile = os.Rename
+
+// across filesystems only regular files can be moved (copied, then the source is removed)
+func (impl *ServerImpl) moveAcrossFilesystems(ctx context.Context, srcInfo fs.FileInfo, srcPath string, destPath string, overwrite bool) error {
+	if !srcInfo.Mode().IsRegular() {
+		return fmt.Errorf("cannot move %q to %q: only regular files can be moved across filesystems", srcPath, destPath)
 	}
-	if err := os.Rename(cleanedPath, cleanedNewPath); err != nil {
-		return fmt.Errorf("cannot rename file %q to %q: %w", cleanedPath, cleanedNewPath, err)
+	_, err := impl.RemoteFileCopyCommand(ctx, wshrpc.CommandRemoteFileCopyData{SrcPath: srcPath, DestPath: destPath, Overwrite: overwrite})
+	if err != nil {
+		return fmt.Errorf("cannot move %q to %q across filesystems, the source is unchanged: %w", srcPath, destPath, err)
+	}
+	os.Chtimes(destPath, time.Time{}, srcInfo.ModTime())
+	if err := os.Remove(srcPath); err != nil {
+		return fmt.Errorf("copied %q to %q across filesystems but could not remove the source (both files now exist): %w", srcPath, destPath, err)
 	}
 	return nil
 }
 
-func (impl *ServerImpl) RemoteMkdirCommand(ctx context.Context, path string) error {
+// moves a file or directory.  on the same filesystem this is an atomic rename, otherwise regular files
+// fall back to copy-then-delete.  a missing source or a permission problem on the source returns a
+// not_found or permission_denied error (see wshrpc.IsNotFoundError).
+func (impl *ServerImpl) RemoteFileRenameCommand(ctx context.Context, data wshrpc.CommandRemoteFileRenameData) (wshrpc.FileOpPreview, error) {
+	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(data.SrcPath))
+	cleanedNewPath := filepath.Clean(wavebase.ExpandHomeDirSafe(data.DestPath))
+	srcInfo, err := os.Lstat(cleanedPath)
+	if err != nil {
+		return wshrpc.FileOpPreview{}, wshrpc.PrefixFileError(fmt.Errorf("cannot move %q: %w", cleanedPath, err))
+	}
+	if destInfo, err := os.Lstat(cleanedNewPath); err == nil {
+		sameFile := os.SameFile(srcInfo, destInfo)
+		switch {
+		case sameFile && cleanedPath != cleanedNewPath && strings.EqualFold(cleanedPath, cleanedNewPath):
+			// changing the case of a name on a case-insensitive filesystem
+		case sameFile:
+			return wshrpc.FileOpPreview{}, fmt.Errorf("cannot move %q onto itself", cleanedPath)
+		case !data.Overwrite:
+			return wshrpc.FileOpPreview{}, fmt.Errorf("destination file path %q already exists: %w (set overwrite to replace it)", data.DestPath, fs.ErrExist)
+		case destInfo.IsDir():
+			return wshrpc.FileOpPreview{}, fmt.Errorf("cannot move to %q: is a directory", cleanedNewPath)
+		}
+	}
+	if data.DryRun {
+		change := wshrpc.FileOpChange{Path: cleanedPath, Action: wshrpc.FileOpAction_Move, DestPath: cleanedNewPath, IsDir: srcInfo.IsDir()}
+		return wshrpc.FileOpPreview{Changes: []wshrpc.FileOpChange{change}}, nil
+	}
+	defer impl.invalidateFileInfo(cleanedPath, cleanedNewPath)
+	err = renameFile(cleanedPath, cleanedNewPath)
+	if errors.Is(err, errCrossDevice) {
+		return wshrpc.FileOpPreview{}, impl.moveAcrossFilesystems(ctx, srcInfo, cleanedPath, cleanedNewPath, data.Overwrite)
+	}
+	if err != nil {
+		return wshrpc.FileOpPreview{}, wshrpc.PrefixFileError(fmt.Errorf("cannot rename file %q to %q: %w", cleanedPath, cleanedNewPath, err))
+	}
+	return wshrpc.FileOpPreview{}, nil
+}
+
+// checks that dest can be written, returns true if it exists (and will be replaced)
+func checkCopyDest(srcInfo fs.FileInfo, destPath string, overwrite bool) (bool, error) {
+	destInfo, err := os.Lstat(destPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot stat destination %q: %w", destPath, err)
+	}
+	if destInfo.IsDir() {
+		return false, fmt.Errorf("cannot copy to %q: is a directory", destPath)
+	}
+	if !overwrite {
+		return false, fmt.Errorf("cannot copy to %q: %w (set overwrite to replace it)", destPath, fs.ErrExist)
+	}
+	if os.SameFile(srcInfo, destInfo) {
+		return false, fmt.Errorf("cannot copy %q onto itself", destPath)
+	}
+	return true, nil
+}
+
+// copies a regular file, preserving its mode.  overwrites go through a temp file in the destination
+// directory (renamed over dest), so a failed copy never leaves a truncated destination
+func (impl *ServerImpl) RemoteFileCopyCommand(ctx context.Context, data wshrpc.CommandRemoteFileCopyData) (wshrpc.FileOpPreview, error) {
+	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(data.SrcPath))
+	cleanedNewPath := filepath.Clean(wavebase.ExpandHomeDirSafe(data.DestPath))
+	srcFd, err := os.Open(cleanedPath)
+	if err != nil {
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot open file %q: %w", cleanedPath, err)
+	}
+	defer srcFd.Close()
+	finfo, err := srcFd.Stat()
+	if err != nil {
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot stat file %q: %w", cleanedPath, err)
+	}
+	if finfo.IsDir() {
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot copy %q: is a directory", cleanedPath)
+	}
+	if !finfo.Mode().IsRegular() {
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot copy %q, not a regular file", cleanedPath)
+	}
+	destExists, err := checkCopyDest(finfo, cleanedNewPath, data.Overwrite)
+	if err != nil {
+		return wshrpc.FileOpPreview{}, err
+	}
+	if data.DryRun {
+		change := wshrpc.FileOpChange{Path: cleanedPath, Action: wshrpc.FileOpAction_Copy, DestPath: cleanedNewPath}
+		return wshrpc.FileOpPreview{Changes: []wshrpc.FileOpChange{change}}, nil
+	}
+	var destFd *os.File
+	if destExists {
+		destFd, err = os.CreateTemp(filepath.Dir(cleanedNewPath), "."+filepath.Base(cleanedNewPath)+".wavecopy-*")
+	} else {
+		destFd, err = os.OpenFile(cleanedNewPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, finfo.Mode().Perm())
+	}
+	if err != nil {
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot create file %q: %w", cleanedNewPath, err)
+	}
+	writePath := destFd.Name()
+	defer destFd.Close()
+	impl.invalidateFileInfo(cleanedNewPath)
+	var srcReader io.Reader = srcFd
+	if impl.isNetworkPath(cleanedPath) || impl.isNetworkPath(cleanedNewPath) {
+		// copies to/from network mounts can take a long time, stop if the caller gives up
+		srcReader = ctxReader{ctx: ctx, r: srcFd}
+	}
+	_, err = io.Copy(destFd, srcReader)
+	if err == nil {
+		// the create mode is masked by the umask
+		err = destFd.Chmod(finfo.Mode().Perm())
+	}
+	if err == nil {
+		err = destFd.Close()
+	}
+	if err == nil && destExists {
+		err = os.Rename(writePath, cleanedNewPath)
+	}
+	if err != nil {
+		// writePath is always ours (a temp file, or a new destination created with O_EXCL)
+		destFd.Close()
+		os.Remove(writePath)
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot copy file %q to %q: %w", cleanedPath, cleanedNewPath, err)
+	}
+	return wshrpc.FileOpPreview{}, nil
+}
+
+// returns the hex encoded sha256 of the file contents
+func (impl *ServerImpl) RemoteFileChecksumCommand(ctx context.Context, path string) (string, error) {
 	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(path))
+	fd, err := os.Open(cleanedPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file %q: %w", cleanedPath, err)
+	}
+	defer fd.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, fd); err != nil {
+		return "", fmt.Errorf("cannot read file %q: %w", cleanedPath, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// like MkdirAll, but creates the missing components one at a time so an error names the one that
+// failed.  only the last component gets mode, parents are created 0755 (like mkdir -p -m).
+func mkdirRecursive(path string, mode os.FileMode) error {
+	var missing []string // deepest first
+	for curPath := path; ; {
+		finfo, err := os.Stat(curPath)
+		if err == nil {
+			if !finfo.IsDir() {
+				return fmt.Errorf("%q is not a directory", curPath)
+			}
+			break
+		}
+		// ENOTDIR: a parent is a file, keep going up to report it
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+			return fmt.Errorf("cannot stat %q: %w", curPath, err)
+		}
+		missing = append(missing, curPath)
+		parentPath := filepath.Dir(curPath)
+		if parentPath == curPath {
+			break
+		}
+		curPath = parentPath
+	}
+	for idx := len(missing) - 1; idx >= 0; idx-- {
+		dirMode := os.FileMode(0755)
+		if idx == 0 {
+			dirMode = mode
+		}
+		if err := os.Mkdir(missing[idx], dirMode); err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("error creating %q (%d of %d missing directories created): %w", missing[idx], len(missing)-1-idx, len(missing), err)
+		}
+	}
+	return nil
+}
+
+func (impl *ServerImpl) RemoteMkdirCommand(ctx context.Context, data wshrpc.CommandRemoteMkdirData) error {
+	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(data.Path))
 	if stat, err := os.Stat(cleanedPath); err == nil {
-		if stat.IsDir() {
-			return fmt.Errorf("directory %q already exists", path)
-		} else {
-			return fmt.Errorf("cannot create directory %q, file exists at path", path)
+		if !stat.IsDir() {
+			return fmt.Errorf("cannot create directory %q, file exists at path", data.Path)
+		}
+		if data.Recursive && !data.MustCreate {
+			return nil
 		}
+		return fmt.Errorf("directory %q already exists", data.Path)
+	}
+	createMode := data.CreateMode
+	if createMode == 0 {
+		createMode = 0755
 	}
-	if err := os.MkdirAll(cleanedPath, 0755); err != nil {
-		return fmt.Errorf("cannot create directory %q: %w", cleanedPath, err)
+	var err error
+	if data.Recursive {
+		err = mkdirRecursive(cleanedPath, createMode)
+	} else {
+		err = os.Mkdir(cleanedPath, createMode)
+	}
+	if err != nil {
+		return wshrpc.PrefixFileError(fmt.Errorf("cannot create directory %q: %w", cleanedPath, err))
 	}
+	impl.invalidateFileInfo(cleanedPath)
 	return nil
 }
 
-func (*ServerImpl) RemoteWriteFileCommand(ctx context.Context, data wshrpc.CommandRemoteWriteFileData) error {
+func (impl *ServerImpl) RemoteWriteFileCommand(ctx context.Context, data wshrpc.CommandRemoteWriteFileData) error {
+	dataBytes, err := wshrpc.DecodeData64("data64", data.Data64)
+	if err != nil {
+		return err
+	}
 	path, err := wavebase.ExpandHomeDir(data.Path)
 	if err != nil {
 		return err
@@ -362,28 +845,125 @@ func (*ServerImpl) RemoteWriteFileCommand(ctx context.Context, data wshrpc.Comma
 	if createMode == 0 {
 		createMode = 0644
 	}
-	dataSize := base64.StdEncoding.DecodedLen(len(data.Data64))
-	dataBytes := make([]byte, dataSize)
-	n, err := base64.StdEncoding.Decode(dataBytes, []byte(data.Data64))
-	if err != nil {
-		return fmt.Errorf("cannot decode base64 data: %w", err)
+	if data.NoAtomic {
+		err = os.WriteFile(path, dataBytes, createMode)
+	} else {
+		err = writeFileAtomic(ctx, path, dataBytes, createMode)
 	}
-	err = os.WriteFile(path, dataBytes[:n], createMode)
 	if err != nil {
 		return fmt.Errorf("cannot write file %q: %w", path, err)
 	}
+	impl.invalidateFileInfo(path)
+	return nil
+}
+
+// creates the temp file for an atomic write of path, returns the resolved path (symlinks are followed) and
+// the file it replaces (nil for a new file).  like os.WriteFile, a new file gets createMode masked by the
+// umask.  an existing file's permissions and owner are copied over by setReplacedFileAttrs.
+func createTempForPath(path string, createMode os.FileMode) (string, os.FileInfo, *os.File, error) {
+	if resolvedPath, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolvedPath
+	}
+	var replaced os.FileInfo
+	if finfo, err := os.Stat(path); err == nil {
+		if !finfo.Mode().IsRegular() {
+			return "", nil, nil, fmt.Errorf("not a regular file")
+		}
+		replaced = finfo
+		createMode = 0600
+	}
+	tmpPrefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".wsh-tmp-")
+	for i := 0; i < 100; i++ {
+		tmpFd, err := os.OpenFile(tmpPrefix+strconv.FormatUint(uint64(rand.Uint32()), 36), os.O_RDWR|os.O_CREATE|os.O_EXCL, createMode)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return path, replaced, tmpFd, nil
+	}
+	return "", nil, nil, fmt.Errorf("cannot create a temp file for %q", path)
+}
+
+// gives the temp file the permissions and owner (where allowed, see copyFileOwner) of the file it replaces
+func setReplacedFileAttrs(tmpFd *os.File, replaced os.FileInfo) error {
+	if replaced == nil {
+		return nil
+	}
+	copyFileOwner(tmpFd, replaced)
+	return tmpFd.Chmod(replaced.Mode().Perm())
+}
+
+// writes a temp file next to path (checking ctx between chunks), syncs it, then renames it over path.
+// a symlink is followed (its target is replaced, not the link).  an existing file keeps its permissions
+// and owner.
+func writeFileAtomic(ctx context.Context, path string, data []byte, createMode os.FileMode) error {
+	path, replaced, tmpFd, err := createTempForPath(path, createMode)
+	if err != nil {
+		return err
+	}
+	tmpName := tmpFd.Name()
+	success := false
+	defer func() {
+		if !success {
+			tmpFd.Close()
+			os.Remove(tmpName)
+		}
+	}()
+	for offset := 0; offset < len(data); offset += AtomicWriteChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := tmpFd.Write(data[offset:min(offset+AtomicWriteChunkSize, len(data))]); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := setReplacedFileAttrs(tmpFd, replaced); err != nil {
+		return err
+	}
+	if err := tmpFd.Sync(); err != nil {
+		return err
+	}
+	if err := tmpFd.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	success = true
 	return nil
 }
 
-func (*ServerImpl) RemoteFileDeleteCommand(ctx context.Context, path string) error {
-	expandedPath, err := wavebase.ExpandHomeDir(path)
+func (impl *ServerImpl) RemoteFileDeleteCommand(ctx context.Context, data wshrpc.CommandRemoteFileDeleteData) (wshrpc.FileOpPreview, error) {
+	expandedPath, err := wavebase.ExpandHomeDir(data.Path)
 	if err != nil {
-		return fmt.Errorf("cannot delete file %q: %w", path, err)
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot delete file %q: %w", data.Path, err)
 	}
 	cleanedPath := filepath.Clean(expandedPath)
-	err = os.Remove(cleanedPath)
+	if data.DryRun {
+		preview, err := previewDelete(ctx, cleanedPath, data.Recursive)
+		if err != nil {
+			return wshrpc.FileOpPreview{}, fmt.Errorf("cannot delete file %q: %w", data.Path, err)
+		}
+		return preview, nil
+	}
+	if data.Recursive {
+		// RemoveAll doesn't fail on a missing path, keep the error consistent with the non-recursive delete
+		if _, err := os.Lstat(cleanedPath); err != nil {
+			return wshrpc.FileOpPreview{}, fmt.Errorf("cannot delete file %q: %w", data.Path, err)
+		}
+		err = os.RemoveAll(cleanedPath)
+		impl.invalidateFileInfo(cleanedPath)
+	} else {
+		err = os.Remove(cleanedPath)
+		impl.invalidateFileInfo(cleanedPath)
+	}
 	if err != nil {
-		return fmt.Errorf("cannot delete file %q: %w", path, err)
+		return wshrpc.FileOpPreview{}, fmt.Errorf("cannot delete file %q: %w", data.Path, err)
 	}
-	return nil
+	return wshrpc.FileOpPreview{}, nil
 }
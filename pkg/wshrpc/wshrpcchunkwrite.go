@@ -0,0 +1,123 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// client side of RemoteWriteFileChunkCommand.  each round sends a window of chunks starting at the last
+// committed offset, and the next round starts from the highest offset acked.  a lost chunk or ack just
+// means the round restarts from an earlier offset (resending is harmless, the server skips committed bytes).
+
+const DefaultWriteChunkSize = 256 * 1024
+const DefaultWriteChunkWindow = 8
+const MaxWriteChunkStalls = 5 // consecutive rounds without progress before giving up
+
+type ChunkWriteSendFn func(data CommandRemoteWriteChunkData) (RemoteWriteChunkAck, error)
+
+type ChunkedWriteOpts struct {
+	UploadId   string
+	Path       string
+	CreateMode os.FileMode
+	ChunkSize  int // defaults to DefaultWriteChunkSize
+	Window     int // chunks in flight, defaults to DefaultWriteChunkWindow
+}
+
+func (opts ChunkedWriteOpts) makeChunk(offset int64, data []byte) CommandRemoteWriteChunkData {
+	rtn := CommandRemoteWriteChunkData{UploadId: opts.UploadId, Path: opts.Path, Offset: offset, CreateMode: opts.CreateMode}
+	if len(data) > 0 {
+		rtn.Data64 = base64.StdEncoding.EncodeToString(data)
+	}
+	return rtn
+}
+
+// sends a window of chunks from offset concurrently, returns the highest committed offset acked (offset
+// if there were no acks) and the last error
+func sendChunkWindow(opts ChunkedWriteOpts, data []byte, offset int64, sendFn ChunkWriteSendFn) (int64, error) {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	committed := offset
+	var lastErr error
+	for idx := 0; idx < opts.Window; idx++ {
+		chunkOffset := offset + int64(idx*opts.ChunkSize)
+		if chunkOffset >= int64(len(data)) {
+			break
+		}
+		chunk := opts.makeChunk(chunkOffset, data[chunkOffset:min(chunkOffset+int64(opts.ChunkSize), int64(len(data)))])
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ack, err := sendFn(chunk)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				lastErr = err
+				return
+			}
+			committed = max(committed, ack.Committed)
+		}()
+	}
+	wg.Wait()
+	return committed, lastErr
+}
+
+// uploads data with sendFn (normally a RemoteWriteFileChunkCommand call), retrying lost chunks and acks
+func RunChunkedWrite(opts ChunkedWriteOpts, data []byte, sendFn ChunkWriteSendFn) error {
+	if opts.UploadId == "" {
+		return fmt.Errorf("uploadid is required")
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultWriteChunkSize
+	}
+	if opts.Window <= 0 {
+		opts.Window = DefaultWriteChunkWindow
+	}
+	var committed int64
+	var stalls int
+	var lastErr error
+	for committed < int64(len(data)) {
+		newCommitted, err := sendChunkWindow(opts, data, committed, sendFn)
+		if err != nil {
+			lastErr = err
+		}
+		if newCommitted > int64(len(data)) {
+			return fmt.Errorf("server committed %d bytes, more than the %d sent", newCommitted, len(data))
+		}
+		if newCommitted > committed {
+			committed = newCommitted
+			stalls = 0
+			continue
+		}
+		stalls++
+		if stalls >= MaxWriteChunkStalls {
+			return fmt.Errorf("upload stalled at offset %d: %w", committed, lastErr)
+		}
+	}
+	checksum := sha256.Sum256(data)
+	doneChunk := opts.makeChunk(int64(len(data)), nil)
+	doneChunk.Done = true
+	doneChunk.Checksum = hex.EncodeToString(checksum[:])
+	for stalls = 0; stalls < MaxWriteChunkStalls; stalls++ {
+		ack, err := sendFn(doneChunk)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if ack.Done {
+			return nil
+		}
+		lastErr = fmt.Errorf("server has %d of %d bytes", ack.Committed, len(data))
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no response")
+	}
+	return fmt.Errorf("error finishing upload: %w", lastErr)
+}
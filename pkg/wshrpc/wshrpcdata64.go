@@ -0,0 +1,99 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// error prefix for malformed base64 command data (errors cross the rpc boundary as strings, like "NOTFOUND:")
+const ErrPrefix_InvalidBase64 = "invalid_base64"
+
+type InvalidBase64Error struct {
+	Field string
+	Err   error
+}
+
+func (e InvalidBase64Error) Error() string {
+	return fmt.Sprintf("%s: field %q: %v", ErrPrefix_InvalidBase64, e.Field, e.Err)
+}
+
+func (e InvalidBase64Error) Unwrap() error {
+	return e.Err
+}
+
+func IsInvalidBase64Error(err error) bool {
+	var b64Err InvalidBase64Error
+	return errors.As(err, &b64Err)
+}
+
+// decodes a base64 command field, call before doing any work so corrupt data can't cause partial writes
+func DecodeData64(field string, data64 string) ([]byte, error) {
+	if data64 == "" {
+		return nil, nil
+	}
+	rtn, err := base64.StdEncoding.DecodeString(data64)
+	if err != nil {
+		return nil, InvalidBase64Error{Field: field, Err: err}
+	}
+	return rtn, nil
+}
+
+const FileEncoding_Gzip = "gzip"
+
+const DefaultCompressMinSize = 4096
+
+// encodes a file read result, returns the base64 data and its encoding (FileEncoding_Gzip or "" for plain).
+// data is only compressed when requested, at least compressMinSize bytes, and the compressed form is actually smaller.
+func EncodeFileReadData(data []byte, encoding string, compressMinSize int64) (string, string) {
+	if encoding != FileEncoding_Gzip {
+		return base64.StdEncoding.EncodeToString(data), ""
+	}
+	if compressMinSize <= 0 {
+		compressMinSize = DefaultCompressMinSize
+	}
+	if int64(len(data)) < compressMinSize {
+		return base64.StdEncoding.EncodeToString(data), ""
+	}
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	_, err := gzWriter.Write(data)
+	if err == nil {
+		err = gzWriter.Close()
+	}
+	if err != nil || buf.Len() >= len(data) {
+		return base64.StdEncoding.EncodeToString(data), ""
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), FileEncoding_Gzip
+}
+
+// decodes a file read result given the encoding returned with it (see EncodeFileReadData)
+func DecodeFileReadData(data64 string, encoding string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(data64)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding file data: %w", err)
+	}
+	switch encoding {
+	case "":
+		return data, nil
+	case FileEncoding_Gzip:
+	default:
+		return nil, fmt.Errorf("unsupported file data encoding %q", encoding)
+	}
+	gzReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing file data: %w", err)
+	}
+	defer gzReader.Close()
+	rtnData, err := io.ReadAll(gzReader)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing file data: %w", err)
+	}
+	return rtnData, nil
+}
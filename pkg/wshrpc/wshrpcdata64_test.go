@@ -0,0 +1,127 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshremote"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshserver"
+)
+
+var badData64 = map[string]string{
+	"truncated":  "aGVsbG8gd29ybG",
+	"non-base64": "hello world!",
+}
+
+func checkInvalidBase64(t *testing.T, name string, field string, err error) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("%s: expected error, got nil", name)
+		return
+	}
+	if !wshrpc.IsInvalidBase64Error(err) {
+		t.Errorf("%s: expected invalid base64 error, got %v", name, err)
+	}
+	if !strings.HasPrefix(err.Error(), wshrpc.ErrPrefix_InvalidBase64) || !strings.Contains(err.Error(), field) {
+		t.Errorf("%s: error should have prefix %q and name field %q, got %q", name, wshrpc.ErrPrefix_InvalidBase64, field, err.Error())
+	}
+}
+
+func TestInvalidBase64Commands(t *testing.T) {
+	ctx := context.Background()
+	ws := &wshserver.WshServer{}
+	remoteImpl := &wshremote.ServerImpl{}
+	tmpDir := t.TempDir()
+	for caseName, data64 := range badData64 {
+		fileData := wshrpc.CommandFileData{ZoneId: "zone", FileName: "file", Data64: data64}
+		checkInvalidBase64(t, "filewrite/"+caseName, "data64", ws.FileWriteCommand(ctx, fileData))
+		checkInvalidBase64(t, "fileappend/"+caseName, "data64", ws.FileAppendCommand(ctx, fileData))
+		inputData := wshrpc.CommandBlockInputData{BlockId: "block", InputData64: data64}
+		checkInvalidBase64(t, "controllerinput/"+caseName, "inputdata64", ws.ControllerInputCommand(ctx, inputData))
+		createData := wshrpc.CommandCreateBlockData{
+			TabId:     "tab",
+			BlockDef:  &waveobj.BlockDef{Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "preview"}},
+			InitFiles: []wshrpc.CommandFileData{{FileName: "ok"}, {FileName: "file", Data64: data64}},
+		}
+		_, err := ws.CreateBlockCommand(ctx, createData)
+		checkInvalidBase64(t, "createblock/"+caseName, "initfiles[1].data64", err)
+		writePath := filepath.Join(tmpDir, caseName)
+		writeData := wshrpc.CommandRemoteWriteFileData{Path: writePath, Data64: data64}
+		checkInvalidBase64(t, "remotewritefile/"+caseName, "data64", remoteImpl.RemoteWriteFileCommand(ctx, writeData))
+		if info, err := remoteImpl.RemoteFileInfoCommand(ctx, writePath); err != nil || !info.NotFound {
+			t.Errorf("remotewritefile/%s: file should not have been written (err: %v)", caseName, err)
+		}
+	}
+}
+
+func makeTestLogData(numLines int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < numLines; i++ {
+		fmt.Fprintf(&buf, "2025-01-01T00:00:%02dZ INFO [conn:%d] processed request id=%08d status=ok\n", i%60, i%8, i)
+	}
+	return buf.Bytes()
+}
+
+func TestFileReadDataEncoding(t *testing.T) {
+	logData := makeTestLogData(1000)
+	for _, tc := range []struct {
+		name       string
+		data       []byte
+		encoding   string
+		minSize    int64
+		expectGzip bool
+	}{
+		{"default", logData, "", 0, false},
+		{"gzip", logData, wshrpc.FileEncoding_Gzip, 0, true},
+		{"below-threshold", logData, wshrpc.FileEncoding_Gzip, int64(len(logData)) + 1, false},
+		{"small", []byte("hello"), wshrpc.FileEncoding_Gzip, 0, false},
+		{"empty", nil, wshrpc.FileEncoding_Gzip, 0, false},
+	} {
+		rtn, rtnEncoding := wshrpc.EncodeFileReadData(tc.data, tc.encoding, tc.minSize)
+		if (rtnEncoding == wshrpc.FileEncoding_Gzip) != tc.expectGzip {
+			t.Errorf("%s: expected gzip=%v, got encoding %q", tc.name, tc.expectGzip, rtnEncoding)
+		}
+		decoded, err := wshrpc.DecodeFileReadData(rtn, rtnEncoding)
+		if err != nil {
+			t.Errorf("%s: error decoding: %v", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(decoded, tc.data) {
+			t.Errorf("%s: decoded data does not match", tc.name)
+		}
+	}
+	if _, err := wshrpc.DecodeFileReadData("aGVsbG8=", "br"); err == nil {
+		t.Errorf("expected an unknown encoding to be rejected")
+	}
+}
+
+func BenchmarkFileReadLog(b *testing.B) {
+	logData := makeTestLogData(100000)
+	for _, encoding := range []string{"", wshrpc.FileEncoding_Gzip} {
+		name := encoding
+		if name == "" {
+			name = "plain"
+		}
+		b.Run(name, func(b *testing.B) {
+			var wireSize int
+			for i := 0; i < b.N; i++ {
+				rtn, rtnEncoding := wshrpc.EncodeFileReadData(logData, encoding, 0)
+				wireSize = len(rtn)
+				if _, err := wshrpc.DecodeFileReadData(rtn, rtnEncoding); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(wireSize), "wire-bytes")
+			b.ReportMetric(float64(wireSize)/float64(len(logData)), "wire-ratio")
+		})
+	}
+}
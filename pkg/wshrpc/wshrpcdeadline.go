@@ -0,0 +1,47 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// a chain of calls can share one overall deadline (RpcOpts.Deadline), each call's timeout is
+// the time left rather than a fresh per-call timeout.  handlers propagate their own deadline
+// (from the request timeout) to downstream calls with RpcOptsFromContext.
+
+var ErrDeadlineExceeded = errors.New("EC-TIME: deadline exceeded")
+
+// returns the timeout (ms) for a call: opts.Timeout (or defaultTimeoutMs), bounded by the time left until opts.Deadline
+// returns ErrDeadlineExceeded if the deadline has already passed
+func (opts *RpcOpts) GetTimeoutMs(defaultTimeoutMs int) (int, error) {
+	timeoutMs := defaultTimeoutMs
+	if opts != nil && opts.Timeout > 0 {
+		timeoutMs = opts.Timeout
+	}
+	if opts == nil || opts.Deadline.IsZero() {
+		return timeoutMs, nil
+	}
+	remainingMs := time.Until(opts.Deadline).Milliseconds()
+	if remainingMs <= 0 {
+		return 0, ErrDeadlineExceeded
+	}
+	return int(min(int64(timeoutMs), remainingMs)), nil
+}
+
+// returns a copy of opts (may be nil) whose Deadline is bounded by ctx's deadline
+func RpcOptsFromContext(ctx context.Context, opts *RpcOpts) *RpcOpts {
+	var rtn RpcOpts
+	if opts != nil {
+		rtn = *opts
+	}
+	rtn.StreamCancelFn = nil
+	ctxDeadline, ok := ctx.Deadline()
+	if ok && (rtn.Deadline.IsZero() || ctxDeadline.Before(rtn.Deadline)) {
+		rtn.Deadline = ctxDeadline
+	}
+	return &rtn
+}
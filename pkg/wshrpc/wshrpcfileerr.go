@@ -0,0 +1,38 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// error prefixes for file operations, so callers can tell a missing file from a permission problem
+const ErrPrefix_NotFound = "not_found"
+const ErrPrefix_PermissionDenied = "permission_denied"
+
+// prefixes not-exist and permission errors, other errors are returned as is
+func PrefixFileError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%s: %w", ErrPrefix_NotFound, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%s: %w", ErrPrefix_PermissionDenied, err)
+	}
+	return err
+}
+
+// works on errors that crossed the rpc boundary (only the message survives)
+func IsNotFoundError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), ErrPrefix_NotFound+":")
+}
+
+// works on errors that crossed the rpc boundary (only the message survives)
+func IsPermissionDeniedError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), ErrPrefix_PermissionDenied+":")
+}
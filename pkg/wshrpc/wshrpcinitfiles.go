@@ -0,0 +1,50 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// returns the blockdef with Vars substituted into its meta and InitFiles merged into its Files (the caller's
+// blockdef is not modified).  all of the files are validated and decoded up front so a bad file fails before
+// the block is created.
+func (data CommandCreateBlockData) MakeInitBlockDef() (*waveobj.BlockDef, error) {
+	if data.BlockDef == nil || (len(data.InitFiles) == 0 && data.Vars == nil) {
+		return data.BlockDef, nil
+	}
+	rtn := *data.BlockDef
+	if data.Vars != nil {
+		meta, err := SubstituteMetaVars(rtn.Meta, data.Vars)
+		if err != nil {
+			return nil, err
+		}
+		rtn.Meta = meta
+	}
+	if len(data.InitFiles) == 0 {
+		return &rtn, nil
+	}
+	rtn.Files = make(map[string]*waveobj.FileDef, len(data.BlockDef.Files)+len(data.InitFiles))
+	maps.Copy(rtn.Files, data.BlockDef.Files)
+	for idx, file := range data.InitFiles {
+		if file.FileName == "" {
+			return nil, fmt.Errorf("initfiles[%d]: filename is required", idx)
+		}
+		if file.At != nil {
+			return nil, fmt.Errorf("initfiles[%d]: offset writes are not supported", idx)
+		}
+		if _, exists := rtn.Files[file.FileName]; exists {
+			return nil, fmt.Errorf("initfiles[%d]: duplicate file %q", idx, file.FileName)
+		}
+		dataBuf, err := DecodeData64(fmt.Sprintf("initfiles[%d].data64", idx), file.Data64)
+		if err != nil {
+			return nil, err
+		}
+		rtn.Files[file.FileName] = &waveobj.FileDef{Content: string(dataBuf)}
+	}
+	return &rtn, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestMakeInitBlockDef(t *testing.T) {
+	blockDef := &waveobj.BlockDef{
+		Meta:  waveobj.MetaMapType{waveobj.MetaKey_View: "preview"},
+		Files: map[string]*waveobj.FileDef{"existing": {Content: "old"}},
+	}
+	data := wshrpc.CommandCreateBlockData{
+		BlockDef: blockDef,
+		InitFiles: []wshrpc.CommandFileData{
+			{FileName: "main", Data64: base64.StdEncoding.EncodeToString([]byte("hello\x00world"))},
+			{FileName: "empty"},
+		},
+	}
+	rtn, err := data.MakeInitBlockDef()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rtn.Files) != 3 || rtn.Files["existing"].Content != "old" || rtn.Files["main"].Content != "hello\x00world" || rtn.Files["empty"].Content != "" {
+		t.Errorf("unexpected files: %v", rtn.Files)
+	}
+	if len(blockDef.Files) != 1 {
+		t.Errorf("caller's blockdef should not be modified, got %d files", len(blockDef.Files))
+	}
+	for name, initFiles := range map[string][]wshrpc.CommandFileData{
+		"no-filename": {{Data64: "aGk="}},
+		"duplicate":   {{FileName: "existing"}},
+		"offset":      {{FileName: "main", At: &wshrpc.CommandFileDataAt{Offset: 10}}},
+	} {
+		data.InitFiles = initFiles
+		if _, err := data.MakeInitBlockDef(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
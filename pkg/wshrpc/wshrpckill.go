@@ -0,0 +1,29 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"strings"
+	"time"
+)
+
+const DefaultKillSignal = "TERM"
+const DefaultKillGrace = 5 * time.Second
+const MaxKillGrace = 60 * time.Second
+
+func (data CommandRemoteKillData) GetGrace() time.Duration {
+	if data.GraceMs <= 0 {
+		return DefaultKillGrace
+	}
+	return min(time.Duration(data.GraceMs)*time.Millisecond, MaxKillGrace)
+}
+
+// upper case without the "SIG" prefix ("sigint" => "INT"), empty is DefaultKillSignal
+func (data CommandRemoteKillData) GetSignalName() string {
+	name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(data.Signal)), "SIG")
+	if name == "" {
+		return DefaultKillSignal
+	}
+	return name
+}
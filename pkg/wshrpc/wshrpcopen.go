@@ -0,0 +1,55 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	ResourceView_Preview = "preview"
+	ResourceView_Web     = "web"
+)
+
+// code mimetypes the preview opens in its editor, the frontend's copy (frontend/app/store/textmimetypes.ts)
+// is generated from this list by cmd/generatets
+var TextApplicationMimeTypes = []string{
+	"application/sql", "application/pem-certificate-chain", "application/x-php", "application/x-httpd-php",
+	"application/liquid", "application/graphql", "application/javascript", "application/typescript",
+	"application/x-javascript", "application/x-typescript", "application/dart", "application/vnd.dart",
+	"application/x-ruby", "application/wasm", "application/x-latex", "application/x-sh",
+	"application/x-python", "application/x-awk",
+}
+
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") ||
+		slices.Contains(TextApplicationMimeTypes, mimeType) ||
+		(strings.HasPrefix(mimeType, "application/") &&
+			(strings.Contains(mimeType, "json") || strings.Contains(mimeType, "yaml") || strings.Contains(mimeType, "toml"))) ||
+		strings.Contains(mimeType, "xml")
+}
+
+// picks the view for a file by its detected mimetype (see RemoteFileInfoCommand), returns the view and
+// whether to open it in the editor.  rendered types (markdown, csv, images, media, pdf) and directories
+// use the preview, other text opens in the editor.
+func GetResourceView(mimeType string) (string, bool, error) {
+	mimeType = strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0])
+	switch {
+	case mimeType == "directory":
+		return ResourceView_Preview, false, nil
+	case strings.HasPrefix(mimeType, "text/markdown"), strings.HasPrefix(mimeType, "text/csv"):
+		return ResourceView_Preview, false, nil
+	case strings.HasPrefix(mimeType, "image/"), strings.HasPrefix(mimeType, "video/"),
+		strings.HasPrefix(mimeType, "audio/"), mimeType == "application/pdf":
+		return ResourceView_Preview, false, nil
+	case isTextMimeType(mimeType):
+		return ResourceView_Preview, true, nil
+	}
+	if mimeType == "" {
+		mimeType = "unknown"
+	}
+	return "", false, fmt.Errorf("cannot open files of type %q in a block (open it from a terminal block instead, e.g. with xdg-open or open)", mimeType)
+}
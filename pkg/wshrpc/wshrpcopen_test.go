@@ -0,0 +1,46 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestGetResourceView(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		edit     bool
+	}{
+		{"directory", false},
+		{"text/markdown", false},
+		{"text/csv", false},
+		{"image/png", false},
+		{"video/mp4", false},
+		{"application/pdf", false},
+		{"text/plain", true},
+		{"text/x-go; charset=utf-8", true},
+		{"application/json", true},
+		{"application/x-sh", true},
+		{"image/svg+xml", false},
+	}
+	for _, tc := range tests {
+		view, edit, err := wshrpc.GetResourceView(tc.mimeType)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.mimeType, err)
+			continue
+		}
+		if view != wshrpc.ResourceView_Preview || edit != tc.edit {
+			t.Errorf("%q: got view=%q edit=%v, want view=%q edit=%v", tc.mimeType, view, edit, wshrpc.ResourceView_Preview, tc.edit)
+		}
+	}
+	for _, mimeType := range []string{"application/octet-stream", "application/zip", ""} {
+		_, _, err := wshrpc.GetResourceView(mimeType)
+		if err == nil || !strings.Contains(err.Error(), "terminal block") {
+			t.Errorf("%q: expected an unsupported type error, got %v", mimeType, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pagination envelope for list commands that can return large results.
+// cursors are opaque, clients must pass NextCursor back unmodified and never parse or construct them.
+// an empty NextCursor means there are no more items.
+
+const DefaultPageLimit = 100
+const MaxPageLimit = 1000
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextcursor,omitempty"`
+	Total      int    `json:"total"` // total number of items across all pages (at the time of the request)
+}
+
+// concrete page types for the rpc api (the code generators need named, non-generic types)
+type FileInfoPage Page[*FileInfo]
+type StringPage Page[string]
+
+type PageOpts struct {
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"` // defaults to 100, max 1000
+}
+
+const pageCursorPrefix = "o:"
+
+func encodePageCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageCursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodePageCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(cursorBytes), pageCursorPrefix) {
+		return 0, fmt.Errorf("invalid page cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(cursorBytes), pageCursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid page cursor")
+	}
+	return offset, nil
+}
+
+// returns the [start, end) bounds of the requested page and the next cursor
+func PageBounds(opts PageOpts, total int) (int, int, string, error) {
+	start, err := decodePageCursor(opts.Cursor)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	var nextCursor string
+	if end < total {
+		nextCursor = encodePageCursor(end)
+	}
+	return start, end, nextCursor, nil
+}
+
+func PaginateSlice[T any](items []T, opts PageOpts) (Page[T], error) {
+	start, end, nextCursor, err := PageBounds(opts, len(items))
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return Page[T]{Items: items[start:end], NextCursor: nextCursor, Total: len(items)}, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestPaginateSlice(t *testing.T) {
+	items := make([]int, 250)
+	for i := range items {
+		items[i] = i
+	}
+	var all []int
+	opts := wshrpc.PageOpts{}
+	for numPages := 0; ; numPages++ {
+		if numPages > 3 {
+			t.Fatalf("too many pages")
+		}
+		page, err := wshrpc.PaginateSlice(items, opts)
+		if err != nil {
+			t.Fatalf("paginate error: %v", err)
+		}
+		if page.Total != len(items) {
+			t.Errorf("expected total %d, got %d", len(items), page.Total)
+		}
+		all = append(all, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if len(all) != len(items) || all[249] != 249 {
+		t.Errorf("pages did not cover all items, got %d", len(all))
+	}
+	if _, err := wshrpc.PaginateSlice(items, wshrpc.PageOpts{Cursor: "bogus"}); err == nil {
+		t.Errorf("expected error for invalid cursor")
+	}
+}
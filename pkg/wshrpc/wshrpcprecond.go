@@ -0,0 +1,49 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// error prefix for conditional writes (IfModTime/IfSize) that did not match the current file
+const ErrPrefix_PreconditionFailed = "precondition_failed"
+
+// holds the file's current stat so the client can reconcile and retry
+type PreconditionFailedError struct {
+	ModTs int64
+	Size  int64
+}
+
+func (e PreconditionFailedError) Error() string {
+	return fmt.Sprintf("%s: modts=%d size=%d", ErrPrefix_PreconditionFailed, e.ModTs, e.Size)
+}
+
+func IsPreconditionFailedError(err error) bool {
+	_, ok := ParsePreconditionFailedError(err)
+	return ok
+}
+
+// also works for errors that crossed the rpc boundary (as strings)
+func ParsePreconditionFailedError(err error) (PreconditionFailedError, bool) {
+	var rtn PreconditionFailedError
+	if err == nil {
+		return rtn, false
+	}
+	if errors.As(err, &rtn) {
+		return rtn, true
+	}
+	errStr := err.Error()
+	idx := strings.Index(errStr, ErrPrefix_PreconditionFailed+":")
+	if idx < 0 {
+		return rtn, false
+	}
+	_, scanErr := fmt.Sscanf(errStr[idx:], ErrPrefix_PreconditionFailed+": modts=%d size=%d", &rtn.ModTs, &rtn.Size)
+	if scanErr != nil {
+		return PreconditionFailedError{}, false
+	}
+	return rtn, true
+}
@@ -0,0 +1,23 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestParsePreconditionFailedError(t *testing.T) {
+	origErr := wshrpc.PreconditionFailedError{ModTs: 1700000000123, Size: 42}
+	// errors cross the rpc boundary as strings
+	parsed, ok := wshrpc.ParsePreconditionFailedError(errors.New(origErr.Error()))
+	if !ok || parsed != origErr {
+		t.Errorf("expected %v, got %v (ok=%v)", origErr, parsed, ok)
+	}
+	if wshrpc.IsPreconditionFailedError(errors.New("error writing to blockfile: disk full")) {
+		t.Errorf("unrelated error should not be a precondition failure")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fields tagged `wshlog:"redact"` (tokens, file contents) are replaced in rpc logs, as are map entries
+// (block meta, config settings) whose key looks like a credential (see IsSensitiveLogKey)
+const RedactTag = "wshlog"
+const RedactedValue = "[redacted]"
+
+var sensitiveKeySuffixes = []string{"token", "password", "secret"}
+
+// true for map keys like "ai:apitoken", "conn:password", or "clientsecret" (case-insensitive suffix match)
+func IsSensitiveLogKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// converts v into plain maps/slices/values (keyed by json name) with redacted fields replaced,
+// suitable for json.Marshal.  structs are redacted by tag, maps by key.
+func RedactForLog(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(v))
+}
+
+func redactValue(rv reflect.Value) any {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return redactValue(rv.Elem())
+	case reflect.Struct:
+		rtn := make(map[string]any)
+		redactStructFields(rv, rtn)
+		return rtn
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte, log the size only
+			return rv.Len()
+		}
+		rtn := make([]any, rv.Len())
+		for idx := 0; idx < rv.Len(); idx++ {
+			rtn[idx] = redactValue(rv.Index(idx))
+		}
+		return rtn
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		rtn := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := reflect.ValueOf(iter.Key().Interface()).String()
+			if IsSensitiveLogKey(key) && !isZeroLogValue(iter.Value()) {
+				rtn[key] = RedactedValue
+				continue
+			}
+			rtn[key] = redactValue(iter.Value())
+		}
+		return rtn
+	default:
+		return rv.Interface()
+	}
+}
+
+func redactStructFields(rv reflect.Value, rtn map[string]any) {
+	rtype := rv.Type()
+	for idx := 0; idx < rtype.NumField(); idx++ {
+		field := rtype.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+		jsonName, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		fieldVal := rv.Field(idx)
+		if field.Anonymous && jsonName == "" {
+			embedded := fieldVal
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				redactStructFields(embedded, rtn)
+				continue
+			}
+			// embedded non-structs (e.g. MetaMapType) marshal as a regular field
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && fieldVal.IsZero() {
+			continue
+		}
+		if field.Tag.Get(RedactTag) == "redact" {
+			if !fieldVal.IsZero() {
+				rtn[jsonName] = RedactedValue
+			}
+			continue
+		}
+		rtn[jsonName] = redactValue(fieldVal)
+	}
+}
+
+// like IsZero, but looks inside interfaces (meta maps are map[string]any)
+func isZeroLogValue(rv reflect.Value) bool {
+	if rv.Kind() == reflect.Interface && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv.IsZero()
+}
@@ -0,0 +1,56 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestRedactForLogMapKeys(t *testing.T) {
+	data := CommandSetMetaData{
+		ORef: waveobj.ORef{OType: "block", OID: "123"},
+		Meta: waveobj.MetaMapType{
+			"ai:apitoken":   "sk-secret-1",
+			"ai:model":      "gpt-test",
+			"conn:Password": "secret-2",
+			"clientsecret":  "secret-3",
+			"cmd:env":       map[string]any{"GITHUB_TOKEN": "secret-4", "HOME": "/home/test"},
+			"ai:emptytoken": "",
+		},
+	}
+	barr, err := json.Marshal(RedactForLog(data))
+	if err != nil {
+		t.Fatalf("error marshaling redacted data: %v", err)
+	}
+	logStr := string(barr)
+	for _, secret := range []string{"sk-secret-1", "secret-2", "secret-3", "secret-4"} {
+		if strings.Contains(logStr, secret) {
+			t.Errorf("secret %q leaked into %s", secret, logStr)
+		}
+	}
+	for _, val := range []string{"gpt-test", "/home/test", `"ai:apitoken":"` + RedactedValue + `"`, `"ai:emptytoken":""`} {
+		if !strings.Contains(logStr, val) {
+			t.Errorf("expected %q in %s", val, logStr)
+		}
+	}
+}
+
+func TestIsSensitiveLogKey(t *testing.T) {
+	for key, expected := range map[string]bool{
+		"ai:apitoken":    true,
+		"AuthToken":      true,
+		"conn:password":  true,
+		"webhook:secret": true,
+		"ai:model":       false,
+		"tokencount":     false,
+	} {
+		if got := IsSensitiveLogKey(key); got != expected {
+			t.Errorf("IsSensitiveLogKey(%q): expected %v, got %v", key, expected, got)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"strings"
+	"time"
+)
+
+// error prefix for requests rejected because the server is draining for a shutdown/restart
+const ErrPrefix_ShuttingDown = "shutting_down"
+
+const DefaultShutdownGrace = 5 * time.Second
+const MaxShutdownGrace = 60 * time.Second
+
+// works on errors that crossed the rpc boundary (only the message survives)
+func IsShuttingDownError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), ErrPrefix_ShuttingDown)
+}
+
+func (data CommandShutdownData) GetGrace() time.Duration {
+	if data.GraceMs <= 0 {
+		return DefaultShutdownGrace
+	}
+	return min(time.Duration(data.GraceMs)*time.Millisecond, MaxShutdownGrace)
+}
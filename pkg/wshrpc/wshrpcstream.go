@@ -0,0 +1,20 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import "errors"
+
+// final error of a response stream that the handler ended early (see RespOrErrorUnion.PartialComplete)
+type StreamPartialError struct {
+	Msg string
+}
+
+func (e StreamPartialError) Error() string {
+	return e.Msg
+}
+
+func IsStreamPartialError(err error) bool {
+	var partialErr StreamPartialError
+	return errors.As(err, &partialErr)
+}
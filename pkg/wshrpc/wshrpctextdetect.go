@@ -0,0 +1,71 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"unicode/utf8"
+)
+
+const (
+	TextEncoding_Utf8   = "utf-8"
+	TextEncoding_Latin1 = "latin-1" // not valid utf-8, but few control chars (assumed to be a single byte charset)
+)
+
+const DefaultTextDetectSampleSize = 8192
+
+// non-utf-8 samples with more than this fraction of control chars are treated as binary
+const binaryControlCharRatio = 0.1
+
+// detects if data is binary or text by sampling the first sampleSize bytes (<= 0 uses DefaultTextDetectSampleSize)
+// a null byte means binary.  otherwise valid utf-8 is TextEncoding_Utf8 and anything else is TextEncoding_Latin1
+// unless it has too many control chars.  encoding is "" for binary data.  empty data is utf-8.
+func DetectTextEncoding(data []byte, sampleSize int) (binary bool, encoding string) {
+	if sampleSize <= 0 {
+		sampleSize = DefaultTextDetectSampleSize
+	}
+	sample := data
+	sampleTruncated := false
+	if len(sample) > sampleSize {
+		sample = sample[:sampleSize]
+		sampleTruncated = true
+	}
+	for _, b := range sample {
+		if b == 0 {
+			return true, ""
+		}
+	}
+	if isValidUtf8Sample(sample, sampleTruncated) {
+		return false, TextEncoding_Utf8
+	}
+	numControl := 0
+	for _, b := range sample {
+		if isBinaryControlChar(b) {
+			numControl++
+		}
+	}
+	if float64(numControl) > float64(len(sample))*binaryControlCharRatio {
+		return true, ""
+	}
+	return false, TextEncoding_Latin1
+}
+
+// if the sample was cut from a larger buffer, a partial rune at the very end is allowed
+func isValidUtf8Sample(sample []byte, truncated bool) bool {
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size <= 1 {
+			return truncated && len(sample) < utf8.UTFMax && !utf8.FullRune(sample)
+		}
+		sample = sample[size:]
+	}
+	return true
+}
+
+func isBinaryControlChar(b byte) bool {
+	switch b {
+	case '\t', '\n', '\r', '\f', '\b', 0x1b:
+		return false
+	}
+	return b < 0x20 || b == 0x7f
+}
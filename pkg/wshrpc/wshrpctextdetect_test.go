@@ -0,0 +1,38 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestDetectTextEncoding(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		sampleSize   int
+		wantBinary   bool
+		wantEncoding string
+	}{
+		{"empty", nil, 0, false, wshrpc.TextEncoding_Utf8},
+		{"ascii", []byte("hello world\n"), 0, false, wshrpc.TextEncoding_Utf8},
+		{"utf-8", []byte("héllo wörld — ✓\n"), 0, false, wshrpc.TextEncoding_Utf8},
+		// "é" (2 bytes) cut in half by the sample boundary
+		{"utf-8 split rune", []byte("abcé"), 4, false, wshrpc.TextEncoding_Utf8},
+		{"latin-1", []byte("caf\xe9 cr\xe8me br\xfbl\xe9e\n"), 0, false, wshrpc.TextEncoding_Latin1},
+		{"binary null", []byte("PK\x03\x04\x00\x00abc"), 0, true, ""},
+		{"binary control", bytes.Repeat([]byte{0x01, 0x02, 0xff, 0x03}, 64), 0, true, ""},
+		// the null byte is past the sample
+		{"null past sample", append(bytes.Repeat([]byte("a"), 100), 0), 10, false, wshrpc.TextEncoding_Utf8},
+	}
+	for _, tc := range tests {
+		binary, encoding := wshrpc.DetectTextEncoding(tc.data, tc.sampleSize)
+		if binary != tc.wantBinary || encoding != tc.wantEncoding {
+			t.Errorf("%s: got (%v, %q), want (%v, %q)", tc.name, binary, encoding, tc.wantBinary, tc.wantEncoding)
+		}
+	}
+}
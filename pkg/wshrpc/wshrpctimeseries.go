@@ -0,0 +1,223 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	TimeSeriesAgg_Avg  = "avg"
+	TimeSeriesAgg_Max  = "max"
+	TimeSeriesAgg_Min  = "min"
+	TimeSeriesAgg_Last = "last"
+)
+
+// server-side downsampling for metric streams.  when set, one aggregated sample is emitted per bucket.
+type DownsampleSpec struct {
+	BucketMs int64  `json:"bucketms"`
+	Agg      string `json:"agg,omitempty"` // TimeSeriesAgg_*, defaults to avg
+}
+
+func (spec DownsampleSpec) Validate() error {
+	if spec.BucketMs <= 0 {
+		return fmt.Errorf("invalid downsample bucketms %d (must be positive)", spec.BucketMs)
+	}
+	switch spec.Agg {
+	case "", TimeSeriesAgg_Avg, TimeSeriesAgg_Max, TimeSeriesAgg_Min, TimeSeriesAgg_Last:
+		return nil
+	}
+	return fmt.Errorf("invalid downsample agg %q", spec.Agg)
+}
+
+// returns a nil aggregator (raw samples) if spec is nil
+func MakeDownsampleAggregator(spec *DownsampleSpec) (*TimeSeriesAggregator, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return MakeTimeSeriesAggregator(*spec), nil
+}
+
+// sends sample to rtn, or with an aggregator, the bucket it completes (if any).  returns false if ctx is done first.
+func SendTimeSeriesSample(ctx context.Context, rtn chan RespOrErrorUnion[TimeSeriesData], agg *TimeSeriesAggregator, sample TimeSeriesData) bool {
+	toSend := &sample
+	if agg != nil {
+		toSend = agg.Add(sample)
+	}
+	if toSend == nil {
+		return true
+	}
+	select {
+	case rtn <- RespOrErrorUnion[TimeSeriesData]{Response: *toSend}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// buckets are aligned to multiples of BucketMs (since the epoch), an emitted sample is stamped with its
+// bucket's start.  each key is aggregated over only the samples that contain it, so missing samples (gaps)
+// don't skew a bucket, and buckets with no samples are not emitted.  samples must arrive in ts order,
+// samples for the current bucket's predecessors (including already emitted buckets) are dropped.
+type TimeSeriesAggregator struct {
+	spec        DownsampleSpec
+	bucketStart int64
+	hasBucket   bool
+	lastEmitted *int64 // start of the last emitted bucket
+	values      map[string]float64
+	counts      map[string]int
+}
+
+func MakeTimeSeriesAggregator(spec DownsampleSpec) *TimeSeriesAggregator {
+	if spec.Agg == "" {
+		spec.Agg = TimeSeriesAgg_Avg
+	}
+	return &TimeSeriesAggregator{spec: spec}
+}
+
+func (a *TimeSeriesAggregator) bucketFor(ts int64) int64 {
+	rem := ts % a.spec.BucketMs
+	if rem < 0 {
+		rem += a.spec.BucketMs
+	}
+	return ts - rem
+}
+
+// adds a sample, returns the completed bucket if the sample starts a new one (otherwise nil)
+func (a *TimeSeriesAggregator) Add(sample TimeSeriesData) *TimeSeriesData {
+	bucketStart := a.bucketFor(sample.Ts)
+	if a.lastEmitted != nil && bucketStart <= *a.lastEmitted {
+		return nil
+	}
+	var rtn *TimeSeriesData
+	if a.hasBucket {
+		if bucketStart < a.bucketStart {
+			return nil
+		}
+		if bucketStart > a.bucketStart {
+			rtn = a.Flush()
+		}
+	}
+	if !a.hasBucket {
+		a.hasBucket = true
+		a.bucketStart = bucketStart
+		a.values = make(map[string]float64)
+		a.counts = make(map[string]int)
+	}
+	for key, val := range sample.Values {
+		if math.IsNaN(val) {
+			continue
+		}
+		a.addValue(key, val)
+	}
+	return rtn
+}
+
+func (a *TimeSeriesAggregator) addValue(key string, val float64) {
+	count := a.counts[key]
+	a.counts[key] = count + 1
+	if count == 0 {
+		a.values[key] = val
+		return
+	}
+	switch a.spec.Agg {
+	case TimeSeriesAgg_Avg:
+		a.values[key] += val
+	case TimeSeriesAgg_Max:
+		a.values[key] = math.Max(a.values[key], val)
+	case TimeSeriesAgg_Min:
+		a.values[key] = math.Min(a.values[key], val)
+	case TimeSeriesAgg_Last:
+		a.values[key] = val
+	}
+}
+
+// emits the current bucket if it ended at or before ts (for streams where samples have stopped arriving)
+func (a *TimeSeriesAggregator) FlushBefore(ts int64) *TimeSeriesData {
+	if !a.hasBucket || a.bucketStart+a.spec.BucketMs > ts {
+		return nil
+	}
+	return a.Flush()
+}
+
+// emits the current (possibly partial) bucket, returns nil if there is none
+func (a *TimeSeriesAggregator) Flush() *TimeSeriesData {
+	if !a.hasBucket {
+		return nil
+	}
+	rtn := &TimeSeriesData{Ts: a.bucketStart, Values: make(map[string]float64, len(a.values))}
+	for key, val := range a.values {
+		if a.spec.Agg == TimeSeriesAgg_Avg {
+			val = val / float64(a.counts[key])
+		}
+		rtn.Values[key] = val
+	}
+	lastEmitted := a.bucketStart
+	a.lastEmitted = &lastEmitted
+	a.hasBucket = false
+	a.values = nil
+	a.counts = nil
+	return rtn
+}
+
+// retains recent samples at a fixed resolution (buckets averaged by a TimeSeriesAggregator).  retention is
+// measured back from the newest sample, so it is unaffected by clock skew between the sender and us.
+type TimeSeriesHistory struct {
+	lock         *sync.Mutex
+	resolutionMs int64
+	retentionMs  int64
+	agg          *TimeSeriesAggregator
+	samples      []TimeSeriesData
+}
+
+func MakeTimeSeriesHistory(resolutionMs int64, retentionMs int64) *TimeSeriesHistory {
+	return &TimeSeriesHistory{
+		lock:         &sync.Mutex{},
+		resolutionMs: resolutionMs,
+		retentionMs:  retentionMs,
+		agg:          MakeTimeSeriesAggregator(DownsampleSpec{BucketMs: resolutionMs, Agg: TimeSeriesAgg_Avg}),
+	}
+}
+
+func (h *TimeSeriesHistory) Matches(resolutionMs int64, retentionMs int64) bool {
+	return h.resolutionMs == resolutionMs && h.retentionMs == retentionMs
+}
+
+func (h *TimeSeriesHistory) Add(sample TimeSeriesData) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	bucket := h.agg.Add(sample)
+	if bucket == nil {
+		return
+	}
+	h.samples = append(h.samples, *bucket)
+	cutoff := bucket.Ts - h.retentionMs
+	idx := 0
+	for idx < len(h.samples) && h.samples[idx].Ts <= cutoff {
+		idx++
+	}
+	if idx > 0 {
+		h.samples = append([]TimeSeriesData(nil), h.samples[idx:]...)
+	}
+}
+
+// completed buckets newer than sinceTs (oldest first), at most maxItems of the newest (0 for all)
+func (h *TimeSeriesHistory) Read(sinceTs int64, maxItems int) []TimeSeriesData {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	startIdx := sort.Search(len(h.samples), func(i int) bool {
+		return h.samples[i].Ts > sinceTs
+	})
+	if maxItems > 0 && len(h.samples)-startIdx > maxItems {
+		startIdx = len(h.samples) - maxItems
+	}
+	return append([]TimeSeriesData{}, h.samples[startIdx:]...)
+}
@@ -0,0 +1,130 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func runAggregator(spec wshrpc.DownsampleSpec, samples []wshrpc.TimeSeriesData) []wshrpc.TimeSeriesData {
+	agg := wshrpc.MakeTimeSeriesAggregator(spec)
+	var rtn []wshrpc.TimeSeriesData
+	for _, sample := range samples {
+		if out := agg.Add(sample); out != nil {
+			rtn = append(rtn, *out)
+		}
+	}
+	if out := agg.Flush(); out != nil {
+		rtn = append(rtn, *out)
+	}
+	return rtn
+}
+
+func TestAggregatorBoundaries(t *testing.T) {
+	samples := []wshrpc.TimeSeriesData{
+		{Ts: 10000, Values: map[string]float64{"cpu": 1}}, // first ms of bucket 10000
+		{Ts: 14999, Values: map[string]float64{"cpu": 3}}, // last ms of bucket 10000
+		{Ts: 15000, Values: map[string]float64{"cpu": 10}},
+		{Ts: 12000, Values: map[string]float64{"cpu": 99}}, // late, dropped
+		{Ts: 31000, Values: map[string]float64{"cpu": 7}},  // after an empty bucket
+	}
+	expected := []wshrpc.TimeSeriesData{
+		{Ts: 10000, Values: map[string]float64{"cpu": 2}},
+		{Ts: 15000, Values: map[string]float64{"cpu": 10}},
+		{Ts: 30000, Values: map[string]float64{"cpu": 7}},
+	}
+	rtn := runAggregator(wshrpc.DownsampleSpec{BucketMs: 5000}, samples)
+	if !reflect.DeepEqual(rtn, expected) {
+		t.Errorf("expected %v, got %v", expected, rtn)
+	}
+}
+
+func TestAggregatorGaps(t *testing.T) {
+	// "cpu:1" is missing from the middle sample, it must not be averaged as a zero
+	samples := []wshrpc.TimeSeriesData{
+		{Ts: 1000, Values: map[string]float64{"cpu": 4, "cpu:1": 8}},
+		{Ts: 2000, Values: map[string]float64{"cpu": 6}},
+		{Ts: 3000, Values: map[string]float64{"cpu": 2, "cpu:1": 2}},
+	}
+	for agg, expected := range map[string]map[string]float64{
+		wshrpc.TimeSeriesAgg_Avg:  {"cpu": 4, "cpu:1": 5},
+		wshrpc.TimeSeriesAgg_Max:  {"cpu": 6, "cpu:1": 8},
+		wshrpc.TimeSeriesAgg_Min:  {"cpu": 2, "cpu:1": 2},
+		wshrpc.TimeSeriesAgg_Last: {"cpu": 2, "cpu:1": 2},
+	} {
+		rtn := runAggregator(wshrpc.DownsampleSpec{BucketMs: 60000, Agg: agg}, samples)
+		if len(rtn) != 1 || rtn[0].Ts != 0 || !reflect.DeepEqual(rtn[0].Values, expected) {
+			t.Errorf("%s: expected one bucket at 0 with %v, got %v", agg, expected, rtn)
+		}
+	}
+}
+
+func TestAggregatorFlushBefore(t *testing.T) {
+	agg := wshrpc.MakeTimeSeriesAggregator(wshrpc.DownsampleSpec{BucketMs: 1000})
+	agg.Add(wshrpc.TimeSeriesData{Ts: 1500, Values: map[string]float64{"cpu": 1}})
+	if out := agg.FlushBefore(1999); out != nil {
+		t.Errorf("bucket flushed before it ended: %v", out)
+	}
+	if out := agg.FlushBefore(2000); out == nil || out.Ts != 1000 {
+		t.Errorf("expected bucket 1000 to be flushed, got %v", out)
+	}
+	if out := agg.Add(wshrpc.TimeSeriesData{Ts: 1900, Values: map[string]float64{"cpu": 1}}); out != nil || agg.Flush() != nil {
+		t.Errorf("sample for an emitted bucket should be dropped")
+	}
+}
+
+func TestTimeSeriesHistory(t *testing.T) {
+	history := wshrpc.MakeTimeSeriesHistory(1000, 5000)
+	for ts := int64(0); ts <= 10000; ts += 500 {
+		history.Add(wshrpc.TimeSeriesData{Ts: ts, Values: map[string]float64{"cpu": float64(ts)}})
+	}
+	// bucket 10000 is still open, buckets older than 5s before the newest completed bucket (9000) are dropped
+	samples := history.Read(0, 0)
+	if len(samples) != 5 || samples[0].Ts != 5000 || samples[4].Ts != 9000 {
+		t.Fatalf("unexpected retained samples: %v", samples)
+	}
+	if samples[0].Values["cpu"] != 5250 {
+		t.Errorf("expected bucket 5000 to average to 5250, got %v", samples[0].Values["cpu"])
+	}
+	if samples := history.Read(7000, 0); len(samples) != 2 || samples[0].Ts != 8000 {
+		t.Errorf("expected samples after 7000, got %v", samples)
+	}
+	if samples := history.Read(0, 1); len(samples) != 1 || samples[0].Ts != 9000 {
+		t.Errorf("expected newest sample only, got %v", samples)
+	}
+}
+
+func TestSendTimeSeriesSample(t *testing.T) {
+	if _, err := wshrpc.MakeDownsampleAggregator(&wshrpc.DownsampleSpec{BucketMs: 1000, Agg: "median"}); err == nil {
+		t.Errorf("expected an invalid agg to be rejected")
+	}
+	rawAgg, _ := wshrpc.MakeDownsampleAggregator(nil)
+	agg, _ := wshrpc.MakeDownsampleAggregator(&wshrpc.DownsampleSpec{BucketMs: 1000})
+	rawCh := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData], 10)
+	aggCh := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData], 10)
+	for _, ts := range []int64{0, 500, 1000} {
+		sample := wshrpc.TimeSeriesData{Ts: ts, Values: map[string]float64{"gpu:0:util": float64(ts)}}
+		wshrpc.SendTimeSeriesSample(context.Background(), rawCh, rawAgg, sample)
+		wshrpc.SendTimeSeriesSample(context.Background(), aggCh, agg, sample)
+	}
+	if len(rawCh) != 3 {
+		t.Errorf("expected every raw sample to be sent, got %d", len(rawCh))
+	}
+	if len(aggCh) != 1 {
+		t.Fatalf("expected only the completed bucket to be sent, got %d", len(aggCh))
+	}
+	if bucket := (<-aggCh).Response; bucket.Ts != 0 || bucket.Values["gpu:0:util"] != 250 {
+		t.Errorf("unexpected bucket %v", bucket)
+	}
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cancelFn()
+	if wshrpc.SendTimeSeriesSample(ctx, make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]), nil, wshrpc.TimeSeriesData{}) {
+		t.Errorf("expected the send to fail once the context is done")
+	}
+}
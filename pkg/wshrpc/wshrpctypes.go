@@ -8,13 +8,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"reflect"
+	"time"
 
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/ijson"
 	"github.com/wavetermdev/waveterm/pkg/vdom"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 )
@@ -36,37 +39,98 @@ const (
 	Command_Message              = "message"
 	Command_GetMeta              = "getmeta"
 	Command_SetMeta              = "setmeta"
+	Command_SetMetaPatch         = "setmetapatch"
+	Command_SetMetaBatch         = "setmetabatch"
 	Command_SetView              = "setview"
+	Command_SetViewBatch         = "setviewbatch"
+	Command_ListBlockViews       = "listblockviews"
+	Command_GetFocus             = "getfocus"
+	Command_SetFocus             = "setfocus"
+	Command_GetLayout            = "getlayout"
+	Command_SetLayout            = "setlayout"
 	Command_ControllerInput      = "controllerinput"
+	Command_BroadcastInput       = "broadcastinput"
+	Command_ControllerResize     = "controllerresize"
+	Command_ControllerStatus     = "controllerstatus"
+	Command_ListControllers      = "listcontrollers"
 	Command_ControllerRestart    = "controllerrestart"
 	Command_ControllerStop       = "controllerstop"
 	Command_ControllerResync     = "controllerresync"
 	Command_FileAppend           = "fileappend"
+	Command_FileFlush            = "fileflush"
 	Command_FileAppendIJson      = "fileappendijson"
 	Command_ResolveIds           = "resolveids"
 	Command_BlockInfo            = "blockinfo"
 	Command_CreateBlock          = "createblock"
+	Command_OpenResource         = "openresource"
 	Command_DeleteBlock          = "deleteblock"
+	Command_SnapshotBlock        = "snapshotblock"
+	Command_RestoreBlock         = "restoreblock"
 	Command_FileWrite            = "filewrite"
 	Command_FileRead             = "fileread"
+	Command_FileReadDetect       = "filereaddetect"
+	Command_FileTail             = "filetail"
+	Command_FileInfo             = "fileinfo"
 	Command_EventPublish         = "eventpublish"
 	Command_EventRecv            = "eventrecv"
 	Command_EventSub             = "eventsub"
 	Command_EventUnsub           = "eventunsub"
 	Command_EventUnsubAll        = "eventunsuball"
+	Command_EventSubBatch        = "eventsubbatch"
+	Command_EventUnsubBatch      = "eventunsubbatch"
 	Command_EventReadHistory     = "eventreadhistory"
+	Command_EventListSubs        = "eventlistsubs"
+	Command_EventListAllSubs     = "eventlistallsubs"
+	Command_WhoAmI               = "whoami"
+	Command_DebugDumpRoutes      = "debugdumproutes"
+	Command_GetRecentErrors      = "getrecenterrors"
+	Command_Health               = "health"       // special (also allowed on unauthenticated connections)
+	Command_Capabilities         = "capabilities" // built in, served by the rpc adapter for every server impl
 	Command_StreamTest           = "streamtest"
 	Command_StreamWaveAi         = "streamwaveai"
+	Command_ResumeStream         = "resumestream"
 	Command_StreamCpuData        = "streamcpudata"
+	Command_GetCpuHistory        = "getcpuhistory"
+	Command_StreamGpuData        = "streamgpudata"
+	Command_StreamSensorData     = "streamsensordata"
 	Command_Test                 = "test"
+	Command_Shutdown             = "shutdown"
 	Command_SetConfig            = "setconfig"
 	Command_SetConnectionsConfig = "connectionsconfig"
 	Command_RemoteStreamFile     = "remotestreamfile"
+	Command_RemoteStreamArchive  = "remotestreamarchive"
+	Command_RemoteExtractArchive = "remoteextractarchive"
+	Command_RemoteFileCopy       = "remotefilecopy"
+	Command_RemoteFileChecksum   = "remotefilechecksum"
+	Command_RemoteTransfer       = "remotetransfer"
 	Command_RemoteFileInfo       = "remotefileinfo"
+	Command_RemoteFileStat       = "remotefilestat"
+	Command_RemoteMountInfo      = "remotemountinfo"
+	Command_RemoteStatFS         = "remotestatfs"
+	Command_RemoteKillProcess    = "remotekillprocess"
+	Command_RemoteSetReadLimit   = "remotesetreadlimit"
+	Command_RemoteListDir        = "remotelistdir"
+	Command_RemoteStreamListDir  = "remotestreamlistdir"
+	Command_RemoteWhich          = "remotewhich"
+	Command_RemoteExpandPath     = "remoteexpandpath"
+	Command_RemoteFileHeadTail   = "remotefileheadtail"
+	Command_RemoteFileLines      = "remotefilelines"
+	Command_RemoteFileDiff       = "remotefilediff"
 	Command_RemoteFileTouch      = "remotefiletouch"
 	Command_RemoteWriteFile      = "remotewritefile"
+	Command_RemoteWriteFileChunk = "remotewritefilechunk"
+	Command_RemoteFindDuplicates = "remotefindduplicates"
+	Command_RemoteClipboardGet   = "remoteclipboardget"
+	Command_RemoteClipboardSet   = "remoteclipboardset"
+	Command_RemoteMultiTail      = "remotemultitail"
+	Command_RemoteRunScript      = "remoterunscript"
 	Command_RemoteFileDelete     = "remotefiledelete"
+	Command_RemoteChmod          = "remotechmod"
 	Command_RemoteFileJoin       = "remotefilejoin"
+	Command_RemoteFileOpen       = "remotefileopen"
+	Command_RemoteFileReadAt     = "remotefilereadat"
+	Command_RemoteFileWriteAt    = "remotefilewriteat"
+	Command_RemoteFileClose      = "remotefileclose"
 	Command_WaveInfo             = "waveinfo"
 	Command_WshActivity          = "wshactivity"
 	Command_Activity             = "activity"
@@ -77,13 +141,18 @@ const (
 	Command_ConnStatus       = "connstatus"
 	Command_WslStatus        = "wslstatus"
 	Command_ConnEnsure       = "connensure"
+	Command_ConnWarmup       = "connwarmup"
 	Command_ConnReinstallWsh = "connreinstallwsh"
 	Command_ConnConnect      = "connconnect"
 	Command_ConnDisconnect   = "conndisconnect"
+	Command_ConnRename       = "connrename"
+	Command_ConnValidate     = "connvalidate"
 	Command_ConnList         = "connlist"
+	Command_ConnListPage     = "connlistpage"
 	Command_WslList          = "wsllist"
 	Command_WslDefaultDistro = "wsldefaultdistro"
 	Command_DismissWshFail   = "dismisswshfail"
+	Command_GetConnBandwidth = "getconnbandwidth"
 
 	Command_WorkspaceList = "workspacelist"
 
@@ -98,13 +167,43 @@ const (
 	Command_VDomUrlRequest      = "vdomurlrequest"
 
 	Command_AiSendMessage = "aisendmessage"
+
+	Command_StreamControllerOutput = "streamcontrolleroutput"
 )
 
+// read-only commands where identical concurrent requests can safely share a single in-flight call
+var idempotentCommands = map[string]bool{
+	Command_FileRead:       true,
+	Command_FileReadDetect: true,
+	Command_FileInfo:       true,
+	Command_RemoteFileInfo: true,
+}
+
+func IsIdempotentCommand(command string) bool {
+	return idempotentCommands[command]
+}
+
+// element of a response stream.  an element with Error set is always the final element, and every
+// response delivered before it remains valid.  PartialComplete is set when the handler itself ended
+// the stream with that error (a clean early end), it is false for transport failures (timeouts,
+// lost connections, panics) where the stream may have been cut off arbitrarily.
 type RespOrErrorUnion[T any] struct {
-	Response T
-	Error    error
+	Response        T
+	Error           error
+	PartialComplete bool
 }
 
+// sent once, before the first item, when a stream is established (unless RpcOpts.NoStreamStart is set)
+// Params holds negotiated parameters that the handler reports up front (see the StreamStartParam_ keys)
+type StreamStartData struct {
+	Params map[string]any `json:"params,omitempty"`
+}
+
+const (
+	StreamStartParam_IntervalMs = "intervalms" // effective sample interval (after defaults/clamping)
+	StreamStartParam_Size       = "size"       // total size in bytes (when known)
+)
+
 type WshRpcInterface interface {
 	AuthenticateCommand(ctx context.Context, data string) (CommandAuthenticateRtnData, error)
 	DisposeCommand(ctx context.Context, data CommandDisposeData) error
@@ -114,32 +213,65 @@ type WshRpcInterface interface {
 	MessageCommand(ctx context.Context, data CommandMessageData) error
 	GetMetaCommand(ctx context.Context, data CommandGetMetaData) (waveobj.MetaMapType, error)
 	SetMetaCommand(ctx context.Context, data CommandSetMetaData) error
+	SetMetaPatchCommand(ctx context.Context, data CommandSetMetaPatchData) error
+	SetMetaBatchCommand(ctx context.Context, data CommandSetMetaBatchData) ([]SetMetaBatchResult, error)
 	SetViewCommand(ctx context.Context, data CommandBlockSetViewData) error
+	SetViewBatchCommand(ctx context.Context, data []CommandBlockSetViewData) ([]SetViewBatchResult, error)
+	ListBlockViewsCommand(ctx context.Context, data CommandListViewsData) ([]string, error)
+	GetFocusCommand(ctx context.Context, data CommandGetFocusData) (waveobj.ORef, error)
+	SetFocusCommand(ctx context.Context, data CommandSetFocusData) error
+	GetLayoutCommand(ctx context.Context, data CommandGetLayoutData) (TabLayout, error)
+	SetLayoutCommand(ctx context.Context, data TabLayout) error
 	ControllerInputCommand(ctx context.Context, data CommandBlockInputData) error
+	BroadcastInputCommand(ctx context.Context, data CommandBroadcastInputData) (CommandBroadcastInputRtnData, error)
+	ControllerResizeCommand(ctx context.Context, data CommandControllerResizeData) (waveobj.TermSize, error)
+	ControllerStatusCommand(ctx context.Context, data CommandControllerStatusData) (ControllerStatus, error)
+	ListControllersCommand(ctx context.Context, data CommandListControllersData) ([]ControllerStatus, error)
+	StreamControllerOutputCommand(ctx context.Context, data CommandStreamOutputData) chan RespOrErrorUnion[ControllerOutputChunk]
 	ControllerStopCommand(ctx context.Context, blockId string) error
 	ControllerResyncCommand(ctx context.Context, data CommandControllerResyncData) error
 	ResolveIdsCommand(ctx context.Context, data CommandResolveIdsData) (CommandResolveIdsRtnData, error)
 	CreateBlockCommand(ctx context.Context, data CommandCreateBlockData) (waveobj.ORef, error)
+	OpenResourceCommand(ctx context.Context, data CommandOpenResourceData) (waveobj.ORef, error)
 	CreateSubBlockCommand(ctx context.Context, data CommandCreateSubBlockData) (waveobj.ORef, error)
 	DeleteBlockCommand(ctx context.Context, data CommandDeleteBlockData) error
 	DeleteSubBlockCommand(ctx context.Context, data CommandDeleteBlockData) error
+	SnapshotBlockCommand(ctx context.Context, data CommandSnapshotBlockData) (BlockSnapshot, error)
+	RestoreBlockCommand(ctx context.Context, data CommandRestoreBlockData) (waveobj.ORef, error)
 	WaitForRouteCommand(ctx context.Context, data CommandWaitForRouteData) (bool, error)
 	FileCreateCommand(ctx context.Context, data CommandFileCreateData) error
 	FileDeleteCommand(ctx context.Context, data CommandFileData) error
 	FileAppendCommand(ctx context.Context, data CommandFileData) error
-	FileAppendIJsonCommand(ctx context.Context, data CommandAppendIJsonData) error
+	FileFlushCommand(ctx context.Context, data CommandFileData) error
+	FileAppendIJsonCommand(ctx context.Context, data CommandAppendIJsonData) (CommandAppendIJsonRtnData, error)
 	FileWriteCommand(ctx context.Context, data CommandFileData) error
 	FileReadCommand(ctx context.Context, data CommandFileData) (string, error)
+	// like FileReadCommand, but also reports if the data is binary (and its detected text encoding), and can gzip the data
+	FileReadDetectCommand(ctx context.Context, data CommandFileData) (FileReadRtnData, error)
+	FileTailCommand(ctx context.Context, data CommandFileTailData) chan RespOrErrorUnion[FileReadChunk]
 	FileInfoCommand(ctx context.Context, data CommandFileData) (*WaveFileInfo, error)
 	FileListCommand(ctx context.Context, data CommandFileListData) ([]*WaveFileInfo, error)
 	EventPublishCommand(ctx context.Context, data wps.WaveEvent) error
 	EventSubCommand(ctx context.Context, data wps.SubscriptionRequest) error
 	EventUnsubCommand(ctx context.Context, data string) error
 	EventUnsubAllCommand(ctx context.Context) error
+	EventSubBatchCommand(ctx context.Context, data []wps.SubscriptionRequest) error // valid requests are registered even if others fail
+	EventUnsubBatchCommand(ctx context.Context, data []string) error
 	EventReadHistoryCommand(ctx context.Context, data CommandEventReadHistoryData) ([]*wps.WaveEvent, error)
+	// like EventReadHistoryCommand, but with a time budget (can return Truncated)
+	EventReadHistoryLimitedCommand(ctx context.Context, data CommandEventReadHistoryData) (EventReadHistoryRtnData, error)
+	EventListSubsCommand(ctx context.Context) ([]wps.SubscriptionInfo, error)    // subscriptions for the calling route
+	EventListAllSubsCommand(ctx context.Context) ([]wps.SubscriptionInfo, error) // subscriptions for all routes, operator only (local routes)
+	WhoAmICommand(ctx context.Context) (CommandWhoAmIRtnData, error)
+	DebugDumpRoutesCommand(ctx context.Context) ([]RouteInfo, error) // operator only (local routes), see IsOperatorRoute
+	GetRecentErrorsCommand(ctx context.Context, data CommandRecentErrorsData) ([]RpcErrorRecord, error)
+	HealthCommand(ctx context.Context) (HealthRtnData, error)
+	CapabilitiesCommand(ctx context.Context) (CapabilitiesRtnData, error)
 	StreamTestCommand(ctx context.Context) chan RespOrErrorUnion[int]
 	StreamWaveAiCommand(ctx context.Context, request WaveAIStreamRequest) chan RespOrErrorUnion[WaveAIPacketType]
+	ResumeStreamCommand(ctx context.Context, data CommandResumeStreamData) chan RespOrErrorUnion[WaveAIPacketType]
 	StreamCpuDataCommand(ctx context.Context, request CpuDataRequest) chan RespOrErrorUnion[TimeSeriesData]
+	GetCpuHistoryCommand(ctx context.Context, data CpuHistoryRequest) ([]TimeSeriesData, error) // retained sysinfo samples (all metrics, not just cpu)
 	TestCommand(ctx context.Context, data string) error
 	SetConfigCommand(ctx context.Context, data MetaSettingsType) error
 	SetConnectionsConfigCommand(ctx context.Context, data ConnConfigRequest) error
@@ -155,27 +287,63 @@ type WshRpcInterface interface {
 	ConnStatusCommand(ctx context.Context) ([]ConnStatus, error)
 	WslStatusCommand(ctx context.Context) ([]ConnStatus, error)
 	ConnEnsureCommand(ctx context.Context, connName string) error
+	ConnWarmupCommand(ctx context.Context, connName string) error
 	ConnReinstallWshCommand(ctx context.Context, connName string) error
 	ConnConnectCommand(ctx context.Context, connRequest ConnRequest) error
 	ConnDisconnectCommand(ctx context.Context, connName string) error
+	ConnRenameCommand(ctx context.Context, data CommandConnRenameData) error
+	ConnValidateCommand(ctx context.Context, data CommandConnValidateData) (ConnValidateRtnData, error)
 	ConnListCommand(ctx context.Context) ([]string, error)
+	ConnListPageCommand(ctx context.Context, data PageOpts) (StringPage, error)
 	WslListCommand(ctx context.Context) ([]string, error)
 	WslDefaultDistroCommand(ctx context.Context) (string, error)
 	DismissWshFailCommand(ctx context.Context, connName string) error
+	GetConnBandwidthCommand(ctx context.Context, connName string) (ConnBandwidth, error)
 
 	// eventrecv is special, it's handled internally by WshRpc with EventListener
 	EventRecvCommand(ctx context.Context, data wps.WaveEvent) error
 
 	// remotes
 	RemoteStreamFileCommand(ctx context.Context, data CommandRemoteStreamFileData) chan RespOrErrorUnion[CommandRemoteStreamFileRtnData]
+	RemoteStreamArchiveCommand(ctx context.Context, data CommandRemoteArchiveData) chan RespOrErrorUnion[ArchiveChunk] // tar(.gz) of a directory tree
+	RemoteExtractArchiveCommand(ctx context.Context, data CommandRemoteExtractData) (CommandRemoteExtractRtnData, error)
+	RemoteTransferCommand(ctx context.Context, data CommandRemoteTransferData) chan RespOrErrorUnion[RemoteTransferProgress] // runs on wavesrv, copies a file between connections
 	RemoteFileInfoCommand(ctx context.Context, path string) (*FileInfo, error)
+	RemoteMountInfoCommand(ctx context.Context, data CommandRemoteMountData) (MountInfo, error)
+	RemoteStatFSCommand(ctx context.Context, data CommandRemoteStatFSData) (StatFSRtnData, error)
+	RemoteKillProcessCommand(ctx context.Context, data CommandRemoteKillData) error                 // operator only (requests from the wave app side)
+	RemoteSetReadLimitCommand(ctx context.Context, limit int64) error                               // operator only, bytes/sec shared by all file streams (0 is unlimited)
+	RemoteFileStatCommand(ctx context.Context, data CommandRemoteFileStatData) ([]*FileInfo, error) // batch fileinfo
+	RemoteListDirCommand(ctx context.Context, data CommandRemoteListDirData) (FileInfoPage, error)
+	RemoteStreamListDirCommand(ctx context.Context, data CommandRemoteListDirData) chan RespOrErrorUnion[ListDirChunk] // unsorted, for very large directories
+	RemoteFindDuplicatesCommand(ctx context.Context, data CommandRemoteDedupData) chan RespOrErrorUnion[DuplicateGroup]
+	RemoteWhichCommand(ctx context.Context, data CommandRemoteWhichData) ([]string, error)
+	RemoteExpandPathCommand(ctx context.Context, data CommandRemoteExpandData) ([]string, error)
+	RemoteFileHeadTailCommand(ctx context.Context, data CommandRemoteHeadTailData) ([]string, error)
+	RemoteMultiTailCommand(ctx context.Context, data CommandRemoteMultiTailData) chan RespOrErrorUnion[MultiTailEvent] // follows files until cancelled
+	RemoteFileLinesCommand(ctx context.Context, data CommandRemoteFileLinesData) (FileLinesRtnData, error)
+	RemoteFileDiffCommand(ctx context.Context, data CommandRemoteDiffData) (CommandRemoteDiffRtnData, error)
 	RemoteFileTouchCommand(ctx context.Context, path string) error
-	RemoteFileRenameCommand(ctx context.Context, pathTuple [2]string) error
-	RemoteFileDeleteCommand(ctx context.Context, path string) error
+	RemoteFileRenameCommand(ctx context.Context, data CommandRemoteFileRenameData) (FileOpPreview, error) // the preview is only filled in for DryRun
+	RemoteFileCopyCommand(ctx context.Context, data CommandRemoteFileCopyData) (FileOpPreview, error)
+	RemoteFileChecksumCommand(ctx context.Context, path string) (string, error)
+	RemoteFileDeleteCommand(ctx context.Context, data CommandRemoteFileDeleteData) (FileOpPreview, error)
+	RemoteChmodCommand(ctx context.Context, data CommandRemoteChmodData) (FileOpPreview, error)
 	RemoteWriteFileCommand(ctx context.Context, data CommandRemoteWriteFileData) error
+	RemoteWriteFileChunkCommand(ctx context.Context, data CommandRemoteWriteChunkData) (RemoteWriteChunkAck, error) // see RunChunkedWrite
 	RemoteFileJoinCommand(ctx context.Context, paths []string) (*FileInfo, error)
-	RemoteMkdirCommand(ctx context.Context, path string) error
+	RemoteFileOpenCommand(ctx context.Context, data CommandRemoteFileOpenData) (RemoteFileOpenRtnData, error) // handles are owned by the calling route
+	RemoteFileReadAtCommand(ctx context.Context, data CommandRemoteFileReadAtData) (RemoteFileReadAtRtnData, error)
+	RemoteFileWriteAtCommand(ctx context.Context, data CommandRemoteFileWriteAtData) error
+	RemoteFileCloseCommand(ctx context.Context, handle string) error
+	RemoteMkdirCommand(ctx context.Context, data CommandRemoteMkdirData) error
+	RemoteClipboardGetCommand(ctx context.Context, connName string) (string, error)       // route to the connection, operator only (requests from the wave app side)
+	RemoteClipboardSetCommand(ctx context.Context, data CommandRemoteClipboardData) error // route to the connection, operator only
+	RemoteRunScriptCommand(ctx context.Context, data CommandRemoteScriptData) chan RespOrErrorUnion[ExecOutputChunk]
 	RemoteStreamCpuDataCommand(ctx context.Context) chan RespOrErrorUnion[TimeSeriesData]
+	StreamGpuDataCommand(ctx context.Context, request GpuDataRequest) chan RespOrErrorUnion[TimeSeriesData]       // route to the connection, keys are gpu:<idx>:<metric>
+	StreamSensorDataCommand(ctx context.Context, request SensorDataRequest) chan RespOrErrorUnion[TimeSeriesData] // route to the connection, ends with a "sensors_unsupported" error where sensors are unsupported
+	ShutdownCommand(ctx context.Context, data CommandShutdownData) error                                          // route to the connection, operator only (requests from the wave app side)
 
 	// emain
 	WebSelectorCommand(ctx context.Context, data CommandWebSelectorData) ([]string, error)
@@ -203,11 +371,16 @@ type WshServerCommandMeta struct {
 }
 
 type RpcOpts struct {
-	Timeout    int    `json:"timeout,omitempty"`
-	NoResponse bool   `json:"noresponse,omitempty"`
-	Route      string `json:"route,omitempty"`
-
-	StreamCancelFn func() `json:"-"` // this is an *output* parameter, set by the handler
+	Timeout    int               `json:"timeout,omitempty"`
+	NoResponse bool              `json:"noresponse,omitempty"`
+	Route      string            `json:"route,omitempty"`
+	Meta       map[string]string `json:"meta,omitempty"` // per-request metadata (merged over RpcContext.Meta)
+	Deadline   time.Time         `json:"-"`              // overall deadline shared across calls, bounds Timeout (see GetTimeoutMs)
+
+	NoStreamStart  bool                       `json:"nostreamstart,omitempty"` // don't request a stream start ack
+	DefaultContext *RpcContext                `json:"-"`                       // fills zero-valued `wshdefault` fields in the call data (see ApplyRpcContextDefaults)
+	OnStreamStart  func(data StreamStartData) `json:"-"`                       // called with the start ack (streaming commands only)
+	StreamCancelFn func()                     `json:"-"`                       // this is an *output* parameter, set by the handler
 }
 
 const (
@@ -216,25 +389,103 @@ const (
 )
 
 type RpcContext struct {
-	ClientType string `json:"ctype,omitempty"`
-	BlockId    string `json:"blockid,omitempty"`
-	TabId      string `json:"tabid,omitempty"`
-	Conn       string `json:"conn,omitempty"`
+	ClientType string            `json:"ctype,omitempty"`
+	BlockId    string            `json:"blockid,omitempty"`
+	TabId      string            `json:"tabid,omitempty"`
+	Conn       string            `json:"conn,omitempty"`
+	Meta       map[string]string `json:"meta,omitempty"` // app-specific metadata (request id, locale, etc.), not injected by HackRpcContextIntoData
+}
+
+const (
+	MaxRpcMetaKeys   = 32
+	MaxRpcMetaKeyLen = 64
+	MaxRpcMetaValLen = 1024
+)
+
+// well-known rpc meta keys
+const (
+	RpcMeta_NoCache = "nocache" // "1" bypasses server-side caches (e.g. the remote fileinfo cache) for commands without a NoCache field
+)
+
+func ValidateRpcMeta(meta map[string]string) error {
+	if len(meta) > MaxRpcMetaKeys {
+		return fmt.Errorf("rpc meta has too many keys (%d), max is %d", len(meta), MaxRpcMetaKeys)
+	}
+	for key, val := range meta {
+		if key == "" || len(key) > MaxRpcMetaKeyLen {
+			return fmt.Errorf("invalid rpc meta key %q (must be 1-%d chars)", key, MaxRpcMetaKeyLen)
+		}
+		if len(val) > MaxRpcMetaValLen {
+			return fmt.Errorf("rpc meta value for %q is too large (%d), max is %d", key, len(val), MaxRpcMetaValLen)
+		}
+	}
+	return nil
+}
+
+// returns a new map with the values of overrideMeta merged on top of baseMeta (nil if both are empty)
+func MergeRpcMeta(baseMeta map[string]string, overrideMeta map[string]string) map[string]string {
+	if len(baseMeta) == 0 && len(overrideMeta) == 0 {
+		return nil
+	}
+	rtn := make(map[string]string, len(baseMeta)+len(overrideMeta))
+	for key, val := range baseMeta {
+		rtn[key] = val
+	}
+	for key, val := range overrideMeta {
+		rtn[key] = val
+	}
+	return rtn
 }
 
+// fills in zero-valued fields tagged with `wshcontext:"..."` from the rpc context (recurses into embedded structs)
+// valid tags: BlockId, TabId, Conn, ClientType, BlockORef, TabORef
 func HackRpcContextIntoData(dataPtr any, rpcContext RpcContext) {
-	dataVal := reflect.ValueOf(dataPtr).Elem()
+	fillRpcContextIntoData("wshcontext", dataPtr, rpcContext)
+}
+
+// client side, fills in zero-valued fields tagged with `wshdefault:"..."` from a caller-bound default context
+// (RpcOpts.DefaultContext).  explicitly set fields always win.  uses the same tag values as wshcontext.
+// a separate tag because the server fills wshcontext fields from the *caller's* context, where an
+// empty Conn must keep meaning the local connection.
+func ApplyRpcContextDefaults(dataPtr any, defaults RpcContext) {
+	fillRpcContextIntoData("wshdefault", dataPtr, defaults)
+}
+
+func fillRpcContextIntoData(tagName string, dataPtr any, rpcContext RpcContext) {
+	ptrVal := reflect.ValueOf(dataPtr)
+	if ptrVal.Kind() != reflect.Pointer || ptrVal.IsNil() {
+		if wavebase.IsDevMode() {
+			log.Printf("%s: expected non-nil pointer, got %T\n", tagName, dataPtr)
+		}
+		return
+	}
+	dataVal := ptrVal.Elem()
 	if dataVal.Kind() != reflect.Struct {
+		if wavebase.IsDevMode() {
+			log.Printf("%s: expected pointer to struct, got %T\n", tagName, dataPtr)
+		}
 		return
 	}
+	hackRpcContextIntoStruct(tagName, dataVal, rpcContext, dataPtr)
+}
+
+func hackRpcContextIntoStruct(tagName string, dataVal reflect.Value, rpcContext RpcContext, dataPtr any) {
 	dataType := dataVal.Type()
 	for i := 0; i < dataVal.NumField(); i++ {
 		field := dataVal.Field(i)
-		if !field.IsZero() {
+		fieldType := dataType.Field(i)
+		if fieldType.Anonymous {
+			if field.Kind() == reflect.Struct {
+				hackRpcContextIntoStruct(tagName, field, rpcContext, dataPtr)
+			} else if field.Kind() == reflect.Pointer && !field.IsNil() && field.Elem().Kind() == reflect.Struct {
+				hackRpcContextIntoStruct(tagName, field.Elem(), rpcContext, dataPtr)
+			}
 			continue
 		}
-		fieldType := dataType.Field(i)
-		tag := fieldType.Tag.Get("wshcontext")
+		if !field.IsZero() || !field.CanSet() {
+			continue
+		}
+		tag := fieldType.Tag.Get(tagName)
 		if tag == "" {
 			continue
 		}
@@ -243,19 +494,33 @@ func HackRpcContextIntoData(dataPtr any, rpcContext RpcContext) {
 			field.SetString(rpcContext.BlockId)
 		case "TabId":
 			field.SetString(rpcContext.TabId)
+		case "Conn":
+			field.SetString(rpcContext.Conn)
+		case "ClientType":
+			field.SetString(rpcContext.ClientType)
 		case "BlockORef":
 			if rpcContext.BlockId != "" {
 				field.Set(reflect.ValueOf(waveobj.MakeORef(waveobj.OType_Block, rpcContext.BlockId)))
 			}
+		case "TabORef":
+			if rpcContext.TabId != "" {
+				field.Set(reflect.ValueOf(waveobj.MakeORef(waveobj.OType_Tab, rpcContext.TabId)))
+			}
 		default:
-			log.Printf("invalid wshcontext tag: %q in type(%T)", tag, dataPtr)
+			log.Printf("invalid %s tag: %q in type(%T)", tagName, tag, dataPtr)
 		}
 	}
 }
 
+type CommandWhoAmIRtnData struct {
+	RouteId    string     `json:"routeid"`
+	RpcContext RpcContext `json:"rpccontext"`
+}
+
 type CommandAuthenticateRtnData struct {
 	RouteId   string `json:"routeid"`
 	AuthToken string `json:"authtoken,omitempty"`
+	SignKey   string `json:"signkey,omitempty"` // frame sign key for a proxied client that requested signing (see wshutil.RpcFrameSigner)
 }
 
 type CommandDisposeData struct {
@@ -269,12 +534,36 @@ type CommandMessageData struct {
 }
 
 type CommandGetMetaData struct {
-	ORef waveobj.ORef `json:"oref" wshcontext:"BlockORef"`
+	ORef     waveobj.ORef `json:"oref" wshcontext:"BlockORef"`
+	Keys     []string     `json:"keys,omitempty"`     // dotted keys select nested paths, empty returns all keys
+	MaxDepth int          `json:"maxdepth,omitempty"` // omits nested maps deeper than this (0 is unlimited)
+
+	// selects the top-level keys starting with Prefix (e.g. "term:") instead of Keys (set one or the other)
+	Prefix      string `json:"prefix,omitempty"`
+	StripPrefix bool   `json:"stripprefix,omitempty"` // remove Prefix from the returned keys
 }
 
 type CommandSetMetaData struct {
-	ORef waveobj.ORef        `json:"oref" wshcontext:"BlockORef"`
-	Meta waveobj.MetaMapType `json:"meta"`
+	ORef    waveobj.ORef        `json:"oref" wshcontext:"BlockORef"`
+	Meta    waveobj.MetaMapType `json:"meta"`
+	Version int                 `json:"version,omitempty"` // if set, the update fails unless the object is still at this version
+}
+
+type CommandSetMetaPatchData struct {
+	ORef    waveobj.ORef  `json:"oref" wshcontext:"BlockORef"`
+	Command ijson.Command `json:"command"`
+	Version int           `json:"version,omitempty"` // if set, the patch fails unless the object is still at this version
+}
+
+type CommandSetMetaBatchData struct {
+	Items        []CommandSetMetaData `json:"items"`
+	AllOrNothing bool                 `json:"allornothing,omitempty"` // any failure rolls back the whole batch and fails the call
+}
+
+// one result per item, in order (Error is empty on success)
+type SetMetaBatchResult struct {
+	ORef  waveobj.ORef `json:"oref"`
+	Error string       `json:"error,omitempty"`
 }
 
 type CommandResolveIdsData struct {
@@ -292,6 +581,17 @@ type CommandCreateBlockData struct {
 	RtOpts    *waveobj.RuntimeOpts `json:"rtopts,omitempty"`
 	Magnified bool                 `json:"magnified,omitempty"`
 	Ephemeral bool                 `json:"ephemeral,omitempty"`
+	InitFiles []CommandFileData    `json:"initfiles,omitempty"` // written before the block is inserted into the layout (zoneid is ignored)
+	Vars      map[string]string    `json:"vars,omitempty"`      // resolves ${name} in blockdef meta (only when set, use $${name} for a literal)
+}
+
+// opens either a url (http/https in a web block, file:// on the local machine) or a path on conn
+type CommandOpenResourceData struct {
+	TabId     string `json:"tabid" wshcontext:"TabId"`
+	Url       string `json:"url,omitempty"`
+	Conn      string `json:"conn,omitempty" wshdefault:"Conn"` // defaults to local
+	Path      string `json:"path,omitempty"`
+	Magnified bool   `json:"magnified,omitempty"`
 }
 
 type CommandCreateSubBlockData struct {
@@ -299,11 +599,74 @@ type CommandCreateSubBlockData struct {
 	BlockDef      *waveobj.BlockDef `json:"blockdef"`
 }
 
+type CommandSnapshotBlockData struct {
+	BlockId       string `json:"blockid" wshcontext:"BlockId"`
+	MaxInlineSize int64  `json:"maxinlinesize,omitempty"` // files larger than this are included by reference, defaults to 256k
+}
+
+const BlockSnapshotVersion = 1
+
+// portable copy of a block's state (subblocks and stickers are not included)
+type BlockSnapshot struct {
+	Version     int                  `json:"version"`
+	BlockId     string               `json:"blockid"` // source block
+	Meta        waveobj.MetaMapType  `json:"meta"`
+	RuntimeOpts *waveobj.RuntimeOpts `json:"runtimeopts,omitempty"`
+	Files       []BlockSnapshotFile  `json:"files,omitempty"`
+}
+
+type BlockSnapshotFile struct {
+	Name   string                 `json:"name"`
+	Size   int64                  `json:"size"`
+	Meta   filestore.FileMeta     `json:"meta,omitempty"`
+	Opts   filestore.FileOptsType `json:"opts,omitempty"`
+	Data64 string                 `json:"data64,omitempty" wshlog:"redact"`
+	ByRef  bool                   `json:"byref,omitempty"` // contents not inlined, read (FileRead) or copied from the source block's file
+}
+
+type CommandRestoreBlockData struct {
+	TabId     string        `json:"tabid" wshcontext:"TabId"`
+	Snapshot  BlockSnapshot `json:"snapshot"`
+	Magnified bool          `json:"magnified,omitempty"`
+}
+
 type CommandBlockSetViewData struct {
 	BlockId string `json:"blockid" wshcontext:"BlockId"`
 	View    string `json:"view"`
 }
 
+type CommandGetFocusData struct {
+	TabId string `json:"tabid" wshcontext:"TabId"`
+}
+
+type CommandSetFocusData struct {
+	TabId   string `json:"tabid" wshcontext:"TabId"`
+	BlockId string `json:"blockid"`
+}
+
+type CommandGetLayoutData struct {
+	TabId string `json:"tabid" wshcontext:"TabId"`
+}
+
+// returned by GetLayoutCommand and accepted as is by SetLayoutCommand.  RootNode is nil until the frontend
+// has laid out the tab, node sizes are flex weights relative to their siblings.
+type TabLayout struct {
+	TabId            string                 `json:"tabid" wshcontext:"TabId"`
+	RootNode         *waveobj.TabLayoutNode `json:"rootnode,omitempty"`
+	FocusedBlockId   string                 `json:"focusedblockid,omitempty"`
+	MagnifiedBlockId string                 `json:"magnifiedblockid,omitempty"`
+}
+
+// one result per item, in order (Error is empty on success)
+type SetViewBatchResult struct {
+	BlockId string `json:"blockid"`
+	Error   string `json:"error,omitempty"` // starts with ErrPrefix_InvalidView for a view the block doesn't support
+}
+
+type CommandListViewsData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+}
+
 type CommandControllerResyncData struct {
 	ForceRestart bool                 `json:"forcerestart,omitempty"`
 	TabId        string               `json:"tabid" wshcontext:"TabId"`
@@ -313,11 +676,61 @@ type CommandControllerResyncData struct {
 
 type CommandBlockInputData struct {
 	BlockId     string            `json:"blockid" wshcontext:"BlockId"`
-	InputData64 string            `json:"inputdata64,omitempty"`
+	InputData64 string            `json:"inputdata64,omitempty" wshlog:"redact"`
+	SigName     string            `json:"signame,omitempty"`
+	TermSize    *waveobj.TermSize `json:"termsize,omitempty"`
+}
+
+type CommandControllerResizeData struct {
+	BlockId  string           `json:"blockid" wshcontext:"BlockId"`
+	TermSize waveobj.TermSize `json:"termsize"`
+}
+
+type CommandControllerStatusData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+}
+
+// controllers for remote connections run in wavesrv too, so they are included (ConnName is set, Pid is not)
+type CommandListControllersData struct {
+	TabId       string `json:"tabid,omitempty"` // empty lists the controllers in every tab
+	RunningOnly bool   `json:"runningonly,omitempty"`
+}
+
+type ControllerStatus struct {
+	BlockId  string `json:"blockid"`
+	TabId    string `json:"tabid,omitempty"`
+	Status   string `json:"status"` // init, running, done
+	Running  bool   `json:"running"`
+	Pid      int    `json:"pid,omitempty"` // only set for local processes
+	CmdLine  string `json:"cmdline,omitempty"`
+	ConnName string `json:"connname,omitempty"`
+	StartTs  int64  `json:"startts,omitempty"`
+	ExitCode int    `json:"exitcode"`
+}
+
+type CommandStreamOutputData struct {
+	BlockId       string `json:"blockid" wshcontext:"BlockId"`
+	BackfillBytes int64  `json:"backfillbytes,omitempty"` // send up to this many bytes of existing output first
+}
+
+type ControllerOutputChunk struct {
+	Data64   string `json:"data64" wshlog:"redact"`
+	Backfill bool   `json:"backfill,omitempty"`
+}
+
+type CommandBroadcastInputData struct {
+	BlockIds    []string          `json:"blockids,omitempty"`
+	TabId       string            `json:"tabid,omitempty"` // if set, only blocks in this tab receive input (all of its blocks if BlockIds is empty)
+	InputData64 string            `json:"inputdata64,omitempty" wshlog:"redact"`
 	SigName     string            `json:"signame,omitempty"`
 	TermSize    *waveobj.TermSize `json:"termsize,omitempty"`
 }
 
+type CommandBroadcastInputRtnData struct {
+	SentBlockIds []string          `json:"sentblockids,omitempty"`
+	Errors       map[string]string `json:"errors,omitempty"` // blockid => error (including blocks skipped because they aren't in TabId)
+}
+
 type CommandFileDataAt struct {
 	Offset int64 `json:"offset"`
 	Size   int64 `json:"size,omitempty"`
@@ -326,10 +739,54 @@ type CommandFileDataAt struct {
 type CommandFileData struct {
 	ZoneId   string             `json:"zoneid" wshcontext:"BlockId"`
 	FileName string             `json:"filename"`
-	Data64   string             `json:"data64,omitempty"`
-	At       *CommandFileDataAt `json:"at,omitempty"` // if set, this turns read/write ops to ReadAt/WriteAt ops (len is only used for ReadAt)
+	Data64   string             `json:"data64,omitempty" wshlog:"redact"`
+	At       *CommandFileDataAt `json:"at,omitempty"`       // if set, this turns read/write ops to ReadAt/WriteAt ops (len is only used for ReadAt)
+	MaxSize  int64              `json:"maxsize,omitempty"`  // appends only, if the file grows past this it is trimmed or rotated
+	SizeMode string             `json:"sizemode,omitempty"` // FileSizeMode_* (defaults to trimfront)
+
+	// filereaddetect only, if set to FileEncoding_Gzip the result may be gzipped (FileReadRtnData.DataEncoding)
+	Encoding         string `json:"encoding,omitempty"`
+	CompressMinSize  int64  `json:"compressminsize,omitempty"`  // skip compression below this size (defaults to DefaultCompressMinSize)
+	DetectSampleSize int    `json:"detectsamplesize,omitempty"` // filereaddetect only, bytes sampled for binary/text detection (defaults to DefaultTextDetectSampleSize)
+	BinaryMaxSize    int64  `json:"binarymaxsize,omitempty"`    // filereaddetect only, if set binary files only return this many bytes from the head (Truncated)
+
+	// writes only, the write fails with a PreconditionFailedError unless the file's current modts/size match
+	IfModTime *int64 `json:"ifmodtime,omitempty"`
+	IfSize    *int64 `json:"ifsize,omitempty"`
+}
+
+type FileReadRtnData struct {
+	Data64       string `json:"data64" wshlog:"redact"`
+	DataEncoding string `json:"dataencoding,omitempty"` // FileEncoding_Gzip if Data64 is gzipped, see DecodeFileReadData
+	Binary       bool   `json:"binary,omitempty"`
+	Encoding     string `json:"encoding,omitempty"`  // detected text encoding, TextEncoding_* (empty for binary data)
+	Truncated    bool   `json:"truncated,omitempty"` // only the head was returned (BinaryMaxSize)
+}
+
+type CommandFileTailData struct {
+	ZoneId        string `json:"zoneid" wshcontext:"BlockId"`
+	FileName      string `json:"filename"`
+	BackfillBytes int64  `json:"backfillbytes,omitempty"` // existing data sent first (defaults to DefaultFileTailBackfillBytes)
+	BackfillLines int    `json:"backfilllines,omitempty"` // if set, the backfill is cut to the last N lines (within BackfillBytes)
+}
+
+type FileReadChunk struct {
+	Data64   string `json:"data64,omitempty" wshlog:"redact"`
+	Offset   int64  `json:"offset"` // file offset of the first byte of Data64
+	Backfill bool   `json:"backfill,omitempty"`
+	Reset    bool   `json:"reset,omitempty"` // the file was truncated, deleted or rewritten, discard prior data (a new backfill follows if it has data)
 }
 
+const (
+	DefaultFileTailBackfillBytes = 64 * 1024
+	MaxFileTailBackfillBytes     = 8 * 1024 * 1024
+)
+
+const (
+	FileSizeMode_TrimFront = "trimfront" // drop data from the front of the file (at a line boundary)
+	FileSizeMode_Rotate    = "rotate"    // move the file to <filename>.1 and start a new empty file
+)
+
 type WaveFileInfo struct {
 	ZoneId    string                 `json:"zoneid"`
 	Name      string                 `json:"name"`
@@ -356,10 +813,80 @@ type CommandFileCreateData struct {
 	Opts     *filestore.FileOptsType `json:"opts,omitempty"`
 }
 
+const (
+	HealthStatus_Ok       = "ok"
+	HealthStatus_Starting = "starting"
+)
+
+// returned by DebugDumpRoutesCommand
+type RouteInfo struct {
+	RouteId        string `json:"routeid"`
+	ClientType     string `json:"ctype,omitempty"`
+	Conn           string `json:"conn,omitempty"`
+	BlockId        string `json:"blockid,omitempty"`
+	Announced      bool   `json:"announced,omitempty"` // reached through ViaRouteId (e.g. a remote wsh behind a connection)
+	ViaRouteId     string `json:"viarouteid,omitempty"`
+	NumSubs        int    `json:"numsubs"`
+	LastActivityTs int64  `json:"lastactivityts,omitempty"` // last message received from the route (from ViaRouteId for announced routes)
+}
+
+type CommandRecentErrorsData struct {
+	RouteId string `json:"routeid,omitempty"` // defaults to the caller's route, other routes are operator only
+	Limit   int    `json:"limit,omitempty"`   // most recent N, defaults to all that are kept (see wshutil.MaxRecentErrors)
+}
+
+const (
+	RpcErrorCode_Error         = "error"
+	RpcErrorCode_NoRoute       = "noroute"
+	RpcErrorCode_StreamPartial = "streampartial" // the handler ended a stream with an error (see StreamPartialError)
+)
+
+// an error response returned to a route, Code is the "EC-" prefix of the message when it has one
+type RpcErrorRecord struct {
+	Ts      int64  `json:"ts"`
+	Command string `json:"command"`
+	Route   string `json:"route"` // where the request was sent
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+type HealthRtnData struct {
+	Status        string `json:"status"`
+	UptimeMs      int64  `json:"uptimems"`
+	Version       string `json:"version"`
+	NumRoutes     int    `json:"numroutes"`
+	ActiveStreams int    `json:"activestreams"` // in-flight routed rpcs
+}
+
+// bump on incompatible wire protocol changes
+const WshRpcProtocolVersion = 1
+
+const RpcCodec_Json = "json"
+
+// feature flags for CapabilitiesRtnData
+const (
+	RpcFeature_Compression = "compression" // gzip filereaddetect results (FileEncoding_Gzip)
+	RpcFeature_StreamStart = "streamstart" // stream start acks (StreamStartData)
+)
+
+type CapabilitiesRtnData struct {
+	Version         string   `json:"version"`
+	ProtocolVersion int      `json:"protocolversion"`
+	Commands        []string `json:"commands"` // sorted, derived from the server impl's handlers
+	Features        []string `json:"features,omitempty"`
+	Codec           string   `json:"codec"`
+	MaxMessageSize  int      `json:"maxmessagesize"`
+}
+
 type CommandAppendIJsonData struct {
-	ZoneId   string        `json:"zoneid" wshcontext:"BlockId"`
-	FileName string        `json:"filename"`
-	Data     ijson.Command `json:"data"`
+	ZoneId          string        `json:"zoneid" wshcontext:"BlockId"`
+	FileName        string        `json:"filename"`
+	Data            ijson.Command `json:"data"`
+	ExpectedVersion *int64        `json:"expectedversion,omitempty"` // if set, the append fails unless the document is at this version
+}
+
+type CommandAppendIJsonRtnData struct {
+	Version int64 `json:"version"`
 }
 
 type CommandWaitForRouteData struct {
@@ -377,50 +904,136 @@ type CommandEventReadHistoryData struct {
 	MaxItems int    `json:"maxitems"`
 }
 
+type EventReadHistoryRtnData struct {
+	Events    []*wps.WaveEvent `json:"events"`              // oldest first
+	Truncated bool             `json:"truncated,omitempty"` // the read ran out of time, Events holds the newest events gathered
+}
+
 type WaveAIStreamRequest struct {
 	ClientId string                    `json:"clientid,omitempty"`
 	Opts     *WaveAIOptsType           `json:"opts"`
 	Prompt   []WaveAIPromptMessageType `json:"prompt"`
+	Debug    bool                      `json:"debug,omitempty"`  // also send the provider's raw response lines as "raw" packets (http backends only)
+	DryRun   bool                      `json:"dryrun,omitempty"` // validate the prompt against the model and return a single "dryrun" packet, nothing is sent to the provider
+
+	// server-side history (the default is stateless, Prompt is the whole conversation).  Conversation starts a
+	// new one, its id is sent in a "conversation" packet.  with ConversationId, Prompt holds only the new messages.
+	Conversation      bool   `json:"conversation,omitempty"`
+	ConversationId    string `json:"conversationid,omitempty"`
+	ResetConversation bool   `json:"resetconversation,omitempty"` // clears ConversationId's history before adding Prompt
 }
 
 type WaveAIPromptMessageType struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-	Name    string `json:"name,omitempty"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Name      string `json:"name,omitempty"`
+	CacheHint bool   `json:"cachehint,omitempty"` // the prompt up to and including this message is stable and may be cached by the provider (ignored by providers without caching)
 }
 
 type WaveAIOptsType struct {
-	Model      string `json:"model"`
-	APIType    string `json:"apitype,omitempty"`
-	APIToken   string `json:"apitoken"`
-	OrgID      string `json:"orgid,omitempty"`
-	APIVersion string `json:"apiversion,omitempty"`
-	BaseURL    string `json:"baseurl,omitempty"`
-	MaxTokens  int    `json:"maxtokens,omitempty"`
-	MaxChoices int    `json:"maxchoices,omitempty"`
-	TimeoutMs  int    `json:"timeoutms,omitempty"`
+	Model       string `json:"model"`
+	APIType     string `json:"apitype,omitempty"`
+	APIToken    string `json:"apitoken" wshlog:"redact"`
+	OrgID       string `json:"orgid,omitempty"`
+	APIVersion  string `json:"apiversion,omitempty"`
+	BaseURL     string `json:"baseurl,omitempty"`
+	MaxTokens   int    `json:"maxtokens,omitempty"`
+	MaxChoices  int    `json:"maxchoices,omitempty"`
+	TimeoutMs   int    `json:"timeoutms,omitempty"`
+	Stream      *bool  `json:"stream,omitempty"`      // nil auto-detects, false forces a single blocking request
+	KeepAliveMs int    `json:"keepalivems,omitempty"` // ping packets during quiet periods, 0 uses the default (15s), negative disables
+
+	MarkdownBlocks bool `json:"markdownblocks,omitempty"` // buffer text so packets end on markdown block boundaries (see Partial)
 }
 
 type WaveAIPacketType struct {
-	Type         string           `json:"type"`
-	Model        string           `json:"model,omitempty"`
-	Created      int64            `json:"created,omitempty"`
-	FinishReason string           `json:"finish_reason,omitempty"`
-	Usage        *WaveAIUsageType `json:"usage,omitempty"`
-	Index        int              `json:"index,omitempty"`
-	Text         string           `json:"text,omitempty"`
-	Error        string           `json:"error,omitempty"`
+	Type           string            `json:"type"`
+	Model          string            `json:"model,omitempty"`
+	Created        int64             `json:"created,omitempty"`
+	FinishReason   string            `json:"finish_reason,omitempty"`
+	Usage          *WaveAIUsageType  `json:"usage,omitempty"`
+	Index          int               `json:"index,omitempty"`
+	Text           string            `json:"text,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Partial        bool              `json:"partial,omitempty"` // markdownblocks mode only, Text is a trailing incomplete block (flushed at the end)
+	Quota          *WaveAIQuotaType  `json:"quota,omitempty"`   // sent once (before any text) when the provider reports rate limits
+	DryRun         *WaveAIDryRunType `json:"dryrun,omitempty"`
+	ConversationId string            `json:"conversationid,omitempty"` // "conversation" packets only
+	RequestId      string            `json:"requestid,omitempty"`      // "conversation" packets only, see ResumeStreamCommand
+	Seq            int               `json:"seq,omitempty"`            // packet number in a conversation turn (keepalive pings have none)
+}
+
+// resumes a conversation turn after a disconnect, AfterSeq is the last Seq the client received
+type CommandResumeStreamData struct {
+	RequestId string `json:"requestid"`
+	AfterSeq  int    `json:"afterseq,omitempty"`
+}
+
+// the result of a dry-run request.  token counts are estimates, ContextWindow is 0 for unknown models (FitsContext is then assumed)
+type WaveAIDryRunType struct {
+	Valid         bool     `json:"valid"`
+	FitsContext   bool     `json:"fitscontext"`
+	PromptTokens  int      `json:"prompttokens"`
+	MaxTokens     int      `json:"maxtokens,omitempty"` // tokens reserved for the response
+	ContextWindow int      `json:"contextwindow,omitempty"`
+	Unsupported   []string `json:"unsupported,omitempty"` // prompt features the model doesn't support
+}
+
+// normalized from the provider's rate-limit headers, fields the provider doesn't report are omitted
+type WaveAIQuotaType struct {
+	RemainingRequests *int  `json:"remainingrequests,omitempty"`
+	RemainingTokens   *int  `json:"remainingtokens,omitempty"`
+	ResetTs           int64 `json:"resetts,omitempty"` // earliest time (unix ms) a limit resets
 }
 
 type WaveAIUsageType struct {
-	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	PromptTokens     int `json:"prompt_tokens,omitempty"` // includes CachedTokens
 	CompletionTokens int `json:"completion_tokens,omitempty"`
 	TotalTokens      int `json:"total_tokens,omitempty"`
+	CachedTokens     int `json:"cached_tokens,omitempty"` // prompt tokens read from the provider's prompt cache
 }
 
 type CpuDataRequest struct {
-	Id    string `json:"id"`
-	Count int    `json:"count"`
+	Id         string          `json:"id"`
+	Count      int             `json:"count"`
+	Conn       string          `json:"conn,omitempty" wshdefault:"Conn"` // defaults to the local connection
+	Downsample *DownsampleSpec `json:"downsample,omitempty"`             // if not set, raw samples are streamed
+}
+
+type CpuHistoryRequest struct {
+	Conn     string `json:"conn,omitempty" wshdefault:"Conn"` // defaults to the local connection
+	SinceTs  int64  `json:"sincets,omitempty"`                // only samples newer than this
+	MaxItems int    `json:"maxitems,omitempty"`               // newest N samples, 0 for all retained samples
+}
+
+type GpuDataRequest struct {
+	IntervalMs int             `json:"intervalms,omitempty"` // defaults to 2000 (sampling runs nvidia-smi), min 500
+	Downsample *DownsampleSpec `json:"downsample,omitempty"` // if not set, raw samples are streamed
+}
+
+type SensorDataRequest struct {
+	IntervalMs int             `json:"intervalms,omitempty"` // defaults to 2000, min 500
+	Sensors    []string        `json:"sensors,omitempty"`    // keys or key prefixes (e.g. "temp:coretemp"), empty for all sensors
+	Downsample *DownsampleSpec `json:"downsample,omitempty"` // if not set, raw samples are streamed
+}
+
+type CommandShutdownData struct {
+	Restart bool `json:"restart,omitempty"` // re-exec the server instead of exiting
+	GraceMs int  `json:"gracems,omitempty"` // time allowed for in-flight calls, defaults to 5000, max 60000
+}
+
+// data for wps.Event_ServerShutdown (scoped to the connection)
+type ShutdownEventData struct {
+	Conn    string `json:"conn"`
+	Restart bool   `json:"restart,omitempty"`
+	GraceMs int    `json:"gracems"`
+}
+
+type CommandRemoteKillData struct {
+	Pid     int    `json:"pid"`
+	Signal  string `json:"signal,omitempty"`  // signal name (e.g. "TERM", "SIGINT"), defaults to TERM
+	Force   bool   `json:"force,omitempty"`   // send KILL if the process is still running after the grace period
+	GraceMs int    `json:"gracems,omitempty"` // force only, defaults to 5000, max 60000
 }
 
 type CpuDataType struct {
@@ -428,40 +1041,412 @@ type CpuDataType struct {
 	Value float64 `json:"value"`
 }
 
+type CommandRemoteFileDeleteData struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive,omitempty"`
+	DryRun    bool   `json:"dryrun,omitempty"`
+}
+
+// copies a regular file on the remote side, the mode bits are preserved
+type CommandRemoteFileCopyData struct {
+	SrcPath   string `json:"srcpath"`
+	DestPath  string `json:"destpath"`
+	Overwrite bool   `json:"overwrite,omitempty"` // replace an existing file (never a directory)
+	DryRun    bool   `json:"dryrun,omitempty"`
+}
+
+// moves a file or directory (see RemoteFileRenameCommand)
+type CommandRemoteFileRenameData struct {
+	SrcPath   string `json:"srcpath"`
+	DestPath  string `json:"destpath"`
+	Overwrite bool   `json:"overwrite,omitempty"` // replace an existing file (never a directory)
+	DryRun    bool   `json:"dryrun,omitempty"`
+}
+
+type CommandRemoteChmodData struct {
+	Path      string `json:"path"`
+	Mode      string `json:"mode"` // octal permission bits, e.g. "755"
+	Recursive bool   `json:"recursive,omitempty"`
+	DryRun    bool   `json:"dryrun,omitempty"`
+}
+
+const (
+	FileOpAction_Delete = "delete"
+	FileOpAction_Copy   = "copy"
+	FileOpAction_Move   = "move"
+	FileOpAction_Chmod  = "chmod"
+)
+
+type FileOpChange struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	DestPath string `json:"destpath,omitempty"` // copy and move
+	Mode     string `json:"mode,omitempty"`     // chmod, the new mode (octal)
+	IsDir    bool   `json:"isdir,omitempty"`
+}
+
+// what a DryRun operation would do, nothing is changed on disk
+type FileOpPreview struct {
+	Changes   []FileOpChange `json:"changes,omitempty"` // for recursive deletes, in the order they would be removed
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+type CommandRemoteMountData struct {
+	Path string `json:"path"`
+}
+
+type MountInfo struct {
+	Path       string `json:"path"`       // cleaned path that was asked about
+	MountPoint string `json:"mountpoint"` // on windows the volume root (C:\ or \\server\share\)
+	FsType     string `json:"fstype"`
+	Device     string `json:"device,omitempty"` // mount source (e.g. "server:/export" for nfs)
+	Network    bool   `json:"network,omitempty"`
+	ReadOnly   bool   `json:"readonly,omitempty"`
+}
+
+// the path doesn't have to exist yet, the nearest existing parent is used (e.g. the target dir of an extract)
+type CommandRemoteStatFSData struct {
+	Path string `json:"path"`
+}
+
+type StatFSRtnData struct {
+	Path        string `json:"path"` // the existing path that was stat'd
+	TotalBytes  uint64 `json:"totalbytes"`
+	FreeBytes   uint64 `json:"freebytes"`
+	AvailBytes  uint64 `json:"availbytes"` // free bytes usable by the current user (excludes root reserved blocks)
+	TotalInodes uint64 `json:"totalinodes,omitempty"`
+	FreeInodes  uint64 `json:"freeinodes,omitempty"`
+	NoInodes    bool   `json:"noinodes,omitempty"` // the filesystem doesn't report inodes (windows, btrfs), the inode fields are zero
+}
+
+const (
+	FileEntryType_File    = "file"
+	FileEntryType_Dir     = "dir"
+	FileEntryType_Symlink = "symlink"
+	FileEntryType_Device  = "device" // block or character device
+	FileEntryType_Pipe    = "pipe"
+	FileEntryType_Socket  = "socket"
+	FileEntryType_Other   = "other"
+)
+
 type FileInfo struct {
-	Path     string      `json:"path"` // cleaned path (may have "~")
-	Dir      string      `json:"dir"`  // returns the directory part of the path (if this is a a directory, it will be equal to Path).  "~" will be expanded, and separators will be normalized to "/"
-	Name     string      `json:"name"`
-	NotFound bool        `json:"notfound,omitempty"`
-	Size     int64       `json:"size"`
-	Mode     os.FileMode `json:"mode"`
-	ModeStr  string      `json:"modestr"`
-	ModTime  int64       `json:"modtime"`
-	IsDir    bool        `json:"isdir,omitempty"`
-	MimeType string      `json:"mimetype,omitempty"`
-	ReadOnly bool        `json:"readonly,omitempty"` // this is not set for fileinfo's returned from directory listings
+	Path      string      `json:"path"` // cleaned path (may have "~")
+	Dir       string      `json:"dir"`  // returns the directory part of the path (if this is a a directory, it will be equal to Path).  "~" will be expanded, and separators will be normalized to "/"
+	Name      string      `json:"name"`
+	NotFound  bool        `json:"notfound,omitempty"`
+	Size      int64       `json:"size"`
+	Mode      os.FileMode `json:"mode"`
+	ModeStr   string      `json:"modestr"`
+	ModTime   int64       `json:"modtime"`
+	IsDir     bool        `json:"isdir,omitempty"`
+	MimeType  string      `json:"mimetype,omitempty"`
+	ReadOnly  bool        `json:"readonly,omitempty"`  // this is not set for fileinfo's returned from directory listings
+	EntryType string      `json:"entrytype,omitempty"` // coarse type (see FileEntryType_), set on remote fileinfo's
+	Statted   bool        `json:"statted,omitempty"`   // set on remote fileinfo's that were stat'd, false for Shallow listing entries (size and modtime are zero)
+	AllocSize int64       `json:"allocsize,omitempty"` // bytes allocated on disk, only set for sparse files (less than Size), not set on windows
 }
 
-type CommandRemoteStreamFileData struct {
+type CommandRemoteWhichData struct {
+	Program string `json:"program"`
+	All     bool   `json:"all,omitempty"` // return every match on PATH, not just the first
+}
+
+// expanded like a shell argument: "~", then $VAR/${VAR}, then globs (no "**")
+type CommandRemoteExpandData struct {
+	Path     string            `json:"path"`
+	Env      map[string]string `json:"env,omitempty"`      // takes precedence over the server's environment
+	NullGlob bool              `json:"nullglob,omitempty"` // a glob with no matches returns nothing instead of the literal path
+}
+
+type CommandRemoteFileOpenData struct {
+	Path       string      `json:"path"`
+	Write      bool        `json:"write,omitempty"`      // open read-write (default is read-only)
+	Create     bool        `json:"create,omitempty"`     // create the file if it doesn't exist (implies write)
+	CreateMode os.FileMode `json:"createmode,omitempty"` // defaults to 0644
+}
+
+type RemoteFileOpenRtnData struct {
+	Handle string    `json:"handle"` // opaque
+	Info   *FileInfo `json:"info"`
+}
+
+type CommandRemoteFileReadAtData struct {
+	Handle string `json:"handle"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+type RemoteFileReadAtRtnData struct {
+	Data64 string `json:"data64" wshlog:"redact"`
+	EOF    bool   `json:"eof,omitempty"` // fewer than Size bytes were available
+}
+
+type CommandRemoteFileWriteAtData struct {
+	Handle string `json:"handle"`
+	Offset int64  `json:"offset"`
+	Data64 string `json:"data64" wshlog:"redact"`
+}
+
+type CommandRemoteListDirData struct {
+	Path             string   `json:"path"`
+	Cursor           string   `json:"cursor,omitempty"` // opaque, from the previous page's NextCursor
+	Limit            int      `json:"limit,omitempty"`
+	Exclude          []string `json:"exclude,omitempty"`          // globs matched against entry names
+	RespectGitignore bool     `json:"respectgitignore,omitempty"` // reads every .gitignore from the repo root down on each call (off by default)
+	Shallow          bool     `json:"shallow,omitempty"`          // skip the per-entry stat, entries only have a name and EntryType (upgrade with RemoteFileStat + ListEntries)
+}
+
+// the stream closes after the last chunk, a failure part way through is sent as an error (earlier chunks remain valid)
+type ListDirChunk struct {
+	Items []*FileInfo `json:"items"`
+}
+
+// lines longer than 64k are truncated, a trailing newline does not start a new (empty) line
+type CommandRemoteHeadTailData struct {
+	Path    string `json:"path"`
+	Lines   int    `json:"lines,omitempty"`   // defaults to 10, max 10000
+	FromEnd bool   `json:"fromend,omitempty"` // the last Lines lines instead of the first
+}
+
+type CommandRemoteMultiTailData struct {
+	Paths         []string `json:"paths"`                   // at most 64 files
+	BackfillLines int      `json:"backfilllines,omitempty"` // existing lines sent first for each file (default 0)
+	Combined      bool     `json:"combined,omitempty"`      // one event per poll with the new lines of all files (see MultiTailEvent.Sources)
+}
+
+const (
+	MultiTailStatus_Following = "following" // the file appeared (or became readable) after missing or error
+	MultiTailStatus_Missing   = "missing"   // the file doesn't exist (yet), it is picked up when it appears
+	MultiTailStatus_Rotated   = "rotated"   // a new file replaced the old one, it is followed from its start
+	MultiTailStatus_Truncated = "truncated" // the file shrank, it is followed from its start
+	MultiTailStatus_Error     = "error"     // the file can't be read (see Error), it keeps being retried
+)
+
+// lines are sent without their newline, an unterminated last line is held back until it is completed
+type MultiTailEvent struct {
+	Path     string   `json:"path,omitempty"` // the file that produced Lines or Status, empty for combined events
+	Lines    []string `json:"lines,omitempty"`
+	Sources  []int    `json:"sources,omitempty"` // combined events only, the index into Paths of each line
+	Backfill bool     `json:"backfill,omitempty"`
+	Status   string   `json:"status,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// line numbers are 1-based and inclusive, at most 10000 lines per call (same line handling as head/tail)
+type CommandRemoteFileLinesData struct {
 	Path      string `json:"path"`
-	ByteRange string `json:"byterange,omitempty"`
+	StartLine int    `json:"startline"`
+	EndLine   int    `json:"endline,omitempty"` // defaults to StartLine+9
+}
+
+type FileLine struct {
+	Line   int    `json:"line"`
+	Offset int64  `json:"offset"` // byte offset of the start of the line
+	Text   string `json:"text"`   // without the line ending ("\n" or "\r\n")
+}
+
+type FileLinesRtnData struct {
+	Lines     []FileLine `json:"lines"`
+	EndOffset int64      `json:"endoffset"`     // byte offset just past the last line read (including its line ending)
+	EOF       bool       `json:"eof,omitempty"` // the file ended before EndLine
+}
+
+// text files only, each side is limited to 2MB
+type CommandRemoteDiffData struct {
+	Path1   string `json:"path1"`
+	Path2   string `json:"path2,omitempty"`                  // the new side, either Path2 or Data64
+	Data64  string `json:"data64,omitempty" wshlog:"redact"` // diff Path1 against a client-provided blob
+	Context int    `json:"context,omitempty"`                // lines of context around changes, defaults to 3
+}
+
+type DiffHunk struct {
+	OldStart int      `json:"oldstart"` // 1-based (the line before the hunk when OldLines is 0)
+	OldLines int      `json:"oldlines"`
+	NewStart int      `json:"newstart"`
+	NewLines int      `json:"newlines"`
+	Lines    []string `json:"lines"` // prefixed with ' ', '-' or '+' (and "\\ No newline at end of file" markers)
+}
+
+type CommandRemoteDiffRtnData struct {
+	Identical bool       `json:"identical,omitempty"`
+	Diff      string     `json:"diff,omitempty"` // unified diff
+	Hunks     []DiffHunk `json:"hunks,omitempty"`
+}
+
+type CommandRemoteFileStatData struct {
+	Paths       []string `json:"paths"`
+	NoCache     bool     `json:"nocache,omitempty"`     // bypass the short-lived fileinfo cache
+	ListEntries bool     `json:"listentries,omitempty"` // stat like a directory listing (no symlink follow, no readonly check, uncached), upgrades Shallow entries
+}
+
+const (
+	StreamDecompress_None  = "none"
+	StreamDecompress_Auto  = "auto" // by extension (.gz, .tgz, .bz2), other files are streamed raw
+	StreamDecompress_Gzip  = "gzip"
+	StreamDecompress_Bzip2 = "bzip2"
+)
+
+type CommandRemoteStreamFileData struct {
+	Path       string `json:"path"`
+	ByteRange  string `json:"byterange,omitempty"`  // offsets into the decompressed data when decompressing
+	Decompress string `json:"decompress,omitempty"` // "none" (default), "auto", or an explicit format
 }
 
 type CommandRemoteStreamFileRtnData struct {
 	FileInfo []*FileInfo `json:"fileinfo,omitempty"`
-	Data64   string      `json:"data64,omitempty"`
+	Data64   string      `json:"data64,omitempty" wshlog:"redact"`
+}
+
+const (
+	ArchiveCompression_None = "none"
+	ArchiveCompression_Gzip = "gzip"
+)
+
+type CommandRemoteArchiveData struct {
+	Path        string   `json:"path"`
+	Include     []string `json:"include,omitempty"`     // globs (matched against the relative path or base name), empty includes all files
+	Exclude     []string `json:"exclude,omitempty"`     // excluded directories are not descended into
+	Compression string   `json:"compression,omitempty"` // "none" (default) or "gzip"
+	MaxSize     int64    `json:"maxsize,omitempty"`     // max total size of file contents, defaults to 1G
+}
+
+// Conn (optional) must match the connection the request is routed to
+type CommandRemoteClipboardData struct {
+	Conn string `json:"conn,omitempty" wshdefault:"Conn"`
+	Text string `json:"text" wshlog:"redact"`
+}
+
+// RemoteRunScriptCommand is operator only (requests from the wave app side)
+type CommandRemoteScriptData struct {
+	Interpreter string            `json:"interpreter"` // e.g. "bash" or "python3" (found in PATH), or an absolute path
+	Script      string            `json:"script" wshlog:"redact"`
+	Args        []string          `json:"args,omitempty"` // passed to the script
+	Stdin64     string            `json:"stdin64,omitempty" wshlog:"redact"`
+	Env         map[string]string `json:"env,omitempty"` // added to wsh's environment
+	Cwd         string            `json:"cwd,omitempty"`
+	TimeoutMs   int               `json:"timeoutms,omitempty"` // 0 runs until cancelled
+}
+
+const (
+	ExecStream_Stdout = "stdout"
+	ExecStream_Stderr = "stderr"
+)
+
+// output chunks are followed by a final chunk with Done set and the exit code (-1 if killed by a signal)
+type ExecOutputChunk struct {
+	Stream   string `json:"stream,omitempty"`
+	Data64   string `json:"data64,omitempty" wshlog:"redact"`
+	Done     bool   `json:"done,omitempty"`
+	ExitCode int    `json:"exitcode"`
+}
+
+const (
+	DedupMode_Hardlink = "hardlink"
+	DedupMode_Content  = "content"
+)
+
+type CommandRemoteDedupData struct {
+	Path    string   `json:"path"`
+	Mode    string   `json:"mode,omitempty"`    // "hardlink" (default, files sharing an inode) or "content" (identical contents)
+	MinSize int64    `json:"minsize,omitempty"` // smaller files are skipped, content mode never hashes files under 1k
+	Exclude []string `json:"exclude,omitempty"` // globs, excluded directories are not descended into
+}
+
+// hardlink groups are sent as soon as all links are found (the rest at the end), content groups by size (largest first)
+type DuplicateGroup struct {
+	Mode  string   `json:"mode"`
+	Key   string   `json:"key"` // "<dev>:<inode>" for hardlinks, the sha256 for content
+	Size  int64    `json:"size"`
+	Paths []string `json:"paths"`
 }
 
+type ArchiveChunk struct {
+	Data64 string `json:"data64" wshlog:"redact"`
+}
+
+// the archive is read from a file on the connection (upload it first, e.g. with RunChunkedWrite), so it is
+// streamed through the extraction instead of sent in one message
+type CommandRemoteExtractData struct {
+	Path          string `json:"path"`                    // destination directory, created if missing
+	ArchivePath   string `json:"archivepath"`             // tar(.gz) archive
+	RemoveArchive bool   `json:"removearchive,omitempty"` // remove ArchivePath when done (whether or not the extraction succeeds)
+	Compression   string `json:"compression,omitempty"`   // "none", "gzip", or empty to auto-detect
+	MaxSize       int64  `json:"maxsize,omitempty"`       // max total size of extracted files, defaults to 1G
+	PreserveMode  bool   `json:"preservemode,omitempty"`
+}
+
+type ExtractEntryResult struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type CommandRemoteExtractRtnData struct {
+	Entries []ExtractEntryResult `json:"entries"`
+}
+
+type CommandRemoteTransferData struct {
+	SrcConn  string `json:"srcconn,omitempty"` // empty is the local connection
+	SrcPath  string `json:"srcpath"`
+	DestConn string `json:"destconn,omitempty"`
+	DestPath string `json:"destpath"` // must not exist
+}
+
+const (
+	TransferPhase_Copy     = "copy"     // same-host copy on the remote
+	TransferPhase_Transfer = "transfer" // relaying the file through wavesrv
+	TransferPhase_Verify   = "verify"
+	TransferPhase_Done     = "done"
+)
+
+type RemoteTransferProgress struct {
+	Phase            string `json:"phase"`
+	BytesTransferred int64  `json:"bytestransferred"`
+	TotalBytes       int64  `json:"totalbytes"`
+	Checksum         string `json:"checksum,omitempty"` // sha256 (hex), set when done
+}
+
+type CommandRemoteMkdirData struct {
+	Path       string      `json:"path"`
+	Recursive  bool        `json:"recursive,omitempty"`  // create missing parents, an existing directory is not an error
+	MustCreate bool        `json:"mustcreate,omitempty"` // with Recursive, an existing directory is still an error
+	CreateMode os.FileMode `json:"createmode,omitempty"` // defaults to 0755 (the umask applies)
+}
+
+// written to a temp file in the same directory and renamed over Path, so a failed or canceled write
+// leaves the original file untouched (an existing file keeps its permissions)
 type CommandRemoteWriteFileData struct {
 	Path       string      `json:"path"`
-	Data64     string      `json:"data64"`
+	Data64     string      `json:"data64" wshlog:"redact"`
 	CreateMode os.FileMode `json:"createmode,omitempty"`
+	NoAtomic   bool        `json:"noatomic,omitempty"` // write Path in place (no temp copy), a failed write can leave a partial file
+}
+
+// one chunk of an upload identified by UploadId (chosen by the client, scoped to its route).  chunks are
+// written to a temp file next to Path, which is renamed over Path by the Done chunk.
+type CommandRemoteWriteChunkData struct {
+	UploadId   string      `json:"uploadid"`
+	Path       string      `json:"path"`
+	Offset     int64       `json:"offset"`
+	Data64     string      `json:"data64,omitempty" wshlog:"redact"`
+	Done       bool        `json:"done,omitempty"`     // finishes the upload (no data), Offset must be the total size
+	Checksum   string      `json:"checksum,omitempty"` // sha256 (hex) of the whole file, required with Done
+	CreateMode os.FileMode `json:"createmode,omitempty"`
+}
+
+// Committed is the number of contiguous bytes written, the client resends from there.  chunks past it
+// are held (up to a limit) until the gap is filled.
+type RemoteWriteChunkAck struct {
+	Committed int64 `json:"committed"`
+	Done      bool  `json:"done,omitempty"`
 }
 
 type ConnKeywords struct {
-	ConnWshEnabled          *bool `json:"conn:wshenabled,omitempty"`
-	ConnAskBeforeWshInstall *bool `json:"conn:askbeforewshinstall,omitempty"`
-	ConnOverrideConfig      bool  `json:"conn:overrideconfig,omitempty"`
+	ConnWshEnabled          *bool  `json:"conn:wshenabled,omitempty"`
+	ConnAskBeforeWshInstall *bool  `json:"conn:askbeforewshinstall,omitempty"`
+	ConnOverrideConfig      bool   `json:"conn:overrideconfig,omitempty"`
+	ConnBandwidthLimit      *int64 `json:"conn:bandwidthlimit,omitempty"`
+	ConnFileInfoCacheTtlMs  *int64 `json:"conn:fileinfocachettlms,omitempty"`
 
 	DisplayHidden *bool   `json:"display:hidden,omitempty"`
 	DisplayOrder  float32 `json:"display:order,omitempty"`
@@ -487,13 +1472,52 @@ type ConnKeywords struct {
 	SshGlobalKnownHostsFile         []string `json:"ssh:globalknownhostsfile,omitempty"`
 }
 
+type CommandConnRenameData struct {
+	Connection string `json:"connection" wshdefault:"Conn"`
+	NewName    string `json:"newname"`         // display label, empty clears it
+	Alias      bool   `json:"alias,omitempty"` // also route "conn:<newname>" to the connection
+}
+
 type ConnRequest struct {
 	Host     string       `json:"host"`
 	Keywords ConnKeywords `json:"keywords,omitempty"`
 }
 
+// checks a proposed connection config without saving it (see ConnValidateCommand)
+type CommandConnValidateData struct {
+	Host      string       `json:"host"` // user@host:port
+	Keywords  ConnKeywords `json:"keywords,omitempty"`
+	Password  string       `json:"password,omitempty" wshlog:"redact"` // only sent to hosts already in known_hosts, never stored
+	TimeoutMs int          `json:"timeoutms,omitempty"`
+}
+
+const (
+	ConnValidateFail_Config  = "config"  // the host or keywords could not be resolved
+	ConnValidateFail_Dns     = "dns"     // the hostname did not resolve
+	ConnValidateFail_Refused = "refused" // nothing is listening on the port
+	ConnValidateFail_Timeout = "timeout"
+	ConnValidateFail_HostKey = "hostkey" // the host key changed or was revoked (or is unknown and only the password could be tried)
+	ConnValidateFail_Auth    = "auth"
+	ConnValidateFail_Other   = "other"
+)
+
+type ConnValidateRtnData struct {
+	Success            bool   `json:"success"`
+	Category           string `json:"category,omitempty"` // one of ConnValidateFail_*
+	Error              string `json:"error,omitempty"`
+	User               string `json:"user,omitempty"` // resolved from the ssh config and keywords
+	HostName           string `json:"hostname,omitempty"`
+	Port               string `json:"port,omitempty"`
+	HostKeyUnknown     bool   `json:"hostkeyunknown,omitempty"` // not in known_hosts yet, confirmed on the first real connect
+	HostKeyFingerprint string `json:"hostkeyfingerprint,omitempty"`
+	DurationMs         int64  `json:"durationms"`
+}
+
 const (
-	TimeSeries_Cpu = "cpu"
+	TimeSeries_Cpu  = "cpu"
+	TimeSeries_Gpu  = "gpu"  // gpu:<idx>:util (%), gpu:<idx>:memused and gpu:<idx>:memtotal (MiB), gpu:<idx>:temp (C)
+	TimeSeries_Temp = "temp" // temp:<chip>:<idx> (C)
+	TimeSeries_Fan  = "fan"  // fan:<chip>:<idx> (RPM)
 )
 
 type TimeSeriesData struct {
@@ -534,6 +1558,18 @@ type ConnStatus struct {
 	ActiveConnNum int    `json:"activeconnnum"`
 	Error         string `json:"error,omitempty"`
 	WshError      string `json:"wsherror,omitempty"`
+	Ready         bool   `json:"ready,omitempty"` // true once warmed up (env, home, shell, and os resolved)
+	Label         string `json:"label,omitempty"` // display label set with ConnRenameCommand
+}
+
+// returned by GetConnBandwidthCommand, rates are averaged over the last few seconds
+type ConnBandwidth struct {
+	Connection   string `json:"connection"`
+	Limit        int64  `json:"limit"` // bytes/sec (conn:bandwidthlimit), 0 is unlimited
+	ReadRate     int64  `json:"readrate"`
+	WriteRate    int64  `json:"writerate"`
+	BytesRead    int64  `json:"bytesread"`
+	BytesWritten int64  `json:"byteswritten"`
 }
 
 type WebSelectorOpts struct {
@@ -0,0 +1,103 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// ${name} placeholders in string meta values (including nested maps and arrays) are replaced from
+// CommandCreateBlockData.Vars.  $${name} is an escape for a literal ${name}, any other "$" is left alone.
+
+var metaVarNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func substituteVars(str string, vars map[string]string) (string, error) {
+	if !strings.Contains(str, "${") {
+		return str, nil
+	}
+	var buf strings.Builder
+	for {
+		idx := strings.Index(str, "${")
+		if idx == -1 {
+			buf.WriteString(str)
+			return buf.String(), nil
+		}
+		if idx > 0 && str[idx-1] == '$' {
+			// escaped, write everything up to (but not including) the escaping "$"
+			buf.WriteString(str[:idx-1])
+			buf.WriteString("${")
+			str = str[idx+2:]
+			continue
+		}
+		buf.WriteString(str[:idx])
+		endIdx := strings.Index(str[idx:], "}")
+		if endIdx == -1 {
+			return "", fmt.Errorf("unterminated placeholder %q", str[idx:])
+		}
+		name := str[idx+2 : idx+endIdx]
+		if !metaVarNameRe.MatchString(name) {
+			return "", fmt.Errorf("invalid variable name %q", name)
+		}
+		val, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("unresolved variable ${%s}", name)
+		}
+		buf.WriteString(val)
+		str = str[idx+endIdx+1:]
+	}
+}
+
+func substituteVarsInValue(key string, val any, vars map[string]string) (any, error) {
+	switch tval := val.(type) {
+	case string:
+		rtn, err := substituteVars(tval, vars)
+		if err != nil {
+			return nil, fmt.Errorf("meta %q: %w", key, err)
+		}
+		return rtn, nil
+	case []any:
+		rtn := make([]any, len(tval))
+		for idx, elem := range tval {
+			newElem, err := substituteVarsInValue(fmt.Sprintf("%s[%d]", key, idx), elem, vars)
+			if err != nil {
+				return nil, err
+			}
+			rtn[idx] = newElem
+		}
+		return rtn, nil
+	case map[string]any:
+		return substituteVarsInMap(key+".", tval, vars)
+	case waveobj.MetaMapType:
+		return substituteVarsInMap(key+".", tval, vars)
+	}
+	return val, nil
+}
+
+func substituteVarsInMap(keyPrefix string, m map[string]any, vars map[string]string) (map[string]any, error) {
+	rtn := make(map[string]any, len(m))
+	for key, val := range m {
+		newVal, err := substituteVarsInValue(keyPrefix+key, val, vars)
+		if err != nil {
+			return nil, err
+		}
+		rtn[key] = newVal
+	}
+	return rtn, nil
+}
+
+// returns a copy of meta with all ${name} placeholders resolved, errors on any unresolved variable
+func SubstituteMetaVars(meta waveobj.MetaMapType, vars map[string]string) (waveobj.MetaMapType, error) {
+	if meta == nil {
+		return nil, nil
+	}
+	rtn, err := substituteVarsInMap("", meta, vars)
+	if err != nil {
+		return nil, err
+	}
+	return waveobj.MetaMapType(rtn), nil
+}
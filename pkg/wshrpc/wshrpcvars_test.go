@@ -0,0 +1,64 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestSubstituteMetaVars(t *testing.T) {
+	vars := map[string]string{"dir": "/tmp/proj", "name": "build"}
+	meta := waveobj.MetaMapType{
+		waveobj.MetaKey_Cmd:    "make -C ${dir} ${name}",
+		waveobj.MetaKey_CmdCwd: "${dir}",
+		"title":                "$${name} stays literal, $HOME too",
+		waveobj.MetaKey_CmdEnv: map[string]any{"TARGET": "${name}"},
+		"args":                 []any{"${dir}", 5},
+		waveobj.MetaKey_Edit:   true,
+	}
+	rtn, err := wshrpc.SubstituteMetaVars(meta, vars)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rtn.GetString(waveobj.MetaKey_Cmd, ""); got != "make -C /tmp/proj build" {
+		t.Errorf("cmd: got %q", got)
+	}
+	if got := rtn.GetString(waveobj.MetaKey_CmdCwd, ""); got != "/tmp/proj" {
+		t.Errorf("cmd:cwd: got %q", got)
+	}
+	if got := rtn.GetString("title", ""); got != "${name} stays literal, $HOME too" {
+		t.Errorf("escaped placeholder: got %q", got)
+	}
+	if got := rtn.GetMap(waveobj.MetaKey_CmdEnv).GetString("TARGET", ""); got != "build" {
+		t.Errorf("nested map: got %q", got)
+	}
+	if got := rtn.GetArray("args"); len(got) != 2 || got[0] != "/tmp/proj" || got[1] != 5 {
+		t.Errorf("array: got %v", got)
+	}
+	if meta.GetString(waveobj.MetaKey_Cmd, "") != "make -C ${dir} ${name}" {
+		t.Errorf("original meta was modified")
+	}
+
+	for _, bad := range []string{"${missing}", "${dir", "${bad-name}"} {
+		_, err := wshrpc.SubstituteMetaVars(waveobj.MetaMapType{waveobj.MetaKey_Cmd: bad}, vars)
+		if err == nil || !strings.Contains(err.Error(), `meta "cmd"`) {
+			t.Errorf("%q: expected an error naming the key, got %v", bad, err)
+		}
+	}
+
+	// without vars the meta is passed through untouched (so shell ${VAR} syntax keeps working)
+	data := wshrpc.CommandCreateBlockData{BlockDef: &waveobj.BlockDef{Meta: waveobj.MetaMapType{waveobj.MetaKey_Cmd: "echo ${HOME}"}}}
+	blockDef, err := data.MakeInitBlockDef()
+	if err != nil || blockDef.Meta.GetString(waveobj.MetaKey_Cmd, "") != "echo ${HOME}" {
+		t.Errorf("expected meta to be untouched without vars, got %v, %v", blockDef, err)
+	}
+	data.Vars = map[string]string{}
+	if _, err := data.MakeInitBlockDef(); err == nil {
+		t.Errorf("expected an unresolved variable error once vars are set")
+	}
+}
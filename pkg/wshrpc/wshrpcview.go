@@ -0,0 +1,27 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// error prefix for SetViewCommand with a view the block doesn't support
+const ErrPrefix_InvalidView = "invalid_view"
+
+type InvalidViewError struct {
+	View       string
+	ValidViews []string
+}
+
+func (e InvalidViewError) Error() string {
+	return fmt.Sprintf("%s: view %q is not valid for this block (valid views: %s)", ErrPrefix_InvalidView, e.View, strings.Join(e.ValidViews, ", "))
+}
+
+func IsInvalidViewError(err error) bool {
+	var viewErr InvalidViewError
+	return errors.As(err, &viewErr)
+}
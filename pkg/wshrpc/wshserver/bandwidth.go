@@ -0,0 +1,122 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/util/ratelimit"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// per-connection bandwidth cap (conn:bandwidthlimit, bytes/sec, overridable per connection).
+// file reads are throttled at the source: wavesrv sets the cap on the remote (RemoteSetReadLimitCommand,
+// operator only) and the remote shares one bucket between its streams (throttling here would back up the
+// rpc read loop).  file writes are sent in chunks, each throttled here before it is sent.  throttling only
+// slows transfers down.
+
+type connBandwidth struct {
+	writeLimiter *ratelimit.TokenBucket
+	readMeter    ratelimit.Meter
+	writeMeter   ratelimit.Meter
+}
+
+var connBandwidthLock = &sync.Mutex{}
+var connBandwidthMap = make(map[string]*connBandwidth)
+
+func normalizeBandwidthConn(connName string) string {
+	if connName == "" {
+		return wshrpc.LocalConnName
+	}
+	return connName
+}
+
+func getConnBandwidth(connName string) *connBandwidth {
+	connName = normalizeBandwidthConn(connName)
+	connBandwidthLock.Lock()
+	defer connBandwidthLock.Unlock()
+	cb := connBandwidthMap[connName]
+	if cb == nil {
+		cb = &connBandwidth{writeLimiter: ratelimit.MakeTokenBucket(0)}
+		connBandwidthMap[connName] = cb
+	}
+	return cb
+}
+
+// bytes/sec for the connection, 0 is unlimited
+func GetConnBandwidthLimit(connName string) int64 {
+	connName = normalizeBandwidthConn(connName)
+	fullConfig := wconfig.GetWatcher().GetFullConfig()
+	limit := fullConfig.Settings.ConnBandwidthLimit
+	if connSettings, ok := fullConfig.Connections[connName]; ok && connSettings.ConnBandwidthLimit != nil {
+		limit = *connSettings.ConnBandwidthLimit
+	}
+	return max(limit, 0)
+}
+
+// waits until n bytes may be written to the connection, only returns an error if ctx is done
+func WaitConnWrite(ctx context.Context, connName string, n int) error {
+	cb := getConnBandwidth(connName)
+	cb.writeLimiter.SetRate(GetConnBandwidthLimit(connName))
+	if err := cb.writeLimiter.Wait(ctx, n); err != nil {
+		return err
+	}
+	cb.writeMeter.Add(n)
+	return nil
+}
+
+// pushes the connection's current cap to the remote before a read, so config changes apply to the next stream
+func ApplyConnReadLimit(connName string) error {
+	connName = normalizeBandwidthConn(connName)
+	rpcOpts := &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(connName)}
+	err := wshclient.RemoteSetReadLimitCommand(GetMainRpcClient(), GetConnBandwidthLimit(connName), rpcOpts)
+	if err != nil {
+		return fmt.Errorf("error setting read limit for %q: %w", connName, err)
+	}
+	return nil
+}
+
+// writes data to path on the connection in chunks (see wshrpc.RunChunkedWrite), each throttled by the write cap
+func WriteConnFile(ctx context.Context, connName string, path string, data []byte, createMode os.FileMode) error {
+	connName = normalizeBandwidthConn(connName)
+	client := GetMainRpcClient()
+	rpcOpts := &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(connName)}
+	writeOpts := wshrpc.ChunkedWriteOpts{UploadId: uuid.NewString(), Path: path, CreateMode: createMode}
+	err := wshrpc.RunChunkedWrite(writeOpts, data, func(chunk wshrpc.CommandRemoteWriteChunkData) (wshrpc.RemoteWriteChunkAck, error) {
+		if err := WaitConnWrite(ctx, connName, base64.StdEncoding.DecodedLen(len(chunk.Data64))); err != nil {
+			return wshrpc.RemoteWriteChunkAck{}, err
+		}
+		return wshclient.RemoteWriteFileChunkCommand(client, chunk, rpcOpts)
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// records n bytes received from the connection (reads are throttled by the remote)
+func RecordConnRead(connName string, n int) {
+	getConnBandwidth(connName).readMeter.Add(n)
+}
+
+func (ws *WshServer) GetConnBandwidthCommand(ctx context.Context, connName string) (wshrpc.ConnBandwidth, error) {
+	connName = normalizeBandwidthConn(connName)
+	cb := getConnBandwidth(connName)
+	return wshrpc.ConnBandwidth{
+		Connection:   connName,
+		Limit:        GetConnBandwidthLimit(connName),
+		ReadRate:     cb.readMeter.Rate(),
+		WriteRate:    cb.writeMeter.Rate(),
+		BytesRead:    cb.readMeter.Total(),
+		BytesWritten: cb.writeMeter.Total(),
+	}, nil
+}
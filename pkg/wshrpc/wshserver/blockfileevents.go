@@ -0,0 +1,153 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// high-frequency appends (e.g. wsh piping output into a blockfile) are coalesced into
+// a single blockfile event per file every BlockFileEventThrottleTime.
+// non-append events flush any pending appends first so subscribers see ops in order.
+
+const BlockFileEventThrottleTime = 50 * time.Millisecond
+const BlockFileEventMaxPending = 256 * 1024
+
+type blockFileEventKey struct {
+	ZoneId   string
+	FileName string
+}
+
+type pendingBlockFileAppend struct {
+	Data   []byte
+	Offset int64 // file offset of Data
+	Timer  *time.Timer
+}
+
+type blockFileEventThrottler struct {
+	Lock    *sync.Mutex
+	Pending map[blockFileEventKey]*pendingBlockFileAppend
+}
+
+var blockFileEvents = &blockFileEventThrottler{
+	Lock:    &sync.Mutex{},
+	Pending: make(map[blockFileEventKey]*pendingBlockFileAppend),
+}
+
+func publishBlockFileEvent(data *wps.WSFileEventData) {
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_BlockFile,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, data.ZoneId).String()},
+		Data:   data,
+	})
+}
+
+// offset is where the append was written (see filestore.AppendDataOffset).  concurrent appends can be
+// queued out of write order, so an append that doesn't continue the pending data is published separately.
+func (t *blockFileEventThrottler) queueAppend(zoneId string, fileName string, data []byte, offset int64) {
+	key := blockFileEventKey{ZoneId: zoneId, FileName: fileName}
+	t.Lock.Lock()
+	defer t.Lock.Unlock()
+	pending := t.Pending[key]
+	if pending != nil && offset != pending.Offset+int64(len(pending.Data)) {
+		t.flush_nolock(key)
+		pending = nil
+	}
+	if pending == nil {
+		pending = &pendingBlockFileAppend{Offset: offset}
+		pending.Timer = time.AfterFunc(BlockFileEventThrottleTime, func() {
+			t.flush(key)
+		})
+		t.Pending[key] = pending
+	}
+	pending.Data = append(pending.Data, data...)
+	if len(pending.Data) >= BlockFileEventMaxPending {
+		t.flush_nolock(key)
+	}
+}
+
+func (t *blockFileEventThrottler) flush(key blockFileEventKey) {
+	t.Lock.Lock()
+	defer t.Lock.Unlock()
+	t.flush_nolock(key)
+}
+
+// publishes under the lock to preserve event ordering for the file
+func (t *blockFileEventThrottler) flush_nolock(key blockFileEventKey) {
+	pending := t.Pending[key]
+	if pending == nil {
+		return
+	}
+	delete(t.Pending, key)
+	pending.Timer.Stop()
+	publishBlockFileEvent(&wps.WSFileEventData{
+		ZoneId:   key.ZoneId,
+		FileName: key.FileName,
+		FileOp:   wps.FileOp_Append,
+		Data64:   base64.StdEncoding.EncodeToString(pending.Data),
+		Size:     pending.Offset + int64(len(pending.Data)),
+		Offset:   pending.Offset,
+	})
+}
+
+// flushes pending appends for the file, then publishes the event
+func (t *blockFileEventThrottler) publish(data *wps.WSFileEventData) {
+	key := blockFileEventKey{ZoneId: data.ZoneId, FileName: data.FileName}
+	t.Lock.Lock()
+	defer t.Lock.Unlock()
+	t.flush_nolock(key)
+	publishBlockFileEvent(data)
+}
+
+func rotatedBlockFileName(fileName string) string {
+	return fileName + ".1"
+}
+
+// called after an append pushes the file past data.MaxSize (data.SizeMode is already validated), publishes the
+// resulting blockfile events
+func enforceBlockFileMaxSize(ctx context.Context, data wshrpc.CommandFileData) error {
+	switch data.SizeMode {
+	case "", wshrpc.FileSizeMode_TrimFront:
+		newSize, err := filestore.WFS.TrimFront(ctx, data.ZoneId, data.FileName, data.MaxSize)
+		if err != nil {
+			return fmt.Errorf("error trimming blockfile: %w", err)
+		}
+		blockFileEvents.publish(&wps.WSFileEventData{
+			ZoneId:   data.ZoneId,
+			FileName: data.FileName,
+			FileOp:   wps.FileOp_Invalidate,
+			Size:     newSize,
+		})
+		return nil
+	case wshrpc.FileSizeMode_Rotate:
+		rotatedName := rotatedBlockFileName(data.FileName)
+		rotatedSize, err := filestore.WFS.RotateFile(ctx, data.ZoneId, data.FileName, rotatedName)
+		if err != nil {
+			return fmt.Errorf("error rotating blockfile: %w", err)
+		}
+		blockFileEvents.publish(&wps.WSFileEventData{
+			ZoneId:   data.ZoneId,
+			FileName: rotatedName,
+			FileOp:   wps.FileOp_Invalidate,
+			Size:     rotatedSize,
+		})
+		blockFileEvents.publish(&wps.WSFileEventData{
+			ZoneId:   data.ZoneId,
+			FileName: data.FileName,
+			FileOp:   wps.FileOp_Truncate,
+		})
+		return nil
+	default:
+		return fmt.Errorf("invalid sizemode %q", data.SizeMode)
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/ijson"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// collects the blockfile events for the zone (call the returned func to stop)
+func collectBlockFileEvents(zoneId string) (func() []*wps.WSFileEventData, func()) {
+	var lock sync.Mutex
+	var events []*wps.WSFileEventData
+	unsubFn := wps.Broker.SubscribeLocal(wps.Event_BlockFile, waveobj.MakeORef(waveobj.OType_Block, zoneId).String(), func(event wps.WaveEvent) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event.Data.(*wps.WSFileEventData))
+	})
+	getFn := func() []*wps.WSFileEventData {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]*wps.WSFileEventData{}, events...)
+	}
+	return getFn, unsubFn
+}
+
+func makeTestBlockFile(t *testing.T, zoneId string, fileName string, opts filestore.FileOptsType) {
+	t.Helper()
+	if err := filestore.WFS.MakeFile(context.Background(), zoneId, fileName, nil, opts); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+}
+
+func TestBlockFileAppendEvents(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	zoneId := uuid.NewString()
+	makeTestBlockFile(t, zoneId, "out", filestore.FileOptsType{})
+	getEvents, unsubFn := collectBlockFileEvents(zoneId)
+	defer unsubFn()
+	for _, chunk := range []string{"ab", "cde", "f"} {
+		appendData := wshrpc.CommandFileData{ZoneId: zoneId, FileName: "out", Data64: base64.StdEncoding.EncodeToString([]byte(chunk))}
+		if err := ws.FileAppendCommand(ctx, appendData); err != nil {
+			t.Fatalf("error appending: %v", err)
+		}
+	}
+	blockFileEvents.flush(blockFileEventKey{ZoneId: zoneId, FileName: "out"})
+	events := getEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected the appends to be coalesced into one event, got %d", len(events))
+	}
+	buf, _ := base64.StdEncoding.DecodeString(events[0].Data64)
+	if string(buf) != "abcdef" || events[0].Offset != 0 || events[0].Size != 6 {
+		t.Errorf("unexpected append event %q at %d (size %d)", buf, events[0].Offset, events[0].Size)
+	}
+}
+
+func TestBlockFileConcurrentAppendEvents(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	zoneId := uuid.NewString()
+	makeTestBlockFile(t, zoneId, "out", filestore.FileOptsType{})
+	getEvents, unsubFn := collectBlockFileEvents(zoneId)
+	defer unsubFn()
+
+	// appends queued out of write order are not coalesced into a single (misordered) event
+	blockFileEvents.queueAppend(zoneId, "queued", []byte("cd"), 2)
+	blockFileEvents.queueAppend(zoneId, "queued", []byte("ab"), 0)
+	blockFileEvents.flush(blockFileEventKey{ZoneId: zoneId, FileName: "queued"})
+
+	const numAppends = 50
+	var wg sync.WaitGroup
+	for idx := 0; idx < numAppends; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			chunk := fmt.Sprintf("[%d]", idx)
+			appendData := wshrpc.CommandFileData{ZoneId: zoneId, FileName: "out", Data64: base64.StdEncoding.EncodeToString([]byte(chunk))}
+			if err := ws.FileAppendCommand(ctx, appendData); err != nil {
+				t.Errorf("error appending: %v", err)
+			}
+		}(idx)
+	}
+	wg.Wait()
+	blockFileEvents.flush(blockFileEventKey{ZoneId: zoneId, FileName: "out"})
+	fileData := readTestBlockFile(t, zoneId, "out")
+
+	var queuedEvents []string
+	var totalSize int
+	for _, event := range getEvents() {
+		buf, _ := base64.StdEncoding.DecodeString(event.Data64)
+		if event.Size != event.Offset+int64(len(buf)) {
+			t.Errorf("event at %d with %d bytes has size %d", event.Offset, len(buf), event.Size)
+		}
+		if event.FileName == "queued" {
+			queuedEvents = append(queuedEvents, fmt.Sprintf("%s@%d", buf, event.Offset))
+			continue
+		}
+		totalSize += len(buf)
+		if event.Offset+int64(len(buf)) > int64(len(fileData)) || fileData[event.Offset:event.Offset+int64(len(buf))] != string(buf) {
+			t.Errorf("event data %q doesn't match the file at offset %d", buf, event.Offset)
+		}
+	}
+	if fmt.Sprint(queuedEvents) != "[cd@2 ab@0]" {
+		t.Errorf("expected separate events for the out of order appends, got %v", queuedEvents)
+	}
+	if totalSize != len(fileData) {
+		t.Errorf("expected the events to cover all %d bytes, got %d", len(fileData), totalSize)
+	}
+}
+
+func TestBlockFileEventSizes(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	zoneId := uuid.NewString()
+	makeTestBlockFile(t, zoneId, "out", filestore.FileOptsType{})
+	getEvents, unsubFn := collectBlockFileEvents(zoneId)
+	defer unsubFn()
+
+	// each write reports the size it left the file at, even with other writes in between
+	const numWrites = 20
+	var wg sync.WaitGroup
+	for idx := 1; idx <= numWrites; idx++ {
+		wg.Add(1)
+		go func(size int) {
+			defer wg.Done()
+			writeData := wshrpc.CommandFileData{ZoneId: zoneId, FileName: "out", Data64: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", size)))}
+			if err := ws.FileWriteCommand(ctx, writeData); err != nil {
+				t.Errorf("error writing: %v", err)
+			}
+		}(idx)
+	}
+	wg.Wait()
+	seenSizes := make(map[int64]bool)
+	for _, event := range getEvents() {
+		seenSizes[event.Size] = true
+	}
+	for size := int64(1); size <= numWrites; size++ {
+		if !seenSizes[size] {
+			t.Errorf("expected an event with size %d, got sizes %v", size, seenSizes)
+		}
+	}
+
+	makeTestBlockFile(t, zoneId, "doc", filestore.FileOptsType{IJson: true})
+	for idx := 0; idx < 15; idx++ {
+		cmd := ijson.MakeSetCommand(ijson.Path{"val"}, idx)
+		if _, err := ws.FileAppendIJsonCommand(ctx, wshrpc.CommandAppendIJsonData{ZoneId: zoneId, FileName: "doc", Data: cmd}); err != nil {
+			t.Fatalf("error appending ijson: %v", err)
+		}
+		events := getEvents()
+		file, err := filestore.WFS.Stat(ctx, zoneId, "doc")
+		if err != nil {
+			t.Fatalf("error getting file info: %v", err)
+		}
+		if lastEvent := events[len(events)-1]; lastEvent.FileName != "doc" || lastEvent.Size != file.Size {
+			t.Errorf("append %d: expected the event size to be %d, got %d", idx, file.Size, lastEvent.Size)
+		}
+	}
+}
+
+func TestFileAppendMaxSize(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	zoneId := uuid.NewString()
+	makeTestBlockFile(t, zoneId, "trim", filestore.FileOptsType{})
+	makeTestBlockFile(t, zoneId, "rotate", filestore.FileOptsType{})
+	getEvents, unsubFn := collectBlockFileEvents(zoneId)
+	defer unsubFn()
+	appendLines := func(fileName string, sizeMode string) {
+		for idx := 0; idx < 4; idx++ {
+			line := fmt.Sprintf("line %d\n", idx)
+			appendData := wshrpc.CommandFileData{ZoneId: zoneId, FileName: fileName, Data64: base64.StdEncoding.EncodeToString([]byte(line)), MaxSize: 20, SizeMode: sizeMode}
+			if err := ws.FileAppendCommand(ctx, appendData); err != nil {
+				t.Fatalf("error appending to %s: %v", fileName, err)
+			}
+		}
+	}
+	readData := func(fileName string) string {
+		_, data, err := filestore.WFS.ReadFile(ctx, zoneId, fileName)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", fileName, err)
+		}
+		return string(data)
+	}
+
+	appendLines("trim", wshrpc.FileSizeMode_TrimFront)
+	if data := readData("trim"); data != "line 2\nline 3\n" {
+		t.Errorf("expected the front of the file to be trimmed, got %q", data)
+	}
+	var trimEvents []string
+	for _, event := range getEvents() {
+		if event.FileName == "trim" {
+			trimEvents = append(trimEvents, fmt.Sprintf("%s@%d", event.FileOp, event.Offset))
+		}
+	}
+	// the appends that overflowed the file are published before the trim's invalidate
+	if fmt.Sprint(trimEvents) != "[append@0 invalidate@0 append@14 invalidate@0]" {
+		t.Errorf("unexpected events for the trimmed file: %v", trimEvents)
+	}
+
+	appendLines("rotate", wshrpc.FileSizeMode_Rotate)
+	if data := readData("rotate.1"); data != "line 0\nline 1\nline 2\n" {
+		t.Errorf("expected the full file to be rotated, got %q", data)
+	}
+	if data := readData("rotate"); data != "line 3\n" {
+		t.Errorf("expected only the appends after the rotate, got %q", data)
+	}
+	var rotateOps []string
+	for _, event := range getEvents() {
+		if strings.HasPrefix(event.FileName, "rotate") && event.FileOp != wps.FileOp_Append {
+			rotateOps = append(rotateOps, event.FileName+":"+event.FileOp)
+		}
+	}
+	if fmt.Sprint(rotateOps) != "[rotate.1:invalidate rotate:truncate]" {
+		t.Errorf("expected an invalidate for the rotated file and a truncate, got %v", rotateOps)
+	}
+
+	badData := wshrpc.CommandFileData{ZoneId: zoneId, FileName: "trim", Data64: base64.StdEncoding.EncodeToString([]byte("more data\n")), MaxSize: 5, SizeMode: "bogus"}
+	if err := ws.FileAppendCommand(ctx, badData); err == nil {
+		t.Errorf("expected an invalid sizemode to be rejected")
+	}
+	if data := readData("trim"); data != "line 2\nline 3\n" {
+		t.Errorf("expected a rejected append not to write, got %q", data)
+	}
+}
+
+func TestFileReadCommand(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	zoneId := uuid.NewString()
+	makeTestBlockFile(t, zoneId, "out", filestore.FileOptsType{})
+	filestore.WFS.WriteFile(ctx, zoneId, "out", []byte("hello world"))
+
+	// fileread returns the (base64) data itself, not a struct
+	data64, err := ws.FileReadCommand(ctx, wshrpc.CommandFileData{ZoneId: zoneId, FileName: "out"})
+	if err != nil {
+		t.Fatalf("error reading: %v", err)
+	}
+	if buf, _ := base64.StdEncoding.DecodeString(data64); string(buf) != "hello world" {
+		t.Errorf("expected the file data, got %q", buf)
+	}
+	data64, err = ws.FileReadCommand(ctx, wshrpc.CommandFileData{ZoneId: zoneId, FileName: "out", At: &wshrpc.CommandFileDataAt{Offset: 6, Size: 5}})
+	if err != nil {
+		t.Fatalf("error reading at offset: %v", err)
+	}
+	if buf, _ := base64.StdEncoding.DecodeString(data64); string(buf) != "world" {
+		t.Errorf("expected the data at the offset, got %q", buf)
+	}
+	// the gzip encoding is only for filereaddetect (plain fileread results have no field to flag it)
+	data64, err = ws.FileReadCommand(ctx, wshrpc.CommandFileData{ZoneId: zoneId, FileName: "out", Encoding: wshrpc.FileEncoding_Gzip, CompressMinSize: 1})
+	if buf, _ := base64.StdEncoding.DecodeString(data64); err != nil || string(buf) != "hello world" {
+		t.Errorf("expected plain data with a gzip encoding requested, got %q (err:%v)", buf, err)
+	}
+	if _, err := ws.FileReadCommand(ctx, wshrpc.CommandFileData{ZoneId: zoneId, FileName: "missing"}); err == nil || !strings.HasPrefix(err.Error(), "NOTFOUND:") {
+		t.Errorf("expected a NOTFOUND error for a missing file, got %v", err)
+	}
+}
+
+func TestFileReadDetectCommand(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	zoneId := uuid.NewString()
+	binaryData := append([]byte{0x7f, 'E', 'L', 'F', 0}, []byte(strings.Repeat("x", 100))...)
+	tests := []struct {
+		name              string
+		data              []byte
+		binaryMaxSize     int64
+		expectedBinary    bool
+		expectedEncoding  string
+		expectedTruncated bool
+		expectedSize      int
+	}{
+		{"utf8", []byte("héllo wörld\n"), 10, false, wshrpc.TextEncoding_Utf8, false, 14},
+		{"latin1", []byte("h\xe9llo w\xf6rld\n"), 10, false, wshrpc.TextEncoding_Latin1, false, 12},
+		{"binary", binaryData, 0, true, "", false, len(binaryData)},
+		{"binary max size", binaryData, 16, true, "", true, 16},
+		{"binary under max size", binaryData, 1000, true, "", false, len(binaryData)},
+	}
+	for _, tc := range tests {
+		makeTestBlockFile(t, zoneId, tc.name, filestore.FileOptsType{})
+		filestore.WFS.WriteFile(ctx, zoneId, tc.name, tc.data)
+		rtn, err := ws.FileReadDetectCommand(ctx, wshrpc.CommandFileData{ZoneId: zoneId, FileName: tc.name, BinaryMaxSize: tc.binaryMaxSize})
+		if err != nil {
+			t.Errorf("%s: error reading: %v", tc.name, err)
+			continue
+		}
+		if rtn.Binary != tc.expectedBinary || rtn.Encoding != tc.expectedEncoding || rtn.Truncated != tc.expectedTruncated {
+			t.Errorf("%s: expected binary:%v encoding:%q truncated:%v, got binary:%v encoding:%q truncated:%v", tc.name, tc.expectedBinary, tc.expectedEncoding, tc.expectedTruncated, rtn.Binary, rtn.Encoding, rtn.Truncated)
+		}
+		buf, _ := base64.StdEncoding.DecodeString(rtn.Data64)
+		if len(buf) != tc.expectedSize || string(buf) != string(tc.data[:tc.expectedSize]) {
+			t.Errorf("%s: expected the first %d bytes of the file, got %q", tc.name, tc.expectedSize, buf)
+		}
+	}
+
+	logData := []byte(strings.Repeat("INFO request handled in 10ms\n", 500))
+	makeTestBlockFile(t, zoneId, "log", filestore.FileOptsType{})
+	filestore.WFS.WriteFile(ctx, zoneId, "log", logData)
+	rtn, err := ws.FileReadDetectCommand(ctx, wshrpc.CommandFileData{ZoneId: zoneId, FileName: "log", Encoding: wshrpc.FileEncoding_Gzip})
+	if err != nil {
+		t.Fatalf("error reading gzipped: %v", err)
+	}
+	if rtn.DataEncoding != wshrpc.FileEncoding_Gzip || len(rtn.Data64) >= len(logData) {
+		t.Errorf("expected the log to be gzipped, got encoding %q (%d bytes)", rtn.DataEncoding, len(rtn.Data64))
+	}
+	if buf, err := wshrpc.DecodeFileReadData(rtn.Data64, rtn.DataEncoding); err != nil || string(buf) != string(logData) {
+		t.Errorf("expected the gzipped data to decode to the log, err:%v", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const DefaultSnapshotMaxInlineSize = 256 * 1024
+
+func (ws *WshServer) SnapshotBlockCommand(ctx context.Context, data wshrpc.CommandSnapshotBlockData) (wshrpc.BlockSnapshot, error) {
+	var rtn wshrpc.BlockSnapshot
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, data.BlockId)
+	if err != nil {
+		return rtn, fmt.Errorf("error getting block: %w", err)
+	}
+	maxInlineSize := data.MaxInlineSize
+	if maxInlineSize <= 0 {
+		maxInlineSize = DefaultSnapshotMaxInlineSize
+	}
+	rtn.Version = wshrpc.BlockSnapshotVersion
+	rtn.BlockId = block.OID
+	rtn.Meta = block.Meta
+	rtn.RuntimeOpts = block.RuntimeOpts
+	files, err := filestore.WFS.ListFiles(ctx, block.OID)
+	if err != nil {
+		return rtn, fmt.Errorf("error listing blockfiles: %w", err)
+	}
+	for _, file := range files {
+		snapFile := wshrpc.BlockSnapshotFile{
+			Name: file.Name,
+			Size: file.Size,
+			Meta: file.Meta,
+			Opts: file.Opts,
+		}
+		if file.Size > maxInlineSize {
+			snapFile.ByRef = true
+		} else {
+			_, fileData, err := filestore.WFS.ReadFile(ctx, block.OID, file.Name)
+			if err != nil {
+				return rtn, fmt.Errorf("error reading blockfile %q: %w", file.Name, err)
+			}
+			snapFile.Data64 = base64.StdEncoding.EncodeToString(fileData)
+		}
+		rtn.Files = append(rtn.Files, snapFile)
+	}
+	return rtn, nil
+}
+
+func restoreSnapshotFile(ctx context.Context, blockId string, snapshot *wshrpc.BlockSnapshot, snapFile wshrpc.BlockSnapshotFile) error {
+	var fileData []byte
+	if snapFile.ByRef {
+		_, refData, err := filestore.WFS.ReadFile(ctx, snapshot.BlockId, snapFile.Name)
+		if err != nil {
+			return fmt.Errorf("error reading referenced blockfile %q from block %q: %w", snapFile.Name, snapshot.BlockId, err)
+		}
+		fileData = refData
+	} else {
+		var err error
+		fileData, err = wshrpc.DecodeData64("data64", snapFile.Data64)
+		if err != nil {
+			return err
+		}
+	}
+	err := filestore.WFS.MakeFile(ctx, blockId, snapFile.Name, snapFile.Meta, snapFile.Opts)
+	if err != nil {
+		return fmt.Errorf("error making blockfile %q: %w", snapFile.Name, err)
+	}
+	err = filestore.WFS.WriteFile(ctx, blockId, snapFile.Name, fileData)
+	if err != nil {
+		return fmt.Errorf("error writing blockfile %q: %w", snapFile.Name, err)
+	}
+	return nil
+}
+
+// creates a new block on the tab from the snapshot.  by-ref files are copied from the source block, which must still exist.
+func (ws *WshServer) RestoreBlockCommand(ctx context.Context, data wshrpc.CommandRestoreBlockData) (*waveobj.ORef, error) {
+	snapshot := &data.Snapshot
+	if snapshot.Version != wshrpc.BlockSnapshotVersion {
+		return nil, fmt.Errorf("unsupported block snapshot version %d", snapshot.Version)
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	blockDef := &waveobj.BlockDef{Meta: snapshot.Meta}
+	blockData, err := wcore.CreateBlock(ctx, data.TabId, blockDef, snapshot.RuntimeOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error creating block: %w", err)
+	}
+	for _, snapFile := range snapshot.Files {
+		err = restoreSnapshotFile(ctx, blockData.OID, snapshot, snapFile)
+		if err != nil {
+			if delErr := wcore.DeleteBlock(ctx, blockData.OID, true); delErr != nil {
+				log.Printf("error cleaning up restored block %s: %v\n", blockData.OID, delErr)
+			}
+			return nil, err
+		}
+	}
+	err = wcore.QueueLayoutActionForTab(ctx, data.TabId, waveobj.LayoutActionData{
+		ActionType: wcore.LayoutActionDataType_Insert,
+		BlockId:    blockData.OID,
+		Magnified:  data.Magnified,
+		Focused:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error queuing layout action: %w", err)
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	return &waveobj.ORef{OType: waveobj.OType_Block, OID: blockData.OID}, nil
+}
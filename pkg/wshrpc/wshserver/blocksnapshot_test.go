@@ -0,0 +1,131 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func makeTestTab(t *testing.T) *waveobj.Tab {
+	t.Helper()
+	ctx := context.Background()
+	layoutState := &waveobj.LayoutState{OID: uuid.NewString()}
+	if err := wstore.DBInsert(ctx, layoutState); err != nil {
+		t.Fatalf("error inserting layout state: %v", err)
+	}
+	tab := &waveobj.Tab{OID: uuid.NewString(), LayoutState: layoutState.OID}
+	if err := wstore.DBInsert(ctx, tab); err != nil {
+		t.Fatalf("error inserting tab: %v", err)
+	}
+	return tab
+}
+
+func writeTestBlockFile(t *testing.T, zoneId string, fileName string, data string) {
+	t.Helper()
+	makeTestBlockFile(t, zoneId, fileName, filestore.FileOptsType{})
+	if err := filestore.WFS.WriteFile(context.Background(), zoneId, fileName, []byte(data)); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+}
+
+func readTestBlockFile(t *testing.T, zoneId string, fileName string) string {
+	t.Helper()
+	_, data, err := filestore.WFS.ReadFile(context.Background(), zoneId, fileName)
+	if err != nil {
+		t.Fatalf("error reading %q: %v", fileName, err)
+	}
+	return string(data)
+}
+
+func TestSnapshotRestoreBlock(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	block := makeTestBlock(t, waveobj.MetaMapType{waveobj.MetaKey_View: "term", "term:fontsize": float64(14)})
+	writeTestBlockFile(t, block.OID, "small", "hello")
+	writeTestBlockFile(t, block.OID, "large", "0123456789abcdef")
+
+	snapshot, err := ws.SnapshotBlockCommand(ctx, wshrpc.CommandSnapshotBlockData{BlockId: block.OID, MaxInlineSize: 8})
+	if err != nil {
+		t.Fatalf("error taking snapshot: %v", err)
+	}
+	if snapshot.Version != wshrpc.BlockSnapshotVersion || snapshot.BlockId != block.OID || snapshot.Meta.GetString(waveobj.MetaKey_View, "") != "term" {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+	filesByName := make(map[string]wshrpc.BlockSnapshotFile)
+	for _, file := range snapshot.Files {
+		filesByName[file.Name] = file
+	}
+	if small := filesByName["small"]; small.ByRef || small.Data64 != "aGVsbG8=" || small.Size != 5 {
+		t.Errorf("expected the small file to be inlined, got %+v", small)
+	}
+	if large := filesByName["large"]; !large.ByRef || large.Data64 != "" || large.Size != 16 {
+		t.Errorf("expected the large file to be included by reference, got %+v", large)
+	}
+
+	// restore from the serialized snapshot
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("error serializing snapshot: %v", err)
+	}
+	var restoreData wshrpc.CommandRestoreBlockData
+	if err := json.Unmarshal(snapshotBytes, &restoreData.Snapshot); err != nil {
+		t.Fatalf("error deserializing snapshot: %v", err)
+	}
+	tab := makeTestTab(t)
+	restoreData.TabId = tab.OID
+	oref, err := ws.RestoreBlockCommand(ctx, restoreData)
+	if err != nil {
+		t.Fatalf("error restoring block: %v", err)
+	}
+	if oref.OID == block.OID {
+		t.Fatalf("expected a new block")
+	}
+	meta, _ := getTestBlockMeta(t, oref.OID)
+	if meta.GetString(waveobj.MetaKey_View, "") != "term" || meta["term:fontsize"] != float64(14) {
+		t.Errorf("expected the snapshot meta on the restored block, got %v", meta)
+	}
+	if small, large := readTestBlockFile(t, oref.OID, "small"), readTestBlockFile(t, oref.OID, "large"); small != "hello" || large != "0123456789abcdef" {
+		t.Errorf("expected the inlined and referenced files to be restored, got %q and %q", small, large)
+	}
+	tab, _ = wstore.DBMustGet[*waveobj.Tab](ctx, tab.OID)
+	if !slices.Contains(tab.BlockIds, oref.OID) {
+		t.Errorf("expected the restored block in the tab, got %v", tab.BlockIds)
+	}
+}
+
+func TestRestoreBlockErrors(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	tab := makeTestTab(t)
+	meta := waveobj.MetaMapType{waveobj.MetaKey_View: "term"}
+
+	badVersion := wshrpc.CommandRestoreBlockData{TabId: tab.OID, Snapshot: wshrpc.BlockSnapshot{Version: wshrpc.BlockSnapshotVersion + 1, Meta: meta}}
+	if _, err := ws.RestoreBlockCommand(ctx, badVersion); err == nil {
+		t.Errorf("expected an unsupported snapshot version to be rejected")
+	}
+
+	// the source block of a by-ref file is gone, so the restored block is cleaned up
+	missingRef := wshrpc.CommandRestoreBlockData{TabId: tab.OID, Snapshot: wshrpc.BlockSnapshot{
+		Version: wshrpc.BlockSnapshotVersion,
+		BlockId: uuid.NewString(),
+		Meta:    meta,
+		Files:   []wshrpc.BlockSnapshotFile{{Name: "large", Size: 16, ByRef: true}},
+	}}
+	if _, err := ws.RestoreBlockCommand(ctx, missingRef); err == nil {
+		t.Errorf("expected a missing referenced file to fail the restore")
+	}
+	tab, _ = wstore.DBMustGet[*waveobj.Tab](ctx, tab.OID)
+	if len(tab.BlockIds) != 0 {
+		t.Errorf("expected the partially restored block to be removed, got %v", tab.BlockIds)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// sysinfo samples are published (and persisted) about once a second per connection (see wshremote.RunSysInfoLoop)
+const SysInfoPollInterval = time.Second
+const SysInfoStreamBufferSize = 16
+
+// defaults for sysinfo:historysecs and sysinfo:historyresolutionms
+const DefaultSysInfoHistorySecs = 600
+const DefaultSysInfoHistoryResolutionMs = 1000
+
+var sysInfoHistoryLock = &sync.Mutex{}
+var sysInfoHistoryMap = make(map[string]*wshrpc.TimeSeriesHistory) // conn => history
+
+func getSysInfoHistorySettings() (resolutionMs int64, retentionMs int64) {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	resolutionMs = int64(settings.SysInfoHistoryResolutionMs)
+	if resolutionMs <= 0 {
+		resolutionMs = DefaultSysInfoHistoryResolutionMs
+	}
+	retentionSecs := settings.SysInfoHistorySecs
+	if retentionSecs <= 0 {
+		retentionSecs = DefaultSysInfoHistorySecs
+	}
+	return resolutionMs, int64(retentionSecs * 1000)
+}
+
+// history is reset if the settings change
+func getSysInfoHistory(conn string, create bool) *wshrpc.TimeSeriesHistory {
+	resolutionMs, retentionMs := getSysInfoHistorySettings()
+	sysInfoHistoryLock.Lock()
+	defer sysInfoHistoryLock.Unlock()
+	history := sysInfoHistoryMap[conn]
+	if history != nil && history.Matches(resolutionMs, retentionMs) {
+		return history
+	}
+	if !create {
+		return nil
+	}
+	history = wshrpc.MakeTimeSeriesHistory(resolutionMs, retentionMs)
+	sysInfoHistoryMap[conn] = history
+	return history
+}
+
+func recordSysInfoEvent(event wps.WaveEvent) {
+	var sample wshrpc.TimeSeriesData
+	err := utilfn.ReUnmarshal(&sample, event.Data)
+	if err != nil {
+		return
+	}
+	for _, conn := range event.Scopes {
+		getSysInfoHistory(conn, true).Add(sample)
+	}
+}
+
+// a disconnected conn's history is dropped (it restarts when the conn reconnects and publishes again)
+func handleSysInfoConnChange(event wps.WaveEvent) {
+	var status wshrpc.ConnStatus
+	err := utilfn.ReUnmarshal(&status, event.Data)
+	if err != nil || status.Connection == "" || status.Connected {
+		return
+	}
+	sysInfoHistoryLock.Lock()
+	defer sysInfoHistoryLock.Unlock()
+	delete(sysInfoHistoryMap, status.Connection)
+}
+
+// records the sysinfo samples from every publisher (rpc or in-process), returns the unsubscribe function
+func subscribeSysInfoHistory() func() {
+	unsubSysInfo := wps.Broker.SubscribeLocal(wps.Event_SysInfo, "", recordSysInfoEvent)
+	unsubConnChange := wps.Broker.SubscribeLocal(wps.Event_ConnChange, "", handleSysInfoConnChange)
+	return func() {
+		unsubSysInfo()
+		unsubConnChange()
+	}
+}
+
+// called once at startup, before the sysinfo loops start
+func InitSysInfoHistory() {
+	subscribeSysInfoHistory()
+}
+
+func (ws *WshServer) GetCpuHistoryCommand(ctx context.Context, data wshrpc.CpuHistoryRequest) ([]wshrpc.TimeSeriesData, error) {
+	conn := data.Conn
+	if conn == "" {
+		conn = wshrpc.LocalConnName
+	}
+	history := getSysInfoHistory(conn, false)
+	if history == nil {
+		return []wshrpc.TimeSeriesData{}, nil
+	}
+	return history.Read(data.SinceTs, data.MaxItems), nil
+}
+
+// streams sysinfo samples (all metrics) from subscribe time forward, optionally downsampled (see wshrpc.TimeSeriesAggregator)
+func (ws *WshServer) StreamCpuDataCommand(ctx context.Context, request wshrpc.CpuDataRequest) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData], 16)
+	agg, err := wshrpc.MakeDownsampleAggregator(request.Downsample)
+	if err != nil {
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Error: err}
+		close(rtn)
+		return rtn
+	}
+	conn := request.Conn
+	if conn == "" {
+		conn = wshrpc.LocalConnName
+	}
+	// a slow consumer drops samples (the aggregation tolerates gaps) rather than blocking the publisher
+	eventCh := make(chan wps.WaveEvent, SysInfoStreamBufferSize)
+	unsubFn := wps.Broker.SubscribeLocal(wps.Event_SysInfo, conn, func(event wps.WaveEvent) {
+		select {
+		case eventCh <- event:
+		default:
+		}
+	})
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("StreamCpuDataCommand", recover())
+		}()
+		defer close(rtn)
+		defer unsubFn()
+		var flushCh <-chan time.Time
+		if agg != nil {
+			// samples have stopped (or the conn went away), emit the open bucket once it is overdue
+			ticker := time.NewTicker(SysInfoPollInterval)
+			defer ticker.Stop()
+			flushCh = ticker.C
+		}
+		var lastTs int64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-eventCh:
+				var sample wshrpc.TimeSeriesData
+				if err := utilfn.ReUnmarshal(&sample, event.Data); err != nil || sample.Ts <= lastTs {
+					continue
+				}
+				lastTs = sample.Ts
+				if !wshrpc.SendTimeSeriesSample(ctx, rtn, agg, sample) {
+					return
+				}
+			case <-flushCh:
+				// samples lag by up to a poll interval, don't cut a bucket off before they arrive
+				bucket := agg.FlushBefore(time.Now().UnixMilli() - SysInfoPollInterval.Milliseconds())
+				if bucket != nil && !wshrpc.SendTimeSeriesSample(ctx, rtn, nil, *bucket) {
+					return
+				}
+			}
+		}
+	}()
+	return rtn
+}
@@ -0,0 +1,108 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func publishTestSysInfo(conn string, ts int64, cpu float64) {
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_SysInfo,
+		Scopes: []string{conn},
+		Data:   wshrpc.TimeSeriesData{Ts: ts, Values: map[string]float64{"cpu": cpu}},
+	})
+}
+
+func TestSysInfoHistory(t *testing.T) {
+	unsubFn := subscribeSysInfoHistory()
+	defer unsubFn()
+	ctx := context.Background()
+	ws := &WshServer{}
+	conn := uuid.NewString()
+
+	// published in-process (not through EventPublishCommand)
+	const baseTs = 1_000_000
+	for ts := int64(baseTs); ts <= baseTs+3000; ts += 500 {
+		publishTestSysInfo(conn, ts, float64(ts))
+	}
+	samples, err := ws.GetCpuHistoryCommand(ctx, wshrpc.CpuHistoryRequest{Conn: conn})
+	if err != nil {
+		t.Fatalf("error getting history: %v", err)
+	}
+	if len(samples) != 3 || samples[0].Ts != baseTs || samples[2].Ts != baseTs+2000 {
+		t.Fatalf("expected the 3 completed buckets, got %v", samples)
+	}
+
+	wps.Broker.Publish(wps.MakeConnChangeEvent(conn, wshrpc.ConnStatus{Connection: conn, Status: "connected", Connected: true}))
+	if samples, _ := ws.GetCpuHistoryCommand(ctx, wshrpc.CpuHistoryRequest{Conn: conn}); len(samples) != 3 {
+		t.Errorf("expected the history to be kept while connected, got %v", samples)
+	}
+	wps.Broker.Publish(wps.MakeConnChangeEvent(conn, wshrpc.ConnStatus{Connection: conn, Status: "disconnected"}))
+	if samples, _ := ws.GetCpuHistoryCommand(ctx, wshrpc.CpuHistoryRequest{Conn: conn}); len(samples) != 0 {
+		t.Errorf("expected the history to be dropped on disconnect, got %v", samples)
+	}
+	sysInfoHistoryLock.Lock()
+	_, found := sysInfoHistoryMap[conn]
+	sysInfoHistoryLock.Unlock()
+	if found {
+		t.Errorf("expected the disconnected conn to be removed from the history map")
+	}
+}
+
+func recvTimeSeries(t *testing.T, ch chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]) wshrpc.TimeSeriesData {
+	t.Helper()
+	select {
+	case respUnion, ok := <-ch:
+		if !ok {
+			t.Fatalf("stream closed early")
+		}
+		if respUnion.Error != nil {
+			t.Fatalf("stream error: %v", respUnion.Error)
+		}
+		return respUnion.Response
+	// well under the flush interval, so the samples must come from the subscription
+	case <-time.After(SysInfoPollInterval / 2):
+		t.Fatalf("timed out waiting for a sample")
+	}
+	return wshrpc.TimeSeriesData{}
+}
+
+func TestStreamCpuDataCommand(t *testing.T) {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	ws := &WshServer{}
+	conn := uuid.NewString()
+	rawCh := ws.StreamCpuDataCommand(ctx, wshrpc.CpuDataRequest{Conn: conn})
+	aggCh := ws.StreamCpuDataCommand(ctx, wshrpc.CpuDataRequest{Conn: conn, Downsample: &wshrpc.DownsampleSpec{BucketMs: 1000, Agg: wshrpc.TimeSeriesAgg_Max}})
+	// the subscriptions are registered before the command returns
+	const baseTs = 1_000_000
+	for ts := int64(baseTs); ts <= baseTs+1000; ts += 500 {
+		wps.Broker.Publish(wps.WaveEvent{
+			Event:  wps.Event_SysInfo,
+			Scopes: []string{conn},
+			Data:   wshrpc.TimeSeriesData{Ts: ts, Values: map[string]float64{"cpu": float64(ts - baseTs), "mem:used": 5}},
+		})
+	}
+	publishTestSysInfo(uuid.NewString(), baseTs+1500, 1) // another conn
+	for _, expectedTs := range []int64{baseTs, baseTs + 500, baseTs + 1000} {
+		if sample := recvTimeSeries(t, rawCh); sample.Ts != expectedTs || sample.Values["mem:used"] != 5 {
+			t.Errorf("expected the raw sample at %d (with every metric), got %v", expectedTs, sample)
+		}
+	}
+	if bucket := recvTimeSeries(t, aggCh); bucket.Ts != baseTs || bucket.Values["cpu"] != 500 || bucket.Values["mem:used"] != 5 {
+		t.Errorf("expected the max over the first bucket, got %v", bucket)
+	}
+
+	badCh := ws.StreamCpuDataCommand(ctx, wshrpc.CpuDataRequest{Conn: conn, Downsample: &wshrpc.DownsampleSpec{BucketMs: 0}})
+	if respUnion := <-badCh; respUnion.Error == nil {
+		t.Errorf("expected an invalid downsample spec to be rejected")
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// a tail subscribes to blockfile events before reading the backfill.  append events carry the
+// offset they were written at (taken under the file lock), so data already covered by the backfill
+// is skipped (or trimmed).
+
+const FileTailEventBufferSize = 64
+
+func getFileEventData(event wps.WaveEvent) *wps.WSFileEventData {
+	switch data := event.Data.(type) {
+	case *wps.WSFileEventData:
+		return data
+	case wps.WSFileEventData:
+		return &data
+	}
+	var rtn wps.WSFileEventData
+	if err := utilfn.ReUnmarshal(&rtn, event.Data); err != nil {
+		return nil
+	}
+	return &rtn
+}
+
+// returns the index where the last numLines lines of data start (a trailing newline doesn't start a new line)
+func lastLinesStart(data []byte, numLines int) int {
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	count := 0
+	for idx := end - 1; idx >= 0; idx-- {
+		if data[idx] == '\n' {
+			count++
+			if count == numLines {
+				return idx + 1
+			}
+		}
+	}
+	return 0
+}
+
+// returns the backfill chunk (nil if the file is empty or doesn't exist yet) and the file offset it ends at
+func readFileTailBackfill(ctx context.Context, data wshrpc.CommandFileTailData, backfillBytes int64) (*wshrpc.FileReadChunk, int64, error) {
+	file, err := filestore.WFS.Stat(ctx, data.ZoneId, data.FileName)
+	if err == fs.ErrNotExist {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting blockfile info: %w", err)
+	}
+	if file.Size == 0 {
+		return nil, 0, nil
+	}
+	offset := max(file.Size-backfillBytes, 0)
+	rtnOffset, dataBuf, err := filestore.WFS.ReadAt(ctx, data.ZoneId, data.FileName, offset, file.Size-offset)
+	if err == fs.ErrNotExist {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading blockfile: %w", err)
+	}
+	endOffset := rtnOffset + int64(len(dataBuf))
+	if data.BackfillLines > 0 {
+		startIdx := lastLinesStart(dataBuf, data.BackfillLines)
+		dataBuf = dataBuf[startIdx:]
+		rtnOffset += int64(startIdx)
+	}
+	if len(dataBuf) == 0 {
+		return nil, endOffset, nil
+	}
+	return &wshrpc.FileReadChunk{Data64: base64.StdEncoding.EncodeToString(dataBuf), Offset: rtnOffset, Backfill: true}, endOffset, nil
+}
+
+// returns the chunk for an append event (false if it is already covered by endOffset), advances endOffset
+func makeFileTailAppendChunk(fileEvent *wps.WSFileEventData, endOffset *int64) (wshrpc.FileReadChunk, bool) {
+	dataBuf, err := base64.StdEncoding.DecodeString(fileEvent.Data64)
+	if err != nil || len(dataBuf) == 0 {
+		return wshrpc.FileReadChunk{}, false
+	}
+	if fileEvent.Size <= 0 {
+		// size unknown, assume it directly follows what we've sent
+		chunk := wshrpc.FileReadChunk{Data64: fileEvent.Data64, Offset: *endOffset}
+		*endOffset += int64(len(dataBuf))
+		return chunk, true
+	}
+	startOffset := fileEvent.Offset
+	appendEnd := startOffset + int64(len(dataBuf))
+	if appendEnd <= *endOffset {
+		return wshrpc.FileReadChunk{}, false
+	}
+	if startOffset < *endOffset {
+		dataBuf = dataBuf[*endOffset-startOffset:]
+		startOffset = *endOffset
+	}
+	*endOffset = appendEnd
+	return wshrpc.FileReadChunk{Data64: base64.StdEncoding.EncodeToString(dataBuf), Offset: startOffset}, true
+}
+
+// backfills the tail of a zone file then streams its appends until canceled
+func (ws *WshServer) FileTailCommand(ctx context.Context, data wshrpc.CommandFileTailData) chan wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk], 16)
+	if data.ZoneId == "" || data.FileName == "" {
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk]{Error: fmt.Errorf("zoneid and filename are required")}
+		close(rtn)
+		return rtn
+	}
+	backfillBytes := data.BackfillBytes
+	if backfillBytes <= 0 {
+		backfillBytes = wshrpc.DefaultFileTailBackfillBytes
+	}
+	backfillBytes = min(backfillBytes, wshrpc.MaxFileTailBackfillBytes)
+	eventCh := make(chan *wps.WSFileEventData, FileTailEventBufferSize)
+	overflowCh := make(chan struct{})
+	var overflowOnce sync.Once
+	scope := waveobj.MakeORef(waveobj.OType_Block, data.ZoneId).String()
+	unsubFn := wps.Broker.SubscribeLocal(wps.Event_BlockFile, scope, func(event wps.WaveEvent) {
+		fileEvent := getFileEventData(event)
+		if fileEvent == nil || fileEvent.ZoneId != data.ZoneId || fileEvent.FileName != data.FileName {
+			return
+		}
+		select {
+		case eventCh <- fileEvent:
+		default:
+			overflowOnce.Do(func() { close(overflowCh) })
+		}
+	})
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("FileTailCommand", recover())
+		}()
+		defer close(rtn)
+		defer unsubFn()
+		send := func(chunk wshrpc.FileReadChunk) bool {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk]{Response: chunk}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		backfill := func() (int64, bool) {
+			chunk, endOffset, err := readFileTailBackfill(ctx, data, backfillBytes)
+			if err != nil {
+				rtn <- wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk]{Error: err}
+				return 0, false
+			}
+			if chunk != nil && !send(*chunk) {
+				return 0, false
+			}
+			return endOffset, true
+		}
+		endOffset, ok := backfill()
+		if !ok {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-overflowCh:
+				rtn <- wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk]{Error: fmt.Errorf("tail of %q fell behind", data.FileName)}
+				return
+			case fileEvent := <-eventCh:
+				if fileEvent.FileOp == wps.FileOp_Append {
+					chunk, ok := makeFileTailAppendChunk(fileEvent, &endOffset)
+					if ok && !send(chunk) {
+						return
+					}
+					continue
+				}
+				// truncate, delete, create or invalidate, start over from the current contents
+				if !send(wshrpc.FileReadChunk{Reset: true}) {
+					return
+				}
+				endOffset, ok = backfill()
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return rtn
+}
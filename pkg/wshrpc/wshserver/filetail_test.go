@@ -0,0 +1,161 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the filestore and wstore are process globals, so they are opened once (in a temp data dir) for all tests
+func TestMain(m *testing.M) {
+	dataDir, err := os.MkdirTemp("", "wshserver-test")
+	if err != nil {
+		log.Fatalf("error creating data dir: %v", err)
+	}
+	wavebase.DataHome_VarCache = dataDir
+	if err := os.MkdirAll(filepath.Join(dataDir, wavebase.WaveDBDir), 0700); err != nil {
+		log.Fatalf("error creating db dir: %v", err)
+	}
+	if err := filestore.InitFilestore(); err != nil {
+		log.Fatalf("error initializing filestore: %v", err)
+	}
+	if err := wstore.InitWStore(); err != nil {
+		log.Fatalf("error initializing wstore: %v", err)
+	}
+	rtn := m.Run()
+	os.RemoveAll(dataDir)
+	os.Exit(rtn)
+}
+
+func TestLastLinesStart(t *testing.T) {
+	tests := []struct {
+		data     string
+		numLines int
+		expected int
+	}{
+		{"a\nb\nc\n", 2, 2},
+		{"a\nb\nc", 2, 2},
+		{"a\nb\nc\n", 1, 4},
+		{"a\nb\nc\n", 5, 0},
+		{"", 1, 0},
+		{"\n\n", 1, 1},
+	}
+	for _, tc := range tests {
+		if got := lastLinesStart([]byte(tc.data), tc.numLines); got != tc.expected {
+			t.Errorf("lastLinesStart(%q, %d): expected %d, got %d", tc.data, tc.numLines, tc.expected, got)
+		}
+	}
+}
+
+func makeTestAppendEvent(data string, offset int64) *wps.WSFileEventData {
+	return &wps.WSFileEventData{
+		FileOp: wps.FileOp_Append,
+		Data64: base64.StdEncoding.EncodeToString([]byte(data)),
+		Size:   offset + int64(len(data)),
+		Offset: offset,
+	}
+}
+
+func TestMakeFileTailAppendChunk(t *testing.T) {
+	tests := []struct {
+		name        string
+		event       *wps.WSFileEventData
+		endOffset   int64
+		expectedOk  bool
+		expectedBuf string
+		expectedOff int64
+		expectedEnd int64
+	}{
+		{"follows", makeTestAppendEvent("abc", 10), 10, true, "abc", 10, 13},
+		{"covered by backfill", makeTestAppendEvent("abc", 10), 13, false, "", 0, 13},
+		{"overlaps backfill", makeTestAppendEvent("abcdef", 10), 13, true, "def", 13, 16},
+		{"unknown size", &wps.WSFileEventData{FileOp: wps.FileOp_Append, Data64: base64.StdEncoding.EncodeToString([]byte("xy"))}, 5, true, "xy", 5, 7},
+		{"empty", makeTestAppendEvent("", 10), 10, false, "", 0, 10},
+	}
+	for _, tc := range tests {
+		endOffset := tc.endOffset
+		chunk, ok := makeFileTailAppendChunk(tc.event, &endOffset)
+		if ok != tc.expectedOk || endOffset != tc.expectedEnd {
+			t.Errorf("%s: expected ok:%v end:%d, got ok:%v end:%d", tc.name, tc.expectedOk, tc.expectedEnd, ok, endOffset)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		buf, _ := base64.StdEncoding.DecodeString(chunk.Data64)
+		if string(buf) != tc.expectedBuf || chunk.Offset != tc.expectedOff {
+			t.Errorf("%s: expected %q at %d, got %q at %d", tc.name, tc.expectedBuf, tc.expectedOff, buf, chunk.Offset)
+		}
+	}
+}
+
+func recvFileTailChunk(t *testing.T, ch chan wshrpc.RespOrErrorUnion[wshrpc.FileReadChunk]) wshrpc.FileReadChunk {
+	t.Helper()
+	select {
+	case respUnion, ok := <-ch:
+		if !ok {
+			t.Fatalf("tail closed early")
+		}
+		if respUnion.Error != nil {
+			t.Fatalf("tail error: %v", respUnion.Error)
+		}
+		return respUnion.Response
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for a tail chunk")
+	}
+	return wshrpc.FileReadChunk{}
+}
+
+func TestFileTailCommand(t *testing.T) {
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	ws := &WshServer{}
+	zoneId := uuid.NewString()
+	if err := filestore.WFS.MakeFile(ctx, zoneId, "out", nil, filestore.FileOptsType{}); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	filestore.WFS.AppendData(ctx, zoneId, "out", []byte("line1\nline2\nline3\n"))
+	tailCh := ws.FileTailCommand(ctx, wshrpc.CommandFileTailData{ZoneId: zoneId, FileName: "out", BackfillLines: 2})
+	chunk := recvFileTailChunk(t, tailCh)
+	buf, _ := base64.StdEncoding.DecodeString(chunk.Data64)
+	if !chunk.Backfill || string(buf) != "line2\nline3\n" || chunk.Offset != 6 {
+		t.Errorf("unexpected backfill %q at %d (backfill:%v)", buf, chunk.Offset, chunk.Backfill)
+	}
+
+	appendData := wshrpc.CommandFileData{ZoneId: zoneId, FileName: "out", Data64: base64.StdEncoding.EncodeToString([]byte("line4\n"))}
+	if err := ws.FileAppendCommand(ctx, appendData); err != nil {
+		t.Fatalf("error appending: %v", err)
+	}
+	chunk = recvFileTailChunk(t, tailCh)
+	buf, _ = base64.StdEncoding.DecodeString(chunk.Data64)
+	if chunk.Backfill || string(buf) != "line4\n" || chunk.Offset != 18 {
+		t.Errorf("unexpected append %q at %d", buf, chunk.Offset)
+	}
+
+	blockFileEvents.publish(&wps.WSFileEventData{ZoneId: zoneId, FileName: "out", FileOp: wps.FileOp_Truncate})
+	if chunk = recvFileTailChunk(t, tailCh); !chunk.Reset {
+		t.Errorf("expected a reset after a truncate, got %+v", chunk)
+	}
+	// the file wasn't actually truncated, so the reset is followed by a new backfill
+	if chunk = recvFileTailChunk(t, tailCh); !chunk.Backfill {
+		t.Errorf("expected a backfill after the reset, got %+v", chunk)
+	}
+
+	cancelFn()
+	for range tailCh {
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"runtime"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func (ws *WshServer) OpenResourceCommand(ctx context.Context, data wshrpc.CommandOpenResourceData) (*waveobj.ORef, error) {
+	meta, err := makeOpenResourceMeta(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return ws.CreateBlockCommand(ctx, wshrpc.CommandCreateBlockData{
+		TabId:     data.TabId,
+		BlockDef:  &waveobj.BlockDef{Meta: meta},
+		Magnified: data.Magnified,
+	})
+}
+
+func makeOpenResourceMeta(ctx context.Context, data wshrpc.CommandOpenResourceData) (waveobj.MetaMapType, error) {
+	conn, path := data.Conn, data.Path
+	if data.Url != "" {
+		if path != "" {
+			return nil, fmt.Errorf("url and path cannot both be set")
+		}
+		parsedUrl, err := url.Parse(data.Url)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing url %q: %w", data.Url, err)
+		}
+		switch parsedUrl.Scheme {
+		case "http", "https":
+			return waveobj.MetaMapType{
+				waveobj.MetaKey_View: wshrpc.ResourceView_Web,
+				waveobj.MetaKey_Url:  data.Url,
+			}, nil
+		case "file":
+			if conn != "" && conn != wshrpc.LocalConnName {
+				return nil, fmt.Errorf("file urls cannot be opened on connection %q, use path instead", conn)
+			}
+			path, err = fileUrlToPath(parsedUrl, runtime.GOOS == "windows")
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported url scheme %q (expected http, https, or file)", parsedUrl.Scheme)
+		}
+	}
+	if path == "" {
+		return nil, fmt.Errorf("url or path is required")
+	}
+	if conn == "" {
+		conn = wshrpc.LocalConnName
+	}
+	rpcOpts := wshrpc.RpcOptsFromContext(ctx, &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(conn)})
+	finfo, err := wshclient.RemoteFileInfoCommand(GetMainRpcClient(), path, rpcOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file info for %q on %q: %w", path, conn, err)
+	}
+	if finfo.NotFound {
+		return nil, fmt.Errorf("%q not found on %q", path, conn)
+	}
+	view, edit, err := wshrpc.GetResourceView(finfo.MimeType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q: %w", path, err)
+	}
+	meta := waveobj.MetaMapType{
+		waveobj.MetaKey_View: view,
+		waveobj.MetaKey_File: finfo.Path,
+	}
+	if edit {
+		meta[waveobj.MetaKey_Edit] = true
+	}
+	if conn != wshrpc.LocalConnName {
+		meta[waveobj.MetaKey_Connection] = conn
+	}
+	return meta, nil
+}
+
+// on windows file:///C:/dir/file is C:\dir\file (the url path has a slash before the drive), and
+// file://host/share/file is the unc path \\host\share\file.  other systems only take local file urls.
+func fileUrlToPath(fileUrl *url.URL, windows bool) (string, error) {
+	host := fileUrl.Host
+	if host == "localhost" {
+		host = ""
+	}
+	path := fileUrl.Path
+	if !windows {
+		if host != "" {
+			return "", fmt.Errorf("file url %q is not on this machine (host %q)", fileUrl.String(), host)
+		}
+		return path, nil
+	}
+	if host != "" {
+		return `\\` + host + strings.ReplaceAll(path, "/", `\`), nil
+	}
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return strings.ReplaceAll(path, "/", `\`), nil
+}
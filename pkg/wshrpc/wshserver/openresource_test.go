@@ -0,0 +1,35 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestFileUrlToPath(t *testing.T) {
+	tests := []struct {
+		fileUrl     string
+		windows     bool
+		expected    string
+		expectedErr bool
+	}{
+		{"file:///home/user/a%20b.txt", false, "/home/user/a b.txt", false},
+		{"file://localhost/home/user/a.txt", false, "/home/user/a.txt", false},
+		{"file://server/share/a.txt", false, "", true},
+		{"file:///C:/Users/user/a%20b.txt", true, `C:\Users\user\a b.txt`, false},
+		{"file://localhost/C:/a.txt", true, `C:\a.txt`, false},
+		{"file://server/share/dir/a.txt", true, `\\server\share\dir\a.txt`, false},
+	}
+	for _, tc := range tests {
+		parsedUrl, err := url.Parse(tc.fileUrl)
+		if err != nil {
+			t.Fatalf("error parsing %q: %v", tc.fileUrl, err)
+		}
+		path, err := fileUrlToPath(parsedUrl, tc.windows)
+		if (err != nil) != tc.expectedErr || path != tc.expected {
+			t.Errorf("%q (windows:%v): expected %q (err:%v), got %q (err:%v)", tc.fileUrl, tc.windows, tc.expected, tc.expectedErr, path, err)
+		}
+	}
+}
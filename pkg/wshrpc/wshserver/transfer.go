@@ -0,0 +1,178 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// files are relayed through wavesrv (remotes can't reach each other directly), so transfers
+// are bounded by wshremote.MaxFileSize.  same-connection transfers are copied on the remote.
+
+const TransferTimeoutMs = 5 * 60 * 1000
+const TransferProgressInterval = 250 * time.Millisecond
+
+type transferEndpoint struct {
+	Side  string // "source" or "destination"
+	Conn  string
+	Path  string
+	Route string
+}
+
+func makeTransferEndpoint(side string, conn string, path string) transferEndpoint {
+	if conn == "" {
+		conn = wshrpc.LocalConnName
+	}
+	return transferEndpoint{Side: side, Conn: conn, Path: path, Route: wshutil.MakeConnectionRouteId(conn)}
+}
+
+func (e transferEndpoint) rpcOpts() *wshrpc.RpcOpts {
+	return &wshrpc.RpcOpts{Route: e.Route, Timeout: TransferTimeoutMs}
+}
+
+// permission errors come back from the remote as plain strings
+func (e transferEndpoint) wrapErr(err error) error {
+	if strings.Contains(strings.ToLower(err.Error()), "permission denied") {
+		return fmt.Errorf("permission denied for %s %q on %q: %w", e.Side, e.Path, e.Conn, err)
+	}
+	return fmt.Errorf("error accessing %s %q on %q: %w", e.Side, e.Path, e.Conn, err)
+}
+
+func (ws *WshServer) RemoteTransferCommand(ctx context.Context, data wshrpc.CommandRemoteTransferData) chan wshrpc.RespOrErrorUnion[wshrpc.RemoteTransferProgress] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.RemoteTransferProgress], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RemoteTransferCommand", recover())
+		}()
+		defer close(rtn)
+		err := runRemoteTransfer(ctx, data, func(progress wshrpc.RemoteTransferProgress) {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.RemoteTransferProgress]{Response: progress}
+		})
+		if err != nil {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.RemoteTransferProgress]{Error: err}
+		}
+	}()
+	return rtn
+}
+
+func runRemoteTransfer(ctx context.Context, data wshrpc.CommandRemoteTransferData, progressFn func(wshrpc.RemoteTransferProgress)) error {
+	src := makeTransferEndpoint("source", data.SrcConn, data.SrcPath)
+	dest := makeTransferEndpoint("destination", data.DestConn, data.DestPath)
+	client := GetMainRpcClient()
+	srcInfo, err := wshclient.RemoteFileInfoCommand(client, src.Path, wshrpc.RpcOptsFromContext(ctx, &wshrpc.RpcOpts{Route: src.Route}))
+	if err != nil {
+		return src.wrapErr(err)
+	}
+	if srcInfo.NotFound {
+		return fmt.Errorf("source %q not found on %q", src.Path, src.Conn)
+	}
+	if srcInfo.IsDir {
+		return fmt.Errorf("cannot transfer directory %q, use the archive commands", src.Path)
+	}
+	destInfo, err := wshclient.RemoteFileInfoCommand(client, dest.Path, wshrpc.RpcOptsFromContext(ctx, &wshrpc.RpcOpts{Route: dest.Route}))
+	if err != nil {
+		return dest.wrapErr(err)
+	}
+	if !destInfo.NotFound {
+		return fmt.Errorf("destination %q already exists on %q", dest.Path, dest.Conn)
+	}
+	if destInfo.ReadOnly {
+		return fmt.Errorf("permission denied for destination %q on %q: not writable", dest.Path, dest.Conn)
+	}
+	var srcChecksum string
+	if src.Conn == dest.Conn {
+		progressFn(wshrpc.RemoteTransferProgress{Phase: wshrpc.TransferPhase_Copy, TotalBytes: srcInfo.Size})
+		_, err = wshclient.RemoteFileCopyCommand(client, wshrpc.CommandRemoteFileCopyData{SrcPath: src.Path, DestPath: dest.Path}, src.rpcOpts())
+		if err != nil {
+			return dest.wrapErr(err)
+		}
+		srcChecksum, err = wshclient.RemoteFileChecksumCommand(client, src.Path, src.rpcOpts())
+		if err != nil {
+			return src.wrapErr(err)
+		}
+	} else {
+		srcChecksum, err = relayTransferFile(ctx, src, dest, srcInfo, progressFn)
+		if err != nil {
+			return err
+		}
+	}
+	progressFn(wshrpc.RemoteTransferProgress{Phase: wshrpc.TransferPhase_Verify, BytesTransferred: srcInfo.Size, TotalBytes: srcInfo.Size})
+	destChecksum, err := wshclient.RemoteFileChecksumCommand(client, dest.Path, dest.rpcOpts())
+	if err != nil {
+		return dest.wrapErr(err)
+	}
+	if destChecksum != srcChecksum {
+		return fmt.Errorf("checksum mismatch after transfer to %q on %q (source %s, destination %s)", dest.Path, dest.Conn, srcChecksum, destChecksum)
+	}
+	progressFn(wshrpc.RemoteTransferProgress{Phase: wshrpc.TransferPhase_Done, BytesTransferred: srcInfo.Size, TotalBytes: srcInfo.Size, Checksum: srcChecksum})
+	return nil
+}
+
+// streams the source file into memory (hashing as it goes) and writes it to the destination, returns the source checksum
+func relayTransferFile(ctx context.Context, src transferEndpoint, dest transferEndpoint, srcInfo *wshrpc.FileInfo, progressFn func(wshrpc.RemoteTransferProgress)) (string, error) {
+	client := GetMainRpcClient()
+	streamOpts := src.rpcOpts()
+	if err := ApplyConnReadLimit(src.Conn); err != nil {
+		log.Printf("transfer: %v\n", err)
+	}
+	streamData := wshrpc.CommandRemoteStreamFileData{Path: src.Path}
+	streamCh := wshclient.RemoteStreamFileCommand(client, streamData, streamOpts)
+	hasher := sha256.New()
+	var fileData []byte
+	var lastProgress time.Time
+	for {
+		var respUnion wshrpc.RespOrErrorUnion[wshrpc.CommandRemoteStreamFileRtnData]
+		var ok bool
+		select {
+		case <-ctx.Done():
+			if streamOpts.StreamCancelFn != nil {
+				streamOpts.StreamCancelFn()
+			}
+			go func() {
+				for range streamCh {
+				}
+			}()
+			return "", ctx.Err()
+		case respUnion, ok = <-streamCh:
+		}
+		if !ok {
+			break
+		}
+		if respUnion.Error != nil {
+			return "", src.wrapErr(respUnion.Error)
+		}
+		if respUnion.Response.Data64 == "" {
+			continue
+		}
+		chunk, err := base64.StdEncoding.DecodeString(respUnion.Response.Data64)
+		if err != nil {
+			return "", fmt.Errorf("error decoding source data: %w", err)
+		}
+		RecordConnRead(src.Conn, len(chunk))
+		hasher.Write(chunk)
+		fileData = append(fileData, chunk...)
+		if time.Since(lastProgress) >= TransferProgressInterval {
+			lastProgress = time.Now()
+			progressFn(wshrpc.RemoteTransferProgress{Phase: wshrpc.TransferPhase_Transfer, BytesTransferred: int64(len(fileData)), TotalBytes: srcInfo.Size})
+		}
+	}
+	progressFn(wshrpc.RemoteTransferProgress{Phase: wshrpc.TransferPhase_Transfer, BytesTransferred: int64(len(fileData)), TotalBytes: srcInfo.Size})
+	err := WriteConnFile(ctx, dest.Conn, dest.Path, fileData, srcInfo.Mode.Perm())
+	if err != nil {
+		return "", dest.wrapErr(err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
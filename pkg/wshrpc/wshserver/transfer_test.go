@@ -0,0 +1,140 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshremote"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const (
+	transferTestConnA = "transfer-test-a"
+	transferTestConnB = "transfer-test-b"
+)
+
+var transferRoutesOnce sync.Once
+
+// both connections are served in-process (on the local filesystem)
+func setupTransferRoutes() {
+	transferRoutesOnce.Do(func() {
+		wshutil.DefaultRouter.RegisterRoute("test:transfer-main", GetMainRpcClient(), false)
+		for _, conn := range []string{transferTestConnA, transferTestConnB} {
+			connRpc := wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{Conn: conn}, &wshremote.ServerImpl{LogWriter: io.Discard})
+			wshutil.DefaultRouter.RegisterRoute(wshutil.MakeConnectionRouteId(conn), connRpc, false)
+		}
+	})
+}
+
+func runTestTransfer(data wshrpc.CommandRemoteTransferData) ([]wshrpc.RemoteTransferProgress, error) {
+	ws := &WshServer{}
+	var progress []wshrpc.RemoteTransferProgress
+	var rtnErr error
+	for respUnion := range ws.RemoteTransferCommand(context.Background(), data) {
+		if respUnion.Error != nil {
+			rtnErr = respUnion.Error
+			continue
+		}
+		progress = append(progress, respUnion.Response)
+	}
+	return progress, rtnErr
+}
+
+func TestRemoteTransferCommand(t *testing.T) {
+	setupTransferRoutes()
+	dir := t.TempDir()
+	data := []byte(strings.Repeat("transfer data\n", 10000))
+	srcPath := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(srcPath, data, 0640); err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(data)
+	checksum := hex.EncodeToString(hash[:])
+
+	tests := []struct {
+		name          string
+		destConn      string
+		expectedPhase string // the phase that moved the data
+	}{
+		{"relayed", transferTestConnB, wshrpc.TransferPhase_Transfer},
+		{"same connection", transferTestConnA, wshrpc.TransferPhase_Copy},
+	}
+	for _, tc := range tests {
+		destPath := filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "-")+".txt")
+		progress, err := runTestTransfer(wshrpc.CommandRemoteTransferData{SrcConn: transferTestConnA, SrcPath: srcPath, DestConn: tc.destConn, DestPath: destPath})
+		if err != nil {
+			t.Fatalf("%s: error transferring: %v", tc.name, err)
+		}
+		if len(progress) < 3 || progress[0].Phase != tc.expectedPhase {
+			t.Fatalf("%s: expected to start with the %q phase, got %+v", tc.name, tc.expectedPhase, progress)
+		}
+		done := progress[len(progress)-1]
+		if done.Phase != wshrpc.TransferPhase_Done || done.Checksum != checksum || done.BytesTransferred != int64(len(data)) {
+			t.Errorf("%s: unexpected final progress %+v", tc.name, done)
+		}
+		destData, err := os.ReadFile(destPath)
+		if err != nil || string(destData) != string(data) {
+			t.Errorf("%s: expected the destination to match the source (err:%v)", tc.name, err)
+		}
+		if finfo, err := os.Stat(destPath); err != nil || finfo.Mode().Perm() != 0640 {
+			t.Errorf("%s: expected the source mode to be kept, got %v (err:%v)", tc.name, finfo.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestRemoteTransferErrors(t *testing.T) {
+	setupTransferRoutes()
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.txt")
+	existingPath := filepath.Join(dir, "existing.txt")
+	for _, path := range []string{srcPath, existingPath} {
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name        string
+		srcPath     string
+		destPath    string
+		expectedErr string
+	}{
+		{"missing source", filepath.Join(dir, "missing.txt"), filepath.Join(dir, "out1.txt"), "not found"},
+		{"directory source", dir, filepath.Join(dir, "out2.txt"), "cannot transfer directory"},
+		{"existing destination", srcPath, existingPath, "already exists"},
+	}
+	for _, tc := range tests {
+		_, err := runTestTransfer(wshrpc.CommandRemoteTransferData{SrcConn: transferTestConnA, SrcPath: tc.srcPath, DestConn: transferTestConnB, DestPath: tc.destPath})
+		if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+			t.Errorf("%s: expected an error containing %q, got %v", tc.name, tc.expectedErr, err)
+		}
+	}
+	if data, _ := os.ReadFile(existingPath); string(data) != "data" {
+		t.Errorf("expected the existing destination to be left alone, got %q", data)
+	}
+}
+
+func TestTransferEndpointWrapErr(t *testing.T) {
+	dest := makeTransferEndpoint("destination", "", "/tmp/out.txt")
+	if dest.Conn != wshrpc.LocalConnName || dest.Route != wshutil.MakeConnectionRouteId(wshrpc.LocalConnName) {
+		t.Errorf("expected an empty conn to be the local connection, got %+v", dest)
+	}
+	err := dest.wrapErr(io.ErrUnexpectedEOF)
+	if strings.Contains(err.Error(), "permission denied") || !strings.Contains(err.Error(), "destination") {
+		t.Errorf("unexpected error %q", err)
+	}
+	err = dest.wrapErr(os.ErrPermission)
+	if !strings.HasPrefix(err.Error(), "permission denied for destination") {
+		t.Errorf("expected a permission error, got %q", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -40,6 +41,12 @@ import (
 
 var InvalidWslDistroNames = []string{"docker-desktop", "docker-desktop-data"}
 
+// soft time budget for EventReadHistoryLimitedCommand, after which it returns what it has (Truncated)
+const EventReadHistoryBudget = 2 * time.Second
+
+const MaxSetMetaBatchItems = 1000
+const MaxSetViewBatchItems = 1000
+
 type WshServer struct{}
 
 func (*WshServer) WshServerImpl() {}
@@ -81,6 +88,10 @@ func (ws *WshServer) StreamWaveAiCommand(ctx context.Context, request wshrpc.Wav
 	return waveai.RunAICommand(ctx, request)
 }
 
+func (ws *WshServer) ResumeStreamCommand(ctx context.Context, data wshrpc.CommandResumeStreamData) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	return waveai.ResumeStream(ctx, data)
+}
+
 func MakePlotData(ctx context.Context, blockId string) error {
 	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
 	if err != nil {
@@ -113,6 +124,9 @@ func SavePlotData(ctx context.Context, blockId string, history string) error {
 }
 
 func (ws *WshServer) GetMetaCommand(ctx context.Context, data wshrpc.CommandGetMetaData) (waveobj.MetaMapType, error) {
+	if data.Prefix != "" && len(data.Keys) > 0 {
+		return nil, fmt.Errorf("keys and prefix cannot be combined")
+	}
 	obj, err := wstore.DBGetORef(ctx, data.ORef)
 	if err != nil {
 		return nil, fmt.Errorf("error getting object: %w", err)
@@ -120,13 +134,16 @@ func (ws *WshServer) GetMetaCommand(ctx context.Context, data wshrpc.CommandGetM
 	if obj == nil {
 		return nil, fmt.Errorf("object not found: %s", data.ORef)
 	}
-	return waveobj.GetMeta(obj), nil
+	if data.Prefix != "" {
+		return waveobj.GetMeta(obj).SelectPrefix(data.Prefix, data.StripPrefix, data.MaxDepth), nil
+	}
+	return waveobj.GetMeta(obj).SelectKeys(data.Keys, data.MaxDepth), nil
 }
 
 func (ws *WshServer) SetMetaCommand(ctx context.Context, data wshrpc.CommandSetMetaData) error {
 	log.Printf("SetMetaCommand: %s | %v\n", data.ORef, data.Meta)
 	oref := data.ORef
-	err := wstore.UpdateObjectMeta(ctx, oref, data.Meta, false)
+	err := wstore.UpdateObjectMetaIfVersion(ctx, oref, data.Meta, false, data.Version)
 	if err != nil {
 		return fmt.Errorf("error updating object meta: %w", err)
 	}
@@ -134,6 +151,59 @@ func (ws *WshServer) SetMetaCommand(ctx context.Context, data wshrpc.CommandSetM
 	return nil
 }
 
+func (ws *WshServer) SetMetaPatchCommand(ctx context.Context, data wshrpc.CommandSetMetaPatchData) error {
+	log.Printf("SetMetaPatchCommand: %s | %v\n", data.ORef, data.Command)
+	oref := data.ORef
+	err := wstore.PatchObjectMeta(ctx, oref, data.Command, data.Version)
+	if err != nil {
+		return fmt.Errorf("error patching object meta: %w", err)
+	}
+	sendWaveObjUpdate(oref)
+	return nil
+}
+
+func (ws *WshServer) SetMetaBatchCommand(ctx context.Context, data wshrpc.CommandSetMetaBatchData) ([]wshrpc.SetMetaBatchResult, error) {
+	if len(data.Items) > MaxSetMetaBatchItems {
+		return nil, fmt.Errorf("too many items in meta batch (%d), max is %d", len(data.Items), MaxSetMetaBatchItems)
+	}
+	log.Printf("SetMetaBatchCommand: %d items (allornothing=%v)\n", len(data.Items), data.AllOrNothing)
+	results := make([]wshrpc.SetMetaBatchResult, len(data.Items))
+	for idx, item := range data.Items {
+		results[idx].ORef = item.ORef
+	}
+	if data.AllOrNothing {
+		err := wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+			for idx, item := range data.Items {
+				err := wstore.UpdateObjectMetaIfVersion(tx.Context(), item.ORef, item.Meta, false, item.Version)
+				if err != nil {
+					return fmt.Errorf("item %d (%s): %w", idx, item.ORef, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error updating object meta (batch rolled back): %w", err)
+		}
+	} else {
+		for idx, item := range data.Items {
+			err := wstore.UpdateObjectMetaIfVersion(ctx, item.ORef, item.Meta, false, item.Version)
+			if err != nil {
+				results[idx].Error = fmt.Sprintf("error updating object meta: %v", err)
+			}
+		}
+	}
+	// one update event per object, even if it appeared more than once in the batch
+	sentUpdates := make(map[waveobj.ORef]bool)
+	for _, result := range results {
+		if result.Error != "" || sentUpdates[result.ORef] {
+			continue
+		}
+		sentUpdates[result.ORef] = true
+		sendWaveObjUpdate(result.ORef)
+	}
+	return results, nil
+}
+
 func sendWaveObjUpdate(oref waveobj.ORef) {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelFn()
@@ -181,7 +251,12 @@ func (ws *WshServer) ResolveIdsCommand(ctx context.Context, data wshrpc.CommandR
 func (ws *WshServer) CreateBlockCommand(ctx context.Context, data wshrpc.CommandCreateBlockData) (*waveobj.ORef, error) {
 	ctx = waveobj.ContextWithUpdates(ctx)
 	tabId := data.TabId
-	blockData, err := wcore.CreateBlock(ctx, tabId, data.BlockDef, data.RtOpts)
+	// init files are written by CreateBlock (which deletes the block if a write fails) before the layout insert
+	blockDef, err := data.MakeInitBlockDef()
+	if err != nil {
+		return nil, err
+	}
+	blockData, err := wcore.CreateBlock(ctx, tabId, blockDef, data.RtOpts)
 	if err != nil {
 		return nil, fmt.Errorf("error creating block: %w", err)
 	}
@@ -213,18 +288,128 @@ func (ws *WshServer) CreateSubBlockCommand(ctx context.Context, data wshrpc.Comm
 func (ws *WshServer) SetViewCommand(ctx context.Context, data wshrpc.CommandBlockSetViewData) error {
 	log.Printf("SETVIEW: %s | %q\n", data.BlockId, data.View)
 	ctx = waveobj.ContextWithUpdates(ctx)
-	block, err := wstore.DBGet[*waveobj.Block](ctx, data.BlockId)
+	err := setBlockView(ctx, data)
+	if err != nil {
+		return err
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	return nil
+}
+
+// returns an empty oref if nothing in the tab is focused
+func (ws *WshServer) GetFocusCommand(ctx context.Context, data wshrpc.CommandGetFocusData) (waveobj.ORef, error) {
+	if data.TabId == "" {
+		return waveobj.ORef{}, fmt.Errorf("tabid is required")
+	}
+	blockId, err := wcore.GetFocusedBlock(ctx, data.TabId)
+	if err != nil {
+		return waveobj.ORef{}, err
+	}
+	if blockId == "" {
+		return waveobj.ORef{}, nil
+	}
+	return waveobj.MakeORef(waveobj.OType_Block, blockId), nil
+}
+
+func (ws *WshServer) SetFocusCommand(ctx context.Context, data wshrpc.CommandSetFocusData) error {
+	if data.TabId == "" || data.BlockId == "" {
+		return fmt.Errorf("tabid and blockid are required")
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	oldFocusedBlockId, err := wcore.GetFocusedBlock(ctx, data.TabId)
+	if err != nil {
+		return err
+	}
+	err = wcore.SetFocusedBlock(ctx, data.TabId, data.BlockId)
+	if err != nil {
+		return err
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	// if the block isn't laid out yet, the event comes from the frontend's layout update once it focuses it
+	newFocusedBlockId, err := wcore.GetFocusedBlock(ctx, data.TabId)
+	if err == nil {
+		wcore.PublishFocusChangeIfMoved(data.TabId, oldFocusedBlockId, newFocusedBlockId)
+	}
+	return nil
+}
+
+func (ws *WshServer) GetLayoutCommand(ctx context.Context, data wshrpc.CommandGetLayoutData) (wshrpc.TabLayout, error) {
+	if data.TabId == "" {
+		return wshrpc.TabLayout{}, fmt.Errorf("tabid is required")
+	}
+	rootNode, focusedBlockId, magnifiedBlockId, err := wcore.GetTabLayout(ctx, data.TabId)
+	if err != nil {
+		return wshrpc.TabLayout{}, err
+	}
+	return wshrpc.TabLayout{TabId: data.TabId, RootNode: rootNode, FocusedBlockId: focusedBlockId, MagnifiedBlockId: magnifiedBlockId}, nil
+}
+
+func (ws *WshServer) SetLayoutCommand(ctx context.Context, data wshrpc.TabLayout) error {
+	if data.TabId == "" {
+		return fmt.Errorf("tabid is required")
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	oldFocusedBlockId, err := wcore.GetFocusedBlock(ctx, data.TabId)
+	if err != nil {
+		return err
+	}
+	err = wcore.SetTabLayout(ctx, data.TabId, data.RootNode, data.FocusedBlockId, data.MagnifiedBlockId)
+	if err != nil {
+		return err
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	wcore.PublishLayoutChange(data.TabId)
+	wcore.PublishFocusChangeIfMoved(data.TabId, oldFocusedBlockId, data.FocusedBlockId)
+	return nil
+}
+
+// ctx must have updates (see waveobj.ContextWithUpdates)
+func setBlockView(ctx context.Context, data wshrpc.CommandBlockSetViewData) error {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, data.BlockId)
 	if err != nil {
 		return fmt.Errorf("error getting block: %w", err)
 	}
+	validViews := wcore.GetBlockViews(block)
+	if !utilfn.ContainsStr(validViews, data.View) {
+		return wshrpc.InvalidViewError{View: data.View, ValidViews: validViews}
+	}
 	block.Meta[waveobj.MetaKey_View] = data.View
 	err = wstore.DBUpdate(ctx, block)
 	if err != nil {
 		return fmt.Errorf("error updating block: %w", err)
 	}
+	return nil
+}
+
+// the update events for every changed block are sent together at the end, so the views switch at once
+func (ws *WshServer) SetViewBatchCommand(ctx context.Context, data []wshrpc.CommandBlockSetViewData) ([]wshrpc.SetViewBatchResult, error) {
+	if len(data) > MaxSetViewBatchItems {
+		return nil, fmt.Errorf("too many items in view batch (%d), max is %d", len(data), MaxSetViewBatchItems)
+	}
+	log.Printf("SetViewBatchCommand: %d items\n", len(data))
+	ctx = waveobj.ContextWithUpdates(ctx)
+	// a failing item doesn't stop the rest of the batch
+	results := make([]wshrpc.SetViewBatchResult, len(data))
+	for idx, item := range data {
+		results[idx].BlockId = item.BlockId
+		if err := setBlockView(ctx, item); err != nil {
+			results[idx].Error = err.Error()
+		}
+	}
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	wps.Broker.SendUpdateEvents(updates)
-	return nil
+	return results, nil
+}
+
+func (ws *WshServer) ListBlockViewsCommand(ctx context.Context, data wshrpc.CommandListViewsData) ([]string, error) {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, data.BlockId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block: %w", err)
+	}
+	return wcore.GetBlockViews(block), nil
 }
 
 func (ws *WshServer) ControllerStopCommand(ctx context.Context, blockId string) error {
@@ -241,23 +426,129 @@ func (ws *WshServer) ControllerResyncCommand(ctx context.Context, data wshrpc.Co
 }
 
 func (ws *WshServer) ControllerInputCommand(ctx context.Context, data wshrpc.CommandBlockInputData) error {
+	return sendControllerInput(data)
+}
+
+// returns once the resize has been applied, so the caller can redraw for the returned size
+func (ws *WshServer) ControllerResizeCommand(ctx context.Context, data wshrpc.CommandControllerResizeData) (waveobj.TermSize, error) {
+	bc := blockcontroller.GetBlockController(data.BlockId)
+	if bc == nil {
+		return waveobj.TermSize{}, fmt.Errorf("block controller not found for block %q", data.BlockId)
+	}
+	if data.TermSize.Rows <= 0 || data.TermSize.Cols <= 0 {
+		return waveobj.TermSize{}, fmt.Errorf("invalid termsize %dx%d", data.TermSize.Cols, data.TermSize.Rows)
+	}
+	return bc.Resize(ctx, data.TermSize)
+}
+
+func (ws *WshServer) ControllerStatusCommand(ctx context.Context, data wshrpc.CommandControllerStatusData) (wshrpc.ControllerStatus, error) {
+	if data.BlockId == "" {
+		return wshrpc.ControllerStatus{}, fmt.Errorf("blockid is required")
+	}
+	return blockcontroller.GetControllerProcStatus(data.BlockId), nil
+}
+
+func (ws *WshServer) ListControllersCommand(ctx context.Context, data wshrpc.CommandListControllersData) ([]wshrpc.ControllerStatus, error) {
+	return blockcontroller.ListControllerProcStatus(data.TabId, data.RunningOnly), nil
+}
+
+// streams pty output until the request is canceled (or times out), canceling never stops the controller
+func (ws *WshServer) StreamControllerOutputCommand(ctx context.Context, data wshrpc.CommandStreamOutputData) chan wshrpc.RespOrErrorUnion[wshrpc.ControllerOutputChunk] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.ControllerOutputChunk], 16)
+	backfill, outputCh, detachFn, err := blockcontroller.AttachOutputListener(ctx, data.BlockId, data.BackfillBytes)
+	if err != nil {
+		rtn <- wshrpc.RespOrErrorUnion[wshrpc.ControllerOutputChunk]{Error: err}
+		close(rtn)
+		return rtn
+	}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("StreamControllerOutputCommand", recover())
+		}()
+		defer close(rtn)
+		defer detachFn()
+		if len(backfill) > 0 {
+			rtn <- wshrpc.RespOrErrorUnion[wshrpc.ControllerOutputChunk]{Response: wshrpc.ControllerOutputChunk{Data64: base64.StdEncoding.EncodeToString(backfill), Backfill: true}}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case output, ok := <-outputCh:
+				if !ok {
+					rtn <- wshrpc.RespOrErrorUnion[wshrpc.ControllerOutputChunk]{Error: fmt.Errorf("output stream for block %q fell behind", data.BlockId)}
+					return
+				}
+				rtn <- wshrpc.RespOrErrorUnion[wshrpc.ControllerOutputChunk]{Response: wshrpc.ControllerOutputChunk{Data64: base64.StdEncoding.EncodeToString(output)}}
+			}
+		}
+	}()
+	return rtn
+}
+
+func sendControllerInput(data wshrpc.CommandBlockInputData) error {
+	inputBuf, err := wshrpc.DecodeData64("inputdata64", data.InputData64)
+	if err != nil {
+		return err
+	}
 	bc := blockcontroller.GetBlockController(data.BlockId)
 	if bc == nil {
 		return fmt.Errorf("block controller not found for block %q", data.BlockId)
 	}
 	inputUnion := &blockcontroller.BlockInputUnion{
-		SigName:  data.SigName,
-		TermSize: data.TermSize,
+		SigName:   data.SigName,
+		TermSize:  data.TermSize,
+		InputData: inputBuf,
+	}
+	return bc.SendInput(inputUnion)
+}
+
+func (ws *WshServer) BroadcastInputCommand(ctx context.Context, data wshrpc.CommandBroadcastInputData) (wshrpc.CommandBroadcastInputRtnData, error) {
+	if _, err := wshrpc.DecodeData64("inputdata64", data.InputData64); err != nil {
+		return wshrpc.CommandBroadcastInputRtnData{}, err
+	}
+	rtn := wshrpc.CommandBroadcastInputRtnData{}
+	addError := func(blockId string, errStr string) {
+		if rtn.Errors == nil {
+			rtn.Errors = make(map[string]string)
+		}
+		rtn.Errors[blockId] = errStr
 	}
-	if len(data.InputData64) > 0 {
-		inputBuf := make([]byte, base64.StdEncoding.DecodedLen(len(data.InputData64)))
-		nw, err := base64.StdEncoding.Decode(inputBuf, []byte(data.InputData64))
+	blockIds := data.BlockIds
+	if data.TabId != "" {
+		tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, data.TabId)
 		if err != nil {
-			return fmt.Errorf("error decoding input data: %w", err)
+			return wshrpc.CommandBroadcastInputRtnData{}, fmt.Errorf("error getting tab: %w", err)
+		}
+		if len(blockIds) == 0 {
+			blockIds = tab.BlockIds
+		} else {
+			var tabBlockIds []string
+			for _, blockId := range blockIds {
+				if !utilfn.ContainsStr(tab.BlockIds, blockId) {
+					addError(blockId, fmt.Sprintf("block is not in tab %s", data.TabId))
+					continue
+				}
+				tabBlockIds = append(tabBlockIds, blockId)
+			}
+			blockIds = tabBlockIds
 		}
-		inputUnion.InputData = inputBuf[:nw]
 	}
-	return bc.SendInput(inputUnion)
+	for _, blockId := range blockIds {
+		inputData := wshrpc.CommandBlockInputData{
+			BlockId:     blockId,
+			InputData64: data.InputData64,
+			SigName:     data.SigName,
+			TermSize:    data.TermSize,
+		}
+		err := sendControllerInput(inputData)
+		if err != nil {
+			addError(blockId, err.Error())
+			continue
+		}
+		rtn.SentBlockIds = append(rtn.SentBlockIds, blockId)
+	}
+	return rtn, nil
 }
 
 func (ws *WshServer) FileCreateCommand(ctx context.Context, data wshrpc.CommandFileCreateData) error {
@@ -386,109 +677,137 @@ func (ws *WshServer) FileListCommand(ctx context.Context, data wshrpc.CommandFil
 }
 
 func (ws *WshServer) FileWriteCommand(ctx context.Context, data wshrpc.CommandFileData) error {
-	dataBuf, err := base64.StdEncoding.DecodeString(data.Data64)
+	dataBuf, err := wshrpc.DecodeData64("data64", data.Data64)
 	if err != nil {
-		return fmt.Errorf("error decoding data64: %w", err)
+		return err
 	}
+	var cond *filestore.WriteCond
+	if data.IfModTime != nil || data.IfSize != nil {
+		cond = &filestore.WriteCond{ModTs: data.IfModTime, Size: data.IfSize}
+	}
+	var newSize int64
 	if data.At != nil {
-		err = filestore.WFS.WriteAt(ctx, data.ZoneId, data.FileName, data.At.Offset, dataBuf)
-		if err == fs.ErrNotExist {
-			return fmt.Errorf("NOTFOUND: %w", err)
-		}
-		if err != nil {
-			return fmt.Errorf("error writing to blockfile: %w", err)
-		}
+		newSize, err = filestore.WFS.WriteAtIf(ctx, data.ZoneId, data.FileName, data.At.Offset, dataBuf, cond)
 	} else {
-		err = filestore.WFS.WriteFile(ctx, data.ZoneId, data.FileName, dataBuf)
-		if err == fs.ErrNotExist {
-			return fmt.Errorf("NOTFOUND: %w", err)
-		}
-		if err != nil {
-			return fmt.Errorf("error writing to blockfile: %w", err)
-		}
+		newSize, err = filestore.WFS.WriteFileIf(ctx, data.ZoneId, data.FileName, dataBuf, cond)
 	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event:  wps.Event_BlockFile,
-		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, data.ZoneId).String()},
-		Data: &wps.WSFileEventData{
-			ZoneId:   data.ZoneId,
-			FileName: data.FileName,
-			FileOp:   wps.FileOp_Invalidate,
-		},
+	if err == fs.ErrNotExist {
+		return fmt.Errorf("NOTFOUND: %w", err)
+	}
+	var condErr filestore.PreconditionFailedError
+	if errors.As(err, &condErr) {
+		return wshrpc.PreconditionFailedError{ModTs: condErr.ModTs, Size: condErr.Size}
+	}
+	if err != nil {
+		return fmt.Errorf("error writing to blockfile: %w", err)
+	}
+	blockFileEvents.publish(&wps.WSFileEventData{
+		ZoneId:   data.ZoneId,
+		FileName: data.FileName,
+		FileOp:   wps.FileOp_Invalidate,
+		Size:     newSize,
 	})
 	return nil
 }
 
-func (ws *WshServer) FileReadCommand(ctx context.Context, data wshrpc.CommandFileData) (string, error) {
+func readBlockFile(ctx context.Context, data wshrpc.CommandFileData) ([]byte, error) {
+	var dataBuf []byte
+	var err error
 	if data.At != nil {
-		_, dataBuf, err := filestore.WFS.ReadAt(ctx, data.ZoneId, data.FileName, data.At.Offset, data.At.Size)
-		if err == fs.ErrNotExist {
-			return "", fmt.Errorf("NOTFOUND: %w", err)
-		}
-		if err != nil {
-			return "", fmt.Errorf("error reading blockfile: %w", err)
-		}
-		return base64.StdEncoding.EncodeToString(dataBuf), nil
+		_, dataBuf, err = filestore.WFS.ReadAt(ctx, data.ZoneId, data.FileName, data.At.Offset, data.At.Size)
 	} else {
-		_, dataBuf, err := filestore.WFS.ReadFile(ctx, data.ZoneId, data.FileName)
-		if err == fs.ErrNotExist {
-			return "", fmt.Errorf("NOTFOUND: %w", err)
-		}
-		if err != nil {
-			return "", fmt.Errorf("error reading blockfile: %w", err)
-		}
-		return base64.StdEncoding.EncodeToString(dataBuf), nil
+		_, dataBuf, err = filestore.WFS.ReadFile(ctx, data.ZoneId, data.FileName)
+	}
+	if err == fs.ErrNotExist {
+		return nil, fmt.Errorf("NOTFOUND: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading blockfile: %w", err)
+	}
+	return dataBuf, nil
+}
+
+func (ws *WshServer) FileReadCommand(ctx context.Context, data wshrpc.CommandFileData) (string, error) {
+	dataBuf, err := readBlockFile(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(dataBuf), nil
+}
+
+func (ws *WshServer) FileReadDetectCommand(ctx context.Context, data wshrpc.CommandFileData) (wshrpc.FileReadRtnData, error) {
+	dataBuf, err := readBlockFile(ctx, data)
+	if err != nil {
+		return wshrpc.FileReadRtnData{}, err
 	}
+	var rtn wshrpc.FileReadRtnData
+	rtn.Binary, rtn.Encoding = wshrpc.DetectTextEncoding(dataBuf, data.DetectSampleSize)
+	if rtn.Binary && data.BinaryMaxSize > 0 && int64(len(dataBuf)) > data.BinaryMaxSize {
+		dataBuf = dataBuf[:data.BinaryMaxSize]
+		rtn.Truncated = true
+	}
+	rtn.Data64, rtn.DataEncoding = wshrpc.EncodeFileReadData(dataBuf, data.Encoding, data.CompressMinSize)
+	return rtn, nil
 }
 
 func (ws *WshServer) FileAppendCommand(ctx context.Context, data wshrpc.CommandFileData) error {
-	dataBuf, err := base64.StdEncoding.DecodeString(data.Data64)
+	dataBuf, err := wshrpc.DecodeData64("data64", data.Data64)
 	if err != nil {
-		return fmt.Errorf("error decoding data64: %w", err)
+		return err
+	}
+	if data.SizeMode != "" && data.SizeMode != wshrpc.FileSizeMode_TrimFront && data.SizeMode != wshrpc.FileSizeMode_Rotate {
+		return fmt.Errorf("invalid sizemode %q", data.SizeMode)
 	}
-	err = filestore.WFS.AppendData(ctx, data.ZoneId, data.FileName, dataBuf)
+	offset, err := filestore.WFS.AppendDataOffset(ctx, data.ZoneId, data.FileName, dataBuf)
 	if err == fs.ErrNotExist {
 		return fmt.Errorf("NOTFOUND: %w", err)
 	}
 	if err != nil {
 		return fmt.Errorf("error appending to blockfile: %w", err)
 	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event:  wps.Event_BlockFile,
-		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, data.ZoneId).String()},
-		Data: &wps.WSFileEventData{
-			ZoneId:   data.ZoneId,
-			FileName: data.FileName,
-			FileOp:   wps.FileOp_Append,
-			Data64:   base64.StdEncoding.EncodeToString(dataBuf),
-		},
-	})
+	// queued even if the file gets trimmed or rotated, so the append is published if that fails
+	blockFileEvents.queueAppend(data.ZoneId, data.FileName, dataBuf, offset)
+	if data.MaxSize > 0 && offset+int64(len(dataBuf)) > data.MaxSize {
+		return enforceBlockFileMaxSize(ctx, data)
+	}
 	return nil
 }
 
-func (ws *WshServer) FileAppendIJsonCommand(ctx context.Context, data wshrpc.CommandAppendIJsonData) error {
+// persists the zone's buffered writes (just the named file if filename is set), returns once they are on disk.
+// each flush costs a synced db transaction, so use it before tearing things down, not after every append.
+func (ws *WshServer) FileFlushCommand(ctx context.Context, data wshrpc.CommandFileData) error {
+	if data.ZoneId == "" {
+		return fmt.Errorf("zoneid is required")
+	}
+	if data.FileName == "" {
+		_, err := filestore.WFS.FlushZone(ctx, data.ZoneId)
+		return err
+	}
+	_, err := filestore.WFS.FlushFile(ctx, data.ZoneId, data.FileName)
+	return err
+}
+
+func (ws *WshServer) FileAppendIJsonCommand(ctx context.Context, data wshrpc.CommandAppendIJsonData) (wshrpc.CommandAppendIJsonRtnData, error) {
 	tryCreate := true
 	if data.FileName == blockcontroller.BlockFile_VDom && tryCreate {
 		err := filestore.WFS.MakeFile(ctx, data.ZoneId, data.FileName, nil, filestore.FileOptsType{MaxSize: blockcontroller.DefaultHtmlMaxFileSize, IJson: true})
 		if err != nil && err != fs.ErrExist {
-			return fmt.Errorf("error creating blockfile[vdom]: %w", err)
+			return wshrpc.CommandAppendIJsonRtnData{}, fmt.Errorf("error creating blockfile[vdom]: %w", err)
 		}
 	}
-	err := filestore.WFS.AppendIJson(ctx, data.ZoneId, data.FileName, data.Data)
+	version, newSize, err := filestore.WFS.AppendIJsonVersioned(ctx, data.ZoneId, data.FileName, data.Data, data.ExpectedVersion)
 	if err != nil {
-		return fmt.Errorf("error appending to blockfile(ijson): %w", err)
-	}
-	wps.Broker.Publish(wps.WaveEvent{
-		Event:  wps.Event_BlockFile,
-		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, data.ZoneId).String()},
-		Data: &wps.WSFileEventData{
-			ZoneId:   data.ZoneId,
-			FileName: data.FileName,
-			FileOp:   wps.FileOp_Append,
-			Data64:   base64.StdEncoding.EncodeToString([]byte("{}")),
-		},
+		return wshrpc.CommandAppendIJsonRtnData{}, fmt.Errorf("error appending to blockfile(ijson): %w", err)
+	}
+	blockFileEvents.publish(&wps.WSFileEventData{
+		ZoneId:       data.ZoneId,
+		FileName:     data.FileName,
+		FileOp:       wps.FileOp_Append,
+		Data64:       base64.StdEncoding.EncodeToString([]byte("{}")),
+		Size:         newSize,
+		IJsonCommand: data.Data,
 	})
-	return nil
+	return wshrpc.CommandAppendIJsonRtnData{Version: version}, nil
 }
 
 func (ws *WshServer) DeleteSubBlockCommand(ctx context.Context, data wshrpc.CommandDeleteBlockData) error {
@@ -540,6 +859,10 @@ func (ws *WshServer) EventPublishCommand(ctx context.Context, data wps.WaveEvent
 	if data.Sender == "" {
 		data.Sender = rpcSource
 	}
+	err := data.ValidateTargetRoutes()
+	if err != nil {
+		return err
+	}
 	wps.Broker.Publish(data)
 	return nil
 }
@@ -549,6 +872,9 @@ func (ws *WshServer) EventSubCommand(ctx context.Context, data wps.SubscriptionR
 	if rpcSource == "" {
 		return fmt.Errorf("no rpc source set")
 	}
+	if err := data.Validate(); err != nil {
+		return fmt.Errorf("invalid subscription: %w", err)
+	}
 	wps.Broker.Subscribe(rpcSource, data)
 	return nil
 }
@@ -562,6 +888,23 @@ func (ws *WshServer) EventUnsubCommand(ctx context.Context, data string) error {
 	return nil
 }
 
+func (ws *WshServer) EventSubBatchCommand(ctx context.Context, data []wps.SubscriptionRequest) error {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if rpcSource == "" {
+		return fmt.Errorf("no rpc source set")
+	}
+	return wps.Broker.SubscribeBatch(rpcSource, data)
+}
+
+func (ws *WshServer) EventUnsubBatchCommand(ctx context.Context, data []string) error {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if rpcSource == "" {
+		return fmt.Errorf("no rpc source set")
+	}
+	wps.Broker.UnsubscribeBatch(rpcSource, data)
+	return nil
+}
+
 func (ws *WshServer) EventUnsubAllCommand(ctx context.Context) error {
 	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
 	if rpcSource == "" {
@@ -571,14 +914,106 @@ func (ws *WshServer) EventUnsubAllCommand(ctx context.Context) error {
 	return nil
 }
 
+// stops when the caller gives up (ctx), but has no time budget so it never truncates
 func (ws *WshServer) EventReadHistoryCommand(ctx context.Context, data wshrpc.CommandEventReadHistoryData) ([]*wps.WaveEvent, error) {
-	events := wps.Broker.ReadEventHistory(data.Event, data.Scope, data.MaxItems)
+	events, _, err := wps.Broker.ReadEventHistoryCtx(ctx, data.Event, data.Scope, data.MaxItems, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading event history: %w", err)
+	}
 	return events, nil
 }
 
+func (ws *WshServer) EventReadHistoryLimitedCommand(ctx context.Context, data wshrpc.CommandEventReadHistoryData) (wshrpc.EventReadHistoryRtnData, error) {
+	deadline := time.Now().Add(EventReadHistoryBudget)
+	events, truncated, err := wps.Broker.ReadEventHistoryCtx(ctx, data.Event, data.Scope, data.MaxItems, deadline)
+	if err != nil {
+		return wshrpc.EventReadHistoryRtnData{}, fmt.Errorf("error reading event history: %w", err)
+	}
+	return wshrpc.EventReadHistoryRtnData{Events: events, Truncated: truncated}, nil
+}
+
+func (ws *WshServer) EventListSubsCommand(ctx context.Context) ([]wps.SubscriptionInfo, error) {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if rpcSource == "" {
+		return nil, fmt.Errorf("no rpc source set")
+	}
+	return wps.Broker.ListSubscriptions(rpcSource), nil
+}
+
+func (ws *WshServer) EventListAllSubsCommand(ctx context.Context) ([]wps.SubscriptionInfo, error) {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if !wshutil.DefaultRouter.IsOperatorRoute(rpcSource) {
+		return nil, fmt.Errorf("eventlistallsubs is only allowed from local routes (not %q)", rpcSource)
+	}
+	return wps.Broker.ListAllSubscriptions(), nil
+}
+
+func (ws *WshServer) DebugDumpRoutesCommand(ctx context.Context) ([]wshrpc.RouteInfo, error) {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if !wshutil.DefaultRouter.IsOperatorRoute(rpcSource) {
+		return nil, fmt.Errorf("debugdumproutes is only allowed from local routes (not %q)", rpcSource)
+	}
+	return wshutil.DefaultRouter.DumpRoutes(), nil
+}
+
+func (ws *WshServer) GetRecentErrorsCommand(ctx context.Context, data wshrpc.CommandRecentErrorsData) ([]wshrpc.RpcErrorRecord, error) {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if rpcSource == "" {
+		return nil, fmt.Errorf("no rpc source set")
+	}
+	routeId := data.RouteId
+	if routeId == "" {
+		routeId = rpcSource
+	} else if routeId != rpcSource && !wshutil.DefaultRouter.IsOperatorRoute(rpcSource) {
+		return nil, fmt.Errorf("getrecenterrors for another route is only allowed from local routes (not %q)", rpcSource)
+	}
+	return wshutil.DefaultRouter.GetRecentErrors(routeId, data.Limit), nil
+}
+
+func (ws *WshServer) WhoAmICommand(ctx context.Context) (wshrpc.CommandWhoAmIRtnData, error) {
+	rpcSource := wshutil.GetRpcSourceFromContext(ctx)
+	if rpcSource == "" {
+		return wshrpc.CommandWhoAmIRtnData{}, fmt.Errorf("no rpc source set")
+	}
+	rtn := wshrpc.CommandWhoAmIRtnData{RouteId: rpcSource}
+	routeCtx := wshutil.DefaultRouter.GetRouteRpcContext(rpcSource)
+	if routeCtx != nil {
+		rtn.RpcContext = *routeCtx
+	}
+	rtn.RpcContext.Meta = wshutil.GetRpcMetaFromContext(ctx)
+	return rtn, nil
+}
+
+func (ws *WshServer) HealthCommand(ctx context.Context) (wshrpc.HealthRtnData, error) {
+	return wshutil.DefaultRouter.GetHealthData(), nil
+}
+
 func (ws *WshServer) SetConfigCommand(ctx context.Context, data wshrpc.MetaSettingsType) error {
 	log.Printf("SETCONFIG: %v\n", data)
-	return wconfig.SetBaseConfigValue(data.MetaMapType)
+	rpcLogVal, hasRpcLog := data.MetaMapType[wconfig.ConfigKey_DebugRpcLog]
+	rpcLogLevel, _ := rpcLogVal.(string)
+	if hasRpcLog {
+		// nil clears the setting (turns logging off)
+		if err := wshutil.ValidateRpcLogLevel(rpcLogLevel); err != nil {
+			return fmt.Errorf("invalid %s: %w", wconfig.ConfigKey_DebugRpcLog, err)
+		}
+	}
+	err := wconfig.SetBaseConfigValue(data.MetaMapType)
+	if err != nil {
+		return err
+	}
+	if hasRpcLog {
+		wshutil.SetRpcLogLevel(rpcLogLevel)
+	}
+	if requireSigningVal, ok := data.MetaMapType[wconfig.ConfigKey_ConnRequireRpcSigning]; ok {
+		requireSigning, _ := requireSigningVal.(bool)
+		wshutil.SetRequireFrameSigning(requireSigning)
+	}
+	if idleTimeoutVal, ok := data.MetaMapType[wconfig.ConfigKey_DebugSubIdleTimeoutSecs]; ok {
+		idleTimeoutSecs, _ := idleTimeoutVal.(float64)
+		wshutil.SetSubIdleTimeout(time.Duration(idleTimeoutSecs * float64(time.Second)))
+	}
+	return nil
 }
 
 func (ws *WshServer) SetConnectionsConfigCommand(ctx context.Context, data wshrpc.ConnConfigRequest) error {
@@ -604,6 +1039,15 @@ func (ws *WshServer) ConnEnsureCommand(ctx context.Context, connName string) err
 	return conncontroller.EnsureConnection(ctx, connName)
 }
 
+// connects if needed and resolves the connection's env, home, shell, and os up front
+func (ws *WshServer) ConnWarmupCommand(ctx context.Context, connName string) error {
+	if strings.HasPrefix(connName, "wsl://") {
+		distroName := strings.TrimPrefix(connName, "wsl://")
+		return wsl.WarmupConnection(ctx, distroName)
+	}
+	return conncontroller.WarmupConnection(ctx, connName)
+}
+
 func (ws *WshServer) ConnDisconnectCommand(ctx context.Context, connName string) error {
 	if strings.HasPrefix(connName, "wsl://") {
 		distroName := strings.TrimPrefix(connName, "wsl://")
@@ -624,6 +1068,29 @@ func (ws *WshServer) ConnDisconnectCommand(ctx context.Context, connName string)
 	return conn.Close()
 }
 
+func (ws *WshServer) ConnRenameCommand(ctx context.Context, data wshrpc.CommandConnRenameData) error {
+	if data.Connection == "" || data.Connection == wshrpc.LocalConnName || strings.HasPrefix(data.Connection, "wsl://") {
+		return fmt.Errorf("cannot rename connection %q (only ssh connections can be renamed)", data.Connection)
+	}
+	if data.Alias {
+		if data.NewName == "" {
+			return fmt.Errorf("newname is required for an alias")
+		}
+		if err := wps.ValidateRouteId(wshutil.MakeConnectionRouteId(data.NewName)); err != nil {
+			return fmt.Errorf("invalid connection name %q: %w", data.NewName, err)
+		}
+	}
+	return conncontroller.RenameConn(data.Connection, data.NewName, data.Alias)
+}
+
+// checks a proposed ssh connection config without saving it (the "test connection" button)
+func (ws *WshServer) ConnValidateCommand(ctx context.Context, data wshrpc.CommandConnValidateData) (wshrpc.ConnValidateRtnData, error) {
+	if data.Host == "" || data.Host == wshrpc.LocalConnName || strings.HasPrefix(data.Host, "wsl://") {
+		return wshrpc.ConnValidateRtnData{}, fmt.Errorf("cannot validate connection %q (only ssh connections can be validated)", data.Host)
+	}
+	return remote.ValidateConnection(ctx, data, nil), nil
+}
+
 func (ws *WshServer) ConnConnectCommand(ctx context.Context, connRequest wshrpc.ConnRequest) error {
 	connName := connRequest.Host
 	if strings.HasPrefix(connName, "wsl://") {
@@ -669,6 +1136,15 @@ func (ws *WshServer) ConnListCommand(ctx context.Context) ([]string, error) {
 	return conncontroller.GetConnectionsList()
 }
 
+func (ws *WshServer) ConnListPageCommand(ctx context.Context, data wshrpc.PageOpts) (wshrpc.StringPage, error) {
+	connList, err := conncontroller.GetConnectionsList()
+	if err != nil {
+		return wshrpc.StringPage{}, err
+	}
+	page, err := wshrpc.PaginateSlice(connList, data)
+	return wshrpc.StringPage(page), err
+}
+
 func (ws *WshServer) WslListCommand(ctx context.Context) ([]string, error) {
 	distros, err := wsl.RegisteredDistros(ctx)
 	if err != nil {
@@ -0,0 +1,405 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/ijson"
+	"github.com/wavetermdev/waveterm/pkg/service/objectservice"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func makeTestBlock(t *testing.T, meta waveobj.MetaMapType) *waveobj.Block {
+	t.Helper()
+	block := &waveobj.Block{OID: uuid.NewString(), Meta: meta}
+	if err := wstore.DBInsert(context.Background(), block); err != nil {
+		t.Fatalf("error inserting block: %v", err)
+	}
+	block, err := wstore.DBMustGet[*waveobj.Block](context.Background(), block.OID)
+	if err != nil {
+		t.Fatalf("error getting block: %v", err)
+	}
+	return block
+}
+
+func getTestBlockMeta(t *testing.T, blockId string) (waveobj.MetaMapType, int) {
+	t.Helper()
+	block, err := wstore.DBMustGet[*waveobj.Block](context.Background(), blockId)
+	if err != nil {
+		t.Fatalf("error getting block: %v", err)
+	}
+	return block.Meta, block.Version
+}
+
+func TestSetMetaPatchCommand(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	block := makeTestBlock(t, waveobj.MetaMapType{"list": []any{"a"}})
+	oref := waveobj.MakeORef(waveobj.OType_Block, block.OID)
+
+	appendCmd := ijson.MakeAppendCommand(ijson.Path{"list"}, "b")
+	if err := ws.SetMetaPatchCommand(ctx, wshrpc.CommandSetMetaPatchData{ORef: oref, Command: appendCmd}); err != nil {
+		t.Fatalf("unconditional patch failed: %v", err)
+	}
+	setCmd := ijson.MakeSetCommand(ijson.Path{"nested", "key"}, "val")
+	staleData := wshrpc.CommandSetMetaPatchData{ORef: oref, Command: setCmd, Version: block.Version}
+	if err := ws.SetMetaPatchCommand(ctx, staleData); !errors.Is(err, wstore.ErrVersionMismatch) {
+		t.Errorf("expected a version mismatch for a stale version, got %v", err)
+	}
+	meta, version := getTestBlockMeta(t, block.OID)
+	if _, ok := meta["nested"]; ok {
+		t.Errorf("the stale patch was applied: %v", meta)
+	}
+	if err := ws.SetMetaPatchCommand(ctx, wshrpc.CommandSetMetaPatchData{ORef: oref, Command: setCmd, Version: version}); err != nil {
+		t.Fatalf("patch at the current version failed: %v", err)
+	}
+	meta, newVersion := getTestBlockMeta(t, block.OID)
+	list, _ := meta["list"].([]any)
+	nested, _ := meta["nested"].(map[string]any)
+	if len(list) != 2 || list[1] != "b" || nested["key"] != "val" {
+		t.Errorf("unexpected meta after patches: %v", meta)
+	}
+	if newVersion != version+1 {
+		t.Errorf("expected version %d after the patch, got %d", version+1, newVersion)
+	}
+}
+
+func TestSetMetaBatchCommand(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	block1 := makeTestBlock(t, nil)
+	block2 := makeTestBlock(t, nil)
+	oref1 := waveobj.MakeORef(waveobj.OType_Block, block1.OID)
+	oref2 := waveobj.MakeORef(waveobj.OType_Block, block2.OID)
+	missingORef := waveobj.MakeORef(waveobj.OType_Block, uuid.NewString())
+
+	// a partial failure (stale version, missing object) only fails its own item
+	results, err := ws.SetMetaBatchCommand(ctx, wshrpc.CommandSetMetaBatchData{Items: []wshrpc.CommandSetMetaData{
+		{ORef: oref1, Meta: waveobj.MetaMapType{"a": "1"}, Version: block1.Version},
+		{ORef: oref2, Meta: waveobj.MetaMapType{"a": "1"}, Version: block2.Version + 1},
+		{ORef: missingORef, Meta: waveobj.MetaMapType{"a": "1"}},
+	}})
+	if err != nil || len(results) != 3 {
+		t.Fatalf("unexpected batch result %v, err:%v", results, err)
+	}
+	if results[0].Error != "" || results[1].Error == "" || results[2].Error == "" {
+		t.Errorf("expected only the first item to succeed, got %v", results)
+	}
+	if meta, _ := getTestBlockMeta(t, block1.OID); meta["a"] != "1" {
+		t.Errorf("expected the first item to be applied, got %v", meta)
+	}
+	if meta, _ := getTestBlockMeta(t, block2.OID); meta["a"] != nil {
+		t.Errorf("the stale item was applied: %v", meta)
+	}
+
+	// with AllOrNothing, one stale item rolls back the whole batch
+	_, version1 := getTestBlockMeta(t, block1.OID)
+	_, err = ws.SetMetaBatchCommand(ctx, wshrpc.CommandSetMetaBatchData{AllOrNothing: true, Items: []wshrpc.CommandSetMetaData{
+		{ORef: oref1, Meta: waveobj.MetaMapType{"b": "2"}, Version: version1},
+		{ORef: oref2, Meta: waveobj.MetaMapType{"b": "2"}, Version: block2.Version + 1},
+	}})
+	if !errors.Is(err, wstore.ErrVersionMismatch) {
+		t.Errorf("expected the batch to fail with a version mismatch, got %v", err)
+	}
+	if meta, version := getTestBlockMeta(t, block1.OID); meta["b"] != nil || version != version1 {
+		t.Errorf("the batch wasn't rolled back: %v (version %d)", meta, version)
+	}
+
+	results, err = ws.SetMetaBatchCommand(ctx, wshrpc.CommandSetMetaBatchData{AllOrNothing: true, Items: []wshrpc.CommandSetMetaData{
+		{ORef: oref1, Meta: waveobj.MetaMapType{"b": "2"}, Version: version1},
+		{ORef: oref2, Meta: waveobj.MetaMapType{"b": "2"}},
+	}})
+	if err != nil || results[0].Error != "" || results[1].Error != "" {
+		t.Fatalf("unexpected batch result %v, err:%v", results, err)
+	}
+	for _, blockId := range []string{block1.OID, block2.OID} {
+		if meta, _ := getTestBlockMeta(t, blockId); meta["b"] != "2" {
+			t.Errorf("expected the batch to be applied to %s, got %v", blockId, meta)
+		}
+	}
+}
+
+func TestSetViewCommand(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	block := makeTestBlock(t, waveobj.MetaMapType{waveobj.MetaKey_View: "term", waveobj.MetaKey_Controller: "shell"})
+	views, err := ws.ListBlockViewsCommand(ctx, wshrpc.CommandListViewsData{BlockId: block.OID})
+	if err != nil {
+		t.Fatalf("error listing views: %v", err)
+	}
+	for _, view := range []string{"term", "preview", "cpuplot", "plot"} {
+		if !slices.Contains(views, view) {
+			t.Errorf("expected %q in the views of a shell block, got %v", view, views)
+		}
+	}
+	if err := ws.SetViewCommand(ctx, wshrpc.CommandBlockSetViewData{BlockId: block.OID, View: "preview"}); err != nil {
+		t.Errorf("expected a shell block to switch to preview, got %v", err)
+	}
+	if meta, _ := getTestBlockMeta(t, block.OID); meta[waveobj.MetaKey_View] != "preview" {
+		t.Errorf("expected the view to be preview, got %v", meta[waveobj.MetaKey_View])
+	}
+	err = ws.SetViewCommand(ctx, wshrpc.CommandBlockSetViewData{BlockId: block.OID, View: "nope"})
+	var viewErr wshrpc.InvalidViewError
+	if !errors.As(err, &viewErr) || !slices.Contains(viewErr.ValidViews, "plot") {
+		t.Errorf("expected an invalid view error listing the valid views, got %v", err)
+	}
+}
+
+func TestSetViewBatchCommand(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	termBlock := makeTestBlock(t, waveobj.MetaMapType{waveobj.MetaKey_View: "term", waveobj.MetaKey_Controller: "shell"})
+	vdomBlock := makeTestBlock(t, waveobj.MetaMapType{waveobj.MetaKey_View: "vdom"})
+	missingBlockId := uuid.NewString()
+	results, err := ws.SetViewBatchCommand(ctx, []wshrpc.CommandBlockSetViewData{
+		{BlockId: termBlock.OID, View: "nope"},
+		{BlockId: vdomBlock.OID, View: "preview"},
+		{BlockId: missingBlockId, View: "preview"},
+		{BlockId: termBlock.OID, View: "cpuplot"},
+	})
+	if err != nil {
+		t.Fatalf("error running the batch: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %v", results)
+	}
+	for idx, blockId := range []string{termBlock.OID, vdomBlock.OID, missingBlockId, termBlock.OID} {
+		if results[idx].BlockId != blockId {
+			t.Errorf("result %d: expected blockid %s, got %s", idx, blockId, results[idx].BlockId)
+		}
+	}
+	for _, idx := range []int{0, 1} {
+		if !strings.HasPrefix(results[idx].Error, wshrpc.ErrPrefix_InvalidView) {
+			t.Errorf("result %d: expected an invalid view error, got %q", idx, results[idx].Error)
+		}
+	}
+	if results[2].Error == "" || strings.HasPrefix(results[2].Error, wshrpc.ErrPrefix_InvalidView) {
+		t.Errorf("expected a lookup error for the missing block, got %q", results[2].Error)
+	}
+	if results[3].Error != "" {
+		t.Errorf("expected the valid item to succeed after the failures, got %q", results[3].Error)
+	}
+	if meta, _ := getTestBlockMeta(t, termBlock.OID); meta[waveobj.MetaKey_View] != "cpuplot" {
+		t.Errorf("expected the term block to switch to cpuplot, got %v", meta[waveobj.MetaKey_View])
+	}
+	if meta, _ := getTestBlockMeta(t, vdomBlock.OID); meta[waveobj.MetaKey_View] != "vdom" {
+		t.Errorf("expected the vdom block to keep its view, got %v", meta[waveobj.MetaKey_View])
+	}
+
+	if _, err := ws.SetViewBatchCommand(ctx, make([]wshrpc.CommandBlockSetViewData, MaxSetViewBatchItems+1)); err == nil {
+		t.Errorf("expected an oversized batch to be rejected")
+	}
+}
+
+func TestWhoAmICommand(t *testing.T) {
+	ws := &WshServer{}
+	routeId := "tab:" + uuid.NewString()
+	routeCtx := wshrpc.RpcContext{TabId: uuid.NewString(), BlockId: uuid.NewString(), Conn: "local"}
+	wshutil.DefaultRouter.RegisterRoute(routeId, wshutil.MakeWshRpc(nil, nil, routeCtx, nil), false)
+	defer wshutil.DefaultRouter.UnregisterRoute(routeId)
+
+	reqCtx := wshutil.WithLocalRequest(context.Background(), routeId, wshrpc.Command_WhoAmI, wshrpc.RpcContext{Meta: map[string]string{"req": "1"}})
+	rtn, err := ws.WhoAmICommand(reqCtx)
+	if err != nil {
+		t.Fatalf("error running whoami: %v", err)
+	}
+	if rtn.RouteId != routeId {
+		t.Errorf("expected route %q, got %q", routeId, rtn.RouteId)
+	}
+	if rtn.RpcContext.TabId != routeCtx.TabId || rtn.RpcContext.BlockId != routeCtx.BlockId || rtn.RpcContext.Conn != "local" {
+		t.Errorf("expected the route's rpc context, got %+v", rtn.RpcContext)
+	}
+	if rtn.RpcContext.Meta["req"] != "1" {
+		t.Errorf("expected the request meta, got %v", rtn.RpcContext.Meta)
+	}
+	// the route's own context (also used for jwt claims) is left alone
+	if storedCtx := wshutil.DefaultRouter.GetRouteRpcContext(routeId); storedCtx == nil || storedCtx.Meta != nil {
+		t.Errorf("expected the route's rpc context to be unchanged, got %+v", storedCtx)
+	}
+
+	unknownRouteId := "tab:" + uuid.NewString()
+	rtn, err = ws.WhoAmICommand(wshutil.WithLocalRequest(context.Background(), unknownRouteId, wshrpc.Command_WhoAmI, wshrpc.RpcContext{}))
+	if err != nil || rtn.RouteId != unknownRouteId || rtn.RpcContext.TabId != "" {
+		t.Errorf("expected only the route id for an unknown route, got %+v (err:%v)", rtn, err)
+	}
+	if _, err := ws.WhoAmICommand(context.Background()); err == nil {
+		t.Errorf("expected an error without an rpc source")
+	}
+}
+
+func TestBroadcastInputCommand(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	inTabBlock := makeTestBlock(t, waveobj.MetaMapType{})
+	otherBlock := makeTestBlock(t, waveobj.MetaMapType{})
+	tab := &waveobj.Tab{OID: uuid.NewString(), BlockIds: []string{inTabBlock.OID}}
+	if err := wstore.DBInsert(ctx, tab); err != nil {
+		t.Fatalf("error inserting tab: %v", err)
+	}
+	data := wshrpc.CommandBroadcastInputData{
+		BlockIds:    []string{inTabBlock.OID, otherBlock.OID},
+		TabId:       tab.OID,
+		InputData64: "aGk=",
+	}
+	rtn, err := ws.BroadcastInputCommand(ctx, data)
+	if err != nil {
+		t.Fatalf("error broadcasting: %v", err)
+	}
+	if len(rtn.SentBlockIds) != 0 || len(rtn.Errors) != 2 {
+		t.Fatalf("expected an error for each block, got %+v", rtn)
+	}
+	if !strings.Contains(rtn.Errors[otherBlock.OID], "not in tab") {
+		t.Errorf("expected the block outside the tab to be reported, got %q", rtn.Errors[otherBlock.OID])
+	}
+	// no controller is running for the block in the tab
+	if !strings.Contains(rtn.Errors[inTabBlock.OID], "controller not found") {
+		t.Errorf("expected a controller error for the block in the tab, got %q", rtn.Errors[inTabBlock.OID])
+	}
+}
+
+func TestEventSubValidate(t *testing.T) {
+	ws := &WshServer{}
+	routeId := "test:" + uuid.NewString()
+	defer wps.Broker.UnsubscribeAll(routeId)
+	ctx := wshutil.WithLocalRequest(context.Background(), routeId, wshrpc.Command_EventSub, wshrpc.RpcContext{})
+	badSubs := []wps.SubscriptionRequest{
+		{Event: wps.Event_BlockClose},
+		{Event: wps.Event_BlockClose, Scopes: []string{"bad scope"}},
+	}
+	for _, sub := range badSubs {
+		if err := ws.EventSubCommand(ctx, sub); err == nil {
+			t.Errorf("expected %+v to be rejected", sub)
+		}
+	}
+	if subs := wps.Broker.ListSubscriptions(routeId); len(subs) != 0 {
+		t.Errorf("expected no subscriptions for the invalid requests, got %v", subs)
+	}
+	if err := ws.EventSubCommand(ctx, wps.SubscriptionRequest{Event: wps.Event_BlockClose, Scopes: []string{"block:1"}}); err != nil {
+		t.Fatalf("expected a valid subscription to be accepted, got %v", err)
+	}
+	if subs := wps.Broker.ListSubscriptions(routeId); len(subs) != 1 {
+		t.Errorf("expected the valid subscription to be registered, got %v", subs)
+	}
+}
+
+func TestEventListAllSubsOperatorOnly(t *testing.T) {
+	ws := &WshServer{}
+	routeId := "tab:" + uuid.NewString()
+	wshutil.DefaultRouter.RegisterRoute(routeId, wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil), false)
+	defer wshutil.DefaultRouter.UnregisterRoute(routeId)
+	connRouteId := wshutil.MakeConnectionRouteId("user@" + uuid.NewString())
+	wshutil.DefaultRouter.RegisterRoute(connRouteId, wshutil.MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil), false)
+	defer wshutil.DefaultRouter.UnregisterRoute(connRouteId)
+
+	if _, err := ws.EventListAllSubsCommand(wshutil.WithLocalRequest(context.Background(), routeId, wshrpc.Command_EventListAllSubs, wshrpc.RpcContext{})); err != nil {
+		t.Errorf("expected an operator route to list all subscriptions, got %v", err)
+	}
+	for _, source := range []string{connRouteId, "tab:" + uuid.NewString(), ""} {
+		ctx := wshutil.WithLocalRequest(context.Background(), source, wshrpc.Command_EventListAllSubs, wshrpc.RpcContext{})
+		if _, err := ws.EventListAllSubsCommand(ctx); err == nil {
+			t.Errorf("expected the listing to be rejected for %q", source)
+		}
+	}
+}
+
+func TestFocusChangeEvents(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	tab := makeTestTab(t)
+	tabORef := waveobj.MakeORef(waveobj.OType_Tab, tab.OID).String()
+	block1 := makeTestBlock(t, nil)
+	block2 := makeTestBlock(t, nil)
+	leafOrder := []waveobj.LeafOrderEntry{{NodeId: "node1", BlockId: block1.OID}, {NodeId: "node2", BlockId: block2.OID}}
+	for _, block := range []*waveobj.Block{block1, block2} {
+		block.ParentORef = tabORef
+		if err := wstore.DBUpdate(ctx, block); err != nil {
+			t.Fatalf("error updating block: %v", err)
+		}
+	}
+	tab.BlockIds = []string{block1.OID, block2.OID}
+	if err := wstore.DBUpdate(ctx, tab); err != nil {
+		t.Fatalf("error updating tab: %v", err)
+	}
+
+	var focused []string
+	unsubFn := wps.Broker.SubscribeLocal(wps.Event_FocusChange, tabORef, func(event wps.WaveEvent) {
+		focused = append(focused, event.Data.(wps.FocusChangeData).BlockId)
+	})
+	defer unsubFn()
+	// the frontend persists its own focus changes as layout state updates
+	setUIFocus := func(nodeId string) {
+		layoutState, err := wstore.DBMustGet[*waveobj.LayoutState](ctx, tab.LayoutState)
+		if err != nil {
+			t.Fatalf("error getting layout state: %v", err)
+		}
+		layoutState.LeafOrder = &leafOrder
+		layoutState.FocusedNodeId = nodeId
+		if _, err := (&objectservice.ObjectService{}).UpdateObject(waveobj.UIContext{}, layoutState, false); err != nil {
+			t.Fatalf("error updating layout state: %v", err)
+		}
+	}
+
+	setUIFocus("node1")
+	setUIFocus("node1")
+	if !slices.Equal(focused, []string{block1.OID}) {
+		t.Errorf("expected one event for the focus change made in the UI, got %v", focused)
+	}
+	if err := ws.SetFocusCommand(ctx, wshrpc.CommandSetFocusData{TabId: tab.OID, BlockId: block2.OID}); err != nil {
+		t.Fatalf("error setting focus: %v", err)
+	}
+	// the frontend applies the queued focus action and writes back the (already recorded) focus
+	setUIFocus("node2")
+	if err := ws.SetFocusCommand(ctx, wshrpc.CommandSetFocusData{TabId: tab.OID, BlockId: block2.OID}); err != nil {
+		t.Fatalf("error setting focus: %v", err)
+	}
+	setUIFocus("node1")
+	if !slices.Equal(focused, []string{block1.OID, block2.OID, block1.OID}) {
+		t.Errorf("expected one event per focus change, got %v", focused)
+	}
+}
+
+func TestCreateBlockInitFiles(t *testing.T) {
+	ctx := context.Background()
+	ws := &WshServer{}
+	tab := makeTestTab(t)
+	data := wshrpc.CommandCreateBlockData{
+		TabId:    tab.OID,
+		BlockDef: &waveobj.BlockDef{Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "preview"}},
+		InitFiles: []wshrpc.CommandFileData{
+			{FileName: "main", Data64: base64.StdEncoding.EncodeToString([]byte("hello\x00world"))},
+			{FileName: "empty"},
+		},
+	}
+	oref, err := ws.CreateBlockCommand(ctx, data)
+	if err != nil {
+		t.Fatalf("error creating block: %v", err)
+	}
+	if data := readTestBlockFile(t, oref.OID, "main"); data != "hello\x00world" {
+		t.Errorf("expected the init file's data, got %q", data)
+	}
+	if data := readTestBlockFile(t, oref.OID, "empty"); data != "" {
+		t.Errorf("expected an empty init file, got %q", data)
+	}
+	layoutState, err := wstore.DBMustGet[*waveobj.LayoutState](ctx, tab.LayoutState)
+	if err != nil {
+		t.Fatalf("error getting layout state: %v", err)
+	}
+	if layoutState.PendingBackendActions == nil || !slices.ContainsFunc(*layoutState.PendingBackendActions, func(action waveobj.LayoutActionData) bool {
+		return action.ActionType == wcore.LayoutActionDataType_Insert && action.BlockId == oref.OID
+	}) {
+		t.Errorf("expected the block to be queued for insertion into the layout")
+	}
+}
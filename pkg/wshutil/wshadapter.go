@@ -4,6 +4,7 @@
 package wshutil
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
@@ -15,7 +16,16 @@ import (
 
 var WshCommandDeclMap = wshrpc.GenerateWshCommandDeclMap()
 
+// implemented by impls that don't serve some of the commands they have methods for (an instance can
+// be configured differently from other instances of its type, e.g. the in-process local connection)
+type CommandFilter interface {
+	ServesCommand(cmd string) bool
+}
+
 func findCmdMethod(impl any, cmd string) *reflect.Method {
+	if filter, ok := impl.(CommandFilter); ok && !filter.ServesCommand(cmd) {
+		return nil
+	}
 	rtype := reflect.TypeOf(impl)
 	methodName := cmd + "command"
 	for i := 0; i < rtype.NumMethod(); i++ {
@@ -27,6 +37,13 @@ func findCmdMethod(impl any, cmd string) *reflect.Method {
 	return nil
 }
 
+// callParams with the ctx (always the first param) replaced
+func withCallCtx(callParams []reflect.Value, ctx context.Context) []reflect.Value {
+	rtn := append([]reflect.Value{}, callParams...)
+	rtn[0] = reflect.ValueOf(ctx)
+	return rtn
+}
+
 func decodeRtnVals(rtnVals []reflect.Value) (any, error) {
 	switch len(rtnVals) {
 	case 0:
@@ -95,6 +112,10 @@ func serverImplAdapter(impl any) func(*RpcResponseHandler) bool {
 			return true
 		}
 		rmethod := findCmdMethod(impl, cmd)
+		if rmethod == nil && cmd == wshrpc.Command_Capabilities {
+			handler.SendResponse(GetCapabilities(impl), true)
+			return true
+		}
 		if rmethod == nil {
 			if !handler.NeedsResponse() {
 				// we also send an out of band message here since this is likely unexpected and will require debugging
@@ -116,8 +137,20 @@ func serverImplAdapter(impl any) func(*RpcResponseHandler) bool {
 			callParams = append(callParams, reflect.ValueOf(cmdData))
 		}
 		if methodDecl.CommandType == wshrpc.RpcType_Call {
-			rtnVals := implMethod.Call(callParams)
-			rtnData, rtnErr := decodeRtnVals(rtnVals)
+			callFn := func(callCtx context.Context) (any, error) {
+				return decodeRtnVals(implMethod.Call(withCallCtx(callParams, callCtx)))
+			}
+			var rtnData any
+			var rtnErr error
+			coalesceKey := ""
+			if wshrpc.IsIdempotentCommand(cmd) && len(callParams) > 1 {
+				coalesceKey = makeCoalesceKey(cmd, callParams[1].Interface())
+			}
+			if coalesceKey != "" {
+				rtnData, rtnErr, _ = handler.w.coalescer.Do(handler.Context(), coalesceKey, callFn)
+			} else {
+				rtnData, rtnErr = callFn(handler.Context())
+			}
 			if rtnErr != nil {
 				handler.SendResponseError(rtnErr)
 				return true
@@ -131,11 +164,16 @@ func serverImplAdapter(impl any) func(*RpcResponseHandler) bool {
 				handler.SendResponse(nil, true)
 				return true
 			}
+			handler.setStreaming()
+			handler.sendStreamStart()
 			go func() {
+				defer handler.Finalize()
 				defer func() {
-					panichandler.PanicHandler("serverImplAdapter:responseStream", recover())
+					panicErr := panichandler.PanicHandler("serverImplAdapter:responseStream", recover())
+					if panicErr != nil {
+						handler.SendResponseError(panicErr)
+					}
 				}()
-				defer handler.Finalize()
 				// must use reflection here because we don't know the generic type of RespOrErrorUnion
 				for {
 					respVal, ok := rtnChVal.Recv()
@@ -144,7 +182,9 @@ func serverImplAdapter(impl any) func(*RpcResponseHandler) bool {
 					}
 					errorVal := respVal.FieldByName("Error")
 					if !errorVal.IsNil() {
-						handler.SendResponseError(errorVal.Interface().(error))
+						handler.SendStreamError(errorVal.Interface().(error))
+						// the error is the final element, drain so the handler goroutine can exit
+						go drainChanVal(rtnChVal)
 						break
 					}
 					respData := respVal.FieldByName("Response").Interface()
@@ -158,3 +198,14 @@ func serverImplAdapter(impl any) func(*RpcResponseHandler) bool {
 		}
 	}
 }
+
+func drainChanVal(chVal reflect.Value) {
+	defer func() {
+		panichandler.PanicHandler("drainChanVal", recover())
+	}()
+	for {
+		if _, ok := chVal.Recv(); !ok {
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// largest encoded rpc message (see EncodeWaveOSCBytes)
+const MaxRpcMessageSize = 64 * 1024 * 1024
+
+var implCommandsCache = &sync.Map{} // reflect.Type => []string
+
+// returns the sorted commands that impl has handlers for (Command_Capabilities is always included)
+func GetImplCommands(impl any) []string {
+	if impl == nil {
+		return nil
+	}
+	rtype := reflect.TypeOf(impl)
+	_, filtered := impl.(CommandFilter)
+	if cached, ok := implCommandsCache.Load(rtype); ok && !filtered {
+		return cached.([]string)
+	}
+	var rtn []string
+	for cmd := range WshCommandDeclMap {
+		if cmd == wshrpc.Command_Capabilities || findCmdMethod(impl, cmd) != nil {
+			rtn = append(rtn, cmd)
+		}
+	}
+	sort.Strings(rtn)
+	if !filtered {
+		// filtered impls can differ per instance
+		implCommandsCache.Store(rtype, rtn)
+	}
+	return rtn
+}
+
+// served for CapabilitiesCommand when the impl doesn't define its own handler
+func GetCapabilities(impl any) wshrpc.CapabilitiesRtnData {
+	return wshrpc.CapabilitiesRtnData{
+		Version:         wavebase.WaveVersion,
+		ProtocolVersion: wshrpc.WshRpcProtocolVersion,
+		Commands:        slices.Clone(GetImplCommands(impl)),
+		Features:        []string{wshrpc.RpcFeature_Compression, wshrpc.RpcFeature_StreamStart},
+		Codec:           wshrpc.RpcCodec_Json,
+		MaxMessageSize:  MaxRpcMessageSize,
+	}
+}
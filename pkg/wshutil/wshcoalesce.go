@@ -0,0 +1,100 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+)
+
+// coalesces identical concurrent calls (same key) into a single in-flight call.
+// only used for commands marked as idempotent (see wshrpc.IsIdempotentCommand)
+// the shared call runs on a ctx detached from the callers (it keeps the first caller's values), each caller
+// waits on its own ctx.  the shared call is cancelled once every caller has given up on it.
+
+type coalescedCall struct {
+	doneCh     chan struct{}
+	cancelFn   context.CancelFunc
+	numWaiters int
+	rtn        any
+	err        error
+}
+
+type callCoalescer struct {
+	lock     *sync.Mutex
+	inFlight map[string]*coalescedCall
+}
+
+func makeCallCoalescer() *callCoalescer {
+	return &callCoalescer{
+		lock:     &sync.Mutex{},
+		inFlight: make(map[string]*coalescedCall),
+	}
+}
+
+// returns "" if the command data cannot be keyed (the call should not be coalesced)
+func makeCoalesceKey(command string, cmdData any) string {
+	barr, err := json.Marshal(cmdData)
+	if err != nil {
+		return ""
+	}
+	return command + ":" + string(barr)
+}
+
+// calls fn (with the shared ctx), or waits for (and shares the result of) an in-flight call with the same key.
+// returns ctx.Err() if ctx is done before the call finishes.  shared=true if the call was started by another caller.
+func (c *callCoalescer) Do(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (rtn any, err error, shared bool) {
+	c.lock.Lock()
+	call := c.inFlight[key]
+	if call != nil {
+		shared = true
+	} else {
+		call = c.startCall_nolock(ctx, key, fn)
+	}
+	call.numWaiters++
+	c.lock.Unlock()
+	select {
+	case <-call.doneCh:
+		return call.rtn, call.err, shared
+	case <-ctx.Done():
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		call.numWaiters--
+		if call.numWaiters == 0 {
+			// nobody is waiting, new callers start a fresh call
+			call.cancelFn()
+			c.removeCall_nolock(key, call)
+		}
+		return nil, ctx.Err(), shared
+	}
+}
+
+func (c *callCoalescer) removeCall_nolock(key string, call *coalescedCall) {
+	if c.inFlight[key] == call {
+		delete(c.inFlight, key)
+	}
+}
+
+func (c *callCoalescer) startCall_nolock(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) *coalescedCall {
+	callCtx, cancelFn := context.WithCancel(context.WithoutCancel(ctx))
+	call := &coalescedCall{doneCh: make(chan struct{}), cancelFn: cancelFn}
+	c.inFlight[key] = call
+	go func() {
+		defer func() {
+			if panicErr := panichandler.PanicHandler("callCoalescer:Do", recover()); panicErr != nil {
+				call.err = panicErr
+			}
+			c.lock.Lock()
+			c.removeCall_nolock(key, call)
+			c.lock.Unlock()
+			cancelFn()
+			close(call.doneCh)
+		}()
+		call.rtn, call.err = fn(callCtx)
+	}()
+	return call
+}
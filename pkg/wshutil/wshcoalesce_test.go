@@ -0,0 +1,216 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// reads block until release is closed (if set), so concurrent calls overlap without sleeps
+type countingServerImpl struct {
+	readCount atomic.Int32
+	release   chan struct{}
+	readDelay time.Duration
+}
+
+func (*countingServerImpl) WshServerImpl() {}
+
+func (impl *countingServerImpl) FileReadCommand(ctx context.Context, data wshrpc.CommandFileData) (string, error) {
+	impl.readCount.Add(1)
+	if impl.readDelay > 0 {
+		time.Sleep(impl.readDelay)
+	}
+	if impl.release != nil {
+		select {
+		case <-impl.release:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return "data:" + data.FileName, nil
+}
+
+func (impl *countingServerImpl) FileWriteCommand(ctx context.Context, data wshrpc.CommandFileData) error {
+	impl.readCount.Add(1)
+	return nil
+}
+
+func makeTestRpcPair(impl ServerImpl) *WshRpc {
+	client, _ := makeTestRpcClientServer(impl)
+	return client
+}
+
+func makeTestRpcClientServer(impl ServerImpl) (*WshRpc, *WshRpc) {
+	clientToServer := make(chan []byte, DefaultInputChSize)
+	serverToClient := make(chan []byte, DefaultOutputChSize)
+	server := MakeWshRpc(clientToServer, serverToClient, wshrpc.RpcContext{}, impl)
+	return MakeWshRpc(serverToClient, clientToServer, wshrpc.RpcContext{}, nil), server
+}
+
+func waitForCondition(t *testing.T, desc string, condFn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condFn() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", desc)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *callCoalescer) getNumWaiters(key string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if call := c.inFlight[key]; call != nil {
+		return call.numWaiters
+	}
+	return 0
+}
+
+func (c *callCoalescer) getTotalWaiters() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	total := 0
+	for _, call := range c.inFlight {
+		total += call.numWaiters
+	}
+	return total
+}
+
+func startConcurrent(t *testing.T, client *WshRpc, command string, data wshrpc.CommandFileData, count int) ([]any, *sync.WaitGroup) {
+	rtn := make([]any, count)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			resp, err := client.SendRpcRequest(command, data, nil)
+			if err != nil {
+				t.Errorf("request %d failed: %v", idx, err)
+				return
+			}
+			rtn[idx] = resp
+		}(i)
+	}
+	return rtn, wg
+}
+
+func TestCoalesceIdenticalReads(t *testing.T) {
+	impl := &countingServerImpl{release: make(chan struct{})}
+	client, server := makeTestRpcClientServer(impl)
+	data := wshrpc.CommandFileData{ZoneId: "zone", FileName: "file.txt"}
+	results, wg := startConcurrent(t, client, wshrpc.Command_FileRead, data, 2)
+	waitForCondition(t, "both reads to wait on the shared call", func() bool { return server.coalescer.getTotalWaiters() == 2 })
+	close(impl.release)
+	wg.Wait()
+	if count := impl.readCount.Load(); count != 1 {
+		t.Errorf("expected backend to be called once, got %d", count)
+	}
+	for idx, result := range results {
+		if result != "data:file.txt" {
+			t.Errorf("result %d: unexpected response %v", idx, result)
+		}
+	}
+}
+
+func TestNoCoalesceDifferentReads(t *testing.T) {
+	impl := &countingServerImpl{release: make(chan struct{})}
+	client := makeTestRpcPair(impl)
+	var wg sync.WaitGroup
+	for _, fileName := range []string{"a.txt", "b.txt"} {
+		wg.Add(1)
+		go func(fileName string) {
+			defer wg.Done()
+			data := wshrpc.CommandFileData{ZoneId: "zone", FileName: fileName}
+			if _, err := client.SendRpcRequest(wshrpc.Command_FileRead, data, nil); err != nil {
+				t.Errorf("request failed: %v", err)
+			}
+		}(fileName)
+	}
+	// both reads are in the backend at once
+	waitForCondition(t, "both reads to reach the backend", func() bool { return impl.readCount.Load() == 2 })
+	close(impl.release)
+	wg.Wait()
+}
+
+func TestCoalesceWaiterCancel(t *testing.T) {
+	coalescer := makeCallCoalescer()
+	release := make(chan struct{})
+	sharedCtxCh := make(chan context.Context, 1)
+	callFn := func(ctx context.Context) (any, error) {
+		sharedCtxCh <- ctx
+		select {
+		case <-release:
+			return "result", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	firstErrCh := make(chan error, 1)
+	go func() {
+		_, err, _ := coalescer.Do(firstCtx, "key", callFn)
+		firstErrCh <- err
+	}()
+	sharedCtx := <-sharedCtxCh
+	secondCh := make(chan any, 1)
+	go func() {
+		rtn, err, shared := coalescer.Do(context.Background(), "key", callFn)
+		if err != nil || !shared {
+			t.Errorf("expected the second caller to share the result, got err:%v shared:%v", err, shared)
+		}
+		secondCh <- rtn
+	}()
+	waitForCondition(t, "the second caller to join", func() bool { return coalescer.getNumWaiters("key") == 2 })
+
+	// the first caller giving up doesn't fail the second
+	firstCancel()
+	if err := <-firstErrCh; err != context.Canceled {
+		t.Errorf("expected the cancelled caller to get %v, got %v", context.Canceled, err)
+	}
+	if sharedCtx.Err() != nil {
+		t.Fatalf("the shared call was cancelled with the first caller")
+	}
+	close(release)
+	if rtn := <-secondCh; rtn != "result" {
+		t.Errorf("expected the second caller to get the result, got %v", rtn)
+	}
+
+	// once every caller has given up, the shared call is cancelled
+	lastCtx, lastCancel := context.WithCancel(context.Background())
+	lastErrCh := make(chan error, 1)
+	go func() {
+		_, err, _ := coalescer.Do(lastCtx, "key2", func(ctx context.Context) (any, error) {
+			sharedCtxCh <- ctx
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		lastErrCh <- err
+	}()
+	sharedCtx = <-sharedCtxCh
+	lastCancel()
+	<-lastErrCh
+	select {
+	case <-sharedCtx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the shared call to be cancelled once nobody is waiting")
+	}
+}
+
+func TestNoCoalesceSideEffects(t *testing.T) {
+	impl := &countingServerImpl{}
+	client := makeTestRpcPair(impl)
+	data := wshrpc.CommandFileData{ZoneId: "zone", FileName: "file.txt", Data64: "aGVsbG8="}
+	_, wg := startConcurrent(t, client, wshrpc.Command_FileWrite, data, 2)
+	wg.Wait()
+	if count := impl.readCount.Load(); count != 2 {
+		t.Errorf("expected non-idempotent command to be called twice, got %d", count)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// approximates process start (package init)
+var processStartTime = time.Now()
+
+// cheap liveness/readiness info, also served to unauthenticated connections (see HandleAuthentication)
+func (router *WshRouter) GetHealthData() wshrpc.HealthRtnData {
+	router.Lock.Lock()
+	numRoutes := len(router.RouteMap)
+	numStreams := len(router.RpcMap)
+	_, hasDefaultRoute := router.RouteMap[DefaultRoute]
+	router.Lock.Unlock()
+	status := wshrpc.HealthStatus_Ok
+	if !hasDefaultRoute && router.GetUpstreamClient() == nil {
+		// wavesrv (or our upstream) isn't reachable yet
+		status = wshrpc.HealthStatus_Starting
+	}
+	return wshrpc.HealthRtnData{
+		Status:        status,
+		UptimeMs:      time.Since(processStartTime).Milliseconds(),
+		Version:       wavebase.WaveVersion,
+		NumRoutes:     numRoutes,
+		ActiveStreams: numStreams,
+	}
+}
+
+func makeHealthResponse(msg RpcMessage) RpcMessage {
+	return RpcMessage{
+		ResId: msg.ReqId,
+		Route: msg.Source,
+		Data:  DefaultRouter.GetHealthData(),
+	}
+}
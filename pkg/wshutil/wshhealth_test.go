@@ -0,0 +1,76 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestGetHealthData(t *testing.T) {
+	router := NewWshRouter()
+	client := &silentRpcClient{doneCh: make(chan struct{})}
+	defer close(client.doneCh)
+	router.RegisterRoute("test:health-block", client, false)
+	health := router.GetHealthData()
+	if health.Status != wshrpc.HealthStatus_Starting || health.NumRoutes != 1 {
+		t.Errorf("expected starting with 1 route before wavesrv is registered, got %+v", health)
+	}
+	router.RegisterRoute(DefaultRoute, client, false)
+	health = router.GetHealthData()
+	if health.Status != wshrpc.HealthStatus_Ok || health.NumRoutes != 2 || health.Version != wavebase.WaveVersion || health.UptimeMs < 0 {
+		t.Errorf("expected ok with 2 routes once wavesrv is registered, got %+v", health)
+	}
+}
+
+func readHealthResponse(t *testing.T, respBytes []byte, reqId string) wshrpc.HealthRtnData {
+	var respMsg RpcMessage
+	if err := json.Unmarshal(respBytes, &respMsg); err != nil {
+		t.Fatalf("error reading the health response: %v", err)
+	}
+	if respMsg.ResId != reqId || respMsg.Error != "" {
+		t.Fatalf("expected a health response for %q, got %#v", reqId, respMsg)
+	}
+	var health wshrpc.HealthRtnData
+	dataBytes, _ := json.Marshal(respMsg.Data)
+	json.Unmarshal(dataBytes, &health)
+	return health
+}
+
+func TestRpcProxyUnauthHealth(t *testing.T) {
+	proxy := MakeRpcProxy()
+	go proxy.HandleAuthentication()
+	msgBytes, _ := json.Marshal(RpcMessage{Command: wshrpc.Command_Health, ReqId: "req-1"})
+	proxy.FromRemoteCh <- msgBytes
+	health := readHealthResponse(t, <-proxy.ToRemoteCh, "req-1")
+	if health.Status == "" || health.Version != wavebase.WaveVersion {
+		t.Errorf("expected health data before authenticating, got %+v", health)
+	}
+
+	// other commands are still refused
+	msgBytes, _ = json.Marshal(RpcMessage{Command: wshrpc.Command_GetMeta, ReqId: "req-2"})
+	proxy.FromRemoteCh <- msgBytes
+	var errMsg RpcMessage
+	json.Unmarshal(<-proxy.ToRemoteCh, &errMsg)
+	if errMsg.ResId != "req-2" || errMsg.Error == "" {
+		t.Errorf("expected an unauthenticated error, got %#v", errMsg)
+	}
+}
+
+func TestMultiProxyUnauthHealth(t *testing.T) {
+	proxy := MakeRpcMultiProxy()
+	defer proxy.DisposeRoutes()
+	msgBytes, _ := json.Marshal(RpcMessage{Command: wshrpc.Command_Health, ReqId: "req-1"})
+	proxy.handleUnauthMessage(msgBytes)
+	health := readHealthResponse(t, <-proxy.ToRemoteCh, "req-1")
+	if health.Status == "" || health.Version != wavebase.WaveVersion {
+		t.Errorf("expected health data before authenticating, got %+v", health)
+	}
+	if len(proxy.RouteInfo) != 0 {
+		t.Errorf("expected no route to be registered for a health check")
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// local impls are server impls that live in the same process as the router (e.g. the "local" connection).
+// requests routed to them can be dispatched directly to the impl, skipping the JSON (and base64) round trip.
+// the impl still sees a request handler in its context (source route, merged meta), the call shares the
+// route's coalescer and drain tracking, and it goes to the rpc log, the same as a routed call.
+// the route must still be registered normally with RegisterRoute (for events and non-passthrough callers).
+
+type localImplInfo struct {
+	Rpc *WshRpc
+}
+
+// rpc is the client registered for routeId (its ServerImpl and RpcContext are used for passthrough calls)
+func (router *WshRouter) RegisterLocalImpl(routeId string, rpc *WshRpc) {
+	validateServerImpl(rpc.ServerImpl)
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	router.LocalImplMap[routeId] = &localImplInfo{Rpc: rpc}
+}
+
+func (router *WshRouter) UnregisterLocalImpl(routeId string) {
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	delete(router.LocalImplMap, routeId)
+}
+
+func (router *WshRouter) getLocalImpl(routeId string) *localImplInfo {
+	if routeId == "" {
+		return nil
+	}
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	return router.LocalImplMap[routeId]
+}
+
+func (router *WshRouter) HasLocalImpl(routeId string) bool {
+	return router.getLocalImpl(routeId) != nil
+}
+
+// the route the caller is registered under (the source a routed request from it would get), "" if none
+func (router *WshRouter) getClientRouteId(client AbstractRpcClient) string {
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	for routeId, rpc := range router.RouteMap {
+		if rpc == client {
+			return routeId
+		}
+	}
+	return ""
+}
+
+// deep copies v (so neither side of a passthrough call can see the other's later mutations).
+// unexported struct fields are copied shallowly.
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		rtn := reflect.New(v.Type().Elem())
+		rtn.Elem().Set(deepCopyValue(v.Elem()))
+		return rtn
+	case reflect.Struct:
+		rtn := reflect.New(v.Type()).Elem()
+		rtn.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if rtn.Field(i).CanSet() {
+				rtn.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return rtn
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		rtn := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			reflect.Copy(rtn, v)
+			return rtn
+		}
+		for i := 0; i < v.Len(); i++ {
+			rtn.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return rtn
+	case reflect.Array:
+		rtn := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			rtn.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return rtn
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		rtn := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			rtn.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return rtn
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		rtn := reflect.New(v.Type()).Elem()
+		rtn.Set(deepCopyValue(v.Elem()))
+		return rtn
+	default:
+		return v
+	}
+}
+
+func makeLocalCallParams(ctx context.Context, methodDecl *wshrpc.WshRpcMethodDecl, rpcCtx wshrpc.RpcContext, data any) ([]reflect.Value, error) {
+	callParams := []reflect.Value{reflect.ValueOf(ctx)}
+	if methodDecl.CommandDataType == nil {
+		return callParams, nil
+	}
+	if data != nil && reflect.TypeOf(data) == methodDecl.CommandDataType {
+		// fast path, data already has the correct type (deep copied, so the impl and the caller don't share it)
+		dataPtr := reflect.New(methodDecl.CommandDataType)
+		dataPtr.Elem().Set(deepCopyValue(reflect.ValueOf(data)))
+		wshrpc.HackRpcContextIntoData(dataPtr.Interface(), rpcCtx)
+		return append(callParams, dataPtr.Elem()), nil
+	}
+	cmdData, err := recodeCommandData(methodDecl.Command, data, &rpcCtx)
+	if err != nil {
+		return nil, err
+	}
+	return append(callParams, reflect.ValueOf(cmdData)), nil
+}
+
+func findLocalImplMethod(info *localImplInfo, command string, commandType string) (*wshrpc.WshRpcMethodDecl, reflect.Value, error) {
+	methodDecl := WshCommandDeclMap[command]
+	if methodDecl == nil {
+		return nil, reflect.Value{}, fmt.Errorf("command %q not found", command)
+	}
+	if methodDecl.CommandType != commandType {
+		return nil, reflect.Value{}, fmt.Errorf("command %q has type %q, expected %q", command, methodDecl.CommandType, commandType)
+	}
+	rmethod := findCmdMethod(info.Rpc.ServerImpl, command)
+	if rmethod == nil {
+		return nil, reflect.Value{}, fmt.Errorf("command not implemented %q", command)
+	}
+	return methodDecl, reflect.ValueOf(info.Rpc.ServerImpl).MethodByName(rmethod.Name), nil
+}
+
+// attaches a request handler to ctx for calls that don't arrive as rpc messages, so the impl sees the
+// caller's source and rpc context like a routed call.  the handler can't send responses.
+func WithLocalRequest(ctx context.Context, source string, command string, rpcCtx wshrpc.RpcContext) context.Context {
+	handler := &RpcResponseHandler{
+		command:         command,
+		source:          source,
+		rpcCtx:          rpcCtx,
+		done:            &atomic.Bool{},
+		canceled:        &atomic.Bool{},
+		streaming:       &atomic.Bool{},
+		contextCancelFn: &atomic.Pointer[context.CancelFunc]{},
+		rtnErr:          &atomic.Pointer[string]{},
+	}
+	handler.ctx = withRespHandler(ctx, handler)
+	return handler.ctx
+}
+
+// builds the request handler context for a passthrough call from caller (like handleRequest does for a routed call)
+func (router *WshRouter) makeLocalRequest(info *localImplInfo, caller *WshRpc, command string, data any, opts *wshrpc.RpcOpts, timeoutMs int) (*RpcResponseHandler, context.CancelFunc, error) {
+	reqMeta := wshrpc.MergeRpcMeta(caller.GetRpcContext().Meta, opts.Meta)
+	if err := wshrpc.ValidateRpcMeta(reqMeta); err != nil {
+		return nil, nil, err
+	}
+	source := router.getClientRouteId(caller)
+	router.setRouteActivity(source)
+	rpcCtx := info.Rpc.GetRpcContext()
+	rpcCtx.Meta = wshrpc.MergeRpcMeta(rpcCtx.Meta, reqMeta)
+	if timeoutMs <= 0 {
+		timeoutMs = DefaultTimeoutMs
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+	ctx = withWshRpcContext(ctx, info.Rpc)
+	handler := &RpcResponseHandler{
+		command:         command,
+		commandData:     data,
+		source:          source,
+		rpcCtx:          rpcCtx,
+		done:            &atomic.Bool{},
+		canceled:        &atomic.Bool{},
+		streaming:       &atomic.Bool{},
+		contextCancelFn: &atomic.Pointer[context.CancelFunc]{},
+		rtnErr:          &atomic.Pointer[string]{},
+	}
+	handler.contextCancelFn.Store(&cancelFn)
+	handler.ctx = withRespHandler(ctx, handler)
+	return handler, cancelFn, nil
+}
+
+// calls a RpcType_Call command from caller directly on the local impl for opts.Route, the result is deep copied
+// returns handled=false if there is no local impl for the route (caller should use the regular rpc path)
+func (router *WshRouter) CallLocalImpl(caller *WshRpc, command string, data any, opts *wshrpc.RpcOpts, timeoutMs int) (rtn any, handled bool, rtnErr error) {
+	info := router.getLocalImpl(opts.Route)
+	if info == nil {
+		return nil, false, nil
+	}
+	startTs := time.Now()
+	handler, cancelFn, err := router.makeLocalRequest(info, caller, command, data, opts, timeoutMs)
+	if err != nil {
+		return nil, true, err
+	}
+	defer func() {
+		panicErr := panichandler.PanicHandler("CallLocalImpl", recover())
+		if panicErr != nil {
+			rtnErr = panicErr
+		}
+		cancelFn()
+		handler.setRtnErr(rtnErr)
+		logRpcRequest(handler, startTs)
+	}()
+	if command == wshrpc.Command_Capabilities && findCmdMethod(info.Rpc.ServerImpl, command) == nil {
+		return GetCapabilities(info.Rpc.ServerImpl), true, nil
+	}
+	methodDecl, implMethod, err := findLocalImplMethod(info, command, wshrpc.RpcType_Call)
+	if err != nil {
+		return nil, true, err
+	}
+	trackCall, err := info.Rpc.startInflight(command)
+	if err != nil {
+		return nil, true, err
+	}
+	if trackCall {
+		defer info.Rpc.finishInflight()
+	}
+	callParams, err := makeLocalCallParams(handler.Context(), methodDecl, handler.GetRpcContext(), data)
+	if err != nil {
+		return nil, true, err
+	}
+	callFn := func(callCtx context.Context) (any, error) {
+		return decodeRtnVals(implMethod.Call(withCallCtx(callParams, callCtx)))
+	}
+	coalesceKey := ""
+	if wshrpc.IsIdempotentCommand(command) && len(callParams) > 1 {
+		coalesceKey = makeCoalesceKey(command, callParams[1].Interface())
+	}
+	if coalesceKey != "" {
+		rtn, rtnErr, _ = info.Rpc.coalescer.Do(handler.Context(), coalesceKey, callFn)
+	} else {
+		rtn, rtnErr = callFn(handler.Context())
+	}
+	if rtn != nil {
+		// like the decoded response of a routed call, the caller gets its own copy (not the impl's state)
+		rtn = deepCopyValue(reflect.ValueOf(rtn)).Interface()
+	}
+	return rtn, true, rtnErr
+}
+
+// calls a RpcType_ResponseStream command from caller directly on the local impl for opts.Route
+// returns the impl's response channel (as any, it is a chan wshrpc.RespOrErrorUnion[T]) and a cancel function (must be called when the stream is done)
+// onStart (if non-nil) is called with the stream start data once the impl has returned its channel
+// returns handled=false if there is no local impl for the route (caller should use the regular rpc path)
+func (router *WshRouter) StreamLocalImpl(caller *WshRpc, command string, data any, opts *wshrpc.RpcOpts, timeoutMs int, onStart func(wshrpc.StreamStartData)) (rtnCh any, cancelFn context.CancelFunc, handled bool, rtnErr error) {
+	info := router.getLocalImpl(opts.Route)
+	if info == nil {
+		return nil, nil, false, nil
+	}
+	methodDecl, implMethod, err := findLocalImplMethod(info, command, wshrpc.RpcType_ResponseStream)
+	if err != nil {
+		return nil, nil, true, err
+	}
+	startTs := time.Now()
+	handler, cancelFn, err := router.makeLocalRequest(info, caller, command, data, opts, timeoutMs)
+	if err != nil {
+		return nil, nil, true, err
+	}
+	ctx, startParams := withStreamStartParams(handler.Context())
+	handler.ctx = ctx
+	isStream := false
+	defer func() {
+		panicErr := panichandler.PanicHandler("StreamLocalImpl", recover())
+		if panicErr != nil {
+			isStream = false
+			rtnCh, cancelFn, rtnErr = nil, nil, panicErr
+		}
+		if !isStream {
+			handler.close()
+			handler.setRtnErr(rtnErr)
+			logRpcRequest(handler, startTs)
+			return
+		}
+		// like an async routed request, the stream is logged when it is done (or canceled)
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("StreamLocalImpl:finalize", recover())
+			}()
+			<-ctx.Done()
+			logRpcRequest(handler, startTs)
+		}()
+	}()
+	callParams, err := makeLocalCallParams(ctx, methodDecl, handler.GetRpcContext(), data)
+	if err != nil {
+		return nil, nil, true, err
+	}
+	rtnVals := implMethod.Call(callParams)
+	if rtnVals[0].IsNil() {
+		return nil, nil, true, nil
+	}
+	if onStart != nil {
+		onStart(startParams.getStartData())
+	}
+	isStream = true
+	return rtnVals[0].Interface(), cancelFn, true, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+type localTestServerImpl struct {
+	source string
+	meta   map[string]string
+}
+
+func (*localTestServerImpl) WshServerImpl() {}
+
+func (impl *localTestServerImpl) SetMetaCommand(ctx context.Context, data wshrpc.CommandSetMetaData) error {
+	impl.source = GetRpcSourceFromContext(ctx)
+	impl.meta = GetRpcMetaFromContext(ctx)
+	data.Meta["changed"] = true
+	return nil
+}
+
+func makeLocalTestRoutes(impl ServerImpl) (*WshRouter, *WshRpc) {
+	router := NewWshRouter()
+	serverRpc := MakeWshRpc(nil, nil, wshrpc.RpcContext{Conn: "local", Meta: map[string]string{"server": "1"}}, impl)
+	router.RegisterRoute("conn:local", serverRpc, false)
+	router.RegisterLocalImpl("conn:local", serverRpc)
+	caller := MakeWshRpc(nil, nil, wshrpc.RpcContext{Meta: map[string]string{"caller": "1"}}, nil)
+	router.RegisterRoute("tab:1", caller, false)
+	return router, caller
+}
+
+func TestLocalImplRequestContext(t *testing.T) {
+	impl := &localTestServerImpl{}
+	router, caller := makeLocalTestRoutes(impl)
+	data := wshrpc.CommandSetMetaData{Meta: waveobj.MetaMapType{"a": 1}}
+	opts := &wshrpc.RpcOpts{Route: "conn:local", Meta: map[string]string{"req": "1"}}
+	_, handled, err := router.CallLocalImpl(caller, wshrpc.Command_SetMeta, data, opts, 0)
+	if !handled || err != nil {
+		t.Fatalf("expected the call to be handled locally, handled:%v err:%v", handled, err)
+	}
+	if impl.source != "tab:1" {
+		t.Errorf("expected source %q, got %q", "tab:1", impl.source)
+	}
+	for _, key := range []string{"server", "caller", "req"} {
+		if impl.meta[key] != "1" {
+			t.Errorf("expected meta key %q in %v", key, impl.meta)
+		}
+	}
+	if _, ok := data.Meta["changed"]; ok {
+		t.Errorf("the impl's changes to the data leaked back to the caller")
+	}
+
+	opts = &wshrpc.RpcOpts{Route: "conn:local", Meta: map[string]string{"": "bad"}}
+	if _, _, err := router.CallLocalImpl(caller, wshrpc.Command_SetMeta, data, opts, 0); err == nil {
+		t.Errorf("expected invalid meta to be rejected")
+	}
+}
+
+func TestLocalImplRpcLog(t *testing.T) {
+	var logBuf syncBuffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+	SetRpcLogLevel(RpcLogLevel_Full)
+	defer SetRpcLogLevel(RpcLogLevel_Off)
+	router, caller := makeLocalTestRoutes(&localTestServerImpl{})
+	data := wshrpc.CommandSetMetaData{Meta: waveobj.MetaMapType{"a": 1, "ai:apitoken": "sk-test-0123456789"}}
+	router.CallLocalImpl(caller, wshrpc.Command_SetMeta, data, &wshrpc.RpcOpts{Route: "conn:local"}, 0)
+	logStr := logBuf.String()
+	if !strings.Contains(logStr, "[rpclog] cmd:setmeta route:tab:1") {
+		t.Errorf("expected the local call in the rpc log, got %q", logStr)
+	}
+	if strings.Contains(logStr, "sk-test-0123456789") || !strings.Contains(logStr, wshrpc.RedactedValue) {
+		t.Errorf("expected the token in the meta to be redacted, got %q", logStr)
+	}
+}
+
+func TestLocalImplCoalesce(t *testing.T) {
+	impl := &countingServerImpl{release: make(chan struct{})}
+	router, caller := makeLocalTestRoutes(impl)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := wshrpc.CommandFileData{FileName: "test.txt"}
+			rtn, _, err := router.CallLocalImpl(caller, wshrpc.Command_FileRead, data, &wshrpc.RpcOpts{Route: "conn:local"}, 0)
+			if err != nil || rtn != "data:test.txt" {
+				t.Errorf("unexpected result %v, err:%v", rtn, err)
+			}
+		}()
+	}
+	coalescer := router.getLocalImpl("conn:local").Rpc.coalescer
+	waitForCondition(t, "the reads to wait on the shared call", func() bool { return coalescer.getTotalWaiters() == 5 })
+	close(impl.release)
+	wg.Wait()
+	if count := impl.readCount.Load(); count != 1 {
+		t.Errorf("expected identical local reads to be coalesced, got %d calls", count)
+	}
+}
+
+func TestDeepCopyValue(t *testing.T) {
+	type inner struct {
+		Vals []int
+	}
+	type outer struct {
+		Data  []byte
+		Inner *inner
+		Map   map[string]any
+	}
+	orig := outer{Data: []byte("abc"), Inner: &inner{Vals: []int{1}}, Map: map[string]any{"list": []any{"x"}}}
+	cp := deepCopyValue(reflect.ValueOf(orig)).Interface().(outer)
+	cp.Data[0] = 'z'
+	cp.Inner.Vals[0] = 2
+	cp.Map["list"].([]any)[0] = "y"
+	if string(orig.Data) != "abc" || orig.Inner.Vals[0] != 1 || orig.Map["list"].([]any)[0] != "x" {
+		t.Errorf("copy shares memory with the original: %#v", orig)
+	}
+}
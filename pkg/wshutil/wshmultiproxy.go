@@ -77,14 +77,14 @@ func (p *WshRpcMultiProxy) sendResponseError(msg RpcMessage, sendErr error) {
 	p.ToRemoteCh <- respBytes
 }
 
-func (p *WshRpcMultiProxy) sendAuthResponse(msg RpcMessage, routeId string, authToken string) {
+func (p *WshRpcMultiProxy) sendAuthResponse(msg RpcMessage, routeId string, authToken string, signKey string) {
 	if msg.ReqId == "" {
 		// no response needed
 		return
 	}
 	resp := RpcMessage{
 		ResId: msg.ReqId,
-		Data:  wshrpc.CommandAuthenticateRtnData{RouteId: routeId, AuthToken: authToken},
+		Data:  wshrpc.CommandAuthenticateRtnData{RouteId: routeId, AuthToken: authToken, SignKey: signKey},
 	}
 	respBytes, _ := json.Marshal(resp)
 	p.ToRemoteCh <- respBytes
@@ -103,6 +103,15 @@ func (p *WshRpcMultiProxy) handleUnauthMessage(msgBytes []byte) {
 			p.sendResponseError(msg, err)
 			return
 		}
+		// the connserver's own link is the ssh session, the wsh clients it proxies sign frames on their
+		// domain socket (the connserver passes along their nonce, and gets the sign key back)
+		var signKey string
+		if msg.SignNonce != "" {
+			signKey = MakeRpcFrameSignKey(msg.Data.(string))
+		} else if IsFrameSigningRequired() && rpcContext.ClientType != wshrpc.ClientType_ConnServer {
+			p.sendResponseError(msg, ErrFrameSigningRequired)
+			return
+		}
 		routeInfo := &multiProxyRouteInfo{
 			RouteId:    routeId,
 			AuthToken:  uuid.New().String(),
@@ -111,7 +120,7 @@ func (p *WshRpcMultiProxy) handleUnauthMessage(msgBytes []byte) {
 		routeInfo.Proxy = MakeRpcProxy()
 		routeInfo.Proxy.SetRpcContext(rpcContext)
 		p.setRouteInfo(routeInfo.AuthToken, routeInfo)
-		p.sendAuthResponse(msg, routeId, routeInfo.AuthToken)
+		p.sendAuthResponse(msg, routeId, routeInfo.AuthToken, signKey)
 		go func() {
 			defer func() {
 				panichandler.PanicHandler("WshRpcMultiProxy:handleUnauthMessage", recover())
@@ -123,6 +132,15 @@ func (p *WshRpcMultiProxy) handleUnauthMessage(msgBytes []byte) {
 		DefaultRouter.RegisterRoute(routeId, routeInfo.Proxy, true)
 		return
 	}
+	if msg.Command == wshrpc.Command_Health && msg.AuthToken == "" {
+		if msg.ReqId != "" {
+			resp := makeHealthResponse(msg)
+			resp.Route = ""
+			respBytes, _ := json.Marshal(resp)
+			p.ToRemoteCh <- respBytes
+		}
+		return
+	}
 	if msg.AuthToken == "" {
 		p.sendResponseError(msg, fmt.Errorf("no auth token"))
 		return
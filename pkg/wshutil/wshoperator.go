@@ -0,0 +1,52 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// operator-only commands act on the machine a connection runs on (shutting down the connserver, etc).
+// the router checks them against the route the message arrived on, not msg.Source (which the sender sets).
+// the terminal router (wavesrv) only accepts them from operator routes (see IsOperatorRoute), routers with
+// an upstream (connservers) only from upstream, which has already checked them.
+var operatorOnlyCommands = map[string]bool{
+	wshrpc.Command_Shutdown:           true,
+	wshrpc.Command_RemoteKillProcess:  true,
+	wshrpc.Command_RemoteClipboardGet: true,
+	wshrpc.Command_RemoteClipboardSet: true,
+	wshrpc.Command_RemoteRunScript:    true,
+	wshrpc.Command_RemoteSetReadLimit: true,
+}
+
+func IsOperatorOnlyCommand(command string) bool {
+	return operatorOnlyCommands[command]
+}
+
+func (router *WshRouter) isOperatorInput(fromRouteId string) bool {
+	if fromRouteId == "" {
+		return false
+	}
+	if router.GetUpstreamClient() != nil {
+		return fromRouteId == UpstreamRoute
+	}
+	return router.IsOperatorRoute(fromRouteId)
+}
+
+func (router *WshRouter) handleNotOperator(msg RpcMessage, fromRouteId string) {
+	if msg.ReqId == "" {
+		return
+	}
+	errStr := fmt.Sprintf("%s is only allowed from the wave app (not %q)", msg.Command, fromRouteId)
+	router.recordRpcError(msg.Source, msg.Command, msg.Route, errStr, wshrpc.RpcErrorCode_Error)
+	response := RpcMessage{
+		ResId: msg.ReqId,
+		Error: errStr,
+	}
+	respBytes, _ := json.Marshal(response)
+	router.sendRoutedMessage(respBytes, fromRouteId)
+}
@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestOperatorOnlyCommands(t *testing.T) {
+	router := NewWshRouter()
+	tab := makeChanRpcClient()
+	connA := makeChanRpcClient()
+	connB := makeChanRpcClient()
+	defer close(tab.recvCh)
+	defer close(connA.recvCh)
+	defer close(connB.recvCh)
+	connARouteId := MakeConnectionRouteId("user@hosta")
+	connBRouteId := MakeConnectionRouteId("user@hostb")
+	router.RegisterRoute("tab:1", tab, false)
+	router.RegisterRoute(connARouteId, connA, false)
+	router.RegisterRoute(connBRouteId, connB, false)
+
+	for cmd := range operatorOnlyCommands {
+		// the frontend can send operator commands to a connection
+		tab.send(RpcMessage{Command: cmd, ReqId: cmd + "-tab", Route: connBRouteId})
+		connB.expect(t, func(msg RpcMessage) bool { return msg.Command == cmd && msg.ReqId == cmd+"-tab" })
+
+		// another connection can't, even when it claims to be the frontend
+		connA.send(RpcMessage{Command: cmd, ReqId: cmd + "-conn", Source: "tab:1", Route: connBRouteId})
+		connA.expect(t, func(msg RpcMessage) bool { return msg.ResId == cmd+"-conn" && msg.Error != "" })
+	}
+	// other commands are routed as usual
+	connA.send(RpcMessage{Command: wshrpc.Command_Message, ReqId: "msg", Route: connBRouteId})
+	connB.expect(t, func(msg RpcMessage) bool { return msg.ReqId == "msg" })
+}
+
+func TestOperatorOnlyCommandsWithUpstream(t *testing.T) {
+	router := NewWshRouter()
+	upstream := makeChanRpcClient()
+	localWsh := makeChanRpcClient()
+	server := makeChanRpcClient()
+	defer close(localWsh.recvCh)
+	defer close(server.recvCh)
+	router.SetUpstreamClient(upstream)
+	serverRouteId := MakeConnectionRouteId("user@host")
+	router.RegisterRoute("proc:1", localWsh, false)
+	router.RegisterRoute(serverRouteId, server, false)
+
+	// wsh sessions on the remote machine can't reach the connserver's operator commands
+	localWsh.send(RpcMessage{Command: wshrpc.Command_Shutdown, ReqId: "local", Route: serverRouteId})
+	localWsh.expect(t, func(msg RpcMessage) bool { return msg.ResId == "local" && msg.Error != "" })
+
+	// the wave app (upstream) can
+	router.InjectMessage([]byte(`{"command":"shutdown","reqid":"up","route":"`+serverRouteId+`","source":"tab:1"}`), UpstreamRoute)
+	server.expect(t, func(msg RpcMessage) bool { return msg.ReqId == "up" })
+}
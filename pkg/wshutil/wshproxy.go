@@ -6,6 +6,7 @@ package wshutil
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/google/uuid"
@@ -14,15 +15,18 @@ import (
 
 type WshRpcProxy struct {
 	Lock         *sync.Mutex
+	SendLock     *sync.Mutex // keeps signed frames in sequence order on ToRemoteCh
 	RpcContext   *wshrpc.RpcContext
 	ToRemoteCh   chan []byte
 	FromRemoteCh chan []byte
 	AuthToken    string
+	FrameSigner  *RpcFrameSigner // set when the remote negotiated frame signing at authenticate
 }
 
 func MakeRpcProxy() *WshRpcProxy {
 	return &WshRpcProxy{
 		Lock:         &sync.Mutex{},
+		SendLock:     &sync.Mutex{},
 		ToRemoteCh:   make(chan []byte, DefaultInputChSize),
 		FromRemoteCh: make(chan []byte, DefaultOutputChSize),
 	}
@@ -52,6 +56,18 @@ func (p *WshRpcProxy) GetAuthToken() string {
 	return p.AuthToken
 }
 
+func (p *WshRpcProxy) SetFrameSigner(signer *RpcFrameSigner) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	p.FrameSigner = signer
+}
+
+func (p *WshRpcProxy) GetFrameSigner() *RpcFrameSigner {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return p.FrameSigner
+}
+
 func (p *WshRpcProxy) sendResponseError(msg RpcMessage, sendErr error) {
 	if msg.ReqId == "" {
 		// no response needed
@@ -66,6 +82,15 @@ func (p *WshRpcProxy) sendResponseError(msg RpcMessage, sendErr error) {
 	p.SendRpcMessage(respBytes)
 }
 
+func (p *WshRpcProxy) sendHealthResponse(msg RpcMessage) {
+	if msg.ReqId == "" {
+		// no response needed
+		return
+	}
+	respBytes, _ := json.Marshal(makeHealthResponse(msg))
+	p.SendRpcMessage(respBytes)
+}
+
 func (p *WshRpcProxy) sendAuthenticateResponse(msg RpcMessage, routeId string) {
 	if msg.ReqId == "" {
 		// no response needed
@@ -133,13 +158,28 @@ func (p *WshRpcProxy) HandleClientProxyAuth(router *WshRouter) (string, error) {
 			p.sendResponseError(origMsg, respErr)
 			continue
 		}
-		authRtn, err := router.HandleProxyAuth(origMsg.Data)
+		// upstream checks conn:requirerpcsigning and hands back the sign key for the client's token
+		authRtn, err := router.HandleProxyAuth(origMsg.Data, origMsg.SignNonce)
 		if err != nil {
 			respErr := fmt.Errorf("error handling proxy auth: %w", err)
 			p.sendResponseError(origMsg, respErr)
 			return "", respErr
 		}
+		signer, err := makeAuthFrameSigner(origMsg, msgBytes, authRtn.SignKey, false)
+		if err != nil {
+			p.sendResponseError(origMsg, err)
+			disposeMsg := RpcMessage{
+				Command:   wshrpc.Command_Dispose,
+				Data:      wshrpc.CommandDisposeData{RouteId: authRtn.RouteId},
+				Source:    authRtn.RouteId,
+				AuthToken: authRtn.AuthToken,
+			}
+			disposeBytes, _ := json.Marshal(disposeMsg)
+			router.InjectMessage(disposeBytes, authRtn.RouteId)
+			return "", err
+		}
 		p.SetAuthToken(authRtn.AuthToken)
+		p.SetFrameSigner(signer)
 		announceMsg := RpcMessage{
 			Command:   wshrpc.Command_RouteAnnounce,
 			Source:    authRtn.RouteId,
@@ -169,7 +209,11 @@ func (p *WshRpcProxy) HandleAuthentication() (*wshrpc.RpcContext, error) {
 			// this message is not allowed (protocol error at this point), ignore
 			continue
 		}
-		// we only allow one command "authenticate", everything else returns an error
+		if msg.Command == wshrpc.Command_Health {
+			p.sendHealthResponse(msg)
+			continue
+		}
+		// other than health, we only allow one command "authenticate", everything else returns an error
 		if msg.Command != wshrpc.Command_Authenticate {
 			respErr := fmt.Errorf("connection not authenticated")
 			p.sendResponseError(msg, respErr)
@@ -180,17 +224,54 @@ func (p *WshRpcProxy) HandleAuthentication() (*wshrpc.RpcContext, error) {
 			p.sendResponseError(msg, err)
 			continue
 		}
+		signer, err := makeAuthFrameSigner(msg, msgBytes, MakeRpcFrameSignKey(msg.Data.(string)), IsFrameSigningRequired())
+		if err != nil {
+			p.sendResponseError(msg, err)
+			continue
+		}
+		p.SetFrameSigner(signer)
 		p.sendAuthenticateResponse(msg, routeId)
 		return newCtx, nil
 	}
 }
 
 func (p *WshRpcProxy) SendRpcMessage(msg []byte) {
+	p.SendLock.Lock()
+	defer p.SendLock.Unlock()
+	signer := p.GetFrameSigner()
+	if signer != nil {
+		signedMsg, err := signer.SignFrame(msg)
+		if err != nil {
+			log.Printf("error signing rpc frame (dropping): %v\n", err)
+			return
+		}
+		msg = signedMsg
+	}
 	p.ToRemoteCh <- msg
 }
 
+// drops (and answers with an error) frames that fail signature verification
+func (p *WshRpcProxy) recvVerifiedRpcMessage() ([]byte, bool) {
+	for {
+		msgBytes, more := <-p.FromRemoteCh
+		signer := p.GetFrameSigner()
+		if !more || signer == nil {
+			return msgBytes, more
+		}
+		verifiedBytes, err := signer.VerifyFrame(msgBytes)
+		if err == nil {
+			return verifiedBytes, true
+		}
+		log.Printf("rejecting rpc frame: %v\n", err)
+		var msg RpcMessage
+		if json.Unmarshal(msgBytes, &msg) == nil && msg.Command != "" {
+			p.sendResponseError(msg, fmt.Errorf("rejected rpc frame: %w", err))
+		}
+	}
+}
+
 func (p *WshRpcProxy) RecvRpcMessage() ([]byte, bool) {
-	msgBytes, more := <-p.FromRemoteCh
+	msgBytes, more := p.recvVerifiedRpcMessage()
 	authToken := p.GetAuthToken()
 	if !more || (p.RpcContext == nil && authToken == "") {
 		return msgBytes, more
@@ -0,0 +1,86 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the router keeps the last few error responses returned to each route (see GetRecentErrorsCommand) so
+// transient failures can be looked at after the fact.  errors that never pass through the router (client
+// side timeouts, in-process local impl calls) are not recorded.
+
+const MaxRecentErrors = 50
+const MaxRecentErrorRoutes = 256
+const MaxRecentErrorLen = 1024
+
+var rpcErrorCodeRe = regexp.MustCompile(`^(EC-[A-Z]+):`)
+
+func getRpcErrorCode(errStr string, partial bool) string {
+	if m := rpcErrorCodeRe.FindStringSubmatch(errStr); m != nil {
+		return m[1]
+	}
+	if partial {
+		return wshrpc.RpcErrorCode_StreamPartial
+	}
+	return wshrpc.RpcErrorCode_Error
+}
+
+// drops the route whose newest error is the oldest (routes are only cleaned up on unregister, announced
+// routes never are)
+func (router *WshRouter) evictRecentErrorRoute_nolock() {
+	var oldestRouteId string
+	var oldestTs int64
+	for routeId, records := range router.RecentErrors {
+		lastTs := records[len(records)-1].Ts
+		if oldestRouteId == "" || lastTs < oldestTs {
+			oldestRouteId, oldestTs = routeId, lastTs
+		}
+	}
+	delete(router.RecentErrors, oldestRouteId)
+}
+
+func (router *WshRouter) recordRpcError(sourceRouteId string, command string, destRouteId string, errStr string, code string) {
+	if sourceRouteId == "" {
+		return
+	}
+	if len(errStr) > MaxRecentErrorLen {
+		errStr = errStr[:MaxRecentErrorLen] + "...[truncated]"
+	}
+	record := wshrpc.RpcErrorRecord{
+		Ts:      time.Now().UnixMilli(),
+		Command: command,
+		Route:   destRouteId,
+		Message: errStr,
+		Code:    code,
+	}
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	records, found := router.RecentErrors[sourceRouteId]
+	if !found && len(router.RecentErrors) >= MaxRecentErrorRoutes {
+		router.evictRecentErrorRoute_nolock()
+	}
+	records = append(records, record)
+	if len(records) > MaxRecentErrors {
+		records = slices.Clone(records[len(records)-MaxRecentErrors:])
+	}
+	router.RecentErrors[sourceRouteId] = records
+}
+
+// returns up to limit of the most recent errors for the route (oldest first), limit <= 0 returns all of them
+func (router *WshRouter) GetRecentErrors(routeId string, limit int) []wshrpc.RpcErrorRecord {
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	records := router.RecentErrors[routeId]
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	rtn := make([]wshrpc.RpcErrorRecord, len(records))
+	copy(rtn, records)
+	return rtn
+}
@@ -0,0 +1,69 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRecentErrors(t *testing.T) {
+	router := NewWshRouter()
+	client := makeChanRpcClient()
+	server := makeChanRpcClient()
+	defer close(client.recvCh)
+	defer close(server.recvCh)
+	router.RegisterRoute("test:client", client, false)
+	router.RegisterRoute("test:server", server, false)
+
+	// no route
+	client.send(RpcMessage{Command: wshrpc.Command_Message, ReqId: "req1", Route: "test:missing"})
+	client.expect(t, func(msg RpcMessage) bool { return msg.ResId == "req1" && msg.Error != "" })
+	// plain and coded errors from the handler, then a success (not recorded)
+	for idx, errStr := range []string{"bad data", "EC-TIME: deadline exceeded", ""} {
+		reqId := fmt.Sprintf("req%d", idx+2)
+		client.send(RpcMessage{Command: wshrpc.Command_FileInfo, ReqId: reqId, Route: "test:server"})
+		server.expect(t, func(msg RpcMessage) bool { return msg.ReqId == reqId })
+		server.send(RpcMessage{ResId: reqId, Error: errStr})
+		client.expect(t, func(msg RpcMessage) bool { return msg.ResId == reqId })
+	}
+	// a stream ended by its handler
+	client.send(RpcMessage{Command: wshrpc.Command_StreamCpuData, ReqId: "req5", Route: "test:server"})
+	server.expect(t, func(msg RpcMessage) bool { return msg.ReqId == "req5" })
+	server.send(RpcMessage{ResId: "req5", Error: "stream failed", Partial: true})
+	client.expect(t, func(msg RpcMessage) bool { return msg.ResId == "req5" })
+
+	records := router.GetRecentErrors("test:client", 0)
+	expected := []wshrpc.RpcErrorRecord{
+		{Command: wshrpc.Command_Message, Route: "test:missing", Code: wshrpc.RpcErrorCode_NoRoute},
+		{Command: wshrpc.Command_FileInfo, Route: "test:server", Message: "bad data", Code: wshrpc.RpcErrorCode_Error},
+		{Command: wshrpc.Command_FileInfo, Route: "test:server", Message: "EC-TIME: deadline exceeded", Code: "EC-TIME"},
+		{Command: wshrpc.Command_StreamCpuData, Route: "test:server", Message: "stream failed", Code: wshrpc.RpcErrorCode_StreamPartial},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %d: %#v", len(expected), len(records), records)
+	}
+	for idx, exp := range expected {
+		rec := records[idx]
+		if rec.Command != exp.Command || rec.Route != exp.Route || rec.Code != exp.Code || (exp.Message != "" && rec.Message != exp.Message) || rec.Ts == 0 {
+			t.Errorf("record %d: got %#v, want %#v", idx, rec, exp)
+		}
+	}
+	if last := router.GetRecentErrors("test:client", 1); len(last) != 1 || last[0].Message != "stream failed" {
+		t.Errorf("limit 1 should return the newest error, got %#v", last)
+	}
+	if other := router.GetRecentErrors("test:server", 0); len(other) != 0 {
+		t.Errorf("errors should be kept per source route, got %#v", other)
+	}
+
+	for idx := 0; idx < MaxRecentErrors+10; idx++ {
+		router.recordRpcError("test:client", wshrpc.Command_FileInfo, "test:server", fmt.Sprintf("err %d", idx), wshrpc.RpcErrorCode_Error)
+	}
+	records = router.GetRecentErrors("test:client", 0)
+	if len(records) != MaxRecentErrors || records[len(records)-1].Message != fmt.Sprintf("err %d", MaxRecentErrors+9) || records[0].Message != "err 10" {
+		t.Errorf("buffer should keep the newest %d errors, got %d (first %q)", MaxRecentErrors, len(records), records[0].Message)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import "fmt"
+
+// an alias lets a route be addressed by another id (e.g. a renamed connection).  the route itself is not
+// changed, so in-flight rpcs and anything still using the original id keep working.
+
+func (router *WshRouter) SetRouteAlias(alias string, routeId string) error {
+	if alias == routeId {
+		return nil
+	}
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	if router.RouteMap[alias] != nil || router.AnnouncedRoutes[alias] != "" {
+		return fmt.Errorf("route %q already exists", alias)
+	}
+	if target, ok := router.RouteAliases[alias]; ok && target != routeId {
+		return fmt.Errorf("route alias %q already points to %q", alias, target)
+	}
+	router.RouteAliases[alias] = routeId
+	return nil
+}
+
+func (router *WshRouter) RemoveRouteAlias(alias string) {
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	delete(router.RouteAliases, alias)
+}
+
+// a registered (or announced) route always wins over an alias
+func (router *WshRouter) resolveRouteAlias(routeId string) string {
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	if router.RouteMap[routeId] != nil || router.AnnouncedRoutes[routeId] != "" {
+		return routeId
+	}
+	if target, ok := router.RouteAliases[routeId]; ok {
+		return target
+	}
+	return routeId
+}
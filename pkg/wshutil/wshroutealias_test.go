@@ -0,0 +1,89 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// a route whose sent messages are captured on sentCh and whose outgoing messages are fed through recvCh
+type chanRpcClient struct {
+	sentCh chan RpcMessage
+	recvCh chan []byte
+}
+
+func makeChanRpcClient() *chanRpcClient {
+	return &chanRpcClient{sentCh: make(chan RpcMessage, 16), recvCh: make(chan []byte, 16)}
+}
+
+func (c *chanRpcClient) SendRpcMessage(msgBytes []byte) {
+	var msg RpcMessage
+	json.Unmarshal(msgBytes, &msg)
+	c.sentCh <- msg
+}
+
+func (c *chanRpcClient) RecvRpcMessage() ([]byte, bool) {
+	msgBytes, ok := <-c.recvCh
+	return msgBytes, ok
+}
+
+func (c *chanRpcClient) send(msg RpcMessage) {
+	msgBytes, _ := json.Marshal(msg)
+	c.recvCh <- msgBytes
+}
+
+func (c *chanRpcClient) expect(t *testing.T, check func(msg RpcMessage) bool) {
+	t.Helper()
+	select {
+	case msg := <-c.sentCh:
+		if !check(msg) {
+			t.Errorf("unexpected message %#v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for message")
+	}
+}
+
+func TestRouteAliasKeepsStreams(t *testing.T) {
+	router := NewWshRouter()
+	client := makeChanRpcClient()
+	conn := makeChanRpcClient()
+	defer close(client.recvCh)
+	defer close(conn.recvCh)
+	connRouteId := MakeConnectionRouteId("user@oldhost")
+	aliasRouteId := MakeConnectionRouteId("prod-box")
+	router.RegisterRoute("test:client", client, false)
+	router.RegisterRoute(connRouteId, conn, false)
+
+	// start a stream on the original route
+	client.send(RpcMessage{Command: wshrpc.Command_StreamCpuData, ReqId: "stream1", Route: connRouteId})
+	conn.expect(t, func(msg RpcMessage) bool { return msg.ReqId == "stream1" })
+	conn.send(RpcMessage{ResId: "stream1", Cont: true, Data: 1})
+	client.expect(t, func(msg RpcMessage) bool { return msg.ResId == "stream1" })
+
+	if err := router.SetRouteAlias(aliasRouteId, connRouteId); err != nil {
+		t.Fatalf("error setting alias: %v", err)
+	}
+	if err := router.SetRouteAlias("test:client", connRouteId); err == nil {
+		t.Errorf("aliasing over a registered route should fail")
+	}
+
+	// the stream keeps flowing after the rename, and the alias reaches the same route
+	conn.send(RpcMessage{ResId: "stream1", Cont: true, Data: 2})
+	client.expect(t, func(msg RpcMessage) bool { return msg.ResId == "stream1" && msg.Cont })
+	client.send(RpcMessage{Command: wshrpc.Command_Message, ReqId: "req2", Route: aliasRouteId})
+	conn.expect(t, func(msg RpcMessage) bool { return msg.ReqId == "req2" })
+	conn.send(RpcMessage{ResId: "req2"})
+	client.expect(t, func(msg RpcMessage) bool { return msg.ResId == "req2" })
+	conn.send(RpcMessage{ResId: "stream1"})
+	client.expect(t, func(msg RpcMessage) bool { return msg.ResId == "stream1" && !msg.Cont })
+
+	router.RemoveRouteAlias(aliasRouteId)
+	client.send(RpcMessage{Command: wshrpc.Command_Message, ReqId: "req3", Route: aliasRouteId})
+	client.expect(t, func(msg RpcMessage) bool { return msg.ResId == "req3" && msg.Error != "" })
+}
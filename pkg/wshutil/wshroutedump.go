@@ -0,0 +1,147 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+type routeActivity struct {
+	LastTs     int64  // ts of the last message from the route, heartbeat on its link, or response delivered to it
+	ViaRouteId string // for announced routes, the link route they were announced through
+}
+
+// called (with Lock held) when a route is registered or announced, activity is only tracked for known routes
+func (router *WshRouter) addRouteActivity(routeId string, viaRouteId string) {
+	router.ActivityLock.Lock()
+	defer router.ActivityLock.Unlock()
+	router.RouteActivityMap[routeId] = &routeActivity{LastTs: time.Now().UnixMilli(), ViaRouteId: viaRouteId}
+}
+
+func (router *WshRouter) removeRouteActivity(routeId string) {
+	router.ActivityLock.Lock()
+	defer router.ActivityLock.Unlock()
+	delete(router.RouteActivityMap, routeId)
+}
+
+// last activity for a (registered or announced) route, 0 if the route is unknown
+func (router *WshRouter) getRouteLastActivity(routeId string) int64 {
+	router.ActivityLock.Lock()
+	defer router.ActivityLock.Unlock()
+	if activity := router.RouteActivityMap[routeId]; activity != nil {
+		return activity.LastTs
+	}
+	return 0
+}
+
+func (router *WshRouter) setRouteActivity_nolock(routeId string, ts int64) {
+	if activity := router.RouteActivityMap[routeId]; activity != nil {
+		activity.LastTs = ts
+	}
+}
+
+func (router *WshRouter) setRouteActivity(routeId string) {
+	router.ActivityLock.Lock()
+	defer router.ActivityLock.Unlock()
+	router.setRouteActivity_nolock(routeId, time.Now().UnixMilli())
+}
+
+// a message arrived on linkRouteId, it also counts for its source if the source is announced through the link
+// (a busy connection doesn't keep the routes behind it alive)
+func (router *WshRouter) setLinkMessageActivity(linkRouteId string, sourceRouteId string) {
+	router.ActivityLock.Lock()
+	defer router.ActivityLock.Unlock()
+	nowTs := time.Now().UnixMilli()
+	router.setRouteActivity_nolock(linkRouteId, nowTs)
+	if sourceRouteId == "" || sourceRouteId == linkRouteId {
+		return
+	}
+	if activity := router.RouteActivityMap[sourceRouteId]; activity != nil && activity.ViaRouteId == linkRouteId {
+		activity.LastTs = nowTs
+	}
+}
+
+// called for transport-level keepalives (e.g. websocket pings), which show that the process on the other
+// end of the link is alive, so they count for the link and every route announced through it
+func (router *WshRouter) RouteHeartbeat(linkRouteId string) {
+	router.ActivityLock.Lock()
+	defer router.ActivityLock.Unlock()
+	nowTs := time.Now().UnixMilli()
+	router.setRouteActivity_nolock(linkRouteId, nowTs)
+	for _, activity := range router.RouteActivityMap {
+		if activity.ViaRouteId == linkRouteId {
+			activity.LastTs = nowTs
+		}
+	}
+}
+
+// operator commands (diagnostics) are only allowed from routes registered directly with this router
+// (wavesrv, the frontend, local wsh).  connections and the remote routes announced through them are not.
+func (router *WshRouter) IsOperatorRoute(routeId string) bool {
+	if strings.HasPrefix(routeId, MakeConnectionRouteId("")) {
+		return false
+	}
+	return router.GetRpc(routeId) != nil
+}
+
+// true for routes registered with this router or announced through one of them (i.e. not reached via upstream)
+func (router *WshRouter) IsLocalRoute(routeId string) bool {
+	return router.GetRpc(routeId) != nil || router.getAnnouncedRoute(routeId) != ""
+}
+
+type routeDumpEntry struct {
+	routeId        string
+	rpc            AbstractRpcClient
+	viaRouteId     string
+	lastActivityTs int64
+}
+
+func (router *WshRouter) snapshotRoutes() []routeDumpEntry {
+	router.Lock.Lock()
+	defer router.Lock.Unlock()
+	rtn := make([]routeDumpEntry, 0, len(router.RouteMap)+len(router.AnnouncedRoutes))
+	for routeId, rpc := range router.RouteMap {
+		rtn = append(rtn, routeDumpEntry{routeId: routeId, rpc: rpc, lastActivityTs: router.getRouteLastActivity(routeId)})
+	}
+	for routeId, localRouteId := range router.AnnouncedRoutes {
+		rtn = append(rtn, routeDumpEntry{
+			routeId:        routeId,
+			rpc:            router.RouteMap[localRouteId],
+			viaRouteId:     localRouteId,
+			lastActivityTs: router.getRouteLastActivity(routeId),
+		})
+	}
+	return rtn
+}
+
+// snapshots the route table (under the router lock), then fills in rpc contexts and subscription counts
+func (router *WshRouter) DumpRoutes() []wshrpc.RouteInfo {
+	entries := router.snapshotRoutes()
+	rtn := make([]wshrpc.RouteInfo, 0, len(entries))
+	for _, entry := range entries {
+		info := wshrpc.RouteInfo{
+			RouteId:        entry.routeId,
+			Announced:      entry.viaRouteId != "",
+			ViaRouteId:     entry.viaRouteId,
+			NumSubs:        len(wps.Broker.ListSubscriptions(entry.routeId)),
+			LastActivityTs: entry.lastActivityTs,
+		}
+		rpcCtx := getRpcClientContext(entry.rpc)
+		if rpcCtx != nil {
+			info.ClientType = rpcCtx.ClientType
+			info.Conn = rpcCtx.Conn
+			info.BlockId = rpcCtx.BlockId
+		}
+		rtn = append(rtn, info)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].RouteId < rtn[j].RouteId
+	})
+	return rtn
+}
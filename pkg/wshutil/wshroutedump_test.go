@@ -0,0 +1,83 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestDumpRoutes(t *testing.T) {
+	router := NewWshRouter()
+	client := &silentRpcClient{doneCh: make(chan struct{})}
+	defer close(client.doneCh)
+	blockRpc := MakeWshRpc(nil, nil, wshrpc.RpcContext{ClientType: wshrpc.ClientType_BlockController, BlockId: "block-1"}, nil)
+	router.RegisterRoute("test:dump-block", blockRpc, false)
+	router.RegisterRoute(MakeConnectionRouteId("dump-conn"), client, false)
+	router.handleAnnounceMessage(RpcMessage{Command: wshrpc.Command_RouteAnnounce, Source: "test:dump-remote"}, msgAndRoute{fromRouteId: MakeConnectionRouteId("dump-conn")})
+	defer wps.Broker.UnsubscribeAll("test:dump-block")
+	wps.Broker.Subscribe("test:dump-block", wps.SubscriptionRequest{Event: wps.Event_BlockFile, AllScopes: true})
+
+	routes := make(map[string]wshrpc.RouteInfo)
+	for _, info := range router.DumpRoutes() {
+		routes[info.RouteId] = info
+	}
+	if len(routes) != 3 {
+		t.Fatalf("expected 3 routes, got %v", routes)
+	}
+	blockInfo := routes["test:dump-block"]
+	if blockInfo.ClientType != wshrpc.ClientType_BlockController || blockInfo.BlockId != "block-1" || blockInfo.NumSubs != 1 || blockInfo.Announced || blockInfo.LastActivityTs == 0 {
+		t.Errorf("unexpected info for the registered route: %+v", blockInfo)
+	}
+	remoteInfo := routes["test:dump-remote"]
+	if !remoteInfo.Announced || remoteInfo.ViaRouteId != MakeConnectionRouteId("dump-conn") || remoteInfo.LastActivityTs == 0 {
+		t.Errorf("unexpected info for the announced route: %+v", remoteInfo)
+	}
+
+	if !router.IsOperatorRoute("test:dump-block") || router.IsOperatorRoute(MakeConnectionRouteId("dump-conn")) || router.IsOperatorRoute("test:dump-remote") {
+		t.Errorf("only directly registered (non-connection) routes should be operator routes")
+	}
+	if !router.IsLocalRoute("test:dump-remote") || router.IsLocalRoute("test:dump-unknown") {
+		t.Errorf("expected announced routes to be local (and unknown routes not)")
+	}
+
+	router.UnregisterRoute(MakeConnectionRouteId("dump-conn"))
+	if ts := router.getRouteLastActivity("test:dump-remote"); ts != 0 {
+		t.Errorf("expected the activity of routes announced through an unregistered link to be dropped, got %d", ts)
+	}
+}
+
+func TestRouteActivityLock(t *testing.T) {
+	router := NewWshRouter()
+	client := &silentRpcClient{doneCh: make(chan struct{})}
+	defer close(client.doneCh)
+	router.RegisterRoute("test:activity-link", client, false)
+	router.handleAnnounceMessage(RpcMessage{Command: wshrpc.Command_RouteAnnounce, Source: "test:activity-remote"}, msgAndRoute{fromRouteId: "test:activity-link"})
+	startTs := router.getRouteLastActivity("test:activity-remote")
+	time.Sleep(5 * time.Millisecond)
+
+	// recording activity (done for every received message) doesn't wait on the router lock
+	router.Lock.Lock()
+	doneCh := make(chan struct{})
+	go func() {
+		router.setLinkMessageActivity("test:activity-link", "test:activity-remote")
+		router.setRouteActivity("test:activity-unknown")
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatalf("recording activity blocked on the router lock")
+	}
+	router.Lock.Unlock()
+	if ts := router.getRouteLastActivity("test:activity-remote"); ts <= startTs {
+		t.Errorf("expected the announced route's activity to be updated (was %d, now %d)", startTs, ts)
+	}
+	if ts := router.getRouteLastActivity("test:activity-unknown"); ts != 0 {
+		t.Errorf("expected no activity to be tracked for an unknown route, got %d", ts)
+	}
+}
@@ -32,6 +32,7 @@ type routeInfo struct {
 	RpcId         string
 	SourceRouteId string
 	DestRouteId   string
+	Command       string
 }
 
 type msgAndRoute struct {
@@ -41,11 +42,16 @@ type msgAndRoute struct {
 
 type WshRouter struct {
 	Lock             *sync.Mutex
-	RouteMap         map[string]AbstractRpcClient // routeid => client
-	UpstreamClient   AbstractRpcClient            // upstream client (if we are not the terminal router)
-	AnnouncedRoutes  map[string]string            // routeid => local routeid
-	RpcMap           map[string]*routeInfo        // rpcid => routeinfo
-	SimpleRequestMap map[string]chan *RpcMessage  // simple reqid => response channel
+	RouteMap         map[string]AbstractRpcClient       // routeid => client
+	UpstreamClient   AbstractRpcClient                  // upstream client (if we are not the terminal router)
+	AnnouncedRoutes  map[string]string                  // routeid => local routeid
+	RpcMap           map[string]*routeInfo              // rpcid => routeinfo
+	SimpleRequestMap map[string]chan *RpcMessage        // simple reqid => response channel
+	LocalImplMap     map[string]*localImplInfo          // routeid => in-process impl (for passthrough calls)
+	ActivityLock     *sync.Mutex                        // guards RouteActivityMap (updated on every message, so it doesn't take Lock)
+	RouteActivityMap map[string]*routeActivity          // registered and announced routes only (see wshroutedump.go)
+	RouteAliases     map[string]string                  // alias => routeid (see SetRouteAlias)
+	RecentErrors     map[string][]wshrpc.RpcErrorRecord // routeid => last MaxRecentErrors errors returned to the route
 	InputCh          chan msgAndRoute
 }
 
@@ -78,6 +84,11 @@ func NewWshRouter() *WshRouter {
 		AnnouncedRoutes:  make(map[string]string),
 		RpcMap:           make(map[string]*routeInfo),
 		SimpleRequestMap: make(map[string]chan *RpcMessage),
+		LocalImplMap:     make(map[string]*localImplInfo),
+		ActivityLock:     &sync.Mutex{},
+		RouteActivityMap: make(map[string]*routeActivity),
+		RouteAliases:     make(map[string]string),
+		RecentErrors:     make(map[string][]wshrpc.RpcErrorRecord),
 		InputCh:          make(chan msgAndRoute, DefaultInputChSize),
 	}
 	go rtn.runServer()
@@ -126,6 +137,7 @@ func (router *WshRouter) handleNoRoute(msg RpcMessage) {
 		return
 	}
 	// send error response
+	router.recordRpcError(msg.Source, msg.Command, msg.Route, nrErr.Error(), wshrpc.RpcErrorCode_NoRoute)
 	response := RpcMessage{
 		ResId: msg.ReqId,
 		Error: nrErr.Error(),
@@ -134,13 +146,13 @@ func (router *WshRouter) handleNoRoute(msg RpcMessage) {
 	router.sendRoutedMessage(respBytes, msg.Source)
 }
 
-func (router *WshRouter) registerRouteInfo(rpcId string, sourceRouteId string, destRouteId string) {
+func (router *WshRouter) registerRouteInfo(rpcId string, sourceRouteId string, destRouteId string, command string) {
 	if rpcId == "" {
 		return
 	}
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
-	router.RpcMap[rpcId] = &routeInfo{RpcId: rpcId, SourceRouteId: sourceRouteId, DestRouteId: destRouteId}
+	router.RpcMap[rpcId] = &routeInfo{RpcId: rpcId, SourceRouteId: sourceRouteId, DestRouteId: destRouteId, Command: command}
 }
 
 func (router *WshRouter) unregisterRouteInfo(rpcId string) {
@@ -170,12 +182,14 @@ func (router *WshRouter) handleAnnounceMessage(msg RpcMessage, input msgAndRoute
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
 	router.AnnouncedRoutes[msg.Source] = input.fromRouteId
+	router.addRouteActivity(msg.Source, input.fromRouteId)
 }
 
 func (router *WshRouter) handleUnannounceMessage(msg RpcMessage) {
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
 	delete(router.AnnouncedRoutes, msg.Source)
+	router.removeRouteActivity(msg.Source)
 }
 
 func (router *WshRouter) getAnnouncedRoute(routeId string) string {
@@ -186,6 +200,7 @@ func (router *WshRouter) getAnnouncedRoute(routeId string) string {
 
 // returns true if message was sent, false if failed
 func (router *WshRouter) sendRoutedMessage(msgBytes []byte, routeId string) bool {
+	routeId = router.resolveRouteAlias(routeId)
 	rpc := router.GetRpc(routeId)
 	if rpc != nil {
 		rpc.SendRpcMessage(msgBytes)
@@ -227,12 +242,16 @@ func (router *WshRouter) runServer() {
 		}
 		if msg.Command != "" {
 			// new comand, setup new rpc
+			if IsOperatorOnlyCommand(msg.Command) && !router.isOperatorInput(input.fromRouteId) {
+				router.handleNotOperator(msg, input.fromRouteId)
+				continue
+			}
 			ok := router.sendRoutedMessage(msgBytes, routeId)
 			if !ok {
 				router.handleNoRoute(msg)
 				continue
 			}
-			router.registerRouteInfo(msg.ReqId, msg.Source, routeId)
+			router.registerRouteInfo(msg.ReqId, msg.Source, routeId, msg.Command)
 			continue
 		}
 		// look at reqid or resid to route correctly
@@ -255,7 +274,12 @@ func (router *WshRouter) runServer() {
 				// no route info, nothing to do
 				continue
 			}
+			if msg.Error != "" {
+				router.recordRpcError(routeInfo.SourceRouteId, routeInfo.Command, routeInfo.DestRouteId, msg.Error, getRpcErrorCode(msg.Error, msg.Partial))
+			}
 			router.sendRoutedMessage(msgBytes, routeInfo.SourceRouteId)
+			// the requester is waiting on this response (e.g. a long running stream), so it counts as activity
+			router.setRouteActivity(routeInfo.SourceRouteId)
 			if !msg.Cont {
 				router.unregisterRouteInfo(msg.ResId)
 			}
@@ -299,6 +323,7 @@ func (router *WshRouter) RegisterRoute(routeId string, rpc AbstractRpcClient, sh
 		log.Printf("[router] warning: route %q already exists (replacing)\n", routeId)
 	}
 	router.RouteMap[routeId] = rpc
+	router.addRouteActivity(routeId, "")
 	go func() {
 		defer func() {
 			panichandler.PanicHandler("WshRouter:registerRoute:recvloop", recover())
@@ -319,6 +344,7 @@ func (router *WshRouter) RegisterRoute(routeId string, rpc AbstractRpcClient, sh
 			if err != nil {
 				continue
 			}
+			router.setLinkMessageActivity(routeId, rpcMsg.Source)
 			if rpcMsg.Command != "" {
 				if rpcMsg.Source == "" {
 					rpcMsg.Source = routeId
@@ -341,10 +367,14 @@ func (router *WshRouter) UnregisterRoute(routeId string) {
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
 	delete(router.RouteMap, routeId)
+	delete(router.LocalImplMap, routeId)
+	router.removeRouteActivity(routeId)
+	delete(router.RecentErrors, routeId)
 	// clear out announced routes
-	for routeId, localRouteId := range router.AnnouncedRoutes {
+	for announcedRouteId, localRouteId := range router.AnnouncedRoutes {
 		if localRouteId == routeId {
-			delete(router.AnnouncedRoutes, routeId)
+			delete(router.AnnouncedRoutes, announcedRouteId)
+			router.removeRouteActivity(announcedRouteId)
 		}
 	}
 	go func() {
@@ -363,6 +393,34 @@ func (router *WshRouter) GetRpc(routeId string) AbstractRpcClient {
 	return router.RouteMap[routeId]
 }
 
+// resolves the RpcContext for a route (following announced routes), returns nil if unknown
+func (router *WshRouter) GetRouteRpcContext(routeId string) *wshrpc.RpcContext {
+	rpc := router.GetRpc(routeId)
+	if rpc == nil {
+		localRouteId := router.getAnnouncedRoute(routeId)
+		if localRouteId != "" {
+			rpc = router.GetRpc(localRouteId)
+		}
+	}
+	return getRpcClientContext(rpc)
+}
+
+func getRpcClientContext(rpc AbstractRpcClient) *wshrpc.RpcContext {
+	switch rpcImpl := rpc.(type) {
+	case *WshRpc:
+		rpcCtx := rpcImpl.GetRpcContext()
+		return &rpcCtx
+	case *WshRpcProxy:
+		rpcCtx := rpcImpl.GetRpcContext()
+		if rpcCtx == nil {
+			return nil
+		}
+		rtn := *rpcCtx
+		return &rtn
+	}
+	return nil
+}
+
 func (router *WshRouter) SetUpstreamClient(rpc AbstractRpcClient) {
 	router.Lock.Lock()
 	defer router.Lock.Unlock()
@@ -433,7 +491,8 @@ func (router *WshRouter) RunSimpleRawCommand(ctx context.Context, msg RpcMessage
 	}
 }
 
-func (router *WshRouter) HandleProxyAuth(jwtTokenAny any) (*wshrpc.CommandAuthenticateRtnData, error) {
+// signNonce is the nonce of a client that requested frame signing (passed upstream so it can return the sign key)
+func (router *WshRouter) HandleProxyAuth(jwtTokenAny any, signNonce string) (*wshrpc.CommandAuthenticateRtnData, error) {
 	if jwtTokenAny == nil {
 		return nil, errors.New("no jwt token")
 	}
@@ -445,9 +504,10 @@ func (router *WshRouter) HandleProxyAuth(jwtTokenAny any) (*wshrpc.CommandAuthen
 		return nil, errors.New("empty jwt token")
 	}
 	msg := RpcMessage{
-		Command: wshrpc.Command_Authenticate,
-		ReqId:   uuid.New().String(),
-		Data:    jwtToken,
+		Command:   wshrpc.Command_Authenticate,
+		ReqId:     uuid.New().String(),
+		Data:      jwtToken,
+		SignNonce: signNonce,
 	}
 	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeoutMs*time.Millisecond)
 	defer cancelFn()
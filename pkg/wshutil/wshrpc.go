@@ -52,6 +52,8 @@ type WshRpc struct {
 	ResponseHandlerMap map[string]*RpcResponseHandler // reqId => handler
 	Debug              bool
 	DebugName          string
+	coalescer          *callCoalescer // for idempotent commands
+	drain              drainState     // protected by Lock
 }
 
 type wshRpcContextKey struct{}
@@ -89,6 +91,15 @@ func GetIsCanceledFromContext(ctx context.Context) bool {
 	return rtn.(*RpcResponseHandler).IsCanceled()
 }
 
+// returns the request metadata (merged over the rpc context's metadata), may be nil
+func GetRpcMetaFromContext(ctx context.Context) map[string]string {
+	rtn := ctx.Value(wshRpcRespHandlerContextKey{})
+	if rtn == nil {
+		return nil
+	}
+	return rtn.(*RpcResponseHandler).GetRpcContext().Meta
+}
+
 func GetRpcResponseHandlerFromContext(ctx context.Context) *RpcResponseHandler {
 	rtn := ctx.Value(wshRpcRespHandlerContextKey{})
 	if rtn == nil {
@@ -107,18 +118,25 @@ func (w *WshRpc) RecvRpcMessage() ([]byte, bool) {
 }
 
 type RpcMessage struct {
-	Command   string `json:"command,omitempty"`
-	ReqId     string `json:"reqid,omitempty"`
-	ResId     string `json:"resid,omitempty"`
-	Timeout   int    `json:"timeout,omitempty"`
-	Route     string `json:"route,omitempty"`     // to route/forward requests to alternate servers
-	AuthToken string `json:"authtoken,omitempty"` // needed for routing unauthenticated requests (WshRpcMultiProxy)
-	Source    string `json:"source,omitempty"`    // source route id
-	Cont      bool   `json:"cont,omitempty"`      // flag if additional requests/responses are forthcoming
-	Cancel    bool   `json:"cancel,omitempty"`    // used to cancel a streaming request or response (sent from the side that is not streaming)
-	Error     string `json:"error,omitempty"`
-	DataType  string `json:"datatype,omitempty"`
-	Data      any    `json:"data,omitempty"`
+	Command     string `json:"command,omitempty"`
+	ReqId       string `json:"reqid,omitempty"`
+	ResId       string `json:"resid,omitempty"`
+	Timeout     int    `json:"timeout,omitempty"`
+	Route       string `json:"route,omitempty"`     // to route/forward requests to alternate servers
+	AuthToken   string `json:"authtoken,omitempty"` // needed for routing unauthenticated requests (WshRpcMultiProxy)
+	Source      string `json:"source,omitempty"`    // source route id
+	Cont        bool   `json:"cont,omitempty"`      // flag if additional requests/responses are forthcoming
+	Cancel      bool   `json:"cancel,omitempty"`    // used to cancel a streaming request or response (sent from the side that is not streaming)
+	Error       string `json:"error,omitempty"`
+	Partial     bool   `json:"partial,omitempty"`     // set on a stream's final error when the handler ended the stream (prior responses are valid)
+	StreamStart bool   `json:"streamstart,omitempty"` // on a command, requests a start ack; on a response, marks the ack (data is wshrpc.StreamStartData)
+	DataType    string `json:"datatype,omitempty"`
+	Data        any    `json:"data,omitempty"`
+	SignNonce   string `json:"signnonce,omitempty"` // on an authenticate command, requests frame signing (see RpcFrameSigner)
+	Seq         int64  `json:"seq,omitempty"`       // sequence number of a signed frame
+	Sig         string `json:"sig,omitempty"`       // hmac of a signed frame
+
+	Meta map[string]string `json:"meta,omitempty"` // request metadata (only for command packets)
 }
 
 func (r *RpcMessage) IsRpcRequest() bool {
@@ -141,6 +159,9 @@ func (r *RpcMessage) Validate() error {
 		}
 		return nil
 	}
+	if r.StreamStart && r.ResId != "" && !r.Cont {
+		return fmt.Errorf("stream start packets must have cont set")
+	}
 	if r.Command != "" {
 		if r.ResId != "" {
 			return fmt.Errorf("command packets may not have resid set")
@@ -151,7 +172,7 @@ func (r *RpcMessage) Validate() error {
 		if r.DataType != "" {
 			return fmt.Errorf("command packets may not have datatype set")
 		}
-		return nil
+		return wshrpc.ValidateRpcMeta(r.Meta)
 	}
 	if r.ReqId != "" {
 		if r.ResId == "" {
@@ -211,6 +232,7 @@ func MakeWshRpc(inputCh chan []byte, outputCh chan []byte, rpcCtx wshrpc.RpcCont
 		EventListener:      MakeEventListener(),
 		ServerImpl:         serverImpl,
 		ResponseHandlerMap: make(map[string]*RpcResponseHandler),
+		coalescer:          makeCallCoalescer(),
 	}
 	rtn.RpcContext.Store(&rpcCtx)
 	go rtn.runServer()
@@ -259,6 +281,9 @@ func (w *WshRpc) cancelRequest(reqId string) {
 	handler := w.ResponseHandlerMap[reqId]
 	if handler != nil {
 		handler.canceled.Store(true)
+		if handler.streaming.Load() {
+			handler.cancelContext()
+		}
 	}
 
 }
@@ -280,13 +305,23 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 		return
 	}
 
+	startTs := time.Now()
 	var respHandler *RpcResponseHandler
+	rpcCtx := w.GetRpcContext()
+	if len(req.Meta) > 0 {
+		if err := wshrpc.ValidateRpcMeta(req.Meta); err != nil {
+			log.Printf("wshrpc dropping invalid request meta (command %q): %v\n", req.Command, err)
+		} else {
+			rpcCtx.Meta = wshrpc.MergeRpcMeta(rpcCtx.Meta, req.Meta)
+		}
+	}
 	timeoutMs := req.Timeout
 	if timeoutMs <= 0 {
 		timeoutMs = DefaultTimeoutMs
 	}
 	ctx, cancelFn := context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
 	ctx = withWshRpcContext(ctx, w)
+	ctx, startParams := withStreamStartParams(ctx)
 	respHandler = &RpcResponseHandler{
 		w:               w,
 		ctx:             ctx,
@@ -296,12 +331,25 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 		source:          req.Source,
 		done:            &atomic.Bool{},
 		canceled:        &atomic.Bool{},
+		streaming:       &atomic.Bool{},
 		contextCancelFn: &atomic.Pointer[context.CancelFunc]{},
-		rpcCtx:          w.GetRpcContext(),
+		rpcCtx:          rpcCtx,
+		rtnErr:          &atomic.Pointer[string]{},
+		wantStreamStart: req.StreamStart,
+		startParams:     startParams,
 	}
 	respHandler.contextCancelFn.Store(&cancelFn)
 	respHandler.ctx = withRespHandler(ctx, respHandler)
-	w.registerResponseHandler(req.ReqId, respHandler)
+	trackCall, err := w.startInflight(req.Command)
+	if err != nil {
+		respHandler.SendResponseError(err)
+		cancelFn()
+		return
+	}
+	if req.ReqId != "" {
+		// fire-and-forget requests can't be canceled, so there is nothing to register
+		w.registerResponseHandler(req.ReqId, respHandler)
+	}
 	isAsync := false
 	defer func() {
 		panicErr := panichandler.PanicHandler("handleRequest", recover())
@@ -315,10 +363,18 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 				}()
 				<-ctx.Done()
 				respHandler.Finalize()
+				if trackCall {
+					w.finishInflight()
+				}
+				logRpcRequest(respHandler, startTs)
 			}()
 		} else {
 			cancelFn()
 			respHandler.Finalize()
+			if trackCall {
+				w.finishInflight()
+			}
+			logRpcRequest(respHandler, startTs)
 		}
 	}()
 	handlerFn := serverImplAdapter(w.ServerImpl)
@@ -451,12 +507,13 @@ func (w *WshRpc) SendRpcRequest(command string, data any, opts *wshrpc.RpcOpts)
 }
 
 type RpcRequestHandler struct {
-	w           *WshRpc
-	ctx         context.Context
-	ctxCancelFn *atomic.Pointer[context.CancelFunc]
-	reqId       string
-	respCh      chan *RpcMessage
-	cachedResp  *RpcMessage
+	w             *WshRpc
+	ctx           context.Context
+	ctxCancelFn   *atomic.Pointer[context.CancelFunc]
+	reqId         string
+	respCh        chan *RpcMessage
+	cachedResp    *RpcMessage
+	onStreamStart func(wshrpc.StreamStartData)
 }
 
 func (handler *RpcRequestHandler) Context() context.Context {
@@ -501,10 +558,17 @@ func (handler *RpcRequestHandler) NextResponse() (any, error) {
 	} else {
 		resp = <-handler.respCh
 	}
+	for resp != nil && resp.StreamStart {
+		handler.handleStreamStart(resp)
+		resp = <-handler.respCh
+	}
 	if resp == nil {
 		return nil, errors.New("response channel closed")
 	}
 	if resp.Error != "" {
+		if resp.Partial {
+			return nil, wshrpc.StreamPartialError{Msg: resp.Error}
+		}
 		return nil, errors.New(resp.Error)
 	}
 	return resp.Data, nil
@@ -531,7 +595,11 @@ type RpcResponseHandler struct {
 	commandData     any
 	rpcCtx          wshrpc.RpcContext
 	canceled        *atomic.Bool // canceled by requestor
+	streaming       *atomic.Bool // response streams also have their ctx canceled when canceled (so the producer can stop)
 	done            *atomic.Bool
+	rtnErr          *atomic.Pointer[string] // last error sent to the requestor (for the rpc log)
+	wantStreamStart bool                    // requestor asked for a start ack
+	startParams     *streamStartParams
 }
 
 func (handler *RpcResponseHandler) Context() context.Context {
@@ -559,6 +627,11 @@ func (handler *RpcResponseHandler) NeedsResponse() bool {
 }
 
 func (handler *RpcResponseHandler) SendMessage(msg string) {
+	if handler.w == nil {
+		// local (passthrough) request, there is no link to send the message on
+		log.Printf("wshrpc message from command %q: %s\n", handler.command, msg)
+		return
+	}
 	rpcMsg := &RpcMessage{
 		Command: wshrpc.Command_Message,
 		Data: wshrpc.CommandMessageData{
@@ -601,7 +674,13 @@ func (handler *RpcResponseHandler) SendResponseError(err error) {
 	defer func() {
 		panichandler.PanicHandler("SendResponseError", recover())
 	}()
-	if handler.reqId == "" || handler.done.Load() {
+	handler.setRtnErr(err)
+	if handler.reqId == "" {
+		// the caller won't see this error, so log it
+		log.Printf("wshrpc error in command %q (no response requested): %v\n", handler.command, err)
+		return
+	}
+	if handler.done.Load() {
 		return
 	}
 	defer handler.close()
@@ -614,10 +693,49 @@ func (handler *RpcResponseHandler) SendResponseError(err error) {
 	handler.w.OutputCh <- barr
 }
 
+// ends a response stream with an error reported by the handler, the client sees PartialComplete
+func (handler *RpcResponseHandler) SendStreamError(err error) {
+	defer func() {
+		panichandler.PanicHandler("SendStreamError", recover())
+	}()
+	handler.setRtnErr(err)
+	if handler.reqId == "" {
+		log.Printf("wshrpc error in command %q (no response requested): %v\n", handler.command, err)
+		return
+	}
+	if handler.done.Load() {
+		return
+	}
+	defer handler.close()
+	msg := &RpcMessage{
+		ResId:     handler.reqId,
+		Error:     err.Error(),
+		Partial:   true,
+		AuthToken: handler.w.GetAuthToken(),
+	}
+	barr, _ := json.Marshal(msg) // will never fail
+	handler.w.OutputCh <- barr
+}
+
 func (handler *RpcResponseHandler) IsCanceled() bool {
 	return handler.canceled.Load()
 }
 
+func (handler *RpcResponseHandler) cancelContext() {
+	cancelFn := handler.contextCancelFn.Load()
+	if cancelFn != nil && *cancelFn != nil {
+		(*cancelFn)()
+	}
+}
+
+// marks the request as a response stream, a cancel that already arrived cancels the context now
+func (handler *RpcResponseHandler) setStreaming() {
+	handler.streaming.Store(true)
+	if handler.canceled.Load() {
+		handler.cancelContext()
+	}
+}
+
 func (handler *RpcResponseHandler) close() {
 	cancelFn := handler.contextCancelFn.Load()
 	if cancelFn != nil && *cancelFn != nil {
@@ -642,26 +760,41 @@ func (handler *RpcResponseHandler) IsDone() bool {
 }
 
 func (w *WshRpc) SendComplexRequest(command string, data any, opts *wshrpc.RpcOpts) (rtnHandler *RpcRequestHandler, rtnErr error) {
+	return w.sendComplexRequest(command, data, opts, false)
+}
+
+// like SendComplexRequest, but requests a stream start ack (unless opts.NoStreamStart), delivered to opts.OnStreamStart
+func (w *WshRpc) SendStreamRequest(command string, data any, opts *wshrpc.RpcOpts) (*RpcRequestHandler, error) {
 	if opts == nil {
 		opts = &wshrpc.RpcOpts{}
 	}
-	timeoutMs := opts.Timeout
-	if timeoutMs <= 0 {
-		timeoutMs = DefaultTimeoutMs
+	return w.sendComplexRequest(command, data, opts, !opts.NoStreamStart)
+}
+
+func (w *WshRpc) sendComplexRequest(command string, data any, opts *wshrpc.RpcOpts, streamStart bool) (rtnHandler *RpcRequestHandler, rtnErr error) {
+	if opts == nil {
+		opts = &wshrpc.RpcOpts{}
 	}
 	defer func() {
 		panichandler.PanicHandler("SendComplexRequest", recover())
 	}()
+	timeoutMs, err := opts.GetTimeoutMs(DefaultTimeoutMs)
+	if err != nil {
+		return nil, err
+	}
 	if command == "" {
 		return nil, fmt.Errorf("command cannot be empty")
 	}
 	handler := &RpcRequestHandler{
-		w:           w,
-		ctxCancelFn: &atomic.Pointer[context.CancelFunc]{},
+		w:             w,
+		ctx:           context.Background(),
+		ctxCancelFn:   &atomic.Pointer[context.CancelFunc]{},
+		onStreamStart: opts.OnStreamStart,
+	}
+	reqMeta := wshrpc.MergeRpcMeta(w.GetRpcContext().Meta, opts.Meta)
+	if err := wshrpc.ValidateRpcMeta(reqMeta); err != nil {
+		return nil, err
 	}
-	var cancelFn context.CancelFunc
-	handler.ctx, cancelFn = context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
-	handler.ctxCancelFn.Store(&cancelFn)
 	if !opts.NoResponse {
 		handler.reqId = uuid.New().String()
 	}
@@ -672,11 +805,23 @@ func (w *WshRpc) SendComplexRequest(command string, data any, opts *wshrpc.RpcOp
 		Timeout:   timeoutMs,
 		Route:     opts.Route,
 		AuthToken: w.GetAuthToken(),
+		Meta:      reqMeta,
+	}
+	if streamStart && !opts.NoResponse {
+		req.StreamStart = true
 	}
 	barr, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
+	if opts.NoResponse {
+		// fire-and-forget, no response waiter (or timeout) is needed
+		w.OutputCh <- barr
+		return handler, nil
+	}
+	var cancelFn context.CancelFunc
+	handler.ctx, cancelFn = context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+	handler.ctxCancelFn.Store(&cancelFn)
 	handler.respCh = w.registerRpc(handler.ctx, handler.reqId)
 	w.OutputCh <- barr
 	return handler, nil
@@ -0,0 +1,353 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+type noResponseServerImpl struct {
+	calledCh chan string
+}
+
+func (*noResponseServerImpl) WshServerImpl() {}
+
+func (impl *noResponseServerImpl) FileWriteCommand(ctx context.Context, data wshrpc.CommandFileData) error {
+	impl.calledCh <- data.FileName
+	return errors.New("write failed")
+}
+
+func TestNoResponseCommand(t *testing.T) {
+	impl := &noResponseServerImpl{calledCh: make(chan string, 1)}
+	clientToServer := make(chan []byte, DefaultInputChSize)
+	serverToClient := make(chan []byte, DefaultOutputChSize)
+	server := MakeWshRpc(clientToServer, serverToClient, wshrpc.RpcContext{}, impl)
+	// not wired to the server, so every frame the client sends can be inspected
+	clientOutput := make(chan []byte, DefaultOutputChSize)
+	client := MakeWshRpc(make(chan []byte), clientOutput, wshrpc.RpcContext{}, nil)
+
+	data := wshrpc.CommandFileData{ZoneId: "zone", FileName: "file.txt"}
+	err := client.SendCommand(wshrpc.Command_FileWrite, data, nil)
+	if err != nil {
+		t.Fatalf("SendCommand failed: %v", err)
+	}
+	client.Lock.Lock()
+	numWaiters := len(client.RpcMap)
+	client.Lock.Unlock()
+	if numWaiters != 0 {
+		t.Errorf("expected no response waiters, got %d", numWaiters)
+	}
+	clientToServer <- <-clientOutput
+
+	select {
+	case fileName := <-impl.calledCh:
+		if fileName != "file.txt" {
+			t.Errorf("unexpected filename %q", fileName)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler was not called")
+	}
+	select {
+	case msg := <-serverToClient:
+		t.Errorf("expected no response frame, got %s", string(msg))
+	case <-time.After(100 * time.Millisecond):
+	}
+	server.Lock.Lock()
+	numHandlers := len(server.ResponseHandlerMap)
+	server.Lock.Unlock()
+	if numHandlers != 0 {
+		t.Errorf("expected no registered response handlers, got %d", numHandlers)
+	}
+}
+
+type partialStreamServerImpl struct {
+	numItems int
+}
+
+func (*partialStreamServerImpl) WshServerImpl() {}
+
+func (impl *partialStreamServerImpl) StreamCpuDataCommand(ctx context.Context, request wshrpc.CpuDataRequest) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	// unbuffered, the handler blocks if the error isn't treated as the final element and drained
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData])
+	go func() {
+		defer close(ch)
+		for i := 0; i < impl.numItems; i++ {
+			ch <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Response: wshrpc.TimeSeriesData{Ts: int64(i)}}
+		}
+		ch <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Error: errors.New("injected error")}
+		ch <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Response: wshrpc.TimeSeriesData{Ts: -1}}
+	}()
+	return ch
+}
+
+func readTestStream(t *testing.T, client *WshRpc) ([]int64, error) {
+	handler, err := client.SendComplexRequest(wshrpc.Command_StreamCpuData, wshrpc.CpuDataRequest{}, nil)
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+	var items []int64
+	for !handler.ResponseDone() {
+		resp, err := handler.NextResponse()
+		if err != nil {
+			return items, err
+		}
+		respMap, _ := resp.(map[string]any)
+		ts, _ := respMap["ts"].(float64)
+		items = append(items, int64(ts))
+	}
+	return items, nil
+}
+
+func TestStreamErrorAfterItems(t *testing.T) {
+	client := makeTestRpcPair(&partialStreamServerImpl{numItems: 5})
+	items, err := readTestStream(t, client)
+	if err == nil {
+		t.Fatalf("expected stream to end with an error")
+	}
+	if !wshrpc.IsStreamPartialError(err) || err.Error() != "injected error" {
+		t.Errorf("expected partial stream error, got %#v", err)
+	}
+	if len(items) != 5 {
+		t.Fatalf("expected 5 items before the error, got %v", items)
+	}
+	for idx, ts := range items {
+		if ts != int64(idx) {
+			t.Errorf("item %d: expected ts %d, got %d", idx, idx, ts)
+		}
+	}
+}
+
+type drainServerImpl struct {
+	startedCh chan struct{}
+	releaseCh chan struct{}
+}
+
+func (*drainServerImpl) WshServerImpl() {}
+
+func (impl *drainServerImpl) FileReadCommand(ctx context.Context, data wshrpc.CommandFileData) (string, error) {
+	impl.startedCh <- struct{}{}
+	<-impl.releaseCh
+	return "ZG9uZQ==", nil
+}
+
+func (impl *drainServerImpl) StreamCpuDataCommand(ctx context.Context, request wshrpc.CpuDataRequest) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData])
+	go func() {
+		defer close(ch)
+		<-ctx.Done()
+	}()
+	return ch
+}
+
+func TestDrainWaitsForInflightCalls(t *testing.T) {
+	impl := &drainServerImpl{startedCh: make(chan struct{}, 1), releaseCh: make(chan struct{})}
+	clientToServer := make(chan []byte, DefaultInputChSize)
+	serverToClient := make(chan []byte, DefaultOutputChSize)
+	server := MakeWshRpc(clientToServer, serverToClient, wshrpc.RpcContext{}, impl)
+	client := MakeWshRpc(serverToClient, clientToServer, wshrpc.RpcContext{}, nil)
+
+	streamHandler, err := client.SendComplexRequest(wshrpc.Command_StreamCpuData, wshrpc.CpuDataRequest{}, nil)
+	if err != nil {
+		t.Fatalf("error starting stream: %v", err)
+	}
+	callErrCh := make(chan error, 1)
+	go func() {
+		_, err := client.SendRpcRequest(wshrpc.Command_FileRead, wshrpc.CommandFileData{}, nil)
+		callErrCh <- err
+	}()
+	<-impl.startedCh
+
+	drainedCh := make(chan bool, 1)
+	go func() {
+		drainedCh <- server.Drain(5 * time.Second)
+	}()
+	for !server.IsDraining() {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = client.SendRpcRequest(wshrpc.Command_FileRead, wshrpc.CommandFileData{}, nil)
+	if !wshrpc.IsShuttingDownError(err) {
+		t.Errorf("expected shutting down error for a new request, got %v", err)
+	}
+	select {
+	case <-drainedCh:
+		t.Fatalf("drain returned while a call was in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(impl.releaseCh)
+	select {
+	case drained := <-drainedCh:
+		if !drained {
+			t.Errorf("expected in-flight calls to drain within the grace period")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("drain did not return after the in-flight call finished")
+	}
+	if err := <-callErrCh; err != nil {
+		t.Errorf("in-flight call failed: %v", err)
+	}
+	// the stream doesn't hold up the drain, it is canceled afterwards
+	for !streamHandler.ResponseDone() {
+		_, err := streamHandler.NextResponse()
+		if err != nil {
+			break
+		}
+	}
+}
+
+type streamStartServerImpl struct{}
+
+func (*streamStartServerImpl) WshServerImpl() {}
+
+func (*streamStartServerImpl) StreamCpuDataCommand(ctx context.Context, request wshrpc.CpuDataRequest) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	SetStreamStartParam(ctx, wshrpc.StreamStartParam_IntervalMs, 250)
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData], 2)
+	ch <- wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData]{Response: wshrpc.TimeSeriesData{Ts: 1}}
+	close(ch)
+	return ch
+}
+
+func TestStreamStartAck(t *testing.T) {
+	client := makeTestRpcPair(&streamStartServerImpl{})
+	var startData *wshrpc.StreamStartData
+	opts := &wshrpc.RpcOpts{OnStreamStart: func(data wshrpc.StreamStartData) {
+		startData = &data
+	}}
+	handler, err := client.SendStreamRequest(wshrpc.Command_StreamCpuData, wshrpc.CpuDataRequest{}, opts)
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+	resp, err := handler.NextResponse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if startData == nil {
+		t.Fatalf("expected the start ack before the first item")
+	}
+	if interval, _ := startData.Params[wshrpc.StreamStartParam_IntervalMs].(float64); interval != 250 {
+		t.Errorf("expected intervalms param 250, got %v", startData.Params)
+	}
+	if respMap, _ := resp.(map[string]any); respMap["ts"] != float64(1) {
+		t.Errorf("expected first item ts=1, got %v", resp)
+	}
+
+	// opted out, no ack (and the items are unchanged)
+	startData = nil
+	opts.NoStreamStart = true
+	items, err := readTestStream(t, client)
+	if err != nil || len(items) == 0 || items[0] != 1 {
+		t.Fatalf("unexpected stream result: %v %v", items, err)
+	}
+	handler, err = client.SendStreamRequest(wshrpc.Command_StreamCpuData, wshrpc.CpuDataRequest{}, opts)
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+	if _, err := handler.NextResponse(); err != nil || startData != nil {
+		t.Errorf("expected no start ack when opted out (err=%v, start=%v)", err, startData)
+	}
+}
+
+func TestChainedCallsShareDeadline(t *testing.T) {
+	client := makeTestRpcPair(&countingServerImpl{readDelay: 200 * time.Millisecond})
+	opts := &wshrpc.RpcOpts{Timeout: 5000, Deadline: time.Now().Add(500 * time.Millisecond)}
+	startTs := time.Now()
+	for idx, fileName := range []string{"a.txt", "b.txt"} {
+		if _, err := client.SendRpcRequest(wshrpc.Command_FileRead, wshrpc.CommandFileData{FileName: fileName}, opts); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", idx+1, err)
+		}
+	}
+	// only ~100ms is left for the third call, it must time out instead of getting its own 5s
+	if _, err := client.SendRpcRequest(wshrpc.Command_FileRead, wshrpc.CommandFileData{FileName: "c.txt"}, opts); err == nil {
+		t.Fatalf("expected the third call to exceed the shared deadline")
+	}
+	if elapsed := time.Since(startTs); elapsed > time.Second {
+		t.Errorf("chain took %v, expected it to be bounded by the 500ms deadline", elapsed)
+	}
+	if _, err := client.SendRpcRequest(wshrpc.Command_FileRead, wshrpc.CommandFileData{FileName: "d.txt"}, opts); !errors.Is(err, wshrpc.ErrDeadlineExceeded) {
+		t.Errorf("expected calls after the deadline to fail immediately, got %v", err)
+	}
+}
+
+func TestCapabilitiesFromHandlers(t *testing.T) {
+	client := makeTestRpcPair(&streamStartServerImpl{})
+	resp, err := client.SendRpcRequest(wshrpc.Command_Capabilities, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var caps wshrpc.CapabilitiesRtnData
+	if err := utilfn.ReUnmarshal(&caps, resp); err != nil {
+		t.Fatalf("error decoding capabilities: %v", err)
+	}
+	if !slices.Contains(caps.Commands, wshrpc.Command_StreamCpuData) || !slices.Contains(caps.Commands, wshrpc.Command_Capabilities) {
+		t.Errorf("expected the impl's commands (and capabilities), got %v", caps.Commands)
+	}
+	if slices.Contains(caps.Commands, wshrpc.Command_FileRead) {
+		t.Errorf("unimplemented command listed: %v", caps.Commands)
+	}
+	if caps.ProtocolVersion != wshrpc.WshRpcProtocolVersion || caps.Codec != wshrpc.RpcCodec_Json {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+}
+
+type cancelServerImpl struct {
+	startedCh chan string
+	ctxErrCh  chan error
+}
+
+func (*cancelServerImpl) WshServerImpl() {}
+
+func (impl *cancelServerImpl) FileReadCommand(ctx context.Context, data wshrpc.CommandFileData) (string, error) {
+	impl.startedCh <- wshrpc.Command_FileRead
+	time.Sleep(200 * time.Millisecond)
+	impl.ctxErrCh <- ctx.Err()
+	return "", nil
+}
+
+func (impl *cancelServerImpl) StreamCpuDataCommand(ctx context.Context, request wshrpc.CpuDataRequest) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData])
+	go func() {
+		defer close(ch)
+		impl.startedCh <- wshrpc.Command_StreamCpuData
+		select {
+		case <-ctx.Done():
+			impl.ctxErrCh <- ctx.Err()
+		case <-time.After(2 * time.Second):
+			impl.ctxErrCh <- nil
+		}
+	}()
+	return ch
+}
+
+// a cancel stops a response stream's producer (via its ctx), but only marks a regular call as canceled
+func TestCancelRequestContext(t *testing.T) {
+	impl := &cancelServerImpl{startedCh: make(chan string, 1), ctxErrCh: make(chan error, 1)}
+	client := makeTestRpcPair(impl)
+
+	streamHandler, err := client.SendComplexRequest(wshrpc.Command_StreamCpuData, wshrpc.CpuDataRequest{}, nil)
+	if err != nil {
+		t.Fatalf("error starting stream: %v", err)
+	}
+	<-impl.startedCh
+	streamHandler.SendCancel()
+	if err := <-impl.ctxErrCh; err == nil {
+		t.Errorf("expected the stream's context to be canceled")
+	}
+
+	callHandler, err := client.SendComplexRequest(wshrpc.Command_FileRead, wshrpc.CommandFileData{}, nil)
+	if err != nil {
+		t.Fatalf("error sending call: %v", err)
+	}
+	<-impl.startedCh
+	callHandler.SendCancel()
+	if err := <-impl.ctxErrCh; err != nil {
+		t.Errorf("expected a canceled call to keep its context, got %v", err)
+	}
+}
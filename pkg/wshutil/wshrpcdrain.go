@@ -0,0 +1,86 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// tracks in-flight non-stream calls so a shutdown can let them finish.  streams are long-lived, they
+// are canceled once the calls have drained (or the grace period runs out).
+type drainState struct {
+	draining      bool
+	inflightCalls int
+	drainedCh     chan struct{} // closed when draining and inflightCalls reaches 0
+}
+
+// returns whether the request counts as an in-flight call (must be paired with finishInflight)
+func (w *WshRpc) startInflight(command string) (bool, error) {
+	w.Lock.Lock()
+	defer w.Lock.Unlock()
+	if w.drain.draining {
+		return false, fmt.Errorf("%s: rpc server is shutting down (command %q rejected)", wshrpc.ErrPrefix_ShuttingDown, command)
+	}
+	methodDecl := WshCommandDeclMap[command]
+	if methodDecl != nil && methodDecl.CommandType == wshrpc.RpcType_ResponseStream {
+		return false, nil
+	}
+	w.drain.inflightCalls++
+	return true, nil
+}
+
+func (w *WshRpc) finishInflight() {
+	w.Lock.Lock()
+	defer w.Lock.Unlock()
+	w.drain.inflightCalls--
+	if w.drain.draining && w.drain.inflightCalls == 0 {
+		close(w.drain.drainedCh)
+	}
+}
+
+func (w *WshRpc) IsDraining() bool {
+	w.Lock.Lock()
+	defer w.Lock.Unlock()
+	return w.drain.draining
+}
+
+// stops accepting new requests (they fail with ErrPrefix_ShuttingDown), waits up to grace for in-flight
+// calls to finish, then cancels everything still running (streams and stragglers).
+// returns false if calls were still running when the grace period ran out.
+// a call that invokes Drain must not wait for it (its own handler counts as in-flight).
+func (w *WshRpc) Drain(grace time.Duration) bool {
+	w.Lock.Lock()
+	if !w.drain.draining {
+		w.drain.draining = true
+		w.drain.drainedCh = make(chan struct{})
+		if w.drain.inflightCalls == 0 {
+			close(w.drain.drainedCh)
+		}
+	}
+	drainedCh := w.drain.drainedCh
+	w.Lock.Unlock()
+	drained := true
+	select {
+	case <-drainedCh:
+	case <-time.After(grace):
+		drained = false
+	}
+	w.cancelAllRequests()
+	return drained
+}
+
+func (w *WshRpc) cancelAllRequests() {
+	w.Lock.Lock()
+	reqIds := make([]string, 0, len(w.ResponseHandlerMap))
+	for reqId := range w.ResponseHandlerMap {
+		reqIds = append(reqIds, reqId)
+	}
+	w.Lock.Unlock()
+	for _, reqId := range reqIds {
+		w.cancelRequest(reqId)
+	}
+}
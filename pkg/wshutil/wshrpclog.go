@@ -0,0 +1,109 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// per-command request logging for debugging (set with the debug:rpclog setting in wavesrv).
+// "summary" logs the command, source route, duration, and outcome.  "full" also logs the request
+// data, decoded into its typed struct so that fields tagged `wshlog:"redact"` can be replaced.
+
+const (
+	RpcLogLevel_Off     = "off"
+	RpcLogLevel_Summary = "summary"
+	RpcLogLevel_Full    = "full"
+)
+
+const MaxRpcLogDataLen = 2048
+
+var rpcLogLevel = &atomic.Pointer[string]{}
+
+// "" is the same as RpcLogLevel_Off
+func ValidateRpcLogLevel(level string) error {
+	if level != "" && level != RpcLogLevel_Off && level != RpcLogLevel_Summary && level != RpcLogLevel_Full {
+		return fmt.Errorf("invalid rpc log level %q (must be %q, %q, or %q)", level, RpcLogLevel_Off, RpcLogLevel_Summary, RpcLogLevel_Full)
+	}
+	return nil
+}
+
+func SetRpcLogLevel(level string) error {
+	err := ValidateRpcLogLevel(level)
+	if err != nil {
+		return err
+	}
+	if level == "" {
+		level = RpcLogLevel_Off
+	}
+	rpcLogLevel.Store(&level)
+	return nil
+}
+
+func GetRpcLogLevel() string {
+	level := rpcLogLevel.Load()
+	if level == nil {
+		return RpcLogLevel_Off
+	}
+	return *level
+}
+
+func (handler *RpcResponseHandler) setRtnErr(err error) {
+	if err == nil {
+		return
+	}
+	errStr := err.Error()
+	handler.rtnErr.Store(&errStr)
+}
+
+func (handler *RpcResponseHandler) getLogOutcome() string {
+	if errStr := handler.rtnErr.Load(); errStr != nil {
+		return fmt.Sprintf("error:%q", *errStr)
+	}
+	if handler.IsCanceled() {
+		return "canceled"
+	}
+	return "ok"
+}
+
+// untyped data (unknown commands, bad data) is never logged since it can't be redacted
+func makeRpcLogData(command string, rawData any) string {
+	if rawData == nil {
+		return ""
+	}
+	cmdData, err := recodeCommandData(command, rawData, nil)
+	if err != nil {
+		return "[untyped]"
+	}
+	if _, isMap := cmdData.(map[string]any); isMap {
+		return "[untyped]"
+	}
+	barr, err := json.Marshal(wshrpc.RedactForLog(cmdData))
+	if err != nil {
+		return "[unmarshalable]"
+	}
+	if len(barr) > MaxRpcLogDataLen {
+		return string(barr[:MaxRpcLogDataLen]) + "...[truncated]"
+	}
+	return string(barr)
+}
+
+func logRpcRequest(handler *RpcResponseHandler, startTs time.Time) {
+	level := GetRpcLogLevel()
+	if level == RpcLogLevel_Off {
+		return
+	}
+	durMs := time.Since(startTs).Milliseconds()
+	if level == RpcLogLevel_Full {
+		log.Printf("[rpclog] cmd:%s route:%s reqid:%s dur:%dms outcome:%s data:%s\n", handler.command, handler.source, handler.reqId, durMs, handler.getLogOutcome(), makeRpcLogData(handler.command, handler.commandData))
+		return
+	}
+	log.Printf("[rpclog] cmd:%s route:%s reqid:%s dur:%dms outcome:%s\n", handler.command, handler.source, handler.reqId, durMs, handler.getLogOutcome())
+}
@@ -0,0 +1,101 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+type rpcLogServerImpl struct{}
+
+func (*rpcLogServerImpl) WshServerImpl() {}
+
+func (*rpcLogServerImpl) StreamWaveAiCommand(ctx context.Context, request wshrpc.WaveAIStreamRequest) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	ch := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	close(ch)
+	return ch
+}
+
+func (*rpcLogServerImpl) FileWriteCommand(ctx context.Context, data wshrpc.CommandFileData) error {
+	return nil
+}
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestRpcLogRedaction(t *testing.T) {
+	const secretToken = "sk-test-0123456789"
+	const secretData64 = "c2VjcmV0IGZpbGUgY29udGVudHM="
+	logBuf := &syncBuffer{}
+	log.SetOutput(logBuf)
+	defer log.SetOutput(os.Stderr)
+	defer SetRpcLogLevel(RpcLogLevel_Off)
+	err := SetRpcLogLevel(RpcLogLevel_Full)
+	if err != nil {
+		t.Fatalf("error setting log level: %v", err)
+	}
+
+	client := makeTestRpcPair(&rpcLogServerImpl{})
+	aiReq := wshrpc.WaveAIStreamRequest{
+		Opts:   &wshrpc.WaveAIOptsType{Model: "gpt-test", APIToken: secretToken},
+		Prompt: []wshrpc.WaveAIPromptMessageType{{Role: "user", Content: "hello"}},
+	}
+	handler, err := client.SendComplexRequest(wshrpc.Command_StreamWaveAi, aiReq, nil)
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+	for !handler.ResponseDone() {
+		if _, err := handler.NextResponse(); err != nil {
+			break
+		}
+	}
+	_, err = client.SendRpcRequest(wshrpc.Command_FileWrite, wshrpc.CommandFileData{ZoneId: "zone", FileName: "f.txt", Data64: secretData64}, nil)
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+
+	// the stream is logged when its handler finalizes, which can trail the final response
+	var logStr string
+	for idx := 0; idx < 100; idx++ {
+		logStr = logBuf.String()
+		if strings.Contains(logStr, "cmd:"+wshrpc.Command_StreamWaveAi) && strings.Contains(logStr, "cmd:"+wshrpc.Command_FileWrite) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, cmd := range []string{wshrpc.Command_StreamWaveAi, wshrpc.Command_FileWrite} {
+		if !strings.Contains(logStr, "cmd:"+cmd) {
+			t.Errorf("expected a log line for %q, got:\n%s", cmd, logStr)
+		}
+	}
+	if !strings.Contains(logStr, "gpt-test") || !strings.Contains(logStr, wshrpc.RedactedValue) {
+		t.Errorf("expected request data with redacted fields, got:\n%s", logStr)
+	}
+	if strings.Contains(logStr, secretToken) || strings.Contains(logStr, secretData64) {
+		t.Errorf("redacted field leaked into the rpc log:\n%s", logStr)
+	}
+}
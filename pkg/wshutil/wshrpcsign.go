@@ -0,0 +1,232 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// integrity signing of rpc frames on wsh links (domain sockets to wavesrv or a connserver).
+// wavesrv derives a sign key for each jwt token from a random per-process secret and hands it to the
+// client out of band (WAVETERM_RPCSIGNKEY, next to WAVETERM_JWT), connservers get it from wavesrv when
+// they authenticate a client.  the key itself never crosses the link being signed.
+// a client opts in by sending a nonce with its authenticate command (RpcMessage.SignNonce), then both
+// sides sign every frame they send with a per-direction key derived from the sign key and the nonce.
+// frames carry an increasing sequence number, and the receiver rejects frames with a bad signature or a
+// sequence number it has already seen.  with the conn:requirerpcsigning setting, wavesrv (and connservers,
+// which ask wavesrv) refuse clients that don't opt in, so a relay can't downgrade by stripping the nonce.
+
+const WaveRpcSignKeyVarName = "WAVETERM_RPCSIGNKEY"
+const rpcFrameSignKeyLabel = "wshrpc-frame-signing:"
+const rpcFrameNonceSize = 16
+const rpcFrameSecretSize = 32
+
+const (
+	rpcFrameDir_ClientToServer = "c2s:"
+	rpcFrameDir_ServerToClient = "s2c:"
+)
+
+var ErrFrameSignature = errors.New("invalid rpc frame signature")
+var ErrFrameReplay = errors.New("replayed rpc frame")
+var ErrFrameSigningRequired = errors.New("rpc frame signing is required (conn:requirerpcsigning)")
+
+var rpcFrameSecretOnce sync.Once
+var rpcFrameSecret []byte
+var requireFrameSigning atomic.Bool
+
+// set in wavesrv from the conn:requirerpcsigning setting
+func SetRequireFrameSigning(require bool) {
+	requireFrameSigning.Store(require)
+}
+
+func IsFrameSigningRequired() bool {
+	return requireFrameSigning.Load()
+}
+
+func getRpcFrameSecret() []byte {
+	rpcFrameSecretOnce.Do(func() {
+		rpcFrameSecret = make([]byte, rpcFrameSecretSize)
+		if _, err := rand.Read(rpcFrameSecret); err != nil {
+			panic(fmt.Sprintf("error generating rpc frame signing secret: %v", err))
+		}
+	})
+	return rpcFrameSecret
+}
+
+// only meaningful in wavesrv (the process that issues jwt tokens), returns the sign key for authToken (url-safe base64, so it can go in shell env assignments)
+func MakeRpcFrameSignKey(authToken string) string {
+	mac := hmac.New(sha256.New, getRpcFrameSecret())
+	mac.Write([]byte(rpcFrameSignKeyLabel + authToken))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type RpcFrameSigner struct {
+	Lock        *sync.Mutex
+	Nonce       string
+	sendKey     []byte
+	recvKey     []byte
+	sendSeq     int64
+	lastRecvSeq int64
+}
+
+func deriveFrameKey(signKey []byte, dir string, nonce string) []byte {
+	mac := hmac.New(sha256.New, signKey)
+	mac.Write([]byte(rpcFrameSignKeyLabel + dir + nonce))
+	return mac.Sum(nil)
+}
+
+// signKey is the base64 key from MakeRpcFrameSignKey, isClient picks the direction of each key
+func MakeRpcFrameSigner(signKey string, nonce string, isClient bool) (*RpcFrameSigner, error) {
+	keyBytes, err := base64.RawURLEncoding.DecodeString(signKey)
+	if err != nil || len(keyBytes) == 0 {
+		return nil, fmt.Errorf("invalid rpc sign key")
+	}
+	c2sKey := deriveFrameKey(keyBytes, rpcFrameDir_ClientToServer, nonce)
+	s2cKey := deriveFrameKey(keyBytes, rpcFrameDir_ServerToClient, nonce)
+	signer := &RpcFrameSigner{Lock: &sync.Mutex{}, Nonce: nonce, sendKey: s2cKey, recvKey: c2sKey}
+	if isClient {
+		signer.sendKey, signer.recvKey = c2sKey, s2cKey
+	}
+	return signer, nil
+}
+
+// creates a signer with a fresh random nonce (for the client side)
+func MakeClientRpcFrameSigner(signKey string) (*RpcFrameSigner, error) {
+	nonceBytes := make([]byte, rpcFrameNonceSize)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return nil, fmt.Errorf("error generating signing nonce: %w", err)
+	}
+	return MakeRpcFrameSigner(signKey, base64.RawURLEncoding.EncodeToString(nonceBytes), true)
+}
+
+// msg.Sig must be empty.  signs the canonical (re-marshaled) json of the message.
+func computeFrameSig(key []byte, msg *RpcMessage) (string, error) {
+	barr, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling rpc frame: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(barr)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// assigns the next sequence number and signs the frame (an authenticate command also carries the nonce).
+// frames must be sent in the order they are signed.
+func (s *RpcFrameSigner) SignFrame(msgBytes []byte) ([]byte, error) {
+	var msg RpcMessage
+	if err := json.Unmarshal(msgBytes, &msg); err != nil {
+		return nil, fmt.Errorf("error unmarshaling rpc frame: %w", err)
+	}
+	if msg.Command == wshrpc.Command_Authenticate {
+		msg.SignNonce = s.Nonce
+	}
+	s.Lock.Lock()
+	defer s.Lock.Unlock()
+	s.sendSeq++
+	msg.Seq = s.sendSeq
+	msg.Sig = ""
+	sig, err := computeFrameSig(s.sendKey, &msg)
+	if err != nil {
+		return nil, err
+	}
+	msg.Sig = sig
+	return json.Marshal(msg)
+}
+
+// verifies the signature and sequence number, returns the frame with the signing fields stripped
+func (s *RpcFrameSigner) VerifyFrame(msgBytes []byte) ([]byte, error) {
+	var msg RpcMessage
+	if err := json.Unmarshal(msgBytes, &msg); err != nil {
+		return nil, fmt.Errorf("error unmarshaling rpc frame: %w", err)
+	}
+	sig := msg.Sig
+	if sig == "" {
+		return nil, ErrFrameSignature
+	}
+	msg.Sig = ""
+	expectedSig, err := computeFrameSig(s.recvKey, &msg)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(sig), []byte(expectedSig)) {
+		return nil, ErrFrameSignature
+	}
+	s.Lock.Lock()
+	defer s.Lock.Unlock()
+	if msg.Seq <= s.lastRecvSeq {
+		return nil, ErrFrameReplay
+	}
+	s.lastRecvSeq = msg.Seq
+	msg.Seq = 0
+	msg.SignNonce = ""
+	return json.Marshal(msg)
+}
+
+// returns a channel with every frame from outputCh signed (closed when outputCh is closed)
+func (s *RpcFrameSigner) signOutputCh(outputCh chan []byte) chan []byte {
+	signedCh := make(chan []byte, DefaultOutputChSize)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RpcFrameSigner:signOutputCh", recover())
+		}()
+		defer close(signedCh)
+		for msgBytes := range outputCh {
+			signedBytes, err := s.SignFrame(msgBytes)
+			if err != nil {
+				log.Printf("error signing rpc frame (dropping): %v\n", err)
+				continue
+			}
+			signedCh <- signedBytes
+		}
+	}()
+	return signedCh
+}
+
+// forwards the frames from rawCh that pass verification to inputCh (closed when rawCh is closed)
+func (s *RpcFrameSigner) verifyInputCh(rawCh chan []byte, inputCh chan []byte) {
+	defer func() {
+		panichandler.PanicHandler("RpcFrameSigner:verifyInputCh", recover())
+	}()
+	defer close(inputCh)
+	for msgBytes := range rawCh {
+		verifiedBytes, err := s.VerifyFrame(msgBytes)
+		if err != nil {
+			log.Printf("rejecting rpc frame: %v\n", err)
+			continue
+		}
+		inputCh <- verifiedBytes
+	}
+}
+
+// sets up frame signing if the authenticate command requested it (verifies the authenticate frame itself).
+// signKey is the key for the token in the authenticate command (see MakeRpcFrameSignKey).  returns nil
+// when signing wasn't requested and isn't required.
+func makeAuthFrameSigner(msg RpcMessage, msgBytes []byte, signKey string, require bool) (*RpcFrameSigner, error) {
+	if msg.SignNonce == "" {
+		if require {
+			return nil, ErrFrameSigningRequired
+		}
+		return nil, nil
+	}
+	signer, err := MakeRpcFrameSigner(signKey, msg.SignNonce, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := signer.VerifyFrame(msgBytes); err != nil {
+		return nil, fmt.Errorf("error verifying authenticate frame: %w", err)
+	}
+	return signer, nil
+}
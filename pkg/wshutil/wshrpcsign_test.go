@@ -0,0 +1,228 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func signTestFrame(t *testing.T, signer *RpcFrameSigner, msg RpcMessage) []byte {
+	t.Helper()
+	msgBytes, _ := json.Marshal(msg)
+	signedBytes, err := signer.SignFrame(msgBytes)
+	if err != nil {
+		t.Fatalf("error signing frame: %v", err)
+	}
+	return signedBytes
+}
+
+func makeTestSigner(t *testing.T, signKey string, nonce string, isClient bool) *RpcFrameSigner {
+	t.Helper()
+	signer, err := MakeRpcFrameSigner(signKey, nonce, isClient)
+	if err != nil {
+		t.Fatalf("error making signer: %v", err)
+	}
+	return signer
+}
+
+func TestRpcFrameSigning(t *testing.T) {
+	signKey := MakeRpcFrameSignKey("test-token")
+	clientSigner, err := MakeClientRpcFrameSigner(signKey)
+	if err != nil {
+		t.Fatalf("error making signer: %v", err)
+	}
+	authBytes := signTestFrame(t, clientSigner, RpcMessage{Command: wshrpc.Command_Authenticate, ReqId: "req-1", Data: "test-token"})
+	var authMsg RpcMessage
+	json.Unmarshal(authBytes, &authMsg)
+	if authMsg.SignNonce != clientSigner.Nonce {
+		t.Fatalf("expected the authenticate frame to carry the nonce")
+	}
+	serverSigner, err := makeAuthFrameSigner(authMsg, authBytes, signKey, false)
+	if err != nil || serverSigner == nil {
+		t.Fatalf("expected signing to be negotiated, err: %v", err)
+	}
+
+	// responses are signed too (with the other direction's key)
+	respFrame := signTestFrame(t, serverSigner, RpcMessage{ResId: "req-1", Data: "ok"})
+	if _, err := clientSigner.VerifyFrame(respFrame); err != nil {
+		t.Fatalf("valid response rejected: %v", err)
+	}
+	// a frame can't be reflected back to its sender
+	reflected := signTestFrame(t, clientSigner, RpcMessage{Command: "getmeta", ReqId: "req-reflect"})
+	if _, err := clientSigner.VerifyFrame(reflected); !errors.Is(err, ErrFrameSignature) {
+		t.Errorf("expected reflected frame to be rejected, got %v", err)
+	}
+
+	// valid
+	frame := signTestFrame(t, clientSigner, RpcMessage{Command: "getmeta", ReqId: "req-2", Data: map[string]any{"oref": "block:123", "n": 5}})
+	verifiedBytes, err := serverSigner.VerifyFrame(frame)
+	if err != nil {
+		t.Fatalf("valid frame rejected: %v", err)
+	}
+	var verifiedMsg RpcMessage
+	json.Unmarshal(verifiedBytes, &verifiedMsg)
+	if verifiedMsg.Command != "getmeta" || verifiedMsg.Seq != 0 || verifiedMsg.Sig != "" {
+		t.Errorf("unexpected verified frame: %s", verifiedBytes)
+	}
+
+	// replayed
+	if _, err := serverSigner.VerifyFrame(frame); !errors.Is(err, ErrFrameReplay) {
+		t.Errorf("expected replayed frame to be rejected, got %v", err)
+	}
+
+	// tampered
+	frame = signTestFrame(t, clientSigner, RpcMessage{Command: "getmeta", ReqId: "req-3", Data: map[string]any{"oref": "block:123"}})
+	tampered := []byte(strings.Replace(string(frame), "block:123", "block:456", 1))
+	if _, err := serverSigner.VerifyFrame(tampered); !errors.Is(err, ErrFrameSignature) {
+		t.Errorf("expected tampered frame to be rejected, got %v", err)
+	}
+	// the untampered frame is still accepted (the tampered one didn't advance the sequence)
+	if _, err := serverSigner.VerifyFrame(frame); err != nil {
+		t.Errorf("expected the original frame to be accepted, got %v", err)
+	}
+
+	// unsigned, or signed with a different key
+	unsigned, _ := json.Marshal(RpcMessage{Command: "getmeta", ReqId: "req-4"})
+	if _, err := serverSigner.VerifyFrame(unsigned); !errors.Is(err, ErrFrameSignature) {
+		t.Errorf("expected unsigned frame to be rejected, got %v", err)
+	}
+	otherSigner := makeTestSigner(t, signKey, "other-nonce", true)
+	otherSigner.sendSeq = 100
+	if _, err := serverSigner.VerifyFrame(signTestFrame(t, otherSigner, RpcMessage{Command: "getmeta", ReqId: "req-5"})); !errors.Is(err, ErrFrameSignature) {
+		t.Errorf("expected frame from another key to be rejected, got %v", err)
+	}
+}
+
+func TestRpcFrameSignKey(t *testing.T) {
+	signKey := MakeRpcFrameSignKey("test-token")
+	if signKey == MakeRpcFrameSignKey("other-token") {
+		t.Fatalf("expected different tokens to get different sign keys")
+	}
+	if strings.Contains(signKey, "test-token") {
+		t.Fatalf("sign key should not contain the token")
+	}
+	// someone who only saw the jwt token (sent on the link) can't produce a valid authenticate frame
+	forger, err := MakeClientRpcFrameSigner(base64.RawURLEncoding.EncodeToString([]byte("test-token")))
+	if err != nil {
+		t.Fatalf("error making signer: %v", err)
+	}
+	authBytes := signTestFrame(t, forger, RpcMessage{Command: wshrpc.Command_Authenticate, ReqId: "req-1", Data: "test-token"})
+	var authMsg RpcMessage
+	json.Unmarshal(authBytes, &authMsg)
+	if _, err := makeAuthFrameSigner(authMsg, authBytes, signKey, false); !errors.Is(err, ErrFrameSignature) {
+		t.Errorf("expected a frame signed without the sign key to be rejected, got %v", err)
+	}
+}
+
+func TestRpcFrameSigningNotRequested(t *testing.T) {
+	msg := RpcMessage{Command: wshrpc.Command_Authenticate, ReqId: "req-1", Data: "test-token"}
+	msgBytes, _ := json.Marshal(msg)
+	signKey := MakeRpcFrameSignKey("test-token")
+	signer, err := makeAuthFrameSigner(msg, msgBytes, signKey, false)
+	if err != nil || signer != nil {
+		t.Fatalf("expected no signer without a nonce, got %v, %v", signer, err)
+	}
+	// with conn:requirerpcsigning, stripping the nonce doesn't downgrade the link
+	if _, err := makeAuthFrameSigner(msg, msgBytes, signKey, true); !errors.Is(err, ErrFrameSigningRequired) {
+		t.Fatalf("expected unsigned authenticate to be refused, got %v", err)
+	}
+}
+
+func TestRpcProxyRequireSigning(t *testing.T) {
+	SetRequireFrameSigning(true)
+	defer SetRequireFrameSigning(false)
+	proxy := MakeRpcProxy()
+	go proxy.HandleAuthentication()
+	jwtToken, err := MakeClientJWTToken(wshrpc.RpcContext{BlockId: uuid.New().String()}, "sock")
+	if err != nil {
+		t.Fatalf("error making jwt token: %v", err)
+	}
+	authBytes, _ := json.Marshal(RpcMessage{Command: wshrpc.Command_Authenticate, ReqId: "req-1", Data: jwtToken})
+	proxy.FromRemoteCh <- authBytes
+	var errMsg RpcMessage
+	json.Unmarshal(<-proxy.ToRemoteCh, &errMsg)
+	if errMsg.ResId != "req-1" || !strings.Contains(errMsg.Error, "required") {
+		t.Fatalf("expected unsigned authenticate to be refused, got %#v", errMsg)
+	}
+
+	clientSigner, err := MakeClientRpcFrameSigner(MakeRpcFrameSignKey(jwtToken))
+	if err != nil {
+		t.Fatalf("error making signer: %v", err)
+	}
+	authBytes, _ = json.Marshal(RpcMessage{Command: wshrpc.Command_Authenticate, ReqId: "req-2", Data: jwtToken})
+	authBytes, _ = clientSigner.SignFrame(authBytes)
+	proxy.FromRemoteCh <- authBytes
+	respBytes, err := clientSigner.VerifyFrame(<-proxy.ToRemoteCh)
+	if err != nil {
+		t.Fatalf("expected a signed authenticate response, got %v", err)
+	}
+	var respMsg RpcMessage
+	json.Unmarshal(respBytes, &respMsg)
+	if respMsg.ResId != "req-2" || respMsg.Error != "" {
+		t.Fatalf("expected signed authenticate to succeed, got %#v", respMsg)
+	}
+}
+
+func TestRpcProxyRejectsBadFrames(t *testing.T) {
+	signKey := MakeRpcFrameSignKey("test-token")
+	clientSigner := makeTestSigner(t, signKey, "nonce", true)
+	proxy := MakeRpcProxy()
+	proxy.SetFrameSigner(makeTestSigner(t, signKey, "nonce", false))
+	good := signTestFrame(t, clientSigner, RpcMessage{Command: "getmeta", ReqId: "req-1", Source: "src"})
+	proxy.FromRemoteCh <- good
+	proxy.FromRemoteCh <- good
+	proxy.FromRemoteCh <- signTestFrame(t, clientSigner, RpcMessage{Command: "getmeta", ReqId: "req-2", Source: "src"})
+	first, _ := proxy.RecvRpcMessage()
+	second, _ := proxy.RecvRpcMessage()
+	var firstMsg, secondMsg RpcMessage
+	json.Unmarshal(first, &firstMsg)
+	json.Unmarshal(second, &secondMsg)
+	if firstMsg.ReqId != "req-1" || secondMsg.ReqId != "req-2" {
+		t.Fatalf("expected the replayed frame to be skipped, got %q then %q", firstMsg.ReqId, secondMsg.ReqId)
+	}
+	errBytes, err := clientSigner.VerifyFrame(<-proxy.ToRemoteCh)
+	if err != nil {
+		t.Fatalf("expected the error response to be signed, got %v", err)
+	}
+	var errMsg RpcMessage
+	json.Unmarshal(errBytes, &errMsg)
+	if errMsg.ResId != "req-1" || !strings.Contains(errMsg.Error, "replayed") {
+		t.Errorf("expected an error response for the replayed frame, got %#v", errMsg)
+	}
+}
+
+func TestMultiProxyRequireSigning(t *testing.T) {
+	SetRequireFrameSigning(true)
+	defer SetRequireFrameSigning(false)
+	proxy := MakeRpcMultiProxy()
+	defer proxy.DisposeRoutes()
+	jwtToken, err := MakeClientJWTToken(wshrpc.RpcContext{BlockId: uuid.New().String(), Conn: "user@host"}, "sock")
+	if err != nil {
+		t.Fatalf("error making jwt token: %v", err)
+	}
+	authBytes, _ := json.Marshal(RpcMessage{Command: wshrpc.Command_Authenticate, ReqId: "req-1", Data: jwtToken})
+	proxy.handleUnauthMessage(authBytes)
+	var errMsg RpcMessage
+	json.Unmarshal(<-proxy.ToRemoteCh, &errMsg)
+	if errMsg.ResId != "req-1" || !strings.Contains(errMsg.Error, "required") {
+		t.Fatalf("expected a proxied client without a nonce to be refused, got %#v", errMsg)
+	}
+
+	// the connserver passes the client's nonce along and gets the client's sign key back
+	authBytes, _ = json.Marshal(RpcMessage{Command: wshrpc.Command_Authenticate, ReqId: "req-2", Data: jwtToken, SignNonce: "nonce"})
+	proxy.handleUnauthMessage(authBytes)
+	var respMsg RpcMessage
+	json.Unmarshal(<-proxy.ToRemoteCh, &respMsg)
+	respData, _ := respMsg.Data.(map[string]any)
+	if respMsg.ResId != "req-2" || respMsg.Error != "" || respData["signkey"] != MakeRpcFrameSignKey(jwtToken) {
+		t.Fatalf("expected the sign key in the authenticate response, got %#v", respMsg)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+type streamStartParamsContextKey struct{}
+
+// parameters reported by a streaming handler for its start ack
+type streamStartParams struct {
+	lock   sync.Mutex
+	params map[string]any
+}
+
+func withStreamStartParams(ctx context.Context) (context.Context, *streamStartParams) {
+	params := &streamStartParams{}
+	return context.WithValue(ctx, streamStartParamsContextKey{}, params), params
+}
+
+// records a negotiated parameter for the stream start ack (see wshrpc.StreamStartParam_ keys)
+// must be called before the handler returns its channel, otherwise the ack has already been sent
+func SetStreamStartParam(ctx context.Context, key string, val any) {
+	params, ok := ctx.Value(streamStartParamsContextKey{}).(*streamStartParams)
+	if !ok || params == nil {
+		return
+	}
+	params.lock.Lock()
+	defer params.lock.Unlock()
+	if params.params == nil {
+		params.params = make(map[string]any)
+	}
+	params.params[key] = val
+}
+
+func (p *streamStartParams) getStartData() wshrpc.StreamStartData {
+	var rtn wshrpc.StreamStartData
+	if p == nil {
+		return rtn
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.params) > 0 {
+		rtn.Params = make(map[string]any, len(p.params))
+		for key, val := range p.params {
+			rtn.Params[key] = val
+		}
+	}
+	return rtn
+}
+
+func (handler *RpcResponseHandler) sendStreamStart() {
+	defer func() {
+		panichandler.PanicHandler("sendStreamStart", recover())
+	}()
+	if handler.reqId == "" || !handler.wantStreamStart || handler.done.Load() {
+		return
+	}
+	msg := &RpcMessage{
+		ResId:       handler.reqId,
+		Data:        handler.startParams.getStartData(),
+		Cont:        true,
+		StreamStart: true,
+		AuthToken:   handler.w.GetAuthToken(),
+	}
+	barr, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("wshrpc error marshaling stream start for %q: %v\n", handler.command, err)
+		return
+	}
+	handler.w.OutputCh <- barr
+}
+
+func (handler *RpcRequestHandler) handleStreamStart(resp *RpcMessage) {
+	if handler.onStreamStart == nil {
+		return
+	}
+	var startData wshrpc.StreamStartData
+	err := utilfn.ReUnmarshal(&startData, resp.Data)
+	if err != nil {
+		log.Printf("wshrpc invalid stream start data: %v\n", err)
+		return
+	}
+	handler.onStreamStart(startData)
+}
@@ -0,0 +1,96 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+)
+
+// routes that die without unsubscribing (e.g. a crashed block) leave their subscriptions behind.  when
+// an idle timeout is set (debug:subidletimeoutsecs in wavesrv), routes with no activity within the timeout
+// (and no subscription newer than it) are unsubscribed.  activity is a message from the route, a response
+// delivered to it, or a heartbeat on its link (see RouteHeartbeat, the frontend's websocket pings), so an
+// idle tab stays subscribed.  announced routes are tracked on their own, not by their link's traffic.
+// 0 disables the reaper.
+
+const SubReapInterval = time.Minute
+
+var subIdleTimeout = &atomic.Int64{}
+
+func SetSubIdleTimeout(timeout time.Duration) {
+	subIdleTimeout.Store(int64(max(timeout, 0)))
+}
+
+func GetSubIdleTimeout() time.Duration {
+	return time.Duration(subIdleTimeout.Load())
+}
+
+// wavesrv's own route never goes idle (its messages don't pass through the router's recv loop)
+func isReapExemptRoute(routeId string) bool {
+	return routeId == DefaultRoute
+}
+
+// returns the subscribed routes with no activity (messages or new subscriptions) since cutoffTs
+func (router *WshRouter) findIdleSubRoutes(cutoffTs int64) []string {
+	lastSeen := make(map[string]int64)
+	var routeIds []string
+	for _, subInfo := range wps.Broker.ListAllSubscriptions() {
+		if _, found := lastSeen[subInfo.RouteId]; !found {
+			routeIds = append(routeIds, subInfo.RouteId)
+		}
+		lastSeen[subInfo.RouteId] = max(lastSeen[subInfo.RouteId], subInfo.SubTs)
+	}
+	var rtn []string
+	for _, routeId := range routeIds {
+		if isReapExemptRoute(routeId) {
+			continue
+		}
+		if max(lastSeen[routeId], router.getRouteLastActivity(routeId)) < cutoffTs {
+			rtn = append(rtn, routeId)
+		}
+	}
+	return rtn
+}
+
+// unsubscribes routes that have been idle for longer than idleTimeout, returns the reaped routes
+func (router *WshRouter) ReapIdleSubscriptions(idleTimeout time.Duration) []string {
+	if idleTimeout <= 0 {
+		return nil
+	}
+	cutoffTs := time.Now().Add(-idleTimeout).UnixMilli()
+	routeIds := router.findIdleSubRoutes(cutoffTs)
+	for _, routeId := range routeIds {
+		subs := wps.Broker.ListSubscriptions(routeId)
+		events := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			events = append(events, sub.Event)
+		}
+		log.Printf("[router] route %q idle for more than %v, removing %d subscription(s) %v\n", routeId, idleTimeout, len(subs), events)
+		wps.Broker.UnsubscribeAll(routeId)
+	}
+	return routeIds
+}
+
+// runs until ctx is done, the idle timeout is re-read every interval (SetConfigCommand updates it at runtime)
+func (router *WshRouter) RunSubReaper(ctx context.Context) {
+	defer func() {
+		panichandler.PanicHandler("WshRouter:RunSubReaper", recover())
+	}()
+	ticker := time.NewTicker(SubReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			router.ReapIdleSubscriptions(GetSubIdleTimeout())
+		}
+	}
+}
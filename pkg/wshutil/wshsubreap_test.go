@@ -0,0 +1,95 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// never sends anything (RecvRpcMessage blocks until doneCh is closed)
+type silentRpcClient struct {
+	doneCh chan struct{}
+}
+
+func (c *silentRpcClient) SendRpcMessage(msg []byte) {}
+
+func (c *silentRpcClient) RecvRpcMessage() ([]byte, bool) {
+	<-c.doneCh
+	return nil, false
+}
+
+func TestReapIdleSubscriptions(t *testing.T) {
+	router := NewWshRouter()
+	client := &silentRpcClient{doneCh: make(chan struct{})}
+	defer close(client.doneCh)
+	silentRoute := "test:reap-silent"
+	activeRoute := "test:reap-active"
+	router.RegisterRoute(silentRoute, client, false)
+	router.RegisterRoute(activeRoute, client, false)
+	defer wps.Broker.UnsubscribeAll(silentRoute)
+	defer wps.Broker.UnsubscribeAll(activeRoute)
+	wps.Broker.Subscribe(silentRoute, wps.SubscriptionRequest{Event: wps.Event_BlockFile, AllScopes: true})
+	wps.Broker.Subscribe(silentRoute, wps.SubscriptionRequest{Event: wps.Event_RouteGone, AllScopes: true})
+	wps.Broker.Subscribe(activeRoute, wps.SubscriptionRequest{Event: wps.Event_BlockFile, AllScopes: true})
+
+	idleTimeout := 50 * time.Millisecond
+	if reaped := router.ReapIdleSubscriptions(idleTimeout); len(reaped) != 0 {
+		t.Fatalf("fresh subscriptions should not be reaped, got %v", reaped)
+	}
+	time.Sleep(2 * idleTimeout)
+	router.setRouteActivity(activeRoute)
+	reaped := router.ReapIdleSubscriptions(idleTimeout)
+	if !slices.Contains(reaped, silentRoute) || slices.Contains(reaped, activeRoute) {
+		t.Fatalf("expected only %q to be reaped, got %v", silentRoute, reaped)
+	}
+	if subs := wps.Broker.ListSubscriptions(silentRoute); len(subs) != 0 {
+		t.Errorf("silent route still has %d subscription(s)", len(subs))
+	}
+	if subs := wps.Broker.ListSubscriptions(activeRoute); len(subs) != 1 {
+		t.Errorf("active route should keep its subscription, got %d", len(subs))
+	}
+	if reaped := router.ReapIdleSubscriptions(0); reaped != nil {
+		t.Errorf("a zero timeout should disable reaping, got %v", reaped)
+	}
+}
+
+func TestReapAnnouncedRoutes(t *testing.T) {
+	router := NewWshRouter()
+	client := &silentRpcClient{doneCh: make(chan struct{})}
+	defer close(client.doneCh)
+	busyLink := "test:reap-busylink"
+	idleLink := "test:reap-idlelink"
+	deadRoute := "test:reap-dead"
+	idleRoute := "test:reap-idle"
+	router.RegisterRoute(busyLink, client, false)
+	router.RegisterRoute(idleLink, client, false)
+	router.handleAnnounceMessage(RpcMessage{Command: wshrpc.Command_RouteAnnounce, Source: deadRoute}, msgAndRoute{fromRouteId: busyLink})
+	router.handleAnnounceMessage(RpcMessage{Command: wshrpc.Command_RouteAnnounce, Source: idleRoute}, msgAndRoute{fromRouteId: idleLink})
+	for _, routeId := range []string{deadRoute, idleRoute} {
+		defer wps.Broker.UnsubscribeAll(routeId)
+		wps.Broker.Subscribe(routeId, wps.SubscriptionRequest{Event: wps.Event_BlockFile, AllScopes: true})
+	}
+
+	idleTimeout := 50 * time.Millisecond
+	time.Sleep(2 * idleTimeout)
+	// traffic from other routes on the link doesn't count for the announced route
+	router.setLinkMessageActivity(busyLink, busyLink)
+	// a heartbeat on the link counts for every route announced through it
+	router.RouteHeartbeat(idleLink)
+	reaped := router.ReapIdleSubscriptions(idleTimeout)
+	if !slices.Contains(reaped, deadRoute) || slices.Contains(reaped, idleRoute) {
+		t.Fatalf("expected only %q to be reaped, got %v", deadRoute, reaped)
+	}
+
+	time.Sleep(2 * idleTimeout)
+	router.setLinkMessageActivity(busyLink, idleRoute) // not announced through busyLink
+	if reaped := router.ReapIdleSubscriptions(idleTimeout); !slices.Contains(reaped, idleRoute) {
+		t.Errorf("expected %q to be reaped without its own activity, got %v", idleRoute, reaped)
+	}
+}
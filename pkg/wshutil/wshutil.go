@@ -73,8 +73,7 @@ func EncodeWaveOSCBytes(oscNum string, barr []byte) ([]byte, error) {
 	if len(oscNum) != 5 {
 		return nil, fmt.Errorf("oscNum must be 5 characters")
 	}
-	const maxSize = 64 * 1024 * 1024 // 64 MB
-	if len(barr) > maxSize {
+	if len(barr) > MaxRpcMessageSize {
 		return nil, fmt.Errorf("input data too large")
 	}
 	hasControlChars := false
@@ -233,15 +232,23 @@ func SetupPacketRpcClient(input io.Reader, output io.Writer, serverImpl ServerIm
 	return rpcClient, rawCh
 }
 
-func SetupConnRpcClient(conn net.Conn, serverImpl ServerImpl) (*WshRpc, chan error, error) {
+// signer is optional, when set every outgoing frame is signed and incoming frames must verify (see RpcFrameSigner)
+func SetupConnRpcClient(conn net.Conn, serverImpl ServerImpl, signer *RpcFrameSigner) (*WshRpc, chan error, error) {
 	inputCh := make(chan []byte, DefaultInputChSize)
 	outputCh := make(chan []byte, DefaultOutputChSize)
 	writeErrCh := make(chan error, 1)
+	writeCh := outputCh
+	readCh := inputCh
+	if signer != nil {
+		writeCh = signer.signOutputCh(outputCh)
+		readCh = make(chan []byte, DefaultInputChSize)
+		go signer.verifyInputCh(readCh, inputCh)
+	}
 	go func() {
 		defer func() {
 			panichandler.PanicHandler("SetupConnRpcClient:AdaptOutputChToStream", recover())
 		}()
-		writeErr := AdaptOutputChToStream(outputCh, conn)
+		writeErr := AdaptOutputChToStream(writeCh, conn)
 		if writeErr != nil {
 			writeErrCh <- writeErr
 			close(writeErrCh)
@@ -253,7 +260,7 @@ func SetupConnRpcClient(conn net.Conn, serverImpl ServerImpl) (*WshRpc, chan err
 		}()
 		// when input is closed, close the connection
 		defer conn.Close()
-		AdaptStreamToMsgCh(conn, inputCh)
+		AdaptStreamToMsgCh(conn, readCh)
 	}()
 	rtn := MakeWshRpc(inputCh, outputCh, wshrpc.RpcContext{}, serverImpl)
 	return rtn, writeErrCh, nil
@@ -267,16 +274,25 @@ func tryTcpSocket(sockName string) (net.Conn, error) {
 	return net.DialTCP("tcp", nil, addr)
 }
 
-func SetupDomainSocketRpcClient(sockName string, serverImpl ServerImpl) (*WshRpc, error) {
+// sockName can be a tcp address or a unix domain socket path
+func DialDomainSocket(sockName string) (net.Conn, error) {
 	conn, tcpErr := tryTcpSocket(sockName)
-	var unixErr error
-	if tcpErr != nil {
-		conn, unixErr = net.Dial("unix", sockName)
+	if tcpErr == nil {
+		return conn, nil
 	}
-	if tcpErr != nil && unixErr != nil {
+	conn, unixErr := net.Dial("unix", sockName)
+	if unixErr != nil {
 		return nil, fmt.Errorf("failed to connect to tcp or unix domain socket: tcp err:%w: unix socket err: %w", tcpErr, unixErr)
 	}
-	rtn, errCh, err := SetupConnRpcClient(conn, serverImpl)
+	return conn, nil
+}
+
+func SetupDomainSocketRpcClient(sockName string, serverImpl ServerImpl, signer *RpcFrameSigner) (*WshRpc, error) {
+	conn, err := DialDomainSocket(sockName)
+	if err != nil {
+		return nil, err
+	}
+	rtn, errCh, err := SetupConnRpcClient(conn, serverImpl, signer)
 	go func() {
 		defer func() {
 			panichandler.PanicHandler("SetupDomainSocketRpcClient:closeConn", recover())
@@ -0,0 +1,76 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wsl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+)
+
+func (conn *WslConn) GetEnvCache() *envutil.ConnEnvCache {
+	conn.Lock.Lock()
+	defer conn.Lock.Unlock()
+	return conn.EnvCache
+}
+
+func resolveConnEnvCache(ctx context.Context, client *Distro) (*envutil.ConnEnvCache, error) {
+	envOut, err := client.WslCommand(ctx, envutil.PrintEnvCmd).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting env: %w", err)
+	}
+	env := envutil.ParseEnvOutput(string(envOut))
+	homeDir := env["HOME"]
+	if homeDir == "" {
+		homeDir = GetHomeDir(ctx, client)
+	}
+	clientOs, err := GetClientOs(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	clientArch, err := GetClientArch(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	shellPath, err := DetectShell(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("error detecting shell: %w", err)
+	}
+	return &envutil.ConnEnvCache{
+		ShellPath:  shellPath,
+		HomeDir:    homeDir,
+		ClientOs:   clientOs,
+		ClientArch: clientArch,
+		Env:        env,
+	}, nil
+}
+
+// eagerly resolves (and caches) the env, home dir, shell, and os of a connected distro
+func (conn *WslConn) Warmup(ctx context.Context) error {
+	if conn.GetStatus() != Status_Connected {
+		return fmt.Errorf("cannot warm up %q when status is %q", conn.GetName(), conn.GetStatus())
+	}
+	return envutil.WarmupEnvCache(ctx, conn, resolveConnEnvCache)
+}
+
+// a close/reconnect while resolving invalidates the result
+func (conn *WslConn) StoreEnvCache(client *Distro, envCache *envutil.ConnEnvCache) bool {
+	conn.Lock.Lock()
+	defer conn.Lock.Unlock()
+	if conn.Status != Status_Connected || conn.Client != client || conn.EnvCache != nil {
+		return false
+	}
+	conn.EnvCache = envCache
+	return true
+}
+
+// connects if needed, then warms up the connection
+func WarmupConnection(ctx context.Context, connName string) error {
+	err := EnsureConnection(ctx, connName)
+	if err != nil {
+		return err
+	}
+	return GetWslConn(ctx, connName, false).Warmup(ctx)
+}
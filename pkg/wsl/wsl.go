@@ -16,6 +16,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
 	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
@@ -51,6 +52,7 @@ type WslConn struct {
 	HasWaiter          *atomic.Bool
 	LastConnectTime    int64
 	ActiveConnNum      int
+	EnvCache           *envutil.ConnEnvCache
 	cancelFn           func()
 }
 
@@ -93,18 +95,13 @@ func (conn *WslConn) DeriveConnStatus() wshrpc.ConnStatus {
 		HasConnected:  (conn.LastConnectTime > 0),
 		ActiveConnNum: conn.ActiveConnNum,
 		Error:         conn.Error,
+		Ready:         conn.EnvCache != nil,
 	}
 }
 
 func (conn *WslConn) FireConnChangeEvent() {
 	status := conn.DeriveConnStatus()
-	event := wps.WaveEvent{
-		Event: wps.Event_ConnChange,
-		Scopes: []string{
-			fmt.Sprintf("connection:%s", conn.GetName()),
-		},
-		Data: status,
-	}
+	event := wps.MakeConnChangeEvent(conn.GetName(), status)
 	log.Printf("sending event: %+#v", event)
 	wps.Broker.Publish(event)
 }
@@ -131,6 +128,7 @@ func (conn *WslConn) Close() error {
 
 func (conn *WslConn) close_nolock() {
 	// does not set status (that should happen at another level)
+	conn.EnvCache = nil
 	if conn.DomainSockListener != nil {
 		conn.DomainSockListener.Close()
 		conn.DomainSockListener = nil
@@ -216,10 +214,11 @@ func (conn *WslConn) StartConnServer() error {
 		return err
 	}
 	var cmdStr string
+	connServerArgs := wconfig.GetConnServerArgs(conn.GetName())
 	if IsPowershell(shellPath) {
-		cmdStr = fmt.Sprintf("$env:%s=\"%s\"; %s connserver --router", wshutil.WaveJwtTokenVarName, jwtToken, wshPath)
+		cmdStr = fmt.Sprintf("$env:%s=\"%s\"; %s connserver --router%s", wshutil.WaveJwtTokenVarName, jwtToken, wshPath, connServerArgs)
 	} else {
-		cmdStr = fmt.Sprintf("%s=\"%s\" %s connserver --router", wshutil.WaveJwtTokenVarName, jwtToken, wshPath)
+		cmdStr = fmt.Sprintf("%s=\"%s\" %s connserver --router%s", wshutil.WaveJwtTokenVarName, jwtToken, wshPath, connServerArgs)
 	}
 	log.Printf("starting conn controller: %s\n", cmdStr)
 	connServerCtx, cancelFn := context.WithCancel(context.Background())
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/wavetermdev/waveterm/pkg/ijson"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 )
@@ -32,6 +33,11 @@ func UpdateTabName(ctx context.Context, tabId, name string) error {
 }
 
 func UpdateObjectMeta(ctx context.Context, oref waveobj.ORef, meta waveobj.MetaMapType, mergeSpecial bool) error {
+	return UpdateObjectMetaIfVersion(ctx, oref, meta, mergeSpecial, 0)
+}
+
+// like UpdateObjectMeta, but a non-zero version makes the update conditional on the object still being at that version
+func UpdateObjectMetaIfVersion(ctx context.Context, oref waveobj.ORef, meta waveobj.MetaMapType, mergeSpecial bool, version int) error {
 	return WithTx(ctx, func(tx *TxWrap) error {
 		if oref.IsEmpty() {
 			return fmt.Errorf("empty object reference")
@@ -40,6 +46,9 @@ func UpdateObjectMeta(ctx context.Context, oref waveobj.ORef, meta waveobj.MetaM
 		if obj == nil {
 			return ErrNotFound
 		}
+		if err := checkObjVersion(obj, version); err != nil {
+			return err
+		}
 		objMeta := waveobj.GetMeta(obj)
 		if objMeta == nil {
 			objMeta = make(map[string]any)
@@ -51,6 +60,52 @@ func UpdateObjectMeta(ctx context.Context, oref waveobj.ORef, meta waveobj.MetaM
 	})
 }
 
+// version is the object version the caller based its edit on (0 skips the check)
+func checkObjVersion(obj waveobj.WaveObj, version int) error {
+	if version == 0 {
+		return nil
+	}
+	if curVersion := waveobj.GetVersion(obj); curVersion != version {
+		return fmt.Errorf("%w: expected version %d, object is at version %d", ErrVersionMismatch, version, curVersion)
+	}
+	return nil
+}
+
+// applies an ijson command (set/del/append) to the object's meta, for deep edits that MergeMeta can't express.
+// a non-zero version makes the patch conditional on the object still being at that version (ErrVersionMismatch otherwise).
+func PatchObjectMeta(ctx context.Context, oref waveobj.ORef, command ijson.Command, version int) error {
+	if _, err := ijson.ValidateAndMarshalCommand(command); err != nil {
+		return fmt.Errorf("invalid meta patch: %w", err)
+	}
+	return WithTx(ctx, func(tx *TxWrap) error {
+		if oref.IsEmpty() {
+			return fmt.Errorf("empty object reference")
+		}
+		obj, _ := DBGetORef(tx.Context(), oref)
+		if obj == nil {
+			return ErrNotFound
+		}
+		if err := checkObjVersion(obj, version); err != nil {
+			return err
+		}
+		objMeta := waveobj.GetMeta(obj)
+		if objMeta == nil {
+			objMeta = make(map[string]any)
+		}
+		newData, err := ijson.ApplyCommand(map[string]any(objMeta), command, 0)
+		if err != nil {
+			return fmt.Errorf("error applying meta patch: %w", err)
+		}
+		newMeta, ok := newData.(map[string]any)
+		if !ok {
+			return fmt.Errorf("meta patch must result in an object, got %T", newData)
+		}
+		waveobj.SetMeta(obj, newMeta)
+		DBUpdate(tx.Context(), obj)
+		return nil
+	})
+}
+
 func MoveBlockToTab(ctx context.Context, currentTabId string, newTabId string, blockId string) error {
 	return WithTx(ctx, func(tx *TxWrap) error {
 		block, _ := DBGet[*waveobj.Block](tx.Context(), blockId)
@@ -18,6 +18,7 @@ import (
 )
 
 var ErrNotFound = fmt.Errorf("not found")
+var ErrVersionMismatch = fmt.Errorf("version mismatch")
 
 func waveObjTableName(w waveobj.WaveObj) string {
 	return "db_" + w.GetOType()